@@ -125,14 +125,22 @@ func (b *batch) age() time.Duration {
 // the encoded bytes and the number of encoded entries
 func (b *batch) encode() ([]byte, int, error) {
 	req, entriesCount := b.createPushRequest()
-	buf, err := proto.Marshal(req)
+	buf, err := encodePushRequest(req)
 	if err != nil {
 		return nil, 0, err
 	}
-	buf = snappy.Encode(nil, buf)
 	return buf, entriesCount, nil
 }
 
+// encodePushRequest encodes the push request as snappy-compressed protobuf.
+func encodePushRequest(req *logproto.PushRequest) ([]byte, error) {
+	buf, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, buf), nil
+}
+
 // creates push request and returns it, together with number of entries
 func (b *batch) createPushRequest() (*logproto.PushRequest, int) {
 	req := logproto.PushRequest{
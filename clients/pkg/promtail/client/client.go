@@ -22,6 +22,8 @@ import (
 
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
 	lokiutil "github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/build"
 )
@@ -30,6 +32,9 @@ const (
 	contentType  = "application/x-protobuf"
 	maxErrMsgLen = 1024
 
+	// partialSuccessHeader asks Loki to list the rejected entries in the push response instead of failing the request.
+	partialSuccessHeader = "X-Loki-Partial-Success"
+
 	// Label reserved to override the tenant ID while processing
 	// pipeline stages
 	ReservedLabelTenantID = "__tenant_id__"
@@ -48,6 +53,14 @@ const (
 
 var Reasons = []string{ReasonGeneric, ReasonRateLimited, ReasonStreamLimited, ReasonLineTooLong}
 
+// retryableReasons are the reasons of the rejections of entries by Loki for which the entries are retried.
+// The entries rejected for other reasons are dropped, counted with the reason of their rejection.
+var retryableReasons = map[string]bool{
+	push.ReasonRateLimited:     true,
+	push.ReasonStreamRateLimit: true,
+	push.ReasonStreamLimit:     true,
+}
+
 var UserAgent = fmt.Sprintf("promtail/%s", build.Version)
 
 type Metrics struct {
@@ -379,7 +392,8 @@ func batchIsRateLimited(status int) bool {
 }
 
 func (c *client) sendBatch(tenantID string, batch *batch) {
-	buf, entriesCount, err := batch.encode()
+	req, entriesCount := batch.createPushRequest()
+	buf, err := encodePushRequest(req)
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
 		return
@@ -388,11 +402,14 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 
 	backoff := backoff.New(c.ctx, c.cfg.BackoffConfig)
-	var status int
+	var (
+		status int
+		resp   *logproto.PushResponse
+	)
 	for {
 		start := time.Now()
 		// send uses `timeout` internally, so `context.Background` is good enough.
-		status, err = c.send(context.Background(), tenantID, buf)
+		status, resp, err = c.send(context.Background(), tenantID, buf)
 
 		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
 
@@ -404,13 +421,45 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 			return
 		}
 
-		if err == nil {
+		if err == nil && (resp == nil || len(resp.RejectedStreams) == 0) {
 			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
 
 			return
 		}
 
+		// Only retry the entries rejected for a retryable reason, and drop the others.
+		if err == nil {
+			var retried, dropped []rejectedEntry
+			req, retried, dropped = splitRejectedEntries(req, resp)
+			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount - len(retried) - len(dropped)))
+			c.dropEntries(tenantID, dropped)
+			if len(retried) == 0 {
+				return
+			}
+
+			level.Warn(c.logger).Log("msg", "entries rejected, will retry", "tenant", tenantID, "retried", len(retried), "dropped", len(dropped))
+			c.metrics.batchRetries.WithLabelValues(c.cfg.URL.Host, tenantID).Inc()
+			backoff.Wait()
+			if !backoff.Ongoing() {
+				level.Error(c.logger).Log("msg", "final error sending rejected entries", "tenant", tenantID, "entries", len(retried))
+				c.dropEntries(tenantID, retried)
+				return
+			}
+
+			buf, err = encodePushRequest(req)
+			if err != nil {
+				level.Error(c.logger).Log("msg", "error encoding rejected entries", "error", err)
+				c.dropEntries(tenantID, retried)
+				return
+			}
+			bufBytes = float64(len(buf))
+			entriesCount = len(retried)
+			c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			continue
+		}
+
 		// Only retry 429s, 500s and connection-level errors.
 		if status > 0 && !batchIsRateLimited(status) && status/100 != 5 {
 			break
@@ -439,16 +488,63 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	}
 }
 
-func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, error) {
+// dropEntries counts the rejected entries as dropped, with the reason of their rejection.
+func (c *client) dropEntries(tenantID string, entries []rejectedEntry) {
+	for _, e := range entries {
+		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, e.reason).Add(float64(len(e.entry.Line)))
+		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, e.reason).Inc()
+	}
+}
+
+// rejectedEntry is an entry of a push request rejected by Loki.
+type rejectedEntry struct {
+	entry  logproto.Entry
+	reason string
+}
+
+// splitRejectedEntries returns a push request with the entries of the push request rejected for a retryable reason,
+// and the retried and dropped rejected entries.
+func splitRejectedEntries(req *logproto.PushRequest, resp *logproto.PushResponse) (*logproto.PushRequest, []rejectedEntry, []rejectedEntry) {
+	var (
+		retry            = &logproto.PushRequest{}
+		retried, dropped []rejectedEntry
+	)
+	for _, rejectedStream := range resp.RejectedStreams {
+		if int(rejectedStream.Index) >= len(req.Streams) {
+			continue
+		}
+		stream := req.Streams[rejectedStream.Index]
+		retryStream := logproto.Stream{Labels: stream.Labels}
+		for _, rejected := range rejectedStream.Entries {
+			if int(rejected.Index) >= len(stream.Entries) {
+				continue
+			}
+			entry := stream.Entries[rejected.Index]
+			if !retryableReasons[rejected.Reason] {
+				dropped = append(dropped, rejectedEntry{entry: entry, reason: rejected.Reason})
+				continue
+			}
+			retried = append(retried, rejectedEntry{entry: entry, reason: rejected.Reason})
+			retryStream.Entries = append(retryStream.Entries, entry)
+		}
+		if len(retryStream.Entries) > 0 {
+			retry.Streams = append(retry.Streams, retryStream)
+		}
+	}
+	return retry, retried, dropped
+}
+
+func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, *logproto.PushResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
 	defer cancel()
 	req, err := http.NewRequest("POST", c.cfg.URL.String(), bytes.NewReader(buf))
 	if err != nil {
-		return -1, err
+		return -1, nil, err
 	}
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set(partialSuccessHeader, "true")
 
 	// If the tenant ID is not empty promtail is running in multi-tenant mode, so
 	// we should send it to Loki
@@ -469,7 +565,7 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return -1, err
+		return -1, nil, err
 	}
 	defer lokiutil.LogError("closing response body", resp.Body.Close)
 
@@ -480,8 +576,26 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 			line = scanner.Text()
 		}
 		err = fmt.Errorf("server returned HTTP status %s (%d): %s", resp.Status, resp.StatusCode, line)
+		return resp.StatusCode, nil, err
+	}
+
+	// The servers accepting partial success list the rejected entries in the response.
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != contentType {
+		return resp.StatusCode, nil, nil
+	}
+	// The entries were accepted, so a response which can't be decoded doesn't fail the request: retrying it would
+	// duplicate the entries.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "error reading push response, the entries are considered sent", "tenant", tenantID, "error", err)
+		return resp.StatusCode, nil, nil
+	}
+	var pushResp logproto.PushResponse
+	if err := pushResp.Unmarshal(body); err != nil {
+		level.Warn(c.logger).Log("msg", "error decoding push response, the entries are considered sent", "tenant", tenantID, "error", err)
+		return resp.StatusCode, nil, nil
 	}
-	return resp.StatusCode, err
+	return resp.StatusCode, &pushResp, nil
 }
 
 func (c *client) getTenantID(labels model.LabelSet) string {
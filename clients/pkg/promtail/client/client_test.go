@@ -3,7 +3,9 @@ package client
 import (
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -23,6 +25,8 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/utils"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/pkg/util"
 	lokiflag "github.com/grafana/loki/pkg/util/flagext"
 )
 
@@ -558,6 +562,112 @@ func TestClient_Handle(t *testing.T) {
 	}
 }
 
+func TestClient_PartialSuccess(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	// The server rejects the first entry as out of order and the second one as rate limited the first time.
+	var received []logproto.PushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "true", req.Header.Get(partialSuccessHeader))
+		var pushReq logproto.PushRequest
+		require.NoError(t, util.ParseProtoReader(req.Context(), req.Body, int(req.ContentLength), math.MaxInt32, &pushReq, util.RawSnappy))
+		received = append(received, pushReq)
+		if len(received) > 1 {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		buf, err := (&logproto.PushResponse{RejectedStreams: []logproto.RejectedStream{{Labels: "{}", Entries: []logproto.RejectedEntry{
+			{Index: 0, Reason: "out_of_order"},
+			{Index: 1, Reason: push.ReasonStreamRateLimit},
+		}}}}).Marshal()
+		require.NoError(t, err)
+		rw.Header().Set("Content-Type", contentType)
+		_, err = rw.Write(buf)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+	cfg := Config{
+		URL:            serverURL,
+		BatchWait:      100 * time.Millisecond,
+		BatchSize:      100,
+		Client:         config.HTTPClientConfig{},
+		BackoffConfig:  backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 3},
+		ExternalLabels: lokiflag.LabelSet{},
+		Timeout:        1 * time.Second,
+	}
+	c, err := New(NewMetrics(reg), cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	for _, e := range logEntries[:3] {
+		c.Chan() <- e
+	}
+	c.Stop()
+
+	require.Equal(t, []logproto.PushRequest{
+		{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry, logEntries[1].Entry, logEntries[2].Entry}}}},
+		{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[1].Entry}}}},
+	}, received)
+
+	expectedMetrics := strings.Replace(`
+		# HELP promtail_sent_entries_total Number of log entries sent to the ingester.
+		# TYPE promtail_sent_entries_total counter
+		promtail_sent_entries_total{host="__HOST__"} 2.0
+		# HELP promtail_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+		# TYPE promtail_dropped_entries_total counter
+		promtail_dropped_entries_total{host="__HOST__",reason="ingester_error",tenant=""} 0
+		promtail_dropped_entries_total{host="__HOST__",reason="line_too_long",tenant=""} 0
+		promtail_dropped_entries_total{host="__HOST__",reason="out_of_order",tenant=""} 1
+		promtail_dropped_entries_total{host="__HOST__",reason="rate_limited",tenant=""} 0
+		promtail_dropped_entries_total{host="__HOST__",reason="stream_limited",tenant=""} 0
+	`, "__HOST__", serverURL.Host, -1)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "promtail_sent_entries_total", "promtail_dropped_entries_total"))
+}
+
+func TestClient_PartialSuccessUndecodableResponse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	// The server accepts the entries, but its response can't be decoded.
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.Header().Set("Content-Type", contentType)
+		_, err := rw.Write([]byte("not a push response"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+	cfg := Config{
+		URL:            serverURL,
+		BatchWait:      100 * time.Millisecond,
+		BatchSize:      100,
+		Client:         config.HTTPClientConfig{},
+		BackoffConfig:  backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 3},
+		ExternalLabels: lokiflag.LabelSet{},
+		Timeout:        1 * time.Second,
+	}
+	c, err := New(NewMetrics(reg), cfg, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+
+	for _, e := range logEntries[:3] {
+		c.Chan() <- e
+	}
+	c.Stop()
+
+	// The entries are not retried, as they would be duplicated.
+	require.Equal(t, 1, requests)
+	expectedMetrics := strings.Replace(`
+		# HELP promtail_sent_entries_total Number of log entries sent to the ingester.
+		# TYPE promtail_sent_entries_total counter
+		promtail_sent_entries_total{host="__HOST__"} 3.0
+	`, "__HOST__", serverURL.Host, -1)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "promtail_sent_entries_total"))
+}
+
 func TestClient_StopNow(t *testing.T) {
 	cases := []struct {
 		name                 string
@@ -181,6 +181,31 @@ This is helpful to find high cardinality labels.
 	seriesQuery = newSeriesQuery(seriesCmd)
 
 	fmtCmd = app.Command("fmt", "Formats a LogQL query.")
+
+	explainCmd = app.Command("explain", `Explain how a LogQL query is executed.
+
+The "explain" command prints the plan of a query as it is split by
+interval and sharded by the query frontend, without executing it.
+The downstream nodes of the plan are the queries executed by the
+queriers, with their shards; all the other nodes are executed by
+the query frontend.
+
+Use the --analyze flag to execute the query and print the statistics
+of each node of the plan, such as the bytes and lines processed, the
+execution time and the cache hits.
+
+Use the --instant flag to explain an instant query at the time
+given by --now; otherwise a range query is explained, over the last
+hour by default.
+
+Example:
+
+	logcli explain
+	   --since=6h
+	   --analyze
+	   'sum by (app) (rate({app="foo"} |= "error" [1m]))'`)
+	explainAnalyze = explainCmd.Flag("analyze", "Execute the query and print the statistics of each node of the plan.").Default("false").Bool()
+	explainQuery   = newExplainQuery(explainCmd)
 )
 
 func main() {
@@ -288,6 +313,8 @@ func main() {
 		labelsQuery.DoLabels(queryClient)
 	case seriesCmd.FullCommand():
 		seriesQuery.DoSeries(queryClient)
+	case explainCmd.FullCommand():
+		explainQuery.DoExplain(queryClient, *explainAnalyze)
 	case fmtCmd.FullCommand():
 		if err := formatLogQL(os.Stdin, os.Stdout); err != nil {
 			log.Fatalf("unable to format logql: %s", err)
@@ -413,6 +440,7 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	// calculate query range from cli params
 	var now, from, to string
 	var since time.Duration
+	var before, after, around int
 
 	q := &query.Query{}
 
@@ -432,6 +460,14 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 				log.Println("parallel-max-workers must be greater than 0, defaulting to 1.")
 				q.ParallelMaxWorkers = 1
 			}
+
+			if before == 0 {
+				before = around
+			}
+			if after == 0 {
+				after = around
+			}
+			q.SetContext(before, after)
 		}
 		q.Quiet = *quiet
 
@@ -449,6 +485,9 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 		cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
 		cmd.Flag("step", "Query resolution step width, for metric queries. Evaluate the query at the specified step over the time range.").DurationVar(&q.Step)
 		cmd.Flag("interval", "Query interval, for log queries. Return entries at the specified interval, ignoring those between. **This parameter is experimental, please see Issue 1779**").DurationVar(&q.Interval)
+		cmd.Flag("before", "Number of log lines to print before each matching log line, from the same stream.").Short('B').Default("0").IntVar(&before)
+		cmd.Flag("after", "Number of log lines to print after each matching log line, from the same stream.").Short('A').Default("0").IntVar(&after)
+		cmd.Flag("context", "Number of log lines to print before and after each matching log line, from the same stream. Overridden by --before and --after.").Short('C').Default("0").IntVar(&around)
 		cmd.Flag("batch", "Query batch size to use until 'limit' is reached").Default("1000").IntVar(&q.BatchSize)
 		cmd.Flag("parallel-duration", "Split the range into jobs of this length to download the logs in parallel. This will result in the logs being out of order. Use --part-path-prefix to create a file per job to maintain ordering.").Default("1h").DurationVar(&q.ParallelDuration)
 		cmd.Flag("parallel-max-workers", "Max number of workers to start up for parallel jobs. A value of 1 will not create any parallel workers. When using parallel workers, limit is ignored.").Default("1").IntVar(&q.ParallelMaxWorkers)
@@ -470,6 +509,41 @@ func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	return q
 }
 
+func newExplainQuery(cmd *kingpin.CmdClause) *query.Query {
+	var now, from, to string
+	var since time.Duration
+	var instant bool
+
+	q := &query.Query{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+
+		if instant {
+			q.SetInstant(mustParse(now, time.Now()))
+		} else {
+			defaultEnd := time.Now()
+			defaultStart := defaultEnd.Add(-since)
+
+			q.Start = mustParse(from, defaultStart)
+			q.End = mustParse(to, defaultEnd)
+		}
+		q.Quiet = *quiet
+
+		return nil
+	})
+
+	cmd.Arg("query", "eg 'sum(rate({foo=\"bar\"} |~ \".*error.*\" [5m]))'").Required().StringVar(&q.QueryString)
+	cmd.Flag("instant", "Explain an instant query.").Default("false").BoolVar(&instant)
+	cmd.Flag("now", "Time at which to execute the instant query.").StringVar(&now)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+	cmd.Flag("step", "Query resolution step width, for metric queries. Evaluate the query at the specified step over the time range.").DurationVar(&q.Step)
+
+	return q
+}
+
 func mustParse(t string, defaultTime time.Time) time.Time {
 	if t == "" {
 		return defaultTime
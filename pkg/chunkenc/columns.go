@@ -0,0 +1,426 @@
+package chunkenc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logql/log"
+)
+
+const (
+	// maxBlockColumns is the maximum number of fields stored as columns in a block.
+	maxBlockColumns = 16
+	// maxBlockFields is the maximum number of field names recorded in a block.
+	// When the lines of a block have more fields, the columns can only be used by queries requiring some of them.
+	maxBlockFields = 256
+)
+
+// Parsers of the columns of a block.
+const (
+	columnsParserNone byte = iota
+	columnsParserJSON
+	columnsParserLogfmt
+)
+
+// Types of the values of a column.
+const (
+	columnTypeString byte = iota
+	columnTypeInt
+	columnTypeFloat
+)
+
+// Starting with chunkFormatV5, the entries of a block are preceded by the length prefixed columns section:
+//
+//	| parser (1b) | #rows (uvarint) | parsed rows bitmap |
+//	| complete (1b) | #fields (uvarint) | len prefixed field names |
+//	| #columns (uvarint) | column-1 | ... | column-n |
+//
+// where each column is:
+//
+//	| field index (uvarint) | type (1b) | len (uvarint) | present rows bitmap | values of the present rows |
+//
+// Strings are length prefixed, ints are varints and floats are 8 bytes. Columns are only decoded once they are read.
+// The section only holds the parser byte when no parser was detected for the lines.
+
+// writeBlockColumns writes the columns section of the lines of a block.
+// The lines are parsed with the json parser if most of them are json objects, with the logfmt parser otherwise,
+// and the fields found in at least half of the lines are stored as columns.
+func writeBlockColumns(buf *bytes.Buffer, lines []string) {
+	eb := EncodeBufferPool.Get().(*encbuf)
+	defer EncodeBufferPool.Put(eb)
+	eb.reset()
+
+	parser := detectColumnsParser(lines)
+	eb.putByte(parser)
+	if parser != columnsParserNone {
+		encodeBlockColumns(eb, parser, lines)
+	}
+
+	var encBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(encBuf[:], uint64(len(eb.get())))
+	buf.Write(encBuf[:n])
+	buf.Write(eb.get())
+}
+
+// writeColumnsTo writes the columns section of the lines of a block to the writer of the block.
+func writeColumnsTo(w io.Writer, lines []string) error {
+	buf := serializeBytesBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		serializeBytesBufferPool.Put(buf)
+	}()
+	writeBlockColumns(buf, lines)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "appending columns")
+	}
+	return nil
+}
+
+func detectColumnsParser(lines []string) byte {
+	if len(lines) == 0 {
+		return columnsParserNone
+	}
+	objects := 0
+	for _, l := range lines {
+		if l = trimLeftSpace(l); len(l) > 0 && l[0] == '{' {
+			objects++
+		}
+	}
+	if 2*objects > len(lines) {
+		return columnsParserJSON
+	}
+	return columnsParserLogfmt
+}
+
+func encodeBlockColumns(eb *encbuf, parser byte, lines []string) {
+	var stage log.Stage
+	if parser == columnsParserJSON {
+		stage = log.NewJSONParser()
+	} else {
+		stage = log.NewLogfmtParser(false, false)
+	}
+	hints := log.NewParserHint(nil, nil, false, false, "", nil)
+	lbs := log.NewBaseLabelsBuilderWithGrouping(nil, hints, false, false).ForLabels(labels.EmptyLabels(), 0)
+
+	// Parse all the lines, and count the lines each field is found in.
+	rows := make([]labels.Labels, len(lines))
+	parsed := make([]bool, len(lines))
+	counts := map[string]int{}
+	var fields []string
+	for i, l := range lines {
+		lbs.Reset()
+		_, _ = stage.Process(0, unsafeGetBytes(l), lbs)
+		if lbs.HasErr() {
+			continue
+		}
+		parsed[i] = true
+		rows[i] = lbs.UnsortedLabels(nil)
+		for _, f := range rows[i] {
+			if counts[f.Name] == 0 {
+				fields = append(fields, f.Name)
+			}
+			counts[f.Name]++
+		}
+	}
+
+	// The most common fields become columns.
+	var columns []string
+	for _, f := range fields {
+		if 2*counts[f] >= len(lines) {
+			columns = append(columns, f)
+		}
+	}
+	sort.SliceStable(columns, func(i, j int) bool { return counts[columns[i]] > counts[columns[j]] })
+	if len(columns) > maxBlockColumns {
+		columns = columns[:maxBlockColumns]
+	}
+	// Columns are first in the field names so they are recorded even if the list is truncated.
+	sort.Strings(fields)
+	fieldIndex := make(map[string]int, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, c := range columns {
+		fieldIndex[c] = len(names)
+		names = append(names, c)
+	}
+	for _, f := range fields {
+		if _, ok := fieldIndex[f]; !ok {
+			fieldIndex[f] = len(names)
+			names = append(names, f)
+		}
+	}
+	complete := len(names) <= maxBlockFields
+	if !complete {
+		names = names[:maxBlockFields]
+	}
+
+	eb.putUvarint(len(lines))
+	putBitmap(eb, parsed)
+	if complete {
+		eb.putByte(1)
+	} else {
+		eb.putByte(0)
+	}
+	eb.putUvarint(len(names))
+	for _, n := range names {
+		eb.putUvarint(len(n))
+		eb.putString(n)
+	}
+
+	eb.putUvarint(len(columns))
+	present := make([]bool, len(lines))
+	values := make([]string, len(lines))
+	col := &encbuf{}
+	for _, c := range columns {
+		for i, row := range rows {
+			values[i], present[i] = "", false
+			for _, l := range row {
+				if l.Name == c {
+					values[i], present[i] = l.Value, true
+					break
+				}
+			}
+		}
+		typ := columnType(values, present)
+
+		col.reset()
+		putBitmap(col, present)
+		for i, v := range values {
+			if !present[i] {
+				continue
+			}
+			switch typ {
+			case columnTypeInt:
+				n, _ := strconv.ParseInt(v, 10, 64)
+				col.putVarint64(n)
+			case columnTypeFloat:
+				f, _ := strconv.ParseFloat(v, 64)
+				col.putBE64(math.Float64bits(f))
+			default:
+				col.putUvarint(len(v))
+				col.putString(v)
+			}
+		}
+
+		eb.putUvarint(fieldIndex[c])
+		eb.putByte(typ)
+		eb.putUvarint(len(col.get()))
+		eb.b = append(eb.b, col.get()...)
+	}
+}
+
+// columnType returns the type that can hold all the present values of a column.
+// Values are only typed when they can be formatted back to the same string.
+func columnType(values []string, present []bool) byte {
+	ints, floats := true, true
+	for i, v := range values {
+		if !present[i] {
+			continue
+		}
+		if ints {
+			n, err := strconv.ParseInt(v, 10, 64)
+			ints = err == nil && strconv.FormatInt(n, 10) == v
+		}
+		if floats {
+			f, err := strconv.ParseFloat(v, 64)
+			floats = err == nil && formatColumnFloat(f) == v
+		}
+		if !ints && !floats {
+			return columnTypeString
+		}
+	}
+	if ints {
+		return columnTypeInt
+	}
+	return columnTypeFloat
+}
+
+func formatColumnFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func putBitmap(eb *encbuf, bits []bool) {
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8 && i+j < len(bits); j++ {
+			if bits[i+j] {
+				b |= 1 << j
+			}
+		}
+		eb.putByte(b)
+	}
+}
+
+func readBitmap(db *decbuf, n int) []bool {
+	b := db.bytes((n + 7) / 8)
+	if db.err() != nil {
+		return nil
+	}
+	bits := make([]bool, n)
+	for i := range bits {
+		bits[i] = b[i/8]&(1<<(i%8)) != 0
+	}
+	return bits
+}
+
+func trimLeftSpace(s string) string {
+	for len(s) > 0 && (s[0] == ' ' || s[0] == '\t' || s[0] == '\r' || s[0] == '\n') {
+		s = s[1:]
+	}
+	return s
+}
+
+// blockColumns are the decoded columns of a block. They implement log.ParsedColumns
+// for the current entry of the block iterator.
+type blockColumns struct {
+	// section is a copy of the columns section, referenced by the string values.
+	section  string
+	parser   string
+	rows     int
+	parsed   []bool
+	fields   []string
+	complete bool
+	names    []string
+	columns  []blockColumn
+
+	// row is the index of the current entry.
+	row int
+	err error
+}
+
+type blockColumn struct {
+	typ byte
+	// offset and size of the values in the section, decoded on first read.
+	offset, size int
+	decoded      bool
+
+	present []bool
+	ints    []int64
+	floats  []float64
+	strings []string
+}
+
+// decodeBlockColumns decodes a columns section, it returns nil if the block has no columns.
+// The values of the columns are decoded once they are read.
+func decodeBlockColumns(b []byte) (*blockColumns, error) {
+	db := decbuf{b: b}
+	var parser string
+	switch db.byte() {
+	case columnsParserNone:
+		return nil, db.err()
+	case columnsParserJSON:
+		parser = log.ColumnsParserJSON
+	case columnsParserLogfmt:
+		parser = log.ColumnsParserLogfmt
+	default:
+		return nil, errors.New("invalid columns parser")
+	}
+
+	c := &blockColumns{section: string(b), parser: parser, row: -1}
+	offset := func() int { return len(b) - len(db.b) }
+
+	c.rows = db.uvarint()
+	c.parsed = readBitmap(&db, c.rows)
+	c.complete = db.byte() == 1
+	c.fields = make([]string, db.uvarint())
+	for i := 0; i < len(c.fields) && db.err() == nil; i++ {
+		n := db.uvarint()
+		start := offset()
+		if db.bytes(n); db.err() == nil {
+			c.fields[i] = c.section[start : start+n]
+		}
+	}
+
+	numColumns := db.uvarint()
+	for i := 0; i < numColumns && db.err() == nil; i++ {
+		idx := db.uvarint()
+		if idx >= len(c.fields) {
+			return nil, errors.New("invalid column field")
+		}
+		col := blockColumn{typ: db.byte(), size: db.uvarint()}
+		col.offset = offset()
+		db.bytes(col.size)
+		c.columns = append(c.columns, col)
+		c.names = append(c.names, c.fields[idx])
+	}
+	if db.err() != nil {
+		return nil, errors.Wrap(db.err(), "decoding columns")
+	}
+	return c, nil
+}
+
+func (c *blockColumns) decodeColumn(col *blockColumn) error {
+	col.decoded = true
+	db := decbuf{b: unsafeGetBytes(c.section[col.offset : col.offset+col.size])}
+	col.present = readBitmap(&db, c.rows)
+	switch col.typ {
+	case columnTypeInt:
+		col.ints = make([]int64, c.rows)
+	case columnTypeFloat:
+		col.floats = make([]float64, c.rows)
+	default:
+		col.strings = make([]string, c.rows)
+	}
+	for r := 0; r < c.rows && db.err() == nil; r++ {
+		if !col.present[r] {
+			continue
+		}
+		switch col.typ {
+		case columnTypeInt:
+			col.ints[r] = db.varint64()
+		case columnTypeFloat:
+			col.floats[r] = math.Float64frombits(db.be64())
+		default:
+			n := db.uvarint()
+			start := col.offset + col.size - len(db.b)
+			if db.bytes(n); db.err() == nil {
+				col.strings[r] = c.section[start : start+n]
+			}
+		}
+	}
+	return errors.Wrap(db.err(), "decoding column")
+}
+
+// next moves to the next entry of the block.
+func (c *blockColumns) next() { c.row++ }
+
+// Parser implements log.ParsedColumns.
+func (c *blockColumns) Parser() string { return c.parser }
+
+// Columns implements log.ParsedColumns.
+func (c *blockColumns) Columns() []string { return c.names }
+
+// Fields implements log.ParsedColumns.
+func (c *blockColumns) Fields() ([]string, bool) { return c.fields, c.complete }
+
+// Parsed implements log.ParsedColumns.
+func (c *blockColumns) Parsed() bool {
+	return c.err == nil && c.row >= 0 && c.row < c.rows && c.parsed[c.row]
+}
+
+// Value implements log.ParsedColumns.
+func (c *blockColumns) Value(i int) (string, bool) {
+	col := &c.columns[i]
+	if !col.decoded {
+		if err := c.decodeColumn(col); err != nil {
+			c.err = err
+		}
+	}
+	if c.err != nil || !col.present[c.row] {
+		return "", false
+	}
+	switch col.typ {
+	case columnTypeInt:
+		return strconv.FormatInt(col.ints[c.row], 10), true
+	case columnTypeFloat:
+		return formatColumnFloat(col.floats[c.row]), true
+	default:
+		return col.strings[c.row], true
+	}
+}
@@ -0,0 +1,237 @@
+package chunkenc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+var columnsTestLines = map[string]func(i int) string{
+	log.ColumnsParserJSON: func(i int) string {
+		switch {
+		case i%10 == 9:
+			return "not json"
+		case i%7 == 0:
+			return fmt.Sprintf(`{"app":"api","status":%d,"latency":%d.5,"duration":"%dms","msg":"request \"%d\"","rare":"yes","nested":{"key":"v%d"}}`, 200+i%4*100, i%3, i, i, i%2)
+		default:
+			return fmt.Sprintf(`{"app":"api","status":%d,"latency":%d.5,"duration":"%dms","msg":"request \"%d\"","nested":{"key":"v%d"}}`, 200+i%4*100, i%3, i, i, i%2)
+		}
+	},
+	log.ColumnsParserLogfmt: func(i int) string {
+		switch {
+		case i%10 == 9:
+			return fmt.Sprintf("level=info empty= msg=\"short line %d\"", i)
+		case i%7 == 0:
+			return fmt.Sprintf("app=api status=%d latency=%d.5 duration=%dms msg=\"request %d\" rare=yes", 200+i%4*100, i%3, i, i)
+		default:
+			return fmt.Sprintf("app=api status=%d latency=%d.5 duration=%dms msg=\"request %d\"", 200+i%4*100, i%3, i, i)
+		}
+	},
+}
+
+func TestBlockColumns(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = columnsTestLines[log.ColumnsParserJSON](i)
+	}
+	var buf bytes.Buffer
+	writeBlockColumns(&buf, lines)
+
+	db := decbuf{b: buf.Bytes()}
+	section := db.bytes(db.uvarint())
+	require.NoError(t, db.err())
+	require.Empty(t, db.b)
+	c, err := decodeBlockColumns(section)
+	require.NoError(t, err)
+
+	require.Equal(t, log.ColumnsParserJSON, c.Parser())
+	require.ElementsMatch(t, []string{"app", "status", "latency", "duration", "msg", "nested_key"}, c.Columns())
+	fields, complete := c.Fields()
+	require.True(t, complete)
+	require.ElementsMatch(t, []string{"app", "status", "latency", "duration", "msg", "nested_key", "rare"}, fields)
+	for i, col := range c.columns {
+		switch c.names[i] {
+		case "status":
+			require.Equal(t, columnTypeInt, col.typ)
+		case "latency":
+			require.Equal(t, columnTypeFloat, col.typ)
+		default:
+			require.Equal(t, columnTypeString, col.typ)
+		}
+	}
+
+	c.next()
+	require.True(t, c.Parsed())
+	var values labels.Labels
+	for i, name := range c.Columns() {
+		v, ok := c.Value(i)
+		require.True(t, ok)
+		values = append(values, labels.Label{Name: name, Value: v})
+	}
+	require.ElementsMatch(t, labels.FromStrings("app", "api", "status", "200", "latency", "0.5", "duration", "0ms", "msg", `request "0"`, "nested_key", "v0"), values)
+
+	for i := 1; i < 10; i++ {
+		c.next()
+	}
+	// Lines that can't be parsed have no values.
+	require.False(t, c.Parsed())
+
+	// Lines without common fields have no columns.
+	buf.Reset()
+	writeBlockColumns(&buf, []string{"foo", "bar"})
+	db = decbuf{b: buf.Bytes()}
+	c, err = decodeBlockColumns(db.bytes(db.uvarint()))
+	require.NoError(t, err)
+	require.Empty(t, c.Columns())
+	fields, complete = c.Fields()
+	require.True(t, complete)
+	require.Empty(t, fields)
+}
+
+func TestMemChunk_ParsedColumns(t *testing.T) {
+	for _, parser := range []string{log.ColumnsParserJSON, log.ColumnsParserLogfmt} {
+		parser := parser
+		t.Run(parser, func(t *testing.T) {
+			t.Parallel()
+
+			// The results of the queries on a chunk with columns must be the same as without.
+			withColumns := NewMemChunk(EncSnappy, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize).WithParsedColumns()
+			withoutColumns := NewMemChunk(EncSnappy, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+			require.Equal(t, chunkFormatV5, withColumns.format)
+			for i := 0; i < 1000; i++ {
+				e := &logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: columnsTestLines[parser](i)}
+				require.NoError(t, withColumns.Append(e))
+				require.NoError(t, withoutColumns.Append(e))
+				if i%300 == 299 {
+					require.NoError(t, withColumns.cut())
+					require.NoError(t, withoutColumns.cut())
+				}
+			}
+			require.NoError(t, withColumns.Close())
+			require.NoError(t, withoutColumns.Close())
+
+			b, err := withColumns.Bytes()
+			require.NoError(t, err)
+			read, err := NewByteChunk(b, testBlockSize, testTargetSize)
+			require.NoError(t, err)
+			require.Equal(t, chunkFormatV5, read.format)
+
+			lbs := labels.FromStrings("app", "foo")
+			for _, query := range []string{
+				`{app="foo"}`,
+				`{app="foo"} |= "request"`,
+				`{app="foo"} | PARSER`,
+				`{app="foo"} | PARSER | status >= 400`,
+				`{app="foo"} | PARSER | rare="yes"`,
+				`{app="foo"} | PARSER | line_format "{{.msg}}" | PARSER`,
+			} {
+				query = strings.ReplaceAll(query, "PARSER", parser)
+				t.Run(query, func(t *testing.T) {
+					expr, err := syntax.ParseLogSelector(query, true)
+					require.NoError(t, err)
+					p, err := expr.Pipeline()
+					require.NoError(t, err)
+
+					expected := columnsTestEntries(t, withoutColumns, p.ForStream(lbs))
+					require.NotEmpty(t, expected)
+					require.Equal(t, expected, columnsTestEntries(t, withColumns, p.ForStream(lbs)))
+					require.Equal(t, expected, columnsTestEntries(t, read, p.ForStream(lbs)))
+				})
+			}
+
+			for _, query := range []string{
+				`count_over_time({app="foo"}[1s])`,
+				`sum by (status) (count_over_time({app="foo"} | PARSER [1s]))`,
+				`sum(count_over_time({app="foo"} | PARSER | status >= 400 [1s]))`,
+				`sum by (app) (count_over_time({app="foo"} | PARSER | app_extracted="api" [1s]))`,
+				`sum by (rare) (count_over_time({app="foo"} | PARSER [1s]))`,
+				`sum by (level) (count_over_time({app="foo"} | PARSER [1s]))`,
+				`sum by (__error__) (count_over_time({app="foo"} | PARSER [1s]))`,
+				`sum by (status) (sum_over_time({app="foo"} | PARSER | unwrap latency [1s]))`,
+				`sum by (status) (sum_over_time({app="foo"} | PARSER | unwrap duration(duration) [1s]))`,
+			} {
+				query = strings.ReplaceAll(query, "PARSER", parser)
+				t.Run(query, func(t *testing.T) {
+					expr, err := syntax.ParseSampleExpr(query)
+					require.NoError(t, err)
+					ex, err := expr.Extractor()
+					require.NoError(t, err)
+
+					expected := columnsTestSamples(withoutColumns, ex.ForStream(lbs))
+					require.NotEmpty(t, expected)
+					require.Equal(t, expected, columnsTestSamples(withColumns, ex.ForStream(lbs)))
+					require.Equal(t, expected, columnsTestSamples(read, ex.ForStream(lbs)))
+				})
+			}
+		})
+	}
+}
+
+func columnsTestEntries(t *testing.T, c Chunk, p log.StreamPipeline) []string {
+	it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1000), logproto.FORWARD, p)
+	require.NoError(t, err)
+	var res []string
+	for it.Next() {
+		res = append(res, fmt.Sprintf("%s %s", it.Labels(), it.Entry().Line))
+	}
+	require.NoError(t, it.Close())
+	return res
+}
+
+func columnsTestSamples(c Chunk, ex log.StreamSampleExtractor) []string {
+	it := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1000), ex)
+	var res []string
+	for it.Next() {
+		res = append(res, fmt.Sprintf("%s %d %v", it.Labels(), it.Sample().Timestamp, it.Sample().Value))
+	}
+	_ = it.Close()
+	return res
+}
+
+func BenchmarkParsedColumns(b *testing.B) {
+	for _, parser := range []string{log.ColumnsParserJSON, log.ColumnsParserLogfmt} {
+		for _, columns := range []bool{false, true} {
+			c := NewMemChunk(EncSnappy, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+			if columns {
+				c = c.WithParsedColumns()
+			}
+			for i := 0; c.SpaceFor(&logproto.Entry{}); i++ {
+				if err := c.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: columnsTestLines[parser](i)}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := c.Close(); err != nil {
+				b.Fatal(err)
+			}
+
+			expr, err := syntax.ParseSampleExpr(fmt.Sprintf(`sum by (status) (count_over_time({app="foo"} | %s | status >= 400 [1s]))`, parser))
+			if err != nil {
+				b.Fatal(err)
+			}
+			ex, err := expr.Extractor()
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("%s_columns=%v", parser, columns), func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					it := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Now(), ex.ForStream(labels.FromStrings("app", "foo")))
+					for it.Next() {
+						_ = it.Sample()
+					}
+					_ = it.Close()
+				}
+			})
+		}
+	}
+}
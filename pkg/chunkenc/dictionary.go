@@ -0,0 +1,231 @@
+package chunkenc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// dictionaryCacheSize is the maximum number of dictionaries kept in memory by a process.
+	dictionaryCacheSize = 1024
+	// dictionaryLoadTimeout bounds the loading of a dictionary from the DictionaryStore.
+	dictionaryLoadTimeout = 30 * time.Second
+)
+
+// Dictionary is a zstd dictionary used by the EncZstdDict encoding.
+// Its ID is derived from its content and written in the header of the chunks compressed with it,
+// so that readers can load it from the dictionaries of the tenant of the chunk in the DictionaryStore.
+type Dictionary struct {
+	ID      uint32
+	Content []byte
+
+	readers sync.Pool
+	writers sync.Pool
+}
+
+// NewDictionary returns the dictionary of the given content.
+func NewDictionary(content []byte) *Dictionary {
+	return &Dictionary{ID: dictionaryID(content), Content: content}
+}
+
+func dictionaryID(content []byte) uint32 {
+	id := uint32(xxhash.Sum64(content))
+	if id == 0 {
+		// 0 means that the chunk isn't compressed with a dictionary.
+		id = 1
+	}
+	return id
+}
+
+// GetReader gets or creates a new CompressionReader using the dictionary and reset it to read from src
+func (d *Dictionary) GetReader(src io.Reader) (io.Reader, error) {
+	if r := d.readers.Get(); r != nil {
+		reader := r.(*zstd.Decoder)
+		err := reader.Reset(src)
+		if err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+	reader, err := zstd.NewReader(src, zstd.WithDecoderDictRaw(d.ID, d.Content))
+	if err != nil {
+		return nil, err
+	}
+	runtime.SetFinalizer(reader, (*zstd.Decoder).Close)
+	return reader, nil
+}
+
+// PutReader places back in the pool a CompressionReader
+func (d *Dictionary) PutReader(reader io.Reader) {
+	d.readers.Put(reader)
+}
+
+// GetWriter gets or creates a new CompressionWriter using the dictionary and reset it to write to dst
+func (d *Dictionary) GetWriter(dst io.Writer) io.WriteCloser {
+	if w := d.writers.Get(); w != nil {
+		writer := w.(*zstd.Encoder)
+		writer.Reset(dst)
+		return writer
+	}
+
+	w, err := zstd.NewWriter(dst, zstd.WithEncoderDictRaw(d.ID, d.Content))
+	if err != nil {
+		panic(err) // never happens, error is only returned on dictionaries larger than 2GiB.
+	}
+	return w
+}
+
+// PutWriter places back in the pool a CompressionWriter
+func (d *Dictionary) PutWriter(writer io.WriteCloser) {
+	d.writers.Put(writer)
+}
+
+// DictionaryStore persists the dictionaries of the EncZstdDict encoding.
+// Dictionaries are trained for a tenant, and the latest dictionary of each tenant is recorded
+// so that all the ingesters of a tenant compress its chunks with the same dictionary.
+type DictionaryStore interface {
+	GetDictionary(ctx context.Context, tenant string, id uint32) ([]byte, error)
+	PutDictionary(ctx context.Context, tenant string, id uint32, content []byte) error
+	// GetLatestDictionary returns the ID of the latest dictionary of the tenant and when it was trained,
+	// or 0 if no dictionary was trained for the tenant.
+	GetLatestDictionary(ctx context.Context, tenant string) (uint32, time.Time, error)
+	SetLatestDictionary(ctx context.Context, tenant string, id uint32, trainedAt time.Time) error
+}
+
+var dictionaries = newDictionaryRegistry(dictionaryCacheSize)
+
+// SetDictionaryStore sets the store from which the dictionaries referenced by chunks are loaded.
+func SetDictionaryStore(s DictionaryStore) {
+	dictionaries.setStore(s)
+}
+
+// PublishDictionary persists the dictionary trained for the tenant and records it as the latest of the tenant.
+func PublishDictionary(ctx context.Context, tenant string, d *Dictionary, trainedAt time.Time) error {
+	s, err := dictionaries.getStore()
+	if err != nil {
+		return err
+	}
+	if err := s.PutDictionary(ctx, tenant, d.ID, d.Content); err != nil {
+		return err
+	}
+	dictionaries.cache.Add(dictionaryCacheKey{tenant: tenant, id: d.ID}, d)
+	return s.SetLatestDictionary(ctx, tenant, d.ID, trainedAt)
+}
+
+// LatestDictionary returns the latest dictionary of the tenant and when it was trained, or nil if there is none.
+func LatestDictionary(ctx context.Context, tenant string) (*Dictionary, time.Time, error) {
+	s, err := dictionaries.getStore()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	id, trainedAt, err := s.GetLatestDictionary(ctx, tenant)
+	if err != nil || id == 0 {
+		return nil, time.Time{}, err
+	}
+	d, err := dictionaries.get(ctx, tenant, id)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return d, trainedAt, nil
+}
+
+// LoadDictionary returns the dictionary of the tenant with the given ID, loading it from the DictionaryStore if it isn't cached.
+// Loading it fails after dictionaryLoadTimeout if the context has no earlier deadline.
+func LoadDictionary(ctx context.Context, tenant string, id uint32) (*Dictionary, error) {
+	return dictionaries.get(ctx, tenant, id)
+}
+
+type dictionaryCacheKey struct {
+	tenant string
+	id     uint32
+}
+
+type dictionaryRegistry struct {
+	mtx   sync.RWMutex
+	store DictionaryStore
+	cache *lru.Cache
+}
+
+func newDictionaryRegistry(size int) *dictionaryRegistry {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err) // never happens, error is only returned on non-positive size.
+	}
+	return &dictionaryRegistry{cache: cache}
+}
+
+func (r *dictionaryRegistry) setStore(s DictionaryStore) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.store = s
+}
+
+func (r *dictionaryRegistry) getStore() (DictionaryStore, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	if r.store == nil {
+		return nil, fmt.Errorf("no store configured for the chunk dictionaries")
+	}
+	return r.store, nil
+}
+
+func (r *dictionaryRegistry) get(ctx context.Context, tenant string, id uint32) (*Dictionary, error) {
+	key := dictionaryCacheKey{tenant: tenant, id: id}
+	if d, ok := r.cache.Get(key); ok {
+		return d.(*Dictionary), nil
+	}
+	if tenant == "" {
+		return nil, fmt.Errorf("unknown tenant of chunk dictionary %08x", id)
+	}
+
+	s, err := r.getStore()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dictionaryLoadTimeout)
+	defer cancel()
+	content, err := s.GetDictionary(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDictionary(content)
+	if d.ID != id {
+		return nil, fmt.Errorf("invalid content for chunk dictionary %08x", id)
+	}
+	r.cache.Add(key, d)
+	return d, nil
+}
+
+// dictionaryReaderPool is the ReaderPool of the blocks of a chunk read from its bytes.
+// The dictionary of the chunk is loaded when the first block is decompressed, with the context of the read.
+type dictionaryReaderPool struct {
+	ctx    context.Context
+	tenant string
+	id     uint32
+	dict   *Dictionary
+}
+
+func (p *dictionaryReaderPool) GetReader(src io.Reader) (io.Reader, error) {
+	if p.dict == nil {
+		d, err := LoadDictionary(p.ctx, p.tenant, p.id)
+		if err != nil {
+			return nil, fmt.Errorf("loading chunk dictionary %08x: %w", p.id, err)
+		}
+		p.dict = d
+	}
+	return p.dict.GetReader(src)
+}
+
+func (p *dictionaryReaderPool) PutReader(reader io.Reader) {
+	if p.dict != nil {
+		p.dict.PutReader(reader)
+	}
+}
@@ -0,0 +1,233 @@
+package chunkenc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+type memDictionaryStore struct {
+	mtx    sync.Mutex
+	dicts  map[string][]byte
+	latest map[string]uint32
+	at     map[string]time.Time
+}
+
+func newMemDictionaryStore() *memDictionaryStore {
+	return &memDictionaryStore{dicts: map[string][]byte{}, latest: map[string]uint32{}, at: map[string]time.Time{}}
+}
+
+func (s *memDictionaryStore) GetDictionary(ctx context.Context, tenant string, id uint32) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	d, ok := s.dicts[fmt.Sprintf("%s/%08x", tenant, id)]
+	if !ok {
+		return nil, fmt.Errorf("dictionary %08x not found", id)
+	}
+	return d, nil
+}
+
+func (s *memDictionaryStore) PutDictionary(_ context.Context, tenant string, id uint32, content []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.dicts[fmt.Sprintf("%s/%08x", tenant, id)] = content
+	return nil
+}
+
+func (s *memDictionaryStore) GetLatestDictionary(_ context.Context, tenant string) (uint32, time.Time, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.latest[tenant], s.at[tenant], nil
+}
+
+func (s *memDictionaryStore) SetLatestDictionary(_ context.Context, tenant string, id uint32, trainedAt time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.latest[tenant], s.at[tenant] = id, trainedAt
+	return nil
+}
+
+// trainTestDictionary trains a dictionary on the first half of the test logs.
+// The second half is used to evaluate it.
+func trainTestDictionary(t testing.TB, maxSize int) *Dictionary {
+	d := TrainDictionary(testdata.LogsBytes[:len(testdata.LogsBytes)/2], maxSize)
+	require.NotNil(t, d)
+	return d
+}
+
+// fillChunkFrom fills the chunk with the test logs from the given index to the end, repeatedly.
+func fillChunkFrom(c Chunk, from int64) int64 {
+	line := func(i int64) string { return testdata.LogString(from + i%(int64(len(testdata.Logs))-from)) }
+	inserted := int64(0)
+	entry := logprotoEntry(0, line(0))
+	for i := int64(0); c.SpaceFor(entry); {
+		if err := c.Append(entry); err != nil {
+			panic(err)
+		}
+		inserted += int64(len(entry.Line))
+		i++
+		entry = logprotoEntry(i, line(i))
+	}
+	_ = c.Close()
+	return inserted
+}
+
+func TestTrainDictionary(t *testing.T) {
+	d := trainTestDictionary(t, 16*1024)
+	require.LessOrEqual(t, len(d.Content), 16*1024)
+	require.Greater(t, len(d.Content), 1024)
+	require.Equal(t, dictionaryID(d.Content), d.ID)
+	require.Contains(t, string(d.Content), "component=tsdb msg=")
+
+	require.Nil(t, TrainDictionary([][]byte{[]byte("foo"), []byte("bar")}, 1024))
+}
+
+func TestMemChunkWithDictionary(t *testing.T) {
+	store := newMemDictionaryStore()
+	SetDictionaryStore(store)
+	defer SetDictionaryStore(nil)
+
+	d := trainTestDictionary(t, 64*1024)
+	require.NoError(t, PublishDictionary(context.Background(), "tenant", d, time.Unix(10, 0)))
+	latest, trainedAt, err := LatestDictionary(context.Background(), "tenant")
+	require.NoError(t, err)
+	require.Equal(t, d.ID, latest.ID)
+	require.Equal(t, time.Unix(10, 0), trainedAt)
+
+	blockSize := 16 * 1024
+	withDict := NewMemChunkWithDictionary(d, DefaultHeadBlockFmt, blockSize, testTargetSize)
+	size := fillChunkFrom(withDict, int64(len(testdata.Logs)/2))
+	withoutDict := NewMemChunk(EncZstd, DefaultHeadBlockFmt, blockSize, testTargetSize)
+	fillChunkFrom(withoutDict, int64(len(testdata.Logs)/2))
+	require.Less(t, withDict.CompressedSize(), withoutDict.CompressedSize())
+
+	b, err := withDict.Bytes()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, withDict.BytesSize(), len(b))
+
+	// The chunk is read back with the dictionary of its tenant referenced by its header.
+	dictionaries.cache.Purge()
+	read, err := NewByteChunk(b, blockSize, testTargetSize)
+	require.NoError(t, err)
+	require.Equal(t, EncZstdDict, read.Encoding())
+	require.Nil(t, read.Dictionary())
+	read.SetTenant("tenant")
+	require.Equal(t, size, readChunkSize(t, read))
+
+	require.NoError(t, read.LoadDictionary(context.Background(), "tenant"))
+	require.Equal(t, d.ID, read.Dictionary().ID)
+
+	// The dictionaries of the other tenants are not used to read the chunk.
+	read, err = NewByteChunk(b, blockSize, testTargetSize)
+	require.NoError(t, err)
+	read.SetTenant("other")
+	_, err = readChunk(context.Background(), read)
+	require.Error(t, err)
+	require.Error(t, read.LoadDictionary(context.Background(), "other"))
+
+	// The dictionary is loaded with the context of the caller.
+	dictionaries.cache.Purge()
+	read, err = NewByteChunk(b, blockSize, testTargetSize)
+	require.NoError(t, err)
+	read.SetTenant("tenant")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, read.LoadDictionary(ctx, "tenant"), context.Canceled)
+
+	// Chunks cut before a dictionary is available are compressed without one.
+	noDict := NewMemChunkWithDictionary(nil, DefaultHeadBlockFmt, blockSize, testTargetSize)
+	size = fillChunkFrom(noDict, 0)
+	b, err = noDict.Bytes()
+	require.NoError(t, err)
+	read, err = NewByteChunk(b, blockSize, testTargetSize)
+	require.NoError(t, err)
+	require.Equal(t, size, readChunkSize(t, read))
+
+	// The dictionary of a chunk must be available to read it, and to append to it.
+	unknown := NewMemChunkWithDictionary(NewDictionary([]byte("unknown dictionary content")), DefaultHeadBlockFmt, blockSize, testTargetSize)
+	fillChunkFrom(unknown, 0)
+	b, err = unknown.Bytes()
+	require.NoError(t, err)
+	read, err = NewByteChunk(b, blockSize, testTargetSize)
+	require.NoError(t, err)
+	read.SetTenant("tenant")
+	_, err = readChunk(context.Background(), read)
+	require.Error(t, err)
+	require.Error(t, read.LoadDictionary(context.Background(), "tenant"))
+	require.NoError(t, read.Append(logprotoEntry(1<<40, "foo")))
+	require.Error(t, read.Close())
+}
+
+func readChunk(ctx context.Context, c Chunk) (int64, error) {
+	it, err := c.Iterator(ctx, time.Unix(0, 0), time.Unix(0, 1<<62), logproto.FORWARD, noopStreamPipeline)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for it.Next() {
+		size += int64(len(it.Entry().Line))
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	return size, it.Close()
+}
+
+func readChunkSize(t *testing.T, c Chunk) int64 {
+	size, err := readChunk(context.Background(), c)
+	require.NoError(t, err)
+	return size
+}
+
+// BenchmarkDictionaryCompression compares the compression of the test logs by the dictionary encoding,
+// with a dictionary trained on other test logs, to the other encodings.
+func BenchmarkDictionaryCompression(b *testing.B) {
+	d := trainTestDictionary(b, 64*1024)
+	from := int64(len(testdata.Logs) / 2)
+
+	for _, bs := range []int{16 * 1024, 64 * 1024, 256 * 1024} {
+		for _, enc := range testEncoding {
+			newChunk := func() *MemChunk { return NewMemChunk(enc, DefaultHeadBlockFmt, bs, testTargetSize) }
+			name := enc.String()
+			if enc == EncZstdDict {
+				newChunk = func() *MemChunk { return NewMemChunkWithDictionary(d, DefaultHeadBlockFmt, bs, testTargetSize) }
+				name = fmt.Sprintf("%s-%s", name, humanize.IBytes(uint64(len(d.Content))))
+			}
+
+			b.Run(fmt.Sprintf("%s_%s", name, humanize.IBytes(uint64(bs))), func(b *testing.B) {
+				b.ReportAllocs()
+				var uncompressed, compressed int
+				for n := 0; n < b.N; n++ {
+					c := newChunk()
+					fillChunkFrom(c, from)
+					uncompressed += c.UncompressedSize()
+					compressed += c.CompressedSize()
+
+					it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1<<62), logproto.FORWARD, noopStreamPipeline)
+					if err != nil {
+						b.Fatal(err)
+					}
+					for it.Next() {
+						_ = it.Entry()
+					}
+					if err := it.Close(); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.SetBytes(int64(uncompressed / b.N))
+				b.ReportMetric(float64(compressed)/float64(uncompressed)*100, "%compressed")
+			})
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package chunkenc
+
+import (
+	"container/heap"
+	"encoding/binary"
+)
+
+const (
+	// trainSegmentSize is the size of the segments of the samples added to dictionaries.
+	trainSegmentSize = 64
+	// trainDmerSize is the size of the d-mers used to score the segments.
+	trainDmerSize = 8
+)
+
+// TrainDictionary trains a dictionary of at most maxSize bytes from sample lines.
+// Like the COVER algorithm of zstd, it greedily picks the segments of the samples covering the d-mers
+// found in the most samples, and puts the best segments at the end of the dictionary
+// where they are the cheapest to reference. It returns nil if the samples have nothing in common.
+func TrainDictionary(samples [][]byte, maxSize int) *Dictionary {
+	// Count the number of samples each d-mer is found in.
+	freqs := map[uint64]int{}
+	seen := map[uint64]struct{}{}
+	for _, s := range samples {
+		for k := range seen {
+			delete(seen, k)
+		}
+		for i := 0; i+trainDmerSize <= len(s); i++ {
+			dmer := binary.LittleEndian.Uint64(s[i:])
+			if _, ok := seen[dmer]; ok {
+				continue
+			}
+			seen[dmer] = struct{}{}
+			freqs[dmer]++
+		}
+	}
+
+	// Candidate segments overlap by half of their size.
+	var candidates segmentHeap
+	for _, s := range samples {
+		for i := 0; i+trainDmerSize <= len(s); i += trainSegmentSize / 2 {
+			end := i + trainSegmentSize
+			if end > len(s) {
+				end = len(s)
+			}
+			seg := s[i:end]
+			candidates = append(candidates, &segment{b: seg, score: segmentScore(seg, freqs)})
+		}
+	}
+	heap.Init(&candidates)
+
+	// The score of a segment only decreases as others are selected, so the scores
+	// can be lazily updated when the segments reach the top of the heap.
+	var selected [][]byte
+	size := 0
+	for candidates.Len() > 0 && size < maxSize {
+		best := heap.Pop(&candidates).(*segment)
+		best.score = segmentScore(best.b, freqs)
+		if candidates.Len() > 0 && best.score < candidates[0].score {
+			heap.Push(&candidates, best)
+			continue
+		}
+		// A d-mer found in a single sample is not worth referencing.
+		if best.score <= len(best.b)-trainDmerSize+1 {
+			break
+		}
+		if size+len(best.b) > maxSize {
+			continue
+		}
+		for i := 0; i+trainDmerSize <= len(best.b); i++ {
+			freqs[binary.LittleEndian.Uint64(best.b[i:])] = 0
+		}
+		selected = append(selected, best.b)
+		size += len(best.b)
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	content := make([]byte, 0, size)
+	for i := len(selected) - 1; i >= 0; i-- {
+		content = append(content, selected[i]...)
+	}
+	return NewDictionary(content)
+}
+
+// segmentScore returns the sum of the frequencies of the distinct d-mers of the segment.
+func segmentScore(seg []byte, freqs map[uint64]int) int {
+	score := 0
+	for i := 0; i+trainDmerSize <= len(seg); i++ {
+		dmer := binary.LittleEndian.Uint64(seg[i:])
+		score += freqs[dmer]
+		// Count repeated d-mers once.
+		for j := 0; j < i; j++ {
+			if binary.LittleEndian.Uint64(seg[j:]) == dmer {
+				score -= freqs[dmer]
+				break
+			}
+		}
+	}
+	return score
+}
+
+type segment struct {
+	b     []byte
+	score int
+}
+
+// segmentHeap is a max-heap of segments by score.
+type segmentHeap []*segment
+
+func (h segmentHeap) Len() int            { return len(h) }
+func (h segmentHeap) Less(i, j int) bool  { return h[i].score > h[j].score }
+func (h segmentHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *segmentHeap) Push(x interface{}) { *h = append(*h, x.(*segment)) }
+func (h *segmentHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
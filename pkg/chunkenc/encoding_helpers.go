@@ -15,7 +15,8 @@ type encbuf struct {
 func (e *encbuf) reset()      { e.b = e.b[:0] }
 func (e *encbuf) get() []byte { return e.b }
 
-func (e *encbuf) putByte(c byte) { e.b = append(e.b, c) }
+func (e *encbuf) putByte(c byte)     { e.b = append(e.b, c) }
+func (e *encbuf) putString(s string) { e.b = append(e.b, s...) }
 
 func (e *encbuf) putBE64int(x int) { e.putBE64(uint64(x)) }
 func (e *encbuf) putUvarint(x int) { e.putUvarint64(uint64(x)) }
@@ -105,6 +106,19 @@ func (d *decbuf) be32() uint32 {
 	return x
 }
 
+func (d *decbuf) be64() uint64 {
+	if d.e != nil {
+		return 0
+	}
+	if len(d.b) < 8 {
+		d.e = ErrInvalidSize
+		return 0
+	}
+	x := binary.BigEndian.Uint64(d.b)
+	d.b = d.b[8:]
+	return x
+}
+
 func (d *decbuf) byte() byte {
 	if d.e != nil {
 		return 0
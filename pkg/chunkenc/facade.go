@@ -65,6 +65,13 @@ func (f *Facade) UnmarshalFromBuf(buf []byte) error {
 	return err
 }
 
+// SetTenant implements chunk.TenantData.
+func (f *Facade) SetTenant(tenant string) {
+	if c, ok := f.c.(*MemChunk); ok {
+		c.SetTenant(tenant)
+	}
+}
+
 // Encoding implements chunk.Chunk.
 func (Facade) Encoding() chunk.Encoding {
 	return LogChunk
@@ -60,6 +60,8 @@ const (
 	EncLZ4_4M
 	EncFlate
 	EncZstd
+	// EncZstdDict compresses the blocks with zstd and a dictionary whose ID is written in the chunk header.
+	EncZstdDict
 )
 
 var supportedEncoding = []Encoding{
@@ -72,6 +74,7 @@ var supportedEncoding = []Encoding{
 	EncLZ4_4M,
 	EncFlate,
 	EncZstd,
+	EncZstdDict,
 }
 
 func (e Encoding) String() string {
@@ -96,6 +99,8 @@ func (e Encoding) String() string {
 		return "flate"
 	case EncZstd:
 		return "zstd"
+	case EncZstdDict:
+		return "zstd-dict"
 	default:
 		return "unknown"
 	}
@@ -31,6 +31,10 @@ const (
 	chunkFormatV1
 	chunkFormatV2
 	chunkFormatV3
+	// chunkFormatV4 adds the structured metadata of each entry to the blocks.
+	chunkFormatV4
+	// chunkFormatV5 adds the columns of the fields parsed from the lines to the blocks.
+	chunkFormatV5
 
 	DefaultChunkFormat = chunkFormatV3 // the currently used chunk format
 
@@ -43,7 +47,7 @@ const (
 	defaultBlockSize = 256 * 1024
 )
 
-var HeadBlockFmts = []HeadBlockFmt{OrderedHeadBlockFmt, UnorderedHeadBlockFmt}
+var HeadBlockFmts = []HeadBlockFmt{OrderedHeadBlockFmt, UnorderedHeadBlockFmt, UnorderedWithStructuredMetadataHeadBlockFmt}
 
 type HeadBlockFmt byte
 
@@ -55,6 +59,8 @@ func (f HeadBlockFmt) String() string {
 		return "ordered"
 	case f == UnorderedHeadBlockFmt:
 		return "unordered"
+	case f == UnorderedWithStructuredMetadataHeadBlockFmt:
+		return "unordered with structured metadata"
 	default:
 		return fmt.Sprintf("unknown: %v", byte(f))
 	}
@@ -65,10 +71,18 @@ func (f HeadBlockFmt) NewBlock() HeadBlock {
 	case f < UnorderedHeadBlockFmt:
 		return &headBlock{}
 	default:
-		return newUnorderedHeadBlock()
+		return newUnorderedHeadBlock(f)
 	}
 }
 
+// ChunkFormat returns the chunk format used for the blocks cut from a head block of this format.
+func (f HeadBlockFmt) ChunkFormat() byte {
+	if f >= UnorderedWithStructuredMetadataHeadBlockFmt {
+		return chunkFormatV4
+	}
+	return DefaultChunkFormat
+}
+
 const (
 	_ HeadBlockFmt = iota
 	// placeholders to start splitting chunk formats vs head block
@@ -77,6 +91,9 @@ const (
 	_
 	OrderedHeadBlockFmt
 	UnorderedHeadBlockFmt
+	// UnorderedWithStructuredMetadataHeadBlockFmt is an unordered head block
+	// which also keeps the structured metadata of each entry.
+	UnorderedWithStructuredMetadataHeadBlockFmt
 )
 
 var magicNumber = uint32(0x12EE56A)
@@ -115,6 +132,13 @@ type MemChunk struct {
 	format   byte
 	encoding Encoding
 	headFmt  HeadBlockFmt
+
+	// dict is the dictionary of the EncZstdDict encoding, nil when the chunk is compressed without one.
+	dict *Dictionary
+	// dictID is the ID of the dictionary of a chunk read from its bytes. The dictionary is loaded from
+	// the dictionaries of the tenant of the chunk when its blocks are read, or with LoadDictionary.
+	dictID uint32
+	tenant string
 }
 
 type block struct {
@@ -159,22 +183,22 @@ func (hb *headBlock) Reset() {
 
 func (hb *headBlock) Bounds() (int64, int64) { return hb.mint, hb.maxt }
 
-func (hb *headBlock) Append(ts int64, line string) error {
+func (hb *headBlock) Append(ts int64, line string, structuredMetadata labels.Labels) error {
 	if !hb.IsEmpty() && hb.maxt > ts {
 		return ErrOutOfOrder
 	}
 
-	hb.entries = append(hb.entries, entry{ts, line})
+	hb.entries = append(hb.entries, entry{t: ts, s: line, structuredMetadata: structuredMetadata})
 	if hb.mint == 0 || hb.mint > ts {
 		hb.mint = ts
 	}
 	hb.maxt = ts
-	hb.size += len(line)
+	hb.size += len(line) + structuredMetadataSize(structuredMetadata)
 
 	return nil
 }
 
-func (hb *headBlock) Serialise(pool WriterPool) ([]byte, error) {
+func (hb *headBlock) Serialise(pool WriterPool, format byte) ([]byte, error) {
 	inBuf := serializeBytesBufferPool.Get().(*bytes.Buffer)
 	defer func() {
 		inBuf.Reset()
@@ -186,15 +210,18 @@ func (hb *headBlock) Serialise(pool WriterPool) ([]byte, error) {
 	compressedWriter := pool.GetWriter(outBuf)
 	defer pool.PutWriter(compressedWriter)
 	for _, logEntry := range hb.entries {
-		n := binary.PutVarint(encBuf, logEntry.t)
-		inBuf.Write(encBuf[:n])
-
-		n = binary.PutUvarint(encBuf, uint64(len(logEntry.s)))
-		inBuf.Write(encBuf[:n])
-
-		inBuf.WriteString(logEntry.s)
+		writeBlockEntry(inBuf, encBuf, format, logEntry.t, logEntry.s, logEntry.structuredMetadata)
 	}
 
+	if format >= chunkFormatV5 {
+		lines := make([]string, 0, len(hb.entries))
+		for _, logEntry := range hb.entries {
+			lines = append(lines, logEntry.s)
+		}
+		if err := writeColumnsTo(compressedWriter, lines); err != nil {
+			return nil, err
+		}
+	}
 	if _, err := compressedWriter.Write(inBuf.Bytes()); err != nil {
 		return nil, errors.Wrap(err, "appending entry")
 	}
@@ -315,10 +342,10 @@ func (hb *headBlock) Convert(version HeadBlockFmt) (HeadBlock, error) {
 	if version < UnorderedHeadBlockFmt {
 		return hb, nil
 	}
-	out := newUnorderedHeadBlock()
+	out := newUnorderedHeadBlock(version)
 
 	for _, e := range hb.entries {
-		if err := out.Append(e.t, e.s); err != nil {
+		if err := out.Append(e.t, e.s, e.structuredMetadata); err != nil {
 			return nil, err
 		}
 	}
@@ -326,18 +353,118 @@ func (hb *headBlock) Convert(version HeadBlockFmt) (HeadBlock, error) {
 }
 
 type entry struct {
-	t int64
-	s string
+	t                  int64
+	s                  string
+	structuredMetadata labels.Labels
+}
+
+// writeBlockEntry encodes a single entry of a block using the given chunk format into buf.
+// Starting with chunkFormatV4 the line is followed by the length prefixed structured metadata.
+func writeBlockEntry(buf *bytes.Buffer, encBuf []byte, format byte, ts int64, line string, structuredMetadata labels.Labels) {
+	n := binary.PutVarint(encBuf, ts)
+	buf.Write(encBuf[:n])
+
+	n = binary.PutUvarint(encBuf, uint64(len(line)))
+	buf.Write(encBuf[:n])
+
+	buf.WriteString(line)
+
+	if format < chunkFormatV4 {
+		return
+	}
+	n = binary.PutUvarint(encBuf, uint64(structuredMetadataEncodedSize(structuredMetadata)))
+	buf.Write(encBuf[:n])
+	writeStructuredMetadata(buf, encBuf, structuredMetadata)
+}
+
+// writeStructuredMetadata writes the number of labels followed by each length prefixed name and value.
+func writeStructuredMetadata(buf io.Writer, encBuf []byte, structuredMetadata labels.Labels) {
+	n := binary.PutUvarint(encBuf, uint64(len(structuredMetadata)))
+	_, _ = buf.Write(encBuf[:n])
+	for _, l := range structuredMetadata {
+		n = binary.PutUvarint(encBuf, uint64(len(l.Name)))
+		_, _ = buf.Write(encBuf[:n])
+		_, _ = io.WriteString(buf, l.Name)
+		n = binary.PutUvarint(encBuf, uint64(len(l.Value)))
+		_, _ = buf.Write(encBuf[:n])
+		_, _ = io.WriteString(buf, l.Value)
+	}
+}
+
+// structuredMetadataEncodedSize returns the size of the structured metadata once written by writeStructuredMetadata.
+func structuredMetadataEncodedSize(structuredMetadata labels.Labels) int {
+	size := uvarintSize(uint64(len(structuredMetadata)))
+	for _, l := range structuredMetadata {
+		size += uvarintSize(uint64(len(l.Name))) + len(l.Name)
+		size += uvarintSize(uint64(len(l.Value))) + len(l.Value)
+	}
+	return size
+}
+
+// structuredMetadataSize returns the uncompressed size of the structured metadata names and values.
+func structuredMetadataSize(structuredMetadata labels.Labels) int {
+	size := 0
+	for _, l := range structuredMetadata {
+		size += len(l.Name) + len(l.Value)
+	}
+	return size
+}
+
+// decodeStructuredMetadata decodes structured metadata written by writeStructuredMetadata.
+// It appends to buf and copies the names and values, so b can be reused.
+func decodeStructuredMetadata(b []byte, buf labels.Labels) (labels.Labels, error) {
+	db := decbuf{b: b}
+	n := db.uvarint()
+	for i := 0; i < n && db.err() == nil; i++ {
+		name := string(db.bytes(db.uvarint()))
+		value := string(db.bytes(db.uvarint()))
+		buf = append(buf, labels.Label{Name: name, Value: value})
+	}
+	if db.err() != nil {
+		return nil, errors.Wrap(db.err(), "decoding structured metadata")
+	}
+	return buf, nil
+}
+
+func uvarintSize(x uint64) int {
+	size := 1
+	for x >= 0x80 {
+		x >>= 7
+		size++
+	}
+	return size
 }
 
 // NewMemChunk returns a new in-mem chunk.
+// The chunk format is derived from the head block format.
 func NewMemChunk(enc Encoding, head HeadBlockFmt, blockSize, targetSize int) *MemChunk {
+	return newMemChunkWithFormat(head.ChunkFormat(), enc, head, blockSize, targetSize)
+}
+
+// NewMemChunkWithDictionary returns a new in-mem chunk compressed with the EncZstdDict encoding and the dictionary.
+// The dictionary must have been published with PublishDictionary for the tenant of the chunk for it to be read back from its bytes.
+// A nil dictionary compresses the chunk without one.
+func NewMemChunkWithDictionary(dict *Dictionary, head HeadBlockFmt, blockSize, targetSize int) *MemChunk {
+	c := NewMemChunk(EncZstdDict, head, blockSize, targetSize)
+	c.dict = dict
+	return c
+}
+
+// WithParsedColumns makes the blocks of the chunk also store the most common fields parsed from their
+// json or logfmt lines as typed columns, which queries read instead of parsing the lines when they can.
+// It must be called before any entry is appended to the chunk.
+func (c *MemChunk) WithParsedColumns() *MemChunk {
+	c.format = chunkFormatV5
+	return c
+}
+
+func newMemChunkWithFormat(format byte, enc Encoding, head HeadBlockFmt, blockSize, targetSize int) *MemChunk {
 	return &MemChunk{
 		blockSize:  blockSize,  // The blockSize in bytes.
 		targetSize: targetSize, // Desired chunk size in compressed bytes
 		blocks:     []block{},
 
-		format: DefaultChunkFormat,
+		format: format,
 		head:   head.NewBlock(),
 
 		encoding: enc,
@@ -366,7 +493,7 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	switch version {
 	case chunkFormatV1:
 		bc.encoding = EncGZIP
-	case chunkFormatV2, chunkFormatV3:
+	case chunkFormatV2, chunkFormatV3, chunkFormatV4, chunkFormatV5:
 		// format v2+ has a byte for block encoding.
 		enc := Encoding(db.byte())
 		if db.err() != nil {
@@ -377,6 +504,13 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 		return nil, errors.Errorf("invalid version %d", version)
 	}
 
+	if bc.encoding == EncZstdDict {
+		bc.dictID = db.be32()
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "verifying dictionary")
+		}
+	}
+
 	metasOffset := binary.BigEndian.Uint64(b[len(b)-8:])
 	mb := b[metasOffset : len(b)-(8+4)] // storing the metasOffset + checksum of meta
 	db = decbuf{b: mb}
@@ -401,7 +535,7 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 
 		// Read offset and length.
 		blk.offset = db.uvarint()
-		if version == chunkFormatV3 {
+		if version >= chunkFormatV3 {
 			blk.uncompressedSize = db.uvarint()
 		}
 		l := db.uvarint()
@@ -451,6 +585,9 @@ func (c *MemChunk) BytesSize() int {
 	if c.format > chunkFormatV1 {
 		size++ // chunk format v2+ has a byte for encoding.
 	}
+	if c.encoding == EncZstdDict {
+		size += 4 // dictionary ID
+	}
 
 	// blocks
 	for _, b := range c.blocks {
@@ -460,7 +597,7 @@ func (c *MemChunk) BytesSize() int {
 		size += binary.MaxVarintLen64 // mint
 		size += binary.MaxVarintLen64 // maxt
 		size += binary.MaxVarintLen32 // offset
-		if c.format == chunkFormatV3 {
+		if c.format >= chunkFormatV3 {
 			size += binary.MaxVarintLen32 // uncompressed size
 		}
 		size += binary.MaxVarintLen32 // len(b)
@@ -499,6 +636,9 @@ func (c *MemChunk) WriteTo(w io.Writer) (int64, error) {
 		// chunk format v2+ has a byte for encoding.
 		eb.putByte(byte(c.encoding))
 	}
+	if c.encoding == EncZstdDict {
+		eb.putBE32(c.dictionaryID())
+	}
 
 	n, err := w.Write(eb.get())
 	if err != nil {
@@ -534,7 +674,7 @@ func (c *MemChunk) WriteTo(w io.Writer) (int64, error) {
 		eb.putVarint64(b.mint)
 		eb.putVarint64(b.maxt)
 		eb.putUvarint(b.offset)
-		if c.format == chunkFormatV3 {
+		if c.format >= chunkFormatV3 {
 			eb.putUvarint(b.uncompressedSize)
 		}
 		eb.putUvarint(len(b.b))
@@ -589,6 +729,10 @@ func MemchunkFromCheckpoint(chk, head []byte, desired HeadBlockFmt, blockSize in
 	if err != nil {
 		return nil, err
 	}
+	// Keep the structured metadata of the head when its blocks can hold it.
+	if mc.format >= chunkFormatV4 && desired == UnorderedHeadBlockFmt {
+		desired = UnorderedWithStructuredMetadataHeadBlockFmt
+	}
 	h, err := HeadFromCheckpoint(head, desired)
 	if err != nil {
 		return nil, err
@@ -604,6 +748,45 @@ func (c *MemChunk) Encoding() Encoding {
 	return c.encoding
 }
 
+// Dictionary returns the dictionary the chunk is compressed with, nil if there is none or if it isn't loaded.
+func (c *MemChunk) Dictionary() *Dictionary {
+	return c.dict
+}
+
+// SetTenant sets the tenant of a chunk read from its bytes, whose dictionaries are used to read its blocks.
+func (c *MemChunk) SetTenant(tenant string) {
+	c.tenant = tenant
+}
+
+// LoadDictionary loads the dictionary of a chunk of the tenant read from its bytes.
+// It must be loaded before appending entries to the chunk.
+func (c *MemChunk) LoadDictionary(ctx context.Context, tenant string) error {
+	c.tenant = tenant
+	if c.dict != nil || c.dictID == 0 {
+		return nil
+	}
+	dict, err := LoadDictionary(ctx, tenant, c.dictID)
+	if err != nil {
+		return errors.Wrapf(err, "loading dictionary %08x", c.dictID)
+	}
+	c.dict = dict
+	return nil
+}
+
+func (c *MemChunk) dictionaryID() uint32 {
+	if c.dict == nil {
+		return c.dictID
+	}
+	return c.dict.ID
+}
+
+func (c *MemChunk) writerPool() WriterPool {
+	if c.dict != nil {
+		return c.dict
+	}
+	return getWriterPool(c.encoding)
+}
+
 // Size implements Chunk.
 func (c *MemChunk) Size() int {
 	ne := 0
@@ -627,6 +810,9 @@ func (c *MemChunk) SpaceFor(e *logproto.Entry) bool {
 		// This is looking to see if the uncompressed lines will fit which is not
 		// a great check, but it will guarantee we are always under the target size
 		newHBSize := c.head.UncompressedSize() + len(e.Line)
+		if c.format >= chunkFormatV4 {
+			newHBSize += structuredMetadataSize(logproto.FromStructuredMetadataToLabels(e.StructuredMetadata))
+		}
 		return (c.cutBlockSize + newHBSize) < c.targetSize
 	}
 	// if targetSize is not defined, default to the original behavior of fixed blocks per chunk
@@ -674,7 +860,13 @@ func (c *MemChunk) Append(entry *logproto.Entry) error {
 		return ErrOutOfOrder
 	}
 
-	if err := c.head.Append(entryTimestamp, entry.Line); err != nil {
+	// Structured metadata is only kept by chunks whose blocks can hold it.
+	var structuredMetadata labels.Labels
+	if c.format >= chunkFormatV4 {
+		structuredMetadata = logproto.FromStructuredMetadataToLabels(entry.StructuredMetadata)
+	}
+
+	if err := c.head.Append(entryTimestamp, entry.Line, structuredMetadata); err != nil {
 		return err
 	}
 
@@ -739,8 +931,11 @@ func (c *MemChunk) cut() error {
 	if c.head.IsEmpty() {
 		return nil
 	}
+	if c.dict == nil && c.dictID != 0 {
+		return errors.Errorf("dictionary %08x of the chunk is not loaded", c.dictID)
+	}
 
-	b, err := c.head.Serialise(getWriterPool(c.encoding))
+	b, err := c.head.Serialise(c.writerPool(), c.format)
 	if err != nil {
 		return err
 	}
@@ -797,7 +992,7 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 		}
 		lastMax = b.maxt
 
-		blockItrs = append(blockItrs, encBlock{c.encoding, b}.Iterator(ctx, pipeline))
+		blockItrs = append(blockItrs, c.encBlock(b).Iterator(ctx, pipeline))
 	}
 
 	if !c.head.IsEmpty() {
@@ -871,7 +1066,7 @@ func (c *MemChunk) SampleIterator(ctx context.Context, from, through time.Time,
 			ordered = false
 		}
 		lastMax = b.maxt
-		its = append(its, encBlock{c.encoding, b}.SampleIterator(ctx, extractor))
+		its = append(its, c.encBlock(b).SampleIterator(ctx, extractor))
 	}
 
 	if !c.head.IsEmpty() {
@@ -903,7 +1098,7 @@ func (c *MemChunk) Blocks(mintT, maxtT time.Time) []Block {
 
 	for _, b := range c.blocks {
 		if maxt >= b.mint && b.maxt >= mint {
-			blocks = append(blocks, encBlock{c.encoding, b})
+			blocks = append(blocks, c.encBlock(b))
 		}
 	}
 	return blocks
@@ -921,13 +1116,15 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 	// as close as possible, respect the block/target sizes specified. However,
 	// if the blockSize is not set, use reasonable defaults.
 	if c.blockSize > 0 {
-		newChunk = NewMemChunk(c.Encoding(), c.headFmt, c.blockSize, c.targetSize)
+		newChunk = newMemChunkWithFormat(c.format, c.Encoding(), c.headFmt, c.blockSize, c.targetSize)
 	} else {
 		// Using defaultBlockSize for target block size.
 		// The alternative here could be going over all the blocks and using the size of the largest block as target block size but I(Sandeep) feel that it is not worth the complexity.
 		// For target chunk size I am using compressed size of original chunk since the newChunk should anyways be lower in size than that.
-		newChunk = NewMemChunk(c.Encoding(), c.headFmt, defaultBlockSize, c.CompressedSize())
+		newChunk = newMemChunkWithFormat(c.format, c.Encoding(), c.headFmt, defaultBlockSize, c.CompressedSize())
 	}
+	// The new chunk of a chunk read from its bytes is compressed without dictionary if it isn't loaded.
+	newChunk.dict = c.dict
 
 	for itr.Next() {
 		entry := itr.Entry()
@@ -955,22 +1152,40 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 // then allows us to bind a decoding context to a block when requested, but otherwise helps reduce the
 // chances of chunk<>block encoding drift in the codebase as the latter is parameterized by the former.
 type encBlock struct {
-	enc Encoding
+	enc    Encoding
+	format byte
+	dict   *Dictionary
+	dictID uint32
+	tenant string
 	block
 }
 
+func (c *MemChunk) encBlock(b block) encBlock {
+	return encBlock{enc: c.encoding, format: c.format, dict: c.dict, dictID: c.dictID, tenant: c.tenant, block: b}
+}
+
+func (b encBlock) readerPool(ctx context.Context) ReaderPool {
+	if b.dict != nil {
+		return b.dict
+	}
+	if b.dictID != 0 {
+		return &dictionaryReaderPool{ctx: ctx, tenant: b.tenant, id: b.dictID}
+	}
+	return getReaderPool(b.enc)
+}
+
 func (b encBlock) Iterator(ctx context.Context, pipeline log.StreamPipeline) iter.EntryIterator {
 	if len(b.b) == 0 {
 		return iter.NoopIterator
 	}
-	return newEntryIterator(ctx, getReaderPool(b.enc), b.b, pipeline)
+	return newEntryIterator(ctx, b.readerPool(ctx), b.b, b.format, pipeline)
 }
 
 func (b encBlock) SampleIterator(ctx context.Context, extractor log.StreamSampleExtractor) iter.SampleIterator {
 	if len(b.b) == 0 {
 		return iter.NoopIterator
 	}
-	return newSampleIterator(ctx, getReaderPool(b.enc), b.b, extractor)
+	return newSampleIterator(ctx, b.readerPool(ctx), b.b, b.format, extractor)
 }
 
 func (b block) Offset() int {
@@ -1009,7 +1224,7 @@ func (hb *headBlock) Iterator(ctx context.Context, direction logproto.Direction,
 			return
 		}
 		stats.AddHeadChunkBytes(int64(len(e.s)))
-		newLine, parsedLbs, matches := pipeline.ProcessString(e.t, e.s)
+		newLine, parsedLbs, matches := pipeline.ProcessString(e.t, e.s, e.structuredMetadata...)
 		if !matches {
 			return
 		}
@@ -1024,8 +1239,9 @@ func (hb *headBlock) Iterator(ctx context.Context, direction logproto.Direction,
 			streams[labels] = stream
 		}
 		stream.Entries = append(stream.Entries, logproto.Entry{
-			Timestamp: time.Unix(0, e.t),
-			Line:      newLine,
+			Timestamp:          time.Unix(0, e.t),
+			Line:               newLine,
+			StructuredMetadata: logproto.FromLabelsToStructuredMetadata(e.structuredMetadata),
 		})
 	}
 
@@ -1060,7 +1276,7 @@ func (hb *headBlock) SampleIterator(ctx context.Context, mint, maxt int64, extra
 
 	for _, e := range hb.entries {
 		stats.AddHeadChunkBytes(int64(len(e.s)))
-		value, parsedLabels, ok := extractor.ProcessString(e.t, e.s)
+		value, parsedLabels, ok := extractor.ProcessString(e.t, e.s, e.structuredMetadata...)
 		if !ok {
 			continue
 		}
@@ -1112,6 +1328,7 @@ func unsafeGetBytes(s string) []byte {
 type bufferedIterator struct {
 	origBytes []byte
 	stats     *stats.Context
+	format    byte
 
 	reader io.Reader
 	pool   ReaderPool
@@ -1125,14 +1342,23 @@ type bufferedIterator struct {
 	currLine []byte // the current line, this is the same as the buffer but sliced the line size.
 	currTs   int64
 
+	metaBuf                []byte        // The buffer for the structured metadata of a single entry.
+	currStructuredMetadata labels.Labels // the structured metadata of the current entry.
+
+	// decodeColumns is set when the columns of the block are used to process its entries.
+	decodeColumns bool
+	columnsRead   bool
+	columns       *blockColumns // the columns of the block, positioned at the current entry.
+
 	closed bool
 }
 
-func newBufferedIterator(ctx context.Context, pool ReaderPool, b []byte) *bufferedIterator {
+func newBufferedIterator(ctx context.Context, pool ReaderPool, b []byte, format byte) *bufferedIterator {
 	stats := stats.FromContext(ctx)
 	stats.AddCompressedBytes(int64(len(b)))
 	return &bufferedIterator{
 		stats:     stats,
+		format:    format,
 		origBytes: b,
 		reader:    nil, // will be initialized later
 		pool:      pool,
@@ -1154,20 +1380,145 @@ func (si *bufferedIterator) Next() bool {
 		}
 	}
 
+	if si.format >= chunkFormatV5 && !si.columnsRead {
+		si.columnsRead = true
+		if !si.readColumns() {
+			si.Close()
+			return false
+		}
+	}
+
 	ts, line, ok := si.moveNext()
 	if !ok {
 		si.Close()
 		return false
 	}
+	if si.columns != nil {
+		si.columns.next()
+	}
 	// we decode always the line length and ts as varint
 	si.stats.AddDecompressedBytes(int64(len(line)) + 2*binary.MaxVarintLen64)
 	si.stats.AddDecompressedLines(1)
 
+	si.currStructuredMetadata = si.currStructuredMetadata[:0]
+	if si.format >= chunkFormatV4 {
+		metadata, ok := si.moveNextStructuredMetadata()
+		if !ok {
+			si.Close()
+			return false
+		}
+		si.currStructuredMetadata = metadata
+	}
+
 	si.currTs = ts
 	si.currLine = line
 	return true
 }
 
+// readColumns reads the columns section preceding the entries of the block.
+// The columns are only decoded when they are used to process the entries.
+func (si *bufferedIterator) readColumns() bool {
+	size, ok := si.readUvarint()
+	if !ok {
+		return false
+	}
+	if int(size) > cap(si.metaBuf) {
+		si.metaBuf = make([]byte, size)
+	}
+	si.metaBuf = si.metaBuf[:size]
+	if !si.readFull(si.metaBuf) {
+		return false
+	}
+	si.stats.AddDecompressedBytes(int64(size) + binary.MaxVarintLen64)
+	if !si.decodeColumns {
+		return true
+	}
+
+	columns, err := decodeBlockColumns(si.metaBuf)
+	if err != nil {
+		si.err = err
+		return false
+	}
+	si.columns = columns
+	return true
+}
+
+// closeOnColumnsErr closes the iterator if the columns of the block couldn't be decoded.
+func (si *bufferedIterator) closeOnColumnsErr() bool {
+	if si.columns.err == nil {
+		return false
+	}
+	si.err = si.columns.err
+	si.Close()
+	return true
+}
+
+// moveNextStructuredMetadata reads the length prefixed structured metadata following the current line.
+func (si *bufferedIterator) moveNextStructuredMetadata() (labels.Labels, bool) {
+	size, ok := si.readUvarint()
+	if !ok {
+		return nil, false
+	}
+	if int(size) > cap(si.metaBuf) {
+		si.metaBuf = make([]byte, size)
+	}
+	si.metaBuf = si.metaBuf[:size]
+	if !si.readFull(si.metaBuf) {
+		return nil, false
+	}
+	si.stats.AddDecompressedBytes(int64(size) + binary.MaxVarintLen64)
+
+	metadata, err := decodeStructuredMetadata(si.metaBuf, si.currStructuredMetadata[:0])
+	if err != nil {
+		si.err = err
+		return nil, false
+	}
+	return metadata, true
+}
+
+// readUvarint reads a uvarint from what is left in the read buffer followed by the reader.
+func (si *bufferedIterator) readUvarint() (uint64, bool) {
+	for {
+		v, w := binary.Uvarint(si.readBuf[:si.readBufValid])
+		if w > 0 {
+			si.readBufValid = copy(si.readBuf[:], si.readBuf[w:si.readBufValid])
+			return v, true
+		}
+		if w < 0 || si.readBufValid == len(si.readBuf) {
+			si.err = fmt.Errorf("invalid data in chunk")
+			return 0, false
+		}
+		n, err := si.reader.Read(si.readBuf[si.readBufValid:])
+		si.readBufValid += n
+		if err != nil && n == 0 {
+			if err == io.EOF {
+				err = fmt.Errorf("invalid data in chunk")
+			}
+			si.err = err
+			return 0, false
+		}
+	}
+}
+
+// readFull fills buf with what is left in the read buffer followed by the reader.
+func (si *bufferedIterator) readFull(buf []byte) bool {
+	n := copy(buf, si.readBuf[:si.readBufValid])
+	si.readBufValid = copy(si.readBuf[:], si.readBuf[n:si.readBufValid])
+	for n < len(buf) {
+		r, err := si.reader.Read(buf[n:])
+		n += r
+		if err != nil {
+			// We might get EOF after reading enough bytes to fill the buffer, which is OK.
+			if err == io.EOF && r != 0 {
+				continue
+			}
+			si.err = err
+			return false
+		}
+	}
+	return true
+}
+
 // moveNext moves the buffer to the next entry
 func (si *bufferedIterator) moveNext() (int64, []byte, bool) {
 	var ts int64
@@ -1254,19 +1605,24 @@ func (si *bufferedIterator) close() {
 		BytesBufferPool.Put(si.buf)
 		si.buf = nil
 	}
+	si.metaBuf = nil
+	si.columns = nil
 	si.origBytes = nil
 }
 
-func newEntryIterator(ctx context.Context, pool ReaderPool, b []byte, pipeline log.StreamPipeline) iter.EntryIterator {
-	return &entryBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b),
+func newEntryIterator(ctx context.Context, pool ReaderPool, b []byte, format byte, pipeline log.StreamPipeline) iter.EntryIterator {
+	it := &entryBufferedIterator{
+		bufferedIterator: newBufferedIterator(ctx, pool, b, format),
 		pipeline:         pipeline,
 	}
+	it.columnsPipeline, it.decodeColumns = pipeline.(log.ColumnsStreamPipeline)
+	return it
 }
 
 type entryBufferedIterator struct {
 	*bufferedIterator
-	pipeline log.StreamPipeline
+	pipeline        log.StreamPipeline
+	columnsPipeline log.ColumnsStreamPipeline
 
 	cur        logproto.Entry
 	currLabels log.LabelsResult
@@ -1282,30 +1638,48 @@ func (e *entryBufferedIterator) StreamHash() uint64 { return e.pipeline.BaseLabe
 
 func (e *entryBufferedIterator) Next() bool {
 	for e.bufferedIterator.Next() {
-		newLine, lbs, matches := e.pipeline.Process(e.currTs, e.currLine)
+		var (
+			newLine []byte
+			lbs     log.LabelsResult
+			matches bool
+		)
+		if e.columns != nil {
+			newLine, lbs, matches = e.columnsPipeline.ProcessWithColumns(e.currTs, e.currLine, e.columns, e.currStructuredMetadata...)
+			if e.closeOnColumnsErr() {
+				return false
+			}
+		} else {
+			newLine, lbs, matches = e.pipeline.Process(e.currTs, e.currLine, e.currStructuredMetadata...)
+		}
 		if !matches {
 			continue
 		}
 		e.cur.Timestamp = time.Unix(0, e.currTs)
 		e.cur.Line = string(newLine)
+		e.cur.StructuredMetadata = nil
+		if len(e.currStructuredMetadata) > 0 {
+			e.cur.StructuredMetadata = logproto.FromLabelsToStructuredMetadata(e.currStructuredMetadata.Copy())
+		}
 		e.currLabels = lbs
 		return true
 	}
 	return false
 }
 
-func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, extractor log.StreamSampleExtractor) iter.SampleIterator {
+func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, format byte, extractor log.StreamSampleExtractor) iter.SampleIterator {
 	it := &sampleBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, format),
 		extractor:        extractor,
 	}
+	it.columnsExtractor, it.decodeColumns = extractor.(log.ColumnsStreamSampleExtractor)
 	return it
 }
 
 type sampleBufferedIterator struct {
 	*bufferedIterator
 
-	extractor log.StreamSampleExtractor
+	extractor        log.StreamSampleExtractor
+	columnsExtractor log.ColumnsStreamSampleExtractor
 
 	cur        logproto.Sample
 	currLabels log.LabelsResult
@@ -1313,7 +1687,19 @@ type sampleBufferedIterator struct {
 
 func (e *sampleBufferedIterator) Next() bool {
 	for e.bufferedIterator.Next() {
-		val, labels, ok := e.extractor.Process(e.currTs, e.currLine)
+		var (
+			val    float64
+			labels log.LabelsResult
+			ok     bool
+		)
+		if e.columns != nil {
+			val, labels, ok = e.columnsExtractor.ProcessWithColumns(e.currTs, e.currLine, e.columns, e.currStructuredMetadata...)
+			if e.closeOnColumnsErr() {
+				return false
+			}
+		} else {
+			val, labels, ok = e.extractor.Process(e.currTs, e.currLine, e.currStructuredMetadata...)
+		}
 		if !ok {
 			continue
 		}
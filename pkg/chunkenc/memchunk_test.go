@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
@@ -37,6 +38,7 @@ var testEncoding = []Encoding{
 	EncSnappy,
 	EncFlate,
 	EncZstd,
+	EncZstdDict,
 }
 
 var (
@@ -714,10 +716,10 @@ func BenchmarkWrite(b *testing.B) {
 type nomatchPipeline struct{}
 
 func (nomatchPipeline) BaseLabels() log.LabelsResult { return log.EmptyLabelsResult }
-func (nomatchPipeline) Process(_ int64, line []byte) ([]byte, log.LabelsResult, bool) {
+func (nomatchPipeline) Process(_ int64, line []byte, _ ...labels.Label) ([]byte, log.LabelsResult, bool) {
 	return line, nil, false
 }
-func (nomatchPipeline) ProcessString(_ int64, line string) (string, log.LabelsResult, bool) {
+func (nomatchPipeline) ProcessString(_ int64, line string, _ ...labels.Label) (string, log.LabelsResult, bool) {
 	return line, nil, false
 }
 
@@ -830,7 +832,7 @@ func BenchmarkHeadBlockIterator(b *testing.B) {
 			h := headBlock{}
 
 			for i := 0; i < j; i++ {
-				if err := h.Append(int64(i), "this is the append string"); err != nil {
+				if err := h.Append(int64(i), "this is the append string", nil); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -854,7 +856,7 @@ func BenchmarkHeadBlockSampleIterator(b *testing.B) {
 			h := headBlock{}
 
 			for i := 0; i < j; i++ {
-				if err := h.Append(int64(i), "this is the append string"); err != nil {
+				if err := h.Append(int64(i), "this is the append string", nil); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -1339,3 +1341,82 @@ func buildFilterableTestMemChunk(t *testing.T, from, through time.Time, matching
 
 	return chk
 }
+
+func TestMemChunk_StructuredMetadata(t *testing.T) {
+	for _, enc := range testEncoding {
+		enc := enc
+		t.Run(enc.String(), func(t *testing.T) {
+			t.Parallel()
+
+			c := NewMemChunk(enc, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+			require.Equal(t, chunkFormatV4, c.format)
+
+			for i := 0; i < 10; i++ {
+				require.NoError(t, c.Append(&logproto.Entry{
+					Timestamp: time.Unix(0, int64(i)),
+					Line:      fmt.Sprintf("line %d", i),
+					StructuredMetadata: logproto.FromLabelsToStructuredMetadata(labels.Labels{
+						{Name: "traceID", Value: strconv.Itoa(i % 2)},
+					}),
+				}))
+				// cut a block half way through to have both blocks and a head block.
+				if i == 4 {
+					require.NoError(t, c.cut())
+				}
+			}
+
+			assertEntries := func(t *testing.T, c Chunk) {
+				expr, err := syntax.ParseLogSelector(`{app="foo"} | traceID="1"`, true)
+				require.NoError(t, err)
+				p, err := expr.Pipeline()
+				require.NoError(t, err)
+
+				it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 10), logproto.FORWARD, p.ForStream(labels.Labels{{Name: "app", Value: "foo"}}))
+				require.NoError(t, err)
+				var lines []string
+				for it.Next() {
+					require.Equal(t, push.LabelsAdapter{{Name: "traceID", Value: "1"}}, it.Entry().StructuredMetadata)
+					require.Equal(t, `{app="foo", traceID="1"}`, it.Labels())
+					lines = append(lines, it.Entry().Line)
+				}
+				require.NoError(t, it.Close())
+				require.Equal(t, []string{"line 1", "line 3", "line 5", "line 7", "line 9"}, lines)
+			}
+
+			assertEntries(t, c)
+
+			// the structured metadata survives a checkpoint of the head block.
+			var chk, head bytes.Buffer
+			require.NoError(t, c.SerializeForCheckpointTo(&chk, &head))
+			cpy, err := MemchunkFromCheckpoint(chk.Bytes(), head.Bytes(), UnorderedHeadBlockFmt, testBlockSize, testTargetSize)
+			require.NoError(t, err)
+			require.Equal(t, UnorderedWithStructuredMetadataHeadBlockFmt, cpy.head.Format())
+			assertEntries(t, cpy)
+
+			// and a round trip through the encoded chunk.
+			require.NoError(t, c.Close())
+			b, err := c.Bytes()
+			require.NoError(t, err)
+			r, err := NewByteChunk(b, testBlockSize, testTargetSize)
+			require.NoError(t, err)
+			assertEntries(t, r)
+		})
+	}
+}
+
+func TestMemChunk_StructuredMetadataDroppedBeforeV4(t *testing.T) {
+	c := NewMemChunk(EncSnappy, UnorderedHeadBlockFmt, testBlockSize, testTargetSize)
+	require.Equal(t, chunkFormatV3, c.format)
+	require.NoError(t, c.Append(&logproto.Entry{
+		Timestamp:          time.Unix(0, 1),
+		Line:               "line",
+		StructuredMetadata: push.LabelsAdapter{{Name: "traceID", Value: "1"}},
+	}))
+	require.NoError(t, c.cut())
+
+	it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 2), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.Labels{}))
+	require.NoError(t, err)
+	require.True(t, it.Next())
+	require.Empty(t, it.Entry().StructuredMetadata)
+	require.False(t, it.Next())
+}
@@ -98,6 +98,10 @@ func getReaderPool(enc Encoding) ReaderPool {
 		return &Flate
 	case EncZstd:
 		return &Zstd
+	case EncZstdDict:
+		// Chunks cut before a dictionary was available are compressed without one.
+		// The pool of the dictionary is used otherwise.
+		return &Zstd
 	default:
 		panic("unknown encoding")
 	}
@@ -28,13 +28,13 @@ type HeadBlock interface {
 	CheckpointBytes(b []byte) ([]byte, error)
 	CheckpointSize() int
 	LoadBytes(b []byte) error
-	Serialise(pool WriterPool) ([]byte, error)
+	Serialise(pool WriterPool, format byte) ([]byte, error)
 	Reset()
 	Bounds() (mint, maxt int64)
 	Entries() int
 	UncompressedSize() int
 	Convert(HeadBlockFmt) (HeadBlock, error)
-	Append(int64, string) error
+	Append(int64, string, labels.Labels) error
 	Iterator(
 		ctx context.Context,
 		direction logproto.Direction,
@@ -52,6 +52,7 @@ type HeadBlock interface {
 }
 
 type unorderedHeadBlock struct {
+	format HeadBlockFmt
 	// Opted for range tree over skiplist for space reduction.
 	// Inserts: O(log(n))
 	// Scans: (O(k+log(n))) where k=num_scanned_entries & n=total_entries
@@ -62,13 +63,14 @@ type unorderedHeadBlock struct {
 	mint, maxt int64 // upper and lower bounds
 }
 
-func newUnorderedHeadBlock() *unorderedHeadBlock {
+func newUnorderedHeadBlock(format HeadBlockFmt) *unorderedHeadBlock {
 	return &unorderedHeadBlock{
-		rt: rangetree.New(1),
+		format: format,
+		rt:     rangetree.New(1),
 	}
 }
 
-func (hb *unorderedHeadBlock) Format() HeadBlockFmt { return UnorderedHeadBlockFmt }
+func (hb *unorderedHeadBlock) Format() HeadBlockFmt { return hb.format }
 
 func (hb *unorderedHeadBlock) IsEmpty() bool {
 	return hb.size == 0
@@ -87,21 +89,31 @@ func (hb *unorderedHeadBlock) UncompressedSize() int {
 }
 
 func (hb *unorderedHeadBlock) Reset() {
-	x := newUnorderedHeadBlock()
+	x := newUnorderedHeadBlock(hb.format)
 	*hb = *x
 }
 
 // collection of entries belonging to the same nanosecond
 type nsEntries struct {
 	ts      int64
-	entries []string
+	entries []nsEntry
+}
+
+type nsEntry struct {
+	line               string
+	structuredMetadata labels.Labels
 }
 
 func (e *nsEntries) ValueAtDimension(_ uint64) int64 {
 	return e.ts
 }
 
-func (hb *unorderedHeadBlock) Append(ts int64, line string) error {
+func (hb *unorderedHeadBlock) Append(ts int64, line string, structuredMetadata labels.Labels) error {
+	if hb.format < UnorderedWithStructuredMetadataHeadBlockFmt {
+		// structured metadata is ignored by head blocks which can't persist it.
+		structuredMetadata = nil
+	}
+
 	// This is an allocation hack. The rangetree lib does not
 	// support the ability to pass a "mutate" function during an insert
 	// and instead will displace any existing entry at the specified timestamp.
@@ -120,14 +132,14 @@ func (hb *unorderedHeadBlock) Append(ts int64, line string) error {
 		// entries at the same time with the same content, iterate through any existing
 		// entries and ignore the line if we already have an entry with the same content
 		for _, et := range displaced[0].(*nsEntries).entries {
-			if et == line {
+			if et.line == line {
 				e.entries = displaced[0].(*nsEntries).entries
 				return nil
 			}
 		}
-		e.entries = append(displaced[0].(*nsEntries).entries, line)
+		e.entries = append(displaced[0].(*nsEntries).entries, nsEntry{line, structuredMetadata})
 	} else {
-		e.entries = []string{line}
+		e.entries = []nsEntry{{line, structuredMetadata}}
 	}
 
 	// Update hb metdata
@@ -139,7 +151,7 @@ func (hb *unorderedHeadBlock) Append(ts int64, line string) error {
 		hb.maxt = ts
 	}
 
-	hb.size += len(line)
+	hb.size += len(line) + structuredMetadataSize(structuredMetadata)
 	hb.lines++
 
 	return nil
@@ -162,7 +174,7 @@ func (hb *unorderedHeadBlock) forEntries(
 	direction logproto.Direction,
 	mint,
 	maxt int64,
-	entryFn func(int64, string) error, // returning an error exits early
+	entryFn func(int64, string, labels.Labels) error, // returning an error exits early
 ) (err error) {
 	if hb.IsEmpty() || (maxt < hb.mint || hb.maxt < mint) {
 		return
@@ -191,9 +203,9 @@ func (hb *unorderedHeadBlock) forEntries(
 		}
 
 		for ; i < len(es.entries) && i >= 0; next() {
-			line := es.entries[i]
-			chunkStats.AddHeadChunkBytes(int64(len(line)))
-			err = entryFn(es.ts, line)
+			e := es.entries[i]
+			chunkStats.AddHeadChunkBytes(int64(len(e.line)))
+			err = entryFn(es.ts, e.line, e.structuredMetadata)
 
 		}
 	}
@@ -235,8 +247,8 @@ func (hb *unorderedHeadBlock) Iterator(
 		direction,
 		mint,
 		maxt,
-		func(ts int64, line string) error {
-			newLine, parsedLbs, matches := pipeline.ProcessString(ts, line)
+		func(ts int64, line string, structuredMetadata labels.Labels) error {
+			newLine, parsedLbs, matches := pipeline.ProcessString(ts, line, structuredMetadata...)
 			if !matches {
 				return nil
 			}
@@ -253,8 +265,9 @@ func (hb *unorderedHeadBlock) Iterator(
 			}
 
 			stream.Entries = append(stream.Entries, logproto.Entry{
-				Timestamp: time.Unix(0, ts),
-				Line:      newLine,
+				Timestamp:          time.Unix(0, ts),
+				Line:               newLine,
+				StructuredMetadata: logproto.FromLabelsToStructuredMetadata(structuredMetadata),
 			})
 			return nil
 		},
@@ -284,8 +297,8 @@ func (hb *unorderedHeadBlock) SampleIterator(
 		logproto.FORWARD,
 		mint,
 		maxt,
-		func(ts int64, line string) error {
-			value, parsedLabels, ok := extractor.ProcessString(ts, line)
+		func(ts int64, line string, structuredMetadata labels.Labels) error {
+			value, parsedLabels, ok := extractor.ProcessString(ts, line, structuredMetadata...)
 			if !ok {
 				return nil
 			}
@@ -329,7 +342,7 @@ func (hb *unorderedHeadBlock) SampleIterator(
 
 // nolint:unused
 // serialise is used in creating an ordered, compressed block from an unorderedHeadBlock
-func (hb *unorderedHeadBlock) Serialise(pool WriterPool) ([]byte, error) {
+func (hb *unorderedHeadBlock) Serialise(pool WriterPool, format byte) ([]byte, error) {
 	inBuf := serializeBytesBufferPool.Get().(*bytes.Buffer)
 	defer func() {
 		inBuf.Reset()
@@ -341,23 +354,26 @@ func (hb *unorderedHeadBlock) Serialise(pool WriterPool) ([]byte, error) {
 	compressedWriter := pool.GetWriter(outBuf)
 	defer pool.PutWriter(compressedWriter)
 
+	var lines []string
 	_ = hb.forEntries(
 		context.Background(),
 		logproto.FORWARD,
 		0,
 		math.MaxInt64,
-		func(ts int64, line string) error {
-			n := binary.PutVarint(encBuf, ts)
-			inBuf.Write(encBuf[:n])
-
-			n = binary.PutUvarint(encBuf, uint64(len(line)))
-			inBuf.Write(encBuf[:n])
-
-			inBuf.WriteString(line)
+		func(ts int64, line string, structuredMetadata labels.Labels) error {
+			writeBlockEntry(inBuf, encBuf, format, ts, line, structuredMetadata)
+			if format >= chunkFormatV5 {
+				lines = append(lines, line)
+			}
 			return nil
 		},
 	)
 
+	if format >= chunkFormatV5 {
+		if err := writeColumnsTo(compressedWriter, lines); err != nil {
+			return nil, err
+		}
+	}
 	if _, err := compressedWriter.Write(inBuf.Bytes()); err != nil {
 		return nil, errors.Wrap(err, "appending entry")
 	}
@@ -369,7 +385,7 @@ func (hb *unorderedHeadBlock) Serialise(pool WriterPool) ([]byte, error) {
 }
 
 func (hb *unorderedHeadBlock) Convert(version HeadBlockFmt) (HeadBlock, error) {
-	if version > OrderedHeadBlockFmt {
+	if version == hb.format {
 		return hb, nil
 	}
 	out := version.NewBlock()
@@ -379,8 +395,8 @@ func (hb *unorderedHeadBlock) Convert(version HeadBlockFmt) (HeadBlock, error) {
 		logproto.FORWARD,
 		0,
 		math.MaxInt64,
-		func(ts int64, line string) error {
-			return out.Append(ts, line)
+		func(ts int64, line string, structuredMetadata labels.Labels) error {
+			return out.Append(ts, line, structuredMetadata)
 		},
 	)
 	return out, err
@@ -393,6 +409,10 @@ func (hb *unorderedHeadBlock) CheckpointSize() int {
 	size += binary.MaxVarintLen64 * 2                                  // mint,maxt
 	size += (binary.MaxVarintLen64 + binary.MaxVarintLen32) * hb.lines // ts + len of log line.
 	size += hb.size                                                    // uncompressed bytes of lines
+	if hb.format >= UnorderedWithStructuredMetadataHeadBlockFmt {
+		size += binary.MaxVarintLen32 * hb.lines     // number of structured metadata labels.
+		size += hb.lines * 2 * binary.MaxVarintLen32 // assume a single label per entry for the name and value lengths.
+	}
 	return size
 }
 
@@ -432,7 +452,7 @@ func (hb *unorderedHeadBlock) CheckpointTo(w io.Writer) error {
 		logproto.FORWARD,
 		0,
 		math.MaxInt64,
-		func(ts int64, line string) error {
+		func(ts int64, line string, structuredMetadata labels.Labels) error {
 			eb.putVarint64(ts)
 			eb.putUvarint(len(line))
 			_, err = w.Write(eb.get())
@@ -445,6 +465,22 @@ func (hb *unorderedHeadBlock) CheckpointTo(w io.Writer) error {
 			if err != nil {
 				return errors.Wrap(err, "write headblock entry line")
 			}
+
+			if hb.format < UnorderedWithStructuredMetadataHeadBlockFmt {
+				return nil
+			}
+			eb.putUvarint(len(structuredMetadata))
+			for _, l := range structuredMetadata {
+				eb.putUvarint(len(l.Name))
+				eb.putString(l.Name)
+				eb.putUvarint(len(l.Value))
+				eb.putString(l.Value)
+			}
+			_, err = w.Write(eb.get())
+			if err != nil {
+				return errors.Wrap(err, "write headBlock entry structured metadata")
+			}
+			eb.reset()
 			return nil
 		},
 	)
@@ -454,7 +490,7 @@ func (hb *unorderedHeadBlock) CheckpointTo(w io.Writer) error {
 
 func (hb *unorderedHeadBlock) LoadBytes(b []byte) error {
 	// ensure it's empty
-	*hb = *newUnorderedHeadBlock()
+	*hb = *newUnorderedHeadBlock(hb.format)
 
 	if len(b) < 1 {
 		return nil
@@ -467,9 +503,10 @@ func (hb *unorderedHeadBlock) LoadBytes(b []byte) error {
 		return errors.Wrap(db.err(), "verifying headblock header")
 	}
 
-	if version != UnorderedHeadBlockFmt.Byte() {
-		return errors.Errorf("incompatible headBlock version (%v), only V4 is currently supported", version)
+	if version != UnorderedHeadBlockFmt.Byte() && version != UnorderedWithStructuredMetadataHeadBlockFmt.Byte() {
+		return errors.Errorf("incompatible headBlock version (%v), only V4 and V5 are currently supported", version)
 	}
+	hb.format = HeadBlockFmt(version)
 
 	n := db.uvarint()
 
@@ -481,7 +518,21 @@ func (hb *unorderedHeadBlock) LoadBytes(b []byte) error {
 		ts := db.varint64()
 		lineLn := db.uvarint()
 		line := string(db.bytes(lineLn))
-		if err := hb.Append(ts, line); err != nil {
+
+		var structuredMetadata labels.Labels
+		if hb.format >= UnorderedWithStructuredMetadataHeadBlockFmt {
+			metadataLn := db.uvarint()
+			if metadataLn > 0 {
+				structuredMetadata = make(labels.Labels, 0, metadataLn)
+			}
+			for j := 0; j < metadataLn && db.err() == nil; j++ {
+				name := string(db.bytes(db.uvarint()))
+				value := string(db.bytes(db.uvarint()))
+				structuredMetadata = append(structuredMetadata, labels.Label{Name: name, Value: value})
+			}
+		}
+
+		if err := hb.Append(ts, line, structuredMetadata); err != nil {
 			return err
 		}
 	}
@@ -508,7 +559,7 @@ func HeadFromCheckpoint(b []byte, desired HeadBlockFmt) (HeadBlock, error) {
 		return nil, errors.Wrap(db.err(), "verifying headblock header")
 	}
 	format := HeadBlockFmt(version)
-	if format > UnorderedHeadBlockFmt {
+	if format > UnorderedWithStructuredMetadataHeadBlockFmt {
 		return nil, fmt.Errorf("unexpected head block version: %v", format)
 	}
 
@@ -30,9 +30,9 @@ func iterEq(t *testing.T, exp []entry, got iter.EntryIterator) {
 }
 
 func Test_forEntriesEarlyReturn(t *testing.T) {
-	hb := newUnorderedHeadBlock()
+	hb := newUnorderedHeadBlock(UnorderedHeadBlockFmt)
 	for i := 0; i < 10; i++ {
-		require.Nil(t, hb.Append(int64(i), fmt.Sprint(i)))
+		require.Nil(t, hb.Append(int64(i), fmt.Sprint(i), nil))
 	}
 
 	// forward
@@ -43,7 +43,7 @@ func Test_forEntriesEarlyReturn(t *testing.T) {
 		logproto.FORWARD,
 		0,
 		math.MaxInt64,
-		func(ts int64, line string) error {
+		func(ts int64, line string, _ labels.Labels) error {
 			forwardCt++
 			forwardStop = ts
 			if ts == 5 {
@@ -64,7 +64,7 @@ func Test_forEntriesEarlyReturn(t *testing.T) {
 		logproto.BACKWARD,
 		0,
 		math.MaxInt64,
-		func(ts int64, line string) error {
+		func(ts int64, line string, _ labels.Labels) error {
 			backwardCt++
 			backwardStop = ts
 			if ts == 5 {
@@ -87,85 +87,85 @@ func Test_Unordered_InsertRetrieval(t *testing.T) {
 		{
 			desc: "simple forward",
 			input: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil},
 			},
 			exp: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil},
 			},
 		},
 		{
 			desc: "simple backward",
 			input: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil},
 			},
 			exp: []entry{
-				{2, "c"}, {1, "b"}, {0, "a"},
+				{2, "c", nil}, {1, "b", nil}, {0, "a", nil},
 			},
 			dir: logproto.BACKWARD,
 		},
 		{
 			desc: "unordered forward",
 			input: []entry{
-				{1, "b"}, {0, "a"}, {2, "c"},
+				{1, "b", nil}, {0, "a", nil}, {2, "c", nil},
 			},
 			exp: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil},
 			},
 		},
 		{
 			desc: "unordered backward",
 			input: []entry{
-				{1, "b"}, {0, "a"}, {2, "c"},
+				{1, "b", nil}, {0, "a", nil}, {2, "c", nil},
 			},
 			exp: []entry{
-				{2, "c"}, {1, "b"}, {0, "a"},
+				{2, "c", nil}, {1, "b", nil}, {0, "a", nil},
 			},
 			dir: logproto.BACKWARD,
 		},
 		{
 			desc: "ts collision forward",
 			input: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil},
 			},
 			exp: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil},
 			},
 		},
 		{
 			desc: "ts collision backward",
 			input: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil},
 			},
 			exp: []entry{
-				{1, "c"}, {0, "b"}, {0, "a"},
+				{1, "c", nil}, {0, "b", nil}, {0, "a", nil},
 			},
 			dir: logproto.BACKWARD,
 		},
 		{
 			desc: "ts remove exact dupe forward",
 			input: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"}, {0, "b"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil}, {0, "b", nil},
 			},
 			exp: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil},
 			},
 			dir: logproto.FORWARD,
 		},
 		{
 			desc: "ts remove exact dupe backward",
 			input: []entry{
-				{0, "a"}, {0, "b"}, {1, "c"}, {0, "b"},
+				{0, "a", nil}, {0, "b", nil}, {1, "c", nil}, {0, "b", nil},
 			},
 			exp: []entry{
-				{1, "c"}, {0, "b"}, {0, "a"},
+				{1, "c", nil}, {0, "b", nil}, {0, "a", nil},
 			},
 			dir: logproto.BACKWARD,
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			hb := newUnorderedHeadBlock()
+			hb := newUnorderedHeadBlock(UnorderedHeadBlockFmt)
 			for _, e := range tc.input {
-				require.Nil(t, hb.Append(e.t, e.s))
+				require.Nil(t, hb.Append(e.t, e.s, nil))
 			}
 
 			itr := hb.Iterator(
@@ -194,10 +194,10 @@ func Test_UnorderedBoundedIter(t *testing.T) {
 			mint: 1,
 			maxt: 4,
 			input: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"}, {3, "d"}, {4, "e"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil}, {3, "d", nil}, {4, "e", nil},
 			},
 			exp: []entry{
-				{1, "b"}, {2, "c"}, {3, "d"},
+				{1, "b", nil}, {2, "c", nil}, {3, "d", nil},
 			},
 		},
 		{
@@ -205,10 +205,10 @@ func Test_UnorderedBoundedIter(t *testing.T) {
 			mint: 1,
 			maxt: 4,
 			input: []entry{
-				{0, "a"}, {1, "b"}, {2, "c"}, {3, "d"}, {4, "e"},
+				{0, "a", nil}, {1, "b", nil}, {2, "c", nil}, {3, "d", nil}, {4, "e", nil},
 			},
 			exp: []entry{
-				{3, "d"}, {2, "c"}, {1, "b"},
+				{3, "d", nil}, {2, "c", nil}, {1, "b", nil},
 			},
 			dir: logproto.BACKWARD,
 		},
@@ -217,17 +217,17 @@ func Test_UnorderedBoundedIter(t *testing.T) {
 			mint: 1,
 			maxt: 4,
 			input: []entry{
-				{0, "a"}, {2, "c"}, {1, "b"}, {4, "e"}, {3, "d"},
+				{0, "a", nil}, {2, "c", nil}, {1, "b", nil}, {4, "e", nil}, {3, "d", nil},
 			},
 			exp: []entry{
-				{1, "b"}, {2, "c"}, {3, "d"},
+				{1, "b", nil}, {2, "c", nil}, {3, "d", nil},
 			},
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			hb := newUnorderedHeadBlock()
+			hb := newUnorderedHeadBlock(UnorderedHeadBlockFmt)
 			for _, e := range tc.input {
-				require.Nil(t, hb.Append(e.t, e.s))
+				require.Nil(t, hb.Append(e.t, e.s, nil))
 			}
 
 			itr := hb.Iterator(
@@ -244,10 +244,10 @@ func Test_UnorderedBoundedIter(t *testing.T) {
 }
 
 func TestHeadBlockInterop(t *testing.T) {
-	unordered, ordered := newUnorderedHeadBlock(), &headBlock{}
+	unordered, ordered := newUnorderedHeadBlock(UnorderedHeadBlockFmt), &headBlock{}
 	for i := 0; i < 100; i++ {
-		require.Nil(t, unordered.Append(int64(99-i), fmt.Sprint(99-i)))
-		require.Nil(t, ordered.Append(int64(i), fmt.Sprint(i)))
+		require.Nil(t, unordered.Append(int64(99-i), fmt.Sprint(99-i), nil))
+		require.Nil(t, ordered.Append(int64(i), fmt.Sprint(i), nil))
 	}
 
 	// turn to bytes
@@ -294,14 +294,14 @@ func BenchmarkHeadBlockWrites(b *testing.B) {
 	headBlockFn := func() func(int64, string) {
 		hb := &headBlock{}
 		return func(ts int64, line string) {
-			_ = hb.Append(ts, line)
+			_ = hb.Append(ts, line, nil)
 		}
 	}
 
 	unorderedHeadBlockFn := func() func(int64, string) {
-		hb := newUnorderedHeadBlock()
+		hb := newUnorderedHeadBlock(UnorderedHeadBlockFmt)
 		return func(ts int64, line string) {
-			_ = hb.Append(ts, line)
+			_ = hb.Append(ts, line, nil)
 		}
 	}
 
@@ -638,11 +638,11 @@ func Test_HeadIteratorHash(t *testing.T) {
 	}
 
 	for name, b := range map[string]HeadBlock{
-		"unordered": newUnorderedHeadBlock(),
+		"unordered": newUnorderedHeadBlock(UnorderedHeadBlockFmt),
 		"ordered":   &headBlock{},
 	} {
 		t.Run(name, func(t *testing.T) {
-			require.NoError(t, b.Append(1, "foo"))
+			require.NoError(t, b.Append(1, "foo", nil))
 			eit := b.Iterator(context.Background(), logproto.BACKWARD, 0, 2, log.NewNoopPipeline().ForStream(lbs))
 
 			for eit.Next() {
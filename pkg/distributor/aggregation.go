@@ -0,0 +1,255 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// AggregationInstanceLabel is the label of the series of the stream aggregations set to the instance ID of the
+// distributor, as each distributor counts the entries of the push requests it receives.
+const AggregationInstanceLabel = "distributor"
+
+// AggregationConfig configures the computation of the stream aggregations of the tenants, see the stream_aggregations limit.
+type AggregationConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Interval    time.Duration `yaml:"interval"`
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	WALDir      string        `yaml:"wal_dir"`
+}
+
+func (cfg *AggregationConfig) RegisterFlagsWithPrefix(prefix string, fs *flag.FlagSet) {
+	fs.BoolVar(&cfg.Enabled, prefix+".enabled", false, "Enable the computation of the stream aggregations of the tenants by the distributors. The samples of the aggregations are remote-written with the remote-write configuration of the ruler, which must be enabled.")
+	fs.DurationVar(&cfg.Interval, prefix+".interval", time.Minute, "The interval at which the samples of the stream aggregations are remote-written. The changes of the stream aggregations of the tenants are applied at this interval too.")
+	fs.DurationVar(&cfg.IdleTimeout, prefix+".idle-timeout", time.Hour, "The duration after which the series of the stream aggregations not incremented anymore are no longer remote-written. They start from 0 when incremented again.")
+	fs.StringVar(&cfg.WALDir, prefix+".wal-dir", "distributor-aggregation-wal", "The directory of the write-ahead logs of the samples of the stream aggregations remote-written by the distributor. It must differ from the directory of the write-ahead logs of the ruler.")
+}
+
+// AggregationStorage stores the samples of the stream aggregations of the tenants.
+type AggregationStorage interface {
+	storage.Appendable
+	Stop()
+}
+
+// streamAggregator counts the lines and bytes of the streams pushed by the tenants into the series of their stream
+// aggregations, and appends their samples to the storage at each interval. The series are counters starting from 0
+// when the distributor starts.
+type streamAggregator struct {
+	cfg        AggregationConfig
+	instanceID string
+	storage    AggregationStorage
+	logger     log.Logger
+	now        func() time.Time
+
+	appendFailures *prometheus.CounterVec
+
+	// mtx guards tenants. The series of each tenant are guarded by the mutex of the tenant, so that the pushes of the
+	// tenants don't contend.
+	mtx     sync.Mutex
+	tenants map[string]*tenantAggregations
+}
+
+type tenantAggregations struct {
+	mtx sync.Mutex
+	// removed is set once the tenant without series is forgotten, so that its series are incremented in a new one.
+	removed bool
+	// streams are the series of the aggregations matching the labels of the streams. They are reset at each interval
+	// so that the changes of the aggregations of the tenant are applied.
+	streams map[string][]*aggregatedSeries
+	series  map[string]*aggregatedSeries
+}
+
+type aggregatedSeries struct {
+	labels  labels.Labels
+	bytes   bool
+	value   float64
+	updated time.Time
+}
+
+func newStreamAggregator(cfg AggregationConfig, instanceID string, storage AggregationStorage, appendFailures *prometheus.CounterVec, logger log.Logger) *streamAggregator {
+	return &streamAggregator{
+		cfg:            cfg,
+		instanceID:     instanceID,
+		storage:        storage,
+		logger:         logger,
+		now:            time.Now,
+		appendFailures: appendFailures,
+		tenants:        map[string]*tenantAggregations{},
+	}
+}
+
+// add increments the series of the aggregations of the tenant matching the streams with the number of their entries
+// and the number of bytes of their lines.
+func (a *streamAggregator) add(tenantID string, aggregations []validation.StreamAggregation, streams []logproto.Stream) {
+	now := a.now()
+
+	tenant := a.lockTenant(tenantID)
+	defer tenant.mtx.Unlock()
+
+	for _, stream := range streams {
+		series, ok := tenant.streams[stream.Labels]
+		if !ok {
+			ls, err := syntax.ParseLabels(stream.Labels)
+			if err != nil {
+				// the labels of the streams are validated before being aggregated.
+				continue
+			}
+			series = a.matchingSeries(tenant, aggregations, ls)
+			tenant.streams[stream.Labels] = series
+		}
+		if len(series) == 0 {
+			continue
+		}
+
+		var bytes int
+		for _, entry := range stream.Entries {
+			bytes += len(entry.Line)
+		}
+		for _, s := range series {
+			if s.bytes {
+				s.value += float64(bytes)
+			} else {
+				s.value += float64(len(stream.Entries))
+			}
+			s.updated = now
+		}
+	}
+}
+
+// lockTenant returns the locked series of the tenant.
+func (a *streamAggregator) lockTenant(tenantID string) *tenantAggregations {
+	for {
+		a.mtx.Lock()
+		tenant, ok := a.tenants[tenantID]
+		if !ok {
+			tenant = &tenantAggregations{
+				streams: map[string][]*aggregatedSeries{},
+				series:  map[string]*aggregatedSeries{},
+			}
+			a.tenants[tenantID] = tenant
+		}
+		a.mtx.Unlock()
+
+		tenant.mtx.Lock()
+		if !tenant.removed {
+			return tenant
+		}
+		tenant.mtx.Unlock()
+	}
+}
+
+// matchingSeries returns the series of the aggregations of the tenant matching the labels of a stream.
+func (a *streamAggregator) matchingSeries(tenant *tenantAggregations, aggregations []validation.StreamAggregation, ls labels.Labels) []*aggregatedSeries {
+	var series []*aggregatedSeries
+	for _, aggregation := range aggregations {
+		if !matches(aggregation.Matchers, ls) {
+			continue
+		}
+
+		b := labels.NewBuilder(labels.FromStrings(labels.MetricName, aggregation.Name))
+		for _, name := range aggregation.By {
+			b.Set(name, ls.Get(name))
+		}
+		b.Set(AggregationInstanceLabel, a.instanceID)
+		seriesLabels := b.Labels()
+
+		key := seriesLabels.String()
+		s, ok := tenant.series[key]
+		if !ok {
+			s = &aggregatedSeries{
+				labels: seriesLabels,
+				bytes:  aggregation.Value == validation.StreamAggregationBytes,
+			}
+			tenant.series[key] = s
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+func matches(matchers []*labels.Matcher, ls labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(ls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// flush appends the samples of the series of the tenants to the storage. The series which were not incremented during
+// the idle timeout are forgotten.
+func (a *streamAggregator) flush(ctx context.Context) {
+	type sample struct {
+		labels labels.Labels
+		value  float64
+	}
+
+	now := a.now()
+	samples := map[string][]sample{}
+
+	a.mtx.Lock()
+	for tenantID, tenant := range a.tenants {
+		tenant.mtx.Lock()
+		tenant.streams = map[string][]*aggregatedSeries{}
+		for key, s := range tenant.series {
+			if now.Sub(s.updated) > a.cfg.IdleTimeout {
+				delete(tenant.series, key)
+				continue
+			}
+			samples[tenantID] = append(samples[tenantID], sample{labels: s.labels, value: s.value})
+		}
+		if len(tenant.series) == 0 {
+			tenant.removed = true
+			delete(a.tenants, tenantID)
+		}
+		tenant.mtx.Unlock()
+	}
+	a.mtx.Unlock()
+
+	ts := now.UnixMilli()
+	for tenantID, tenantSamples := range samples {
+		sort.Slice(tenantSamples, func(i, j int) bool {
+			return labels.Compare(tenantSamples[i].labels, tenantSamples[j].labels) < 0
+		})
+
+		app := a.storage.Appender(user.InjectOrgID(ctx, tenantID))
+		err := func() error {
+			for _, s := range tenantSamples {
+				if _, err := app.Append(0, s.labels, ts, s.value); err != nil {
+					_ = app.Rollback()
+					return err
+				}
+			}
+			return app.Commit()
+		}()
+		if err != nil {
+			// the series are counters, so the next samples make up for the ones which could not be appended.
+			level.Warn(a.logger).Log("msg", "failed to append the samples of the stream aggregations", "user", tenantID, "err", err)
+			a.appendFailures.WithLabelValues(tenantID).Add(float64(len(tenantSamples)))
+		}
+	}
+}
+
+func (a *streamAggregator) iteration(ctx context.Context) error {
+	a.flush(ctx)
+	return nil
+}
+
+func (a *streamAggregator) stopping(_ error) error {
+	a.flush(context.Background())
+	a.storage.Stop()
+	return nil
+}
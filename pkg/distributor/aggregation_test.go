@@ -0,0 +1,166 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+type aggregationSample struct {
+	tenant string
+	labels labels.Labels
+	t      int64
+	v      float64
+}
+
+type mockAggregationStorage struct {
+	mtx     sync.Mutex
+	samples []aggregationSample
+	err     error
+}
+
+func (s *mockAggregationStorage) Appender(ctx context.Context) storage.Appender {
+	tenant, _ := user.ExtractOrgID(ctx)
+	return &mockAggregationAppender{storage: s, tenant: tenant}
+}
+
+func (s *mockAggregationStorage) Stop() {}
+
+func (s *mockAggregationStorage) flushed() []aggregationSample {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	samples := s.samples
+	s.samples = nil
+	return samples
+}
+
+type mockAggregationAppender struct {
+	storage.Appender
+	storage *mockAggregationStorage
+	tenant  string
+	samples []aggregationSample
+}
+
+func (a *mockAggregationAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.samples = append(a.samples, aggregationSample{tenant: a.tenant, labels: l, t: t, v: v})
+	return 0, nil
+}
+
+func (a *mockAggregationAppender) Commit() error {
+	a.storage.mtx.Lock()
+	defer a.storage.mtx.Unlock()
+	if a.storage.err != nil {
+		return a.storage.err
+	}
+	a.storage.samples = append(a.storage.samples, a.samples...)
+	return nil
+}
+
+func (a *mockAggregationAppender) Rollback() error {
+	return nil
+}
+
+func TestStreamAggregator(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := &mockAggregationStorage{}
+	appendFailures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"tenant"})
+	aggregator := newStreamAggregator(AggregationConfig{Interval: time.Minute, IdleTimeout: time.Hour}, "distributor-1", store, appendFailures, log.NewNopLogger())
+	aggregator.now = func() time.Time { return now }
+
+	limits := validation.Limits{DeletionMode: "disabled", StreamAggregations: []validation.StreamAggregation{
+		{Name: "app_lines_total", Selector: `{env="prod"}`, By: []string{"app", "level"}},
+		{Name: "bytes_total", Value: validation.StreamAggregationBytes},
+	}}
+	require.NoError(t, limits.Validate())
+
+	stream := func(ls string, lines ...string) logproto.Stream {
+		s := logproto.Stream{Labels: ls}
+		for _, line := range lines {
+			s.Entries = append(s.Entries, logproto.Entry{Timestamp: now, Line: line})
+		}
+		return s
+	}
+	aggregator.add("tenant", limits.StreamAggregations, []logproto.Stream{
+		stream(`{app="foo", env="prod", level="error", pod="foo-1"}`, "a", "bb"),
+		stream(`{app="foo", env="prod", level="error", pod="foo-2"}`, "ccc"),
+		stream(`{app="bar", env="prod", pod="bar-1"}`, "dddd"),
+		stream(`{app="foo", env="dev", level="error", pod="foo-3"}`, "eeeee"),
+	})
+	aggregator.add("tenant", limits.StreamAggregations, []logproto.Stream{
+		stream(`{app="foo", env="prod", level="error", pod="foo-1"}`, "f"),
+	})
+
+	now = now.Add(time.Minute)
+	aggregator.flush(context.Background())
+	require.Equal(t, []aggregationSample{
+		{tenant: "tenant", labels: labels.FromStrings(labels.MetricName, "app_lines_total", "app", "bar", AggregationInstanceLabel, "distributor-1"), t: now.UnixMilli(), v: 1},
+		{tenant: "tenant", labels: labels.FromStrings(labels.MetricName, "app_lines_total", "app", "foo", AggregationInstanceLabel, "distributor-1", "level", "error"), t: now.UnixMilli(), v: 4},
+		{tenant: "tenant", labels: labels.FromStrings(labels.MetricName, "bytes_total", AggregationInstanceLabel, "distributor-1"), t: now.UnixMilli(), v: 16},
+	}, store.flushed())
+
+	now = now.Add(time.Minute)
+	store.err = errors.New("not ready")
+	aggregator.flush(context.Background())
+	require.Equal(t, 3.0, testutil.ToFloat64(appendFailures.WithLabelValues("tenant")))
+	store.err = nil
+
+	// The series are counters, whose samples are appended at each interval until they are idle.
+	now = now.Add(time.Minute * 50)
+	aggregator.add("tenant", limits.StreamAggregations, []logproto.Stream{
+		stream(`{app="bar", env="prod", pod="bar-1"}`, "g"),
+	})
+	now = now.Add(time.Minute * 10)
+	aggregator.flush(context.Background())
+	require.Equal(t, []aggregationSample{
+		{tenant: "tenant", labels: labels.FromStrings(labels.MetricName, "app_lines_total", "app", "bar", AggregationInstanceLabel, "distributor-1"), t: now.UnixMilli(), v: 2},
+		{tenant: "tenant", labels: labels.FromStrings(labels.MetricName, "bytes_total", AggregationInstanceLabel, "distributor-1"), t: now.UnixMilli(), v: 17},
+	}, store.flushed())
+
+	now = now.Add(time.Hour)
+	aggregator.flush(context.Background())
+	require.Empty(t, store.flushed())
+	require.Empty(t, aggregator.tenants)
+}
+
+func TestStreamAggregator_ConcurrentTenants(t *testing.T) {
+	store := &mockAggregationStorage{}
+	appendFailures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"tenant"})
+	aggregator := newStreamAggregator(AggregationConfig{Interval: time.Minute, IdleTimeout: time.Hour}, "distributor-1", store, appendFailures, log.NewNopLogger())
+	aggregations := []validation.StreamAggregation{{Name: "lines_total", Value: validation.StreamAggregationLines}}
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"tenant 1", "tenant 2"} {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				aggregator.add(tenant, aggregations, []logproto.Stream{{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Line: "line"}}}})
+			}
+		}(tenant)
+	}
+	for i := 0; i < 10; i++ {
+		aggregator.flush(context.Background())
+	}
+	wg.Wait()
+
+	// The series of the tenants are counters, so their last samples count all the entries.
+	aggregator.flush(context.Background())
+	last := map[string]float64{}
+	for _, s := range store.flushed() {
+		last[s.tenant] = s.v
+	}
+	require.Equal(t, map[string]float64{"tenant 1": 100, "tenant 2": 100}, last)
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/loki/pkg/ingester"
@@ -16,6 +17,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/limiter"
@@ -39,6 +41,7 @@ import (
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/storage/stores/indexshipper/compactor/retention"
 	"github.com/grafana/loki/pkg/util"
@@ -55,6 +58,9 @@ const (
 var (
 	maxLabelCacheSize = 100000
 	rfStats           = analytics.NewInt("distributor_replication_factor")
+
+	// errStreamDropped is returned by parseStreamLabels for the streams dropped by the relabel configs of the tenant.
+	errStreamDropped = errors.New("stream dropped by relabeling")
 )
 
 // Config for a Distributor.
@@ -70,6 +76,12 @@ type Config struct {
 
 	// WriteFailuresLoggingCfg customizes write failures logging behavior.
 	WriteFailuresLogging writefailures.Cfg `yaml:"write_failures_logging" doc:"description=Experimental. Customize the logging of write failures."`
+
+	// LabelCardinality customizes the counting of the label values used by the label cardinality collapse threshold limit.
+	LabelCardinality LabelCardinalityConfig `yaml:"label_cardinality"`
+
+	// Aggregation customizes the computation of the stream aggregations of the tenants.
+	Aggregation AggregationConfig `yaml:"aggregation" doc:"description=Experimental. Customize the computation of the stream aggregations of the tenants."`
 }
 
 // RegisterFlags registers distributor-related flags.
@@ -77,6 +89,8 @@ func (cfg *Config) RegisterFlags(fs *flag.FlagSet) {
 	cfg.DistributorRing.RegisterFlags(fs)
 	cfg.RateStore.RegisterFlagsWithPrefix("distributor.rate-store", fs)
 	cfg.WriteFailuresLogging.RegisterFlagsWithPrefix("distributor.write-failures-logging", fs)
+	cfg.LabelCardinality.RegisterFlagsWithPrefix("distributor.label-cardinality", fs)
+	cfg.Aggregation.RegisterFlagsWithPrefix("distributor.aggregation", fs)
 }
 
 // RateStore manages the ingestion rate of streams, populated by data fetched from ingesters.
@@ -99,6 +113,11 @@ type Distributor struct {
 	rateStore    RateStore
 	shardTracker *ShardTracker
 
+	labelCardinality *labelCardinalityTracker
+
+	// aggregator computes the stream aggregations of the tenants, nil if it is disabled.
+	aggregator *streamAggregator
+
 	// The global rate limiter requires a distributors ring to count
 	// the number of healthy instances.
 	distributorsLifecycler *ring.BasicLifecycler
@@ -121,6 +140,9 @@ type Distributor struct {
 	ingesterAppendFailures *prometheus.CounterVec
 	replicationFactor      prometheus.Gauge
 	streamShardCount       prometheus.Counter
+	relabeledStreams       *prometheus.CounterVec
+	relabelDroppedStreams  *prometheus.CounterVec
+	collapsedStreams       *prometheus.CounterVec
 }
 
 // New a distributor creates.
@@ -131,6 +153,7 @@ func New(
 	ingestersRing ring.ReadRing,
 	overrides Limits,
 	registerer prometheus.Registerer,
+	aggregationStorage AggregationStorage,
 ) (*Distributor, error) {
 	factory := cfg.factory
 	if factory == nil {
@@ -173,6 +196,7 @@ func New(
 		pool:                  clientpool.NewPool(clientCfg.PoolConfig, ingestersRing, factory, util_log.Logger),
 		labelCache:            labelCache,
 		shardTracker:          NewShardTracker(),
+		labelCardinality:      newLabelCardinalityTracker(cfg.LabelCardinality),
 		healthyInstancesCount: atomic.NewUint32(0),
 		rateLimitStrat:        rateLimitStrat,
 		ingesterAppends: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
@@ -195,6 +219,21 @@ func New(
 			Name:      "stream_sharding_count",
 			Help:      "Total number of times the distributor has sharded streams",
 		}),
+		relabeledStreams: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_relabeled_streams_total",
+			Help:      "The total number of pushed streams whose labels were rewritten by the relabel configs of the tenant.",
+		}, []string{"tenant"}),
+		relabelDroppedStreams: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_relabel_dropped_streams_total",
+			Help:      "The total number of pushed streams dropped by the relabel configs of the tenant.",
+		}, []string{"tenant"}),
+		collapsedStreams: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_collapsed_streams_total",
+			Help:      "The total number of pushed streams whose label values were collapsed for exceeding the label cardinality collapse threshold of the tenant.",
+		}, []string{"tenant"}),
 		writeFailuresManager: writefailures.NewManager(util_log.Logger, cfg.WriteFailuresLogging, configs),
 	}
 
@@ -235,6 +274,19 @@ func New(
 	d.rateStore = rs
 
 	servs = append(servs, d.pool, rs)
+
+	if cfg.Aggregation.Enabled && aggregationStorage != nil {
+		if cfg.Aggregation.Interval <= 0 {
+			return nil, errors.New("the interval of the distributor aggregation must be positive")
+		}
+		d.aggregator = newStreamAggregator(cfg.Aggregation, cfg.DistributorRing.InstanceID, aggregationStorage, promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_aggregation_failed_samples_total",
+			Help:      "The total number of samples of the stream aggregations of the tenant which could not be appended to the remote-write storage.",
+		}, []string{"tenant"}), util_log.Logger)
+		servs = append(servs, services.NewTimerService(cfg.Aggregation.Interval, nil, d.aggregator.iteration, d.aggregator.stopping))
+	}
+
 	d.subservices, err = services.NewManager(servs...)
 	if err != nil {
 		return nil, errors.Wrap(err, "services manager")
@@ -270,6 +322,25 @@ type streamTracker struct {
 	maxFailures int
 	succeeded   atomic.Int32
 	failed      atomic.Int32
+
+	// index is the index of the stream in the push request, and entries are the indexes of its entries in the
+	// stream of the push request. They are only set for the push requests accepting partial success.
+	// The entries of a sharded stream are first set to the positions of its entries in the stream it derives from.
+	index   int
+	entries []int
+}
+
+// setIndexes sets the indexes of the stream and of its entries in the push request, given the indexes of the entries of
+// the stream it derives from.
+func (t *streamTracker) setIndexes(index int, indexes []int) {
+	t.index = index
+	if t.entries == nil {
+		t.entries = indexes
+		return
+	}
+	for i, position := range t.entries {
+		t.entries[i] = indexes[position]
+	}
 }
 
 // TODO taken from Cortex, see if we can refactor out an usable interface.
@@ -278,11 +349,99 @@ type pushTracker struct {
 	streamsFailed  atomic.Int32
 	done           chan struct{}
 	err            chan error
+
+	// mtx guards rejected, the entries rejected by the ingesters for the push requests accepting partial success.
+	mtx      sync.Mutex
+	rejected rejectedEntries
+}
+
+// reject records the entries rejected by an ingester for the streams pushed to it, unless the streams already got
+// their minimum number of successful pushes.
+func (p *pushTracker) reject(streamTrackers []*streamTracker, rejected []logproto.RejectedStream) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, stream := range rejected {
+		if int(stream.Index) >= len(streamTrackers) {
+			continue
+		}
+		tracker := streamTrackers[stream.Index]
+		if int(tracker.succeeded.Load()) >= tracker.minSuccess {
+			continue
+		}
+		for _, entry := range stream.Entries {
+			if int(entry.Index) >= len(tracker.entries) {
+				continue
+			}
+			p.rejected.add(tracker.index, tracker.entries[entry.Index], entry)
+		}
+	}
+}
+
+// rejectedEntries are the entries of a push request rejected by the distributor or by the ingesters,
+// by index of their stream in the push request and by index in the stream.
+type rejectedEntries map[int]map[int]logproto.RejectedEntry
+
+// add records the rejection of an entry, unless it is already rejected.
+func (r rejectedEntries) add(stream, index int, entry logproto.RejectedEntry) {
+	entries, ok := r[stream]
+	if !ok {
+		entries = map[int]logproto.RejectedEntry{}
+		r[stream] = entries
+	}
+	if _, ok := entries[index]; ok {
+		return
+	}
+	entry.Index = int32(index)
+	entries[index] = entry
+}
+
+// accepted returns the stream of the tracker without its rejected entries.
+func (r rejectedEntries) accepted(t streamTracker) logproto.Stream {
+	rejected := r[t.index]
+	if len(rejected) == 0 {
+		return t.stream
+	}
+	stream := t.stream
+	stream.Entries = make([]logproto.Entry, 0, len(t.stream.Entries))
+	for i, entry := range t.stream.Entries {
+		if _, ok := rejected[t.entries[i]]; !ok {
+			stream.Entries = append(stream.Entries, entry)
+		}
+	}
+	return stream
+}
+
+// streams returns the streams of the push request with rejected entries, sorted by index.
+func (r rejectedEntries) streams(req *logproto.PushRequest) []logproto.RejectedStream {
+	if len(r) == 0 {
+		return nil
+	}
+	streams := make([]logproto.RejectedStream, 0, len(r))
+	for index, rejected := range r {
+		stream := logproto.RejectedStream{
+			Index:   int32(index),
+			Labels:  req.Streams[index].Labels,
+			Entries: make([]logproto.RejectedEntry, 0, len(rejected)),
+		}
+		for _, entry := range rejected {
+			stream.Entries = append(stream.Entries, entry)
+		}
+		sort.Slice(stream.Entries, func(i, j int) bool { return stream.Entries[i].Index < stream.Entries[j].Index })
+		streams = append(streams, stream)
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Index < streams[j].Index })
+	return streams
 }
 
 // Push a set of streams.
 // The returned error is the last one seen.
+// The entries rejected by the distributor and the ingesters are returned in the response instead of an error
+// to the clients accepting partial success.
 func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*logproto.PushResponse, error) {
+	return d.push(ctx, req, client.ExtractPartialSuccess(ctx))
+}
+
+func (d *Distributor) push(ctx context.Context, req *logproto.PushRequest, partialSuccess bool) (*logproto.PushResponse, error) {
 	tenantID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -302,8 +461,16 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	validatedLineCount := 0
 
 	var validationErrors util.GroupedErrors
+	rejected := rejectedEntries{}
 	validationContext := d.validator.getValidationContextForTime(time.Now(), tenantID)
 
+	// aggregated are the validated streams counted by the stream aggregations of the tenant once they are pushed.
+	var aggregations []validation.StreamAggregation
+	var aggregated []streamTracker
+	if d.aggregator != nil {
+		aggregations = d.validator.Limits.StreamAggregations(tenantID)
+	}
+
 	func() {
 		sp := opentracing.SpanFromContext(ctx)
 		if sp != nil {
@@ -312,16 +479,18 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 				sp.LogKV("event", "finished to validate request")
 			}()
 		}
-		for _, stream := range req.Streams {
+		for i, stream := range req.Streams {
 			// Return early if stream does not contain any entries
 			if len(stream.Entries) == 0 {
 				continue
 			}
 
-			// Truncate first so subsequent steps have consistent line lengths
-			d.truncateLines(validationContext, &stream)
-
-			stream.Labels, stream.Hash, err = d.parseStreamLabels(validationContext, stream.Labels, &stream)
+			// The values of the collapsed labels are moved to the lines while parsing the labels, so the lines are
+			// truncated afterwards.
+			parsed, reason, err := d.parseStreamLabels(validationContext, stream.Labels, &stream)
+			if err == errStreamDropped {
+				continue
+			}
 			if err != nil {
 				d.writeFailuresManager.Log(tenantID, err)
 				validationErrors.Add(err)
@@ -331,18 +500,46 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 					bytes += len(e.Line)
 				}
 				validation.DiscardedBytes.WithLabelValues(validation.InvalidLabels, tenantID).Add(float64(bytes))
+				if partialSuccess {
+					for j := range stream.Entries {
+						rejected.add(i, j, logproto.RejectedEntry{Reason: reason, Message: err.Error()})
+					}
+				}
 				continue
 			}
+			stream.Labels, stream.Hash = parsed.labels, parsed.hash
+			unmoved := parsed.unmoved
+
+			// Truncate first so subsequent steps have consistent line lengths
+			d.truncateLines(validationContext, &stream)
 
 			n := 0
 			pushSize := 0
 			prevTs := stream.Entries[0].Timestamp
-			for _, entry := range stream.Entries {
-				if err := d.validator.ValidateEntry(validationContext, stream.Labels, entry); err != nil {
+			// indexes are the indexes of the valid entries in the stream, for the push requests accepting partial success.
+			var indexes []int
+			for j, entry := range stream.Entries {
+				var (
+					reason string
+					err    error
+				)
+				if len(unmoved) > 0 && unmoved[0] == j {
+					unmoved = unmoved[1:]
+					reason, err = d.validator.rejectUnmovedCollapsedLabels(validationContext, stream.Labels, entry)
+				} else {
+					reason, err = d.validator.validateEntry(validationContext, stream.Labels, entry)
+				}
+				if err != nil {
 					d.writeFailuresManager.Log(tenantID, err)
 					validationErrors.Add(err)
+					if partialSuccess {
+						rejected.add(i, j, logproto.RejectedEntry{Reason: reason, Message: err.Error()})
+					}
 					continue
 				}
+				if partialSuccess {
+					indexes = append(indexes, j)
+				}
 
 				stream.Entries[n] = entry
 
@@ -362,32 +559,46 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 				}
 
 				n++
-				validatedLineSize += len(entry.Line)
+				validatedLineSize += util.EntryTotalSize(&entry)
 				validatedLineCount++
-				pushSize += len(entry.Line)
+				pushSize += util.EntryTotalSize(&entry)
 			}
 			stream.Entries = stream.Entries[:n]
+			if len(aggregations) > 0 && n > 0 {
+				aggregated = append(aggregated, streamTracker{stream: stream, index: i, entries: indexes})
+			}
 
 			shardStreamsCfg := d.validator.Limits.ShardStreams(tenantID)
 			if shardStreamsCfg.Enabled {
 				derivedKeys, derivedStreams := d.shardStream(stream, pushSize, tenantID)
+				if partialSuccess {
+					for k := range derivedStreams {
+						derivedStreams[k].setIndexes(i, indexes)
+					}
+				}
 				keys = append(keys, derivedKeys...)
 				streams = append(streams, derivedStreams...)
 			} else {
 				keys = append(keys, util.TokenFor(tenantID, stream.Labels))
-				streams = append(streams, streamTracker{stream: stream})
+				streams = append(streams, streamTracker{stream: stream, index: i, entries: indexes})
 			}
 		}
 	}()
 
+	if validationContext.labelCardinalityCollapseThreshold > 0 {
+		if events := d.labelCardinality.events(tenantID); len(events) > 0 {
+			go d.pushLabelCardinalityEvents(tenantID, events)
+		}
+	}
+
 	var validationErr error
-	if validationErrors.Err() != nil {
+	if validationErrors.Err() != nil && !partialSuccess {
 		validationErr = httpgrpc.Errorf(http.StatusBadRequest, validationErrors.Error())
 	}
 
 	// Return early if none of the streams contained entries
 	if len(streams) == 0 {
-		return &logproto.PushResponse{}, validationErr
+		return &logproto.PushResponse{RejectedStreams: rejected.streams(req)}, validationErr
 	}
 
 	now := time.Now()
@@ -398,6 +609,14 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 
 		err = fmt.Errorf(validation.RateLimitedErrorMsg, tenantID, int(d.ingestionRateLimiter.Limit(now, tenantID)), validatedLineCount, validatedLineSize)
 		d.writeFailuresManager.Log(tenantID, err)
+		if partialSuccess {
+			for _, stream := range streams {
+				for _, index := range stream.entries {
+					rejected.add(stream.index, index, logproto.RejectedEntry{Reason: validation.RateLimited, Message: err.Error()})
+				}
+			}
+			return &logproto.PushResponse{RejectedStreams: rejected.streams(req)}, nil
+		}
 		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, err.Error())
 	}
 
@@ -407,6 +626,12 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	streamsByIngester := map[string][]*streamTracker{}
 	ingesterDescs := map[string]ring.InstanceDesc{}
 
+	// With shuffle sharding, the streams of the tenant are only written to the ingesters of its shard.
+	ingestersRing := d.ingestersRing
+	if shardSize := d.validator.Limits.IngestionTenantShardSize(tenantID); shardSize > 0 {
+		ingestersRing = d.ingestersRing.ShuffleShard(tenantID, shardSize)
+	}
+
 	if err := func() error {
 		sp := opentracing.SpanFromContext(ctx)
 		if sp != nil {
@@ -417,7 +642,7 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 		}
 
 		for i, key := range keys {
-			replicationSet, err := d.ingestersRing.Get(key, ring.WriteNoExtend, descs[:0], nil, nil)
+			replicationSet, err := ingestersRing.Get(key, ring.WriteNoExtend, descs[:0], nil, nil)
 			if err != nil {
 				return err
 			}
@@ -435,8 +660,9 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	}
 
 	tracker := pushTracker{
-		done: make(chan struct{}, 1), // buffer avoids blocking if caller terminates - sendSamples() only sends once on each
-		err:  make(chan error, 1),
+		done:     make(chan struct{}, 1), // buffer avoids blocking if caller terminates - sendSamples() only sends once on each
+		err:      make(chan error, 1),
+		rejected: rejected,
 	}
 	tracker.streamsPending.Store(int32(len(streams)))
 	for ingester, streams := range streamsByIngester {
@@ -445,6 +671,9 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 			localCtx, cancel := context.WithTimeout(context.Background(), d.clientCfg.RemoteTimeout)
 			defer cancel()
 			localCtx = user.InjectOrgID(localCtx, tenantID)
+			if partialSuccess {
+				localCtx = client.InjectPartialSuccess(localCtx)
+			}
 			if sp := opentracing.SpanFromContext(ctx); sp != nil {
 				localCtx = opentracing.ContextWithSpan(localCtx, sp)
 			}
@@ -455,7 +684,17 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	case err := <-tracker.err:
 		return nil, err
 	case <-tracker.done:
-		return &logproto.PushResponse{}, validationErr
+		tracker.mtx.Lock()
+		defer tracker.mtx.Unlock()
+		// The entries rejected by the ingesters of the push requests accepting partial success are not counted.
+		if len(aggregated) > 0 {
+			accepted := make([]logproto.Stream, 0, len(aggregated))
+			for _, stream := range aggregated {
+				accepted = append(accepted, tracker.rejected.accepted(stream))
+			}
+			d.aggregator.add(tenantID, aggregations, accepted)
+		}
+		return &logproto.PushResponse{RejectedStreams: tracker.rejected.streams(req)}, validationErr
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
@@ -490,6 +729,7 @@ func (d *Distributor) divideEntriesBetweenShards(tenantID string, totalShards in
 		streamIndex := i % len(derivedStreams)
 		entries := append(derivedStreams[streamIndex].stream.Entries, stream.Entries[i])
 		derivedStreams[streamIndex].stream.Entries = entries
+		derivedStreams[streamIndex].entries = append(derivedStreams[streamIndex].entries, i)
 	}
 
 	return derivedKeys, derivedStreams
@@ -599,7 +839,10 @@ func (d *Distributor) truncateLines(vContext validationContext, stream *logproto
 
 // TODO taken from Cortex, see if we can refactor out an usable interface.
 func (d *Distributor) sendStreams(ctx context.Context, ingester ring.InstanceDesc, streamTrackers []*streamTracker, pushTracker *pushTracker) {
-	err := d.sendStreamsErr(ctx, ingester, streamTrackers)
+	resp, err := d.sendStreamsErr(ctx, ingester, streamTrackers)
+	if err == nil && resp != nil && len(resp.RejectedStreams) > 0 {
+		pushTracker.reject(streamTrackers, resp.RejectedStreams)
+	}
 
 	// If we succeed, decrement each stream's pending count by one.
 	// If we reach the required number of successful puts on this stream, then
@@ -630,10 +873,10 @@ func (d *Distributor) sendStreams(ctx context.Context, ingester ring.InstanceDes
 }
 
 // TODO taken from Cortex, see if we can refactor out an usable interface.
-func (d *Distributor) sendStreamsErr(ctx context.Context, ingester ring.InstanceDesc, streams []*streamTracker) error {
+func (d *Distributor) sendStreamsErr(ctx context.Context, ingester ring.InstanceDesc, streams []*streamTracker) (*logproto.PushResponse, error) {
 	c, err := d.pool.GetClientFor(ingester.Addr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req := &logproto.PushRequest{
@@ -643,12 +886,12 @@ func (d *Distributor) sendStreamsErr(ctx context.Context, ingester ring.Instance
 		req.Streams[i] = s.stream
 	}
 
-	_, err = c.(logproto.PusherClient).Push(ctx, req)
+	resp, err := c.(logproto.PusherClient).Push(ctx, req)
 	d.ingesterAppends.WithLabelValues(ingester.Addr).Inc()
 	if err != nil {
 		d.ingesterAppendFailures.WithLabelValues(ingester.Addr).Inc()
 	}
-	return err
+	return resp, err
 }
 
 type labelData struct {
@@ -656,26 +899,183 @@ type labelData struct {
 	hash   uint64
 }
 
-func (d *Distributor) parseStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (string, uint64, error) {
+// parsedStreamLabels are the validated labels of a stream returned by parseStreamLabels.
+type parsedStreamLabels struct {
+	labels string
+	hash   uint64
+	// unmoved are the indexes of the entries which can't hold the values of the collapsed labels of the stream, which
+	// must be rejected.
+	unmoved []int
+}
+
+// parseStreamLabels returns the validated labels of the stream. If the labels are invalid, it also returns the reason
+// of the rejection of the entries of the stream.
+func (d *Distributor) parseStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (parsedStreamLabels, string, error) {
+	if len(vContext.relabelConfigs) > 0 || vContext.labelCardinalityCollapseThreshold > 0 {
+		return d.rewriteStreamLabels(vContext, key, stream)
+	}
+
 	if val, ok := d.labelCache.Get(key); ok {
 		labelVal := val.(labelData)
-		return labelVal.labels, labelVal.hash, nil
+		return parsedStreamLabels{labels: labelVal.labels, hash: labelVal.hash}, "", nil
 	}
 
 	ls, err := syntax.ParseLabels(key)
 	if err != nil {
-		return "", 0, fmt.Errorf(validation.InvalidLabelsErrorMsg, key, err)
+		return parsedStreamLabels{}, validation.InvalidLabels, fmt.Errorf(validation.InvalidLabelsErrorMsg, key, err)
 	}
 
-	if err := d.validator.ValidateLabels(vContext, ls, *stream); err != nil {
-		return "", 0, err
+	if reason, err := d.validator.validateLabels(vContext, ls, *stream); err != nil {
+		return parsedStreamLabels{}, reason, err
 	}
 
 	lsVal := ls.String()
 	lsHash := ls.Hash()
 
 	d.labelCache.Add(key, labelData{lsVal, lsHash})
-	return lsVal, lsHash, nil
+	return parsedStreamLabels{labels: lsVal, hash: lsHash}, "", nil
+}
+
+// rewrittenLabelData are the labels of a stream of a tenant rewritten by its relabel configs. The validated labels are
+// only set once the labels without collapsed values are validated.
+type rewrittenLabelData struct {
+	labels    labels.Labels
+	dropped   bool
+	relabeled bool
+	validated *labelData
+}
+
+// rewrittenLabelsCacheKey returns the key of the rewritten labels of a stream in the label cache. The labels are
+// cached by tenant and hash of the relabel configs, so that they are rewritten again once the configs change.
+func rewrittenLabelsCacheKey(vContext validationContext, key string) string {
+	return vContext.userID + "\xff" + strconv.FormatUint(vContext.relabelConfigsHash, 16) + "\xff" + key
+}
+
+// rewriteStreamLabels parses the labels of the stream, applies the relabel configs of the tenant and collapses the
+// values of the labels exceeding the label cardinality collapse threshold of the tenant before validating them.
+// It returns errStreamDropped if the stream is dropped by the relabeling. The relabeled labels are cached, but the
+// values of the labels are collapsed at each push since the collapsed labels change over time.
+func (d *Distributor) rewriteStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (parsedStreamLabels, string, error) {
+	cacheKey := rewrittenLabelsCacheKey(vContext, key)
+	var rewritten rewrittenLabelData
+	if val, ok := d.labelCache.Get(cacheKey); ok {
+		rewritten = val.(rewrittenLabelData)
+	} else {
+		ls, err := syntax.ParseLabels(key)
+		if err != nil {
+			return parsedStreamLabels{}, validation.InvalidLabels, fmt.Errorf(validation.InvalidLabelsErrorMsg, key, err)
+		}
+		rewritten.labels = ls
+		if len(vContext.relabelConfigs) > 0 {
+			relabeled, keep := relabel.Process(ls, vContext.relabelConfigs...)
+			rewritten.labels = relabeled
+			rewritten.dropped = !keep
+			rewritten.relabeled = !labels.Equal(ls, relabeled)
+		}
+		d.labelCache.Add(cacheKey, rewritten)
+	}
+
+	if rewritten.dropped {
+		d.relabelDroppedStreams.WithLabelValues(vContext.userID).Inc()
+		return parsedStreamLabels{}, "", errStreamDropped
+	}
+	if rewritten.relabeled {
+		d.relabeledStreams.WithLabelValues(vContext.userID).Inc()
+	}
+
+	ls := rewritten.labels
+	if vContext.labelCardinalityCollapseThreshold > 0 {
+		var collapsed []labels.Label
+		ls, collapsed = d.labelCardinality.collapse(vContext.userID, ls, vContext.labelCardinalityCollapseThreshold)
+		if len(collapsed) > 0 {
+			d.collapsedStreams.WithLabelValues(vContext.userID).Inc()
+			unmoved := moveCollapsedLabels(vContext, stream, collapsed)
+
+			if reason, err := d.validator.validateLabels(vContext, ls, *stream); err != nil {
+				return parsedStreamLabels{}, reason, err
+			}
+			return parsedStreamLabels{labels: ls.String(), hash: ls.Hash(), unmoved: unmoved}, "", nil
+		}
+	}
+
+	if rewritten.validated != nil {
+		return parsedStreamLabels{labels: rewritten.validated.labels, hash: rewritten.validated.hash}, "", nil
+	}
+	if reason, err := d.validator.validateLabels(vContext, ls, *stream); err != nil {
+		return parsedStreamLabels{}, reason, err
+	}
+	rewritten.validated = &labelData{ls.String(), ls.Hash()}
+	d.labelCache.Add(cacheKey, rewritten)
+	return parsedStreamLabels{labels: rewritten.validated.labels, hash: rewritten.validated.hash}, "", nil
+}
+
+// moveCollapsedLabels moves the values of the collapsed labels of the stream to the structured metadata of its entries
+// if the tenant allows it and they stay within the structured metadata limits, or else appends them to their log lines.
+// The lines are truncated before the appended values if the tenant truncates the lines exceeding the max line size.
+// It returns the indexes of the entries which can't hold the values within the limits, left unchanged.
+func moveCollapsedLabels(vContext validationContext, stream *logproto.Stream, collapsed []labels.Label) []int {
+	var suffix string
+	var metadataSize int
+	for _, l := range collapsed {
+		suffix += " " + l.Name + "=" + strconv.Quote(l.Value)
+		metadataSize += len(l.Name) + len(l.Value)
+	}
+
+	var unmoved []int
+	var truncatedSamples, truncatedBytes int
+	for i := range stream.Entries {
+		entry := &stream.Entries[i]
+
+		if vContext.allowStructuredMetadata {
+			size := metadataSize
+			for _, l := range entry.StructuredMetadata {
+				size += len(l.Name) + len(l.Value)
+			}
+			maxSize, maxCount := vContext.maxStructuredMetadataSize, vContext.maxStructuredMetadataCount
+			if (maxSize == 0 || size <= maxSize) && (maxCount == 0 || len(entry.StructuredMetadata)+len(collapsed) <= maxCount) {
+				for _, l := range collapsed {
+					entry.StructuredMetadata = append(entry.StructuredMetadata, push.LabelAdapter{Name: l.Name, Value: l.Value})
+				}
+				continue
+			}
+		}
+
+		maxSize := vContext.maxLineSize
+		switch {
+		case maxSize == 0 || len(entry.Line)+len(suffix) <= maxSize:
+			entry.Line += suffix
+		case vContext.maxLineSizeTruncate && len(suffix) < maxSize:
+			truncatedSamples++
+			truncatedBytes += len(entry.Line) + len(suffix) - maxSize
+			entry.Line = entry.Line[:maxSize-len(suffix)] + suffix
+		default:
+			unmoved = append(unmoved, i)
+		}
+	}
+
+	validation.MutatedSamples.WithLabelValues(validation.LineTooLong, vContext.userID).Add(float64(truncatedSamples))
+	validation.MutatedBytes.WithLabelValues(validation.LineTooLong, vContext.userID).Add(float64(truncatedBytes))
+	return unmoved
+}
+
+// pushLabelCardinalityEvents logs the events of the labels of the tenant whose values started to be collapsed, and
+// pushes them to the tenant so that it is told about them.
+func (d *Distributor) pushLabelCardinalityEvents(tenantID string, events []labelCardinalityEvent) {
+	logger := util_log.WithUserID(tenantID, util_log.Logger)
+	stream := logproto.Stream{Labels: labels.FromStrings(EventLabel, LabelValuesCollapsedEvent).String()}
+	for _, e := range events {
+		level.Warn(logger).Log("msg", "collapsing the values of a label exceeding the label cardinality collapse threshold", "label", e.label, "estimated_values", e.estimate, "threshold", e.threshold)
+		stream.Entries = append(stream.Entries, logproto.Entry{
+			Timestamp: e.time,
+			Line:      fmt.Sprintf("msg=%q label=%s estimated_values=%d threshold=%d collapsed_value=%s", "label values collapsed", e.label, e.estimate, e.threshold, CollapsedLabelValue),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), tenantID), d.clientCfg.RemoteTimeout)
+	defer cancel()
+	if _, err := d.Push(ctx, &logproto.PushRequest{Streams: []logproto.Stream{stream}}); err != nil {
+		level.Warn(logger).Log("msg", "failed to push label cardinality events", "err", err)
+	}
 }
 
 // shardCountFor returns the right number of shards to be used by the given stream.
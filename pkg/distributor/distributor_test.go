@@ -6,6 +6,7 @@ import (
 	"math"
 	"math/rand"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -20,7 +21,10 @@ import (
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
@@ -32,6 +36,8 @@ import (
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/push"
+	ruler_util "github.com/grafana/loki/pkg/ruler/util"
 	"github.com/grafana/loki/pkg/runtime"
 	fe "github.com/grafana/loki/pkg/util/flagext"
 	loki_flagext "github.com/grafana/loki/pkg/util/flagext"
@@ -490,6 +496,65 @@ func TestDistributorPushErrors(t *testing.T) {
 	})
 }
 
+func TestDistributorPushPartialSuccess(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxLineSize = 10
+	ingester := &mockIngester{
+		// The ingester rejects the second entry it gets, the third entry of the request.
+		rejected: []logproto.RejectedStream{{Labels: `{foo="bar"}`, Entries: []logproto.RejectedEntry{
+			{Index: 1, Reason: validation.StreamRateLimit, Message: "rate limited"},
+		}}},
+	}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := makeWriteRequest(4, 5)
+	request.Streams[0].Entries[1].Line = strings.Repeat("0", 11)
+	resp, err := distributors[0].push(ctx, request, true)
+	require.NoError(t, err)
+	require.Len(t, resp.RejectedStreams, 1)
+	rejected := resp.RejectedStreams[0]
+	require.Equal(t, int32(0), rejected.Index)
+	require.Equal(t, `{foo="bar"}`, rejected.Labels)
+	require.Len(t, rejected.Entries, 2)
+	require.Equal(t, int32(1), rejected.Entries[0].Index)
+	require.Equal(t, validation.LineTooLong, rejected.Entries[0].Reason)
+	require.Equal(t, int32(2), rejected.Entries[1].Index)
+	require.Equal(t, validation.StreamRateLimit, rejected.Entries[1].Reason)
+	require.Equal(t, "rate limited", rejected.Entries[1].Message)
+
+	// Without partial success the push fails.
+	request = makeWriteRequest(4, 5)
+	request.Streams[0].Entries[1].Line = strings.Repeat("0", 11)
+	resp, err = distributors[0].Push(ctx, request)
+	require.Error(t, err)
+	require.Empty(t, resp.RejectedStreams)
+}
+
+func TestDistributorPushPartialSuccessInvalidLabels(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxLabelValueLength = 5
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := makeWriteRequest(2, 5)
+	for _, ls := range []string{`{foo="too long"}`, `{foo=`} {
+		stream := makeWriteRequest(1, 5).Streams[0]
+		stream.Labels = ls
+		request.Streams = append(request.Streams, stream)
+	}
+	resp, err := distributors[0].push(ctx, request, true)
+	require.NoError(t, err)
+
+	// The entries of the streams with invalid labels are rejected with the reason of the validation failure.
+	require.Len(t, resp.RejectedStreams, 2)
+	require.Equal(t, int32(1), resp.RejectedStreams[0].Index)
+	require.Equal(t, validation.LabelValueTooLong, resp.RejectedStreams[0].Entries[0].Reason)
+	require.Equal(t, int32(2), resp.RejectedStreams[1].Index)
+	require.Equal(t, validation.InvalidLabels, resp.RejectedStreams[1].Entries[0].Reason)
+}
+
 func Test_SortLabelsOnPush(t *testing.T) {
 	limits := &validation.Limits{}
 	flagext.DefaultValues(limits)
@@ -504,6 +569,264 @@ func Test_SortLabelsOnPush(t *testing.T) {
 	require.Equal(t, `{a="b", buzz="f"}`, ingester.pushed[0].Streams[0].Labels)
 }
 
+func Test_RelabelOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RelabelConfigs = []*ruler_util.RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "dev", Action: "drop"},
+		{Regex: "pod", Action: "labeldrop"},
+		{SourceLabels: []string{"app"}, Regex: "(.*)-v[0-9]+", TargetLabel: "app", Replacement: "$1", Action: "replace"},
+	}
+	require.NoError(t, limits.Validate())
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := makeWriteRequest(10, 10)
+	request.Streams[0].Labels = `{app="foo-v2", env="prod", pod="foo-v2-abcde"}`
+	dropped := makeWriteRequest(10, 10).Streams[0]
+	dropped.Labels = `{app="foo-v2", env="dev", pod="foo-v2-abcde"}`
+	unchanged := makeWriteRequest(10, 10).Streams[0]
+	unchanged.Labels = `{app="bar", env="prod"}`
+	request.Streams = append(request.Streams, dropped, unchanged)
+
+	_, err := distributors[0].Push(ctx, request)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		`{app="foo", env="prod"} ` + request.Streams[0].Entries[0].Line,
+		`{app="bar", env="prod"} ` + unchanged.Entries[0].Line,
+	}, ingester.pushedStreams())
+	require.Equal(t, float64(1), testutil.ToFloat64(distributors[0].relabeledStreams.WithLabelValues("test")))
+	require.Equal(t, float64(1), testutil.ToFloat64(distributors[0].relabelDroppedStreams.WithLabelValues("test")))
+}
+
+func Test_RewriteStreamLabelsCache(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RelabelConfigs = []*ruler_util.RelabelConfig{
+		{Regex: "pod", Action: "labeldrop"},
+	}
+	require.NoError(t, limits.Validate())
+	distributors, _ := prepare(t, 1, 5, limits, nil)
+	d := distributors[0]
+
+	key := `{app="foo", pod="foo-abcde"}`
+	vContext := d.validator.getValidationContextForTime(time.Now(), "test")
+	for i := 0; i < 2; i++ {
+		parsed, _, err := d.parseStreamLabels(vContext, key, &logproto.Stream{Labels: key})
+		require.NoError(t, err)
+		require.Equal(t, `{app="foo"}`, parsed.labels)
+	}
+	// The rewritten labels are cached by tenant, and counted at each push.
+	require.True(t, d.labelCache.Contains(rewrittenLabelsCacheKey(vContext, key)))
+	require.False(t, d.labelCache.Contains(key))
+	require.Equal(t, float64(2), testutil.ToFloat64(d.relabeledStreams.WithLabelValues("test")))
+
+	// The labels are rewritten again once the relabel configs change.
+	vContext.relabelConfigs = []*relabel.Config{{Regex: relabel.MustNewRegexp("app"), Action: relabel.LabelDrop}}
+	vContext.relabelConfigsHash++
+	parsed, _, err := d.parseStreamLabels(vContext, key, &logproto.Stream{Labels: key})
+	require.NoError(t, err)
+	require.Equal(t, `{pod="foo-abcde"}`, parsed.labels)
+}
+
+func Test_CollapseLabelValuesOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.LabelCardinalityCollapseThreshold = 2
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := &logproto.PushRequest{}
+	for i := 0; i < 4; i++ {
+		request.Streams = append(request.Streams, logproto.Stream{
+			Labels:  fmt.Sprintf(`{app="foo", request_id="%d"}`, i),
+			Entries: []logproto.Entry{{Timestamp: time.Now(), Line: "line"}},
+		})
+	}
+	_, err := distributors[0].Push(ctx, request)
+	require.NoError(t, err)
+
+	expected := []string{
+		`{__loki_event__="label_values_collapsed"} msg="label values collapsed" label=request_id estimated_values=3 threshold=2 collapsed_value=__collapsed__`,
+		`{app="foo", request_id="0"} line`,
+		`{app="foo", request_id="1"} line`,
+		`{app="foo", request_id="__collapsed__"} line request_id="2"`,
+		`{app="foo", request_id="__collapsed__"} line request_id="3"`,
+	}
+	// The tenant is told about the collapsed label by an event, pushed asynchronously.
+	test.Poll(t, time.Second, expected, func() interface{} {
+		streams := ingester.pushedStreams()
+		sort.Strings(streams)
+		return streams
+	})
+	require.Equal(t, float64(2), testutil.ToFloat64(distributors[0].collapsedStreams.WithLabelValues("test")))
+}
+
+func Test_RejectUnmovedCollapsedLabelsOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.LabelCardinalityCollapseThreshold = 1
+	limits.MaxLineSize = 10
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	// The structured metadata is disallowed and the lines are at the max line size, so the values of the collapsed
+	// labels can't be moved: the entries are rejected rather than losing them.
+	request := &logproto.PushRequest{}
+	for i := 0; i < 2; i++ {
+		request.Streams = append(request.Streams, logproto.Stream{
+			Labels:  fmt.Sprintf(`{app="foo", request_id="%d"}`, i),
+			Entries: []logproto.Entry{{Timestamp: time.Now(), Line: strings.Repeat("0", 10)}},
+		})
+	}
+	resp, err := distributors[0].push(ctx, request, true)
+	require.NoError(t, err)
+	require.Len(t, resp.RejectedStreams, 1)
+	rejected := resp.RejectedStreams[0]
+	require.Equal(t, int32(1), rejected.Index)
+	require.Len(t, rejected.Entries, 1)
+	require.Equal(t, validation.CollapsedLabelsTooLong, rejected.Entries[0].Reason)
+	require.Equal(t, float64(1), testutil.ToFloat64(validation.DiscardedSamples.WithLabelValues(validation.CollapsedLabelsTooLong, "test")))
+	require.Equal(t, float64(10), testutil.ToFloat64(validation.DiscardedBytes.WithLabelValues(validation.CollapsedLabelsTooLong, "test")))
+}
+
+func Test_MoveCollapsedLabels(t *testing.T) {
+	collapsed := []labels.Label{{Name: "request_id", Value: "42"}}
+	for _, tc := range []struct {
+		name             string
+		vContext         validationContext
+		entry            logproto.Entry
+		expectedLine     string
+		expectedMetadata push.LabelsAdapter
+		expectedUnmoved  []int
+	}{
+		{
+			name:         "appended to the line",
+			entry:        logproto.Entry{Line: "line"},
+			expectedLine: `line request_id="42"`,
+		},
+		{
+			name:         "appended to the line within the max line size",
+			vContext:     validationContext{maxLineSize: 20},
+			entry:        logproto.Entry{Line: "line"},
+			expectedLine: `line request_id="42"`,
+		},
+		{
+			name:         "line truncated before the values",
+			vContext:     validationContext{maxLineSize: 20, maxLineSizeTruncate: true},
+			entry:        logproto.Entry{Line: "a longer line"},
+			expectedLine: `a lo request_id="42"`,
+		},
+		{
+			name:            "not moved to the lines which would be too long",
+			vContext:        validationContext{maxLineSize: 20},
+			entry:           logproto.Entry{Line: "a longer line"},
+			expectedLine:    "a longer line",
+			expectedUnmoved: []int{0},
+		},
+		{
+			name:            "not moved to the lines at the max line size without structured metadata",
+			vContext:        validationContext{maxLineSize: 20, allowStructuredMetadata: false},
+			entry:           logproto.Entry{Line: "a line of 20 bytes.."},
+			expectedLine:    "a line of 20 bytes..",
+			expectedUnmoved: []int{0},
+		},
+		{
+			name:            "not moved to the truncated lines if the values alone are too long",
+			vContext:        validationContext{maxLineSize: 10, maxLineSizeTruncate: true},
+			entry:           logproto.Entry{Line: "line"},
+			expectedLine:    "line",
+			expectedUnmoved: []int{0},
+		},
+		{
+			name:             "moved to the structured metadata",
+			vContext:         validationContext{allowStructuredMetadata: true, maxStructuredMetadataSize: 25, maxStructuredMetadataCount: 2},
+			entry:            logproto.Entry{Line: "line", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}}},
+			expectedLine:     "line",
+			expectedMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}, {Name: "request_id", Value: "42"}},
+		},
+		{
+			name:             "appended to the line if the structured metadata would be too large",
+			vContext:         validationContext{allowStructuredMetadata: true, maxStructuredMetadataSize: 15},
+			entry:            logproto.Entry{Line: "line", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}}},
+			expectedLine:     `line request_id="42"`,
+			expectedMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}},
+		},
+		{
+			name:             "appended to the line if the structured metadata would have too many labels",
+			vContext:         validationContext{allowStructuredMetadata: true, maxStructuredMetadataCount: 1},
+			entry:            logproto.Entry{Line: "line", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}}},
+			expectedLine:     `line request_id="42"`,
+			expectedMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "1"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := logproto.Stream{Entries: []logproto.Entry{tc.entry}}
+			require.Equal(t, tc.expectedUnmoved, moveCollapsedLabels(tc.vContext, &stream, collapsed))
+			require.Equal(t, tc.expectedLine, stream.Entries[0].Line)
+			require.Equal(t, tc.expectedMetadata, stream.Entries[0].StructuredMetadata)
+			if tc.vContext.maxLineSize > 0 && len(tc.expectedUnmoved) == 0 {
+				require.LessOrEqual(t, len(stream.Entries[0].Line), tc.vContext.maxLineSize)
+			}
+		})
+	}
+}
+
+func Test_AggregateStreamsOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.StreamAggregations = []validation.StreamAggregation{{Name: "lines_total", By: []string{"app"}, Value: validation.StreamAggregationLines}}
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	store := &mockAggregationStorage{}
+	appendFailures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"tenant"})
+	distributors[0].aggregator = newStreamAggregator(AggregationConfig{Interval: time.Minute, IdleTimeout: time.Hour}, "distributor-1", store, appendFailures, log.NewNopLogger())
+
+	_, err := distributors[0].Push(ctx, &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="foo", pod="foo-1"}`, Entries: []logproto.Entry{{Timestamp: time.Now(), Line: "line"}, {Timestamp: time.Now(), Line: "line"}}},
+		{Labels: `{app="foo", pod="foo-2"}`, Entries: []logproto.Entry{{Timestamp: time.Now(), Line: "line"}}},
+	}})
+	require.NoError(t, err)
+
+	distributors[0].aggregator.flush(context.Background())
+	samples := store.flushed()
+	require.Len(t, samples, 1)
+	require.Equal(t, "test", samples[0].tenant)
+	require.Equal(t, labels.FromStrings(labels.MetricName, "lines_total", "app", "foo", AggregationInstanceLabel, "distributor-1"), samples[0].labels)
+	require.Equal(t, 3.0, samples[0].v)
+}
+
+func Test_AggregateStreamsOnPushPartialSuccess(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxLineSize = 10
+	limits.StreamAggregations = []validation.StreamAggregation{{Name: "lines_total", Value: validation.StreamAggregationLines}}
+	ingester := &mockIngester{
+		// The ingester rejects the second entry it gets, the third entry of the request.
+		rejected: []logproto.RejectedStream{{Labels: `{foo="bar"}`, Entries: []logproto.RejectedEntry{
+			{Index: 1, Reason: validation.StreamRateLimit, Message: "rate limited"},
+		}}},
+	}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	store := &mockAggregationStorage{}
+	appendFailures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"tenant"})
+	distributors[0].aggregator = newStreamAggregator(AggregationConfig{Interval: time.Minute, IdleTimeout: time.Hour}, "distributor-1", store, appendFailures, log.NewNopLogger())
+
+	request := makeWriteRequest(4, 5)
+	request.Streams[0].Entries[1].Line = strings.Repeat("0", 11)
+	resp, err := distributors[0].push(ctx, request, true)
+	require.NoError(t, err)
+	require.Len(t, resp.RejectedStreams, 1)
+
+	// The entries rejected by the distributor and the ingester are not counted.
+	distributors[0].aggregator.flush(context.Background())
+	samples := store.flushed()
+	require.Len(t, samples, 1)
+	require.Equal(t, 2.0, samples[0].v)
+}
+
 func Test_TruncateLogLines(t *testing.T) {
 	setup := func() (*validation.Limits, *mockIngester) {
 		limits := &validation.Limits{}
@@ -1158,7 +1481,7 @@ func prepare(t *testing.T, numDistributors, numIngesters int, limits *validation
 		overrides, err := validation.NewOverrides(*limits, nil)
 		require.NoError(t, err)
 
-		d, err := New(distributorConfig, clientConfig, runtime.DefaultTenantConfigs(), ingestersRing, overrides, prometheus.NewPedanticRegistry())
+		d, err := New(distributorConfig, clientConfig, runtime.DefaultTenantConfigs(), ingestersRing, overrides, prometheus.NewPedanticRegistry(), nil)
 		require.NoError(t, err)
 		require.NoError(t, services.StartAndAwaitRunning(context.Background(), d))
 		distributors[i] = d
@@ -1216,6 +1539,7 @@ type mockIngester struct {
 
 	failAfter    time.Duration
 	succeedAfter time.Duration
+	rejected     []logproto.RejectedStream
 	mu           sync.Mutex
 	pushed       []*logproto.PushRequest
 }
@@ -1233,7 +1557,27 @@ func (i *mockIngester) Push(_ context.Context, in *logproto.PushRequest, _ ...gr
 	defer i.mu.Unlock()
 
 	i.pushed = append(i.pushed, in)
-	return nil, nil
+	return &logproto.PushResponse{RejectedStreams: i.rejected}, nil
+}
+
+// pushedStreams returns the labels of the streams pushed to the ingester followed by the line of their first entry,
+// without the duplicates of the replication.
+func (i *mockIngester) pushedStreams() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	seen := map[string]struct{}{}
+	var streams []string
+	for _, req := range i.pushed {
+		for _, stream := range req.Streams {
+			s := stream.Labels + " " + stream.Entries[0].Line
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				streams = append(streams, s)
+			}
+		}
+	}
+	return streams
 }
 
 func (i *mockIngester) GetStreamRates(_ context.Context, _ *logproto.StreamRatesRequest, _ ...grpc.CallOption) (*logproto.StreamRatesResponse, error) {
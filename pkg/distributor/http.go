@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/weaveworks/common/httpgrpc"
 
@@ -13,12 +14,30 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	"github.com/grafana/loki/pkg/validation"
 )
 
 // PushHandler reads a snappy-compressed proto from the HTTP body.
 func (d *Distributor) PushHandler(w http.ResponseWriter, r *http.Request) {
+	d.pushHandler(w, r, func(logger log.Logger, tenantID string, r *http.Request) (*logproto.PushRequest, error) {
+		return push.ParseRequest(logger, tenantID, r, d.tenantsRetention)
+	}, http.StatusNoContent, push.AcceptsPartialSuccess(r))
+}
+
+// OTLPPushHandler reads an OTLP/HTTP logs export request, either protobuf or JSON, from the HTTP body.
+func (d *Distributor) OTLPPushHandler(w http.ResponseWriter, r *http.Request) {
+	d.pushHandler(w, r, func(logger log.Logger, tenantID string, r *http.Request) (*logproto.PushRequest, error) {
+		return push.ParseOTLPRequest(logger, tenantID, r, d.tenantsRetention, d.validator.Limits.OTLPConfig(tenantID))
+	}, http.StatusOK, false)
+}
+
+type requestParser func(logger log.Logger, tenantID string, r *http.Request) (*logproto.PushRequest, error)
+
+// pushHandler pushes the request parsed from the HTTP body. The clients accepting partial success get the rejected
+// entries in the push response instead of an error.
+func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, parse requestParser, successCode int, partialSuccess bool) {
 	logger := util_log.WithContext(r.Context(), util_log.Logger)
 	tenantID, err := tenant.TenantID(r.Context())
 	if err != nil {
@@ -26,7 +45,7 @@ func (d *Distributor) PushHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	req, err := push.ParseRequest(logger, tenantID, r, d.tenantsRetention)
+	req, err := parse(logger, tenantID, r)
 	if err != nil {
 		if d.tenantConfigs.LogPushRequest(tenantID) {
 			level.Debug(logger).Log(
@@ -52,14 +71,21 @@ func (d *Distributor) PushHandler(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	_, err = d.Push(r.Context(), req)
+	pushResp, err := d.push(r.Context(), req, partialSuccess)
 	if err == nil {
 		if d.tenantConfigs.LogPushRequest(tenantID) {
 			level.Debug(logger).Log(
 				"msg", "push request successful",
+				"rejected_streams", len(pushResp.RejectedStreams),
 			)
 		}
-		w.WriteHeader(http.StatusNoContent)
+		if !partialSuccess {
+			w.WriteHeader(successCode)
+			return
+		}
+		if err := push.WriteResponse(w, r, pushResp); err != nil {
+			level.Error(logger).Log("msg", "error writing push response", "err", err)
+		}
 		return
 	}
 
@@ -1,14 +1,21 @@
 package distributor
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/validation"
 )
 
@@ -54,3 +61,41 @@ func TestDistributorRingHandler(t *testing.T) {
 		require.NotContains(t, string(body), "<th>Instance ID</th>")
 	})
 }
+
+func TestPushHandlerPartialSuccess(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.MaxLineSize = 10
+	distributors, _ := prepare(t, 1, 3, limits, nil)
+
+	now := time.Now().UnixNano()
+	body := fmt.Sprintf(`{"streams":[{"stream":{"foo":"bar"},"values":[["%d","line"],["%d","too long line"]]}]}`, now, now+1)
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req.WithContext(user.InjectOrgID(req.Context(), "fake"))
+	}
+
+	t.Run("lists the rejected entries", func(t *testing.T) {
+		req := newRequest()
+		req.Header.Set(push.PartialSuccessHeader, "true")
+		w := httptest.NewRecorder()
+		distributors[0].PushHandler(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var resp logproto.PushResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.RejectedStreams, 1)
+		require.Equal(t, `{foo="bar"}`, resp.RejectedStreams[0].Labels)
+		require.Len(t, resp.RejectedStreams[0].Entries, 1)
+		require.Equal(t, int32(1), resp.RejectedStreams[0].Entries[0].Index)
+		require.Equal(t, validation.LineTooLong, resp.RejectedStreams[0].Entries[0].Reason)
+	})
+
+	t.Run("fails without partial success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		distributors[0].PushHandler(w, newRequest())
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,188 @@
+package distributor
+
+import (
+	"flag"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/util/hll"
+)
+
+const (
+	// CollapsedLabelValue is the value of the labels whose values are collapsed, see
+	// the label_cardinality_collapse_threshold limit.
+	CollapsedLabelValue = "__collapsed__"
+
+	// EventLabel is the label of the streams of the events pushed by the distributors to the tenants they concern.
+	EventLabel = "__loki_event__"
+	// LabelValuesCollapsedEvent is the EventLabel value of the events of the labels whose values started to be collapsed.
+	LabelValuesCollapsedEvent = "label_values_collapsed"
+)
+
+// LabelCardinalityConfig configures the estimation of the number of values of the labels of the streams, used by
+// the label_cardinality_collapse_threshold limit.
+type LabelCardinalityConfig struct {
+	Window time.Duration `yaml:"window"`
+}
+
+func (cfg *LabelCardinalityConfig) RegisterFlagsWithPrefix(prefix string, fs *flag.FlagSet) {
+	fs.DurationVar(&cfg.Window, prefix+".window", time.Hour, "The window over which each distributor counts the distinct values of the labels of the streams of the tenants. The values are counted over the current and the previous windows, which are aligned to the wall clock so that all the distributors start them at the same time. A label whose values are collapsed stays collapsed until the end of the window.")
+}
+
+// labelCardinalityTracker estimates the number of distinct values of each label of the streams pushed by each tenant
+// over the current and the previous windows, and collapses the values of the labels having more values than the
+// threshold of the tenant. Once collapsed, the values of a label stay collapsed until the end of the window, so that
+// the streams of the label don't flip between collapsed and original values. The windows are aligned to the wall clock,
+// so that the distributors, which each estimate the numbers of values of the streams they receive, start and end them
+// at the same time.
+type labelCardinalityTracker struct {
+	window time.Duration
+	now    func() time.Time
+
+	mtx       sync.Mutex
+	tenants   map[string]*tenantLabelCardinality
+	lastSweep time.Time
+}
+
+type tenantLabelCardinality struct {
+	mtx    sync.Mutex
+	start  time.Time
+	labels map[string]*labelCardinality
+	// events are the events of the labels whose values started to be collapsed, not yet pushed to the tenant.
+	events []labelCardinalityEvent
+}
+
+type labelCardinality struct {
+	current, previous hll.Sketch
+	// estimate is the greatest count of the current and previous sketches.
+	estimate float64
+	// collapsedUntil is the end of the window during which the values of the label were last collapsed.
+	collapsedUntil time.Time
+}
+
+type labelCardinalityEvent struct {
+	time      time.Time
+	label     string
+	estimate  int
+	threshold int
+}
+
+func newLabelCardinalityTracker(cfg LabelCardinalityConfig) *labelCardinalityTracker {
+	return &labelCardinalityTracker{
+		window:  cfg.Window,
+		now:     time.Now,
+		tenants: map[string]*tenantLabelCardinality{},
+	}
+}
+
+// collapse adds the values of the labels to the sketches of the tenant. It returns the labels with the values of the
+// labels having more values than the threshold replaced by CollapsedLabelValue, and the collapsed labels with their
+// original values.
+func (t *labelCardinalityTracker) collapse(tenantID string, ls labels.Labels, threshold int) (labels.Labels, []labels.Label) {
+	now := t.now()
+	tenant := t.tenant(tenantID, now)
+	tenant.mtx.Lock()
+	defer tenant.mtx.Unlock()
+
+	tenant.rotate(now, t.window)
+
+	var collapsed []labels.Label
+	for _, l := range ls {
+		c, ok := tenant.labels[l.Name]
+		if !ok {
+			c = &labelCardinality{}
+			tenant.labels[l.Name] = c
+		}
+		if c.current.Insert(xxhash.Sum64String(l.Value), 64) {
+			if count := c.current.Count(); count > c.estimate {
+				c.estimate = count
+			}
+		}
+		if math.Round(c.estimate) > float64(threshold) {
+			if !now.Before(c.collapsedUntil) {
+				tenant.events = append(tenant.events, labelCardinalityEvent{
+					time:      now,
+					label:     l.Name,
+					estimate:  int(math.Round(c.estimate)),
+					threshold: threshold,
+				})
+			}
+			c.collapsedUntil = tenant.start.Add(t.window)
+		}
+		if now.Before(c.collapsedUntil) {
+			collapsed = append(collapsed, l)
+		}
+	}
+	if len(collapsed) == 0 {
+		return ls, nil
+	}
+
+	b := labels.NewBuilder(ls)
+	for _, l := range collapsed {
+		b.Set(l.Name, CollapsedLabelValue)
+	}
+	return b.Labels(), collapsed
+}
+
+// events returns the events of the tenant not yet pushed, and forgets them.
+func (t *labelCardinalityTracker) events(tenantID string) []labelCardinalityEvent {
+	tenant := t.tenant(tenantID, t.now())
+	tenant.mtx.Lock()
+	defer tenant.mtx.Unlock()
+
+	events := tenant.events
+	tenant.events = nil
+	return events
+}
+
+// tenant returns the sketches of the tenant. The tenants which did not push during the last two windows are forgotten.
+func (t *labelCardinalityTracker) tenant(tenantID string, now time.Time) *tenantLabelCardinality {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if now.Sub(t.lastSweep) >= t.window {
+		for id, tenant := range t.tenants {
+			tenant.mtx.Lock()
+			if now.Sub(tenant.start) >= 2*t.window && len(tenant.events) == 0 {
+				delete(t.tenants, id)
+			}
+			tenant.mtx.Unlock()
+		}
+		t.lastSweep = now
+	}
+
+	tenant, ok := t.tenants[tenantID]
+	if !ok {
+		tenant = &tenantLabelCardinality{
+			start:  now.Truncate(t.window),
+			labels: map[string]*labelCardinality{},
+		}
+		t.tenants[tenantID] = tenant
+	}
+	return tenant
+}
+
+// rotate starts a new window if the current one is over. The labels without values during the previous window are forgotten.
+func (t *tenantLabelCardinality) rotate(now time.Time, window time.Duration) {
+	elapsed := now.Sub(t.start)
+	if elapsed < window {
+		return
+	}
+	for name, c := range t.labels {
+		if elapsed < 2*window {
+			c.previous = c.current
+		} else {
+			c.previous = hll.Sketch{}
+		}
+		c.current = hll.Sketch{}
+		c.estimate = c.previous.Count()
+		if c.estimate == 0 {
+			delete(t.labels, name)
+		}
+	}
+	t.start = now.Truncate(window)
+}
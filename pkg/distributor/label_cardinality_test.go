@@ -0,0 +1,83 @@
+package distributor
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelCardinalityTracker(t *testing.T) {
+	now := time.Unix(0, 0)
+	tracker := newLabelCardinalityTracker(LabelCardinalityConfig{Window: time.Hour})
+	tracker.now = func() time.Time { return now }
+
+	push := func(tenant string, id int) (labels.Labels, []labels.Label) {
+		return tracker.collapse(tenant, labels.FromStrings("app", "foo", "request_id", strconv.Itoa(id)), 10)
+	}
+
+	for i := 0; i < 10; i++ {
+		ls, collapsed := push("tenant 1", i)
+		require.Equal(t, strconv.Itoa(i), ls.Get("request_id"))
+		require.Empty(t, collapsed)
+	}
+	require.Empty(t, tracker.events("tenant 1"))
+
+	// The label values are collapsed once the threshold is crossed, and an event is emitted once.
+	for i := 10; i < 20; i++ {
+		ls, collapsed := push("tenant 1", i)
+		require.Equal(t, labels.FromStrings("app", "foo", "request_id", CollapsedLabelValue), ls)
+		require.Equal(t, []labels.Label{{Name: "request_id", Value: strconv.Itoa(i)}}, collapsed)
+	}
+	events := tracker.events("tenant 1")
+	require.Len(t, events, 1)
+	require.Equal(t, "request_id", events[0].label)
+	require.Equal(t, 10, events[0].threshold)
+	require.Empty(t, tracker.events("tenant 1"))
+
+	// The other tenants are not affected.
+	_, collapsed := push("tenant 2", 0)
+	require.Empty(t, collapsed)
+
+	// The values of the previous window are still counted.
+	now = now.Add(time.Hour)
+	_, collapsed = push("tenant 1", 0)
+	require.Len(t, collapsed, 1)
+
+	// The label is no longer collapsed once its values of the previous window are forgotten.
+	now = now.Add(2 * time.Hour)
+	_, collapsed = push("tenant 1", 0)
+	require.Empty(t, collapsed)
+	require.NotContains(t, tracker.tenants, "tenant 2")
+}
+
+func TestLabelCardinalityTracker_CollapsedForTheWindow(t *testing.T) {
+	// The windows are aligned to the wall clock, so the first one ends at 1h.
+	now := time.Unix(0, 0).Add(30 * time.Minute)
+	tracker := newLabelCardinalityTracker(LabelCardinalityConfig{Window: time.Hour})
+	tracker.now = func() time.Time { return now }
+
+	push := func(id, threshold int) []labels.Label {
+		_, collapsed := tracker.collapse("tenant", labels.FromStrings("request_id", strconv.Itoa(id)), threshold)
+		return collapsed
+	}
+
+	for i := 0; i < 3; i++ {
+		push(i, 2)
+	}
+	require.Len(t, tracker.events("tenant"), 1)
+
+	// The label stays collapsed until the end of the window, even if the threshold is raised.
+	now = now.Add(29 * time.Minute)
+	require.Len(t, push(0, 10), 1)
+	require.Empty(t, tracker.events("tenant"))
+
+	now = now.Add(time.Minute)
+	require.Empty(t, push(0, 10))
+
+	// It is collapsed again, with a new event, once it exceeds the threshold again.
+	require.Len(t, push(1, 2), 1)
+	require.Len(t, tracker.events("tenant"), 1)
+}
@@ -3,8 +3,12 @@ package distributor
 import (
 	"time"
 
+	"github.com/prometheus/prometheus/model/relabel"
+
 	"github.com/grafana/loki/pkg/distributor/shardstreams"
+	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/storage/stores/indexshipper/compactor/retention"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 // Limits is an interface for distributor limits/related configs
@@ -23,8 +27,19 @@ type Limits interface {
 
 	IncrementDuplicateTimestamps(userID string) bool
 
+	AllowStructuredMetadata(userID string) bool
+	MaxStructuredMetadataSize(userID string) int
+	MaxStructuredMetadataCount(userID string) int
+
+	RelabelConfigs(userID string) []*relabel.Config
+	RelabelConfigsHash(userID string) uint64
+	LabelCardinalityCollapseThreshold(userID string) int
+
 	ShardStreams(userID string) *shardstreams.Config
+	OTLPConfig(userID string) push.OTLPConfig
 	IngestionRateStrategy() string
 	IngestionRateBytes(userID string) float64
 	IngestionBurstSizeBytes(userID string) int
+	IngestionTenantShardSize(userID string) int
+	StreamAggregations(userID string) []validation.StreamAggregation
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/validation"
@@ -41,6 +42,14 @@ type validationContext struct {
 
 	incrementDuplicateTimestamps bool
 
+	allowStructuredMetadata    bool
+	maxStructuredMetadataSize  int
+	maxStructuredMetadataCount int
+
+	relabelConfigs                    []*relabel.Config
+	relabelConfigsHash                uint64
+	labelCardinalityCollapseThreshold int
+
 	userID string
 }
 
@@ -56,11 +65,24 @@ func (v Validator) getValidationContextForTime(now time.Time, userID string) val
 		maxLabelNameLength:           v.MaxLabelNameLength(userID),
 		maxLabelValueLength:          v.MaxLabelValueLength(userID),
 		incrementDuplicateTimestamps: v.IncrementDuplicateTimestamps(userID),
+		allowStructuredMetadata:      v.AllowStructuredMetadata(userID),
+		maxStructuredMetadataSize:    v.MaxStructuredMetadataSize(userID),
+		maxStructuredMetadataCount:   v.MaxStructuredMetadataCount(userID),
+		relabelConfigs:               v.RelabelConfigs(userID),
+		relabelConfigsHash:           v.RelabelConfigsHash(userID),
+
+		labelCardinalityCollapseThreshold: v.LabelCardinalityCollapseThreshold(userID),
 	}
 }
 
 // ValidateEntry returns an error if the entry is invalid and report metrics for invalid entries accordingly.
 func (v Validator) ValidateEntry(ctx validationContext, labels string, entry logproto.Entry) error {
+	_, err := v.validateEntry(ctx, labels, entry)
+	return err
+}
+
+// validateEntry is like ValidateEntry but also returns the reason of the rejection of an invalid entry.
+func (v Validator) validateEntry(ctx validationContext, labels string, entry logproto.Entry) (string, error) {
 	ts := entry.Timestamp.UnixNano()
 	validation.LineLengthHist.Observe(float64(len(entry.Line)))
 
@@ -70,14 +92,14 @@ func (v Validator) ValidateEntry(ctx validationContext, labels string, entry log
 		formatedRejectMaxAgeTime := time.Unix(0, ctx.rejectOldSampleMaxAge).Format(timeFormat)
 		validation.DiscardedSamples.WithLabelValues(validation.GreaterThanMaxSampleAge, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.GreaterThanMaxSampleAge, ctx.userID).Add(float64(len(entry.Line)))
-		return fmt.Errorf(validation.GreaterThanMaxSampleAgeErrorMsg, labels, formatedEntryTime, formatedRejectMaxAgeTime)
+		return validation.GreaterThanMaxSampleAge, fmt.Errorf(validation.GreaterThanMaxSampleAgeErrorMsg, labels, formatedEntryTime, formatedRejectMaxAgeTime)
 	}
 
 	if ts > ctx.creationGracePeriod {
 		formatedEntryTime := entry.Timestamp.Format(timeFormat)
 		validation.DiscardedSamples.WithLabelValues(validation.TooFarInFuture, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.TooFarInFuture, ctx.userID).Add(float64(len(entry.Line)))
-		return fmt.Errorf(validation.TooFarInFutureErrorMsg, labels, formatedEntryTime)
+		return validation.TooFarInFuture, fmt.Errorf(validation.TooFarInFutureErrorMsg, labels, formatedEntryTime)
 	}
 
 	if maxSize := ctx.maxLineSize; maxSize != 0 && len(entry.Line) > maxSize {
@@ -87,39 +109,77 @@ func (v Validator) ValidateEntry(ctx validationContext, labels string, entry log
 		// for parity.
 		validation.DiscardedSamples.WithLabelValues(validation.LineTooLong, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.LineTooLong, ctx.userID).Add(float64(len(entry.Line)))
-		return fmt.Errorf(validation.LineTooLongErrorMsg, maxSize, labels, len(entry.Line))
+		return validation.LineTooLong, fmt.Errorf(validation.LineTooLongErrorMsg, maxSize, labels, len(entry.Line))
 	}
 
-	return nil
+	if len(entry.StructuredMetadata) > 0 {
+		if !ctx.allowStructuredMetadata {
+			validation.DiscardedSamples.WithLabelValues(validation.DisallowedStructuredMetadata, ctx.userID).Inc()
+			validation.DiscardedBytes.WithLabelValues(validation.DisallowedStructuredMetadata, ctx.userID).Add(float64(len(entry.Line)))
+			return validation.DisallowedStructuredMetadata, fmt.Errorf(validation.DisallowedStructuredMetadataErrorMsg, labels)
+		}
+
+		var structuredMetadataSize int
+		for _, l := range entry.StructuredMetadata {
+			structuredMetadataSize += len(l.Name) + len(l.Value)
+		}
+		if maxSize := ctx.maxStructuredMetadataSize; maxSize != 0 && structuredMetadataSize > maxSize {
+			validation.DiscardedSamples.WithLabelValues(validation.StructuredMetadataTooLarge, ctx.userID).Inc()
+			validation.DiscardedBytes.WithLabelValues(validation.StructuredMetadataTooLarge, ctx.userID).Add(float64(len(entry.Line)))
+			return validation.StructuredMetadataTooLarge, fmt.Errorf(validation.StructuredMetadataTooLargeErrorMsg, labels, structuredMetadataSize, maxSize)
+		}
+
+		if maxCount := ctx.maxStructuredMetadataCount; maxCount != 0 && len(entry.StructuredMetadata) > maxCount {
+			validation.DiscardedSamples.WithLabelValues(validation.StructuredMetadataTooMany, ctx.userID).Inc()
+			validation.DiscardedBytes.WithLabelValues(validation.StructuredMetadataTooMany, ctx.userID).Add(float64(len(entry.Line)))
+			return validation.StructuredMetadataTooMany, fmt.Errorf(validation.StructuredMetadataTooManyErrorMsg, labels, len(entry.StructuredMetadata), maxCount)
+		}
+	}
+
+	return "", nil
+}
+
+// rejectUnmovedCollapsedLabels returns the reason of the rejection of an entry which can't hold the values of the
+// collapsed labels of its stream, see moveCollapsedLabels.
+func (v Validator) rejectUnmovedCollapsedLabels(ctx validationContext, labels string, entry logproto.Entry) (string, error) {
+	validation.DiscardedSamples.WithLabelValues(validation.CollapsedLabelsTooLong, ctx.userID).Inc()
+	validation.DiscardedBytes.WithLabelValues(validation.CollapsedLabelsTooLong, ctx.userID).Add(float64(len(entry.Line)))
+	return validation.CollapsedLabelsTooLong, fmt.Errorf(validation.CollapsedLabelsTooLongErrorMsg, labels, ctx.maxLineSize)
 }
 
 // Validate labels returns an error if the labels are invalid
 func (v Validator) ValidateLabels(ctx validationContext, ls labels.Labels, stream logproto.Stream) error {
+	_, err := v.validateLabels(ctx, ls, stream)
+	return err
+}
+
+// validateLabels is like ValidateLabels but also returns the reason of the rejection of the entries of the stream.
+func (v Validator) validateLabels(ctx validationContext, ls labels.Labels, stream logproto.Stream) (string, error) {
 	if len(ls) == 0 {
 		validation.DiscardedSamples.WithLabelValues(validation.MissingLabels, ctx.userID).Inc()
-		return fmt.Errorf(validation.MissingLabelsErrorMsg)
+		return validation.MissingLabels, fmt.Errorf(validation.MissingLabelsErrorMsg)
 	}
 	numLabelNames := len(ls)
 	if numLabelNames > ctx.maxLabelNamesPerSeries {
 		updateMetrics(validation.MaxLabelNamesPerSeries, ctx.userID, stream)
-		return fmt.Errorf(validation.MaxLabelNamesPerSeriesErrorMsg, stream.Labels, numLabelNames, ctx.maxLabelNamesPerSeries)
+		return validation.MaxLabelNamesPerSeries, fmt.Errorf(validation.MaxLabelNamesPerSeriesErrorMsg, stream.Labels, numLabelNames, ctx.maxLabelNamesPerSeries)
 	}
 
 	lastLabelName := ""
 	for _, l := range ls {
 		if len(l.Name) > ctx.maxLabelNameLength {
 			updateMetrics(validation.LabelNameTooLong, ctx.userID, stream)
-			return fmt.Errorf(validation.LabelNameTooLongErrorMsg, stream.Labels, l.Name)
+			return validation.LabelNameTooLong, fmt.Errorf(validation.LabelNameTooLongErrorMsg, stream.Labels, l.Name)
 		} else if len(l.Value) > ctx.maxLabelValueLength {
 			updateMetrics(validation.LabelValueTooLong, ctx.userID, stream)
-			return fmt.Errorf(validation.LabelValueTooLongErrorMsg, stream.Labels, l.Value)
+			return validation.LabelValueTooLong, fmt.Errorf(validation.LabelValueTooLongErrorMsg, stream.Labels, l.Value)
 		} else if cmp := strings.Compare(lastLabelName, l.Name); cmp == 0 {
 			updateMetrics(validation.DuplicateLabelNames, ctx.userID, stream)
-			return fmt.Errorf(validation.DuplicateLabelNamesErrorMsg, stream.Labels, l.Name)
+			return validation.DuplicateLabelNames, fmt.Errorf(validation.DuplicateLabelNamesErrorMsg, stream.Labels, l.Name)
 		}
 		lastLabelName = l.Name
 	}
-	return nil
+	return "", nil
 }
 
 func updateMetrics(reason, userID string, stream logproto.Stream) {
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// partialSuccessHeaderName is the gRPC metadata set on the push requests of the clients accepting partial success,
+// i.e. a push response listing the rejected entries instead of an error.
+const partialSuccessHeaderName = "x-loki-partial-success"
+
+// InjectPartialSuccess returns a context accepting partial success for the push requests to the ingesters.
+func InjectPartialSuccess(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, partialSuccessHeaderName, "true")
+}
+
+// ExtractPartialSuccess returns whether the client of the push request of the context accepts partial success.
+func ExtractPartialSuccess(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	values := md.Get(partialSuccessHeaderName)
+	return len(values) > 0 && values[0] == "true"
+}
@@ -0,0 +1,153 @@
+package ingester
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+const (
+	// maxDictionarySampleLength is the maximum length of the lines sampled to train a dictionary.
+	maxDictionarySampleLength = 1024
+	dictionaryOpTimeout       = time.Minute
+)
+
+// DictionaryConfig configures the training of the dictionaries of the zstd-dict chunk encoding.
+type DictionaryConfig struct {
+	MaxSize       int           `yaml:"max_size"`
+	TrainingSize  int           `yaml:"training_size"`
+	RetrainPeriod time.Duration `yaml:"retrain_period"`
+	SyncPeriod    time.Duration `yaml:"sync_period"`
+}
+
+// RegisterFlags registers the flags.
+func (cfg *DictionaryConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.MaxSize, "ingester.zstd-dictionary.max-size", 64*1024, "Maximum size in bytes of a dictionary.")
+	f.IntVar(&cfg.TrainingSize, "ingester.zstd-dictionary.training-size", 1024*1024, "Size in bytes of the lines sampled to train a dictionary. Each tenant being trained holds its training samples in memory.")
+	f.DurationVar(&cfg.RetrainPeriod, "ingester.zstd-dictionary.retrain-period", 24*time.Hour, "How long a dictionary is used before a new one is trained.")
+	f.DurationVar(&cfg.SyncPeriod, "ingester.zstd-dictionary.sync-period", 5*time.Minute, "How often the latest dictionary of a tenant is looked up in the object store, so that all the ingesters of a tenant use the same dictionary and the replicas of a chunk can be deduplicated.")
+}
+
+// Validate validates the config.
+func (cfg *DictionaryConfig) Validate() error {
+	if cfg.MaxSize <= 0 || cfg.TrainingSize <= 0 {
+		return fmt.Errorf("the size of the zstd dictionaries and of their training samples must be positive")
+	}
+	return nil
+}
+
+// dictionaryTrainer trains the dictionaries of a tenant from the lines pushed to it.
+// It publishes the dictionaries it trains, and uses the latest dictionary of the tenant,
+// which may have been trained by another ingester.
+type dictionaryTrainer struct {
+	cfg    *DictionaryConfig
+	tenant string
+
+	// collecting is set while lines are sampled for the training.
+	collecting atomic.Bool
+
+	mtx       sync.Mutex
+	dict      *chunkenc.Dictionary
+	trainedAt time.Time
+	syncedAt  time.Time
+	syncing   bool
+	training  bool
+	samples   [][]byte
+	size      int
+}
+
+func newDictionaryTrainer(cfg *DictionaryConfig, tenant string) *dictionaryTrainer {
+	return &dictionaryTrainer{cfg: cfg, tenant: tenant}
+}
+
+// Observe samples the line for the training of the next dictionary.
+func (t *dictionaryTrainer) Observe(line string) {
+	if !t.collecting.Load() {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if !t.collecting.Load() {
+		return
+	}
+	if len(line) > maxDictionarySampleLength {
+		line = line[:maxDictionarySampleLength]
+	}
+	t.samples = append(t.samples, []byte(line))
+	t.size += len(line)
+	if t.size < t.cfg.TrainingSize {
+		return
+	}
+
+	t.collecting.Store(false)
+	t.training = true
+	samples := t.samples
+	t.samples, t.size = nil, 0
+	go t.train(samples)
+}
+
+func (t *dictionaryTrainer) train(samples [][]byte) {
+	d := chunkenc.TrainDictionary(samples, t.cfg.MaxSize)
+	trainedAt := time.Now()
+
+	if d != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), dictionaryOpTimeout)
+		defer cancel()
+		if err := chunkenc.PublishDictionary(ctx, t.tenant, d, trainedAt); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to publish chunk dictionary", "user", t.tenant, "err", err)
+			d = nil
+		}
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.training = false
+	// Without a dictionary, the samples are collected again once the dictionary would have been retrained.
+	t.trainedAt = trainedAt
+	if d != nil {
+		t.dict = d
+	}
+}
+
+// Dictionary returns the dictionary to compress the new chunks of the tenant with, nil if there is none yet.
+// It periodically looks up the latest dictionary of the tenant, and starts sampling lines when it is due for retraining.
+func (t *dictionaryTrainer) Dictionary() *chunkenc.Dictionary {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if !t.syncing && !t.training && !t.collecting.Load() && time.Since(t.syncedAt) >= t.cfg.SyncPeriod {
+		t.syncing = true
+		go t.sync()
+	}
+	return t.dict
+}
+
+func (t *dictionaryTrainer) sync() {
+	ctx, cancel := context.WithTimeout(context.Background(), dictionaryOpTimeout)
+	defer cancel()
+	latest, trainedAt, err := chunkenc.LatestDictionary(ctx, t.tenant)
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.syncing = false
+	t.syncedAt = time.Now()
+	if err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to get the latest chunk dictionary", "user", t.tenant, "err", err)
+		return
+	}
+
+	if latest != nil && trainedAt.After(t.trainedAt) {
+		t.dict, t.trainedAt = latest, trainedAt
+	}
+	if time.Since(t.trainedAt) >= t.cfg.RetrainPeriod {
+		t.collecting.Store(true)
+	}
+}
@@ -0,0 +1,85 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+)
+
+type memDictionaryStore struct {
+	mtx    sync.Mutex
+	dicts  map[string][]byte
+	latest map[string]uint32
+	at     map[string]time.Time
+}
+
+func (s *memDictionaryStore) GetDictionary(_ context.Context, tenant string, id uint32) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	d, ok := s.dicts[fmt.Sprintf("%s/%08x", tenant, id)]
+	if !ok {
+		return nil, fmt.Errorf("dictionary %08x not found", id)
+	}
+	return d, nil
+}
+
+func (s *memDictionaryStore) PutDictionary(_ context.Context, tenant string, id uint32, content []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.dicts[fmt.Sprintf("%s/%08x", tenant, id)] = content
+	return nil
+}
+
+func (s *memDictionaryStore) GetLatestDictionary(_ context.Context, tenant string) (uint32, time.Time, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.latest[tenant], s.at[tenant], nil
+}
+
+func (s *memDictionaryStore) SetLatestDictionary(_ context.Context, tenant string, id uint32, trainedAt time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.latest[tenant], s.at[tenant] = id, trainedAt
+	return nil
+}
+
+func TestDictionaryTrainer(t *testing.T) {
+	store := &memDictionaryStore{dicts: map[string][]byte{}, latest: map[string]uint32{}, at: map[string]time.Time{}}
+	chunkenc.SetDictionaryStore(store)
+	defer chunkenc.SetDictionaryStore(nil)
+
+	cfg := &DictionaryConfig{MaxSize: 4096, TrainingSize: 64 * 1024, RetrainPeriod: time.Hour, SyncPeriod: 0}
+	trainer := newDictionaryTrainer(cfg, "fake")
+
+	// The first lookup finds no dictionary and starts the sampling of lines.
+	require.Nil(t, trainer.Dictionary())
+	require.Eventually(t, trainer.collecting.Load, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; trainer.collecting.Load(); i++ {
+		trainer.Observe(fmt.Sprintf(`level=info caller=flush.go:%d msg="flushing stream" user=fake reason=idle total_comp=%dB`, i%100, i))
+	}
+	require.Eventually(t, func() bool { return trainer.Dictionary() != nil }, 5*time.Second, 10*time.Millisecond)
+	d := trainer.Dictionary()
+	require.LessOrEqual(t, len(d.Content), cfg.MaxSize)
+	require.Equal(t, d.ID, store.latest["fake"])
+	require.False(t, trainer.collecting.Load())
+
+	// Another ingester uses the latest dictionary of the tenant.
+	other := newDictionaryTrainer(cfg, "fake")
+	require.Nil(t, other.Dictionary())
+	require.Eventually(t, func() bool { return other.Dictionary() != nil }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, d.ID, other.Dictionary().ID)
+	require.False(t, other.collecting.Load())
+
+	// The dictionaries of the other tenants are trained separately.
+	another := newDictionaryTrainer(cfg, "other")
+	require.Nil(t, another.Dictionary())
+	require.Eventually(t, another.collecting.Load, 5*time.Second, 10*time.Millisecond)
+	require.Nil(t, another.Dictionary())
+}
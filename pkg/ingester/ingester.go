@@ -86,6 +86,8 @@ type Config struct {
 	TargetChunkSize     int               `yaml:"chunk_target_size"`
 	ChunkEncoding       string            `yaml:"chunk_encoding"`
 	parsedEncoding      chunkenc.Encoding `yaml:"-"` // placeholder for validated encoding
+	ZstdDictionary      DictionaryConfig  `yaml:"zstd_dictionary" doc:"description=Configures the training of the dictionaries of the zstd-dict chunk encoding. Dictionaries are stored in the object store of the active period."`
+	ChunkParsedColumns  bool              `yaml:"chunk_parsed_columns"`
 	MaxChunkAge         time.Duration     `yaml:"max_chunk_age"`
 	AutoForgetUnhealthy bool              `yaml:"autoforget_unhealthy"`
 
@@ -118,6 +120,7 @@ type Config struct {
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f, util_log.Logger)
 	cfg.WAL.RegisterFlags(f)
+	cfg.ZstdDictionary.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxTransferRetries, "ingester.max-transfer-retries", 0, "Number of times to try and transfer chunks before falling back to flushing. If set to 0 or negative value, transfers are disabled.")
 	f.IntVar(&cfg.ConcurrentFlushes, "ingester.concurrent-flushes", 32, "How many flushes can happen concurrently from each stream.")
@@ -128,6 +131,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.BlockSize, "ingester.chunks-block-size", 256*1024, "The targeted _uncompressed_ size in bytes of a chunk block When this threshold is exceeded the head block will be cut and compressed inside the chunk.")
 	f.IntVar(&cfg.TargetChunkSize, "ingester.chunk-target-size", 1572864, "A target _compressed_ size in bytes for chunks. This is a desired size not an exact size, chunks may be slightly bigger or significantly smaller if they get flushed for other reasons (e.g. chunk_idle_period). A value of 0 creates chunks with a fixed 10 blocks, a non zero value will create chunks with a variable number of blocks to meet the target size.") // 1.5 MB
 	f.StringVar(&cfg.ChunkEncoding, "ingester.chunk-encoding", chunkenc.EncGZIP.String(), fmt.Sprintf("The algorithm to use for compressing chunk. (%s)", chunkenc.SupportedEncoding()))
+	f.BoolVar(&cfg.ChunkParsedColumns, "ingester.chunk-parsed-columns", false, "Store the most common fields of the json or logfmt lines of each chunk block as typed columns next to the lines. Queries only requiring fields stored in the columns read them instead of parsing the lines. Chunks with columns can't be read by older versions.")
 	f.DurationVar(&cfg.SyncPeriod, "ingester.sync-period", 0, "Parameters used to synchronize ingesters to cut chunks at the same moment. Sync period is used to roll over incoming entry to a new chunk. If chunk's utilization isn't high enough (eg. less than 50% when sync_min_utilization is set to 0.5), then this chunk rollover doesn't happen.")
 	f.Float64Var(&cfg.SyncMinUtilization, "ingester.sync-min-utilization", 0, "Minimum utilization of chunk when doing synchronization.")
 	f.IntVar(&cfg.MaxReturnedErrors, "ingester.max-ignored-stream-errors", 10, "The maximum number of errors a stream will report to the user when a push fails. 0 to make unlimited.")
@@ -145,6 +149,11 @@ func (cfg *Config) Validate() error {
 		return err
 	}
 	cfg.parsedEncoding = enc
+	if enc == chunkenc.EncZstdDict {
+		if err := cfg.ZstdDictionary.Validate(); err != nil {
+			return err
+		}
+	}
 
 	if err = cfg.WAL.Validate(); err != nil {
 		return err
@@ -224,6 +233,9 @@ type Ingester struct {
 
 	limiter *Limiter
 
+	// lookupTables resolves the lookup tables referenced by the queries.
+	lookupTables *logql.LookupTables
+
 	// Denotes whether the ingester should flush on shutdown.
 	// Currently only used by the WAL to signal when the disk is full.
 	flushOnShutdownSwitch *OnceSwitch
@@ -247,7 +259,7 @@ type Ingester struct {
 }
 
 // New makes a new Ingester.
-func New(cfg Config, clientConfig client.Config, store ChunkStore, limits Limits, configs *runtime.TenantConfigs, registerer prometheus.Registerer, writeFailuresCfg writefailures.Cfg) (*Ingester, error) {
+func New(cfg Config, clientConfig client.Config, store ChunkStore, limits Limits, lookupTables *logql.LookupTables, configs *runtime.TenantConfigs, registerer prometheus.Registerer, writeFailuresCfg writefailures.Cfg) (*Ingester, error) {
 	if cfg.ingesterClientFactory == nil {
 		cfg.ingesterClientFactory = client.New
 	}
@@ -265,6 +277,7 @@ func New(cfg Config, clientConfig client.Config, store ChunkStore, limits Limits
 		tenantConfigs:         configs,
 		instances:             map[string]*instance{},
 		store:                 store,
+		lookupTables:          lookupTables,
 		periodicConfigs:       store.GetSchemaConfigs(),
 		loopQuit:              make(chan struct{}),
 		flushQueues:           make([]*util.PriorityQueue, cfg.ConcurrentFlushes),
@@ -806,6 +819,10 @@ func (i *Ingester) Push(ctx context.Context, req *logproto.PushRequest) (*logpro
 	if err != nil {
 		return &logproto.PushResponse{}, err
 	}
+	if client.ExtractPartialSuccess(ctx) {
+		rejected, err := instance.PushPartial(ctx, req)
+		return &logproto.PushResponse{RejectedStreams: rejected}, err
+	}
 	return &logproto.PushResponse{}, instance.Push(ctx, req)
 }
 
@@ -856,25 +873,31 @@ func (i *Ingester) Query(req *logproto.QueryRequest, queryServer logproto.Querie
 		return err
 	}
 
+	lookupTables, err := i.lookupTables.Resolve(ctx, instanceID, req.LookupTables)
+	if err != nil {
+		return err
+	}
+
 	instance, err := i.GetOrCreateInstance(instanceID)
 	if err != nil {
 		return err
 	}
-	it, err := instance.Query(ctx, logql.SelectLogParams{QueryRequest: req})
+	it, err := instance.Query(ctx, logql.SelectLogParams{QueryRequest: req, ResolvedLookupTables: lookupTables})
 	if err != nil {
 		return err
 	}
 
 	if start, end, ok := buildStoreRequest(i.cfg, req.Start, req.End, time.Now()); ok {
 		storeReq := logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
-			Selector:  req.Selector,
-			Direction: req.Direction,
-			Start:     start,
-			End:       end,
-			Limit:     req.Limit,
-			Shards:    req.Shards,
-			Deletes:   req.Deletes,
-		}}
+			Selector:     req.Selector,
+			Direction:    req.Direction,
+			Start:        start,
+			End:          end,
+			Limit:        req.Limit,
+			Shards:       req.Shards,
+			Deletes:      req.Deletes,
+			LookupTables: req.LookupTables,
+		}, ResolvedLookupTables: lookupTables}
 		storeItr, err := i.store.SelectLogs(ctx, storeReq)
 		if err != nil {
 			util.LogErrorWithContext(ctx, "closing iterator", it.Close)
@@ -883,6 +906,15 @@ func (i *Ingester) Query(req *logproto.QueryRequest, queryServer logproto.Querie
 		it = iter.NewMergeEntryIterator(ctx, []iter.EntryIterator{it, storeItr}, req.Direction)
 	}
 
+	// the queries with sort, head or tail stages send their first log lines ordered by these stages,
+	// instead of their first log lines by timestamp.
+	expr, err := logql.SelectLogParams{QueryRequest: req, ResolvedLookupTables: lookupTables}.LogSelector()
+	if err != nil {
+		util.LogErrorWithContext(ctx, "closing iterator", it.Close)
+		return err
+	}
+	it = logql.NewOrderedEntryIterator(it, expr, req.Direction, req.Limit)
+
 	defer util.LogErrorWithContext(ctx, "closing iterator", it.Close)
 
 	// sendBatches uses -1 to specify no limit.
@@ -904,23 +936,29 @@ func (i *Ingester) QuerySample(req *logproto.SampleQueryRequest, queryServer log
 		return err
 	}
 
+	lookupTables, err := i.lookupTables.Resolve(ctx, instanceID, req.LookupTables)
+	if err != nil {
+		return err
+	}
+
 	instance, err := i.GetOrCreateInstance(instanceID)
 	if err != nil {
 		return err
 	}
-	it, err := instance.QuerySample(ctx, logql.SelectSampleParams{SampleQueryRequest: req})
+	it, err := instance.QuerySample(ctx, logql.SelectSampleParams{SampleQueryRequest: req, ResolvedLookupTables: lookupTables})
 	if err != nil {
 		return err
 	}
 
 	if start, end, ok := buildStoreRequest(i.cfg, req.Start, req.End, time.Now()); ok {
 		storeReq := logql.SelectSampleParams{SampleQueryRequest: &logproto.SampleQueryRequest{
-			Start:    start,
-			End:      end,
-			Selector: req.Selector,
-			Shards:   req.Shards,
-			Deletes:  req.Deletes,
-		}}
+			Start:        start,
+			End:          end,
+			Selector:     req.Selector,
+			Shards:       req.Shards,
+			Deletes:      req.Deletes,
+			LookupTables: req.LookupTables,
+		}, ResolvedLookupTables: lookupTables}
 		storeItr, err := i.store.SelectSamples(ctx, storeReq)
 		if err != nil {
 			util.LogErrorWithContext(ctx, "closing iterator", it.Close)
@@ -21,12 +21,14 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/loki/pkg/analytics"
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/distributor/writefailures"
 	"github.com/grafana/loki/pkg/ingester/index"
 	"github.com/grafana/loki/pkg/ingester/wal"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/astmapper"
@@ -108,6 +110,9 @@ type instance struct {
 	streamRateCalculator *StreamRateCalculator
 
 	writeFailures *writefailures.Manager
+
+	// dictionary trains the dictionaries of the tenant when chunks are encoded with zstd-dict.
+	dictionary *dictionaryTrainer
 }
 
 func newInstance(
@@ -152,6 +157,9 @@ func newInstance(
 		writeFailures: writeFailures,
 	}
 	i.mapper = newFPMapper(i.getLabelsFromFingerprint)
+	if cfg.parsedEncoding == chunkenc.EncZstdDict {
+		i.dictionary = newDictionaryTrainer(&cfg.ZstdDictionary, instanceID)
+	}
 	return i, err
 }
 
@@ -187,13 +195,26 @@ func (i *instance) consumeChunk(ctx context.Context, ls labels.Labels, chunk *lo
 // happened to *the last stream in the request*. Ex: if three streams are part of the PushRequest
 // and all three failed, the returned error only describes what happened to the last processed stream.
 func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
+	_, err := i.push(ctx, req, false)
+	return err
+}
+
+// PushPartial is like Push but returns the streams of the request with rejected entries instead of an error.
+func (i *instance) PushPartial(ctx context.Context, req *logproto.PushRequest) ([]logproto.RejectedStream, error) {
+	return i.push(ctx, req, true)
+}
+
+func (i *instance) push(ctx context.Context, req *logproto.PushRequest, partialSuccess bool) ([]logproto.RejectedStream, error) {
 	record := recordPool.GetRecord()
 	record.UserID = i.instanceID
 	defer recordPool.PutRecord(record)
 	rateLimitWholeStream := i.limiter.limits.ShardStreams(i.instanceID).Enabled
 
-	var appendErr error
-	for _, reqStream := range req.Streams {
+	var (
+		appendErr error
+		rejected  []logproto.RejectedStream
+	)
+	for idx, reqStream := range req.Streams {
 
 		s, _, err := i.streams.LoadOrStoreNew(reqStream.Labels,
 			func() (*stream, error) {
@@ -210,12 +231,33 @@ func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
 			},
 		)
 		if err != nil {
+			if reason := streamRejectionReason(err); partialSuccess && reason != "" {
+				rejected = append(rejected, rejectedStream(idx, reqStream, reason, err))
+				continue
+			}
 			appendErr = err
 			continue
 		}
 
-		_, appendErr = s.Push(ctx, reqStream.Entries, record, 0, false, rateLimitWholeStream)
+		if !partialSuccess {
+			_, appendErr = s.Push(ctx, reqStream.Entries, record, 0, false, rateLimitWholeStream)
+			s.chunkMtx.Unlock()
+			continue
+		}
+
+		_, failedEntriesWithError, err := s.push(ctx, reqStream.Entries, record, 0, false, rateLimitWholeStream)
+		var entries []logproto.RejectedEntry
+		if err == nil {
+			entries, err = s.rejectedEntries(failedEntriesWithError)
+		}
 		s.chunkMtx.Unlock()
+		if err != nil {
+			appendErr = err
+			continue
+		}
+		if len(entries) > 0 {
+			rejected = append(rejected, logproto.RejectedStream{Index: int32(idx), Labels: reqStream.Labels, Entries: entries})
+		}
 	}
 
 	if !record.IsEmpty() {
@@ -229,12 +271,37 @@ func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
 					)
 				})
 			} else {
-				return err
+				return nil, err
 			}
 		}
 	}
 
-	return appendErr
+	return rejected, appendErr
+}
+
+// streamRejectionReason returns the reason of the rejection of the entries of a stream failing to be created,
+// an empty string if the error is not a rejection.
+func streamRejectionReason(err error) string {
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	if !ok {
+		return ""
+	}
+	switch resp.Code {
+	case http.StatusTooManyRequests:
+		return validation.StreamLimit
+	case http.StatusBadRequest:
+		return validation.InvalidLabels
+	}
+	return ""
+}
+
+// rejectedStream returns the stream of a push request with all its entries rejected.
+func rejectedStream(index int, stream logproto.Stream, reason string, err error) logproto.RejectedStream {
+	entries := make([]logproto.RejectedEntry, 0, len(stream.Entries))
+	for i := range stream.Entries {
+		entries = append(entries, logproto.RejectedEntry{Index: int32(i), Reason: reason, Message: err.Error()})
+	}
+	return logproto.RejectedStream{Index: int32(index), Labels: stream.Labels, Entries: entries}
 }
 
 func (i *instance) createStream(pushReqStream logproto.Stream, record *wal.Record) (*stream, error) {
@@ -279,7 +346,8 @@ func (i *instance) createStream(pushReqStream logproto.Stream, record *wal.Recor
 	fp := i.getHashForLabels(labels)
 
 	sortedLabels := i.index.Add(logproto.FromLabelsToLabelAdapters(labels), fp)
-	s := newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.streamRateCalculator, i.metrics, i.writeFailures)
+	s := newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.limiter.AllowStructuredMetadata(i.instanceID), i.streamRateCalculator, i.metrics, i.writeFailures)
+	s.dictionary = i.dictionary
 
 	// record will be nil when replaying the wal (we don't want to rewrite wal entries as we replay them).
 	if record != nil {
@@ -311,7 +379,8 @@ func (i *instance) createStream(pushReqStream logproto.Stream, record *wal.Recor
 
 func (i *instance) createStreamByFP(ls labels.Labels, fp model.Fingerprint) *stream {
 	sortedLabels := i.index.Add(logproto.FromLabelsToLabelAdapters(ls), fp)
-	s := newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.streamRateCalculator, i.metrics, i.writeFailures)
+	s := newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.limiter.AllowStructuredMetadata(i.instanceID), i.streamRateCalculator, i.metrics, i.writeFailures)
+	s.dictionary = i.dictionary
 
 	i.streamsCreatedTotal.Inc()
 	memoryStreams.WithLabelValues(i.instanceID).Inc()
@@ -369,6 +438,16 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) (iter.E
 		return nil, err
 	}
 
+	// the streams of the queries with a context stage are read without the pipeline, which is applied by the
+	// context lines to return the log lines around the matches.
+	contextLines, err := logql.NewContextLines(expr, req.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if contextLines != nil {
+		pipeline = log.NewNoopPipeline()
+	}
+
 	pipeline, err = deletion.SetupPipeline(req, pipeline)
 	if err != nil {
 		return nil, err
@@ -392,6 +471,9 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) (iter.E
 			if err != nil {
 				return err
 			}
+			if contextLines != nil {
+				iter = contextLines.Iterator(iter, stream.labels)
+			}
 			iters = append(iters, iter)
 			return nil
 		},
@@ -136,6 +136,51 @@ func TestConcurrentPushes(t *testing.T) {
 	// test passes if no goroutine reports error
 }
 
+func TestPushPartial(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	inst, err := newInstance(defaultConfig(), defaultPeriodConfigs, "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil, NewStreamRateCalculator(), nil)
+	require.Nil(t, err)
+
+	tt := time.Now().Add(-5 * time.Minute)
+	require.NoError(t, inst.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: tt, Line: "1"}}},
+	}}))
+
+	rejected, err := inst.PushPartial(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="bar"}`, Entries: entries(2, tt)},
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{
+			{Timestamp: tt.Add(time.Second), Line: "2"},
+			{Timestamp: tt.Add(-time.Second), Line: "0"},
+			{Timestamp: tt.Add(2 * time.Second), Line: "3"},
+		}},
+		{Labels: `{app="baz"`, Entries: entries(2, tt)},
+	}})
+	require.NoError(t, err)
+	require.Len(t, rejected, 2)
+
+	require.Equal(t, int32(1), rejected[0].Index)
+	require.Equal(t, `{app="foo"}`, rejected[0].Labels)
+	require.Len(t, rejected[0].Entries, 1)
+	require.Equal(t, int32(1), rejected[0].Entries[0].Index)
+	require.Equal(t, validation.TooFarBehind, rejected[0].Entries[0].Reason)
+
+	require.Equal(t, int32(2), rejected[1].Index)
+	require.Len(t, rejected[1].Entries, 2)
+	for i, entry := range rejected[1].Entries {
+		require.Equal(t, int32(i), entry.Index)
+		require.Equal(t, validation.InvalidLabels, entry.Reason)
+	}
+
+	// The same push fails without partial success.
+	err = inst.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: tt, Line: "0"}}},
+	}})
+	require.Error(t, err)
+}
+
 func TestGetStreamRates(t *testing.T) {
 	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
@@ -294,7 +339,7 @@ func setupTestStreams(t *testing.T) (*instance, time.Time, int) {
 	for _, testStream := range testStreams {
 		stream, err := instance.getOrCreateStream(testStream, recordPool.GetRecord())
 		require.NoError(t, err)
-		chunk := newStream(cfg, limiter, "fake", 0, nil, true, NewStreamRateCalculator(), NilMetrics, nil).NewChunk()
+		chunk := newStream(cfg, limiter, "fake", 0, nil, true, false, NewStreamRateCalculator(), NilMetrics, nil).NewChunk()
 		for _, entry := range testStream.Entries {
 			err = chunk.Append(&entry)
 			require.NoError(t, err)
@@ -547,7 +592,7 @@ func Benchmark_instance_addNewTailer(b *testing.B) {
 	lbs := makeRandomLabels()
 	b.Run("addTailersToNewStream", func(b *testing.B) {
 		for n := 0; n < b.N; n++ {
-			inst.addTailersToNewStream(newStream(nil, limiter, "fake", 0, lbs, true, NewStreamRateCalculator(), NilMetrics, nil))
+			inst.addTailersToNewStream(newStream(nil, limiter, "fake", 0, lbs, true, false, NewStreamRateCalculator(), NilMetrics, nil))
 		}
 	})
 }
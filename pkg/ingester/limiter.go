@@ -1,6 +1,7 @@
 package ingester
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync"
@@ -24,10 +25,13 @@ type RingCount interface {
 
 type Limits interface {
 	UnorderedWrites(userID string) bool
+	AllowStructuredMetadata(userID string) bool
 	MaxLocalStreamsPerUser(userID string) int
 	MaxGlobalStreamsPerUser(userID string) int
+	IngestionTenantShardSize(userID string) int
 	PerStreamRateLimit(userID string) validation.RateLimit
 	ShardStreams(userID string) *shardstreams.Config
+	LookupTables(ctx context.Context, userID string) map[string]string
 }
 
 // Limiter implements primitives to get the maximum number of streams
@@ -76,6 +80,14 @@ func (l *Limiter) UnorderedWrites(userID string) bool {
 	return l.limits.UnorderedWrites(userID)
 }
 
+func (l *Limiter) AllowStructuredMetadata(userID string) bool {
+	// WAL replay should not drop the structured metadata of previously ack'd writes.
+	if l.disabled {
+		return true
+	}
+	return l.limits.AllowStructuredMetadata(userID)
+}
+
 // AssertMaxStreamsPerUser ensures limit has not been reached compared to the current
 // number of streams in input and returns an error if so.
 func (l *Limiter) AssertMaxStreamsPerUser(userID string, streams int) error {
@@ -93,7 +105,7 @@ func (l *Limiter) AssertMaxStreamsPerUser(userID string, streams int) error {
 	// We can assume that streams are evenly distributed across ingesters
 	// so we do convert the global limit into a local limit
 	globalLimit := l.limits.MaxGlobalStreamsPerUser(userID)
-	adjustedGlobalLimit := l.convertGlobalToLocalLimit(globalLimit)
+	adjustedGlobalLimit := l.convertGlobalToLocalLimit(userID, globalLimit)
 
 	// Set the calculated limit to the lesser of the local limit or the new calculated global limit
 	calculatedLimit := l.minNonZero(localLimit, adjustedGlobalLimit)
@@ -111,7 +123,7 @@ func (l *Limiter) AssertMaxStreamsPerUser(userID string, streams int) error {
 	return fmt.Errorf(errMaxStreamsPerUserLimitExceeded, userID, streams, calculatedLimit, localLimit, globalLimit, adjustedGlobalLimit)
 }
 
-func (l *Limiter) convertGlobalToLocalLimit(globalLimit int) int {
+func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int {
 	if globalLimit == 0 {
 		return 0
 	}
@@ -121,6 +133,10 @@ func (l *Limiter) convertGlobalToLocalLimit(globalLimit int) int {
 	// we can use a per-ingester limit equal to:
 	// (global limit / number of ingesters) * replication factor
 	numIngesters := l.ring.HealthyInstancesCount()
+	// With shuffle sharding, the streams of the tenant are only written to the ingesters of its shard.
+	if shardSize := l.limits.IngestionTenantShardSize(userID); shardSize > 0 && shardSize < numIngesters {
+		numIngesters = shardSize
+	}
 
 	// May happen because the number of ingesters is asynchronously updated.
 	// If happens, we just temporarily ignore the global limit.
@@ -19,6 +19,7 @@ func TestLimiter_AssertMaxStreamsPerUser(t *testing.T) {
 		maxGlobalStreamsPerUser int
 		ringReplicationFactor   int
 		ringIngesterCount       int
+		shardSize               int
 		streams                 int
 		expected                error
 	}{
@@ -94,6 +95,24 @@ func TestLimiter_AssertMaxStreamsPerUser(t *testing.T) {
 			streams:                 3000,
 			expected:                fmt.Errorf(errMaxStreamsPerUserLimitExceeded, "test", 3000, 300, 500, 1000, 300),
 		},
+		"only global limit is enabled with shuffle sharding": {
+			maxLocalStreamsPerUser:  0,
+			maxGlobalStreamsPerUser: 1000,
+			ringReplicationFactor:   3,
+			ringIngesterCount:       10,
+			shardSize:               5,
+			streams:                 3000,
+			expected:                fmt.Errorf(errMaxStreamsPerUserLimitExceeded, "test", 3000, 600, 0, 1000, 600),
+		},
+		"only global limit is enabled with a shard larger than the ring": {
+			maxLocalStreamsPerUser:  0,
+			maxGlobalStreamsPerUser: 1000,
+			ringReplicationFactor:   3,
+			ringIngesterCount:       10,
+			shardSize:               20,
+			streams:                 3000,
+			expected:                fmt.Errorf(errMaxStreamsPerUserLimitExceeded, "test", 3000, 300, 0, 1000, 300),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -105,8 +124,9 @@ func TestLimiter_AssertMaxStreamsPerUser(t *testing.T) {
 
 			// Mock limits
 			limits, err := validation.NewOverrides(validation.Limits{
-				MaxLocalStreamsPerUser:  testData.maxLocalStreamsPerUser,
-				MaxGlobalStreamsPerUser: testData.maxGlobalStreamsPerUser,
+				MaxLocalStreamsPerUser:   testData.maxLocalStreamsPerUser,
+				MaxGlobalStreamsPerUser:  testData.maxGlobalStreamsPerUser,
+				IngestionTenantShardSize: testData.shardSize,
 			}, nil)
 			require.NoError(t, err)
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -71,9 +72,13 @@ type stream struct {
 	entryCt int64
 
 	unorderedWrites      bool
+	structuredMetadata   bool
 	streamRateCalculator *StreamRateCalculator
 
 	writeFailures *writefailures.Manager
+
+	// dictionary trains the dictionaries of the chunks encoded with zstd-dict, nil for other encodings.
+	dictionary *dictionaryTrainer
 }
 
 type chunkDesc struct {
@@ -89,9 +94,11 @@ type chunkDesc struct {
 type entryWithError struct {
 	entry *logproto.Entry
 	e     error
+	// index is the index of the entry in the pushed entries.
+	index int
 }
 
-func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.Fingerprint, labels labels.Labels, unorderedWrites bool, streamRateCalculator *StreamRateCalculator, metrics *ingesterMetrics, writeFailures *writefailures.Manager) *stream {
+func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.Fingerprint, labels labels.Labels, unorderedWrites, structuredMetadata bool, streamRateCalculator *StreamRateCalculator, metrics *ingesterMetrics, writeFailures *writefailures.Manager) *stream {
 	hashNoShard, _ := labels.HashWithoutLabels(make([]byte, 0, 1024), ShardLbName)
 	return &stream{
 		limiter:              NewStreamRateLimiter(limits, tenant, 10*time.Second),
@@ -106,8 +113,9 @@ func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.
 		tenant:               tenant,
 		streamRateCalculator: streamRateCalculator,
 
-		unorderedWrites: unorderedWrites,
-		writeFailures:   writeFailures,
+		unorderedWrites:    unorderedWrites,
+		structuredMetadata: structuredMetadata,
+		writeFailures:      writeFailures,
 	}
 }
 
@@ -115,11 +123,14 @@ func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.
 // ingester chunk transfer.
 // Must hold chunkMtx
 // DEPRECATED: chunk transfers are no longer suggested and remain for compatibility.
-func (s *stream) consumeChunk(_ context.Context, chunk *logproto.Chunk) error {
+func (s *stream) consumeChunk(ctx context.Context, chunk *logproto.Chunk) error {
 	c, err := chunkenc.NewByteChunk(chunk.Data, s.cfg.BlockSize, s.cfg.TargetChunkSize)
 	if err != nil {
 		return err
 	}
+	if err := c.LoadDictionary(ctx, s.tenant); err != nil {
+		return err
+	}
 
 	s.chunks = append(s.chunks, chunkDesc{
 		chunk: c,
@@ -136,6 +147,14 @@ func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err er
 	if err != nil {
 		return 0, 0, err
 	}
+	// The dictionaries of the chunks are needed to cut their head blocks.
+	ctx, cancel := context.WithTimeout(context.Background(), dictionaryOpTimeout)
+	defer cancel()
+	for _, c := range chks {
+		if err := c.chunk.LoadDictionary(ctx, s.tenant); err != nil {
+			return 0, 0, err
+		}
+	}
 	s.chunks = chks
 	for _, c := range s.chunks {
 		entriesAdded += c.chunk.Size()
@@ -145,7 +164,16 @@ func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err er
 }
 
 func (s *stream) NewChunk() *chunkenc.MemChunk {
-	return chunkenc.NewMemChunk(s.cfg.parsedEncoding, headBlockType(s.unorderedWrites), s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	var c *chunkenc.MemChunk
+	if s.dictionary != nil {
+		c = chunkenc.NewMemChunkWithDictionary(s.dictionary.Dictionary(), headBlockType(s.unorderedWrites, s.structuredMetadata), s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	} else {
+		c = chunkenc.NewMemChunk(s.cfg.parsedEncoding, headBlockType(s.unorderedWrites, s.structuredMetadata), s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	}
+	if s.cfg.ChunkParsedColumns {
+		c = c.WithParsedColumns()
+	}
+	return c
 }
 
 func (s *stream) Push(
@@ -165,6 +193,22 @@ func (s *stream) Push(
 	// Whether nor not to ingest all at once or not. It is a per-tenant configuration.
 	rateLimitWholeStream bool,
 ) (int, error) {
+	bytesAdded, failedEntriesWithError, err := s.push(ctx, entries, record, counter, lockChunk, rateLimitWholeStream)
+	if err != nil {
+		return bytesAdded, err
+	}
+	return bytesAdded, errorForFailedEntries(s, failedEntriesWithError, len(entries))
+}
+
+// push is like Push but returns the entries failing to be appended to the stream instead of an error.
+func (s *stream) push(
+	ctx context.Context,
+	entries []logproto.Entry,
+	record *wal.Record,
+	counter int64,
+	lockChunk bool,
+	rateLimitWholeStream bool,
+) (int, []entryWithError, error) {
 	if lockChunk {
 		s.chunkMtx.Lock()
 		defer s.chunkMtx.Unlock()
@@ -179,12 +223,12 @@ func (s *stream) Push(
 
 		s.metrics.walReplaySamplesDropped.WithLabelValues(duplicateReason).Add(float64(len(entries)))
 		s.metrics.walReplayBytesDropped.WithLabelValues(duplicateReason).Add(float64(byteCt))
-		return 0, ErrEntriesExist
+		return 0, nil, ErrEntriesExist
 	}
 
-	toStore, invalid := s.validateEntries(entries, isReplay, rateLimitWholeStream)
+	toStore, indexes, invalid := s.validateEntries(entries, isReplay, rateLimitWholeStream)
 	if rateLimitWholeStream && hasRateLimitErr(invalid) {
-		return 0, errorForFailedEntries(s, invalid, len(entries))
+		return 0, invalid, nil
 	}
 
 	prevNumChunks := len(s.chunks)
@@ -196,14 +240,14 @@ func (s *stream) Push(
 		s.metrics.chunkCreatedStats.Inc(1)
 	}
 
-	bytesAdded, storedEntries, entriesWithErr := s.storeEntries(ctx, toStore)
+	bytesAdded, storedEntries, entriesWithErr := s.storeEntries(ctx, toStore, indexes)
 	s.recordAndSendToTailers(record, storedEntries)
 
 	if len(s.chunks) != prevNumChunks {
 		s.metrics.memoryChunks.Add(float64(len(s.chunks) - prevNumChunks))
 	}
 
-	return bytesAdded, errorForFailedEntries(s, append(invalid, entriesWithErr...), len(entries))
+	return bytesAdded, append(invalid, entriesWithErr...), nil
 }
 
 func errorForFailedEntries(s *stream, failedEntriesWithError []entryWithError, totalEntries int) error {
@@ -244,6 +288,35 @@ func errorForFailedEntries(s *stream, failedEntriesWithError []entryWithError, t
 	return httpgrpc.Errorf(statusCode, buf.String())
 }
 
+// rejectedEntries returns the entries rejected by the stream, sorted by their index in the pushed entries,
+// or the error of the first entry failing for another reason than the validation of the stream.
+func (s *stream) rejectedEntries(failedEntriesWithError []entryWithError) ([]logproto.RejectedEntry, error) {
+	if len(failedEntriesWithError) == 0 {
+		return nil, nil
+	}
+	rejected := make([]logproto.RejectedEntry, 0, len(failedEntriesWithError))
+	for _, entryWithError := range failedEntriesWithError {
+		var reason string
+		if _, ok := entryWithError.e.(*validation.ErrStreamRateLimit); ok {
+			reason = validation.StreamRateLimit
+		} else if chunkenc.IsOutOfOrderErr(entryWithError.e) {
+			reason = validation.OutOfOrder
+			if s.unorderedWrites {
+				reason = validation.TooFarBehind
+			}
+		} else {
+			return nil, entryWithError.e
+		}
+		rejected = append(rejected, logproto.RejectedEntry{
+			Index:   int32(entryWithError.index),
+			Reason:  reason,
+			Message: entryWithError.e.Error(),
+		})
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Index < rejected[j].Index })
+	return rejected, nil
+}
+
 func hasRateLimitErr(errs []entryWithError) bool {
 	if len(errs) == 0 {
 		return false
@@ -298,7 +371,8 @@ func (s *stream) recordAndSendToTailers(record *wal.Record, entries []logproto.E
 	}
 }
 
-func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry) (int, []logproto.Entry, []entryWithError) {
+// storeEntries appends the entries to the chunks of the stream. indexes are the indexes of the entries in the pushed entries.
+func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry, indexes []int) (int, []logproto.Entry, []entryWithError) {
 	if sp := opentracing.SpanFromContext(ctx); sp != nil {
 		sp.LogKV("event", "stream started to store entries", "labels", s.labelsString)
 		defer sp.LogKV("event", "stream finished to store entries")
@@ -316,7 +390,7 @@ func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry) (in
 
 		chunk.lastUpdated = time.Now()
 		if err := chunk.chunk.Append(&entries[i]); err != nil {
-			invalid = append(invalid, entryWithError{&entries[i], err})
+			invalid = append(invalid, entryWithError{&entries[i], err, indexes[i]})
 			if chunkenc.IsOutOfOrderErr(err) {
 				s.writeFailures.Log(s.tenant, err)
 				outOfOrderSamples++
@@ -332,6 +406,10 @@ func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry) (in
 			s.highestTs = entries[i].Timestamp
 		}
 
+		if s.dictionary != nil {
+			s.dictionary.Observe(entries[i].Line)
+		}
+
 		bytesAdded += len(entries[i].Line)
 		storedEntries = append(storedEntries, entries[i])
 	}
@@ -339,7 +417,8 @@ func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry) (in
 	return bytesAdded, storedEntries, invalid
 }
 
-func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWholeStream bool) ([]logproto.Entry, []entryWithError) {
+// validateEntries returns the valid entries to store with their indexes in the entries, and the invalid entries.
+func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWholeStream bool) ([]logproto.Entry, []int, []entryWithError) {
 	var (
 		outOfOrderSamples, outOfOrderBytes   int
 		rateLimitedSamples, rateLimitedBytes int
@@ -349,6 +428,7 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 		lastLine                             = s.lastLine
 		highestTs                            = s.highestTs
 		toStore                              = make([]logproto.Entry, 0, len(entries))
+		indexes                              = make([]int, 0, len(entries))
 	)
 
 	for i := range entries {
@@ -369,7 +449,7 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 
 		now := time.Now()
 		if !rateLimitWholeStream && !s.limiter.AllowN(now, len(entries[i].Line)) {
-			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], &validation.ErrStreamRateLimit{RateLimit: flagext.ByteSize(limit), Labels: s.labelsString, Bytes: flagext.ByteSize(lineBytes)}})
+			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], &validation.ErrStreamRateLimit{RateLimit: flagext.ByteSize(limit), Labels: s.labelsString, Bytes: flagext.ByteSize(lineBytes)}, i})
 			s.writeFailures.Log(s.tenant, failedEntriesWithError[len(failedEntriesWithError)-1].e)
 			rateLimitedSamples++
 			rateLimitedBytes += lineBytes
@@ -379,7 +459,7 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 		// The validity window for unordered writes is the highest timestamp present minus 1/2 * max-chunk-age.
 		cutoff := highestTs.Add(-s.cfg.MaxChunkAge / 2)
 		if !isReplay && s.unorderedWrites && !highestTs.IsZero() && cutoff.After(entries[i].Timestamp) {
-			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrTooFarBehind(cutoff)})
+			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrTooFarBehind(cutoff), i})
 			s.writeFailures.Log(s.tenant, failedEntriesWithError[len(failedEntriesWithError)-1].e)
 			outOfOrderSamples++
 			outOfOrderBytes += lineBytes
@@ -395,6 +475,7 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 		}
 
 		toStore = append(toStore, entries[i])
+		indexes = append(indexes, i)
 	}
 
 	// Each successful call to 'AllowN' advances the limiter. With all-or-nothing
@@ -406,14 +487,14 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 		rateLimitedSamples = len(toStore)
 		failedEntriesWithError = make([]entryWithError, 0, len(toStore))
 		for i := 0; i < len(toStore); i++ {
-			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&toStore[i], &validation.ErrStreamRateLimit{RateLimit: flagext.ByteSize(limit), Labels: s.labelsString, Bytes: flagext.ByteSize(len(toStore[i].Line))}})
+			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&toStore[i], &validation.ErrStreamRateLimit{RateLimit: flagext.ByteSize(limit), Labels: s.labelsString, Bytes: flagext.ByteSize(len(toStore[i].Line))}, indexes[i]})
 			rateLimitedBytes += len(toStore[i].Line)
 		}
 	}
 
 	s.streamRateCalculator.Record(s.tenant, s.labelHash, s.labelHashNoShard, totalBytes)
 	s.reportMetrics(outOfOrderSamples, outOfOrderBytes, rateLimitedSamples, rateLimitedBytes)
-	return toStore, failedEntriesWithError
+	return toStore, indexes, failedEntriesWithError
 }
 
 func (s *stream) reportMetrics(outOfOrderSamples, outOfOrderBytes, rateLimitedSamples, rateLimitedBytes int) {
@@ -592,8 +673,11 @@ func (s *stream) resetCounter() {
 	s.entryCt = 0
 }
 
-func headBlockType(unorderedWrites bool) chunkenc.HeadBlockFmt {
+func headBlockType(unorderedWrites, structuredMetadata bool) chunkenc.HeadBlockFmt {
 	if unorderedWrites {
+		if structuredMetadata {
+			return chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt
+		}
 		return chunkenc.UnorderedHeadBlockFmt
 	}
 	return chunkenc.OrderedHeadBlockFmt
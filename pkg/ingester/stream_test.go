@@ -61,6 +61,7 @@ func TestMaxReturnedStreamsErrors(t *testing.T) {
 					{Name: "foo", Value: "bar"},
 				},
 				true,
+				false,
 				NewStreamRateCalculator(),
 				NilMetrics,
 				nil,
@@ -109,6 +110,7 @@ func TestPushDeduplication(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -140,6 +142,7 @@ func TestPushRejectOldCounter(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -240,6 +243,7 @@ func TestEntryErrorCorrectlyReported(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -250,7 +254,7 @@ func TestEntryErrorCorrectlyReported(t *testing.T) {
 		{Line: "observability", Timestamp: time.Now().AddDate(-1 /* year */, 0 /* month */, 0 /* day */)},
 		{Line: "short", Timestamp: time.Now()},
 	}
-	_, failed := s.validateEntries(entries, false, true)
+	_, _, failed := s.validateEntries(entries, false, true)
 	require.NotEmpty(t, failed)
 	require.False(t, hasRateLimitErr(failed))
 }
@@ -271,6 +275,7 @@ func TestUnorderedPush(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -369,6 +374,7 @@ func TestPushRateLimit(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -404,6 +410,7 @@ func TestPushRateLimitAllOrNothing(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -438,6 +445,7 @@ func TestReplayAppendIgnoresValidityWindow(t *testing.T) {
 			{Name: "foo", Value: "bar"},
 		},
 		true,
+		false,
 		NewStreamRateCalculator(),
 		NilMetrics,
 		nil,
@@ -490,7 +498,7 @@ func Benchmark_PushStream(b *testing.B) {
 	require.NoError(b, err)
 	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
 
-	s := newStream(&Config{MaxChunkAge: 24 * time.Hour}, limiter, "fake", model.Fingerprint(0), ls, true, NewStreamRateCalculator(), NilMetrics, nil)
+	s := newStream(&Config{MaxChunkAge: 24 * time.Hour}, limiter, "fake", model.Fingerprint(0), ls, true, false, NewStreamRateCalculator(), NilMetrics, nil)
 	t, err := newTailer("foo", `{namespace="loki-dev"}`, &fakeTailServer{}, 10)
 	require.NoError(b, err)
 
@@ -1,9 +1,13 @@
 package iter
 
 import (
+	"container/heap"
 	"context"
 	"io"
 	"math"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -517,6 +521,14 @@ type entryWithLabels struct {
 	streamHash uint64
 }
 
+func (e entryWithLabels) sortFields() sortFields {
+	return sortFields{
+		timeNanos:  e.Timestamp.UnixNano(),
+		labels:     e.labels,
+		streamHash: e.streamHash,
+	}
+}
+
 type reverseIterator struct {
 	iter              EntryIterator
 	cur               entryWithLabels
@@ -587,6 +599,314 @@ func (i *reverseIterator) Close() error {
 	return nil
 }
 
+// EntryLess reports whether the entry a of the stream with the labels aLabels is ordered before the entry b of the
+// stream with the labels bLabels.
+type EntryLess func(aLabels string, a logproto.Entry, bLabels string, b logproto.Entry) bool
+
+type topkEntryIterator struct {
+	iter      EntryIterator
+	k         int
+	direction logproto.Direction
+
+	top     *entryHeap
+	entries []entryWithLabels
+	cur     entryWithLabels
+	err     error
+	loaded  bool
+}
+
+// NewTopKEntryIterator returns an iterator over the k first entries of an existing iterator ordered by less.
+// All the entries are loaded on the first call to Next but only k of them are kept in memory.
+// The entries are then iterated by timestamp in the given direction, so the iterator can be merged with other iterators.
+func NewTopKEntryIterator(it EntryIterator, k int, less EntryLess, direction logproto.Direction) EntryIterator {
+	return &topkEntryIterator{
+		iter:      it,
+		k:         k,
+		direction: direction,
+		top:       &entryHeap{less: less},
+	}
+}
+
+func (i *topkEntryIterator) load() {
+	if i.loaded {
+		return
+	}
+	i.loaded = true
+	defer func() {
+		i.err = i.iter.Error()
+		util.LogError("closing iterator", i.iter.Close)
+	}()
+	if i.k <= 0 {
+		return
+	}
+	h := i.top
+	for i.iter.Next() {
+		e := entryWithLabels{i.iter.Entry(), i.iter.Labels(), i.iter.StreamHash()}
+		if h.Len() < i.k {
+			heap.Push(h, e)
+			continue
+		}
+		if h.less(e.labels, e.Entry, h.entries[0].labels, h.entries[0].Entry) {
+			h.entries[0] = e
+			heap.Fix(h, 0)
+		}
+	}
+	_, less := treeLess(i.direction)
+	i.entries = h.entries
+	sort.Slice(i.entries, func(a, b int) bool {
+		return less(i.entries[a].sortFields(), i.entries[b].sortFields())
+	})
+}
+
+func (i *topkEntryIterator) Next() bool {
+	i.load()
+	if len(i.entries) == 0 {
+		i.entries = nil
+		return false
+	}
+	i.cur, i.entries = i.entries[0], i.entries[1:]
+	return true
+}
+
+func (i *topkEntryIterator) Entry() logproto.Entry {
+	return i.cur.Entry
+}
+
+func (i *topkEntryIterator) Labels() string {
+	return i.cur.labels
+}
+
+func (i *topkEntryIterator) StreamHash() uint64 {
+	return i.cur.streamHash
+}
+
+func (i *topkEntryIterator) Error() error { return i.err }
+
+func (i *topkEntryIterator) Close() error {
+	if !i.loaded {
+		return i.iter.Close()
+	}
+	return nil
+}
+
+// entryHeap is a heap of entries whose root is the last entry ordered by less.
+type entryHeap struct {
+	entries []entryWithLabels
+	less    EntryLess
+}
+
+func (h *entryHeap) Len() int { return len(h.entries) }
+
+func (h *entryHeap) Less(i, j int) bool {
+	return h.less(h.entries[j].labels, h.entries[j].Entry, h.entries[i].labels, h.entries[i].Entry)
+}
+
+func (h *entryHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *entryHeap) Push(x interface{}) { h.entries = append(h.entries, x.(entryWithLabels)) }
+
+func (h *entryHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+type multilineEntryIterator struct {
+	iter      EntryIterator
+	firstLine *regexp.Regexp
+	maxLines  int
+	maxWait   time.Duration
+	direction logproto.Direction
+
+	// open are the groups of the streams which can still receive entries, by labels.
+	open map[string]*multilineGroup
+	// groups are the groups not returned yet, ordered by the timestamp of their first entry.
+	groups    *multilineHeap
+	seq       uint64
+	last      time.Time
+	exhausted bool
+	cur       entryWithLabels
+}
+
+// NewMultilineEntryIterator returns an iterator grouping the consecutive entries of each stream of it into single
+// entries. An entry matching firstLine starts a new group, and the following entries of the stream not matching it
+// are appended to the group, separated by new lines. A group ends after maxLines entries, or when the next entry of
+// its stream is more than maxWait apart from the last entry of the group.
+// The groups have the timestamp of their first entry, and are iterated by timestamp in the direction of it.
+func NewMultilineEntryIterator(it EntryIterator, firstLine *regexp.Regexp, maxLines int, maxWait time.Duration, direction logproto.Direction) EntryIterator {
+	return &multilineEntryIterator{
+		iter:      it,
+		firstLine: firstLine,
+		maxLines:  maxLines,
+		maxWait:   maxWait,
+		direction: direction,
+		open:      map[string]*multilineGroup{},
+		groups:    &multilineHeap{direction: direction},
+	}
+}
+
+func (i *multilineEntryIterator) Next() bool {
+	for {
+		if i.groups.Len() > 0 {
+			// the first group can only be returned once it ends, since the entries of the groups of the other streams
+			// can start before the end of the first group.
+			g := i.groups.groups[0]
+			if !g.closed && (i.exhausted || i.expired(g, i.last)) {
+				i.close(g)
+			}
+			if g.closed {
+				heap.Pop(i.groups)
+				i.cur = entryWithLabels{Entry: g.entry(i.direction), labels: g.labels, streamHash: g.streamHash}
+				return true
+			}
+		} else if i.exhausted {
+			return false
+		}
+		if !i.iter.Next() {
+			i.exhausted = true
+			continue
+		}
+		i.add(i.iter.Labels(), i.iter.StreamHash(), i.iter.Entry())
+	}
+}
+
+func (i *multilineEntryIterator) add(labels string, streamHash uint64, entry logproto.Entry) {
+	i.last = entry.Timestamp
+	g := i.open[labels]
+	if g != nil && i.expired(g, entry.Timestamp) {
+		i.close(g)
+		g = nil
+	}
+
+	// the first line of a group is its last entry when iterating backward.
+	first := i.firstLine.MatchString(entry.Line)
+	if g != nil && (!first || i.direction == logproto.BACKWARD) {
+		g.entries = append(g.entries, entry)
+		if i.direction == logproto.BACKWARD {
+			heap.Fix(i.groups, g.index)
+		}
+	} else {
+		if g != nil {
+			i.close(g)
+		}
+		g = &multilineGroup{labels: labels, streamHash: streamHash, entries: []logproto.Entry{entry}, seq: i.seq}
+		i.seq++
+		i.open[labels] = g
+		heap.Push(i.groups, g)
+	}
+	if len(g.entries) >= i.maxLines || (first && i.direction == logproto.BACKWARD) {
+		i.close(g)
+	}
+}
+
+func (i *multilineEntryIterator) expired(g *multilineGroup, ts time.Time) bool {
+	d := ts.Sub(g.entries[len(g.entries)-1].Timestamp)
+	if d < 0 {
+		d = -d
+	}
+	return d > i.maxWait
+}
+
+func (i *multilineEntryIterator) close(g *multilineGroup) {
+	g.closed = true
+	if i.open[g.labels] == g {
+		delete(i.open, g.labels)
+	}
+}
+
+func (i *multilineEntryIterator) Entry() logproto.Entry {
+	return i.cur.Entry
+}
+
+func (i *multilineEntryIterator) Labels() string {
+	return i.cur.labels
+}
+
+func (i *multilineEntryIterator) StreamHash() uint64 {
+	return i.cur.streamHash
+}
+
+func (i *multilineEntryIterator) Error() error { return i.iter.Error() }
+
+func (i *multilineEntryIterator) Close() error { return i.iter.Close() }
+
+type multilineGroup struct {
+	labels     string
+	streamHash uint64
+	// entries are in the order of the iterator.
+	entries []logproto.Entry
+	seq     uint64
+	closed  bool
+	index   int
+}
+
+// start returns the timestamp of the first entry of the group.
+func (g *multilineGroup) start(direction logproto.Direction) time.Time {
+	if direction == logproto.BACKWARD {
+		return g.entries[len(g.entries)-1].Timestamp
+	}
+	return g.entries[0].Timestamp
+}
+
+func (g *multilineGroup) entry(direction logproto.Direction) logproto.Entry {
+	if len(g.entries) == 1 {
+		return g.entries[0]
+	}
+	if direction == logproto.BACKWARD {
+		for l, r := 0, len(g.entries)-1; l < r; l, r = l+1, r-1 {
+			g.entries[l], g.entries[r] = g.entries[r], g.entries[l]
+		}
+	}
+	var sb strings.Builder
+	for i, e := range g.entries {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(e.Line)
+	}
+	return logproto.Entry{
+		Timestamp:          g.entries[0].Timestamp,
+		Line:               sb.String(),
+		StructuredMetadata: g.entries[0].StructuredMetadata,
+	}
+}
+
+type multilineHeap struct {
+	groups    []*multilineGroup
+	direction logproto.Direction
+}
+
+func (h *multilineHeap) Len() int { return len(h.groups) }
+
+func (h *multilineHeap) Less(i, j int) bool {
+	a, b := h.groups[i].start(h.direction), h.groups[j].start(h.direction)
+	if !a.Equal(b) {
+		if h.direction == logproto.BACKWARD {
+			return a.After(b)
+		}
+		return a.Before(b)
+	}
+	return h.groups[i].seq < h.groups[j].seq
+}
+
+func (h *multilineHeap) Swap(i, j int) {
+	h.groups[i], h.groups[j] = h.groups[j], h.groups[i]
+	h.groups[i].index = i
+	h.groups[j].index = j
+}
+
+func (h *multilineHeap) Push(x interface{}) {
+	g := x.(*multilineGroup)
+	g.index = len(h.groups)
+	h.groups = append(h.groups, g)
+}
+
+func (h *multilineHeap) Pop() interface{} {
+	last := h.groups[len(h.groups)-1]
+	h.groups = h.groups[:len(h.groups)-1]
+	return last
+}
+
 var entryBufferPool = sync.Pool{
 	New: func() interface{} {
 		return &entryBuffer{
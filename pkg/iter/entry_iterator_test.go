@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"math/rand"
+	"regexp"
+	"sort"
 	"testing"
 	"time"
 
@@ -418,6 +420,111 @@ func TestReverseEntryIteratorUnlimited(t *testing.T) {
 	require.Equal(t, expected, ct)
 }
 
+func TestTopKEntryIterator(t *testing.T) {
+	// orders the entries by the length of their lines, then by their timestamps.
+	less := func(_ string, a logproto.Entry, _ string, b logproto.Entry) bool {
+		if len(a.Line) != len(b.Line) {
+			return len(a.Line) > len(b.Line)
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	stream := logproto.Stream{
+		Labels: defaultLabels,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(1, 0), Line: "a"},
+			{Timestamp: time.Unix(2, 0), Line: "ccc"},
+			{Timestamp: time.Unix(3, 0), Line: "bb"},
+			{Timestamp: time.Unix(4, 0), Line: "dddd"},
+			{Timestamp: time.Unix(5, 0), Line: "ee"},
+		},
+	}
+
+	for _, tc := range []struct {
+		k         int
+		direction logproto.Direction
+		expected  []string
+	}{
+		{k: 3, direction: logproto.FORWARD, expected: []string{"ccc", "bb", "dddd"}},
+		{k: 3, direction: logproto.BACKWARD, expected: []string{"dddd", "bb", "ccc"}},
+		{k: 1, direction: logproto.FORWARD, expected: []string{"dddd"}},
+		{k: 10, direction: logproto.FORWARD, expected: []string{"a", "ccc", "bb", "dddd", "ee"}},
+		{k: 0, direction: logproto.FORWARD},
+	} {
+		t.Run(fmt.Sprintf("%d %s", tc.k, tc.direction), func(t *testing.T) {
+			it := NewTopKEntryIterator(NewStreamIterator(stream), tc.k, less, tc.direction)
+			var lines []string
+			for it.Next() {
+				require.Equal(t, defaultLabels, it.Labels())
+				lines = append(lines, it.Entry().Line)
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+			require.Equal(t, tc.expected, lines)
+		})
+	}
+}
+
+func TestMultilineEntryIterator(t *testing.T) {
+	const (
+		a = `{app="a"}`
+		b = `{app="b"}`
+	)
+	entry := func(sec int64, line string) logproto.Entry {
+		return logproto.Entry{Timestamp: time.Unix(sec, 0), Line: line}
+	}
+	// the entries of the stream a are split in two chunks, in the middle of a group.
+	streams := []logproto.Stream{
+		{Labels: a, Entries: []logproto.Entry{entry(1, "2023 a1"), entry(2, " at foo")}},
+		{Labels: a, Entries: []logproto.Entry{entry(4, " at bar"), entry(5, "2023 a2"), entry(6, " cont"), entry(20, " late")}},
+		{Labels: b, Entries: []logproto.Entry{entry(3, "2023 b1"), entry(7, " x"), entry(8, " y"), entry(9, " z")}},
+	}
+
+	for _, tc := range []struct {
+		direction logproto.Direction
+		expected  []entryWithLabels
+	}{
+		{
+			logproto.FORWARD,
+			[]entryWithLabels{
+				{Entry: entry(1, "2023 a1\n at foo\n at bar"), labels: a},
+				{Entry: entry(3, "2023 b1\n x\n y"), labels: b},
+				{Entry: entry(5, "2023 a2\n cont"), labels: a},
+				{Entry: entry(9, " z"), labels: b},
+				{Entry: entry(20, " late"), labels: a},
+			},
+		},
+		{
+			logproto.BACKWARD,
+			[]entryWithLabels{
+				{Entry: entry(20, " late"), labels: a},
+				{Entry: entry(7, " x\n y\n z"), labels: b},
+				{Entry: entry(5, "2023 a2\n cont"), labels: a},
+				{Entry: entry(3, "2023 b1"), labels: b},
+				{Entry: entry(1, "2023 a1\n at foo\n at bar"), labels: a},
+			},
+		},
+	} {
+		t.Run(tc.direction.String(), func(t *testing.T) {
+			input := make([]logproto.Stream, 0, len(streams))
+			for _, s := range streams {
+				entries := append([]logproto.Entry{}, s.Entries...)
+				if tc.direction == logproto.BACKWARD {
+					sort.Slice(entries, func(i, j int) bool { return entries[i].Timestamp.After(entries[j].Timestamp) })
+				}
+				input = append(input, logproto.Stream{Labels: s.Labels, Entries: entries})
+			}
+			it := NewMultilineEntryIterator(NewStreamsIterator(input, tc.direction), regexp.MustCompile(`^\d{4} `), 3, 5*time.Second, tc.direction)
+			var entries []entryWithLabels
+			for it.Next() {
+				entries = append(entries, entryWithLabels{Entry: it.Entry(), labels: it.Labels()})
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+			require.Equal(t, tc.expected, entries)
+		})
+	}
+}
+
 func Test_PeekingIterator(t *testing.T) {
 	iter := NewPeekingIterator(NewStreamIterator(logproto.Stream{
 		Entries: []logproto.Entry{
@@ -45,6 +45,7 @@ type Client interface {
 	ListLabelValues(name string, quiet bool, start, end time.Time) (*loghttp.LabelResponse, error)
 	Series(matchers []string, start, end time.Time, quiet bool) (*loghttp.SeriesResponse, error)
 	LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error)
+	Explain(queryStr string, start, end time.Time, step time.Duration, instant bool, mode loghttp.ExplainMode, quiet bool) (*loghttp.ExplainResponse, error)
 	GetOrgID() string
 }
 
@@ -161,6 +162,31 @@ func (c *DefaultClient) LiveTailQueryConn(queryStr string, delayFor time.Duratio
 	return c.wsConnect(tailPath, params.Encode(), quiet)
 }
 
+// Explain uses the explain parameter of the /api/v1/query and /api/v1/query_range endpoints to get the plan of a query
+func (c *DefaultClient) Explain(queryStr string, start, end time.Time, step time.Duration, instant bool, mode loghttp.ExplainMode, quiet bool) (*loghttp.ExplainResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("query", queryStr)
+	params.SetString("explain", string(mode))
+
+	path := queryRangePath
+	if instant {
+		path = queryPath
+		params.SetInt("time", start.UnixNano())
+	} else {
+		params.SetInt("start", start.UnixNano())
+		params.SetInt("end", end.UnixNano())
+		if step != 0 {
+			params.SetFloat("step", step.Seconds())
+		}
+	}
+
+	var explainResponse loghttp.ExplainResponse
+	if err := c.doRequest(path, params.Encode(), quiet, &explainResponse); err != nil {
+		return nil, err
+	}
+	return &explainResponse, nil
+}
+
 func (c *DefaultClient) GetOrgID() string {
 	return c.OrgID
 }
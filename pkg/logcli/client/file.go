@@ -178,6 +178,10 @@ func (f *FileClient) LiveTailQueryConn(_ string, _ time.Duration, _ int, _ time.
 	return nil, fmt.Errorf("LiveTailQuery: %w", ErrNotSupported)
 }
 
+func (f *FileClient) Explain(_ string, _, _ time.Time, _ time.Duration, _ bool, _ loghttp.ExplainMode, _ bool) (*loghttp.ExplainResponse, error) {
+	return nil, fmt.Errorf("Explain: %w", ErrNotSupported)
+}
+
 func (f *FileClient) GetOrgID() string {
 	return f.orgID
 }
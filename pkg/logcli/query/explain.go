@@ -0,0 +1,80 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// DoExplain gets the plan of the query, as it is split and sharded by the query frontend, and prints it.
+// When analyze is true, the query is executed and the plan contains the statistics of each node.
+func (q *Query) DoExplain(c client.Client, analyze bool) {
+	mode := loghttp.ExplainPlan
+	if analyze {
+		mode = loghttp.ExplainAnalyze
+	}
+
+	resp, err := c.Explain(q.QueryString, q.Start, q.End, q.Step, q.isInstant(), mode, q.Quiet)
+	if err != nil {
+		log.Fatalf("Explain failed: %+v", err)
+	}
+	if resp.Data == nil {
+		log.Fatalf("Explain failed: empty plan")
+	}
+	printPlan(os.Stdout, resp.Data)
+}
+
+// printPlan prints the plan as a tree, one node per line.
+func printPlan(w io.Writer, plan *logql.PlanNode) {
+	printPlanNode(w, plan, "", "")
+}
+
+func printPlanNode(w io.Writer, n *logql.PlanNode, prefix, childPrefix string) {
+	fmt.Fprintf(w, "%s%s\n", prefix, describePlanNode(n))
+	for i, c := range n.Children {
+		if i == len(n.Children)-1 {
+			printPlanNode(w, c, childPrefix+"└── ", childPrefix+"    ")
+			continue
+		}
+		printPlanNode(w, c, childPrefix+"├── ", childPrefix+"│   ")
+	}
+}
+
+func describePlanNode(n *logql.PlanNode) string {
+	parts := []string{n.Type}
+	if n.Operation != "" {
+		parts = append(parts, n.Operation)
+	}
+	if n.Interval != 0 {
+		parts = append(parts, "interval="+n.Interval.String())
+	}
+	if n.Start != nil && n.End != nil {
+		parts = append(parts, fmt.Sprintf("[%s, %s)", n.Start.UTC().Format(time.RFC3339), n.End.UTC().Format(time.RFC3339)))
+	}
+	if n.Shard != "" {
+		parts = append(parts, "shard="+n.Shard)
+	}
+	if n.Query != "" {
+		parts = append(parts, n.Query)
+	}
+	if s := n.Stats; s != nil {
+		cacheHits := s.Caches.Chunk.EntriesFound + s.Caches.Index.EntriesFound + s.Caches.Result.EntriesFound
+		parts = append(parts, fmt.Sprintf(
+			"(bytes=%s lines=%d exec_time=%s cache_hits=%d)",
+			humanize.Bytes(uint64(s.Summary.TotalBytesProcessed)),
+			s.Summary.TotalLinesProcessed,
+			time.Duration(s.Summary.ExecTime*float64(time.Second)),
+			cacheHits,
+		))
+	}
+	return strings.Join(parts, " ")
+}
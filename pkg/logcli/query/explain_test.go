@@ -0,0 +1,42 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+func TestPrintPlan(t *testing.T) {
+	start, end := time.Unix(0, 0), time.Unix(3600, 0)
+	leaf := func(shard string) *logql.PlanNode {
+		return &logql.PlanNode{
+			Type:  logql.PlanDownstream,
+			Query: `count_over_time({app="foo"}[1m])`,
+			Shard: shard,
+			Stats: &stats.Result{Summary: stats.Summary{TotalBytesProcessed: 1000, TotalLinesProcessed: 10, ExecTime: 0.5}},
+		}
+	}
+	plan := &logql.PlanNode{Type: logql.PlanSplitByInterval, Interval: model.Duration(time.Hour), Children: []*logql.PlanNode{
+		{Type: logql.PlanSplit, Start: &start, End: &end, Children: []*logql.PlanNode{
+			{Type: logql.PlanAggregation, Operation: "sum by (app)", Children: []*logql.PlanNode{
+				{Type: logql.PlanConcat, Children: []*logql.PlanNode{leaf("0_of_2"), leaf("1_of_2")}},
+			}},
+		}},
+	}}
+
+	var buf bytes.Buffer
+	printPlan(&buf, plan)
+	require.Equal(t, `split_by_interval interval=1h
+└── split [1970-01-01T00:00:00Z, 1970-01-01T01:00:00Z)
+    └── vector_aggregation sum by (app)
+        └── concat
+            ├── downstream shard=0_of_2 count_over_time({app="foo"}[1m]) (bytes=1.0 kB lines=10 exec_time=500ms cache_hits=0)
+            └── downstream shard=1_of_2 count_over_time({app="foo"}[1m]) (bytes=1.0 kB lines=10 exec_time=500ms cache_hits=0)
+`, buf.String())
+}
@@ -570,6 +570,15 @@ func (q *Query) SetInstant(time time.Time) {
 	q.End = time
 }
 
+// SetContext adds a context stage to the Query, returning the given numbers of log lines before and after each log line
+// of the Query from the same streams.
+func (q *Query) SetContext(before, after int) {
+	if before <= 0 && after <= 0 {
+		return
+	}
+	q.QueryString = fmt.Sprintf("%s | context before=%d after=%d", strings.TrimSpace(q.QueryString), before, after)
+}
+
 func (q *Query) isInstant() bool {
 	return q.Start == q.End && q.Step == 0
 }
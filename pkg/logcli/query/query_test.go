@@ -606,6 +606,10 @@ func (t *testQueryClient) LiveTailQueryConn(_ string, _ time.Duration, _ int, _
 	panic("implement me")
 }
 
+func (t *testQueryClient) Explain(_ string, _, _ time.Time, _ time.Duration, _ bool, _ loghttp.ExplainMode, _ bool) (*loghttp.ExplainResponse, error) {
+	panic("implement me")
+}
+
 func (t *testQueryClient) GetOrgID() string {
 	panic("implement me")
 }
@@ -725,6 +729,21 @@ func TestDurationCeilDiv(t *testing.T) {
 	}
 }
 
+func TestSetContext(t *testing.T) {
+	for _, tt := range []struct {
+		before, after int
+		expect        string
+	}{
+		{0, 0, `{app="foo"} |= "error"`},
+		{2, 0, `{app="foo"} |= "error" | context before=2 after=0`},
+		{2, 3, `{app="foo"} |= "error" | context before=2 after=3`},
+	} {
+		q := &Query{QueryString: `{app="foo"} |= "error" `}
+		q.SetContext(tt.before, tt.after)
+		require.Equal(t, tt.expect, strings.TrimSpace(q.QueryString))
+	}
+}
+
 func mustParseTime(value string) time.Time {
 	t, err := time.Parse("2006-01-02 15:04:05", value)
 	if err != nil {
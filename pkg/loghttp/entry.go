@@ -1,6 +1,7 @@
 package loghttp
 
 import (
+	"sort"
 	"strconv"
 	"time"
 	"unsafe"
@@ -8,6 +9,7 @@ import (
 	"github.com/buger/jsonparser"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/modern-go/reflect2"
+	"github.com/prometheus/prometheus/model/labels"
 )
 
 func init() {
@@ -15,9 +17,11 @@ func init() {
 }
 
 // Entry represents a log entry.  It includes a log message and the time it occurred at.
+// StructuredMetadata is optional and is encoded as a third element of the entry array.
 type Entry struct {
-	Timestamp time.Time
-	Line      string
+	Timestamp          time.Time
+	Line               string
+	StructuredMetadata labels.Labels
 }
 
 func (e *Entry) UnmarshalJSON(data []byte) error {
@@ -26,10 +30,13 @@ func (e *Entry) UnmarshalJSON(data []byte) error {
 		parseError error
 	)
 	_, err := jsonparser.ArrayEach(data, func(value []byte, t jsonparser.ValueType, _ int, _ error) {
-		// assert that both items in array are of type string
-		if t != jsonparser.String {
+		// assert that the timestamp and the line are of type string and the structured metadata is an object.
+		if i < 2 && t != jsonparser.String {
 			parseError = jsonparser.MalformedStringError
 			return
+		} else if i == 2 && t != jsonparser.Object {
+			parseError = jsonparser.MalformedObjectError
+			return
 		}
 		switch i {
 		case 0: // timestamp
@@ -46,6 +53,29 @@ func (e *Entry) UnmarshalJSON(data []byte) error {
 				return
 			}
 			e.Line = v
+		case 2: // structured metadata
+			var metadata labels.Labels
+			err := jsonparser.ObjectEach(value, func(key, val []byte, dataType jsonparser.ValueType, _ int) error {
+				if dataType != jsonparser.String {
+					return jsonparser.MalformedStringError
+				}
+				k, err := jsonparser.ParseString(key)
+				if err != nil {
+					return err
+				}
+				v, err := jsonparser.ParseString(val)
+				if err != nil {
+					return err
+				}
+				metadata = append(metadata, labels.Label{Name: k, Value: v})
+				return nil
+			})
+			if err != nil {
+				parseError = err
+				return
+			}
+			sort.Sort(metadata)
+			e.StructuredMetadata = metadata
 		}
 		i++
 	})
@@ -67,6 +97,7 @@ func (sliceEntryDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
 		i := 0
 		var ts time.Time
 		var line string
+		var metadata labels.Labels
 		ok := iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
 			var ok bool
 			switch i {
@@ -81,15 +112,20 @@ func (sliceEntryDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
 					return false
 				}
 				return true
+			case 2:
+				metadata = readStructuredMetadata(iter)
+				i++
+				return iter.Error == nil
 			default:
-				iter.ReportError("error reading entry", "array must contains 2 values")
+				iter.ReportError("error reading entry", "array must contains 2 or 3 values")
 				return false
 			}
 		})
 		if ok {
 			*((*[]Entry)(ptr)) = append(*((*[]Entry)(ptr)), Entry{
-				Timestamp: ts,
-				Line:      line,
+				Timestamp:          ts,
+				Line:               line,
+				StructuredMetadata: metadata,
 			})
 			return true
 		}
@@ -111,6 +147,20 @@ func readTimestamp(iter *jsoniter.Iterator) (time.Time, bool) {
 	return time.Unix(0, t), true
 }
 
+func readStructuredMetadata(iter *jsoniter.Iterator) labels.Labels {
+	var metadata labels.Labels
+	iter.ReadMapCB(func(iter *jsoniter.Iterator, name string) bool {
+		value := iter.ReadString()
+		if iter.Error != nil {
+			return false
+		}
+		metadata = append(metadata, labels.Label{Name: name, Value: value})
+		return true
+	})
+	sort.Sort(metadata)
+	return metadata
+}
+
 type EntryEncoder struct{}
 
 func (EntryEncoder) IsEmpty(_ unsafe.Pointer) bool {
@@ -126,6 +176,18 @@ func (EntryEncoder) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
 	stream.WriteRaw(`"`)
 	stream.WriteMore()
 	stream.WriteStringWithHTMLEscaped(e.Line)
+	if len(e.StructuredMetadata) > 0 {
+		stream.WriteMore()
+		stream.WriteObjectStart()
+		for i, l := range e.StructuredMetadata {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			stream.WriteObjectField(l.Name)
+			stream.WriteString(l.Value)
+		}
+		stream.WriteObjectEnd()
+	}
 	stream.WriteArrayEnd()
 }
 
@@ -46,6 +46,10 @@ func shards(r *http.Request) []string {
 	return r.Form["shards"]
 }
 
+func explain(r *http.Request) (ExplainMode, error) {
+	return parseExplain(r.Form.Get("explain"))
+}
+
 func bounds(r *http.Request) (time.Time, time.Time, error) {
 	now := time.Now()
 	start := r.Form.Get("start")
@@ -165,6 +169,18 @@ func parseDirection(value string, def logproto.Direction) (logproto.Direction, e
 	return logproto.Direction(d), nil
 }
 
+// parseExplain parses an ExplainMode from a string, the value false disables it.
+func parseExplain(value string) (ExplainMode, error) {
+	switch mode := ExplainMode(strings.ToLower(value)); mode {
+	case ExplainNone, ExplainPlan, ExplainAnalyze:
+		return mode, nil
+	case "false":
+		return ExplainNone, nil
+	default:
+		return ExplainNone, fmt.Errorf("invalid explain mode '%s'", value)
+	}
+}
+
 func parseSecondsOrDuration(value string) (time.Duration, error) {
 	if d, err := strconv.ParseFloat(value, 64); err == nil {
 		ts := d * float64(time.Second)
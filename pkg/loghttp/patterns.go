@@ -0,0 +1,100 @@
+package loghttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+)
+
+var errMissingQuery = errors.New("query parameter is required")
+
+// PatternsQuery is a request for the patterns of the log lines selected by a query.
+type PatternsQuery struct {
+	Query string
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+// PatternsResponse is the response of a patterns query.
+type PatternsResponse struct {
+	Status string          `json:"status"`
+	Data   []PatternSeries `json:"data"`
+}
+
+// PatternSeries is a detected pattern with the number of lines matching it over time.
+type PatternSeries struct {
+	Pattern string          `json:"pattern"`
+	Samples []PatternSample `json:"samples"`
+}
+
+// PatternSample is the number of lines matching a pattern within a step.
+type PatternSample struct {
+	Timestamp model.Time
+	Value     int64
+}
+
+// MarshalJSON encodes the sample as a [<unix epoch in seconds>, <count>] pair.
+func (s PatternSample) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%s,%d]", s.Timestamp.String(), s.Value)), nil
+}
+
+// UnmarshalJSON decodes a [<unix epoch in seconds>, <count>] pair.
+func (s *PatternSample) UnmarshalJSON(b []byte) error {
+	var pair [2]jsoniter.Number
+	if err := jsoniter.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	ts, err := strconv.ParseFloat(string(pair[0]), 64)
+	if err != nil {
+		return err
+	}
+	v, err := strconv.ParseInt(string(pair[1]), 10, 64)
+	if err != nil {
+		return err
+	}
+	s.Timestamp = model.TimeFromUnixNano(int64(ts * float64(time.Second)))
+	s.Value = v
+	return nil
+}
+
+// ParsePatternsQuery parses a patterns request.
+func ParsePatternsQuery(r *http.Request) (*PatternsQuery, error) {
+	var result PatternsQuery
+	var err error
+
+	result.Query = query(r)
+	if result.Query == "" {
+		return nil, errMissingQuery
+	}
+
+	result.Start, result.End, err = bounds(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.End.Before(result.Start) {
+		return nil, errEndBeforeStart
+	}
+
+	result.Step, err = step(r, result.Start, result.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Step <= 0 {
+		return nil, errZeroOrNegativeStep
+	}
+
+	// For safety, limit the number of returned points per pattern.
+	if (result.End.Sub(result.Start) / result.Step) > 11000 {
+		return nil, errStepTooSmall
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,92 @@
+package loghttp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatternsQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		path     string
+		expected *PatternsQuery
+		err      error
+	}{
+		{
+			name: "with step",
+			path: `/loki/api/v1/patterns?query={app="foo"}&start=1000000000&end=1000003600&step=60`,
+			expected: &PatternsQuery{
+				Query: `{app="foo"}`,
+				Start: time.Unix(1000000000, 0),
+				End:   time.Unix(1000003600, 0),
+				Step:  time.Minute,
+			},
+		},
+		{
+			name: "default step",
+			path: `/loki/api/v1/patterns?query={app="foo"} |= "error"&start=1000000000&end=1000003600`,
+			expected: &PatternsQuery{
+				Query: `{app="foo"} |= "error"`,
+				Start: time.Unix(1000000000, 0),
+				End:   time.Unix(1000003600, 0),
+				Step:  14 * time.Second,
+			},
+		},
+		{
+			name: "missing query",
+			path: `/loki/api/v1/patterns?start=1000000000&end=1000003600`,
+			err:  errMissingQuery,
+		},
+		{
+			name: "end before start",
+			path: `/loki/api/v1/patterns?query={app="foo"}&start=1000003600&end=1000000000`,
+			err:  errEndBeforeStart,
+		},
+		{
+			name: "step too small",
+			path: `/loki/api/v1/patterns?query={app="foo"}&start=1000000000&end=1000003600&step=0.1`,
+			err:  errStepTooSmall,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.NewRequest("GET", tc.path, nil)
+			require.NoError(t, err)
+			require.NoError(t, r.ParseForm())
+
+			actual, err := ParsePatternsQuery(r)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected.Query, actual.Query)
+			require.True(t, tc.expected.Start.Equal(actual.Start))
+			require.True(t, tc.expected.End.Equal(actual.End))
+			require.Equal(t, tc.expected.Step, actual.Step)
+		})
+	}
+}
+
+func TestPatternsResponse_JSON(t *testing.T) {
+	resp := PatternsResponse{
+		Status: QueryStatusSuccess,
+		Data: []PatternSeries{
+			{
+				Pattern: "GET <_> took <_>",
+				Samples: []PatternSample{{Timestamp: 1000000000000, Value: 3}, {Timestamp: 1000000030500, Value: 1}},
+			},
+		},
+	}
+
+	b, err := jsoniter.Marshal(resp)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":"success","data":[{"pattern":"GET <_> took <_>","samples":[[1000000000,3],[1000000030.5,1]]}]}`, string(b))
+
+	var actual PatternsResponse
+	require.NoError(t, jsoniter.Unmarshal(b, &actual))
+	require.Equal(t, resp, actual)
+}
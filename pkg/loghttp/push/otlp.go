@@ -0,0 +1,546 @@
+package push
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-logfmt/logfmt"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/util/strutil"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	applicationProtobuf = "application/x-protobuf"
+
+	// otlpDefaultServiceName is the stream label used when none of the attributes
+	// of a resource or scope is turned into a stream label.
+	otlpDefaultServiceName = "unknown_service"
+)
+
+// ParseOTLPRequest parses an OTLP/HTTP logs export request, either protobuf or JSON encoded,
+// and converts it to a push request using the given config. Requests without content type are
+// decoded as protobuf.
+func ParseOTLPRequest(logger log.Logger, userID string, r *http.Request, tenantsRetention TenantsRetention, cfg OTLPConfig) (*logproto.PushRequest, error) {
+	// The OTLP exporters and clients commonly omit the content type of protobuf requests.
+	if r.Header.Get(contentType) == "" {
+		r.Header.Set(contentType, applicationProtobuf)
+	}
+	return parseRequest(logger, userID, r, tenantsRetention, func(_ *http.Request, body io.Reader, contentType string) (*logproto.PushRequest, error) {
+		req, err := decodeOTLPRequest(body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		return otlpToPushRequest(req, cfg, time.Now()), nil
+	})
+}
+
+func decodeOTLPRequest(body io.Reader, contentType string) (*otlpLogsRequest, error) {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var req otlpLogsRequest
+	switch contentType {
+	case applicationJSON:
+		if err := json.Unmarshal(b, &req); err != nil {
+			return nil, fmt.Errorf("decoding OTLP JSON request: %w", err)
+		}
+	case applicationProtobuf:
+		if err := req.unmarshal(b); err != nil {
+			return nil, fmt.Errorf("decoding OTLP protobuf request: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP content type %q, expected %q or %q", contentType, applicationProtobuf, applicationJSON)
+	}
+	return &req, nil
+}
+
+// otlpToPushRequest groups the log records into streams labelled with the
+// allowlisted resource and scope attributes. All other attributes are either
+// kept as structured metadata or appended to the line.
+func otlpToPushRequest(req *otlpLogsRequest, cfg OTLPConfig, now time.Time) *logproto.PushRequest {
+	var (
+		streams = map[string]int{}
+		result  = &logproto.PushRequest{}
+		builder = labels.NewBuilder(nil)
+	)
+
+	for _, rl := range req.ResourceLogs {
+		resourceLabels, resourceAttrs := splitOTLPAttributes(rl.Resource.Attributes, cfg.ResourceAttributesAsLabels)
+
+		for _, sl := range rl.ScopeLogs {
+			scopeLabels, scopeAttrs := splitOTLPAttributes(sl.Scope.Attributes, cfg.ScopeAttributesAsLabels)
+			if sl.Scope.Name != "" {
+				scopeAttrs = append(scopeAttrs, labels.Label{Name: "scope_name", Value: sl.Scope.Name})
+			}
+			if sl.Scope.Version != "" {
+				scopeAttrs = append(scopeAttrs, labels.Label{Name: "scope_version", Value: sl.Scope.Version})
+			}
+
+			builder.Reset(nil)
+			for _, l := range resourceLabels {
+				builder.Set(l.Name, l.Value)
+			}
+			for _, l := range scopeLabels {
+				builder.Set(l.Name, l.Value)
+			}
+			lbs := builder.Labels()
+			if len(lbs) == 0 {
+				lbs = labels.Labels{{Name: "service_name", Value: otlpDefaultServiceName}}
+			}
+
+			key := lbs.String()
+			idx, ok := streams[key]
+			if !ok {
+				idx = len(result.Streams)
+				streams[key] = idx
+				result.Streams = append(result.Streams, logproto.Stream{Labels: key, Hash: lbs.Hash()})
+			}
+
+			for _, lr := range sl.LogRecords {
+				result.Streams[idx].Entries = append(result.Streams[idx].Entries, otlpLogRecordToEntry(lr, resourceAttrs, scopeAttrs, cfg, now))
+			}
+		}
+	}
+	return result
+}
+
+func otlpLogRecordToEntry(lr otlpLogRecord, resourceAttrs, scopeAttrs labels.Labels, cfg OTLPConfig, now time.Time) logproto.Entry {
+	ts := time.Unix(0, int64(lr.TimeUnixNano))
+	switch {
+	case lr.TimeUnixNano != 0:
+	case lr.ObservedTimeUnixNano != 0:
+		ts = time.Unix(0, int64(lr.ObservedTimeUnixNano))
+	default:
+		ts = now
+	}
+
+	attrs := make(labels.Labels, 0, len(resourceAttrs)+len(scopeAttrs)+len(lr.Attributes)+4)
+	attrs = append(attrs, resourceAttrs...)
+	attrs = append(attrs, scopeAttrs...)
+	for _, kv := range lr.Attributes {
+		attrs = append(attrs, labels.Label{Name: strutil.SanitizeFullLabelName(kv.Key), Value: kv.Value.String()})
+	}
+	if len(lr.TraceID) > 0 {
+		attrs = append(attrs, labels.Label{Name: "trace_id", Value: hex.EncodeToString(lr.TraceID)})
+	}
+	if len(lr.SpanID) > 0 {
+		attrs = append(attrs, labels.Label{Name: "span_id", Value: hex.EncodeToString(lr.SpanID)})
+	}
+	if lr.SeverityText != "" {
+		attrs = append(attrs, labels.Label{Name: "severity_text", Value: lr.SeverityText})
+	}
+	if lr.SeverityNumber != 0 {
+		attrs = append(attrs, labels.Label{Name: "severity_number", Value: strconv.Itoa(int(lr.SeverityNumber))})
+	}
+
+	entry := logproto.Entry{
+		Timestamp: ts,
+		Line:      lr.Body.String(),
+	}
+	if len(attrs) == 0 {
+		return entry
+	}
+
+	if cfg.AttributesAsStructuredMetadata {
+		entry.StructuredMetadata = logproto.FromLabelsToStructuredMetadata(attrs)
+		return entry
+	}
+
+	keyvals := make([]interface{}, 0, 2*len(attrs))
+	for _, l := range attrs {
+		keyvals = append(keyvals, l.Name, l.Value)
+	}
+	if b, err := logfmt.MarshalKeyvals(keyvals...); err == nil {
+		if entry.Line != "" {
+			entry.Line += " "
+		}
+		entry.Line += string(b)
+	}
+	return entry
+}
+
+// splitOTLPAttributes returns the attributes listed in asLabels as labels and all others as a separate set.
+func splitOTLPAttributes(attributes []otlpKeyValue, asLabels []string) (lbs, others labels.Labels) {
+	for _, kv := range attributes {
+		l := labels.Label{Name: strutil.SanitizeFullLabelName(kv.Key), Value: kv.Value.String()}
+		if contains(asLabels, kv.Key) {
+			if l.Value != "" {
+				lbs = append(lbs, l)
+			}
+			continue
+		}
+		others = append(others, l)
+	}
+	return lbs, others
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// The types below are the subset of the OTLP logs data model used by Loki.
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto
+// Their JSON encoding follows the OTLP/JSON mapping of the protobuf messages.
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name       string         `json:"name"`
+	Version    string         `json:"version"`
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         otlpUint64     `json:"timeUnixNano"`
+	ObservedTimeUnixNano otlpUint64     `json:"observedTimeUnixNano"`
+	SeverityNumber       int32          `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+	TraceID              otlpHexBytes   `json:"traceId"`
+	SpanID               otlpHexBytes   `json:"spanId"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string           `json:"stringValue,omitempty"`
+	BoolValue   *bool             `json:"boolValue,omitempty"`
+	IntValue    *otlpInt64        `json:"intValue,omitempty"`
+	DoubleValue *float64          `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue   `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKeyValueList `json:"kvlistValue,omitempty"`
+	BytesValue  []byte            `json:"bytesValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValueList struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// String renders scalar values as is, and arrays and key value lists as JSON.
+func (v otlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+	case v.BytesValue != nil:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case v.ArrayValue != nil, v.KvlistValue != nil:
+		b, err := json.Marshal(v.value())
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	return ""
+}
+
+// value returns the plain Go value of v.
+func (v otlpAnyValue) value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return v.BytesValue
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, av := range v.ArrayValue.Values {
+			values = append(values, av.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]interface{}, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = kv.Value.value()
+		}
+		return values
+	}
+	return nil
+}
+
+// otlpUint64 is an uint64 which is encoded as a string or a number in OTLP/JSON.
+type otlpUint64 uint64
+
+func (u *otlpUint64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*u = otlpUint64(v)
+	return nil
+}
+
+// otlpInt64 is an int64 which is encoded as a string or a number in OTLP/JSON.
+type otlpInt64 int64
+
+func (i *otlpInt64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = otlpInt64(v)
+	return nil
+}
+
+// otlpHexBytes are bytes encoded as a hex string in OTLP/JSON, used for trace and span ids.
+type otlpHexBytes []byte
+
+func (h *otlpHexBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// Protobuf decoding of the OTLP messages. Unknown fields are skipped.
+
+func (m *otlpLogsRequest) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, _ uint64, buf []byte) error {
+		if num == 1 {
+			var rl otlpResourceLogs
+			if err := rl.unmarshal(buf); err != nil {
+				return err
+			}
+			m.ResourceLogs = append(m.ResourceLogs, rl)
+		}
+		return nil
+	})
+}
+
+func (m *otlpResourceLogs) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, _ uint64, buf []byte) error {
+		switch num {
+		case 1:
+			return forEachProtoField(buf, func(num protowire.Number, _ uint64, buf []byte) error {
+				if num == 1 {
+					return appendProtoKeyValue(&m.Resource.Attributes, buf)
+				}
+				return nil
+			})
+		case 2:
+			var sl otlpScopeLogs
+			if err := sl.unmarshal(buf); err != nil {
+				return err
+			}
+			m.ScopeLogs = append(m.ScopeLogs, sl)
+		}
+		return nil
+	})
+}
+
+func (m *otlpScopeLogs) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, _ uint64, buf []byte) error {
+		switch num {
+		case 1:
+			return m.Scope.unmarshal(buf)
+		case 2:
+			var lr otlpLogRecord
+			if err := lr.unmarshal(buf); err != nil {
+				return err
+			}
+			m.LogRecords = append(m.LogRecords, lr)
+		}
+		return nil
+	})
+}
+
+func (m *otlpScope) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, _ uint64, buf []byte) error {
+		switch num {
+		case 1:
+			m.Name = string(buf)
+		case 2:
+			m.Version = string(buf)
+		case 3:
+			return appendProtoKeyValue(&m.Attributes, buf)
+		}
+		return nil
+	})
+}
+
+func (m *otlpLogRecord) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, v uint64, buf []byte) error {
+		switch num {
+		case 1:
+			m.TimeUnixNano = otlpUint64(v)
+		case 2:
+			m.SeverityNumber = int32(v)
+		case 3:
+			m.SeverityText = string(buf)
+		case 5:
+			return m.Body.unmarshal(buf)
+		case 6:
+			return appendProtoKeyValue(&m.Attributes, buf)
+		case 9:
+			m.TraceID = append(otlpHexBytes(nil), buf...)
+		case 10:
+			m.SpanID = append(otlpHexBytes(nil), buf...)
+		case 11:
+			m.ObservedTimeUnixNano = otlpUint64(v)
+		}
+		return nil
+	})
+}
+
+func (m *otlpKeyValue) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, _ uint64, buf []byte) error {
+		switch num {
+		case 1:
+			m.Key = string(buf)
+		case 2:
+			return m.Value.unmarshal(buf)
+		}
+		return nil
+	})
+}
+
+func (m *otlpAnyValue) unmarshal(b []byte) error {
+	return forEachProtoField(b, func(num protowire.Number, v uint64, buf []byte) error {
+		switch num {
+		case 1:
+			s := string(buf)
+			m.StringValue = &s
+		case 2:
+			b := v != 0
+			m.BoolValue = &b
+		case 3:
+			i := otlpInt64(v)
+			m.IntValue = &i
+		case 4:
+			f := math.Float64frombits(v)
+			m.DoubleValue = &f
+		case 5:
+			m.ArrayValue = &otlpArrayValue{}
+			return forEachProtoField(buf, func(num protowire.Number, _ uint64, buf []byte) error {
+				if num != 1 {
+					return nil
+				}
+				var av otlpAnyValue
+				if err := av.unmarshal(buf); err != nil {
+					return err
+				}
+				m.ArrayValue.Values = append(m.ArrayValue.Values, av)
+				return nil
+			})
+		case 6:
+			m.KvlistValue = &otlpKeyValueList{}
+			return forEachProtoField(buf, func(num protowire.Number, _ uint64, buf []byte) error {
+				if num == 1 {
+					return appendProtoKeyValue(&m.KvlistValue.Values, buf)
+				}
+				return nil
+			})
+		case 7:
+			m.BytesValue = append([]byte{}, buf...)
+		}
+		return nil
+	})
+}
+
+func appendProtoKeyValue(kvs *[]otlpKeyValue, b []byte) error {
+	var kv otlpKeyValue
+	if err := kv.unmarshal(b); err != nil {
+		return err
+	}
+	*kvs = append(*kvs, kv)
+	return nil
+}
+
+// forEachProtoField calls fn for each field of the protobuf encoded message b.
+// Varint and fixed size values are passed as v, length delimited values as buf.
+func forEachProtoField(b []byte, fn func(num protowire.Number, v uint64, buf []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var (
+			v   uint64
+			buf []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.BytesType:
+			buf, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, v, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package push
+
+import (
+	"flag"
+
+	"github.com/grafana/dskit/flagext"
+)
+
+// DefaultOTLPResourceAttributesAsLabels are the resource attributes turned into stream labels by default.
+var DefaultOTLPResourceAttributesAsLabels = []string{
+	"service.name",
+	"service.namespace",
+	"service.instance.id",
+	"deployment.environment",
+	"cloud.region",
+	"cloud.availability_zone",
+	"k8s.cluster.name",
+	"k8s.namespace.name",
+	"k8s.pod.name",
+	"k8s.container.name",
+	"container.name",
+	"k8s.replicaset.name",
+	"k8s.deployment.name",
+	"k8s.statefulset.name",
+	"k8s.daemonset.name",
+	"k8s.cronjob.name",
+	"k8s.job.name",
+}
+
+// OTLPConfig configures how OTLP log records are mapped to Loki streams and entries.
+type OTLPConfig struct {
+	// ResourceAttributesAsLabels and ScopeAttributesAsLabels are the allowlists of
+	// attributes turned into stream labels. Attribute names are sanitized into valid label names.
+	ResourceAttributesAsLabels flagext.StringSliceCSV `yaml:"resource_attributes_as_labels" json:"resource_attributes_as_labels"`
+	ScopeAttributesAsLabels    flagext.StringSliceCSV `yaml:"scope_attributes_as_labels" json:"scope_attributes_as_labels"`
+
+	// AttributesAsStructuredMetadata stores all other attributes as structured metadata of each entry
+	// instead of appending them to the log line.
+	AttributesAsStructuredMetadata bool `yaml:"attributes_as_structured_metadata" json:"attributes_as_structured_metadata"`
+}
+
+func (cfg *OTLPConfig) RegisterFlagsWithPrefix(prefix string, fs *flag.FlagSet) {
+	cfg.ResourceAttributesAsLabels = append(flagext.StringSliceCSV{}, DefaultOTLPResourceAttributesAsLabels...)
+	fs.Var(&cfg.ResourceAttributesAsLabels, prefix+".resource-attributes-as-labels", "Comma separated list of OTLP resource attributes which are turned into stream labels.")
+	fs.Var(&cfg.ScopeAttributesAsLabels, prefix+".scope-attributes-as-labels", "Comma separated list of OTLP instrumentation scope attributes which are turned into stream labels.")
+	fs.BoolVar(&cfg.AttributesAsStructuredMetadata, prefix+".attributes-as-structured-metadata", false, "Store the OTLP attributes which are not turned into stream labels as structured metadata of each entry instead of appending them to the log line in logfmt format. Requires structured metadata to be allowed for the tenant.")
+}
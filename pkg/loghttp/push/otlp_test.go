@@ -0,0 +1,234 @@
+package push
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/grafana/loki/pkg/logproto"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// The helpers below encode OTLP messages with the field numbers of
+// opentelemetry/proto/collector/logs/v1/logs_service.proto.
+
+func protoMessage(num protowire.Number, fields ...[]byte) []byte {
+	return protowire.AppendBytes(protowire.AppendTag(nil, num, protowire.BytesType), bytes.Join(fields, nil))
+}
+
+func protoString(num protowire.Number, s string) []byte {
+	return protowire.AppendString(protowire.AppendTag(nil, num, protowire.BytesType), s)
+}
+
+func protoKeyValue(num protowire.Number, key string, value []byte) []byte {
+	return protoMessage(num, protoString(1, key), protoMessage(2, value))
+}
+
+func protoLogRecord(ts uint64, body string, fields ...[]byte) []byte {
+	record := [][]byte{protoMessage(5, protoString(1, body))}
+	if ts != 0 {
+		record = append(record, protowire.AppendFixed64(protowire.AppendTag(nil, 1, protowire.Fixed64Type), ts))
+	}
+	return protoMessage(2, append(record, fields...)...)
+}
+
+func TestParseOTLPRequest(t *testing.T) {
+	traceID := []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x3, 0x81, 0x3, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0xc}
+	spanID := []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74}
+
+	protoPayload := protoMessage(1,
+		// resource
+		protoMessage(1,
+			protoKeyValue(1, "service.name", protoString(1, "checkout")),
+			protoKeyValue(1, "host.name", protoString(1, "node-1")),
+		),
+		// scope logs
+		protoMessage(2,
+			protoMessage(1, protoString(1, "otel-go"), protoString(2, "1.0.0")),
+			protoLogRecord(uint64(time.Unix(0, 10).UnixNano()), "payment accepted",
+				protoKeyValue(6, "amount", protowire.AppendVarint(protowire.AppendTag(nil, 3, protowire.VarintType), 42)),
+				protowire.AppendVarint(protowire.AppendTag(nil, 2, protowire.VarintType), 9),
+				protoString(3, "INFO"),
+				protowire.AppendBytes(protowire.AppendTag(nil, 9, protowire.BytesType), traceID),
+				protowire.AppendBytes(protowire.AppendTag(nil, 10, protowire.BytesType), spanID),
+			),
+		),
+	)
+
+	jsonPayload := `{"resourceLogs":[{
+		"resource":{"attributes":[
+			{"key":"service.name","value":{"stringValue":"checkout"}},
+			{"key":"host.name","value":{"stringValue":"node-1"}}
+		]},
+		"scopeLogs":[{
+			"scope":{"name":"otel-go","version":"1.0.0"},
+			"logRecords":[{
+				"timeUnixNano":"10",
+				"severityNumber":9,
+				"severityText":"INFO",
+				"traceId":"5b8efff798038103d269b633813fc60c",
+				"spanId":"eee19b7ec3c1b174",
+				"body":{"stringValue":"payment accepted"},
+				"attributes":[{"key":"amount","value":{"intValue":"42"}}]
+			}]
+		}]
+	}]}`
+
+	for _, tc := range []struct {
+		name        string
+		body        []byte
+		contentType string
+		cfg         OTLPConfig
+		expected    []logproto.Stream
+		valid       bool
+	}{
+		{
+			name:        "protobuf with attributes appended to the line",
+			body:        protoPayload,
+			contentType: applicationProtobuf,
+			cfg:         OTLPConfig{ResourceAttributesAsLabels: DefaultOTLPResourceAttributesAsLabels},
+			expected: []logproto.Stream{
+				{
+					Labels: `{service_name="checkout"}`,
+					Entries: []logproto.Entry{
+						{
+							Timestamp: time.Unix(0, 10),
+							Line:      `payment accepted host_name=node-1 scope_name=otel-go scope_version=1.0.0 amount=42 trace_id=5b8efff798038103d269b633813fc60c span_id=eee19b7ec3c1b174 severity_text=INFO severity_number=9`,
+						},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			name:        "json with attributes as structured metadata",
+			body:        []byte(jsonPayload),
+			contentType: applicationJSON,
+			cfg:         OTLPConfig{ResourceAttributesAsLabels: DefaultOTLPResourceAttributesAsLabels, AttributesAsStructuredMetadata: true},
+			expected: []logproto.Stream{
+				{
+					Labels: `{service_name="checkout"}`,
+					Entries: []logproto.Entry{
+						{
+							Timestamp: time.Unix(0, 10),
+							Line:      `payment accepted`,
+							StructuredMetadata: logproto.FromLabelsToStructuredMetadata(labels.Labels{
+								{Name: "host_name", Value: "node-1"},
+								{Name: "scope_name", Value: "otel-go"},
+								{Name: "scope_version", Value: "1.0.0"},
+								{Name: "amount", Value: "42"},
+								{Name: "trace_id", Value: "5b8efff798038103d269b633813fc60c"},
+								{Name: "span_id", Value: "eee19b7ec3c1b174"},
+								{Name: "severity_text", Value: "INFO"},
+								{Name: "severity_number", Value: "9"},
+							}),
+						},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			name:        "protobuf without label attributes",
+			body:        protoMessage(1, protoMessage(2, protoLogRecord(10, "hello"))),
+			contentType: applicationProtobuf,
+			cfg:         OTLPConfig{ResourceAttributesAsLabels: DefaultOTLPResourceAttributesAsLabels},
+			expected: []logproto.Stream{
+				{
+					Labels:  `{service_name="unknown_service"}`,
+					Entries: []logproto.Entry{{Timestamp: time.Unix(0, 10), Line: "hello"}},
+				},
+			},
+			valid: true,
+		},
+		{
+			name:        "json with observed timestamp",
+			body:        []byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"observedTimeUnixNano":"20","body":{"stringValue":"hello"}}]}]}]}`),
+			contentType: applicationJSON,
+			expected: []logproto.Stream{
+				{
+					Labels:  `{service_name="unknown_service"}`,
+					Entries: []logproto.Entry{{Timestamp: time.Unix(0, 20), Line: "hello"}},
+				},
+			},
+			valid: true,
+		},
+		{
+			name:        "scope attributes as labels",
+			body:        []byte(`{"resourceLogs":[{"scopeLogs":[{"scope":{"attributes":[{"key":"team","value":{"stringValue":"payments"}}]},"logRecords":[{"timeUnixNano":"10","body":{"stringValue":"hello"}}]}]}]}`),
+			contentType: applicationJSON,
+			cfg:         OTLPConfig{ScopeAttributesAsLabels: []string{"team"}},
+			expected: []logproto.Stream{
+				{
+					Labels:  `{team="payments"}`,
+					Entries: []logproto.Entry{{Timestamp: time.Unix(0, 10), Line: "hello"}},
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "protobuf without content type",
+			body: protoMessage(1, protoMessage(2, protoLogRecord(10, "hello"))),
+			cfg:  OTLPConfig{ResourceAttributesAsLabels: DefaultOTLPResourceAttributesAsLabels},
+			expected: []logproto.Stream{
+				{
+					Labels:  `{service_name="unknown_service"}`,
+					Entries: []logproto.Entry{{Timestamp: time.Unix(0, 10), Line: "hello"}},
+				},
+			},
+			valid: true,
+		},
+		{
+			name:        "unsupported content type",
+			body:        []byte(jsonPayload),
+			contentType: "text/plain",
+		},
+		{
+			name:        "invalid protobuf",
+			body:        []byte("not a protobuf message"),
+			contentType: applicationProtobuf,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/otlp/v1/logs", bytes.NewReader(tc.body))
+			if tc.contentType != "" {
+				request.Header.Add("Content-Type", tc.contentType)
+			}
+
+			req, err := ParseOTLPRequest(util_log.Logger, "fake", request, nil, tc.cfg)
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, req.Streams, len(tc.expected))
+			for i, s := range req.Streams {
+				require.Equal(t, tc.expected[i].Labels, s.Labels)
+				require.Len(t, s.Entries, len(tc.expected[i].Entries))
+				for j, e := range s.Entries {
+					require.True(t, tc.expected[i].Entries[j].Timestamp.Equal(e.Timestamp))
+					require.Equal(t, tc.expected[i].Entries[j].Line, e.Line)
+					require.Equal(t, tc.expected[i].Entries[j].StructuredMetadata, e.StructuredMetadata)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOTLPRequest_Gzip(t *testing.T) {
+	body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"checkout"}}]},"scopeLogs":[{"logRecords":[{"timeUnixNano":"10","body":{"stringValue":"hello"}}]}]}]}`
+	request := httptest.NewRequest("POST", "/otlp/v1/logs", bytes.NewReader([]byte(gzipString(body))))
+	request.Header.Add("Content-Type", applicationJSON)
+	request.Header.Add("Content-Encoding", "gzip")
+
+	req, err := ParseOTLPRequest(util_log.Logger, "fake", request, nil, OTLPConfig{ResourceAttributesAsLabels: DefaultOTLPResourceAttributesAsLabels})
+	require.NoError(t, err)
+	require.Len(t, req.Streams, 1)
+	require.Equal(t, `{service_name="checkout"}`, req.Streams[0].Labels)
+	require.Equal(t, "hello", req.Streams[0].Entries[0].Line)
+}
@@ -3,6 +3,7 @@ package push
 import (
 	"compress/flate"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -45,13 +46,27 @@ var (
 	linesReceivedStats = analytics.NewCounter("distributor_lines_received")
 )
 
-const applicationJSON = "application/json"
+const (
+	applicationJSON = "application/json"
+
+	// PartialSuccessHeader is the header of the push requests of the clients accepting partial success:
+	// the entries rejected by Loki are listed in the push response instead of failing the request.
+	PartialSuccessHeader = "X-Loki-Partial-Success"
+)
 
 type TenantsRetention interface {
 	RetentionPeriodFor(userID string, lbs labels.Labels) time.Duration
 }
 
+// requestDecoder decodes the uncompressed body of a push request with the given content type.
+type requestDecoder func(r *http.Request, body io.Reader, contentType string) (*logproto.PushRequest, error)
+
+// ParseRequest parses a push request in the Loki push format, either JSON or snappy-compressed protobuf.
 func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRetention TenantsRetention) (*logproto.PushRequest, error) {
+	return parseRequest(logger, userID, r, tenantsRetention, decodePushRequest)
+}
+
+func parseRequest(logger log.Logger, userID string, r *http.Request, tenantsRetention TenantsRetention, decode requestDecoder) (*logproto.PushRequest, error) {
 	// Body
 	var body io.Reader
 	// bodySize should always reflect the compressed size of the request body
@@ -85,7 +100,6 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 		entriesSize      int64
 		streamLabelsSize int64
 		totalEntries     int64
-		req              logproto.PushRequest
 	)
 
 	contentType, _ /* params */, err := mime.ParseMediaType(contentType)
@@ -93,29 +107,9 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 		return nil, err
 	}
 
-	switch contentType {
-	case applicationJSON:
-
-		var err error
-
-		// todo once https://github.com/weaveworks/common/commit/73225442af7da93ec8f6a6e2f7c8aafaee3f8840 is in Loki.
-		// We can try to pass the body as bytes.buffer instead to avoid reading into another buffer.
-		if loghttp.GetVersion(r.RequestURI) == loghttp.VersionV1 {
-			err = unmarshal.DecodePushRequest(body, &req)
-		} else {
-			err = unmarshal2.DecodePushRequest(body, &req)
-		}
-
-		if err != nil {
-			return nil, err
-		}
-
-	default:
-		// When no content-type header is set or when it is set to
-		// `application/x-protobuf`: expect snappy compression.
-		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
-			return nil, err
-		}
+	req, err := decode(r, body, contentType)
+	if err != nil {
+		return nil, err
 	}
 
 	mostRecentEntry := time.Unix(0, 0)
@@ -132,9 +126,10 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 		}
 		for _, e := range s.Entries {
 			totalEntries++
-			entriesSize += int64(len(e.Line))
-			bytesIngested.WithLabelValues(userID, retentionHours).Add(float64(int64(len(e.Line))))
-			bytesReceivedStats.Inc(int64(len(e.Line)))
+			entrySize := int64(util.EntryTotalSize(&e))
+			entriesSize += entrySize
+			bytesIngested.WithLabelValues(userID, retentionHours).Add(float64(entrySize))
+			bytesReceivedStats.Inc(entrySize)
 			if e.Timestamp.After(mostRecentEntry) {
 				mostRecentEntry = e.Timestamp
 			}
@@ -160,5 +155,62 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 		"totalSize", humanize.Bytes(uint64(entriesSize+streamLabelsSize)),
 		"mostRecentLagMs", time.Since(mostRecentEntry).Milliseconds(),
 	)
+	return req, nil
+}
+
+// AcceptsPartialSuccess returns whether the client of the push request accepts partial success.
+func AcceptsPartialSuccess(r *http.Request) bool {
+	return r.Header.Get(PartialSuccessHeader) == "true"
+}
+
+// WriteResponse writes the push response in the encoding of the push request, either JSON or protobuf.
+func WriteResponse(w http.ResponseWriter, r *http.Request, resp *logproto.PushResponse) error {
+	var (
+		buf []byte
+		err error
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentType))
+	if mediaType == applicationJSON {
+		buf, err = json.Marshal(resp)
+		w.Header().Set(contentType, applicationJSON)
+	} else {
+		buf, err = resp.Marshal()
+		w.Header().Set(contentType, "application/x-protobuf")
+	}
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(buf)
+	return err
+}
+
+func decodePushRequest(r *http.Request, body io.Reader, contentType string) (*logproto.PushRequest, error) {
+	var req logproto.PushRequest
+
+	switch contentType {
+	case applicationJSON:
+
+		var err error
+
+		// todo once https://github.com/weaveworks/common/commit/73225442af7da93ec8f6a6e2f7c8aafaee3f8840 is in Loki.
+		// We can try to pass the body as bytes.buffer instead to avoid reading into another buffer.
+		if loghttp.GetVersion(r.RequestURI) == loghttp.VersionV1 {
+			err = unmarshal.DecodePushRequest(body, &req)
+		} else {
+			err = unmarshal2.DecodePushRequest(body, &req)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		// When no content-type header is set or when it is set to
+		// `application/x-protobuf`: expect snappy compression.
+		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
+			return nil, err
+		}
+	}
 	return &req, nil
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
@@ -25,6 +26,25 @@ var (
 	errNegativeInterval   = errors.New("interval must be >= 0")
 )
 
+// ExplainMode is the mode of the explain parameter of the queries.
+type ExplainMode string
+
+// ExplainMode values
+const (
+	// ExplainNone executes the query.
+	ExplainNone ExplainMode = ""
+	// ExplainPlan returns the execution plan of the query without executing it.
+	ExplainPlan ExplainMode = "true"
+	// ExplainAnalyze executes the downstream queries of the plan and returns the plan with their statistics.
+	ExplainAnalyze ExplainMode = "analyze"
+)
+
+// ExplainResponse represents the http json response to a Loki query with the explain parameter.
+type ExplainResponse struct {
+	Status string          `json:"status"`
+	Data   *logql.PlanNode `json:"data"`
+}
+
 // QueryStatus holds the status of a query
 type QueryStatus string
 
@@ -36,8 +56,9 @@ const (
 
 // QueryResponse represents the http json response to a Loki range and instant query
 type QueryResponse struct {
-	Status string            `json:"status"`
-	Data   QueryResponseData `json:"data"`
+	Status   string            `json:"status"`
+	Data     QueryResponseData `json:"data"`
+	Warnings []string          `json:"warnings,omitempty"`
 }
 
 func (q *QueryResponse) UnmarshalJSON(data []byte) error {
@@ -51,6 +72,19 @@ func (q *QueryResponse) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			q.Data = responseData
+		case "warnings":
+			var parseErr error
+			if _, err := jsonparser.ArrayEach(value, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+				warning, err := jsonparser.ParseString(value)
+				if err != nil {
+					parseErr = err
+					return
+				}
+				q.Warnings = append(q.Warnings, warning)
+			}); err != nil {
+				return err
+			}
+			return parseErr
 		}
 		return nil
 	})
@@ -248,6 +282,7 @@ type InstantQuery struct {
 	Limit     uint32
 	Direction logproto.Direction
 	Shards    []string
+	Explain   ExplainMode
 }
 
 // ParseInstantQuery parses an InstantQuery request from an http request.
@@ -272,6 +307,11 @@ func ParseInstantQuery(r *http.Request) (*InstantQuery, error) {
 		return nil, err
 	}
 
+	request.Explain, err = explain(r)
+	if err != nil {
+		return nil, err
+	}
+
 	return request, nil
 }
 
@@ -285,6 +325,7 @@ type RangeQuery struct {
 	Direction logproto.Direction
 	Limit     uint32
 	Shards    []string
+	Explain   ExplainMode
 }
 
 // ParseRangeQuery parses a RangeQuery request from an http request.
@@ -338,6 +379,11 @@ func ParseRangeQuery(r *http.Request) (*RangeQuery, error) {
 		return nil, errNegativeInterval
 	}
 
+	result.Explain, err = explain(r)
+	if err != nil {
+		return nil, err
+	}
+
 	return &result, nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/logproto"
@@ -70,6 +71,26 @@ func TestParseRangeQuery(t *testing.T) {
 				Limit:     1000,
 			}, false,
 		},
+		{
+			"bad explain",
+			&http.Request{
+				URL: mustParseURL(`?query={foo="bar"}&start=2017-06-10T21:42:24.760738998Z&end=2017-07-10T21:42:24.760738998Z&limit=1000&step=3600&explain=profile`),
+			}, nil, true,
+		},
+		{
+			"explain",
+			&http.Request{
+				URL: mustParseURL(`?query={foo="bar"}&start=2017-06-10T21:42:24.760738998Z&end=2017-07-10T21:42:24.760738998Z&limit=1000&direction=BACKWARD&step=3600&explain=analyze`),
+			}, &RangeQuery{
+				Step:      time.Hour,
+				Query:     `{foo="bar"}`,
+				Direction: logproto.BACKWARD,
+				Start:     time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC),
+				End:       time.Date(2017, 07, 10, 21, 42, 24, 760738998, time.UTC),
+				Limit:     1000,
+				Explain:   ExplainAnalyze,
+			}, false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -114,6 +135,18 @@ func TestParseInstantQuery(t *testing.T) {
 				Limit:     1000,
 			}, false,
 		},
+		{
+			"explain",
+			&http.Request{
+				URL: mustParseURL(`?query={foo="bar"}&time=2017-06-10T21:42:24.760738998Z&limit=1000&direction=BACKWARD&explain=true`),
+			}, &InstantQuery{
+				Query:     `{foo="bar"}`,
+				Direction: logproto.BACKWARD,
+				Ts:        time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC),
+				Limit:     1000,
+				Explain:   ExplainPlan,
+			}, false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -230,7 +263,10 @@ func Test_QueryResponseUnmarshal(t *testing.T) {
 						Labels: LabelSet{"foo": "bar"},
 						Entries: []Entry{
 							{Timestamp: time.Unix(0, 1), Line: "log line 1"},
-							{Timestamp: time.Unix(0, 2), Line: "some log line 2"},
+							{Timestamp: time.Unix(0, 2), Line: "some log line 2", StructuredMetadata: labels.Labels{
+								{Name: "traceID", Value: "123"},
+								{Name: "userID", Value: "a"},
+							}},
 						},
 					},
 					Stream{
@@ -12,6 +12,8 @@ type Entry = push.Entry
 type Stream = push.Stream
 type PushRequest = push.PushRequest
 type PushResponse = push.PushResponse
+type RejectedStream = push.RejectedStream
+type RejectedEntry = push.RejectedEntry
 type PusherClient = push.PusherClient
 type PusherServer = push.PusherServer
 
@@ -216,13 +216,14 @@ func (m *StreamRate) GetPushes() uint32 {
 }
 
 type QueryRequest struct {
-	Selector  string    `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
-	Limit     uint32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	Start     time.Time `protobuf:"bytes,3,opt,name=start,proto3,stdtime" json:"start"`
-	End       time.Time `protobuf:"bytes,4,opt,name=end,proto3,stdtime" json:"end"`
-	Direction Direction `protobuf:"varint,5,opt,name=direction,proto3,enum=logproto.Direction" json:"direction,omitempty"`
-	Shards    []string  `protobuf:"bytes,7,rep,name=shards,proto3" json:"shards,omitempty"`
-	Deletes   []*Delete `protobuf:"bytes,8,rep,name=deletes,proto3" json:"deletes,omitempty"`
+	Selector     string         `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	Limit        uint32         `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Start        time.Time      `protobuf:"bytes,3,opt,name=start,proto3,stdtime" json:"start"`
+	End          time.Time      `protobuf:"bytes,4,opt,name=end,proto3,stdtime" json:"end"`
+	Direction    Direction      `protobuf:"varint,5,opt,name=direction,proto3,enum=logproto.Direction" json:"direction,omitempty"`
+	Shards       []string       `protobuf:"bytes,7,rep,name=shards,proto3" json:"shards,omitempty"`
+	Deletes      []*Delete      `protobuf:"bytes,8,rep,name=deletes,proto3" json:"deletes,omitempty"`
+	LookupTables []*LookupTable `protobuf:"bytes,9,rep,name=lookupTables,proto3" json:"lookupTables,omitempty"`
 }
 
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
@@ -306,12 +307,20 @@ func (m *QueryRequest) GetDeletes() []*Delete {
 	return nil
 }
 
+func (m *QueryRequest) GetLookupTables() []*LookupTable {
+	if m != nil {
+		return m.LookupTables
+	}
+	return nil
+}
+
 type SampleQueryRequest struct {
-	Selector string    `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
-	Start    time.Time `protobuf:"bytes,2,opt,name=start,proto3,stdtime" json:"start"`
-	End      time.Time `protobuf:"bytes,3,opt,name=end,proto3,stdtime" json:"end"`
-	Shards   []string  `protobuf:"bytes,4,rep,name=shards,proto3" json:"shards,omitempty"`
-	Deletes  []*Delete `protobuf:"bytes,5,rep,name=deletes,proto3" json:"deletes,omitempty"`
+	Selector     string         `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	Start        time.Time      `protobuf:"bytes,2,opt,name=start,proto3,stdtime" json:"start"`
+	End          time.Time      `protobuf:"bytes,3,opt,name=end,proto3,stdtime" json:"end"`
+	Shards       []string       `protobuf:"bytes,4,rep,name=shards,proto3" json:"shards,omitempty"`
+	Deletes      []*Delete      `protobuf:"bytes,5,rep,name=deletes,proto3" json:"deletes,omitempty"`
+	LookupTables []*LookupTable `protobuf:"bytes,6,rep,name=lookupTables,proto3" json:"lookupTables,omitempty"`
 }
 
 func (m *SampleQueryRequest) Reset()      { *m = SampleQueryRequest{} }
@@ -381,6 +390,13 @@ func (m *SampleQueryRequest) GetDeletes() []*Delete {
 	return nil
 }
 
+func (m *SampleQueryRequest) GetLookupTables() []*LookupTable {
+	if m != nil {
+		return m.LookupTables
+	}
+	return nil
+}
+
 type Delete struct {
 	Selector string `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
 	Start    int64  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
@@ -440,6 +456,60 @@ func (m *Delete) GetEnd() int64 {
 	return 0
 }
 
+// LookupTable references a table of the lookup stages of a query. The queriers and the ingesters load the tables
+// from the same configuration, and the version of the table loaded by the querier must be the one of the ingesters.
+type LookupTable struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// version is the hash of the content of the table.
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (m *LookupTable) Reset()      { *m = LookupTable{} }
+func (*LookupTable) ProtoMessage() {}
+func (*LookupTable) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{6}
+}
+func (m *LookupTable) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LookupTable) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LookupTable.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LookupTable) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LookupTable.Merge(m, src)
+}
+func (m *LookupTable) XXX_Size() int {
+	return m.Size()
+}
+func (m *LookupTable) XXX_DiscardUnknown() {
+	xxx_messageInfo_LookupTable.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LookupTable proto.InternalMessageInfo
+
+func (m *LookupTable) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *LookupTable) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
 type QueryResponse struct {
 	Streams []github_com_grafana_loki_pkg_push.Stream `protobuf:"bytes,1,rep,name=streams,proto3,customtype=github.com/grafana/loki/pkg/push.Stream" json:"streams,omitempty"`
 	Stats   stats.Ingester                            `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats"`
@@ -448,7 +518,7 @@ type QueryResponse struct {
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{6}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{7}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -492,7 +562,7 @@ type SampleQueryResponse struct {
 func (m *SampleQueryResponse) Reset()      { *m = SampleQueryResponse{} }
 func (*SampleQueryResponse) ProtoMessage() {}
 func (*SampleQueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{7}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{8}
 }
 func (m *SampleQueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -539,7 +609,7 @@ type LabelRequest struct {
 func (m *LabelRequest) Reset()      { *m = LabelRequest{} }
 func (*LabelRequest) ProtoMessage() {}
 func (*LabelRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{8}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{9}
 }
 func (m *LabelRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -610,7 +680,7 @@ type LabelResponse struct {
 func (m *LabelResponse) Reset()      { *m = LabelResponse{} }
 func (*LabelResponse) ProtoMessage() {}
 func (*LabelResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{9}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{10}
 }
 func (m *LabelResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -655,7 +725,7 @@ type Sample struct {
 func (m *Sample) Reset()      { *m = Sample{} }
 func (*Sample) ProtoMessage() {}
 func (*Sample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{10}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{11}
 }
 func (m *Sample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -714,7 +784,7 @@ type LegacySample struct {
 func (m *LegacySample) Reset()      { *m = LegacySample{} }
 func (*LegacySample) ProtoMessage() {}
 func (*LegacySample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{11}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{12}
 }
 func (m *LegacySample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -766,7 +836,7 @@ type Series struct {
 func (m *Series) Reset()      { *m = Series{} }
 func (*Series) ProtoMessage() {}
 func (*Series) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{12}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{13}
 }
 func (m *Series) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -826,7 +896,7 @@ type TailRequest struct {
 func (m *TailRequest) Reset()      { *m = TailRequest{} }
 func (*TailRequest) ProtoMessage() {}
 func (*TailRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{13}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{14}
 }
 func (m *TailRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -891,7 +961,7 @@ type TailResponse struct {
 func (m *TailResponse) Reset()      { *m = TailResponse{} }
 func (*TailResponse) ProtoMessage() {}
 func (*TailResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{14}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{15}
 }
 func (m *TailResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -937,7 +1007,7 @@ type SeriesRequest struct {
 func (m *SeriesRequest) Reset()      { *m = SeriesRequest{} }
 func (*SeriesRequest) ProtoMessage() {}
 func (*SeriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{15}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{16}
 }
 func (m *SeriesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1001,7 +1071,7 @@ type SeriesResponse struct {
 func (m *SeriesResponse) Reset()      { *m = SeriesResponse{} }
 func (*SeriesResponse) ProtoMessage() {}
 func (*SeriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{16}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
 }
 func (m *SeriesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1044,7 +1114,7 @@ type SeriesIdentifier struct {
 func (m *SeriesIdentifier) Reset()      { *m = SeriesIdentifier{} }
 func (*SeriesIdentifier) ProtoMessage() {}
 func (*SeriesIdentifier) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
 }
 func (m *SeriesIdentifier) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1089,7 +1159,7 @@ type DroppedStream struct {
 func (m *DroppedStream) Reset()      { *m = DroppedStream{} }
 func (*DroppedStream) ProtoMessage() {}
 func (*DroppedStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
 }
 func (m *DroppedStream) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1149,7 +1219,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1214,7 +1284,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1266,7 +1336,7 @@ type LegacyLabelPair struct {
 func (m *LegacyLabelPair) Reset()      { *m = LegacyLabelPair{} }
 func (*LegacyLabelPair) ProtoMessage() {}
 func (*LegacyLabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
 }
 func (m *LegacyLabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1316,7 +1386,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1358,7 +1428,7 @@ type TransferChunksResponse struct {
 func (m *TransferChunksResponse) Reset()      { *m = TransferChunksResponse{} }
 func (*TransferChunksResponse) ProtoMessage() {}
 func (*TransferChunksResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{24}
 }
 func (m *TransferChunksResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1393,7 +1463,7 @@ type TailersCountRequest struct {
 func (m *TailersCountRequest) Reset()      { *m = TailersCountRequest{} }
 func (*TailersCountRequest) ProtoMessage() {}
 func (*TailersCountRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{24}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{25}
 }
 func (m *TailersCountRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1429,7 +1499,7 @@ type TailersCountResponse struct {
 func (m *TailersCountResponse) Reset()      { *m = TailersCountResponse{} }
 func (*TailersCountResponse) ProtoMessage() {}
 func (*TailersCountResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{25}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{26}
 }
 func (m *TailersCountResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1474,7 +1544,7 @@ type GetChunkIDsRequest struct {
 func (m *GetChunkIDsRequest) Reset()      { *m = GetChunkIDsRequest{} }
 func (*GetChunkIDsRequest) ProtoMessage() {}
 func (*GetChunkIDsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{26}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{27}
 }
 func (m *GetChunkIDsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1531,7 +1601,7 @@ type GetChunkIDsResponse struct {
 func (m *GetChunkIDsResponse) Reset()      { *m = GetChunkIDsResponse{} }
 func (*GetChunkIDsResponse) ProtoMessage() {}
 func (*GetChunkIDsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{27}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{28}
 }
 func (m *GetChunkIDsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1584,7 +1654,7 @@ type ChunkRef struct {
 func (m *ChunkRef) Reset()      { *m = ChunkRef{} }
 func (*ChunkRef) ProtoMessage() {}
 func (*ChunkRef) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{28}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{29}
 }
 func (m *ChunkRef) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1645,7 +1715,7 @@ type LabelValuesForMetricNameRequest struct {
 func (m *LabelValuesForMetricNameRequest) Reset()      { *m = LabelValuesForMetricNameRequest{} }
 func (*LabelValuesForMetricNameRequest) ProtoMessage() {}
 func (*LabelValuesForMetricNameRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{29}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{30}
 }
 func (m *LabelValuesForMetricNameRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1704,7 +1774,7 @@ type LabelNamesForMetricNameRequest struct {
 func (m *LabelNamesForMetricNameRequest) Reset()      { *m = LabelNamesForMetricNameRequest{} }
 func (*LabelNamesForMetricNameRequest) ProtoMessage() {}
 func (*LabelNamesForMetricNameRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{30}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{31}
 }
 func (m *LabelNamesForMetricNameRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1749,7 +1819,7 @@ type GetChunkRefRequest struct {
 func (m *GetChunkRefRequest) Reset()      { *m = GetChunkRefRequest{} }
 func (*GetChunkRefRequest) ProtoMessage() {}
 func (*GetChunkRefRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{31}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{32}
 }
 func (m *GetChunkRefRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1792,7 +1862,7 @@ type GetChunkRefResponse struct {
 func (m *GetChunkRefResponse) Reset()      { *m = GetChunkRefResponse{} }
 func (*GetChunkRefResponse) ProtoMessage() {}
 func (*GetChunkRefResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{32}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{33}
 }
 func (m *GetChunkRefResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1837,7 +1907,7 @@ type GetSeriesRequest struct {
 func (m *GetSeriesRequest) Reset()      { *m = GetSeriesRequest{} }
 func (*GetSeriesRequest) ProtoMessage() {}
 func (*GetSeriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{33}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{34}
 }
 func (m *GetSeriesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1880,7 +1950,7 @@ type GetSeriesResponse struct {
 func (m *GetSeriesResponse) Reset()      { *m = GetSeriesResponse{} }
 func (*GetSeriesResponse) ProtoMessage() {}
 func (*GetSeriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{34}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{35}
 }
 func (m *GetSeriesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1924,7 +1994,7 @@ type IndexSeries struct {
 func (m *IndexSeries) Reset()      { *m = IndexSeries{} }
 func (*IndexSeries) ProtoMessage() {}
 func (*IndexSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{35}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{36}
 }
 func (m *IndexSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1961,7 +2031,7 @@ type QueryIndexResponse struct {
 func (m *QueryIndexResponse) Reset()      { *m = QueryIndexResponse{} }
 func (*QueryIndexResponse) ProtoMessage() {}
 func (*QueryIndexResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{36}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{37}
 }
 func (m *QueryIndexResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2012,7 +2082,7 @@ type Row struct {
 func (m *Row) Reset()      { *m = Row{} }
 func (*Row) ProtoMessage() {}
 func (*Row) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{37}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{38}
 }
 func (m *Row) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2062,7 +2132,7 @@ type QueryIndexRequest struct {
 func (m *QueryIndexRequest) Reset()      { *m = QueryIndexRequest{} }
 func (*QueryIndexRequest) ProtoMessage() {}
 func (*QueryIndexRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{38}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{39}
 }
 func (m *QueryIndexRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2109,7 +2179,7 @@ type IndexQuery struct {
 func (m *IndexQuery) Reset()      { *m = IndexQuery{} }
 func (*IndexQuery) ProtoMessage() {}
 func (*IndexQuery) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{39}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{40}
 }
 func (m *IndexQuery) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2182,7 +2252,7 @@ type IndexStatsRequest struct {
 func (m *IndexStatsRequest) Reset()      { *m = IndexStatsRequest{} }
 func (*IndexStatsRequest) ProtoMessage() {}
 func (*IndexStatsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{40}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{41}
 }
 func (m *IndexStatsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2228,7 +2298,7 @@ type IndexStatsResponse struct {
 func (m *IndexStatsResponse) Reset()      { *m = IndexStatsResponse{} }
 func (*IndexStatsResponse) ProtoMessage() {}
 func (*IndexStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{41}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{42}
 }
 func (m *IndexStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2296,7 +2366,7 @@ type VolumeRequest struct {
 func (m *VolumeRequest) Reset()      { *m = VolumeRequest{} }
 func (*VolumeRequest) ProtoMessage() {}
 func (*VolumeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{42}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{43}
 }
 func (m *VolumeRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2354,7 +2424,7 @@ type VolumeResponse struct {
 func (m *VolumeResponse) Reset()      { *m = VolumeResponse{} }
 func (*VolumeResponse) ProtoMessage() {}
 func (*VolumeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{43}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{44}
 }
 func (m *VolumeResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2405,7 +2475,7 @@ type Volume struct {
 func (m *Volume) Reset()      { *m = Volume{} }
 func (*Volume) ProtoMessage() {}
 func (*Volume) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{44}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{45}
 }
 func (m *Volume) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2456,6 +2526,7 @@ func init() {
 	proto.RegisterType((*QueryRequest)(nil), "logproto.QueryRequest")
 	proto.RegisterType((*SampleQueryRequest)(nil), "logproto.SampleQueryRequest")
 	proto.RegisterType((*Delete)(nil), "logproto.Delete")
+	proto.RegisterType((*LookupTable)(nil), "logproto.LookupTable")
 	proto.RegisterType((*QueryResponse)(nil), "logproto.QueryResponse")
 	proto.RegisterType((*SampleQueryResponse)(nil), "logproto.SampleQueryResponse")
 	proto.RegisterType((*LabelRequest)(nil), "logproto.LabelRequest")
@@ -2501,146 +2572,148 @@ func init() {
 func init() { proto.RegisterFile("pkg/logproto/logproto.proto", fileDescriptor_c28a5f14f1f4c79a) }
 
 var fileDescriptor_c28a5f14f1f4c79a = []byte{
-	// 2213 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x39, 0xcd, 0x6f, 0x1b, 0xc7,
-	0xf5, 0x1c, 0x72, 0x49, 0x91, 0x8f, 0xd4, 0x87, 0x47, 0x8c, 0xad, 0x1f, 0x6d, 0x93, 0xf2, 0x20,
-	0x3f, 0x5b, 0xb0, 0x1d, 0x32, 0x56, 0xda, 0xd4, 0xb1, 0x9b, 0x16, 0xa6, 0x14, 0x3b, 0xf2, 0x77,
-	0x46, 0xae, 0x5b, 0x04, 0x0d, 0x8c, 0x15, 0x39, 0xfc, 0x80, 0xb9, 0x5c, 0x7a, 0x77, 0x19, 0x47,
-	0x40, 0x0f, 0xfd, 0x07, 0x02, 0xe4, 0x56, 0xf4, 0x52, 0xf4, 0x50, 0xa0, 0x45, 0x81, 0x5e, 0xfa,
-	0x07, 0xb4, 0x3d, 0x14, 0xa8, 0x7b, 0x73, 0x6f, 0x41, 0x0f, 0x6c, 0x2d, 0xa3, 0x40, 0xa1, 0x53,
-	0xfe, 0x81, 0x16, 0xc5, 0x7c, 0xed, 0xce, 0xae, 0xa8, 0x24, 0x74, 0x0d, 0x14, 0xbe, 0x88, 0xfb,
-	0xde, 0xbc, 0x79, 0xf3, 0xbe, 0xdf, 0xbc, 0x11, 0x1c, 0x1f, 0x3d, 0xec, 0x36, 0x06, 0x6e, 0x77,
-	0xe4, 0xb9, 0x81, 0x1b, 0x7e, 0xd4, 0xc5, 0x5f, 0x9c, 0xd7, 0x70, 0xa5, 0xdc, 0x75, 0xbb, 0xae,
-	0xa4, 0xe1, 0x5f, 0x72, 0xbd, 0x52, 0xeb, 0xba, 0x6e, 0x77, 0xc0, 0x1a, 0x02, 0xda, 0x19, 0x77,
-	0x1a, 0x41, 0xdf, 0x61, 0x7e, 0x60, 0x3b, 0x23, 0x45, 0xb0, 0xaa, 0xb8, 0x3f, 0x1a, 0x38, 0x6e,
-	0x9b, 0x0d, 0x1a, 0x7e, 0x60, 0x07, 0xbe, 0xfc, 0xab, 0x28, 0x96, 0x39, 0xc5, 0x68, 0xec, 0xf7,
-	0xc4, 0x1f, 0x89, 0x24, 0x65, 0xc0, 0xdb, 0x81, 0xc7, 0x6c, 0x87, 0xda, 0x01, 0xf3, 0x29, 0x7b,
-	0x34, 0x66, 0x7e, 0x40, 0x6e, 0xc1, 0x72, 0x0c, 0xeb, 0x8f, 0xdc, 0xa1, 0xcf, 0xf0, 0xdb, 0x50,
-	0xf4, 0x23, 0xf4, 0x0a, 0x5a, 0xcd, 0xac, 0x15, 0xd7, 0xcb, 0xf5, 0x50, 0x95, 0x68, 0x0f, 0x35,
-	0x09, 0xc9, 0xcf, 0x10, 0x40, 0xb4, 0x86, 0xab, 0x00, 0x72, 0xf5, 0x7d, 0xdb, 0xef, 0xad, 0xa0,
-	0x55, 0xb4, 0x66, 0x51, 0x03, 0x83, 0xcf, 0xc3, 0x91, 0x08, 0xba, 0xed, 0x6e, 0xf7, 0x6c, 0xaf,
-	0xbd, 0x92, 0x16, 0x64, 0x07, 0x17, 0x30, 0x06, 0xcb, 0xb3, 0x03, 0xb6, 0x92, 0x59, 0x45, 0x6b,
-	0x19, 0x2a, 0xbe, 0xf1, 0x51, 0xc8, 0x05, 0x6c, 0x68, 0x0f, 0x83, 0x15, 0x6b, 0x15, 0xad, 0x15,
-	0xa8, 0x82, 0x38, 0x9e, 0xeb, 0xce, 0xfc, 0x95, 0xec, 0x2a, 0x5a, 0x9b, 0xa7, 0x0a, 0x22, 0x7f,
-	0x4a, 0x43, 0xe9, 0x83, 0x31, 0xf3, 0x76, 0x95, 0x01, 0x70, 0x05, 0xf2, 0x3e, 0x1b, 0xb0, 0x56,
-	0xe0, 0x7a, 0x42, 0xc0, 0x02, 0x0d, 0x61, 0x5c, 0x86, 0xec, 0xa0, 0xef, 0xf4, 0x03, 0x21, 0xd2,
-	0x3c, 0x95, 0x00, 0xbe, 0x04, 0x59, 0x3f, 0xb0, 0xbd, 0x40, 0xc8, 0x51, 0x5c, 0xaf, 0xd4, 0xa5,
-	0xc3, 0xea, 0xda, 0x61, 0xf5, 0x7b, 0xda, 0x61, 0xcd, 0xfc, 0x93, 0x49, 0x2d, 0xf5, 0xd9, 0xdf,
-	0x6a, 0x88, 0xca, 0x2d, 0xf8, 0x6d, 0xc8, 0xb0, 0x61, 0x5b, 0xc8, 0xfa, 0x75, 0x77, 0xf2, 0x0d,
-	0xf8, 0x02, 0x14, 0xda, 0x7d, 0x8f, 0xb5, 0x82, 0xbe, 0x3b, 0x14, 0x1a, 0x2d, 0xac, 0x2f, 0x47,
-	0xde, 0xd8, 0xd4, 0x4b, 0x34, 0xa2, 0xc2, 0xe7, 0x21, 0xe7, 0x73, 0xb3, 0xf9, 0x2b, 0x73, 0xab,
-	0x99, 0xb5, 0x42, 0xb3, 0xbc, 0x3f, 0xa9, 0x2d, 0x49, 0xcc, 0x79, 0xd7, 0xe9, 0x07, 0xcc, 0x19,
-	0x05, 0xbb, 0x54, 0xd1, 0xe0, 0xb3, 0x30, 0xd7, 0x66, 0x03, 0xc6, 0x9d, 0x9d, 0x17, 0xce, 0x5e,
-	0x32, 0xd8, 0x8b, 0x05, 0xaa, 0x09, 0xae, 0x5b, 0xf9, 0xdc, 0xd2, 0x1c, 0xf9, 0x37, 0x02, 0xbc,
-	0x6d, 0x3b, 0xa3, 0x01, 0xfb, 0xda, 0xf6, 0x0c, 0x2d, 0x97, 0x7e, 0x61, 0xcb, 0x65, 0x66, 0xb5,
-	0x5c, 0x64, 0x06, 0x6b, 0x36, 0x33, 0x64, 0xbf, 0xc2, 0x0c, 0xe4, 0x26, 0xe4, 0x24, 0xea, 0xab,
-	0x62, 0x28, 0xd2, 0x39, 0xa3, 0xb5, 0x59, 0x8a, 0xb4, 0xc9, 0x08, 0x39, 0xc9, 0xcf, 0x11, 0xcc,
-	0x2b, 0x43, 0xaa, 0x1c, 0xdc, 0x81, 0x39, 0x99, 0x03, 0x3a, 0xff, 0x8e, 0x25, 0xf3, 0xef, 0x4a,
-	0xdb, 0x1e, 0x05, 0xcc, 0x6b, 0x36, 0x9e, 0x4c, 0x6a, 0xe8, 0xaf, 0x93, 0xda, 0x99, 0x6e, 0x3f,
-	0xe8, 0x8d, 0x77, 0xea, 0x2d, 0xd7, 0x69, 0x74, 0x3d, 0xbb, 0x63, 0x0f, 0xed, 0xc6, 0xc0, 0x7d,
-	0xd8, 0x6f, 0xe8, 0x7a, 0xa0, 0xf3, 0x56, 0x33, 0xc6, 0xe7, 0x84, 0x74, 0x81, 0xaf, 0x3c, 0xb2,
-	0x58, 0x97, 0x65, 0x64, 0x6b, 0xd8, 0x65, 0x3e, 0xe7, 0x6c, 0x71, 0x63, 0x52, 0x49, 0x43, 0x7e,
-	0x04, 0xcb, 0x31, 0x87, 0x2b, 0x39, 0x2f, 0x42, 0xce, 0x67, 0x5e, 0x3f, 0x2c, 0x13, 0x86, 0xc9,
-	0xb6, 0x05, 0xbe, 0xb9, 0xa0, 0xe4, 0xcb, 0x49, 0x98, 0x2a, 0xfa, 0xd9, 0x4e, 0xff, 0x23, 0x82,
-	0xd2, 0x4d, 0x7b, 0x87, 0x0d, 0x74, 0xa4, 0x61, 0xb0, 0x86, 0xb6, 0xc3, 0x94, 0xc5, 0xc5, 0x37,
-	0x4f, 0xfb, 0x8f, 0xed, 0xc1, 0x98, 0x49, 0x96, 0x79, 0xaa, 0xa0, 0x59, 0x73, 0x16, 0xbd, 0x70,
-	0xce, 0xa2, 0x28, 0xf2, 0xca, 0x90, 0x7d, 0xc4, 0x0d, 0x25, 0xf2, 0xb5, 0x40, 0x25, 0x40, 0xce,
-	0xc0, 0xbc, 0xd2, 0x42, 0x99, 0x2f, 0x12, 0x99, 0x9b, 0xaf, 0xa0, 0x45, 0x26, 0x0e, 0xe4, 0xa4,
-	0xb5, 0xf1, 0xeb, 0x50, 0x08, 0x7b, 0x80, 0xd0, 0x36, 0xd3, 0xcc, 0xed, 0x4f, 0x6a, 0xe9, 0xc0,
-	0xa7, 0xd1, 0x02, 0xae, 0x41, 0x56, 0xec, 0x14, 0x9a, 0xa3, 0x66, 0x61, 0x7f, 0x52, 0x93, 0x08,
-	0x2a, 0x7f, 0xf0, 0x09, 0xb0, 0x7a, 0xbc, 0x0c, 0x73, 0x13, 0x58, 0xcd, 0xfc, 0xfe, 0xa4, 0x26,
-	0x60, 0x2a, 0xfe, 0x92, 0x6b, 0x50, 0xba, 0xc9, 0xba, 0x76, 0x6b, 0x57, 0x1d, 0x5a, 0xd6, 0xec,
-	0xf8, 0x81, 0x48, 0xf3, 0x38, 0x05, 0xa5, 0xf0, 0xc4, 0x07, 0x8e, 0xaf, 0x82, 0xba, 0x18, 0xe2,
-	0x6e, 0xf9, 0xe4, 0xa7, 0x08, 0x94, 0x9f, 0x31, 0x81, 0xdc, 0x80, 0xeb, 0xea, 0x4b, 0x1f, 0x35,
-	0x61, 0x7f, 0x52, 0x53, 0x18, 0xaa, 0x7e, 0xf1, 0x65, 0x98, 0xf3, 0xc5, 0x89, 0x9c, 0x59, 0x32,
-	0x7c, 0xc4, 0x42, 0x73, 0x91, 0x87, 0xc1, 0xfe, 0xa4, 0xa6, 0x09, 0xa9, 0xfe, 0xc0, 0xf5, 0x58,
-	0x7f, 0x91, 0x8a, 0x2d, 0xec, 0x4f, 0x6a, 0x06, 0xd6, 0xec, 0x37, 0xe4, 0x27, 0x08, 0x8a, 0xf7,
-	0xec, 0x7e, 0x18, 0x42, 0xa1, 0x8b, 0x90, 0xe1, 0x22, 0x9e, 0xce, 0x6d, 0x36, 0xb0, 0x77, 0xaf,
-	0xba, 0x9e, 0xe0, 0x39, 0x4f, 0x43, 0x38, 0x6a, 0x09, 0xd6, 0xd4, 0x96, 0x90, 0x9d, 0xb9, 0xb0,
-	0x5d, 0xb7, 0xf2, 0xe9, 0xa5, 0x0c, 0xf9, 0x0d, 0x82, 0x92, 0x94, 0x4c, 0x85, 0xc5, 0x0f, 0x21,
-	0x27, 0x05, 0x17, 0xb2, 0x7d, 0x49, 0xf2, 0x9f, 0x9b, 0x25, 0xf1, 0x15, 0x4f, 0xfc, 0x5d, 0x58,
-	0x68, 0x7b, 0xee, 0x68, 0xc4, 0xda, 0xdb, 0xaa, 0xc4, 0xa4, 0x93, 0x25, 0x66, 0xd3, 0x5c, 0xa7,
-	0x09, 0x72, 0xf2, 0x67, 0x04, 0xf3, 0x2a, 0x9b, 0x95, 0x2d, 0x43, 0x1b, 0xa0, 0x17, 0x2e, 0xee,
-	0xe9, 0x59, 0x8b, 0xfb, 0x51, 0xc8, 0x75, 0x3d, 0x77, 0x3c, 0xf2, 0x57, 0x32, 0x32, 0x77, 0x24,
-	0x34, 0x5b, 0xd1, 0x27, 0xd7, 0x61, 0x41, 0xab, 0x72, 0x48, 0x49, 0xab, 0x24, 0x4b, 0xda, 0x56,
-	0x9b, 0x0d, 0x83, 0x7e, 0xa7, 0x1f, 0x16, 0x29, 0x45, 0x4f, 0x3e, 0x45, 0xb0, 0x94, 0x24, 0xc1,
-	0xdf, 0x31, 0xf2, 0x80, 0xb3, 0x3b, 0x7d, 0x38, 0xbb, 0xba, 0x28, 0x0e, 0xfe, 0x7b, 0xc3, 0xc0,
-	0xdb, 0xd5, 0x39, 0x52, 0x79, 0x07, 0x8a, 0x06, 0x9a, 0x37, 0x8f, 0x87, 0x4c, 0xc7, 0x2c, 0xff,
-	0x8c, 0x92, 0x35, 0x2d, 0xe3, 0x58, 0x00, 0x97, 0xd2, 0x17, 0x11, 0x8f, 0xf8, 0xf9, 0x98, 0x27,
-	0xf1, 0x45, 0xb0, 0x3a, 0x9e, 0xeb, 0xcc, 0xe4, 0x26, 0xb1, 0x03, 0x7f, 0x03, 0xd2, 0x81, 0x3b,
-	0x93, 0x93, 0xd2, 0x81, 0xcb, 0x7d, 0xa4, 0x94, 0xcf, 0xc8, 0x1b, 0x9a, 0x84, 0xc8, 0xaf, 0x11,
-	0x2c, 0xf2, 0x3d, 0xd2, 0x02, 0x1b, 0xbd, 0xf1, 0xf0, 0x21, 0x5e, 0x83, 0x25, 0x7e, 0xd2, 0x83,
-	0xbe, 0xea, 0x00, 0x0f, 0xfa, 0x6d, 0xa5, 0xe6, 0x02, 0xc7, 0xeb, 0xc6, 0xb0, 0xd5, 0xc6, 0xc7,
-	0x60, 0x6e, 0xec, 0x4b, 0x02, 0xa9, 0x73, 0x8e, 0x83, 0x5b, 0x6d, 0x7c, 0xce, 0x38, 0x8e, 0xdb,
-	0xda, 0xb8, 0x26, 0x09, 0x1b, 0xde, 0xb5, 0xfb, 0x5e, 0x58, 0x7c, 0xce, 0x40, 0xae, 0xc5, 0x0f,
-	0x96, 0x71, 0xc2, 0x3b, 0x50, 0x48, 0x2c, 0x04, 0xa2, 0x6a, 0x99, 0x7c, 0x13, 0x0a, 0xe1, 0xee,
-	0xa9, 0x8d, 0x67, 0xaa, 0x07, 0xc8, 0x65, 0x58, 0x94, 0x45, 0x75, 0xfa, 0xe6, 0xd2, 0xb4, 0xcd,
-	0x25, 0xbd, 0xf9, 0x38, 0x64, 0xa5, 0x55, 0x30, 0x58, 0x6d, 0x3b, 0xb0, 0xf5, 0x16, 0xfe, 0x4d,
-	0x56, 0xe0, 0xe8, 0x3d, 0xcf, 0x1e, 0xfa, 0x1d, 0xe6, 0x09, 0xa2, 0x30, 0x76, 0xc9, 0x6b, 0xb0,
-	0xcc, 0x0b, 0x09, 0xf3, 0xfc, 0x0d, 0x77, 0x3c, 0x0c, 0xf4, 0x45, 0xff, 0x3c, 0x94, 0xe3, 0x68,
-	0x15, 0xea, 0x65, 0xc8, 0xb6, 0x38, 0x42, 0x70, 0x9f, 0xa7, 0x12, 0x20, 0xbf, 0x40, 0x80, 0xaf,
-	0xb1, 0x40, 0xb0, 0xde, 0xda, 0xf4, 0x8d, 0xcb, 0x9d, 0x63, 0x07, 0xad, 0x1e, 0xf3, 0x7c, 0x7d,
-	0xd1, 0xd1, 0xf0, 0xff, 0xe2, 0x72, 0x47, 0x2e, 0xc0, 0x72, 0x4c, 0x4a, 0xa5, 0x53, 0x05, 0xf2,
-	0x2d, 0x85, 0x53, 0x4d, 0x35, 0x84, 0xc9, 0x6f, 0xd3, 0x90, 0x97, 0xbe, 0x65, 0x1d, 0x7c, 0x01,
-	0x8a, 0x1d, 0x1e, 0x6b, 0xde, 0xc8, 0xeb, 0x2b, 0x13, 0x58, 0xcd, 0xc5, 0xfd, 0x49, 0xcd, 0x44,
-	0x53, 0x13, 0xc0, 0x6f, 0x24, 0x02, 0xaf, 0x59, 0xde, 0x9b, 0xd4, 0x72, 0xdf, 0xe3, 0xc1, 0xb7,
-	0xc9, 0xdb, 0x9b, 0x08, 0xc3, 0xcd, 0x30, 0x1c, 0x6f, 0xa8, 0x6c, 0x13, 0x37, 0xbd, 0xe6, 0xb7,
-	0xb8, 0xf8, 0x89, 0x7a, 0x3d, 0xf2, 0x5c, 0x87, 0x05, 0x3d, 0x36, 0xf6, 0x1b, 0x2d, 0xd7, 0x71,
-	0xdc, 0x61, 0x43, 0x8c, 0x75, 0x42, 0x69, 0xde, 0xa3, 0xf9, 0x76, 0x95, 0x80, 0xf7, 0x60, 0x2e,
-	0xe8, 0x79, 0xee, 0xb8, 0xdb, 0x13, 0xed, 0x27, 0xd3, 0xbc, 0x34, 0x3b, 0x3f, 0xcd, 0x81, 0xea,
-	0x0f, 0x7c, 0x8a, 0x5b, 0x8b, 0xb5, 0x1e, 0xfa, 0x63, 0x47, 0x0e, 0x4b, 0xcd, 0xec, 0xfe, 0xa4,
-	0x86, 0xde, 0xa0, 0x21, 0x9a, 0x7c, 0x9a, 0x86, 0x9a, 0x08, 0xe1, 0xfb, 0xe2, 0x6e, 0x72, 0xd5,
-	0xf5, 0x6e, 0xb1, 0xc0, 0xeb, 0xb7, 0x6e, 0xdb, 0x0e, 0xd3, 0xb1, 0x51, 0x83, 0xa2, 0x23, 0x90,
-	0x0f, 0x8c, 0xe4, 0x00, 0x27, 0xa4, 0xc3, 0x27, 0x01, 0x44, 0xda, 0xc9, 0x75, 0x99, 0x27, 0x05,
-	0x81, 0x11, 0xcb, 0x1b, 0x31, 0x4b, 0x35, 0x66, 0xd4, 0x4c, 0x59, 0x68, 0x2b, 0x69, 0xa1, 0x99,
-	0xf9, 0x84, 0x66, 0x31, 0x63, 0x3d, 0x1b, 0x8f, 0x75, 0xf2, 0x17, 0x04, 0xd5, 0x9b, 0x5a, 0xf2,
-	0x17, 0x34, 0x87, 0xd6, 0x37, 0xfd, 0x92, 0xf4, 0xcd, 0xfc, 0x77, 0xfa, 0x92, 0x3f, 0x18, 0x29,
-	0x4f, 0x59, 0x47, 0xeb, 0xb1, 0x61, 0xb4, 0x8b, 0x97, 0x21, 0x66, 0xfa, 0x25, 0xba, 0x25, 0x93,
-	0x70, 0xcb, 0xbb, 0x51, 0x39, 0x10, 0x1a, 0xa8, 0x72, 0x70, 0x1a, 0x2c, 0x8f, 0x75, 0x74, 0xf3,
-	0xc5, 0xc9, 0x1a, 0xcf, 0x3a, 0x54, 0xac, 0x93, 0xdf, 0x21, 0x58, 0xba, 0xc6, 0x82, 0xf8, 0xb5,
-	0xe6, 0x55, 0xd2, 0xff, 0x7d, 0x38, 0x62, 0xc8, 0xaf, 0xb4, 0x7f, 0x2b, 0x71, 0x97, 0x79, 0x2d,
-	0xd2, 0x7f, 0x6b, 0xd8, 0x66, 0x9f, 0xa8, 0x19, 0x2d, 0x7e, 0x8d, 0xb9, 0x0b, 0x45, 0x63, 0x11,
-	0x5f, 0x49, 0x5c, 0x60, 0xa6, 0x35, 0xd5, 0x66, 0x59, 0xe9, 0x24, 0xa7, 0x34, 0x75, 0x3d, 0x0d,
-	0xdb, 0xfd, 0x36, 0x60, 0x31, 0x36, 0x0a, 0xb6, 0x66, 0xa5, 0x16, 0xd8, 0x1b, 0xe1, 0x7d, 0x26,
-	0x84, 0xf1, 0x29, 0xb0, 0x3c, 0xf7, 0xb1, 0xbe, 0x99, 0xce, 0x47, 0x47, 0x52, 0xf7, 0x31, 0x15,
-	0x4b, 0xe4, 0x32, 0x64, 0xa8, 0xfb, 0x18, 0x57, 0x01, 0x3c, 0x7b, 0xd8, 0x65, 0xf7, 0xc3, 0x81,
-	0xa5, 0x44, 0x0d, 0xcc, 0x21, 0xfd, 0x75, 0x03, 0x8e, 0x98, 0x12, 0x49, 0x77, 0xd7, 0x61, 0x8e,
-	0x23, 0xfb, 0xd3, 0x1e, 0xbd, 0x04, 0xa1, 0x9c, 0x7d, 0x35, 0x11, 0x8f, 0x19, 0x88, 0xf0, 0xf8,
-	0x04, 0x14, 0x02, 0x7b, 0x67, 0xc0, 0x6e, 0x47, 0x39, 0x1f, 0x21, 0xf8, 0x2a, 0x9f, 0xb5, 0xee,
-	0x1b, 0x17, 0x85, 0x08, 0x81, 0xcf, 0xc2, 0x52, 0x24, 0xf3, 0x5d, 0x8f, 0x75, 0xfa, 0x9f, 0x08,
-	0x0f, 0x97, 0xe8, 0x01, 0x3c, 0x5e, 0x83, 0xc5, 0x08, 0xb7, 0x2d, 0xda, 0xae, 0x25, 0x48, 0x93,
-	0x68, 0x6e, 0x1b, 0xa1, 0xee, 0x7b, 0x8f, 0xc6, 0xf6, 0x40, 0x14, 0xb2, 0x12, 0x35, 0x30, 0xe4,
-	0xf7, 0x08, 0x8e, 0x48, 0x57, 0xf3, 0x29, 0xfb, 0x55, 0x8c, 0xfa, 0x5f, 0x22, 0xc0, 0xa6, 0x06,
-	0x2a, 0xb4, 0xfe, 0xdf, 0x7c, 0x3e, 0xe1, 0x7d, 0xbd, 0x28, 0x46, 0x48, 0x89, 0x8a, 0x5e, 0x40,
-	0x48, 0x78, 0x05, 0x14, 0xef, 0x8e, 0x72, 0x46, 0x95, 0x18, 0x7d, 0xfb, 0xe3, 0xa3, 0xf5, 0xce,
-	0x6e, 0xc0, 0x7c, 0x35, 0x61, 0x8a, 0xd1, 0x5a, 0x20, 0xa8, 0xfc, 0xe1, 0x67, 0xb1, 0x61, 0x20,
-	0xa2, 0xc6, 0x8a, 0xce, 0x52, 0x28, 0xaa, 0x3f, 0xc8, 0x3f, 0x10, 0xcc, 0xdf, 0x77, 0x07, 0xe3,
-	0xa8, 0x4b, 0xbc, 0x42, 0x76, 0x8e, 0x8f, 0xbe, 0x59, 0x3d, 0xfa, 0x62, 0xb0, 0xfc, 0x80, 0x8d,
-	0x44, 0x64, 0x65, 0xa8, 0xf8, 0x26, 0x3f, 0x80, 0x05, 0xad, 0xa6, 0x72, 0xc6, 0x9b, 0x30, 0xf7,
-	0xb1, 0xc0, 0x4c, 0x79, 0x24, 0x92, 0xa4, 0xaa, 0x00, 0x69, 0xb2, 0xf8, 0xdb, 0xab, 0x3e, 0x8d,
-	0x5c, 0x87, 0x9c, 0x24, 0xc7, 0x27, 0xcc, 0x4b, 0xb8, 0x7c, 0xcd, 0xe0, 0xb0, 0xba, 0x51, 0x13,
-	0xc8, 0x49, 0x46, 0xca, 0x65, 0xc2, 0xab, 0x12, 0x43, 0xd5, 0xef, 0xd9, 0xd3, 0x50, 0x08, 0x1f,
-	0x4e, 0x71, 0x11, 0xe6, 0xae, 0xde, 0xa1, 0xdf, 0xbf, 0x42, 0x37, 0x97, 0x52, 0xb8, 0x04, 0xf9,
-	0xe6, 0x95, 0x8d, 0x1b, 0x02, 0x42, 0xeb, 0xff, 0xb2, 0x74, 0x4d, 0xf0, 0xf0, 0xb7, 0x21, 0x2b,
-	0x13, 0xfd, 0x68, 0x24, 0xbf, 0xf9, 0xfc, 0x59, 0x39, 0x76, 0x00, 0xaf, 0xae, 0xe5, 0xa9, 0x37,
-	0x11, 0xbe, 0x0d, 0x45, 0x81, 0x54, 0x4f, 0x2c, 0x27, 0x92, 0x2f, 0x1d, 0x31, 0x4e, 0x27, 0x0f,
-	0x59, 0x35, 0xf8, 0x5d, 0x82, 0xac, 0xa8, 0xb5, 0xa6, 0x34, 0xe6, 0x13, 0x99, 0x29, 0x4d, 0xec,
-	0xd1, 0x89, 0xa4, 0xf0, 0x3b, 0x60, 0xf1, 0x79, 0x00, 0x1b, 0xed, 0xc0, 0x78, 0x19, 0xa9, 0x1c,
-	0x4d, 0xa2, 0x8d, 0x63, 0xdf, 0x0d, 0x1f, 0x78, 0x8e, 0x25, 0x07, 0x59, 0xbd, 0x7d, 0xe5, 0xe0,
-	0x42, 0x78, 0xf2, 0x1d, 0xf9, 0xd2, 0xa1, 0x27, 0x11, 0x7c, 0x32, 0x7e, 0x54, 0x62, 0x70, 0xa9,
-	0x54, 0x0f, 0x5b, 0x0e, 0x19, 0xde, 0x84, 0xa2, 0x31, 0x05, 0x98, 0x66, 0x3d, 0x38, 0xc2, 0x98,
-	0x66, 0x9d, 0x32, 0x3a, 0x90, 0x14, 0xbe, 0x06, 0x79, 0xde, 0x44, 0x79, 0x2d, 0xc1, 0xc7, 0x93,
-	0xbd, 0xd2, 0xa8, 0x91, 0x95, 0x13, 0xd3, 0x17, 0x43, 0x46, 0x57, 0x61, 0x31, 0xec, 0xc6, 0x2a,
-	0x68, 0x8f, 0x25, 0xa3, 0x7e, 0x8a, 0xbd, 0xe2, 0x99, 0x43, 0x52, 0xeb, 0x1f, 0x41, 0x5e, 0x0f,
-	0xbe, 0xf8, 0x03, 0x58, 0x88, 0x8f, 0x7d, 0xf8, 0xff, 0x0c, 0xf3, 0xc4, 0xa7, 0xe9, 0xca, 0xaa,
-	0xb1, 0x34, 0x7d, 0x56, 0x4c, 0xad, 0xa1, 0xf5, 0x8f, 0xf4, 0x7f, 0x6c, 0x36, 0xed, 0xc0, 0xc6,
-	0x77, 0x60, 0x41, 0x68, 0x1f, 0xfe, 0x4b, 0x27, 0x16, 0xa5, 0x07, 0xfe, 0x7f, 0x14, 0x8b, 0xd2,
-	0x83, 0xff, 0x47, 0x22, 0xa9, 0xe6, 0x87, 0x4f, 0x9f, 0x55, 0x53, 0x9f, 0x3f, 0xab, 0xa6, 0xbe,
-	0x78, 0x56, 0x45, 0x3f, 0xde, 0xab, 0xa2, 0x5f, 0xed, 0x55, 0xd1, 0x93, 0xbd, 0x2a, 0x7a, 0xba,
-	0x57, 0x45, 0x7f, 0xdf, 0xab, 0xa2, 0x7f, 0xee, 0x55, 0x53, 0x5f, 0xec, 0x55, 0xd1, 0x67, 0xcf,
-	0xab, 0xa9, 0xa7, 0xcf, 0xab, 0xa9, 0xcf, 0x9f, 0x57, 0x53, 0x1f, 0xbe, 0xfe, 0x65, 0x0f, 0x5a,
-	0xfa, 0xc4, 0x9d, 0x9c, 0xf8, 0x79, 0xeb, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x4e, 0x23, 0x5c,
-	0x1e, 0x70, 0x1b, 0x00, 0x00,
+	// 2250 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x19, 0x4b, 0x8f, 0x1b, 0x49,
+	0xd9, 0xe5, 0xb7, 0x3f, 0x7b, 0x1e, 0xa9, 0xf1, 0xce, 0x18, 0x6f, 0x62, 0x4f, 0x5a, 0x4b, 0x32,
+	0x4a, 0xb2, 0xf6, 0x66, 0x16, 0x96, 0x3c, 0x58, 0x50, 0x3c, 0xb3, 0xc9, 0x4e, 0x32, 0x79, 0xd5,
+	0x0c, 0x01, 0xad, 0x58, 0x45, 0x3d, 0x76, 0xd9, 0x63, 0x8d, 0xdb, 0xed, 0x74, 0x97, 0x93, 0x1d,
+	0x89, 0x03, 0xe2, 0xbe, 0xd2, 0xde, 0x10, 0x17, 0xc4, 0x01, 0x09, 0x84, 0xc4, 0x85, 0x1b, 0x17,
+	0xe0, 0xc0, 0x21, 0xdc, 0xc2, 0x6d, 0xc5, 0xc1, 0x90, 0x89, 0x90, 0xd0, 0x9c, 0xf6, 0x0f, 0x20,
+	0xa1, 0x7a, 0x75, 0x97, 0x7b, 0x3c, 0xcb, 0x3a, 0x44, 0x42, 0xb9, 0xb8, 0xeb, 0x7b, 0xd4, 0x57,
+	0xf5, 0x3d, 0xea, 0xfb, 0xea, 0x2b, 0xc3, 0x9b, 0x83, 0xbd, 0x4e, 0xbd, 0xe7, 0x76, 0x06, 0x9e,
+	0xcb, 0xdc, 0x60, 0x50, 0x13, 0xbf, 0x38, 0xab, 0xe1, 0x72, 0xb1, 0xe3, 0x76, 0x5c, 0xc9, 0xc3,
+	0x47, 0x92, 0x5e, 0xae, 0x76, 0x5c, 0xb7, 0xd3, 0xa3, 0x75, 0x01, 0xed, 0x0c, 0xdb, 0x75, 0xd6,
+	0x75, 0xa8, 0xcf, 0x6c, 0x67, 0xa0, 0x18, 0x96, 0x95, 0xf4, 0x47, 0x3d, 0xc7, 0x6d, 0xd1, 0x5e,
+	0xdd, 0x67, 0x36, 0xf3, 0xe5, 0xaf, 0xe2, 0x58, 0xe0, 0x1c, 0x83, 0xa1, 0xbf, 0x2b, 0x7e, 0x24,
+	0xd2, 0x2a, 0x02, 0xde, 0x62, 0x1e, 0xb5, 0x1d, 0x62, 0x33, 0xea, 0x13, 0xfa, 0x68, 0x48, 0x7d,
+	0x66, 0xdd, 0x86, 0x85, 0x31, 0xac, 0x3f, 0x70, 0xfb, 0x3e, 0xc5, 0xef, 0x41, 0xde, 0x0f, 0xd1,
+	0x25, 0xb4, 0x9c, 0x58, 0xc9, 0xaf, 0x16, 0x6b, 0x81, 0x2a, 0xe1, 0x1c, 0x62, 0x32, 0x5a, 0x3f,
+	0x47, 0x00, 0x21, 0x0d, 0x57, 0x00, 0x24, 0xf5, 0x43, 0xdb, 0xdf, 0x2d, 0xa1, 0x65, 0xb4, 0x92,
+	0x24, 0x06, 0x06, 0x5f, 0x80, 0x13, 0x21, 0x74, 0xc7, 0xdd, 0xda, 0xb5, 0xbd, 0x56, 0x29, 0x2e,
+	0xd8, 0x8e, 0x12, 0x30, 0x86, 0xa4, 0x67, 0x33, 0x5a, 0x4a, 0x2c, 0xa3, 0x95, 0x04, 0x11, 0x63,
+	0xbc, 0x08, 0x69, 0x46, 0xfb, 0x76, 0x9f, 0x95, 0x92, 0xcb, 0x68, 0x25, 0x47, 0x14, 0xc4, 0xf1,
+	0x5c, 0x77, 0xea, 0x97, 0x52, 0xcb, 0x68, 0x65, 0x86, 0x28, 0xc8, 0xfa, 0x49, 0x02, 0x0a, 0xf7,
+	0x87, 0xd4, 0xdb, 0x57, 0x06, 0xc0, 0x65, 0xc8, 0xfa, 0xb4, 0x47, 0x9b, 0xcc, 0xf5, 0xc4, 0x06,
+	0x73, 0x24, 0x80, 0x71, 0x11, 0x52, 0xbd, 0xae, 0xd3, 0x65, 0x62, 0x4b, 0x33, 0x44, 0x02, 0xf8,
+	0x0a, 0xa4, 0x7c, 0x66, 0x7b, 0x4c, 0xec, 0x23, 0xbf, 0x5a, 0xae, 0x49, 0x87, 0xd5, 0xb4, 0xc3,
+	0x6a, 0xdb, 0xda, 0x61, 0x8d, 0xec, 0xd3, 0x51, 0x35, 0xf6, 0xd9, 0xdf, 0xab, 0x88, 0xc8, 0x29,
+	0xf8, 0x3d, 0x48, 0xd0, 0x7e, 0xab, 0x94, 0x9c, 0x62, 0x26, 0x9f, 0x80, 0x2f, 0x42, 0xae, 0xd5,
+	0xf5, 0x68, 0x93, 0x75, 0xdd, 0xbe, 0xd0, 0x68, 0x76, 0x75, 0x21, 0xf4, 0xc6, 0xba, 0x26, 0x91,
+	0x90, 0x0b, 0x5f, 0x80, 0xb4, 0xcf, 0xcd, 0xe6, 0x97, 0x32, 0xcb, 0x89, 0x95, 0x5c, 0xa3, 0x78,
+	0x38, 0xaa, 0xce, 0x4b, 0xcc, 0x05, 0xd7, 0xe9, 0x32, 0xea, 0x0c, 0xd8, 0x3e, 0x51, 0x3c, 0xf8,
+	0x1c, 0x64, 0x5a, 0xb4, 0x47, 0xb9, 0xb3, 0xb3, 0xc2, 0xd9, 0xf3, 0x86, 0x78, 0x41, 0x20, 0x9a,
+	0x01, 0x5f, 0x86, 0x42, 0xcf, 0x75, 0xf7, 0x86, 0x83, 0x6d, 0x7b, 0xa7, 0x47, 0xfd, 0x52, 0x4e,
+	0x4c, 0x78, 0x23, 0x9c, 0xb0, 0x19, 0x52, 0xc9, 0x18, 0xeb, 0xcd, 0x64, 0x36, 0x3d, 0x9f, 0xb1,
+	0x7e, 0x1f, 0x07, 0xbc, 0x65, 0x3b, 0x83, 0x1e, 0xfd, 0xca, 0xae, 0x08, 0x8c, 0x1e, 0x7f, 0x69,
+	0xa3, 0x27, 0xa6, 0x35, 0x7a, 0x68, 0xc1, 0xe4, 0x74, 0x16, 0x4c, 0x4d, 0x6b, 0xc1, 0xf4, 0x57,
+	0xb6, 0xa0, 0xb5, 0x09, 0x69, 0x29, 0xed, 0xbf, 0x45, 0x6e, 0x68, 0xae, 0x84, 0x36, 0xc4, 0x7c,
+	0x68, 0x88, 0x84, 0x50, 0xd1, 0xba, 0x0a, 0x79, 0x63, 0x29, 0x7e, 0xc2, 0xfa, 0xb6, 0x43, 0x95,
+	0x38, 0x31, 0xc6, 0x25, 0xc8, 0x3c, 0xa6, 0x9e, 0xcf, 0x03, 0x2f, 0x2e, 0xd0, 0x1a, 0xb4, 0x7e,
+	0x81, 0x60, 0x46, 0x39, 0x50, 0xa5, 0x8d, 0x1d, 0xc8, 0xc8, 0x63, 0xab, 0x53, 0xc6, 0x52, 0x34,
+	0x65, 0x5c, 0x6b, 0xd9, 0x03, 0x46, 0xbd, 0x46, 0xfd, 0xe9, 0xa8, 0x8a, 0xfe, 0x36, 0xaa, 0x9e,
+	0xed, 0x74, 0xd9, 0xee, 0x70, 0xa7, 0xd6, 0x74, 0x9d, 0x7a, 0xc7, 0xb3, 0xdb, 0x76, 0xdf, 0xae,
+	0xf7, 0xdc, 0xbd, 0x6e, 0x5d, 0xa7, 0x30, 0x35, 0x8f, 0x68, 0xc1, 0xf8, 0xbc, 0x50, 0x8d, 0xf9,
+	0x2a, 0x12, 0xe6, 0x6a, 0x02, 0xaa, 0x6d, 0xf4, 0x3b, 0xd4, 0xe7, 0x92, 0x93, 0xdc, 0x89, 0x44,
+	0xf2, 0x58, 0x3f, 0x82, 0x85, 0xb1, 0x40, 0x53, 0xfb, 0xbc, 0x04, 0x69, 0x9f, 0x7a, 0xdd, 0x20,
+	0xb3, 0x19, 0xae, 0xda, 0x12, 0xf8, 0xc6, 0xac, 0xda, 0x5f, 0x5a, 0xc2, 0x44, 0xf1, 0x4f, 0xb7,
+	0xfa, 0x9f, 0x11, 0x14, 0x36, 0xed, 0x1d, 0xda, 0xd3, 0x11, 0x3e, 0xc9, 0xbe, 0x8b, 0x90, 0x7e,
+	0x6c, 0xf7, 0x86, 0x54, 0x8a, 0xcc, 0x12, 0x05, 0x4d, 0x9b, 0x66, 0xd0, 0x4b, 0xa7, 0x19, 0x14,
+	0x46, 0x7c, 0x11, 0x52, 0x8f, 0xb8, 0xa1, 0x44, 0x8a, 0xc9, 0x11, 0x09, 0x58, 0x67, 0x61, 0x46,
+	0x69, 0xa1, 0xcc, 0x17, 0x6e, 0x99, 0x9b, 0x2f, 0xa7, 0xb7, 0x6c, 0x39, 0x90, 0x96, 0xd6, 0xc6,
+	0x6f, 0x41, 0x2e, 0x28, 0x5b, 0x42, 0xdb, 0x44, 0x23, 0x7d, 0x38, 0xaa, 0xc6, 0x99, 0x4f, 0x42,
+	0x02, 0xae, 0x42, 0x4a, 0xcc, 0x14, 0x9a, 0xa3, 0x46, 0xee, 0x70, 0x54, 0x95, 0x08, 0x22, 0x3f,
+	0xf8, 0x24, 0x24, 0x77, 0x79, 0xe5, 0xe0, 0x26, 0x48, 0x36, 0xb2, 0x87, 0xa3, 0xaa, 0x80, 0x89,
+	0xf8, 0xb5, 0x6e, 0x40, 0x61, 0x93, 0x76, 0xec, 0xe6, 0xbe, 0x5a, 0xb4, 0xa8, 0xc5, 0xf1, 0x05,
+	0x91, 0x96, 0x71, 0x1a, 0x0a, 0xc1, 0x8a, 0x0f, 0x1d, 0x5f, 0x9d, 0x88, 0x7c, 0x80, 0xbb, 0xed,
+	0x5b, 0x3f, 0x43, 0xa0, 0xfc, 0x8c, 0x2d, 0x48, 0xf7, 0xb8, 0xae, 0xbe, 0xf4, 0x51, 0x03, 0x0e,
+	0x47, 0x55, 0x85, 0x21, 0xea, 0x8b, 0xaf, 0x42, 0xc6, 0x17, 0x2b, 0x72, 0x61, 0xd1, 0xf0, 0x11,
+	0x84, 0xc6, 0x1c, 0x0f, 0x83, 0xc3, 0x51, 0x55, 0x33, 0x12, 0x3d, 0xc0, 0xb5, 0xb1, 0x92, 0x28,
+	0x15, 0x9b, 0x3d, 0x1c, 0x55, 0x0d, 0xac, 0x59, 0x22, 0xad, 0x9f, 0x22, 0xc8, 0x6f, 0xdb, 0xdd,
+	0x20, 0x84, 0x02, 0x17, 0x21, 0xc3, 0x45, 0x3c, 0x17, 0xb4, 0x68, 0xcf, 0xde, 0xbf, 0xee, 0x7a,
+	0x42, 0xe6, 0x0c, 0x09, 0xe0, 0xb0, 0x8a, 0x25, 0x27, 0x56, 0xb1, 0xd4, 0xd4, 0x09, 0xf5, 0x66,
+	0x32, 0x1b, 0x9f, 0x4f, 0x58, 0xbf, 0x45, 0x50, 0x90, 0x3b, 0x53, 0x61, 0xf1, 0x43, 0x48, 0xcb,
+	0x8d, 0x8b, 0xbd, 0x7d, 0xc9, 0xe1, 0x3f, 0x3f, 0xcd, 0xc1, 0x57, 0x32, 0xf1, 0x77, 0x61, 0xb6,
+	0xe5, 0xb9, 0x83, 0x01, 0x6d, 0x6d, 0xa9, 0x14, 0x13, 0x8f, 0xa6, 0x98, 0x75, 0x93, 0x4e, 0x22,
+	0xec, 0xd6, 0x5f, 0x10, 0xcc, 0xa8, 0xd3, 0xac, 0x6c, 0x19, 0xd8, 0x00, 0xbd, 0x74, 0x51, 0x89,
+	0x4f, 0x5b, 0x54, 0x16, 0x21, 0xdd, 0xf1, 0xdc, 0xe1, 0xc0, 0x2f, 0x25, 0xe4, 0xd9, 0x91, 0xd0,
+	0x74, 0xc5, 0xc6, 0xba, 0x09, 0xb3, 0x5a, 0x95, 0x63, 0x52, 0x5a, 0x39, 0x9a, 0xd2, 0x36, 0x5a,
+	0xb4, 0xcf, 0xba, 0xed, 0x6e, 0x90, 0xa4, 0x14, 0xbf, 0xf5, 0x29, 0x82, 0xf9, 0x28, 0x0b, 0xfe,
+	0x8e, 0x71, 0x0e, 0xb8, 0xb8, 0x33, 0xc7, 0x8b, 0xab, 0x89, 0xe4, 0xe0, 0x7f, 0xd0, 0x67, 0xde,
+	0xbe, 0x3e, 0x23, 0xe5, 0xcb, 0x90, 0x37, 0xd0, 0xbc, 0xf2, 0xec, 0x51, 0x1d, 0xb3, 0x7c, 0x18,
+	0x1e, 0x56, 0x59, 0x54, 0x24, 0x70, 0x25, 0x7e, 0x09, 0xf1, 0x88, 0x9f, 0x19, 0xf3, 0x24, 0xbe,
+	0x04, 0xc9, 0xb6, 0xe7, 0x3a, 0x53, 0xb9, 0x49, 0xcc, 0xc0, 0xdf, 0x80, 0x38, 0x73, 0xa7, 0x72,
+	0x52, 0x9c, 0xb9, 0xdc, 0x47, 0x4a, 0xf9, 0x84, 0xbc, 0x54, 0x4a, 0xc8, 0xfa, 0x0d, 0x82, 0x39,
+	0x3e, 0x47, 0x5a, 0x60, 0x6d, 0x77, 0xd8, 0xdf, 0xc3, 0x2b, 0x30, 0xcf, 0x57, 0x7a, 0xd8, 0x55,
+	0x15, 0xe0, 0x61, 0xb7, 0xa5, 0xd4, 0x9c, 0xe5, 0x78, 0x5d, 0x18, 0x36, 0x5a, 0x78, 0x09, 0x32,
+	0x43, 0x5f, 0x32, 0x48, 0x9d, 0xd3, 0x1c, 0xdc, 0x68, 0xe1, 0xf3, 0xc6, 0x72, 0xdc, 0xd6, 0xc6,
+	0xcd, 0x4e, 0xd8, 0xf0, 0x9e, 0xdd, 0xf5, 0x82, 0xe4, 0x73, 0x16, 0xd2, 0x4d, 0xbe, 0xb0, 0x8c,
+	0x13, 0x5e, 0x81, 0x02, 0x66, 0xb1, 0x21, 0xa2, 0xc8, 0xd6, 0x37, 0x21, 0x17, 0xcc, 0x9e, 0x58,
+	0x78, 0x26, 0x7a, 0xc0, 0xba, 0x0a, 0x73, 0x32, 0xa9, 0x4e, 0x9e, 0x5c, 0x98, 0x34, 0xb9, 0xa0,
+	0x27, 0xbf, 0x09, 0x29, 0x69, 0x15, 0x0c, 0xc9, 0x96, 0xcd, 0x6c, 0x3d, 0x85, 0x8f, 0xad, 0x12,
+	0x2c, 0x6e, 0x7b, 0x76, 0xdf, 0x6f, 0x53, 0x4f, 0x30, 0x05, 0xb1, 0x6b, 0xbd, 0x01, 0x0b, 0x3c,
+	0x91, 0x50, 0xcf, 0x5f, 0x73, 0x87, 0x7d, 0xa6, 0x7b, 0x93, 0x0b, 0x50, 0x1c, 0x47, 0xab, 0x50,
+	0x2f, 0x42, 0xaa, 0xc9, 0x11, 0x42, 0xfa, 0x0c, 0x91, 0x80, 0xf5, 0x4b, 0x04, 0xf8, 0x06, 0x65,
+	0x42, 0xf4, 0xc6, 0xba, 0x6f, 0x5c, 0x2a, 0x1d, 0x9b, 0x35, 0x77, 0xa9, 0xe7, 0xeb, 0x5b, 0x92,
+	0x86, 0xff, 0x1f, 0x97, 0x4a, 0xeb, 0x22, 0x2c, 0x8c, 0xed, 0x52, 0xe9, 0x54, 0x86, 0x6c, 0x53,
+	0xe1, 0x54, 0x51, 0x0d, 0x60, 0xeb, 0x77, 0x71, 0xc8, 0x4a, 0xdf, 0xd2, 0x36, 0xbe, 0x08, 0xf9,
+	0x36, 0x8f, 0x35, 0x6f, 0xe0, 0x75, 0x95, 0x09, 0x92, 0x8d, 0xb9, 0xc3, 0x51, 0xd5, 0x44, 0x13,
+	0x13, 0xc0, 0x6f, 0x47, 0x02, 0xaf, 0x51, 0x3c, 0x18, 0x55, 0xd3, 0xdf, 0xe3, 0xc1, 0xb7, 0xce,
+	0xcb, 0x9b, 0x08, 0xc3, 0xf5, 0x20, 0x1c, 0x6f, 0xa9, 0xd3, 0x26, 0xae, 0x89, 0x8d, 0x6f, 0xf1,
+	0xed, 0x47, 0xf2, 0xf5, 0xc0, 0x73, 0x1d, 0xca, 0x76, 0xe9, 0xd0, 0xaf, 0x37, 0x5d, 0xc7, 0x71,
+	0xfb, 0x75, 0xd1, 0x89, 0x0a, 0xa5, 0x79, 0x8d, 0xe6, 0xd3, 0xd5, 0x01, 0xdc, 0x86, 0x0c, 0xdb,
+	0xf5, 0xdc, 0x61, 0x67, 0x57, 0x94, 0x9f, 0x44, 0xe3, 0xca, 0xf4, 0xf2, 0xb4, 0x04, 0xa2, 0x07,
+	0xf8, 0x34, 0xb7, 0x16, 0x6d, 0xee, 0xf9, 0x43, 0x47, 0xf6, 0x77, 0x8d, 0xd4, 0xe1, 0xa8, 0x8a,
+	0xde, 0x26, 0x01, 0xda, 0xfa, 0x34, 0x0e, 0x55, 0x11, 0xc2, 0x0f, 0xc4, 0xdd, 0xe4, 0xba, 0xeb,
+	0xdd, 0xa6, 0xcc, 0xeb, 0x36, 0xef, 0xd8, 0x0e, 0xd5, 0xb1, 0x51, 0x85, 0xbc, 0x23, 0x90, 0x0f,
+	0x8d, 0xc3, 0x01, 0x4e, 0xc0, 0x87, 0x4f, 0x01, 0x88, 0x63, 0x27, 0xe9, 0xf2, 0x9c, 0xe4, 0x04,
+	0x46, 0x90, 0xd7, 0xc6, 0x2c, 0x55, 0x9f, 0x52, 0x33, 0x65, 0xa1, 0x8d, 0xa8, 0x85, 0xa6, 0x96,
+	0x13, 0x98, 0xc5, 0x8c, 0xf5, 0xd4, 0x78, 0xac, 0x5b, 0x7f, 0x45, 0x50, 0xd9, 0xd4, 0x3b, 0x7f,
+	0x49, 0x73, 0x68, 0x7d, 0xe3, 0xaf, 0x48, 0xdf, 0xc4, 0xff, 0xa6, 0xaf, 0xf5, 0x27, 0xe3, 0xc8,
+	0x13, 0xda, 0xd6, 0x7a, 0xac, 0x19, 0xe5, 0xe2, 0x55, 0x6c, 0x33, 0xfe, 0x0a, 0xdd, 0x92, 0x88,
+	0xb8, 0xe5, 0x7d, 0x58, 0x18, 0xd3, 0x40, 0xa5, 0x83, 0x33, 0x90, 0xf4, 0x68, 0x5b, 0x17, 0x5f,
+	0x1c, 0xcd, 0xf1, 0xb4, 0x4d, 0x04, 0xdd, 0xfa, 0x03, 0x82, 0xf9, 0x1b, 0x94, 0x8d, 0x5f, 0x6b,
+	0x5e, 0x27, 0xfd, 0x3f, 0x84, 0x13, 0xc6, 0xfe, 0x95, 0xf6, 0xef, 0x46, 0xee, 0x32, 0x46, 0x63,
+	0xbc, 0xd1, 0x6f, 0xd1, 0x4f, 0x54, 0x8f, 0x36, 0x7e, 0x8d, 0xb9, 0x07, 0x79, 0x83, 0x88, 0xaf,
+	0x45, 0x2e, 0x30, 0x93, 0x8a, 0x6a, 0xa3, 0xa8, 0x74, 0x92, 0x5d, 0x9a, 0xba, 0x9e, 0x06, 0xe5,
+	0x7e, 0x0b, 0xb0, 0x68, 0x1b, 0x85, 0x58, 0x33, 0x53, 0x0b, 0xec, 0xad, 0xe0, 0x3e, 0x13, 0xc0,
+	0xf8, 0x34, 0x24, 0x3d, 0xf7, 0x89, 0xbe, 0x99, 0xce, 0x84, 0x4b, 0x12, 0xf7, 0x09, 0x11, 0x24,
+	0xeb, 0x2a, 0x24, 0x88, 0xfb, 0x84, 0xbf, 0x8c, 0x79, 0x76, 0xbf, 0x43, 0x1f, 0x04, 0x0d, 0x4b,
+	0x81, 0x18, 0x98, 0x63, 0xea, 0xeb, 0x1a, 0x9c, 0x30, 0x77, 0x24, 0xdd, 0x5d, 0x83, 0xcc, 0xfd,
+	0xa1, 0x69, 0xae, 0x62, 0xc4, 0x5c, 0x62, 0x0a, 0xd1, 0x4c, 0x3c, 0x66, 0x20, 0xc4, 0xe3, 0x93,
+	0x90, 0x63, 0xbc, 0xf9, 0xbf, 0x13, 0x9e, 0xf9, 0x10, 0xc1, 0xa9, 0xbc, 0xd7, 0x7a, 0x60, 0x5c,
+	0x14, 0x42, 0x04, 0x3e, 0x07, 0xf3, 0xe1, 0x9e, 0xef, 0x79, 0xb4, 0xdd, 0xfd, 0x44, 0x78, 0xb8,
+	0x40, 0x8e, 0xe0, 0xf1, 0x0a, 0xcc, 0x85, 0xb8, 0x2d, 0x51, 0x76, 0x93, 0x82, 0x35, 0x8a, 0xe6,
+	0xb6, 0x11, 0xea, 0x7e, 0xf0, 0x68, 0x68, 0xf7, 0x44, 0x22, 0x2b, 0x10, 0x03, 0x63, 0xfd, 0x11,
+	0xc1, 0x09, 0xe9, 0x6a, 0x66, 0xb3, 0xd7, 0x32, 0xea, 0x7f, 0x85, 0x00, 0x9b, 0x1a, 0xa8, 0xd0,
+	0xfa, 0xba, 0xf9, 0x7c, 0xc2, 0xeb, 0x7a, 0x5e, 0xb4, 0x90, 0x12, 0x15, 0xbe, 0x80, 0x58, 0xc1,
+	0x15, 0x50, 0x3c, 0x95, 0xca, 0x1e, 0x55, 0x62, 0xf4, 0xed, 0x8f, 0xb7, 0xd6, 0x3b, 0xfb, 0x8c,
+	0xfa, 0xaa, 0xc3, 0x14, 0xad, 0xb5, 0x40, 0x10, 0xf9, 0xe1, 0x6b, 0xd1, 0x3e, 0x13, 0x51, 0x93,
+	0x0c, 0xd7, 0x52, 0x28, 0xa2, 0x07, 0xd6, 0x3f, 0x11, 0xcc, 0x3c, 0x70, 0x7b, 0x43, 0x87, 0xbe,
+	0x86, 0x76, 0x1e, 0x6f, 0x7d, 0x53, 0xba, 0xf5, 0xc5, 0x90, 0xf4, 0x19, 0x1d, 0x88, 0xc8, 0x4a,
+	0x10, 0x31, 0xb6, 0x7e, 0x00, 0xb3, 0x5a, 0x4d, 0xe5, 0x8c, 0x77, 0x20, 0xf3, 0x58, 0x60, 0x26,
+	0x3c, 0x12, 0x49, 0x56, 0x95, 0x80, 0x34, 0xdb, 0xf8, 0x73, 0xb1, 0x5e, 0xcd, 0xba, 0x09, 0x69,
+	0xc9, 0xce, 0x5f, 0x33, 0xc2, 0xc2, 0x2a, 0x5f, 0x33, 0x38, 0xac, 0x6e, 0xd4, 0x16, 0xa4, 0xa5,
+	0xa0, 0x52, 0x22, 0xf4, 0xaa, 0xc4, 0x10, 0xf5, 0x3d, 0x77, 0x06, 0x72, 0xc1, 0x5b, 0x2f, 0xce,
+	0x43, 0xe6, 0xfa, 0x5d, 0xf2, 0xfd, 0x6b, 0x64, 0x7d, 0x3e, 0x86, 0x0b, 0x90, 0x6d, 0x5c, 0x5b,
+	0xbb, 0x25, 0x20, 0xb4, 0xfa, 0xef, 0xa4, 0xce, 0x09, 0x1e, 0xfe, 0x36, 0xa4, 0xe4, 0x41, 0x5f,
+	0x0c, 0xf7, 0x6f, 0x3e, 0xbb, 0x96, 0x97, 0x8e, 0xe0, 0xd5, 0xb5, 0x3c, 0xf6, 0x0e, 0xc2, 0x77,
+	0x20, 0x2f, 0x90, 0xea, 0x89, 0xe5, 0x64, 0xf4, 0xa5, 0x63, 0x4c, 0xd2, 0xa9, 0x63, 0xa8, 0x86,
+	0xbc, 0x2b, 0x90, 0x12, 0xb9, 0xd6, 0xdc, 0x8d, 0xf9, 0x44, 0x56, 0x5e, 0x3a, 0x82, 0xd7, 0xb3,
+	0xf1, 0x65, 0x48, 0xf2, 0x7e, 0x00, 0x1b, 0xe5, 0xc0, 0x78, 0x19, 0x29, 0x2f, 0x46, 0xd1, 0xc6,
+	0xb2, 0xef, 0x07, 0x0f, 0x3c, 0x4b, 0xd1, 0x46, 0x56, 0x4f, 0x2f, 0x1d, 0x25, 0x04, 0x2b, 0xdf,
+	0x85, 0x82, 0xd9, 0x89, 0xe0, 0x53, 0xe3, 0x4b, 0x45, 0x1a, 0x97, 0x72, 0xe5, 0x38, 0x72, 0x20,
+	0x70, 0x13, 0xf2, 0x46, 0x17, 0x60, 0x9a, 0xf5, 0x68, 0x0b, 0x53, 0x3e, 0x75, 0x0c, 0x35, 0x90,
+	0x76, 0x03, 0xb2, 0xbc, 0x88, 0xf2, 0x5c, 0x82, 0xdf, 0x8c, 0xd6, 0x4a, 0x23, 0x47, 0x96, 0x4f,
+	0x4e, 0x26, 0x06, 0x82, 0xae, 0xc3, 0x5c, 0x50, 0x8d, 0x55, 0xd0, 0x2e, 0x45, 0xa3, 0x7e, 0x82,
+	0xbd, 0xc6, 0x4f, 0x8e, 0x15, 0x5b, 0xfd, 0x18, 0xb2, 0xba, 0xf1, 0xc5, 0xf7, 0x61, 0x76, 0xbc,
+	0xed, 0xc3, 0x5f, 0x33, 0xcc, 0x33, 0xde, 0x4d, 0x97, 0x97, 0x0d, 0xd2, 0xe4, 0x5e, 0x31, 0xb6,
+	0x82, 0x56, 0x3f, 0xd6, 0x7f, 0x32, 0xad, 0xdb, 0xcc, 0xc6, 0x77, 0x61, 0x56, 0x68, 0x1f, 0xfc,
+	0x0b, 0x35, 0x16, 0xa5, 0x47, 0xfe, 0xf2, 0x2a, 0x9f, 0x3a, 0x86, 0xaa, 0x17, 0x68, 0x7c, 0xf4,
+	0xec, 0x79, 0x25, 0xf6, 0xf9, 0xf3, 0x4a, 0xec, 0x8b, 0xe7, 0x15, 0xf4, 0xe3, 0x83, 0x0a, 0xfa,
+	0xf5, 0x41, 0x05, 0x3d, 0x3d, 0xa8, 0xa0, 0x67, 0x07, 0x15, 0xf4, 0x8f, 0x83, 0x0a, 0xfa, 0xd7,
+	0x41, 0x25, 0xf6, 0xc5, 0x41, 0x05, 0x7d, 0xf6, 0xa2, 0x12, 0x7b, 0xf6, 0xa2, 0x12, 0xfb, 0xfc,
+	0x45, 0x25, 0xf6, 0xd1, 0x5b, 0x5f, 0xf6, 0xa0, 0xa5, 0x57, 0xdc, 0x49, 0x8b, 0xcf, 0xbb, 0xff,
+	0x19, 0x00, 0x76, 0xe2, 0xcc, 0x8a, 0x23, 0x1c, 0x00, 0x00,
 }
 
 func (x Direction) String() string {
@@ -2786,6 +2859,14 @@ func (this *QueryRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.LookupTables) != len(that1.LookupTables) {
+		return false
+	}
+	for i := range this.LookupTables {
+		if !this.LookupTables[i].Equal(that1.LookupTables[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *SampleQueryRequest) Equal(that interface{}) bool {
@@ -2832,6 +2913,14 @@ func (this *SampleQueryRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.LookupTables) != len(that1.LookupTables) {
+		return false
+	}
+	for i := range this.LookupTables {
+		if !this.LookupTables[i].Equal(that1.LookupTables[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *Delete) Equal(that interface{}) bool {
@@ -2864,6 +2953,33 @@ func (this *Delete) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *LookupTable) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LookupTable)
+	if !ok {
+		that2, ok := that.(LookupTable)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Name != that1.Name {
+		return false
+	}
+	if this.Version != that1.Version {
+		return false
+	}
+	return true
+}
 func (this *QueryResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -4109,7 +4225,7 @@ func (this *QueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 12)
 	s = append(s, "&logproto.QueryRequest{")
 	s = append(s, "Selector: "+fmt.Sprintf("%#v", this.Selector)+",\n")
 	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
@@ -4120,6 +4236,9 @@ func (this *QueryRequest) GoString() string {
 	if this.Deletes != nil {
 		s = append(s, "Deletes: "+fmt.Sprintf("%#v", this.Deletes)+",\n")
 	}
+	if this.LookupTables != nil {
+		s = append(s, "LookupTables: "+fmt.Sprintf("%#v", this.LookupTables)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -4127,7 +4246,7 @@ func (this *SampleQueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&logproto.SampleQueryRequest{")
 	s = append(s, "Selector: "+fmt.Sprintf("%#v", this.Selector)+",\n")
 	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
@@ -4136,6 +4255,9 @@ func (this *SampleQueryRequest) GoString() string {
 	if this.Deletes != nil {
 		s = append(s, "Deletes: "+fmt.Sprintf("%#v", this.Deletes)+",\n")
 	}
+	if this.LookupTables != nil {
+		s = append(s, "LookupTables: "+fmt.Sprintf("%#v", this.LookupTables)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -4151,6 +4273,17 @@ func (this *Delete) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *LookupTable) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&logproto.LookupTable{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Version: "+fmt.Sprintf("%#v", this.Version)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *QueryResponse) GoString() string {
 	if this == nil {
 		return "nil"
@@ -4228,9 +4361,9 @@ func (this *Series) GoString() string {
 	s = append(s, "&logproto.Series{")
 	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	if this.Samples != nil {
-		vs := make([]*Sample, len(this.Samples))
+		vs := make([]Sample, len(this.Samples))
 		for i := range vs {
-			vs[i] = &this.Samples[i]
+			vs[i] = this.Samples[i]
 		}
 		s = append(s, "Samples: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
@@ -4284,9 +4417,9 @@ func (this *SeriesResponse) GoString() string {
 	s := make([]string, 0, 5)
 	s = append(s, "&logproto.SeriesResponse{")
 	if this.Series != nil {
-		vs := make([]*SeriesIdentifier, len(this.Series))
+		vs := make([]SeriesIdentifier, len(this.Series))
 		for i := range vs {
-			vs[i] = &this.Series[i]
+			vs[i] = this.Series[i]
 		}
 		s = append(s, "Series: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
@@ -4509,9 +4642,9 @@ func (this *GetSeriesResponse) GoString() string {
 	s := make([]string, 0, 5)
 	s = append(s, "&logproto.GetSeriesResponse{")
 	if this.Series != nil {
-		vs := make([]*IndexSeries, len(this.Series))
+		vs := make([]IndexSeries, len(this.Series))
 		for i := range vs {
-			vs[i] = &this.Series[i]
+			vs[i] = this.Series[i]
 		}
 		s = append(s, "Series: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
@@ -4624,9 +4757,9 @@ func (this *VolumeResponse) GoString() string {
 	s := make([]string, 0, 6)
 	s = append(s, "&logproto.VolumeResponse{")
 	if this.Volumes != nil {
-		vs := make([]*Volume, len(this.Volumes))
+		vs := make([]Volume, len(this.Volumes))
 		for i := range vs {
-			vs[i] = &this.Volumes[i]
+			vs[i] = this.Volumes[i]
 		}
 		s = append(s, "Volumes: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
@@ -5420,6 +5553,20 @@ func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.LookupTables) > 0 {
+		for iNdEx := len(m.LookupTables) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LookupTables[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintLogproto(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x4a
+		}
+	}
 	if len(m.Deletes) > 0 {
 		for iNdEx := len(m.Deletes) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -5499,6 +5646,20 @@ func (m *SampleQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.LookupTables) > 0 {
+		for iNdEx := len(m.LookupTables) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LookupTables[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintLogproto(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
 	if len(m.Deletes) > 0 {
 		for iNdEx := len(m.Deletes) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -5588,6 +5749,43 @@ func (m *Delete) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *LookupTable) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LookupTable) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LookupTable) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Version) > 0 {
+		i -= len(m.Version)
+		copy(dAtA[i:], m.Version)
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Version)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *QueryResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -7316,6 +7514,12 @@ func (m *QueryRequest) Size() (n int) {
 			n += 1 + l + sovLogproto(uint64(l))
 		}
 	}
+	if len(m.LookupTables) > 0 {
+		for _, e := range m.LookupTables {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -7345,6 +7549,12 @@ func (m *SampleQueryRequest) Size() (n int) {
 			n += 1 + l + sovLogproto(uint64(l))
 		}
 	}
+	if len(m.LookupTables) > 0 {
+		for _, e := range m.LookupTables {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -7367,6 +7577,23 @@ func (m *Delete) Size() (n int) {
 	return n
 }
 
+func (m *LookupTable) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	return n
+}
+
 func (m *QueryResponse) Size() (n int) {
 	if m == nil {
 		return 0
@@ -8137,14 +8364,20 @@ func (this *QueryRequest) String() string {
 		repeatedStringForDeletes += strings.Replace(f.String(), "Delete", "Delete", 1) + ","
 	}
 	repeatedStringForDeletes += "}"
+	repeatedStringForLookupTables := "[]*LookupTable{"
+	for _, f := range this.LookupTables {
+		repeatedStringForLookupTables += strings.Replace(f.String(), "LookupTable", "LookupTable", 1) + ","
+	}
+	repeatedStringForLookupTables += "}"
 	s := strings.Join([]string{`&QueryRequest{`,
 		`Selector:` + fmt.Sprintf("%v", this.Selector) + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Direction:` + fmt.Sprintf("%v", this.Direction) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`Deletes:` + repeatedStringForDeletes + `,`,
+		`LookupTables:` + repeatedStringForLookupTables + `,`,
 		`}`,
 	}, "")
 	return s
@@ -8158,12 +8391,18 @@ func (this *SampleQueryRequest) String() string {
 		repeatedStringForDeletes += strings.Replace(f.String(), "Delete", "Delete", 1) + ","
 	}
 	repeatedStringForDeletes += "}"
+	repeatedStringForLookupTables := "[]*LookupTable{"
+	for _, f := range this.LookupTables {
+		repeatedStringForLookupTables += strings.Replace(f.String(), "LookupTable", "LookupTable", 1) + ","
+	}
+	repeatedStringForLookupTables += "}"
 	s := strings.Join([]string{`&SampleQueryRequest{`,
 		`Selector:` + fmt.Sprintf("%v", this.Selector) + `,`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`Deletes:` + repeatedStringForDeletes + `,`,
+		`LookupTables:` + repeatedStringForLookupTables + `,`,
 		`}`,
 	}, "")
 	return s
@@ -8180,6 +8419,17 @@ func (this *Delete) String() string {
 	}, "")
 	return s
 }
+func (this *LookupTable) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LookupTable{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Version:` + fmt.Sprintf("%v", this.Version) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *QueryResponse) String() string {
 	if this == nil {
 		return "nil"
@@ -8209,8 +8459,8 @@ func (this *LabelRequest) String() string {
 	s := strings.Join([]string{`&LabelRequest{`,
 		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
 		`Values:` + fmt.Sprintf("%v", this.Values) + `,`,
-		`Start:` + strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1) + `,`,
-		`End:` + strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1) + `,`,
+		`Start:` + strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1) + `,`,
+		`End:` + strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "timestamppb.Timestamp", 1) + `,`,
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
 		`}`,
 	}, "")
@@ -8274,7 +8524,7 @@ func (this *TailRequest) String() string {
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
 		`DelayFor:` + fmt.Sprintf("%v", this.DelayFor) + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -8300,8 +8550,8 @@ func (this *SeriesRequest) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&SeriesRequest{`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Groups:` + fmt.Sprintf("%v", this.Groups) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`}`,
@@ -8348,8 +8598,8 @@ func (this *DroppedStream) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&DroppedStream{`,
-		`From:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.From), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`To:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.To), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`From:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.From), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`To:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.To), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
 		`}`,
 	}, "")
@@ -8444,8 +8694,8 @@ func (this *GetChunkIDsRequest) String() string {
 	}
 	s := strings.Join([]string{`&GetChunkIDsRequest{`,
 		`Matchers:` + fmt.Sprintf("%v", this.Matchers) + `,`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -9226,6 +9476,40 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LookupTables", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LookupTables = append(m.LookupTables, &LookupTable{})
+			if err := m.LookupTables[len(m.LookupTables)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLogproto(dAtA[iNdEx:])
@@ -9443,6 +9727,40 @@ func (m *SampleQueryRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LookupTables", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LookupTables = append(m.LookupTables, &LookupTable{})
+			if err := m.LookupTables[len(m.LookupTables)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLogproto(dAtA[iNdEx:])
@@ -9590,6 +9908,123 @@ func (m *Delete) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *LookupTable) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LookupTable: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LookupTable: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Version = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,148 @@
+package logql
+
+import (
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+const (
+	// ContextLabel is the label of the log lines returned around the log lines of a log query by its context stage.
+	// Its value is ContextBefore or ContextAfter.
+	ContextLabel  = "__context__"
+	ContextBefore = "before"
+	ContextAfter  = "after"
+)
+
+// ContextLines returns the log lines around the log lines of a log query with a context stage, from the same streams.
+// The log lines are read from iterators over all the log lines of the streams, and the pipeline of the query is applied
+// by ContextLines. The log lines around are returned as they are read, with the ContextLabel label.
+type ContextLines struct {
+	pipeline log.Pipeline
+	// lead and trail are the numbers of log lines around in the direction of the query.
+	lead, trail           int
+	leadLabel, trailLabel string
+
+	streams map[uint64]*contextStream
+}
+
+// NewContextLines returns the ContextLines of the log query, nil if it has no context stage.
+func NewContextLines(expr syntax.LogSelectorExpr, direction logproto.Direction) (*ContextLines, error) {
+	context := syntax.ContextStage(expr)
+	if context == nil {
+		return nil, nil
+	}
+	// the context stage is a noop stage of the pipeline.
+	pipeline, err := expr.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	c := &ContextLines{
+		pipeline:   pipeline,
+		lead:       context.Before,
+		trail:      context.After,
+		leadLabel:  ContextBefore,
+		trailLabel: ContextAfter,
+		streams:    map[uint64]*contextStream{},
+	}
+	if direction == logproto.BACKWARD {
+		c.lead, c.trail = c.trail, c.lead
+		c.leadLabel, c.trailLabel = c.trailLabel, c.leadLabel
+	}
+	return c, nil
+}
+
+// Iterator returns an iterator over the log lines of the stream matching the pipeline and the log lines around them.
+// it must iterate over all the log lines of the stream. The log lines around a match can be returned by the following
+// iterators of the same stream, e.g. the iterators over the next chunks of the stream.
+func (c *ContextLines) Iterator(it iter.EntryIterator, stream labels.Labels) iter.EntryIterator {
+	hash := stream.Hash()
+	s, ok := c.streams[hash]
+	if !ok {
+		s = &contextStream{
+			pipeline:    c.pipeline.ForStream(stream),
+			leadLabels:  newContextLabels(stream, c.leadLabel),
+			trailLabels: newContextLabels(stream, c.trailLabel),
+		}
+		c.streams[hash] = s
+	}
+	return &contextIterator{EntryIterator: it, lines: c, stream: s}
+}
+
+type contextStream struct {
+	pipeline                log.StreamPipeline
+	leadLabels, trailLabels log.LabelsResult
+
+	// lead are the last log lines not returned, at most ContextLines.lead.
+	lead []logproto.Entry
+	// remaining is the number of log lines to return after the last match.
+	remaining int
+}
+
+func newContextLabels(stream labels.Labels, value string) log.LabelsResult {
+	lbs := labels.NewBuilder(stream).Set(ContextLabel, value).Labels()
+	return log.NewLabelsResult(lbs, lbs.Hash())
+}
+
+type contextIterator struct {
+	iter.EntryIterator
+	lines  *ContextLines
+	stream *contextStream
+
+	// next are the log lines to return, the lead log lines followed by their match.
+	next   []logproto.Entry
+	labels []log.LabelsResult
+
+	cur       logproto.Entry
+	curLabels log.LabelsResult
+}
+
+func (i *contextIterator) Next() bool {
+	if len(i.next) > 0 {
+		i.pop()
+		return true
+	}
+	s := i.stream
+	for i.EntryIterator.Next() {
+		entry := i.EntryIterator.Entry()
+		line, lbs, ok := s.pipeline.ProcessString(entry.Timestamp.UnixNano(), entry.Line, logproto.FromStructuredMetadataToLabels(entry.StructuredMetadata)...)
+		switch {
+		case ok:
+			for _, lead := range s.lead {
+				i.next = append(i.next, lead)
+				i.labels = append(i.labels, s.leadLabels)
+			}
+			i.next = append(i.next, logproto.Entry{Timestamp: entry.Timestamp, Line: line, StructuredMetadata: entry.StructuredMetadata})
+			i.labels = append(i.labels, lbs)
+			s.lead = s.lead[:0]
+			s.remaining = i.lines.trail
+			i.pop()
+			return true
+		case s.remaining > 0:
+			s.remaining--
+			i.cur, i.curLabels = entry, s.trailLabels
+			return true
+		case i.lines.lead > 0:
+			if len(s.lead) == i.lines.lead {
+				copy(s.lead, s.lead[1:])
+				s.lead = s.lead[:len(s.lead)-1]
+			}
+			s.lead = append(s.lead, entry)
+		}
+	}
+	return false
+}
+
+func (i *contextIterator) pop() {
+	i.cur, i.curLabels = i.next[0], i.labels[0]
+	i.next, i.labels = i.next[1:], i.labels[1:]
+}
+
+func (i *contextIterator) Entry() logproto.Entry { return i.cur }
+
+func (i *contextIterator) Labels() string { return i.curLabels.String() }
+
+func (i *contextIterator) StreamHash() uint64 { return i.curLabels.Hash() }
@@ -0,0 +1,111 @@
+package logql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+func TestContextLines(t *testing.T) {
+	lines := []string{"a", "b", "error 1", "c", "d", "e", "f", "error 2", "g", "error 3", "h", "i"}
+	stream := `{app="foo"}`
+
+	for _, tc := range []struct {
+		name      string
+		query     string
+		direction logproto.Direction
+		// split is the index of the log line starting the second iterator of the stream.
+		split    int
+		expected []string
+	}{
+		{
+			name:      "forward",
+			query:     `{app="foo"} |= "error" | context before=2 after=1`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{__context__="before", app="foo"} a`,
+				`{__context__="before", app="foo"} b`,
+				`{app="foo"} error 1`,
+				`{__context__="after", app="foo"} c`,
+				`{__context__="before", app="foo"} e`,
+				`{__context__="before", app="foo"} f`,
+				`{app="foo"} error 2`,
+				`{__context__="after", app="foo"} g`,
+				`{app="foo"} error 3`,
+				`{__context__="after", app="foo"} h`,
+			},
+		},
+		{
+			name:      "forward across iterators",
+			query:     `{app="foo"} |= "error" | context before=2 after=1`,
+			direction: logproto.FORWARD,
+			split:     6,
+			expected: []string{
+				`{__context__="before", app="foo"} a`,
+				`{__context__="before", app="foo"} b`,
+				`{app="foo"} error 1`,
+				`{__context__="after", app="foo"} c`,
+				`{__context__="before", app="foo"} e`,
+				`{__context__="before", app="foo"} f`,
+				`{app="foo"} error 2`,
+				`{__context__="after", app="foo"} g`,
+				`{app="foo"} error 3`,
+				`{__context__="after", app="foo"} h`,
+			},
+		},
+		{
+			name:      "backward",
+			query:     `{app="foo"} |= "error" | context before=1 after=2`,
+			direction: logproto.BACKWARD,
+			expected: []string{
+				`{__context__="after", app="foo"} i`,
+				`{__context__="after", app="foo"} h`,
+				`{app="foo"} error 3`,
+				`{__context__="before", app="foo"} g`,
+				`{app="foo"} error 2`,
+				`{__context__="before", app="foo"} f`,
+				`{__context__="after", app="foo"} d`,
+				`{__context__="after", app="foo"} c`,
+				`{app="foo"} error 1`,
+				`{__context__="before", app="foo"} b`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := syntax.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			contextLines, err := NewContextLines(expr, tc.direction)
+			require.NoError(t, err)
+			require.NotNil(t, contextLines)
+
+			entries := make([]logproto.Entry, 0, len(lines))
+			for i, line := range lines {
+				entries = append(entries, logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: line})
+			}
+			if tc.direction == logproto.BACKWARD {
+				for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+					entries[i], entries[j] = entries[j], entries[i]
+				}
+			}
+			batches := [][]logproto.Entry{entries}
+			if tc.split > 0 {
+				batches = [][]logproto.Entry{entries[:tc.split], entries[tc.split:]}
+			}
+
+			var actual []string
+			for _, batch := range batches {
+				it := contextLines.Iterator(iter.NewStreamIterator(logproto.Stream{Labels: stream, Entries: batch}), mustParseLabels(stream))
+				for it.Next() {
+					actual = append(actual, it.Labels()+" "+it.Entry().Line)
+				}
+				require.NoError(t, it.Close())
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -233,6 +233,13 @@ func (ev DownstreamEvaluator) Downstream(ctx context.Context, queries []Downstre
 		}
 	}
 
+	for _, res := range results {
+		if err := metadata.AddWarnings(ctx, res.Warnings...); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "unable to add warnings to results context", "error", err)
+			break
+		}
+	}
+
 	return results, nil
 }
 
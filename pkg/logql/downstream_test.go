@@ -2,7 +2,9 @@ package logql
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -51,6 +53,15 @@ func TestMappingEquivalence(t *testing.T) {
 		{`max(count(rate({a=~".+"}[1s])))`, false},
 		{`max(sum by (cluster) (rate({a=~".+"}[1s]))) / count(rate({a=~".+"}[1s]))`, false},
 		{`sum(rate({a=~".+"} |= "foo" != "foo"[1s]) or vector(1))`, false},
+		{`max_over_time(sum by (a) (count_over_time({a=~".+"}[1s]))[5s:1s])`, false},
+		{`sum(count_over_time(count_over_time({a=~".+"}[2s])[3s:]))`, false},
+		{`histogram_over_time({a=~".+"} | unwrap b [1s])`, false},
+		{`histogram_over_time({a=~".+"} | unwrap b [1s]) by (a)`, false},
+		{`histogram_quantile(0.9, sum by (le) (histogram_over_time({a=~".+"} | unwrap b [1s])))`, false},
+		{`count_distinct_over_time({a=~".+"} | unwrap b [1s])`, false},
+		{`count_distinct_over_time({a=~".+"} | logfmt | unwrap line [1s]) by (a)`, false},
+		{`approx_count_distinct(hll_over_time({a=~".+"} | unwrap b [1s]))`, false},
+		{`hll_over_time({a=~".+"} | unwrap b [1s]) by (a)`, false},
 		// topk prefers already-seen values in tiebreakers. Since the test data generates
 		// the same log lines for each series & the resulting promql.Vectors aren't deterministically
 		// sorted by labels, we don't expect this to pass.
@@ -80,7 +91,7 @@ func TestMappingEquivalence(t *testing.T) {
 			qry := regular.Query(params)
 			ctx := user.InjectOrgID(context.Background(), "fake")
 
-			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics)
+			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics, false)
 			_, _, mapped, err := mapper.Parse(tc.query)
 			require.Nil(t, err)
 
@@ -101,6 +112,81 @@ func TestMappingEquivalence(t *testing.T) {
 	}
 }
 
+func TestApproximateQuantileEquivalence(t *testing.T) {
+	var (
+		shards   = 3
+		nStreams = 60
+		rounds   = 100
+		streams  = randomStreams(nStreams, rounds+1, shards, []string{"a", "b", "c", "d"})
+		start    = time.Unix(0, int64(30*time.Second))
+		end      = time.Unix(0, int64(time.Second*time.Duration(rounds)))
+		step     = 10 * time.Second
+		interval = time.Duration(0)
+		limit    = 100
+	)
+	// The latencies are uniformly distributed, so the exact quantiles are close to the values of the samples.
+	rnd := rand.New(rand.NewSource(42))
+	for i := range streams {
+		for j := range streams[i].Entries {
+			streams[i].Entries[j].Line = fmt.Sprintf("latency=%f", 100+900*rnd.Float64())
+		}
+	}
+
+	for _, query := range []string{
+		`quantile_over_time(0.5, {a=~".+"} | logfmt | unwrap latency [30s]) by (a)`,
+		`quantile_over_time(0.9, {a=~".+"} | logfmt | unwrap latency [30s]) by (a, b)`,
+		`quantile_over_time(0.99, {a=~".+"} | logfmt | unwrap latency [30s]) by (a)`,
+		`max by (a) (quantile_over_time(0.1, {a=~".+"} | logfmt | unwrap latency [30s]) by (a, b))`,
+	} {
+		q := NewMockQuerier(
+			shards,
+			streams,
+		)
+
+		opts := EngineOpts{}
+		regular := NewEngine(opts, q, NoLimits, log.NewNopLogger())
+		sharded := NewDownstreamEngine(opts, MockDownstreamer{regular}, NoLimits, log.NewNopLogger())
+
+		t.Run(query, func(t *testing.T) {
+			params := NewLiteralParams(
+				query,
+				start,
+				end,
+				step,
+				interval,
+				logproto.FORWARD,
+				uint32(limit),
+				nil,
+			)
+			ctx := user.InjectOrgID(context.Background(), "fake")
+
+			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics, true)
+			noop, _, mapped, err := mapper.Parse(query)
+			require.Nil(t, err)
+			require.False(t, noop)
+
+			res, err := regular.Query(params).Exec(ctx)
+			require.Nil(t, err)
+
+			shardedRes, err := sharded.Query(ctx, params, mapped).Exec(ctx)
+			require.Nil(t, err)
+
+			// The approximated quantiles are within the width of the buckets from the exact quantiles, which are
+			// interpolated between the values of the samples.
+			exact, approximated := res.Data.(promql.Matrix), shardedRes.Data.(promql.Matrix)
+			require.Equal(t, len(exact), len(approximated))
+			for i := range exact {
+				require.Equal(t, exact[i].Metric, approximated[i].Metric)
+				require.Equal(t, len(exact[i].Floats), len(approximated[i].Floats))
+				for j := range exact[i].Floats {
+					require.Equal(t, exact[i].Floats[j].T, approximated[i].Floats[j].T)
+					require.InEpsilon(t, exact[i].Floats[j].F, approximated[i].Floats[j].F, 0.1)
+				}
+			}
+		})
+	}
+}
+
 func TestShardCounter(t *testing.T) {
 	var (
 		shards   = 3
@@ -145,7 +231,7 @@ func TestShardCounter(t *testing.T) {
 			)
 			ctx := user.InjectOrgID(context.Background(), "fake")
 
-			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics)
+			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics, false)
 			noop, _, mapped, err := mapper.Parse(tc.query)
 			require.Nil(t, err)
 
@@ -375,6 +461,23 @@ func TestRangeMappingEquivalence(t *testing.T) {
 		{`rate({a=~".+"}[5s] offset 0s)`, 2 * time.Second},
 		{`rate({a=~".+"}[3s] offset -1s)`, 2 * time.Second},
 
+		// subqueries
+		{`max_over_time(sum by (a) (count_over_time({a=~".+"}[1s]))[4s:1s])`, time.Second},
+		{`sum_over_time(count_over_time({a=~".+"}[1s])[5s:])`, 2 * time.Second},
+		{`count_over_time(count_over_time({a=~".+"}[1s])[3s:1s] offset 1s)`, 2 * time.Second},
+		{`sum by (a) (sum_over_time(count_over_time({a=~".+"}[1s])[3s:1s]))`, time.Second},
+		{`max by (a) (min_over_time(count_over_time({a=~".+"}[2s])[4s:1s]))`, time.Second},
+
+		// histograms
+		{`histogram_over_time({a=~".+"} | unwrap b [2s])`, time.Second},
+		{`histogram_over_time({a=~".+"} | unwrap b [2s]) by (a)`, time.Second},
+		{`histogram_quantile(0.9, sum by (le) (histogram_over_time({a=~".+"} | unwrap b [3s])))`, time.Second},
+
+		// distinct counts
+		{`count_distinct_over_time({a=~".+"} | unwrap b [2s])`, time.Second},
+		{`count_distinct_over_time({a=~".+"} | logfmt | unwrap line [3s]) by (a)`, time.Second},
+		{`approx_count_distinct by (a) (hll_over_time({a=~".+"} | unwrap b [2s]))`, time.Second},
+
 		// label_replace
 		{`label_replace(sum by (a) (count_over_time({a=~".+"}[3s])), "", "", "", "")`, time.Second},
 		{`label_replace(sum by (a) (count_over_time({a=~".+"}[3s])), "foo", "$1", "a", "(.*)")`, time.Second},
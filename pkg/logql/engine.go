@@ -27,10 +27,12 @@ import (
 
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
+	logqllog "github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/hll"
 	"github.com/grafana/loki/pkg/util/httpreq"
 	logutil "github.com/grafana/loki/pkg/util/log"
 	"github.com/grafana/loki/pkg/util/spanlogger"
@@ -69,6 +71,8 @@ type QueryParams interface {
 // SelectParams specifies parameters passed to data selections.
 type SelectLogParams struct {
 	*logproto.QueryRequest
+	// ResolvedLookupTables are the lookup tables referenced by the request, by name, see LookupTables.
+	ResolvedLookupTables map[string]*logqllog.LookupTable
 }
 
 func (s SelectLogParams) String() string {
@@ -82,29 +86,60 @@ func (s SelectLogParams) String() string {
 // LogSelector returns the LogSelectorExpr from the SelectParams.
 // The `LogSelectorExpr` can then returns all matchers and filters to use for that request.
 func (s SelectLogParams) LogSelector() (syntax.LogSelectorExpr, error) {
-	return syntax.ParseLogSelector(s.Selector, true)
+	expr, err := syntax.ParseLogSelector(s.Selector, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := setLookupTables(expr, s.LookupTables, s.ResolvedLookupTables); err != nil {
+		return nil, err
+	}
+	return expr, nil
 }
 
 type SelectSampleParams struct {
 	*logproto.SampleQueryRequest
+	// ResolvedLookupTables are the lookup tables referenced by the request, by name, see LookupTables.
+	ResolvedLookupTables map[string]*logqllog.LookupTable
 }
 
 // Expr returns the SampleExpr from the SelectSampleParams.
 // The `LogSelectorExpr` can then returns all matchers and filters to use for that request.
 func (s SelectSampleParams) Expr() (syntax.SampleExpr, error) {
-	return syntax.ParseSampleExpr(s.Selector)
+	expr, err := syntax.ParseSampleExpr(s.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := setLookupTables(expr, s.LookupTables, s.ResolvedLookupTables); err != nil {
+		return nil, err
+	}
+	return expr, nil
 }
 
 // LogSelector returns the LogSelectorExpr from the SelectParams.
 // The `LogSelectorExpr` can then returns all matchers and filters to use for that request.
 func (s SelectSampleParams) LogSelector() (syntax.LogSelectorExpr, error) {
-	expr, err := syntax.ParseSampleExpr(s.Selector)
+	expr, err := s.Expr()
 	if err != nil {
 		return nil, err
 	}
 	return expr.Selector()
 }
 
+// setLookupTables sets the lookup tables referenced by the request to the lookup stages of the expression.
+// The tables are resolved by LookupTables before the request is executed.
+func setLookupTables(expr syntax.Expr, refs []*logproto.LookupTable, tables map[string]*logqllog.LookupTable) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	for _, ref := range refs {
+		if _, ok := tables[ref.Name]; !ok {
+			return fmt.Errorf("lookup table %s: version %s is not loaded", ref.Name, ref.Version)
+		}
+	}
+	syntax.SetLookupTables(expr, tables)
+	return nil
+}
+
 // Querier allows a LogQL expression to fetch an EntryIterator for a
 // set of matchers and filters
 type Querier interface {
@@ -271,6 +306,7 @@ func (q *query) Exec(ctx context.Context) (logqlmodel.Result, error) {
 		Data:       data,
 		Statistics: statResult,
 		Headers:    metadataCtx.Headers(),
+		Warnings:   metadataCtx.Warnings(),
 	}, err
 }
 
@@ -301,8 +337,16 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 			return nil, err
 		}
 
+		ordering := newLogOrdering(e, q.params.Direction())
+		if ordering != nil {
+			iter = ordering.iterator(iter, q.params.Limit())
+		}
+
 		defer util.LogErrorWithContext(ctx, "closing iterator", iter.Close)
 		streams, err := readStreams(iter, q.params.Limit(), q.params.Direction(), q.params.Interval())
+		if err == nil && ordering != nil {
+			ordering.sortStreams(streams)
+		}
 		return streams, err
 	default:
 		return nil, errors.New("Unexpected type (%T): cannot evaluate")
@@ -358,6 +402,10 @@ func (q *query) evalSample(ctx context.Context, expr syntax.SampleExpr) (promql_
 
 	maxSeriesCapture := func(id string) int { return q.limits.MaxQuerySeries(ctx, id) }
 	maxSeries := validation.SmallestPositiveIntPerTenant(tenantIDs, maxSeriesCapture)
+	if returnsSketches(expr) && maxSeries < math.MaxInt/hll.Registers {
+		// the limit applies to the sketches, whose registers are returned as series.
+		maxSeries *= hll.Registers
+	}
 	seriesIndex := map[uint64]*promql.Series{}
 
 	next, ts, vec := stepEvaluator.Next()
@@ -436,6 +484,11 @@ func (q *query) checkIntervalLimit(expr syntax.SampleExpr, limit time.Duration)
 				return
 			}
 			err = fmt.Errorf("%w: [%s] > [%s]", logqlmodel.ErrIntervalLimit, model.Duration(e.Left.Interval), model.Duration(limit))
+		case *syntax.SubqueryExpr:
+			if e.Range <= limit {
+				return
+			}
+			err = fmt.Errorf("%w: [%s] > [%s]", logqlmodel.ErrIntervalLimit, model.Duration(e.Range), model.Duration(limit))
 		}
 	})
 	return err
@@ -191,12 +191,13 @@ func (ev *DefaultEvaluator) StepEvaluator(
 ) (StepEvaluator, error) {
 	switch e := expr.(type) {
 	case *syntax.VectorAggregationExpr:
-		if rangExpr, ok := e.Left.(*syntax.RangeAggregationExpr); ok && e.Operation == syntax.OpTypeSum {
+		if rangExpr, ok := e.Left.(*syntax.RangeAggregationExpr); ok && (e.Operation == syntax.OpTypeSum ||
+			e.Operation == syntax.OpTypeApproxCountDistinct && rangExpr.Operation == syntax.OpRangeTypeHLL) {
 			// if range expression is wrapped with a vector expression
 			// we should send the vector expression for allowing reducing labels at the source.
 			nextEv = SampleEvaluatorFunc(func(ctx context.Context, _ SampleEvaluator, _ syntax.SampleExpr, _ Params) (StepEvaluator, error) {
 				it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
-					&logproto.SampleQueryRequest{
+					SampleQueryRequest: &logproto.SampleQueryRequest{
 						Start:    q.Start().Add(-rangExpr.Left.Interval).Add(-rangExpr.Left.Offset),
 						End:      q.End().Add(-rangExpr.Left.Offset),
 						Selector: e.String(), // intentionally send the vector for reducing labels.
@@ -209,10 +210,13 @@ func (ev *DefaultEvaluator) StepEvaluator(
 				return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), rangExpr, q, rangExpr.Left.Offset)
 			})
 		}
+		if e.Operation == syntax.OpTypeApproxCountDistinct {
+			return approxCountDistinctEvaluator(ctx, nextEv, e, q)
+		}
 		return vectorAggEvaluator(ctx, nextEv, e, q)
 	case *syntax.RangeAggregationExpr:
 		it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
-			&logproto.SampleQueryRequest{
+			SampleQueryRequest: &logproto.SampleQueryRequest{
 				Start:    q.Start().Add(-e.Left.Interval).Add(-e.Left.Offset),
 				End:      q.End().Add(-e.Left.Offset),
 				Selector: expr.String(),
@@ -227,6 +231,10 @@ func (ev *DefaultEvaluator) StepEvaluator(
 		return binOpStepEvaluator(ctx, nextEv, e, q)
 	case *syntax.LabelReplaceExpr:
 		return labelReplaceEvaluator(ctx, nextEv, e, q)
+	case *syntax.SubqueryExpr:
+		return subqueryEvaluator(ctx, nextEv, e, q)
+	case *syntax.HistogramQuantileExpr:
+		return histogramQuantileEvaluator(ctx, nextEv, e, q)
 	case *syntax.VectorExpr:
 		val, err := e.Value()
 		if err != nil {
@@ -1023,6 +1031,79 @@ func labelReplaceEvaluator(
 	}, nextEvaluator.Close, nextEvaluator.Error)
 }
 
+// subqueryEvaluator evaluates the inner query of the subquery at every step of the subquery over the range of the
+// query extended by the range of the subquery, then applies the range aggregation to the resulting samples.
+func subqueryEvaluator(
+	ctx context.Context,
+	ev SampleEvaluator,
+	expr *syntax.SubqueryExpr,
+	q Params,
+) (StepEvaluator, error) {
+	step := subqueryStep(expr, q)
+	// The steps of the inner query are aligned to its resolution, so consecutive queries share the same samples.
+	start := q.Start().Add(-expr.Range).Add(-expr.Offset).UnixNano()
+	alignedStart := start - start%step.Nanoseconds()
+	if alignedStart < start {
+		alignedStart += step.Nanoseconds()
+	}
+	innerParams := NewLiteralParams(
+		expr.Left.String(),
+		time.Unix(0, alignedStart),
+		q.End().Add(-expr.Offset),
+		step,
+		q.Interval(),
+		q.Direction(),
+		q.Limit(),
+		q.Shards(),
+	)
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.Left, innerParams)
+	if err != nil {
+		return nil, err
+	}
+	defer nextEvaluator.Close()
+
+	series := map[string]*logproto.Series{}
+	for next, ts, vec := nextEvaluator.Next(); next; next, ts, vec = nextEvaluator.Next() {
+		for _, s := range vec {
+			lbs := s.Metric.String()
+			ss, ok := series[lbs]
+			if !ok {
+				ss = &logproto.Series{Labels: lbs, StreamHash: s.Metric.Hash()}
+				series[lbs] = ss
+			}
+			ss.Samples = append(ss.Samples, logproto.Sample{
+				Timestamp: ts * int64(time.Millisecond),
+				Value:     s.F,
+			})
+		}
+	}
+	if err := nextEvaluator.Error(); err != nil {
+		return nil, err
+	}
+
+	all := make([]logproto.Series, 0, len(series))
+	for _, s := range series {
+		all = append(all, *s)
+	}
+	it := iter.NewPeekingSampleIterator(iter.NewMultiSeriesIterator(all))
+	return rangeAggEvaluator(it, expr.RangeAggregation(), q, expr.Offset)
+}
+
+// defaultSubqueryStep is the resolution of the subqueries without resolution within the instant queries.
+const defaultSubqueryStep = time.Minute
+
+// subqueryStep returns the resolution of the subquery, defaulting to the step of the query,
+// or to defaultSubqueryStep for the instant queries.
+func subqueryStep(expr *syntax.SubqueryExpr, q Params) time.Duration {
+	if expr.Step != 0 {
+		return expr.Step
+	}
+	if q.Step() != 0 {
+		return q.Step()
+	}
+	return defaultSubqueryStep
+}
+
 // This is to replace missing timeseries during absent_over_time aggregation.
 func absentLabels(expr syntax.SampleExpr) (labels.Labels, error) {
 	m := labels.Labels{}
@@ -0,0 +1,175 @@
+package logql
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/astmapper"
+)
+
+// Types of the nodes of a query plan.
+const (
+	PlanSplitByInterval   = "split_by_interval"
+	PlanSplitByRange      = "split_by_range"
+	PlanSplit             = "split"
+	PlanConcat            = "concat"
+	PlanDownstream        = "downstream"
+	PlanAggregation       = "vector_aggregation"
+	PlanBinaryOperation   = "binary_operation"
+	PlanLabelReplace      = "label_replace"
+	PlanSubquery          = "subquery"
+	PlanHistogramQuantile = "histogram_quantile"
+	PlanLiteral           = "literal"
+	PlanVector            = "vector"
+	PlanExpr              = "expr"
+)
+
+// PlanNode is a node of the plan of a query as it is executed by the query frontend.
+// The downstream nodes are the queries executed by the queriers, all the other nodes are executed by the frontend.
+type PlanNode struct {
+	Type string `json:"type"`
+	// Operation is the operation of the aggregations and binary operations, with their grouping.
+	Operation string `json:"operation,omitempty"`
+	// Query is the query of the downstream nodes.
+	Query string `json:"query,omitempty"`
+	// Shard is the shard of the downstream nodes, if they are sharded.
+	Shard string `json:"shard,omitempty"`
+	// Interval is the split interval of the split_by_interval and split_by_range nodes.
+	Interval model.Duration `json:"interval,omitempty"`
+	// Start and End are the time range of the split nodes.
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+	// Stats are the execution statistics of the node when the query is analyzed.
+	// The statistics of a node are the sum of the ones of its children.
+	Stats    *stats.Result `json:"stats,omitempty"`
+	Children []*PlanNode   `json:"children,omitempty"`
+}
+
+// Leaves returns the downstream nodes of the plan, from left to right.
+func (n *PlanNode) Leaves() []*PlanNode {
+	if n.Type == PlanDownstream {
+		return []*PlanNode{n}
+	}
+	var leaves []*PlanNode
+	for _, c := range n.Children {
+		leaves = append(leaves, c.Leaves()...)
+	}
+	return leaves
+}
+
+// MergeStats sets the statistics of the nodes without statistics to the sum of the ones of their children.
+func (n *PlanNode) MergeStats() *stats.Result {
+	if len(n.Children) == 0 || n.Stats != nil {
+		return n.Stats
+	}
+	var merged *stats.Result
+	for _, c := range n.Children {
+		s := c.MergeStats()
+		if s == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &stats.Result{}
+		}
+		merged.Merge(*s)
+	}
+	n.Stats = merged
+	return merged
+}
+
+// NewPlan returns the plan of an expression mapped by the ShardMapper or the RangeMapper.
+// An expression which is not mapped is planned as a single downstream query.
+func NewPlan(expr syntax.Expr) *PlanNode {
+	switch e := expr.(type) {
+	case DownstreamSampleExpr:
+		return downstreamNode(e.SampleExpr, e.shard)
+	case DownstreamLogSelectorExpr:
+		return downstreamNode(e.LogSelectorExpr, e.shard)
+	case *ConcatSampleExpr:
+		node := &PlanNode{Type: PlanConcat}
+		for cur := e; cur != nil; cur = cur.next {
+			node.Children = append(node.Children, NewPlan(cur.DownstreamSampleExpr))
+		}
+		return node
+	case *ConcatLogSelectorExpr:
+		node := &PlanNode{Type: PlanConcat}
+		for cur := e; cur != nil; cur = cur.next {
+			node.Children = append(node.Children, NewPlan(cur.DownstreamLogSelectorExpr))
+		}
+		return node
+	}
+
+	if !isMapped(expr) {
+		return downstreamNode(expr, nil)
+	}
+
+	switch e := expr.(type) {
+	case *syntax.VectorAggregationExpr:
+		op := e.Operation
+		if e.Params != 0 || e.Operation == syntax.OpTypeTopK || e.Operation == syntax.OpTypeBottomK {
+			op = fmt.Sprintf("%s(%d)", op, e.Params)
+		}
+		if e.Grouping != nil {
+			op += e.Grouping.String()
+		}
+		return &PlanNode{Type: PlanAggregation, Operation: op, Children: []*PlanNode{NewPlan(e.Left)}}
+	case *syntax.BinOpExpr:
+		return &PlanNode{Type: PlanBinaryOperation, Operation: e.Op, Children: []*PlanNode{NewPlan(e.SampleExpr), NewPlan(e.RHS)}}
+	case *syntax.LabelReplaceExpr:
+		return &PlanNode{Type: PlanLabelReplace, Operation: fmt.Sprintf("%q, %q, %q, %q", e.Dst, e.Replacement, e.Src, e.Regex), Children: []*PlanNode{NewPlan(e.Left)}}
+	case *syntax.HistogramQuantileExpr:
+		return &PlanNode{Type: PlanHistogramQuantile, Operation: strconv.FormatFloat(e.Quantile, 'f', -1, 64), Children: []*PlanNode{NewPlan(e.Left)}}
+	case *syntax.SubqueryExpr:
+		return &PlanNode{Type: PlanSubquery, Operation: subqueryOperation(e), Children: []*PlanNode{NewPlan(e.Left)}}
+	default:
+		return &PlanNode{Type: PlanExpr, Query: expr.String()}
+	}
+}
+
+// subqueryOperation returns the range aggregation of a subquery with its range, e.g: max_over_time [1h:1m].
+func subqueryOperation(e *syntax.SubqueryExpr) string {
+	op := e.Operation
+	if e.Params != nil {
+		op = fmt.Sprintf("%s(%s)", op, strconv.FormatFloat(*e.Params, 'f', -1, 64))
+	}
+	op += fmt.Sprintf(" [%s:", model.Duration(e.Range))
+	if e.Step != 0 {
+		op += model.Duration(e.Step).String()
+	}
+	op += "]"
+	if e.Offset != 0 {
+		op += " offset " + model.Duration(e.Offset).String()
+	}
+	return op
+}
+
+func downstreamNode(expr syntax.Expr, shard *astmapper.ShardAnnotation) *PlanNode {
+	switch e := expr.(type) {
+	case *syntax.LiteralExpr:
+		return &PlanNode{Type: PlanLiteral, Query: e.String()}
+	case *syntax.VectorExpr:
+		return &PlanNode{Type: PlanVector, Query: e.String()}
+	}
+	node := &PlanNode{Type: PlanDownstream, Query: expr.String()}
+	if shard != nil {
+		node.Shard = shard.String()
+	}
+	return node
+}
+
+// isMapped returns true if the expression contains downstream expressions.
+func isMapped(expr syntax.Expr) bool {
+	mapped := false
+	expr.Walk(func(e interface{}) {
+		switch e.(type) {
+		case DownstreamSampleExpr, DownstreamLogSelectorExpr, *ConcatSampleExpr, *ConcatLogSelectorExpr, ConcatSampleExpr:
+			mapped = true
+		}
+	})
+	return mapped
+}
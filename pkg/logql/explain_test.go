@@ -0,0 +1,114 @@
+package logql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+func TestNewPlan(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected *PlanNode
+	}{
+		{
+			query:    `quantile_over_time(0.99, {app="foo"} | unwrap bar [1m])`,
+			expected: &PlanNode{Type: PlanDownstream, Query: `quantile_over_time(0.99,{app="foo"} | unwrap bar[1m])`},
+		},
+		{
+			query: `{app="foo"} |= "bar"`,
+			expected: &PlanNode{Type: PlanConcat, Children: []*PlanNode{
+				{Type: PlanDownstream, Query: `{app="foo"} |= "bar"`, Shard: "0_of_2"},
+				{Type: PlanDownstream, Query: `{app="foo"} |= "bar"`, Shard: "1_of_2"},
+			}},
+		},
+		{
+			query: `sum by (app) (rate({app="foo"}[1m])) / 2`,
+			expected: &PlanNode{Type: PlanBinaryOperation, Operation: "/", Children: []*PlanNode{
+				{Type: PlanAggregation, Operation: "sum by (app)", Children: []*PlanNode{
+					{Type: PlanConcat, Children: []*PlanNode{
+						{Type: PlanDownstream, Query: `sum by (app)(rate({app="foo"}[1m]))`, Shard: "0_of_2"},
+						{Type: PlanDownstream, Query: `sum by (app)(rate({app="foo"}[1m]))`, Shard: "1_of_2"},
+					}},
+				}},
+				{Type: PlanLiteral, Query: "2"},
+			}},
+		},
+		{
+			query: `topk(3, count_over_time({app="foo"}[1m]))`,
+			expected: &PlanNode{Type: PlanAggregation, Operation: "topk(3)", Children: []*PlanNode{
+				{Type: PlanConcat, Children: []*PlanNode{
+					{Type: PlanDownstream, Query: `count_over_time({app="foo"}[1m])`, Shard: "0_of_2"},
+					{Type: PlanDownstream, Query: `count_over_time({app="foo"}[1m])`, Shard: "1_of_2"},
+				}},
+			}},
+		},
+		{
+			query: `histogram_quantile(0.99, histogram_over_time({app="foo"} | unwrap latency [1m]))`,
+			expected: &PlanNode{Type: PlanHistogramQuantile, Operation: "0.99", Children: []*PlanNode{
+				{Type: PlanConcat, Children: []*PlanNode{
+					{Type: PlanDownstream, Query: `histogram_over_time({app="foo"} | unwrap latency[1m])`, Shard: "0_of_2"},
+					{Type: PlanDownstream, Query: `histogram_over_time({app="foo"} | unwrap latency[1m])`, Shard: "1_of_2"},
+				}},
+			}},
+		},
+		{
+			query: `quantile_over_time(0.99, count_over_time({app="foo"}[1m])[1h:1m] offset 1h)`,
+			expected: &PlanNode{Type: PlanSubquery, Operation: "quantile_over_time(0.99) [1h:1m] offset 1h", Children: []*PlanNode{
+				{Type: PlanConcat, Children: []*PlanNode{
+					{Type: PlanDownstream, Query: `count_over_time({app="foo"}[1m])`, Shard: "0_of_2"},
+					{Type: PlanDownstream, Query: `count_over_time({app="foo"}[1m])`, Shard: "1_of_2"},
+				}},
+			}},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			_, _, mapped, err := NewShardMapper(ConstantShards(2), nilShardMetrics, false).Parse(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, NewPlan(mapped))
+		})
+	}
+}
+
+func TestNewPlan_RangeMapped(t *testing.T) {
+	mapper, err := NewRangeMapper(time.Minute, nilShardMetrics, NewMapperStats())
+	require.NoError(t, err)
+	_, mapped, err := mapper.Parse(`max_over_time({app="foo"} | unwrap bar [2m])`)
+	require.NoError(t, err)
+
+	require.Equal(t, &PlanNode{Type: PlanAggregation, Operation: "max without ()", Children: []*PlanNode{
+		{Type: PlanConcat, Children: []*PlanNode{
+			{Type: PlanDownstream, Query: `max_over_time({app="foo"} | unwrap bar[1m] offset 1m0s)`},
+			{Type: PlanDownstream, Query: `max_over_time({app="foo"} | unwrap bar[1m])`},
+		}},
+	}}, NewPlan(mapped))
+}
+
+func TestPlanNode_Stats(t *testing.T) {
+	leaf := func(bytes int64) *PlanNode {
+		return &PlanNode{Type: PlanDownstream, Stats: &stats.Result{
+			Querier: stats.Querier{Store: stats.Store{Chunk: stats.Chunk{DecompressedBytes: bytes, DecompressedLines: 1}}},
+			Summary: stats.Summary{ExecTime: 1},
+		}}
+	}
+	plan := &PlanNode{Type: PlanBinaryOperation, Children: []*PlanNode{
+		{Type: PlanConcat, Children: []*PlanNode{leaf(10), leaf(20)}},
+		{Type: PlanLiteral},
+		leaf(30),
+	}}
+
+	leaves := plan.Leaves()
+	require.Len(t, leaves, 3)
+	require.Equal(t, plan.Children[2], leaves[2])
+
+	merged := plan.MergeStats()
+	require.Equal(t, merged, plan.Stats)
+	require.Equal(t, int64(60), plan.Stats.Summary.TotalBytesProcessed)
+	require.Equal(t, int64(3), plan.Stats.Summary.TotalLinesProcessed)
+	require.Equal(t, float64(3), plan.Stats.Summary.ExecTime)
+	require.Equal(t, int64(30), plan.Children[0].Stats.Summary.TotalBytesProcessed)
+	require.Nil(t, plan.Children[1].Stats)
+}
@@ -0,0 +1,261 @@
+package logql
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+const (
+	// HistogramBucketLabel is the label holding the upper bound of the bucket of the series produced by histogram_over_time.
+	HistogramBucketLabel = "le"
+
+	// histogramSchema is the resolution of the exponential buckets of the histograms, same as the schema of the
+	// Prometheus native histograms. Each power of 2 is divided into 2^histogramSchema buckets, so the quantiles are
+	// within about 9% of the values.
+	histogramSchema = 3
+)
+
+// histogramBase is the ratio between the upper and the lower bounds of a bucket.
+var histogramBase = math.Exp2(math.Exp2(-histogramSchema))
+
+// histogramBucket returns the upper bound of the exponential bucket of the value.
+// The positive values are in the buckets (base^(i-1), base^i], the negative values in the buckets [-base^i, -base^(i-1))
+// and the zeros in their own bucket. The infinite values are in the buckets +Inf and -Inf, the NaN values in +Inf.
+// The buckets are sparse: only the buckets of the values are produced, so any value can be counted without
+// configuring the buckets beforehand, and the buckets produced by different queries can always be merged.
+func histogramBucket(v float64) float64 {
+	switch {
+	case math.IsNaN(v):
+		return math.Inf(1)
+	case math.IsInf(v, 0) || v == 0:
+		return v
+	case v > 0:
+		return histogramBound(bucketIndex(v))
+	default:
+		return -histogramBound(bucketIndex(-v) - 1)
+	}
+}
+
+// bucketIndex returns the index i of the bucket (base^(i-1), base^i] of the positive value.
+func bucketIndex(v float64) int {
+	i := int(math.Ceil(math.Log2(v) * (1 << histogramSchema)))
+	// corrects the rounding errors of the logarithm of the bounds.
+	switch {
+	case histogramBound(i-1) >= v:
+		i--
+	case histogramBound(i) < v:
+		i++
+	}
+	return i
+}
+
+func histogramBound(i int) float64 {
+	return math.Exp2(float64(i) / (1 << histogramSchema))
+}
+
+// histogramLowerBound returns the lower bound of the bucket with the upper bound le.
+func histogramLowerBound(le float64) float64 {
+	switch {
+	case math.IsInf(le, 0) || le == 0:
+		return le
+	case le > 0:
+		return le / histogramBase
+	default:
+		return le * histogramBase
+	}
+}
+
+// histogramGrouping returns the grouping merging the buckets of histogram_over_time grouped by g, which keeps the
+// bucket label.
+func histogramGrouping(g *syntax.Grouping) *syntax.Grouping {
+	return groupingWithLabel(g, HistogramBucketLabel)
+}
+
+// groupingWithLabel returns the grouping g keeping the label name.
+func groupingWithLabel(g *syntax.Grouping, name string) *syntax.Grouping {
+	groups := make([]string, 0, len(g.Groups)+1)
+	for _, group := range g.Groups {
+		if group != name {
+			groups = append(groups, group)
+		}
+	}
+	if !g.Without {
+		groups = append(groups, name)
+	}
+	return &syntax.Grouping{Groups: groups, Without: g.Without}
+}
+
+// histogramBucketIterator turns the samples of an unwrapped range aggregation into the samples of the buckets of
+// their values, counted by histogram_over_time.
+type histogramBucketIterator struct {
+	iter.PeekingSampleIterator
+
+	// series caches the labels of the bucket series, by labels of the samples and upper bound.
+	series map[string]map[float64]string
+}
+
+func newHistogramBucketIterator(it iter.PeekingSampleIterator) iter.PeekingSampleIterator {
+	return &histogramBucketIterator{
+		PeekingSampleIterator: it,
+		series:                map[string]map[float64]string{},
+	}
+}
+
+func (it *histogramBucketIterator) Labels() string {
+	return it.bucketLabels(it.PeekingSampleIterator.Labels(), it.PeekingSampleIterator.Sample().Value)
+}
+
+func (it *histogramBucketIterator) Sample() logproto.Sample {
+	return bucketSample(it.PeekingSampleIterator.Sample())
+}
+
+func (it *histogramBucketIterator) Peek() (string, logproto.Sample, bool) {
+	lbs, sample, ok := it.PeekingSampleIterator.Peek()
+	if !ok {
+		return lbs, sample, ok
+	}
+	return it.bucketLabels(lbs, sample.Value), bucketSample(sample), true
+}
+
+func (it *histogramBucketIterator) bucketLabels(lbs string, v float64) string {
+	le := histogramBucket(v)
+	buckets, ok := it.series[lbs]
+	if !ok {
+		buckets = map[float64]string{}
+		it.series[lbs] = buckets
+	}
+	if series, ok := buckets[le]; ok {
+		return series
+	}
+	series := lbs
+	if metric, err := promql_parser.ParseMetric(lbs); err == nil {
+		series = labels.NewBuilder(metric).Set(HistogramBucketLabel, formatBucket(le)).Labels().String()
+	}
+	buckets[le] = series
+	return series
+}
+
+func bucketSample(s logproto.Sample) logproto.Sample {
+	s.Value = 1
+	return s
+}
+
+func formatBucket(le float64) string {
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
+
+// histogramQuantileEvaluator calculates the quantile of the histogram of each series, from the counts of its buckets.
+func histogramQuantileEvaluator(
+	ctx context.Context,
+	ev SampleEvaluator,
+	expr *syntax.HistogramQuantileExpr,
+	q Params,
+) (StepEvaluator, error) {
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.Left, q)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 1024)
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		next, ts, vec := nextEvaluator.Next()
+		if !next {
+			return false, 0, promql.Vector{}
+		}
+		histograms := map[uint64]*histogram{}
+		var hash uint64
+		for _, s := range vec {
+			le, err := strconv.ParseFloat(s.Metric.Get(HistogramBucketLabel), 64)
+			if err != nil {
+				// the series is not a bucket.
+				continue
+			}
+			hash, buf = s.Metric.HashWithoutLabels(buf, HistogramBucketLabel)
+			h, ok := histograms[hash]
+			if !ok {
+				h = &histogram{metric: labels.NewBuilder(s.Metric).Del(HistogramBucketLabel).Labels()}
+				histograms[hash] = h
+			}
+			h.buckets = append(h.buckets, bucket{upperBound: le, count: s.F})
+		}
+		result := make(promql.Vector, 0, len(histograms))
+		for _, h := range histograms {
+			result = append(result, promql.Sample{
+				T:      ts,
+				F:      h.quantile(expr.Quantile),
+				Metric: h.metric,
+			})
+		}
+		return next, ts, result
+	}, nextEvaluator.Close, nextEvaluator.Error)
+}
+
+type bucket struct {
+	upperBound float64
+	count      float64
+}
+
+type histogram struct {
+	metric  labels.Labels
+	buckets []bucket
+}
+
+// quantile calculates the quantile q of the histogram, interpolated linearly within the bucket of the quantile.
+// If q<0, -Inf is returned. If q>1, +Inf is returned. If the histogram is empty, NaN is returned.
+// If the quantile is in the +Inf bucket, the upper bound of the highest finite bucket is returned.
+func (h *histogram) quantile(q float64) float64 {
+	switch {
+	case math.IsNaN(q):
+		return math.NaN()
+	case q < 0:
+		return math.Inf(-1)
+	case q > 1:
+		return math.Inf(1)
+	}
+	sort.Slice(h.buckets, func(i, j int) bool { return h.buckets[i].upperBound < h.buckets[j].upperBound })
+	// merges the counts of the same buckets.
+	buckets := h.buckets[:0]
+	var total float64
+	for _, b := range h.buckets {
+		total += b.count
+		if len(buckets) > 0 && buckets[len(buckets)-1].upperBound == b.upperBound {
+			buckets[len(buckets)-1].count += b.count
+			continue
+		}
+		buckets = append(buckets, b)
+	}
+	h.buckets = buckets
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * total
+	var count float64
+	for i, b := range h.buckets {
+		if b.count <= 0 || count+b.count < rank {
+			count += b.count
+			continue
+		}
+		if math.IsInf(b.upperBound, -1) {
+			return b.upperBound
+		}
+		if math.IsInf(b.upperBound, 1) {
+			if i > 0 {
+				return h.buckets[i-1].upperBound
+			}
+			return b.upperBound
+		}
+		lower := histogramLowerBound(b.upperBound)
+		return lower + (b.upperBound-lower)*(rank-count)/b.count
+	}
+	return h.buckets[len(h.buckets)-1].upperBound
+}
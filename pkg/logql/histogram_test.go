@@ -0,0 +1,139 @@
+package logql
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+func Test_histogramBucket(t *testing.T) {
+	for _, tc := range []struct {
+		value    float64
+		expected float64
+	}{
+		{1, 1},
+		{4, 4},
+		{0.25, 0.25},
+		{1.05, histogramBase},
+		{histogramBase, histogramBase},
+		{3.9, 4},
+		{-1, -1 / histogramBase},
+		{-1.05, -1},
+		{0, 0},
+		{math.Inf(1), math.Inf(1)},
+		{math.Inf(-1), math.Inf(-1)},
+		{math.NaN(), math.Inf(1)},
+	} {
+		le := histogramBucket(tc.value)
+		require.InDelta(t, tc.expected, le, 1e-9, "value %v", tc.value)
+		if !math.IsInf(le, 0) && le != 0 {
+			require.True(t, histogramLowerBound(le) < tc.value || math.Abs(tc.value-histogramLowerBound(le)) < 1e-9, "value %v", tc.value)
+			require.LessOrEqual(t, tc.value, le)
+		}
+	}
+}
+
+func Test_histogramQuantile(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		q        float64
+		buckets  []bucket
+		expected float64
+	}{
+		{
+			desc:     "empty",
+			q:        0.5,
+			expected: math.NaN(),
+		},
+		{
+			desc:     "interpolated within the bucket",
+			q:        0.5,
+			buckets:  []bucket{{upperBound: 4, count: 10}},
+			expected: (4 + 4/histogramBase) / 2,
+		},
+		{
+			desc:     "unsorted buckets",
+			q:        0.75,
+			buckets:  []bucket{{upperBound: 4, count: 1}, {upperBound: 1, count: 3}},
+			expected: 1,
+		},
+		{
+			desc:     "negative and zero buckets",
+			q:        0.5,
+			buckets:  []bucket{{upperBound: -1, count: 1}, {upperBound: 0, count: 2}, {upperBound: 1, count: 1}},
+			expected: 0,
+		},
+		{
+			desc:     "+Inf bucket",
+			q:        0.99,
+			buckets:  []bucket{{upperBound: 2, count: 1}, {upperBound: math.Inf(1), count: 1}},
+			expected: 2,
+		},
+		{
+			desc:     "quantile lower than 0",
+			q:        -1,
+			buckets:  []bucket{{upperBound: 2, count: 1}},
+			expected: math.Inf(-1),
+		},
+		{
+			desc:     "quantile greater than 1",
+			q:        2,
+			buckets:  []bucket{{upperBound: 2, count: 1}},
+			expected: math.Inf(1),
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			h := &histogram{buckets: tc.buckets}
+			actual := h.quantile(tc.q)
+			if math.IsNaN(tc.expected) {
+				require.True(t, math.IsNaN(actual))
+				return
+			}
+			require.InDelta(t, tc.expected, actual, 1e-9)
+		})
+	}
+}
+
+func Test_histogramQuantile_Error(t *testing.T) {
+	// The quantiles of the histograms are within 5% of the exact quantiles.
+	h := &histogram{}
+	for i := 1; i <= 1000; i++ {
+		h.buckets = append(h.buckets, bucket{upperBound: histogramBucket(float64(i)), count: 1})
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9, 0.99} {
+		require.InEpsilon(t, q*1000, h.quantile(q), 0.05, "quantile %v", q)
+	}
+}
+
+func Test_histogramBucketIterator(t *testing.T) {
+	it := newHistogramBucketIterator(iter.NewPeekingSampleIterator(iter.NewSeriesIterator(logproto.Series{
+		Labels: `{app="foo"}`,
+		Samples: []logproto.Sample{
+			{Timestamp: 1, Value: 4},
+			{Timestamp: 2, Value: 0.5},
+		},
+	})))
+
+	lbs, sample, ok := it.Peek()
+	require.True(t, ok)
+	require.Equal(t, `{app="foo", le="4"}`, lbs)
+	require.Equal(t, logproto.Sample{Timestamp: 1, Value: 1}, sample)
+
+	require.True(t, it.Next())
+	require.Equal(t, `{app="foo", le="4"}`, it.Labels())
+	require.True(t, it.Next())
+	require.Equal(t, `{app="foo", le="0.5"}`, it.Labels())
+	require.Equal(t, logproto.Sample{Timestamp: 2, Value: 1}, it.Sample())
+	require.False(t, it.Next())
+}
+
+func Test_histogramGrouping(t *testing.T) {
+	require.Equal(t, &syntax.Grouping{Groups: []string{"app", "le"}}, histogramGrouping(&syntax.Grouping{Groups: []string{"app"}}))
+	require.Equal(t, &syntax.Grouping{Groups: []string{"app", "le"}}, histogramGrouping(&syntax.Grouping{Groups: []string{"app", "le"}}))
+	require.Equal(t, &syntax.Grouping{Groups: []string{"app"}, Without: true}, histogramGrouping(&syntax.Grouping{Groups: []string{"app", "le"}, Without: true}))
+}
@@ -0,0 +1,200 @@
+package logql
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/util/hll"
+)
+
+const (
+	// HLLRegisterLabel is the label holding the index of the register of the series produced by hll_over_time.
+	HLLRegisterLabel = "hll_register"
+
+	// hllHashBits is the number of bits of the hashes of the values, see log.ConvertHash.
+	hllHashBits = 53
+)
+
+// hllRegister returns the index of the register of the hash of a value, and the rank of the hash stored in the register.
+func hllRegister(v float64) (int, uint8) {
+	return hll.Register(uint64(v), hllHashBits)
+}
+
+// hllInsert adds the hash of a value to the sketch.
+func hllInsert(s *hll.Sketch, v float64) {
+	s.Insert(uint64(v), hllHashBits)
+}
+
+// countDistinctOverTime returns the estimated number of distinct values of the samples, whose values are hashes.
+func countDistinctOverTime(samples []promql.FPoint) float64 {
+	var h hll.Sketch
+	for _, s := range samples {
+		hllInsert(&h, s.F)
+	}
+	return h.Count()
+}
+
+// hllGrouping returns the grouping merging the sketches of hll_over_time grouped by g, which keeps the register label.
+func hllGrouping(g *syntax.Grouping) *syntax.Grouping {
+	return groupingWithLabel(g, HLLRegisterLabel)
+}
+
+// hllRegisterIterator turns the samples of the hashes of the values into the samples of the ranks of the registers
+// of their sketches, which are merged with max_over_time by hll_over_time.
+type hllRegisterIterator struct {
+	iter.PeekingSampleIterator
+
+	// series caches the labels of the register series, by labels of the samples and register.
+	series map[string]map[int]string
+}
+
+func newHLLRegisterIterator(it iter.PeekingSampleIterator) iter.PeekingSampleIterator {
+	return &hllRegisterIterator{
+		PeekingSampleIterator: it,
+		series:                map[string]map[int]string{},
+	}
+}
+
+func (it *hllRegisterIterator) Labels() string {
+	return it.registerLabels(it.PeekingSampleIterator.Labels(), it.PeekingSampleIterator.Sample().Value)
+}
+
+func (it *hllRegisterIterator) Sample() logproto.Sample {
+	return registerSample(it.PeekingSampleIterator.Sample())
+}
+
+func (it *hllRegisterIterator) Peek() (string, logproto.Sample, bool) {
+	lbs, sample, ok := it.PeekingSampleIterator.Peek()
+	if !ok {
+		return lbs, sample, ok
+	}
+	return it.registerLabels(lbs, sample.Value), registerSample(sample), true
+}
+
+func (it *hllRegisterIterator) registerLabels(lbs string, v float64) string {
+	i, _ := hllRegister(v)
+	registers, ok := it.series[lbs]
+	if !ok {
+		registers = map[int]string{}
+		it.series[lbs] = registers
+	}
+	if series, ok := registers[i]; ok {
+		return series
+	}
+	series := lbs
+	if metric, err := promql_parser.ParseMetric(lbs); err == nil {
+		series = labels.NewBuilder(metric).Set(HLLRegisterLabel, strconv.Itoa(i)).Labels().String()
+	}
+	registers[i] = series
+	return series
+}
+
+func registerSample(s logproto.Sample) logproto.Sample {
+	_, rank := hllRegister(s.Value)
+	s.Value = float64(rank)
+	return s
+}
+
+// approxCountDistinctEvaluator merges the sketches of each group by register, and estimates their distinct counts.
+// The series which are not registers are ignored.
+func approxCountDistinctEvaluator(
+	ctx context.Context,
+	ev SampleEvaluator,
+	expr *syntax.VectorAggregationExpr,
+	q Params,
+) (StepEvaluator, error) {
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.Left, q)
+	if err != nil {
+		return nil, err
+	}
+	grouping := &syntax.Grouping{Without: expr.Grouping.Without}
+	for _, g := range expr.Grouping.Groups {
+		if g != HLLRegisterLabel {
+			grouping.Groups = append(grouping.Groups, g)
+		}
+	}
+	if grouping.Without {
+		grouping.Groups = append(grouping.Groups, HLLRegisterLabel)
+	}
+	sort.Strings(grouping.Groups)
+
+	lb := labels.NewBuilder(nil)
+	buf := make([]byte, 0, 1024)
+	type sketch struct {
+		metric labels.Labels
+		hll    hll.Sketch
+	}
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		next, ts, vec := nextEvaluator.Next()
+		if !next {
+			return false, 0, promql.Vector{}
+		}
+		sketches := map[uint64]*sketch{}
+		for _, s := range vec {
+			i, err := strconv.Atoi(s.Metric.Get(HLLRegisterLabel))
+			if err != nil || i < 0 || i >= hll.Registers {
+				// the series is not a register.
+				continue
+			}
+			var hash uint64
+			if grouping.Without {
+				hash, buf = s.Metric.HashWithoutLabels(buf, grouping.Groups...)
+			} else {
+				hash, buf = s.Metric.HashForLabels(buf, grouping.Groups...)
+			}
+			sk, ok := sketches[hash]
+			if !ok {
+				lb.Reset(s.Metric)
+				if grouping.Without {
+					lb.Del(grouping.Groups...)
+				} else {
+					lb.Keep(grouping.Groups...)
+				}
+				sk = &sketch{metric: lb.Labels()}
+				sketches[hash] = sk
+			}
+			sk.hll.Set(i, uint8(s.F))
+		}
+		result := make(promql.Vector, 0, len(sketches))
+		for _, sk := range sketches {
+			result = append(result, promql.Sample{
+				T:      ts,
+				F:      sk.hll.Count(),
+				Metric: sk.metric,
+			})
+		}
+		return next, ts, result
+	}, nextEvaluator.Close, nextEvaluator.Error)
+}
+
+// returnsSketches returns whether the series of the results of the expression are the registers of sketches.
+func returnsSketches(expr syntax.SampleExpr) bool {
+	switch e := expr.(type) {
+	case *syntax.RangeAggregationExpr:
+		return e.Operation == syntax.OpRangeTypeHLL
+	case *syntax.VectorAggregationExpr:
+		// the sketches merged by register.
+		return e.Operation == syntax.OpTypeMax && e.Grouping.Without != hasLabel(e.Grouping.Groups, HLLRegisterLabel) && returnsSketches(e.Left)
+	case *ConcatSampleExpr:
+		return returnsSketches(e.DownstreamSampleExpr.SampleExpr)
+	default:
+		return false
+	}
+}
+
+func hasLabel(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package logql
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/util/hll"
+)
+
+// newHasher returns a function hashing the values as the unwrapped labels of count_distinct_over_time.
+func newHasher(t *testing.T) func(string) float64 {
+	ex, err := log.LabelExtractorWithStages("v", log.ConvertHash, nil, false, false, nil, log.NoopStage)
+	require.NoError(t, err)
+	stream := ex.ForStream(labels.EmptyLabels())
+	return func(v string) float64 {
+		f, _, ok := stream.Process(0, nil, labels.Label{Name: "v", Value: v})
+		require.True(t, ok)
+		return f
+	}
+}
+
+func Test_hllRegister(t *testing.T) {
+	for _, tc := range []struct {
+		hash          uint64
+		expectedIndex int
+		expectedRank  uint8
+	}{
+		{hash: 0, expectedIndex: 0, expectedRank: hllHashBits - hll.Precision + 1},
+		{hash: 1, expectedIndex: 0, expectedRank: hllHashBits - hll.Precision},
+		{hash: 1 << (hllHashBits - 1), expectedIndex: hll.Registers / 2, expectedRank: hllHashBits - hll.Precision + 1},
+		{hash: 1<<(hllHashBits-1) | 1<<(hllHashBits-hll.Precision-1), expectedIndex: hll.Registers / 2, expectedRank: 1},
+		{hash: 1<<hllHashBits - 1, expectedIndex: hll.Registers - 1, expectedRank: 1},
+	} {
+		i, rank := hllRegister(float64(tc.hash))
+		require.Equal(t, tc.expectedIndex, i, "hash %x", tc.hash)
+		require.Equal(t, tc.expectedRank, rank, "hash %x", tc.hash)
+	}
+}
+
+func Test_hllCount(t *testing.T) {
+	hash := newHasher(t)
+	for _, n := range []int{0, 1, 10, 100, 1000, 10000, 100000} {
+		var h hll.Sketch
+		for i := 0; i < n; i++ {
+			v := hash(strconv.Itoa(i))
+			// the duplicated values are counted once.
+			hllInsert(&h, v)
+			hllInsert(&h, v)
+		}
+		// The standard error of the counts is about 3%.
+		require.InDelta(t, float64(n), h.Count(), 0.1*float64(n)+0.5, "count %d", n)
+	}
+}
+
+func Test_hllMerge(t *testing.T) {
+	hash := newHasher(t)
+	var a, b, merged hll.Sketch
+	for i := 0; i < 1000; i++ {
+		hllInsert(&a, hash(strconv.Itoa(i)))
+	}
+	for i := 500; i < 2000; i++ {
+		hllInsert(&b, hash(strconv.Itoa(i)))
+	}
+	for i := range merged {
+		merged.Set(i, a[i])
+		merged.Set(i, b[i])
+	}
+	require.InEpsilon(t, 2000, merged.Count(), 0.1)
+}
+
+func Test_hllRegisterIterator(t *testing.T) {
+	hash := newHasher(t)
+	v := hash("foo")
+	i, rank := hllRegister(v)
+	register := strconv.Itoa(i)
+
+	it := newHLLRegisterIterator(iter.NewPeekingSampleIterator(iter.NewSeriesIterator(logproto.Series{
+		Labels: `{app="foo"}`,
+		Samples: []logproto.Sample{
+			{Timestamp: 1, Value: v},
+			{Timestamp: 2, Value: v},
+		},
+	})))
+
+	lbs, sample, ok := it.Peek()
+	require.True(t, ok)
+	require.Equal(t, `{app="foo", hll_register="`+register+`"}`, lbs)
+	require.Equal(t, logproto.Sample{Timestamp: 1, Value: float64(rank)}, sample)
+
+	require.True(t, it.Next())
+	require.Equal(t, `{app="foo", hll_register="`+register+`"}`, it.Labels())
+	require.True(t, it.Next())
+	require.Equal(t, logproto.Sample{Timestamp: 2, Value: float64(rank)}, it.Sample())
+	require.False(t, it.Next())
+}
+
+func Test_hllGrouping(t *testing.T) {
+	require.Equal(t, &syntax.Grouping{Groups: []string{"app", "hll_register"}}, hllGrouping(&syntax.Grouping{Groups: []string{"app"}}))
+	require.Equal(t, &syntax.Grouping{Groups: []string{"app"}, Without: true}, hllGrouping(&syntax.Grouping{Groups: []string{"app", "hll_register"}, Without: true}))
+}
+
+func Test_approxCountDistinctEvaluator(t *testing.T) {
+	hash := newHasher(t)
+	var a, b hll.Sketch
+	for i := 0; i < 100; i++ {
+		hllInsert(&a, hash(strconv.Itoa(i)))
+		hllInsert(&b, hash(strconv.Itoa(i+50)))
+	}
+	var vec promql.Vector
+	for _, sketch := range []struct {
+		hll hll.Sketch
+		lbs []string
+	}{
+		{a, []string{"app", "foo", "pod", "a"}},
+		{b, []string{"app", "foo", "pod", "b"}},
+		{a, []string{"app", "bar", "pod", "a"}},
+	} {
+		for i, rank := range sketch.hll {
+			if rank > 0 {
+				vec = append(vec, promql.Sample{
+					T:      1,
+					F:      float64(rank),
+					Metric: labels.FromStrings(append(sketch.lbs, HLLRegisterLabel, strconv.Itoa(i))...),
+				})
+			}
+		}
+	}
+	// the series which are not registers are ignored.
+	vec = append(vec, promql.Sample{T: 1, F: 1000, Metric: labels.FromStrings("app", "foo")})
+
+	ev := SampleEvaluatorFunc(func(context.Context, SampleEvaluator, syntax.SampleExpr, Params) (StepEvaluator, error) {
+		next := true
+		return newStepEvaluator(func() (bool, int64, promql.Vector) {
+			defer func() { next = false }()
+			return next, 1, vec
+		}, func() error { return nil }, func() error { return nil })
+	})
+	expr, err := syntax.ParseSampleExpr(`approx_count_distinct by (app) (hll_over_time({app=~".+"} | unwrap user [1m]))`)
+	require.NoError(t, err)
+	params := NewLiteralParams(expr.String(), time.Unix(0, 0), time.Unix(0, 0), 0, 0, logproto.FORWARD, 0, nil)
+
+	stepEvaluator, err := approxCountDistinctEvaluator(context.Background(), ev, expr.(*syntax.VectorAggregationExpr), params)
+	require.NoError(t, err)
+	ok, _, result := stepEvaluator.Next()
+	require.True(t, ok)
+	require.Len(t, result, 2)
+	for _, s := range result {
+		switch s.Metric.Get("app") {
+		case "foo":
+			require.InEpsilon(t, 150, s.F, 0.1)
+		case "bar":
+			require.InEpsilon(t, 100, s.F, 0.1)
+		default:
+			t.Fatalf("unexpected series %s", s.Metric)
+		}
+		require.Equal(t, 1, s.Metric.Len())
+	}
+}
@@ -0,0 +1,111 @@
+package log
+
+import (
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Parsers of the fields of ParsedColumns.
+const (
+	ColumnsParserJSON   = "json"
+	ColumnsParserLogfmt = "logfmt"
+)
+
+// ParsedColumns hold the fields parsed from log lines when they were stored, for instance in the blocks of a chunk.
+// The json and logfmt parsers read the fields of a line from its columns instead of parsing it
+// when all the fields a query needs are known to be in the columns.
+type ParsedColumns interface {
+	// Parser returns the parser the fields were extracted with, ColumnsParserJSON or ColumnsParserLogfmt.
+	Parser() string
+	// Columns returns the label names of the fields stored in the columns.
+	Columns() []string
+	// Fields returns the label names of all the fields parsed from the lines, including those not stored in the columns,
+	// and whether the list is complete.
+	Fields() ([]string, bool)
+	// Parsed returns false if the current line couldn't be parsed when it was stored.
+	Parsed() bool
+	// Value returns the value of the field of the i-th column for the current line, false if the line doesn't have it.
+	Value(i int) (string, bool)
+}
+
+// ColumnsStreamPipeline is a StreamPipeline that can process a line along with its parsed columns.
+type ColumnsStreamPipeline interface {
+	StreamPipeline
+	ProcessWithColumns(ts int64, line []byte, columns ParsedColumns, structuredMetadata ...labels.Label) ([]byte, LabelsResult, bool)
+}
+
+// ColumnsStreamSampleExtractor is a StreamSampleExtractor that can process a line along with its parsed columns.
+type ColumnsStreamSampleExtractor interface {
+	StreamSampleExtractor
+	ProcessWithColumns(ts int64, line []byte, columns ParsedColumns, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
+}
+
+// extractFromColumns sets the labels the parser would extract from the line using its parsed columns.
+// It returns false, leaving the labels untouched, when the line must be parsed instead.
+// Otherwise matches tells whether the extracted labels match the label filters of the parser hints.
+func extractFromColumns(parser string, line []byte, lbs *LabelsBuilder) (ok, matches bool) {
+	columns := lbs.parsedColumns(line)
+	if columns == nil || columns.Parser() != parser || !lbs.columnsCoverHints(columns) {
+		return false, false
+	}
+
+	if !columns.Parsed() {
+		return false, false
+	}
+
+	hints := lbs.ParserLabelHints()
+	for _, l := range lbs.columnLabels {
+		if !hints.ShouldExtract(l.name) {
+			continue
+		}
+		value, ok := columns.Value(l.index)
+		if !ok {
+			continue
+		}
+		lbs.Set(l.name, value)
+		if !hints.ShouldContinueParsingLine(l.name, lbs) {
+			return true, false
+		}
+	}
+	return true, true
+}
+
+// columnLabel is the label a column is extracted to.
+type columnLabel struct {
+	index int
+	name  string
+}
+
+// columnsCover tells whether the columns hold all the fields that may be extracted for the required labels,
+// or all the fields of the lines if any label may be required.
+func columnsCover(columns ParsedColumns, required []string) bool {
+	fields, complete := columns.Fields()
+	names := columns.Columns()
+	if len(required) == 0 {
+		return complete && len(fields) == len(names)
+	}
+
+	for _, r := range required {
+		// A field colliding with a stream label is extracted with the duplicate suffix.
+		field := strings.TrimSuffix(r, duplicateSuffix)
+		if containsString(names, r) || containsString(names, field) {
+			continue
+		}
+		// The label isn't required from the parser if no line has such a field.
+		if complete && !containsString(fields, r) && !containsString(fields, field) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeColumns struct {
+	parser   string
+	columns  []string
+	fields   []string
+	complete bool
+	values   []string
+	parsed   bool
+}
+
+func (c *fakeColumns) Parser() string             { return c.parser }
+func (c *fakeColumns) Columns() []string          { return c.columns }
+func (c *fakeColumns) Fields() ([]string, bool)   { return c.fields, c.complete }
+func (c *fakeColumns) Parsed() bool               { return c.parsed }
+func (c *fakeColumns) Value(i int) (string, bool) { return c.values[i], c.values[i] != "" }
+
+func TestParsedColumns(t *testing.T) {
+	// The values of the columns differ from the line to tell where the labels come from.
+	line := []byte(`{"app":"line","status":"200","other":"line"}`)
+	newColumns := func() *fakeColumns {
+		return &fakeColumns{
+			parser:   ColumnsParserJSON,
+			columns:  []string{"app", "status"},
+			fields:   []string{"app", "status", "other"},
+			complete: true,
+			values:   []string{"columns", "500"},
+			parsed:   true,
+		}
+	}
+	stream := labels.FromStrings("app", "foo")
+
+	for _, tc := range []struct {
+		name     string
+		stages   []Stage
+		groups   []string
+		columns  func() *fakeColumns
+		expected labels.Labels
+		matches  bool
+	}{
+		{
+			name:     "required fields in the columns",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"status"},
+			columns:  newColumns,
+			expected: labels.FromStrings("status", "500"),
+			matches:  true,
+		},
+		{
+			name:     "field colliding with a stream label",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"app_extracted"},
+			columns:  newColumns,
+			expected: labels.FromStrings("app_extracted", "columns"),
+			matches:  true,
+		},
+		{
+			name:     "required field absent from the lines",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"status", "missing"},
+			columns:  newColumns,
+			expected: labels.FromStrings("status", "500"),
+			matches:  true,
+		},
+		{
+			name:    "label filter on the columns",
+			stages:  []Stage{NewJSONParser(), NewNumericLabelFilter(LabelFilterLesserThan, "status", 400)},
+			groups:  []string{"status"},
+			columns: newColumns,
+			matches: false,
+		},
+		{
+			name:     "required field not in the columns",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"status", "other"},
+			columns:  newColumns,
+			expected: labels.Labels{{Name: "status", Value: "200"}, {Name: "other", Value: "line"}},
+			matches:  true,
+		},
+		{
+			name:     "incomplete fields",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"missing"},
+			columns:  func() *fakeColumns { c := newColumns(); c.complete = false; return c },
+			expected: labels.EmptyLabels(),
+			matches:  true,
+		},
+		{
+			name:     "all fields required",
+			stages:   []Stage{NewJSONParser()},
+			columns:  newColumns,
+			expected: labels.FromStrings("app", "foo", "app_extracted", "line", "status", "200", "other", "line"),
+			matches:  true,
+		},
+		{
+			name:     "all fields in the columns",
+			stages:   []Stage{NewJSONParser()},
+			columns:  func() *fakeColumns { c := newColumns(); c.fields = c.columns; return c },
+			expected: labels.FromStrings("app", "foo", "app_extracted", "columns", "status", "500"),
+			matches:  true,
+		},
+		{
+			name:     "line that couldn't be parsed",
+			stages:   []Stage{NewJSONParser()},
+			groups:   []string{"status"},
+			columns:  func() *fakeColumns { c := newColumns(); c.parsed = false; return c },
+			expected: labels.FromStrings("status", "200"),
+			matches:  true,
+		},
+		{
+			name:     "other parser",
+			stages:   []Stage{NewLogfmtParser(false, false), NewJSONParser()},
+			groups:   []string{"status"},
+			columns:  func() *fakeColumns { c := newColumns(); c.parser = ColumnsParserLogfmt; return c },
+			expected: labels.FromStrings("status", "500"),
+			matches:  true,
+		},
+		{
+			name:     "line modified by a previous stage",
+			stages:   []Stage{mustNewLineFormatter(`{"status":"300"}`), NewJSONParser()},
+			groups:   []string{"status"},
+			columns:  newColumns,
+			expected: labels.FromStrings("status", "300"),
+			matches:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ex, err := NewLineSampleExtractor(CountExtractor, tc.stages, tc.groups, false, false)
+			require.NoError(t, err)
+			sp, ok := ex.ForStream(stream).(ColumnsStreamSampleExtractor)
+			require.True(t, ok)
+
+			_, lbs, matches := sp.ProcessWithColumns(0, line, tc.columns())
+			require.Equal(t, tc.matches, matches)
+			if matches {
+				require.Equal(t, tc.expected, lbs.Labels())
+			}
+		})
+	}
+}
+
+func TestParsedColumnsNotKept(t *testing.T) {
+	line := []byte(`{"status":"200"}`)
+	columns := &fakeColumns{parser: ColumnsParserJSON, columns: []string{"status"}, fields: []string{"status"}, complete: true, values: []string{"500"}, parsed: true}
+
+	p := NewPipeline([]Stage{NewJSONParser()}).ForStream(labels.EmptyLabels())
+	cp, ok := p.(ColumnsStreamPipeline)
+	require.True(t, ok)
+	_, lbs, _ := cp.ProcessWithColumns(0, line, columns)
+	require.Equal(t, labels.FromStrings("status", "500"), lbs.Labels())
+
+	_, lbs, _ = p.Process(0, line)
+	require.Equal(t, labels.FromStrings("status", "200"), lbs.Labels())
+}
+
+func mustNewLineFormatter(tmpl string) Stage {
+	f, err := NewFormatter(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
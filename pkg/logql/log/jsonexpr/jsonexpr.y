@@ -17,7 +17,7 @@ func setScannerData(lex interface{}, data []interface{}) {
     int     int
 }
 
-%token<empty>   DOT LSB RSB
+%token<empty>   DOT LSB RSB STAR
 %token<str>     STRING
 %token<field>   FIELD
 %token<int>     INDEX
@@ -35,8 +35,10 @@ values:
     field                   { $$ = []interface{}{$1} }
   | key_access              { $$ = []interface{}{$1} }
   | index_access            { $$ = []interface{}{$1} }
+  | wildcard_access         { $$ = []interface{}{Wildcard{}} }
   | values key_access       { $$ = append($1, $2) }
   | values index_access     { $$ = append($1, $2) }
+  | values wildcard_access  { $$ = append($1, Wildcard{}) }
   | values DOT field        { $$ = append($1, $3) }
   ;
 
@@ -46,6 +48,9 @@ key_access:
 index_access:
     LSB index RSB   { $$ = $2 }
 
+wildcard_access:
+    LSB STAR RSB
+
 field:
   FIELD             { $$ = $1 }
 
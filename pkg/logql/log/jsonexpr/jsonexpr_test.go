@@ -76,6 +76,24 @@ func TestJSONExpressionParser(t *testing.T) {
 			[]interface{}{"pod", "deployment", "params", 0, "param"},
 			nil,
 		},
+		{
+			"array wildcard",
+			`pod.deployment.params[*].param`,
+			[]interface{}{"pod", "deployment", "params", Wildcard{}, "param"},
+			nil,
+		},
+		{
+			"top-level array wildcard",
+			`[*]["id"]`,
+			[]interface{}{Wildcard{}, "id"},
+			nil,
+		},
+		{
+			"nested array wildcards",
+			`items[*].tags[*]`,
+			[]interface{}{"items", Wildcard{}, "tags", Wildcard{}},
+			nil,
+		},
 		{
 			"empty",
 			``,
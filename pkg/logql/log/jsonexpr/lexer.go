@@ -68,6 +68,8 @@ func (sc *Scanner) lex(lval *JSONExprSymType) int {
 			return RSB
 		case r == '.':
 			return DOT
+		case r == '*':
+			return STAR
 		case isStartIdentifier(r):
 			sc.unread()
 			lval.field = sc.scanField()
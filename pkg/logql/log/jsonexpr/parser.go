@@ -8,6 +8,9 @@ func init() {
 	JSONExprErrorVerbose = true
 }
 
+// Wildcard is the element of the paths iterating over all the elements of an array, e.g: items[*].id.
+type Wildcard struct{}
+
 func Parse(expr string, debug bool) ([]interface{}, error) {
 	s := NewScanner(strings.NewReader(expr), debug)
 	JSONExprParse(s)
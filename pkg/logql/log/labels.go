@@ -88,6 +88,15 @@ type LabelsBuilder struct {
 	currentResult LabelsResult
 	groupedResult LabelsResult
 
+	// columns are the parsed columns of the line being processed, and columnsLine the line they were parsed from.
+	columns     ParsedColumns
+	columnsLine []byte
+	// coveredColumns caches whether the last columns seen cover the parser hints,
+	// and the labels of the columns to extract.
+	coveredColumns ParsedColumns
+	covered        bool
+	columnLabels   []columnLabel
+
 	*BaseLabelsBuilder
 }
 
@@ -141,9 +150,45 @@ func (b *LabelsBuilder) Reset() {
 	b.add = b.add[:0]
 	b.err = ""
 	b.errDetails = ""
+	b.columns = nil
+	b.columnsLine = nil
 	b.parserKeyHints.Reset()
 }
 
+// setParsedColumns sets the parsed columns of the line being processed.
+func (b *LabelsBuilder) setParsedColumns(line []byte, columns ParsedColumns) {
+	b.columns = columns
+	b.columnsLine = line
+}
+
+// parsedColumns returns the parsed columns of the line, nil if there are none
+// or if the line was modified by a previous stage.
+func (b *LabelsBuilder) parsedColumns(line []byte) ParsedColumns {
+	if b.columns == nil || len(line) == 0 || len(line) != len(b.columnsLine) || &line[0] != &b.columnsLine[0] {
+		return nil
+	}
+	return b.columns
+}
+
+// columnsCoverHints tells whether the columns hold all the fields the parser hints may require.
+func (b *LabelsBuilder) columnsCoverHints(columns ParsedColumns) bool {
+	if columns != b.coveredColumns {
+		required := b.parserKeyHints.RequiredLabels()
+		b.coveredColumns = columns
+		b.covered = columnsCover(columns, required)
+		b.columnLabels = b.columnLabels[:0]
+		for i, name := range columns.Columns() {
+			if b.BaseHas(name) {
+				name = name + duplicateSuffix
+			}
+			if len(required) == 0 || containsString(required, name) {
+				b.columnLabels = append(b.columnLabels, columnLabel{index: i, name: name})
+			}
+		}
+	}
+	return b.covered
+}
+
 // ParserLabelHints returns a limited list of expected labels to extract for metric queries.
 // Returns nil when it's impossible to hint labels extractions.
 func (b *BaseLabelsBuilder) ParserLabelHints() ParserHint {
@@ -244,6 +289,38 @@ func (b *LabelsBuilder) Set(n, v string) *LabelsBuilder {
 	return b
 }
 
+// Add adds the given labels to the builder. This is used to attach the structured metadata
+// of an entry. If a label with the same name already exists in the base labels,
+// the "_extracted" suffix is appended to the name.
+func (b *LabelsBuilder) Add(lbs ...labels.Label) *LabelsBuilder {
+	for _, l := range lbs {
+		name := l.Name
+		if b.BaseHas(name) {
+			name = name + duplicateSuffix
+		}
+		b.Set(name, l.Value)
+	}
+	return b
+}
+
+// WithoutStructuredMetadata returns the labels of a stream without the structured metadata added to them by Add.
+func WithoutStructuredMetadata(lbs labels.Labels, structuredMetadata labels.Labels) labels.Labels {
+	if len(structuredMetadata) == 0 {
+		return lbs
+	}
+	b := labels.NewBuilder(lbs)
+	for _, l := range structuredMetadata {
+		name := l.Name
+		if lbs.Has(name) && lbs.Get(name+duplicateSuffix) == l.Value {
+			name = name + duplicateSuffix
+		}
+		if lbs.Get(name) == l.Value {
+			b.Del(name)
+		}
+	}
+	return b.Labels()
+}
+
 // Labels returns the labels from the builder. If no modifications
 // were made, the original labels are returned.
 func (b *LabelsBuilder) labels() labels.Labels {
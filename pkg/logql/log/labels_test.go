@@ -32,6 +32,31 @@ func TestLabelsBuilder_Get(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestLabelsBuilder_Add(t *testing.T) {
+	lbs := labels.FromStrings("already", "in")
+	b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+	b.Reset()
+	b.Add(labels.Label{Name: "traceID", Value: "123"}, labels.Label{Name: "already", Value: "meta"})
+	v, ok := b.Get("traceID")
+	require.True(t, ok)
+	require.Equal(t, "123", v)
+	v, ok = b.Get("already")
+	require.True(t, ok)
+	require.Equal(t, "in", v)
+	v, ok = b.Get("already_extracted")
+	require.True(t, ok)
+	require.Equal(t, "meta", v)
+}
+
+func TestWithoutStructuredMetadata(t *testing.T) {
+	lbs := labels.FromStrings("already", "in")
+	b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+	b.Reset()
+	structuredMetadata := labels.FromStrings("traceID", "123", "already", "meta")
+	b.Add(structuredMetadata...)
+	require.Equal(t, lbs, WithoutStructuredMetadata(b.LabelsResult().Labels(), structuredMetadata))
+}
+
 func TestLabelsBuilder_LabelsError(t *testing.T) {
 	lbs := labels.FromStrings("already", "in")
 	b := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/prometheus/common/model"
+)
+
+// LookupTable is a table of labels, whose rows are looked up by the value of a label of the log lines.
+type LookupTable struct {
+	columns []string
+	rows    [][]string
+}
+
+// NewLookupTable creates a lookup table. The columns are the names of the labels of the values of the rows.
+func NewLookupTable(columns []string, rows [][]string) (*LookupTable, error) {
+	for _, c := range columns {
+		if !model.LabelName(c).IsValid() {
+			return nil, fmt.Errorf("invalid column %q of lookup table: not a valid label name", c)
+		}
+	}
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("invalid row %d of lookup table: %d values for %d columns", i+1, len(row), len(columns))
+		}
+	}
+	return &LookupTable{columns: columns, rows: rows}, nil
+}
+
+// ReadLookupTable reads a lookup table from a CSV file, whose first record is the header of the columns.
+func ReadLookupTable(r io.Reader) (*LookupTable, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty lookup table: the header of the columns is missing")
+	}
+	return NewLookupTable(records[0], records[1:])
+}
+
+func (t *LookupTable) Columns() []string {
+	return t.columns
+}
+
+func (t *LookupTable) Rows() [][]string {
+	return t.rows
+}
+
+// Lookup adds the values of the row of a lookup table whose column `on` has the value of the label `on`.
+// The values of the labels of the streams are added with the _extracted suffix, like the extracted labels of the
+// parsers. The lines without the label or without a matching row are left unchanged.
+type Lookup struct {
+	on      string
+	columns []string
+	// rows maps the values of the column on to their rows. The first row of duplicated values is used.
+	rows map[string][]string
+}
+
+func NewLookup(table *LookupTable, on string) (*Lookup, error) {
+	key := -1
+	for i, c := range table.columns {
+		if c == on {
+			key = i
+			break
+		}
+	}
+	if key < 0 {
+		return nil, fmt.Errorf("lookup table has no column %s", on)
+	}
+	rows := make(map[string][]string, len(table.rows))
+	for _, row := range table.rows {
+		if _, ok := rows[row[key]]; !ok {
+			rows[row[key]] = row
+		}
+	}
+	return &Lookup{
+		on:      on,
+		columns: table.columns,
+		rows:    rows,
+	}, nil
+}
+
+func (l *Lookup) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	v, ok := lbs.Get(l.on)
+	if !ok {
+		return line, true
+	}
+	row, ok := l.rows[v]
+	if !ok {
+		return line, true
+	}
+	for i, name := range l.columns {
+		if name == l.on || row[i] == "" {
+			continue
+		}
+		if lbs.BaseHas(name) {
+			name = name + duplicateSuffix
+		}
+		lbs.Set(name, row[i])
+	}
+	return line, true
+}
+
+func (l *Lookup) RequiredLabelNames() []string {
+	return []string{l.on}
+}
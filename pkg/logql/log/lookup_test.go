@@ -0,0 +1,90 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Lookup(t *testing.T) {
+	table, err := ReadLookupTable(strings.NewReader(`service,team,owner
+api,platform,alice
+billing,payments,
+api,other,bob
+`))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		on   string
+		lbs  labels.Labels
+
+		want labels.Labels
+	}{
+		{
+			"row found",
+			"service",
+			labels.FromStrings("service", "api", "env", "prod"),
+			labels.FromStrings("service", "api", "env", "prod", "team", "platform", "owner", "alice"),
+		},
+		{
+			"empty values are not added",
+			"service",
+			labels.FromStrings("service", "billing"),
+			labels.FromStrings("service", "billing", "team", "payments"),
+		},
+		{
+			"existing labels",
+			"service",
+			labels.FromStrings("service", "api", "team", "infra"),
+			labels.FromStrings("service", "api", "team", "infra", "team_extracted", "platform", "owner", "alice"),
+		},
+		{
+			"row not found",
+			"service",
+			labels.FromStrings("service", "web"),
+			labels.FromStrings("service", "web"),
+		},
+		{
+			"label not found",
+			"service",
+			labels.FromStrings("app", "api"),
+			labels.FromStrings("app", "api"),
+		},
+		{
+			"other column",
+			"owner",
+			labels.FromStrings("owner", "bob"),
+			labels.FromStrings("owner", "bob", "service", "api", "team", "other"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup, err := NewLookup(table, tc.on)
+			require.NoError(t, err)
+			lbls := NewBaseLabelsBuilder().ForLabels(tc.lbs, tc.lbs.Hash())
+			lbls.Reset()
+			line, ok := lookup.Process(0, []byte("foo"), lbls)
+			require.True(t, ok)
+			require.Equal(t, "foo", string(line))
+			require.Equal(t, tc.want, lbls.LabelsResult().Labels())
+		})
+	}
+}
+
+func Test_LookupErrors(t *testing.T) {
+	_, err := ReadLookupTable(strings.NewReader(``))
+	require.EqualError(t, err, "empty lookup table: the header of the columns is missing")
+
+	_, err = ReadLookupTable(strings.NewReader("service,team-name\napi,platform\n"))
+	require.EqualError(t, err, `invalid column "team-name" of lookup table: not a valid label name`)
+
+	_, err = NewLookupTable([]string{"service", "team"}, [][]string{{"api"}})
+	require.EqualError(t, err, "invalid row 1 of lookup table: 1 values for 2 columns")
+
+	table, err := NewLookupTable([]string{"service", "team"}, nil)
+	require.NoError(t, err)
+	_, err = NewLookup(table, "app")
+	require.EqualError(t, err, "lookup table has no column app")
+}
@@ -2,8 +2,12 @@ package log
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/buger/jsonparser"
@@ -62,6 +66,9 @@ func (j *JSONParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte,
 	if parserHints.NoLabels() {
 		return line, true
 	}
+	if ok, matches := extractFromColumns(ColumnsParserJSON, line, lbs); ok {
+		return line, matches
+	}
 
 	// reset the state.
 	j.prefixBuffer = j.prefixBuffer[:0]
@@ -306,6 +313,12 @@ func (l *LogfmtParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte
 	if parserHints.NoLabels() {
 		return line, true
 	}
+	// The columns hold the fields extracted by the default logfmt parser.
+	if !l.strict && !l.keepEmpty {
+		if ok, matches := extractFromColumns(ColumnsParserLogfmt, line, lbs); ok {
+			return line, matches
+		}
+	}
 
 	l.dec.Reset(line)
 	for !l.dec.EOL() {
@@ -540,15 +553,26 @@ func (l *LogfmtExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilde
 
 func (l *LogfmtExpressionParser) RequiredLabelNames() []string { return []string{} }
 
+const (
+	// jsonWildcard is the element of the paths iterating over the elements of an array.
+	jsonWildcard = "[*]"
+	// jsonWildcardSeparator separates the values of the paths with wildcards.
+	jsonWildcardSeparator = ","
+)
+
 type JSONExpressionParser struct {
 	ids   []string
 	paths [][]string
-	keys  internedStringSet
+	// wildcardIDs and wildcardPaths are the expressions with wildcards, which can match several values.
+	wildcardIDs   []string
+	wildcardPaths [][]string
+	keys          internedStringSet
 }
 
 func NewJSONExpressionParser(expressions []LabelExtractionExpr) (*JSONExpressionParser, error) {
-	var ids []string
-	var paths [][]string
+	j := &JSONExpressionParser{
+		keys: internedStringSet{},
+	}
 	for _, exp := range expressions {
 		path, err := jsonexpr.Parse(exp.Expression, false)
 		if err != nil {
@@ -559,15 +583,17 @@ func NewJSONExpressionParser(expressions []LabelExtractionExpr) (*JSONExpression
 			return nil, fmt.Errorf("invalid extracted label name '%s'", exp.Identifier)
 		}
 
-		ids = append(ids, exp.Identifier)
-		paths = append(paths, pathsToString(path))
+		stringPath := pathsToString(path)
+		if hasJSONWildcard(stringPath) {
+			j.wildcardIDs = append(j.wildcardIDs, exp.Identifier)
+			j.wildcardPaths = append(j.wildcardPaths, stringPath)
+			continue
+		}
+		j.ids = append(j.ids, exp.Identifier)
+		j.paths = append(j.paths, stringPath)
 	}
 
-	return &JSONExpressionParser{
-		ids:   ids,
-		paths: paths,
-		keys:  internedStringSet{},
-	}, nil
+	return j, nil
 }
 
 func pathsToString(paths []interface{}) []string {
@@ -578,11 +604,22 @@ func pathsToString(paths []interface{}) []string {
 			stingPaths = append(stingPaths, fmt.Sprintf("[%d]", v))
 		case string:
 			stingPaths = append(stingPaths, v)
+		case jsonexpr.Wildcard:
+			stingPaths = append(stingPaths, jsonWildcard)
 		}
 	}
 	return stingPaths
 }
 
+func hasJSONWildcard(path []string) bool {
+	for _, p := range path {
+		if p == jsonWildcard {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *JSONExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
 	if len(line) == 0 || lbs.ParserLabelHints().NoLabels() {
 		return line, true
@@ -597,29 +634,30 @@ func (j *JSONExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilder)
 	}
 
 	var matches int
-	jsonparser.EachKey(line, func(idx int, data []byte, typ jsonparser.ValueType, err error) {
-		if err != nil {
-			addErrLabel(errJSON, err, lbs)
-			return
-		}
+	if len(j.paths) > 0 {
+		jsonparser.EachKey(line, func(idx int, data []byte, typ jsonparser.ValueType, err error) {
+			if err != nil {
+				addErrLabel(errJSON, err, lbs)
+				return
+			}
 
-		identifier := j.ids[idx]
-		key, _ := j.keys.Get(unsafeGetBytes(identifier), func() (string, bool) {
-			if lbs.BaseHas(identifier) {
-				identifier = identifier + duplicateSuffix
+			key := j.key(j.ids[idx], lbs)
+			switch typ {
+			case jsonparser.Null:
+				lbs.Set(key, "")
+			default:
+				lbs.Set(key, unescapeJSONString(data))
 			}
-			return identifier, true
-		})
 
-		switch typ {
-		case jsonparser.Null:
-			lbs.Set(key, "")
-		default:
-			lbs.Set(key, unescapeJSONString(data))
-		}
+			matches++
+		}, j.paths...)
+	}
 
-		matches++
-	}, j.paths...)
+	// The values matching the paths with wildcards are joined, an empty value is set when none matches.
+	for i, id := range j.wildcardIDs {
+		values := jsonWildcardValues(line, j.wildcardPaths[i], nil)
+		lbs.Set(j.key(id, lbs), strings.Join(values, jsonWildcardSeparator))
+	}
 
 	// Ensure there's a label for every value
 	if matches < len(j.ids) {
@@ -633,6 +671,96 @@ func (j *JSONExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilder)
 	return line, true
 }
 
+func (j *JSONExpressionParser) key(identifier string, lbs *LabelsBuilder) string {
+	key, _ := j.keys.Get(unsafeGetBytes(identifier), func() (string, bool) {
+		if lbs.BaseHas(identifier) {
+			identifier = identifier + duplicateSuffix
+		}
+		return identifier, true
+	})
+	return key
+}
+
+// jsonWildcardValues appends the values of data matching the path to values. The wildcards of the path iterate over
+// the elements of the arrays, and null values are skipped.
+func jsonWildcardValues(data []byte, path []string, values []string) []string {
+	i := 0
+	for i < len(path) && path[i] != jsonWildcard {
+		i++
+	}
+	value, typ, _, err := jsonparser.Get(data, path[:i]...)
+	if err != nil {
+		return values
+	}
+	if i == len(path) {
+		return appendJSONValue(values, value, typ)
+	}
+	if typ != jsonparser.Array {
+		return values
+	}
+	_, _ = jsonparser.ArrayEach(value, func(element []byte, typ jsonparser.ValueType, _ int, _ error) {
+		if i+1 == len(path) {
+			values = appendJSONValue(values, element, typ)
+			return
+		}
+		values = jsonWildcardValues(element, path[i+1:], values)
+	})
+	return values
+}
+
+func appendJSONValue(values []string, value []byte, typ jsonparser.ValueType) []string {
+	switch typ {
+	case jsonparser.Null:
+		return values
+	case jsonparser.String:
+		return append(values, unescapeJSONString(value))
+	default:
+		return append(values, string(value))
+	}
+}
+
+// JSONArraySplitter splits the log lines holding a JSON array into the elements of the array.
+type JSONArraySplitter struct {
+	path []string
+}
+
+// NewJSONArraySplitter creates a new splitter of the arrays at the path of the expression, the log lines themselves if
+// the expression is empty.
+func NewJSONArraySplitter(expression string) (*JSONArraySplitter, error) {
+	if expression == "" {
+		return &JSONArraySplitter{}, nil
+	}
+	path, err := jsonexpr.Parse(expression, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse expression [%s]: %w", expression, err)
+	}
+	stringPath := pathsToString(path)
+	if hasJSONWildcard(stringPath) {
+		return nil, fmt.Errorf("cannot use wildcards in expression [%s]", expression)
+	}
+	return &JSONArraySplitter{path: stringPath}, nil
+}
+
+// Split returns the elements of the array of the line, and false if the line holds no array at the path.
+// The string elements are unquoted and the other ones are returned as they are.
+func (s *JSONArraySplitter) Split(line []byte) ([][]byte, bool) {
+	array, typ, _, err := jsonparser.Get(line, s.path...)
+	if err != nil || typ != jsonparser.Array {
+		return nil, false
+	}
+	var elements [][]byte
+	_, err = jsonparser.ArrayEach(array, func(element []byte, typ jsonparser.ValueType, _ int, _ error) {
+		if typ == jsonparser.String {
+			element = []byte(unescapeJSONString(element))
+		}
+		elements = append(elements, element)
+	})
+	if err != nil {
+		return nil, false
+	}
+	return elements, true
+}
+
 func isValidJSONStart(data []byte) bool {
 	switch data[0] {
 	case '"', '{', '[':
@@ -754,3 +882,490 @@ func (u *UnpackParser) unpack(entry []byte, lbs *LabelsBuilder) ([]byte, error)
 	}
 	return entry, nil
 }
+
+type XMLParser struct {
+	prefixBuffer []byte // buffer used to build the keys of the elements
+	prefixes     []int  // lengths of the prefix buffer at the start of the current elements
+	text         []byte
+
+	keys internedStringSet
+}
+
+// NewXMLParser creates a log stage that can parse a xml log line and add the text of its elements without children,
+// and the attributes of all its elements, as labels. The keys of the labels are the names of the elements from the
+// root element, excluded, joined with an underscore, e.g. the label of <event><user id="1">foo</user></event> are
+// user="foo" and user_id="1".
+func NewXMLParser() *XMLParser {
+	return &XMLParser{
+		prefixBuffer: make([]byte, 0, 1024),
+		keys:         internedStringSet{},
+	}
+}
+
+func (x *XMLParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if len(line) == 0 || parserHints.NoLabels() {
+		return line, true
+	}
+
+	// reset the state.
+	x.prefixBuffer = x.prefixBuffer[:0]
+	x.prefixes = x.prefixes[:0]
+	var root string
+	// leaf tells if the current element has no children so far.
+	leaf := false
+
+	dec := xml.NewDecoder(bytes.NewReader(line))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return line, true
+			}
+			addErrLabel(errXML, err, lbs)
+			if !parserHints.ShouldContinueParsingLine(logqlmodel.ErrorLabel, lbs) {
+				return line, false
+			}
+			return line, true
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			x.prefixes = append(x.prefixes, len(x.prefixBuffer))
+			if len(x.prefixes) == 1 {
+				root = t.Name.Local
+			} else {
+				x.appendPrefix(t.Name.Local)
+				if !parserHints.ShouldExtractPrefix(unsafeGetString(x.prefixBuffer)) {
+					if err := dec.Skip(); err != nil {
+						continue
+					}
+					x.endElement()
+					leaf = false
+					continue
+				}
+			}
+			for _, attr := range t.Attr {
+				prefixLen := len(x.prefixBuffer)
+				x.appendPrefix(attr.Name.Local)
+				ok, matches := x.setLabel(lbs, attr.Value)
+				x.prefixBuffer = x.prefixBuffer[:prefixLen]
+				if !matches {
+					return line, false
+				}
+				if ok && parserHints.AllRequiredExtracted() {
+					return line, true
+				}
+			}
+			x.text = x.text[:0]
+			leaf = true
+		case xml.CharData:
+			x.text = append(x.text, t...)
+		case xml.EndElement:
+			if leaf {
+				// the text of the root element without children is extracted with its name.
+				if len(x.prefixes) == 1 {
+					x.appendPrefix(root)
+				}
+				ok, matches := x.setLabel(lbs, string(bytes.TrimSpace(x.text)))
+				if !matches {
+					return line, false
+				}
+				if ok && parserHints.AllRequiredExtracted() {
+					return line, true
+				}
+			}
+			x.endElement()
+			leaf = false
+		}
+	}
+}
+
+func (x *XMLParser) appendPrefix(name string) {
+	if len(x.prefixBuffer) != 0 {
+		x.prefixBuffer = append(x.prefixBuffer, byte(jsonSpacer))
+	}
+	x.prefixBuffer = appendSanitized(x.prefixBuffer, unsafeGetBytes(name))
+}
+
+// endElement rolls back the prefix as we exit the current element.
+func (x *XMLParser) endElement() {
+	x.prefixBuffer = x.prefixBuffer[:x.prefixes[len(x.prefixes)-1]]
+	x.prefixes = x.prefixes[:len(x.prefixes)-1]
+}
+
+// setLabel sets the label of the current prefix, it returns if the label was extracted and if the line still matches
+// the label filters.
+func (x *XMLParser) setLabel(lbs *LabelsBuilder, value string) (bool, bool) {
+	if len(x.prefixBuffer) == 0 {
+		return false, true
+	}
+	key, ok := x.keys.Get(x.prefixBuffer, func() (string, bool) {
+		field := string(x.prefixBuffer)
+		if lbs.BaseHas(field) {
+			field = field + duplicateSuffix
+		}
+		if !lbs.ParserLabelHints().ShouldExtract(field) {
+			return "", false
+		}
+		return field, true
+	})
+	if !ok {
+		return false, true
+	}
+	lbs.Set(key, value)
+	return true, lbs.ParserLabelHints().ShouldContinueParsingLine(key, lbs)
+}
+
+func (x *XMLParser) RequiredLabelNames() []string { return []string{} }
+
+// xmlPath is a path of the XPath subset supported by the XMLExpressionParser: the names of the elements from the root
+// element separated by slashes, or from any element if the path starts with //, optionally followed by the position of
+// the element among its siblings of the same name, from 1, and ending with an @attribute or the text() of the element,
+// e.g: /event/user[2]/@id. A * matches the elements of any name, and //@id the attribute of any element.
+type xmlPath struct {
+	descendant bool
+	steps      []xmlStep
+	attr       string
+}
+
+type xmlStep struct {
+	name     string
+	position int
+}
+
+func parseXMLPath(expression string) (xmlPath, error) {
+	var p xmlPath
+	path := strings.TrimSpace(expression)
+	switch {
+	case strings.HasPrefix(path, "//"):
+		p.descendant = true
+		path = path[2:]
+	case strings.HasPrefix(path, "/"):
+		path = path[1:]
+	}
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch {
+		case part == "":
+			return p, fmt.Errorf("empty element name")
+		case last && strings.HasPrefix(part, "@"):
+			if len(part) == 1 {
+				return p, fmt.Errorf("empty attribute name")
+			}
+			p.attr = part[1:]
+			continue
+		case last && part == "text()":
+			continue
+		}
+		step := xmlStep{name: part}
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return p, fmt.Errorf("missing closing square bracket in %s", part)
+			}
+			position, err := strconv.Atoi(part[i+1 : len(part)-1])
+			if err != nil || position < 1 {
+				return p, fmt.Errorf("invalid position in %s", part)
+			}
+			step = xmlStep{name: part[:i], position: position}
+		}
+		if step.name == "" || strings.ContainsAny(step.name, "@()[]") {
+			return p, fmt.Errorf("invalid element name %s", part)
+		}
+		p.steps = append(p.steps, step)
+	}
+	// //@attribute selects the attribute of any element.
+	if len(p.steps) == 0 && !(p.descendant && p.attr != "") {
+		return p, fmt.Errorf("no element in path")
+	}
+	return p, nil
+}
+
+// matches tells if the path selects the current element of the stack of elements.
+func (p xmlPath) matches(stack []xmlElement) bool {
+	if p.descendant {
+		if len(stack) < len(p.steps) {
+			return false
+		}
+		stack = stack[len(stack)-len(p.steps):]
+	} else if len(stack) != len(p.steps) {
+		return false
+	}
+	for i, step := range p.steps {
+		e := stack[i]
+		if step.name == "*" {
+			if step.position > 0 && step.position != e.position {
+				return false
+			}
+			continue
+		}
+		if step.name != e.name || (step.position > 0 && step.position != e.namePosition) {
+			return false
+		}
+	}
+	return true
+}
+
+// xmlElement is an element of the stack of elements of the XMLExpressionParser.
+type xmlElement struct {
+	name string
+	// position and namePosition are the positions of the element among its siblings, and its siblings of the same name.
+	position, namePosition int
+	children               map[string]int
+	childCount             int
+}
+
+type XMLExpressionParser struct {
+	ids   []string
+	paths []xmlPath
+	keys  internedStringSet
+
+	// state of the current line, by expression.
+	stack    []xmlElement
+	pending  []bool
+	captures []int // depths of the elements whose text is captured, -1 if none
+	texts    [][]byte
+}
+
+func NewXMLExpressionParser(expressions []LabelExtractionExpr) (*XMLExpressionParser, error) {
+	x := &XMLExpressionParser{
+		keys:     internedStringSet{},
+		pending:  make([]bool, len(expressions)),
+		captures: make([]int, len(expressions)),
+		texts:    make([][]byte, len(expressions)),
+	}
+	for _, exp := range expressions {
+		path, err := parseXMLPath(exp.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse expression [%s]: %w", exp.Expression, err)
+		}
+
+		if !model.LabelName(exp.Identifier).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", exp.Identifier)
+		}
+
+		x.ids = append(x.ids, exp.Identifier)
+		x.paths = append(x.paths, path)
+	}
+	return x, nil
+}
+
+func (x *XMLExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if len(line) == 0 || parserHints.NoLabels() {
+		return line, true
+	}
+
+	// only the labels required by the query are extracted, the ones not found are empty.
+	remaining := 0
+	for i, id := range x.ids {
+		x.pending[i] = parserHints.ShouldExtract(x.key(id, lbs))
+		if x.pending[i] {
+			lbs.Set(x.key(id, lbs), "")
+			remaining++
+		}
+		x.captures[i] = -1
+		x.texts[i] = x.texts[i][:0]
+	}
+	x.stack = x.stack[:0]
+
+	dec := xml.NewDecoder(bytes.NewReader(line))
+	for remaining > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			addErrLabel(errXML, err, lbs)
+			if !parserHints.ShouldContinueParsingLine(logqlmodel.ErrorLabel, lbs) {
+				return line, false
+			}
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			x.push(t.Name.Local)
+			for i, p := range x.paths {
+				if !x.pending[i] || x.captures[i] >= 0 || !p.matches(x.stack) {
+					continue
+				}
+				if p.attr == "" {
+					x.captures[i] = len(x.stack)
+					continue
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Local != p.attr {
+						continue
+					}
+					remaining--
+					if !x.set(i, attr.Value, lbs) {
+						return line, false
+					}
+					break
+				}
+			}
+		case xml.CharData:
+			for i, depth := range x.captures {
+				if depth >= 0 {
+					x.texts[i] = append(x.texts[i], t...)
+				}
+			}
+		case xml.EndElement:
+			for i, depth := range x.captures {
+				if depth != len(x.stack) {
+					continue
+				}
+				x.captures[i] = -1
+				remaining--
+				if !x.set(i, string(bytes.TrimSpace(x.texts[i])), lbs) {
+					return line, false
+				}
+			}
+			x.stack = x.stack[:len(x.stack)-1]
+		}
+	}
+	return line, true
+}
+
+func (x *XMLExpressionParser) push(name string) {
+	e := xmlElement{name: name, position: 1, namePosition: 1}
+	if len(x.stack) > 0 {
+		parent := &x.stack[len(x.stack)-1]
+		if parent.children == nil {
+			parent.children = map[string]int{}
+		}
+		parent.childCount++
+		parent.children[name]++
+		e.position, e.namePosition = parent.childCount, parent.children[name]
+	}
+	x.stack = append(x.stack, e)
+}
+
+// set sets the label of the expression, and tells if the line still matches the label filters.
+func (x *XMLExpressionParser) set(i int, value string, lbs *LabelsBuilder) bool {
+	x.pending[i] = false
+	key := x.key(x.ids[i], lbs)
+	lbs.Set(key, value)
+	return lbs.ParserLabelHints().ShouldContinueParsingLine(key, lbs)
+}
+
+func (x *XMLExpressionParser) key(identifier string, lbs *LabelsBuilder) string {
+	key, _ := x.keys.Get(unsafeGetBytes(identifier), func() (string, bool) {
+		if lbs.BaseHas(identifier) {
+			identifier = identifier + duplicateSuffix
+		}
+		return identifier, true
+	})
+	return key
+}
+
+func (x *XMLExpressionParser) RequiredLabelNames() []string { return []string{} }
+
+type CSVParser struct {
+	columns   []string
+	delimiter string
+	fields    []string
+	keys      internedStringSet
+}
+
+// NewCSVParser creates a log stage that can parse a line of delimiter separated values, and add the values as labels
+// named by the columns, in the same order. The values of the columns without name are not extracted. The values can be
+// quoted with double quotes, a double quote being escaped by another one in quoted values.
+func NewCSVParser(columns []string, delimiter string) (*CSVParser, error) {
+	if utf8.RuneCountInString(delimiter) != 1 || strings.ContainsAny(delimiter, "\"\r\n") {
+		return nil, fmt.Errorf("invalid delimiter %q, expected a single character other than a double quote or a new line", delimiter)
+	}
+	named := false
+	for _, column := range columns {
+		if column == "" {
+			continue
+		}
+		if !model.LabelName(column).IsValid() {
+			return nil, fmt.Errorf("invalid column label name '%s'", column)
+		}
+		named = true
+	}
+	if !named {
+		return nil, fmt.Errorf("no csv column provided")
+	}
+	return &CSVParser{
+		columns:   columns,
+		delimiter: delimiter,
+		keys:      internedStringSet{},
+	}, nil
+}
+
+func (c *CSVParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if len(line) == 0 || parserHints.NoLabels() {
+		return line, true
+	}
+
+	c.fields = splitCSV(c.fields[:0], strings.TrimRight(string(line), "\r\n"), c.delimiter, len(c.columns))
+	for i, column := range c.columns {
+		if column == "" {
+			continue
+		}
+		key, ok := c.keys.Get(unsafeGetBytes(column), func() (string, bool) {
+			if lbs.BaseHas(column) {
+				column = column + duplicateSuffix
+			}
+			if !parserHints.ShouldExtract(column) {
+				return "", false
+			}
+			return column, true
+		})
+		if !ok {
+			continue
+		}
+		var value string
+		if i < len(c.fields) {
+			value = c.fields[i]
+		}
+		lbs.Set(key, value)
+		if !parserHints.ShouldContinueParsingLine(key, lbs) {
+			return line, false
+		}
+		if parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+	return line, true
+}
+
+// splitCSV appends at most limit fields of the line separated by the delimiter to fields. The text following the closing
+// quote of a quoted field is kept, as well as the text following an unclosed quote.
+func splitCSV(fields []string, line, delimiter string, limit int) []string {
+	for len(fields) < limit {
+		var quoted strings.Builder
+		if strings.HasPrefix(line, `"`) {
+			line = line[1:]
+			for {
+				i := strings.IndexByte(line, '"')
+				if i < 0 {
+					quoted.WriteString(line)
+					line = ""
+					break
+				}
+				quoted.WriteString(line[:i])
+				line = line[i+1:]
+				if !strings.HasPrefix(line, `"`) {
+					break
+				}
+				quoted.WriteByte('"')
+				line = line[1:]
+			}
+		}
+		i := strings.Index(line, delimiter)
+		if i < 0 {
+			return append(fields, quoted.String()+line)
+		}
+		fields = append(fields, quoted.String()+line[:i])
+		line = line[i+len(delimiter):]
+	}
+	return fields
+}
+
+func (c *CSVParser) RequiredLabelNames() []string { return []string{} }
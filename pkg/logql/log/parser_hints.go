@@ -24,6 +24,9 @@ type ParserHint interface {
 	// This allows to speed up key searching in nested structured like json.
 	ShouldExtractPrefix(prefix string) bool
 
+	// Returns the labels that should be extracted, all labels should be extracted if empty.
+	RequiredLabels() []string
+
 	// Tells if we should not extract any labels.
 	// For example in :
 	//		 sum(rate({app="foo"} | json [5m]))
@@ -90,6 +93,10 @@ func (p *Hints) ShouldExtractPrefix(prefix string) bool {
 	return false
 }
 
+func (p *Hints) RequiredLabels() []string {
+	return p.requiredLabels
+}
+
 func (p *Hints) NoLabels() bool {
 	return p.noLabels || p.AllRequiredExtracted()
 }
@@ -122,6 +122,8 @@ func TestKeyShortCircuit(t *testing.T) {
 	logfmtLine := []byte(`level=info ts=2020-12-14T21:25:20.947307459Z caller=metrics.go:83 org_id=29 traceID=c80e691e8db08e2 latency=fast query="sum by (object_name) (rate(({container=\"metrictank\", cluster=\"hm-us-east2\"} |= \"PANIC\")[5m]))" query_type=metric range_type=range length=5m0s step=15s duration=322.623724ms status=200 throughput=1.2GB total_bytes=375MB`)
 	nginxline := []byte(`10.1.0.88 - - [14/Dec/2020:22:56:24 +0000] "GET /static/img/about/bob.jpg HTTP/1.1" 200 60755 "https://grafana.com/go/observabilitycon/grafana-the-open-and-composable-observability-platform/?tech=ggl-o&pg=oss-graf&plcmt=hero-txt" "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.1 Safari/605.1.15" "123.123.123.123, 35.35.122.223" "TLSv1.3"`)
 	packedLike := []byte(`{"job":"123","pod":"someuid123","app":"foo","_entry":"10.1.0.88 - - [14/Dec/2020:22:56:24 +0000] GET /static/img/about/bob.jpg HTTP/1.1"}`)
+	xmlLine := []byte(`<event><request><method>GET</method><uri>/</uri></request><response><status>200</status></response></event>`)
+	csvLine := []byte(`10.1.0.88,GET,/static/img/about/bob.jpg`)
 
 	lbs := NewBaseLabelsBuilder().ForLabels(labels.EmptyLabels(), 0)
 	hints := newFakeParserHints()
@@ -141,6 +143,8 @@ func TestKeyShortCircuit(t *testing.T) {
 		{"logfmt", logfmtLine, NewLogfmtParser(false, false), labels.MustNewMatcher(labels.MatchEqual, "info", "nope")},
 		{"regex greedy", nginxline, mustStage(NewRegexpParser(`GET (?P<path>.*?)/\?`)), labels.MustNewMatcher(labels.MatchEqual, "path", "nope")},
 		{"pattern", nginxline, mustStage(NewPatternParser(`<_> "<method> <path> <_>"<_>`)), labels.MustNewMatcher(labels.MatchEqual, "method", "nope")},
+		{"xml", xmlLine, NewXMLParser(), labels.MustNewMatcher(labels.MatchEqual, "request_method", "nope")},
+		{"csv", csvLine, mustStage(NewCSVParser([]string{"ip", "method", "path"}, ",")), labels.MustNewMatcher(labels.MatchEqual, "ip", "nope")},
 	} {
 		lbs.Reset()
 		t.Run(tt.name, func(t *testing.T) {
@@ -215,6 +219,12 @@ func (p *fakeParseHints) ShouldExtract(key string) bool {
 func (p *fakeParseHints) ShouldExtractPrefix(prefix string) bool {
 	return prefix == p.label || p.extractAll
 }
+func (p *fakeParseHints) RequiredLabels() []string {
+	if p.extractAll {
+		return nil
+	}
+	return []string{p.label}
+}
 func (p *fakeParseHints) NoLabels() bool {
 	return false
 }
@@ -483,6 +493,43 @@ func TestJSONExpressionParser(t *testing.T) {
 			),
 			noParserHints,
 		},
+		{
+			"array wildcard",
+			[]byte(`{"items":[{"id":"a1","qty":2},{"id":"b\"2"},{"qty":1},{"id":null},{"id":3}]}`),
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("ids", `items[*].id`),
+				NewLabelExtractionExpr("qty", `items[*]["qty"]`),
+			},
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"ids", `a1,b"2,3`,
+				"qty", "2,1",
+			),
+			noParserHints,
+		},
+		{
+			"nested array wildcards with other fields",
+			[]byte(`[{"app":"foo","tags":["a","b"]},{"tags":[{"k":1},"c"]},{"tags":"d"}]`),
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("tags", `[*].tags[*]`),
+				NewLabelExtractionExpr("first", `[0].app`),
+			},
+			labels.EmptyLabels(),
+			labels.FromStrings("first", "foo",
+				"tags", `a,b,{"k":1},c`,
+			),
+			noParserHints,
+		},
+		{
+			"array wildcard without match",
+			testLine,
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("ids", `pod.uuid[*].id`),
+			},
+			labels.EmptyLabels(),
+			labels.FromStrings("ids", ""),
+			noParserHints,
+		},
 	}
 	for _, tt := range tests {
 		j, err := NewJSONExpressionParser(tt.expressions)
@@ -498,6 +545,38 @@ func TestJSONExpressionParser(t *testing.T) {
 	}
 }
 
+func TestJSONArraySplitter(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		expression string
+		line       string
+		want       []string
+		ok         bool
+	}{
+		{"line", "", `[{"user":"foo"}, "b\"ar", 1, null]`, []string{`{"user":"foo"}`, `b"ar`, `1`, `null`}, true},
+		{"empty array", "", `[]`, nil, true},
+		{"path", "events", `{"source":"audit","events":[{"user":"foo"},{"user":"bar"}]}`, []string{`{"user":"foo"}`, `{"user":"bar"}`}, true},
+		{"not an array", "", `{"events":[]}`, nil, false},
+		{"path not found", "records", `{"events":[]}`, nil, false},
+		{"invalid json", "", `[{"user":`, nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewJSONArraySplitter(tt.expression)
+			require.NoError(t, err)
+			elements, ok := s.Split([]byte(tt.line))
+			require.Equal(t, tt.ok, ok)
+			var got []string
+			for _, e := range elements {
+				got = append(got, string(e))
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := NewJSONArraySplitter("events[*]")
+	require.Error(t, err)
+}
+
 func TestJSONExpressionParserFailures(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1360,6 +1439,251 @@ func Test_PatternParser(t *testing.T) {
 	}
 }
 
+func Test_xmlParser_Parse(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  []byte
+		lbs   labels.Labels
+		want  labels.Labels
+		hints ParserHint
+	}{
+		{
+			"multi depth",
+			[]byte(`<?xml version="1.0"?><event id="42"><user type="admin">foo</user><request><method>GET</method><path>/api</path></request></event>`),
+			labels.EmptyLabels(),
+			labels.FromStrings("id", "42",
+				"user", "foo",
+				"user_type", "admin",
+				"request_method", "GET",
+				"request_path", "/api",
+			),
+			noParserHints,
+		},
+		{
+			"root element",
+			[]byte(`<msg level="info"> started </msg>`),
+			labels.EmptyLabels(),
+			labels.FromStrings("level", "info",
+				"msg", "started",
+			),
+			noParserHints,
+		},
+		{
+			"bad key replaced and duplicate extraction",
+			[]byte(`<event><app>foo</app><app-name>bar</app-name></event>`),
+			labels.FromStrings("app", "baz"),
+			labels.FromStrings("app", "baz",
+				"app_extracted", "foo",
+				"app_name", "bar",
+			),
+			noParserHints,
+		},
+		{
+			"hints",
+			[]byte(`<event><user>foo</user><request><method>GET</method><path>/api</path></request></event>`),
+			labels.EmptyLabels(),
+			labels.FromStrings("request_method", "GET"),
+			NewParserHint([]string{"request_method"}, nil, false, true, "", nil),
+		},
+		{
+			"errors",
+			[]byte(`<event><user>foo</event>`),
+			labels.EmptyLabels(),
+			labels.FromStrings("__error__", "XMLParserErr",
+				"__error_details__", "XML syntax error on line 1: element <user> closed by </event>",
+			),
+			noParserHints,
+		},
+	}
+	for _, tt := range tests {
+		x := NewXMLParser()
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = x.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestXMLExpressionParser(t *testing.T) {
+	testLine := []byte(`<event id="42"><users><user role="admin">foo</user><user role="dev">bar</user></users><request><method>GET</method></request></event>`)
+
+	tests := []struct {
+		name        string
+		line        []byte
+		expressions []LabelExtractionExpr
+		lbs         labels.Labels
+		want        labels.Labels
+		hints       ParserHint
+	}{
+		{
+			"paths",
+			testLine,
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("id", "/event/@id"),
+				NewLabelExtractionExpr("method", "event/request/method"),
+				NewLabelExtractionExpr("user", "/event/users/user/text()"),
+			},
+			labels.EmptyLabels(),
+			labels.FromStrings("id", "42",
+				"method", "GET",
+				"user", "foo",
+			),
+			noParserHints,
+		},
+		{
+			"positions and descendants",
+			testLine,
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("second", "//user[2]"),
+				NewLabelExtractionExpr("role", "//users/*[2]/@role"),
+				NewLabelExtractionExpr("request", "/event/request"),
+				NewLabelExtractionExpr("any_id", "//@id"),
+			},
+			labels.EmptyLabels(),
+			labels.FromStrings("any_id", "42",
+				"second", "bar",
+				"role", "dev",
+				"request", "GET",
+			),
+			noParserHints,
+		},
+		{
+			"missing",
+			testLine,
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("status", "/event/response/status"),
+				NewLabelExtractionExpr("id", "/event/@uid"),
+			},
+			labels.FromStrings("id", "1"),
+			labels.FromStrings("id", "1",
+				"id_extracted", "",
+				"status", "",
+			),
+			noParserHints,
+		},
+		{
+			"hints",
+			testLine,
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("id", "/event/@id"),
+				NewLabelExtractionExpr("method", "/event/request/method"),
+			},
+			labels.EmptyLabels(),
+			labels.FromStrings("method", "GET"),
+			NewParserHint([]string{"method"}, nil, false, true, "", nil),
+		},
+	}
+	for _, tt := range tests {
+		x, err := NewXMLExpressionParser(tt.expressions)
+		require.NoError(t, err)
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = x.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestXMLExpressionParserFailures(t *testing.T) {
+	for _, tt := range []struct {
+		expression string
+		error      string
+	}{
+		{"", "empty element name"},
+		{"/event//user", "empty element name"},
+		{"/event/@", "empty attribute name"},
+		{"/event/@id/user", "invalid element name @id"},
+		{"/event/user[0]", "invalid position in user[0]"},
+		{"/event/user[1", "missing closing square bracket in user[1"},
+		{"/@id", "no element in path"},
+	} {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := NewXMLExpressionParser([]LabelExtractionExpr{NewLabelExtractionExpr("label", tt.expression)})
+			require.EqualError(t, err, fmt.Sprintf("cannot parse expression [%s]: %s", tt.expression, tt.error))
+		})
+	}
+}
+
+func Test_csvParser_Parse(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []string
+		delimiter string
+		line      []byte
+		lbs       labels.Labels
+		want      labels.Labels
+		hints     ParserHint
+	}{
+		{
+			"columns",
+			[]string{"ts", "level", "msg"},
+			",",
+			[]byte(`2023-01-01,error,"disk ""sda"" full, 98%"`),
+			labels.EmptyLabels(),
+			labels.FromStrings("ts", "2023-01-01",
+				"level", "error",
+				"msg", `disk "sda" full, 98%`,
+			),
+			noParserHints,
+		},
+		{
+			"tab separated with skipped and missing columns",
+			[]string{"ts", "", "level", "status"},
+			"\t",
+			[]byte("2023-01-01\tdevice-1\twarn\n"),
+			labels.FromStrings("level", "info"),
+			labels.FromStrings("level", "info",
+				"level_extracted", "warn",
+				"status", "",
+				"ts", "2023-01-01",
+			),
+			noParserHints,
+		},
+		{
+			"extra fields",
+			[]string{"a", "b"},
+			";",
+			[]byte(`1;2;3;4`),
+			labels.EmptyLabels(),
+			labels.FromStrings("a", "1", "b", "2"),
+			noParserHints,
+		},
+		{
+			"hints",
+			[]string{"ts", "level", "msg"},
+			",",
+			[]byte(`2023-01-01,error,disk full`),
+			labels.EmptyLabels(),
+			labels.FromStrings("level", "error"),
+			NewParserHint([]string{"level"}, nil, false, true, "", nil),
+		},
+	}
+	for _, tt := range tests {
+		c, err := NewCSVParser(tt.columns, tt.delimiter)
+		require.NoError(t, err)
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = c.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestNewCSVParser(t *testing.T) {
+	_, err := NewCSVParser([]string{"a"}, ",,")
+	require.EqualError(t, err, `invalid delimiter ",,", expected a single character other than a double quote or a new line`)
+	_, err = NewCSVParser([]string{"a"}, `"`)
+	require.Error(t, err)
+	_, err = NewCSVParser([]string{"a-b"}, ",")
+	require.EqualError(t, err, "invalid column label name 'a-b'")
+	_, err = NewCSVParser([]string{"", ""}, ",")
+	require.EqualError(t, err, "no csv column provided")
+}
+
 func BenchmarkJsonExpressionParser(b *testing.B) {
 	simpleJsn := []byte(`{
       "data": "Click Here",
@@ -0,0 +1,200 @@
+package logql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// lookupTableGracePeriod is how long the previous versions of the lookup tables are kept once their files change, so
+// that the requests already referencing them are not failed.
+const lookupTableGracePeriod = 10 * time.Minute
+
+// LookupTableLimits returns the paths of the CSV files of the lookup tables of the tenants, by name.
+type LookupTableLimits interface {
+	LookupTables(context.Context, string) map[string]string
+}
+
+// LookupTables loads the lookup tables of the tenants for the queriers, the ingesters and the query-frontends. The
+// requests only reference the tables by name and version, which are resolved with Load or Resolve.
+//
+// The CSV files of the tables are cached by path, and reloaded when their modification time or size change.
+type LookupTables struct {
+	limits      LookupTableLimits
+	gracePeriod time.Duration
+	now         func() time.Time
+
+	mtx   sync.Mutex
+	files map[string]*lookupFile
+	// previous holds the replaced versions of the files by path, until the end of the grace period.
+	previous map[string][]replacedLookupFile
+}
+
+type lookupFile struct {
+	modTime time.Time
+	size    int64
+	// version is the hash of the content of the file.
+	version string
+	table   *log.LookupTable
+}
+
+type replacedLookupFile struct {
+	*lookupFile
+	replacedAt time.Time
+}
+
+// NewLookupTables creates the lookup tables of the tenants configured by limits.
+func NewLookupTables(limits LookupTableLimits) *LookupTables {
+	return &LookupTables{
+		limits:      limits,
+		gracePeriod: lookupTableGracePeriod,
+		now:         time.Now,
+		files:       map[string]*lookupFile{},
+		previous:    map[string][]replacedLookupFile{},
+	}
+}
+
+// load returns the CSV file at path. The file is read without holding the lock, so that the other requests are not
+// blocked while it is reloaded.
+func (t *LookupTables) load(path string) (*lookupFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mtx.Lock()
+	f, ok := t.files[path]
+	t.mtx.Unlock()
+	if ok && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+		return f, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	table, err := log.ReadLookupTable(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	loaded := &lookupFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		version: strconv.FormatUint(xxhash.Sum64(content), 16),
+		table:   table,
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	now := t.now()
+	if current, ok := t.files[path]; ok && current.version != loaded.version {
+		t.previous[path] = append(t.previous[path], replacedLookupFile{lookupFile: current, replacedAt: now})
+	}
+	t.files[path] = loaded
+	t.expire(path, now)
+	return loaded, nil
+}
+
+// expire removes the previous versions of the file at path replaced before the grace period.
+func (t *LookupTables) expire(path string, now time.Time) {
+	previous := t.previous[path][:0]
+	for _, f := range t.previous[path] {
+		if now.Sub(f.replacedAt) < t.gracePeriod {
+			previous = append(previous, f)
+		}
+	}
+	if len(previous) == 0 {
+		delete(t.previous, path)
+		return
+	}
+	t.previous[path] = previous
+}
+
+// previousVersion returns the previous version of the file at path, if it was replaced during the grace period.
+func (t *LookupTables) previousVersion(path, version string) (*lookupFile, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	now := t.now()
+	for _, f := range t.previous[path] {
+		if f.version == version && now.Sub(f.replacedAt) < t.gracePeriod {
+			return f.lookupFile, true
+		}
+	}
+	return nil, false
+}
+
+// path returns the path of the CSV file of the lookup table of the tenant named name.
+func (t *LookupTables) path(ctx context.Context, userID, name string) (string, error) {
+	path, ok := t.limits.LookupTables(ctx, userID)[name]
+	if !ok {
+		return "", httpgrpc.Errorf(http.StatusBadRequest, "lookup table %s is not configured", name)
+	}
+	return path, nil
+}
+
+// Load loads the lookup tables of the tenant used by the lookup stages of the expression, and returns their references
+// sent along with the requests, and the tables by name.
+func (t *LookupTables) Load(ctx context.Context, userID string, expr syntax.Expr) ([]*logproto.LookupTable, map[string]*log.LookupTable, error) {
+	names := syntax.LookupTableNames(expr)
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+
+	refs := make([]*logproto.LookupTable, 0, len(names))
+	tables := make(map[string]*log.LookupTable, len(names))
+	for _, name := range names {
+		path, err := t.path(ctx, userID, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		f, err := t.load(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading lookup table %s: %w", name, err)
+		}
+		refs = append(refs, &logproto.LookupTable{Name: name, Version: f.version})
+		tables[name] = f.table
+	}
+	return refs, tables, nil
+}
+
+// Resolve loads the lookup tables of the tenant referenced by a request, and returns them by name. The versions of the
+// tables must be the current ones, or the previous ones replaced during the grace period: the queriers and the
+// ingesters must load the tables from the same files.
+func (t *LookupTables) Resolve(ctx context.Context, userID string, refs []*logproto.LookupTable) (map[string]*log.LookupTable, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	tables := make(map[string]*log.LookupTable, len(refs))
+	for _, ref := range refs {
+		path, err := t.path(ctx, userID, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := t.load(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading lookup table %s: %w", ref.Name, err)
+		}
+		if f.version != ref.Version {
+			previous, ok := t.previousVersion(path, ref.Version)
+			if !ok {
+				return nil, fmt.Errorf("lookup table %s has version %s instead of version %s: the lookup tables differ between the queriers and the ingesters", ref.Name, f.version, ref.Version)
+			}
+			f = previous
+		}
+		tables[ref.Name] = f.table
+	}
+	return tables, nil
+}
@@ -0,0 +1,100 @@
+package logql
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+type lookupTableLimits map[string]string
+
+func (l lookupTableLimits) LookupTables(_ context.Context, _ string) map[string]string {
+	return l
+}
+
+func Test_lookupTablesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\n"), 0o644))
+
+	now := time.Unix(0, 0)
+	tables := NewLookupTables(lookupTableLimits{"owners": path})
+	tables.now = func() time.Time { return now }
+
+	f, err := tables.load(path)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"api", "platform"}}, f.table.Rows())
+
+	cached, err := tables.load(path)
+	require.NoError(t, err)
+	require.Same(t, f, cached)
+
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\nbilling,payments\n"), 0o644))
+	reloaded, err := tables.load(path)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"api", "platform"}, {"billing", "payments"}}, reloaded.table.Rows())
+	require.NotEqual(t, f.version, reloaded.version)
+
+	// the previous version is kept for the grace period.
+	previous, ok := tables.previousVersion(path, f.version)
+	require.True(t, ok)
+	require.Same(t, f, previous)
+
+	now = now.Add(lookupTableGracePeriod)
+	_, ok = tables.previousVersion(path, f.version)
+	require.False(t, ok)
+
+	require.NoError(t, os.Remove(path))
+	_, err = tables.load(path)
+	require.Error(t, err)
+}
+
+func TestLookupTables(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "owners.csv")
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\n"), 0o644))
+	limits := lookupTableLimits{"owners": path}
+	queriers, ingesters := NewLookupTables(limits), NewLookupTables(limits)
+	ctx := context.Background()
+
+	req := &logproto.QueryRequest{Selector: `{app="foo"} | logfmt | lookup "owners" on service`}
+	expr, err := syntax.ParseExpr(req.Selector)
+	require.NoError(t, err)
+	req.LookupTables, _, err = queriers.Load(ctx, "tenant", expr)
+	require.NoError(t, err)
+	resolved, err := ingesters.Resolve(ctx, "tenant", req.LookupTables)
+	require.NoError(t, err)
+	require.Contains(t, resolved, "owners")
+
+	// the tables referenced by the requests are set to the lookup stages.
+	selector, err := SelectLogParams{QueryRequest: req, ResolvedLookupTables: resolved}.LogSelector()
+	require.NoError(t, err)
+	_, err = selector.Pipeline()
+	require.NoError(t, err)
+
+	// the requests referencing the previous version of a table are still resolved once it changes.
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,payments\nbilling,payments\n"), 0o644))
+	previous, err := ingesters.Resolve(ctx, "tenant", req.LookupTables)
+	require.NoError(t, err)
+	require.Same(t, resolved["owners"], previous["owners"])
+
+	// the ingesters loading another table fail the requests.
+	other := filepath.Join(dir, "other.csv")
+	require.NoError(t, os.WriteFile(other, []byte("service,team\napi,storage\n"), 0o644))
+	_, err = NewLookupTables(lookupTableLimits{"owners": other}).Resolve(ctx, "tenant", req.LookupTables)
+	require.Error(t, err)
+
+	_, err = SelectLogParams{QueryRequest: req}.LogSelector()
+	require.EqualError(t, err, "lookup table owners: version "+req.LookupTables[0].Version+" is not loaded")
+
+	_, _, err = NewLookupTables(lookupTableLimits{}).Load(ctx, "tenant", expr)
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "lookup table owners is not configured"), err)
+}
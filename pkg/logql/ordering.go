@@ -0,0 +1,189 @@
+package logql
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/logqlmodel"
+)
+
+// logOrdering orders the log lines of a log query by its sort stage, or by timestamp in the direction of the query,
+// and keeps the first or the last of them with its head or tail stage.
+type logOrdering struct {
+	sort      *syntax.SortExpr
+	limit     *syntax.LimitExpr
+	direction logproto.Direction
+
+	// values caches the values of the sort label, by labels of the streams.
+	values map[string]sortValue
+}
+
+// newLogOrdering returns the ordering of the log query, nil if it has no sort, head or tail stage.
+func newLogOrdering(expr syntax.LogSelectorExpr, direction logproto.Direction) *logOrdering {
+	sortExpr, limitExpr := syntax.LogOrdering(expr)
+	if sortExpr == nil && limitExpr == nil {
+		return nil
+	}
+	return &logOrdering{
+		sort:      sortExpr,
+		limit:     limitExpr,
+		direction: direction,
+		values:    map[string]sortValue{},
+	}
+}
+
+// NewOrderedEntryIterator returns an iterator over the first log lines of the log query ordered by its sort, head or
+// tail stages, up to the limit of the query. The log lines are iterated by timestamp, so the iterator can be merged
+// with other iterators. The iterator is returned as is if the query has no such stages.
+func NewOrderedEntryIterator(it iter.EntryIterator, expr syntax.LogSelectorExpr, direction logproto.Direction, limit uint32) iter.EntryIterator {
+	o := newLogOrdering(expr, direction)
+	if o == nil {
+		return it
+	}
+	return o.iterator(it, limit)
+}
+
+// MergeOrderedStreams merges the streams of the results of a log query with sort, head or tail stages, e.g. the results
+// of its split queries, and keeps the first log lines ordered by these stages up to the limit of the query.
+// The streams are returned in the order of the sort stage.
+func MergeOrderedStreams(expr syntax.LogSelectorExpr, streams []logproto.Stream, direction logproto.Direction, limit uint32) []logproto.Stream {
+	o := newLogOrdering(expr, direction)
+	if o == nil {
+		o = &logOrdering{direction: direction}
+	}
+	if limit == 0 {
+		limit = math.MaxUint32
+	}
+	// the iterators of the streams never fail.
+	result, _ := readStreams(o.iterator(iter.NewStreamsIterator(streams, direction), limit), limit, direction, 0)
+	o.sortStreams(result)
+	return result
+}
+
+func (o *logOrdering) iterator(it iter.EntryIterator, limit uint32) iter.EntryIterator {
+	k := int(limit)
+	if limit == 0 || limit > math.MaxInt32 {
+		k = math.MaxInt32
+	}
+	if o.limit != nil && o.limit.Limit < k {
+		k = o.limit.Limit
+	}
+	less := o.less
+	if o.limit != nil && o.limit.Op == syntax.OpTail {
+		less = func(aLabels string, a logproto.Entry, bLabels string, b logproto.Entry) bool {
+			return o.less(bLabels, b, aLabels, a)
+		}
+	}
+	return iter.NewTopKEntryIterator(it, k, less, o.direction)
+}
+
+// less orders the log lines by the values of the sort label, then by timestamp in the direction of the query.
+func (o *logOrdering) less(aLabels string, a logproto.Entry, bLabels string, b logproto.Entry) bool {
+	if o.sort != nil {
+		if c := o.compare(aLabels, bLabels); c != 0 {
+			return c < 0
+		}
+	}
+	if !a.Timestamp.Equal(b.Timestamp) {
+		if o.direction == logproto.BACKWARD {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return aLabels < bLabels
+}
+
+// sortStreams orders the streams by the values of the sort label. Since the label is a label of the streams, all
+// the log lines of a stream have the same value.
+func (o *logOrdering) sortStreams(streams logqlmodel.Streams) {
+	if o.sort == nil {
+		return
+	}
+	sort.SliceStable(streams, func(i, j int) bool {
+		return o.compare(streams[i].Labels, streams[j].Labels) < 0
+	})
+}
+
+// compare compares the values of the sort label of the streams, in the order of the sort stage.
+// The streams without the label are always last.
+func (o *logOrdering) compare(aLabels, bLabels string) int {
+	a, b := o.value(aLabels), o.value(bLabels)
+	switch {
+	case !a.ok && !b.ok:
+		return 0
+	case !a.ok:
+		return 1
+	case !b.ok:
+		return -1
+	}
+	c := a.compare(b)
+	if o.sort.Desc {
+		return -c
+	}
+	return c
+}
+
+func (o *logOrdering) value(lbs string) sortValue {
+	if v, ok := o.values[lbs]; ok {
+		return v
+	}
+	var v sortValue
+	if metric, err := syntax.ParseLabels(lbs); err == nil {
+		v = newSortValue(metric, o.sort.Label)
+	}
+	o.values[lbs] = v
+	return v
+}
+
+// sortValue is the value of the sort label of a stream. The numbers, durations and bytes are compared by value and
+// ordered before the other values, which are compared as strings.
+type sortValue struct {
+	ok       bool
+	isNumber bool
+	number   float64
+	str      string
+}
+
+func newSortValue(lbs labels.Labels, name string) sortValue {
+	str := lbs.Get(name)
+	if str == "" {
+		return sortValue{}
+	}
+	v := sortValue{ok: true, str: str}
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		v.isNumber, v.number = true, f
+	} else if d, err := time.ParseDuration(str); err == nil {
+		v.isNumber, v.number = true, d.Seconds()
+	} else if b, err := humanize.ParseBytes(str); err == nil {
+		v.isNumber, v.number = true, float64(b)
+	}
+	return v
+}
+
+func (v sortValue) compare(other sortValue) int {
+	switch {
+	case v.isNumber && other.isNumber:
+		switch {
+		case v.number < other.number:
+			return -1
+		case v.number > other.number:
+			return 1
+		}
+		return 0
+	case v.isNumber:
+		return -1
+	case other.isNumber:
+		return 1
+	default:
+		return strings.Compare(v.str, other.str)
+	}
+}
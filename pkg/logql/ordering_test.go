@@ -0,0 +1,209 @@
+package logql
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/logqlmodel"
+)
+
+func Test_sortValue(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1.5", "1.5", 0},
+		{"1.5s", "200ms", 1},
+		{"1m", "59s", 1},
+		{"1KB", "999B", 1},
+		{"5", "abc", -1},
+		{"abc", "5", 1},
+		{"abc", "abd", -1},
+	} {
+		t.Run(fmt.Sprintf("%s %s", tc.a, tc.b), func(t *testing.T) {
+			a := newSortValue(mustParseLabels(`{v="`+tc.a+`"}`), "v")
+			b := newSortValue(mustParseLabels(`{v="`+tc.b+`"}`), "v")
+			require.Equal(t, tc.expected, a.compare(b))
+		})
+	}
+}
+
+func TestEngine_OrderedLogQuery(t *testing.T) {
+	streams := []logproto.Stream{
+		{
+			Labels: `{app="foo", duration="2s"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "a"},
+				{Timestamp: time.Unix(4, 0), Line: "b"},
+			},
+		},
+		{
+			Labels: `{app="foo", duration="500ms"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(2, 0), Line: "c"},
+			},
+		},
+		{
+			Labels: `{app="foo", duration="1m"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(3, 0), Line: "d"},
+			},
+		},
+		{
+			Labels: `{app="foo"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(5, 0), Line: "e"},
+			},
+		},
+	}
+	stream := func(lbs string, entries ...logproto.Entry) logproto.Stream {
+		return logproto.Stream{Labels: lbs, Entries: entries}
+	}
+
+	for _, tc := range []struct {
+		query     string
+		direction logproto.Direction
+		limit     uint32
+		expected  logqlmodel.Streams
+	}{
+		{
+			`{app="foo"} | sort by duration desc`, logproto.FORWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="1m"}`, streams[2].Entries...),
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries...),
+				stream(`{app="foo", duration="500ms"}`, streams[1].Entries...),
+				stream(`{app="foo"}`, streams[3].Entries...),
+			},
+		},
+		{
+			`{app="foo"} | sort by duration desc | head 2`, logproto.FORWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="1m"}`, streams[2].Entries...),
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries[0]),
+			},
+		},
+		{
+			`{app="foo"} | sort by duration desc | head 2`, logproto.BACKWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="1m"}`, streams[2].Entries...),
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries[1]),
+			},
+		},
+		{
+			// the limit of the query applies too.
+			`{app="foo"} | sort by duration | head 10`, logproto.FORWARD, 2,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="500ms"}`, streams[1].Entries...),
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries[0]),
+			},
+		},
+		{
+			`{app="foo"} | sort by duration | tail 2`, logproto.FORWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="1m"}`, streams[2].Entries...),
+				stream(`{app="foo"}`, streams[3].Entries...),
+			},
+		},
+		{
+			`{app="foo"} | head 2`, logproto.FORWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries[0]),
+				stream(`{app="foo", duration="500ms"}`, streams[1].Entries...),
+			},
+		},
+		{
+			`{app="foo"} | tail 2`, logproto.FORWARD, 100,
+			logqlmodel.Streams{
+				stream(`{app="foo", duration="2s"}`, streams[0].Entries[1]),
+				stream(`{app="foo"}`, streams[3].Entries...),
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("%s %s %d", tc.query, tc.direction, tc.limit), func(t *testing.T) {
+			eng := NewEngine(EngineOpts{}, NewMockQuerier(1, streams), NoLimits, log.NewNopLogger())
+			params := NewLiteralParams(tc.query, time.Unix(0, 0), time.Unix(10, 0), 0, 0, tc.direction, tc.limit, nil)
+			res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res.Data)
+		})
+	}
+}
+
+func TestMergeOrderedStreams(t *testing.T) {
+	expr, err := syntax.ParseLogSelector(`{app="foo"} | logfmt | sort by latency desc | head 3`, true)
+	require.NoError(t, err)
+
+	// the streams of the results of two split queries.
+	streams := []logproto.Stream{
+		{Labels: `{app="foo", latency="10"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "latency=10"}}},
+		{Labels: `{app="foo", latency="30"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(2, 0), Line: "latency=30"}}},
+		{Labels: `{app="foo", latency="10"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(11, 0), Line: "latency=10"}}},
+		{Labels: `{app="foo", latency="20"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(12, 0), Line: "latency=20"}}},
+	}
+	require.Equal(t, []logproto.Stream{
+		{Labels: `{app="foo", latency="30"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(2, 0), Line: "latency=30"}}},
+		{Labels: `{app="foo", latency="20"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(12, 0), Line: "latency=20"}}},
+		{Labels: `{app="foo", latency="10"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "latency=10"}}},
+	}, MergeOrderedStreams(expr, streams, logproto.FORWARD, 100))
+}
+
+func TestOrderedLogQueryShardingEquivalence(t *testing.T) {
+	var (
+		shards   = 3
+		nStreams = 30
+		rounds   = 20
+		streams  = randomStreams(nStreams, rounds+1, shards, []string{"a", "b"})
+		start    = time.Unix(0, 0)
+		end      = time.Unix(0, int64(time.Second*time.Duration(rounds)))
+		limit    = 100
+	)
+	rnd := rand.New(rand.NewSource(42))
+	for i := range streams {
+		for j := range streams[i].Entries {
+			streams[i].Entries[j].Line = fmt.Sprintf("latency=%d", rnd.Intn(1000))
+		}
+	}
+
+	for _, query := range []string{
+		`{a=~".+"} | logfmt | sort by latency desc | head 20`,
+		`{a=~".+"} | logfmt | sort by latency | tail 5`,
+		`{a=~".+"} | logfmt | sort by latency`,
+		`{a=~".+"} | head 7`,
+		`{a=~".+"} | tail 7`,
+	} {
+		q := NewMockQuerier(shards, streams)
+
+		opts := EngineOpts{}
+		regular := NewEngine(opts, q, NoLimits, log.NewNopLogger())
+		sharded := NewDownstreamEngine(opts, MockDownstreamer{regular}, NoLimits, log.NewNopLogger())
+
+		t.Run(query, func(t *testing.T) {
+			params := NewLiteralParams(query, start, end, 0, 0, logproto.BACKWARD, uint32(limit), nil)
+			ctx := user.InjectOrgID(context.Background(), "fake")
+
+			mapper := NewShardMapper(ConstantShards(shards), nilShardMetrics, true)
+			noop, _, mapped, err := mapper.Parse(query)
+			require.NoError(t, err)
+			require.False(t, noop)
+
+			res, err := regular.Query(params).Exec(ctx)
+			require.NoError(t, err)
+
+			shardedRes, err := sharded.Query(ctx, params, mapped).Exec(ctx)
+			require.NoError(t, err)
+
+			require.Equal(t, res.Data, shardedRes.Data)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logql/vector"
+	"github.com/grafana/loki/pkg/util/hll"
 )
 
 // BatchRangeVectorAggregator aggregates samples for a given range of samples.
@@ -51,6 +52,12 @@ func newRangeVectorIterator(
 		start = start - offset
 		end = end - offset
 	}
+	switch expr.Operation {
+	case syntax.OpRangeTypeHistogram:
+		it = newHistogramBucketIterator(it)
+	case syntax.OpRangeTypeHLL:
+		it = newHLLRegisterIterator(it)
+	}
 	var overlap bool
 	if selRange >= step && start != end {
 		overlap = true
@@ -226,7 +233,7 @@ func aggregator(r *syntax.RangeAggregationExpr) (BatchRangeVectorAggregator, err
 		return rateLogs(r.Left.Interval, r.Left.Unwrap != nil), nil
 	case syntax.OpRangeTypeRateCounter:
 		return rateCounter(r.Left.Interval), nil
-	case syntax.OpRangeTypeCount:
+	case syntax.OpRangeTypeCount, syntax.OpRangeTypeHistogram:
 		return countOverTime, nil
 	case syntax.OpRangeTypeBytesRate:
 		return rateLogBytes(r.Left.Interval), nil
@@ -234,7 +241,7 @@ func aggregator(r *syntax.RangeAggregationExpr) (BatchRangeVectorAggregator, err
 		return sumOverTime, nil
 	case syntax.OpRangeTypeAvg:
 		return avgOverTime, nil
-	case syntax.OpRangeTypeMax:
+	case syntax.OpRangeTypeMax, syntax.OpRangeTypeHLL:
 		return maxOverTime, nil
 	case syntax.OpRangeTypeMin:
 		return minOverTime, nil
@@ -250,6 +257,8 @@ func aggregator(r *syntax.RangeAggregationExpr) (BatchRangeVectorAggregator, err
 		return last, nil
 	case syntax.OpRangeTypeAbsent:
 		return one, nil
+	case syntax.OpRangeTypeCountDistinct:
+		return countDistinctOverTime, nil
 	default:
 		return nil, fmt.Errorf(syntax.UnsupportedErr, r.Operation)
 	}
@@ -601,7 +610,8 @@ func streamingAggregator(r *syntax.RangeAggregationExpr) (RangeStreamingAgg, err
 		return newRateLogs(r.Left.Interval, r.Left.Unwrap != nil), nil
 	case syntax.OpRangeTypeRateCounter:
 		return &RateCounterOverTime{selRange: r.Left.Interval, samples: make([]promql.FPoint, 0)}, nil
-	case syntax.OpRangeTypeCount:
+	case syntax.OpRangeTypeCount, syntax.OpRangeTypeHistogram:
+		// the samples of histogram_over_time are counted in their buckets by the histogramBucketIterator.
 		return &CountOverTime{}, nil
 	case syntax.OpRangeTypeBytesRate:
 		return &RateLogBytesOverTime{selRange: r.Left.Interval}, nil
@@ -609,7 +619,8 @@ func streamingAggregator(r *syntax.RangeAggregationExpr) (RangeStreamingAgg, err
 		return &SumOverTime{}, nil
 	case syntax.OpRangeTypeAvg:
 		return &AvgOverTime{}, nil
-	case syntax.OpRangeTypeMax:
+	case syntax.OpRangeTypeMax, syntax.OpRangeTypeHLL:
+		// the ranks of the registers of hll_over_time are set by the hllRegisterIterator.
 		return &MaxOverTime{max: math.NaN()}, nil
 	case syntax.OpRangeTypeMin:
 		return &MinOverTime{min: math.NaN()}, nil
@@ -625,6 +636,8 @@ func streamingAggregator(r *syntax.RangeAggregationExpr) (RangeStreamingAgg, err
 		return &LastOverTime{}, nil
 	case syntax.OpRangeTypeAbsent:
 		return &OneOverTime{}, nil
+	case syntax.OpRangeTypeCountDistinct:
+		return &CountDistinctOverTime{}, nil
 	default:
 		return nil, fmt.Errorf(syntax.UnsupportedErr, r.Operation)
 	}
@@ -841,6 +854,18 @@ func (a *LastOverTime) at() float64 {
 	return a.v
 }
 
+type CountDistinctOverTime struct {
+	sketch hll.Sketch
+}
+
+func (a *CountDistinctOverTime) agg(sample promql.FPoint) {
+	hllInsert(&a.sketch, sample.F)
+}
+
+func (a *CountDistinctOverTime) at() float64 {
+	return a.sketch.Count()
+}
+
 type OneOverTime struct {
 }
 
@@ -22,6 +22,8 @@ var splittableVectorOp = map[string]struct{}{
 	syntax.OpTypeTopK:     {},
 	syntax.OpTypeSort:     {},
 	syntax.OpTypeSortDesc: {},
+
+	syntax.OpTypeApproxCountDistinct: {},
 }
 
 var splittableRangeVectorOp = map[string]struct{}{
@@ -32,6 +34,19 @@ var splittableRangeVectorOp = map[string]struct{}{
 	syntax.OpRangeTypeSum:       {},
 	syntax.OpRangeTypeMax:       {},
 	syntax.OpRangeTypeMin:       {},
+	syntax.OpRangeTypeHistogram: {},
+	syntax.OpRangeTypeHLL:       {},
+
+	syntax.OpRangeTypeCountDistinct: {},
+}
+
+// splittableSubqueryOp are the range aggregations of the subqueries whose results over the full range are the
+// aggregation of their results over the split ranges.
+var splittableSubqueryOp = map[string]string{
+	syntax.OpRangeTypeSum:   syntax.OpTypeSum,
+	syntax.OpRangeTypeCount: syntax.OpTypeSum,
+	syntax.OpRangeTypeMax:   syntax.OpTypeMax,
+	syntax.OpRangeTypeMin:   syntax.OpTypeMin,
 }
 
 // RangeMapper is used to rewrite LogQL sample expressions into multiple
@@ -53,6 +68,9 @@ var splittableRangeVectorOp = map[string]struct{}{
 //     either with or without grouping.
 //  5. Left and right-hand side of binary operations are split individually
 //     using the same rules as above.
+//  6. Subqueries are split into multiple downstream subqueries with a smaller
+//     range that are merged with the vector aggregation matching the range
+//     aggregation of the subquery. The inner query of the subquery is not split.
 type RangeMapper struct {
 	splitByInterval time.Duration
 	metrics         *MapperMetrics
@@ -126,6 +144,8 @@ func (m RangeMapper) Map(expr syntax.SampleExpr, vectorAggrPushdown *syntax.Vect
 		return m.mapVectorAggregationExpr(e, recorder)
 	case *syntax.RangeAggregationExpr:
 		return m.mapRangeAggregationExpr(e, vectorAggrPushdown, recorder), nil
+	case *syntax.SubqueryExpr:
+		return m.mapSubqueryExpr(e, vectorAggrPushdown, recorder), nil
 	case *syntax.BinOpExpr:
 		lhsMapped, err := m.Map(e.SampleExpr, vectorAggrPushdown, recorder)
 		if err != nil {
@@ -161,6 +181,14 @@ func (m RangeMapper) Map(expr syntax.SampleExpr, vectorAggrPushdown *syntax.Vect
 		}
 		e.Left = lhsMapped
 		return e, nil
+	case *syntax.HistogramQuantileExpr:
+		// the outer vector aggregation can't be pushed down to the histograms, since it is applied to the quantiles.
+		lhsMapped, err := m.Map(e.Left, nil, recorder)
+		if err != nil {
+			return nil, err
+		}
+		e.Left = lhsMapped
+		return e, nil
 	case *syntax.LiteralExpr:
 		return e, nil
 	case *syntax.VectorExpr:
@@ -174,11 +202,20 @@ func (m RangeMapper) Map(expr syntax.SampleExpr, vectorAggrPushdown *syntax.Vect
 // getRangeInterval returns the interval in the range vector
 // Note that this function must not be called with a BinOpExpr as argument
 // as it returns only the range of the RHS.
+// The range of a subquery is returned instead of the ranges of its inner query, which are not split.
 // Example: expression `count_over_time({app="foo"}[10m])` returns 10m
+// Example: expression `max_over_time(rate({app="foo"}[1m])[1h:1m])` returns 1h
 func getRangeInterval(expr syntax.SampleExpr) time.Duration {
 	var rangeInterval time.Duration
+	var subquery bool
 	expr.Walk(func(e interface{}) {
+		if subquery {
+			return
+		}
 		switch concrete := e.(type) {
+		case *syntax.SubqueryExpr:
+			rangeInterval = concrete.Range
+			subquery = true
 		case *syntax.RangeAggregationExpr:
 			rangeInterval = concrete.Left.Interval
 		}
@@ -262,6 +299,10 @@ func (m RangeMapper) vectorAggrWithRangeDownstreams(expr *syntax.RangeAggregatio
 			Without: true,
 			Groups:  []string{},
 		}
+	} else if expr.Operation == syntax.OpRangeTypeHistogram {
+		grouping = histogramGrouping(expr.Grouping)
+	} else if expr.Operation == syntax.OpRangeTypeHLL {
+		grouping = hllGrouping(expr.Grouping)
 	}
 	var downstream syntax.SampleExpr = expr
 	if vectorAggrPushdown != nil {
@@ -417,7 +458,7 @@ func (m RangeMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 	switch expr.Operation {
 	case syntax.OpRangeTypeSum:
 		return m.vectorAggrWithRangeDownstreams(expr, vectorAggrPushdown, syntax.OpTypeSum, rangeInterval, recorder)
-	case syntax.OpRangeTypeBytes, syntax.OpRangeTypeCount:
+	case syntax.OpRangeTypeBytes, syntax.OpRangeTypeCount, syntax.OpRangeTypeHistogram:
 		// Downstream queries with label extractors use concat as aggregation operator instead of sum
 		// in order to merge the resultant label sets
 		if labelExtractor {
@@ -432,6 +473,35 @@ func (m RangeMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 		return m.vectorAggrWithRangeDownstreams(expr, vectorAggrPushdown, syntax.OpTypeMax, rangeInterval, recorder)
 	case syntax.OpRangeTypeMin:
 		return m.vectorAggrWithRangeDownstreams(expr, vectorAggrPushdown, syntax.OpTypeMin, rangeInterval, recorder)
+	case syntax.OpRangeTypeHLL:
+		// The registers of the sketches are merged with max, so only the grouping of approx_count_distinct, which
+		// merges the sketches, can be pushed down to the sketches of the downstream queries.
+		// approx_count_distinct by (a) (hll_over_time(x [2m]))
+		// => approx_count_distinct by (a) (max by (a, hll_register) (hll_over_time(x [1m]) by (a) ++ hll_over_time(x [1m] offset 1m) by (a)))
+		if vectorAggrPushdown != nil && vectorAggrPushdown.Operation == syntax.OpTypeApproxCountDistinct && expr.Grouping == nil &&
+			!noopGrouping(vectorAggrPushdown.Grouping) {
+			cpy := *expr
+			cpy.Grouping = vectorAggrPushdown.Grouping
+			expr = &cpy
+		}
+		return m.vectorAggrWithRangeDownstreams(expr, nil, syntax.OpTypeMax, rangeInterval, recorder)
+	case syntax.OpRangeTypeCountDistinct:
+		// The distinct values are counted from the sketches merged across the split ranges.
+		// count_distinct_over_time(x [2m]) by (a)
+		// => approx_count_distinct by (a) (hll_over_time(x [1m]) by (a) ++ hll_over_time(x [1m] offset 1m) by (a))
+		grouping := expr.Grouping
+		if grouping == nil {
+			grouping = &syntax.Grouping{Without: true, Groups: []string{}}
+		}
+		return &syntax.VectorAggregationExpr{
+			Left: m.mapConcatSampleExpr(&syntax.RangeAggregationExpr{
+				Left:      expr.Left,
+				Operation: syntax.OpRangeTypeHLL,
+				Grouping:  expr.Grouping,
+			}, rangeInterval, recorder),
+			Grouping:  grouping,
+			Operation: syntax.OpTypeApproxCountDistinct,
+		}
 	case syntax.OpRangeTypeRate:
 		if labelExtractor && vectorAggrPushdown.Operation != syntax.OpTypeSum {
 			return expr
@@ -461,12 +531,71 @@ func (m RangeMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 	}
 }
 
+// mapSubqueryExpr maps expr into a vector aggregation of multiple downstream subqueries split by range interval.
+// The outer vector aggregation is pushed down to the downstream subqueries only if it is the same as the one merging
+// them, since the other ones can't be applied to the partial results of the subqueries.
+// Example:
+// max_over_time(rate({app="foo"}[1m])[2h:1m])
+// => max without () (max_over_time(rate({app="foo"}[1m])[1h:1m] offset 1h) ++ max_over_time(rate({app="foo"}[1m])[1h:1m]))
+func (m RangeMapper) mapSubqueryExpr(expr *syntax.SubqueryExpr, vectorAggrPushdown *syntax.VectorAggregationExpr, recorder *downstreamRecorder) syntax.SampleExpr {
+	op, ok := splittableSubqueryOp[expr.Operation]
+	if !ok || expr.Range <= m.splitByInterval {
+		return expr
+	}
+	if vectorAggrPushdown != nil && vectorAggrPushdown.Operation != op {
+		vectorAggrPushdown = nil
+	}
+
+	splitCount := int(math.Ceil(float64(expr.Range) / float64(m.splitByInterval)))
+	var downstreams *ConcatSampleExpr
+	for split := 0; split < splitCount; split++ {
+		splitOffset := time.Duration(split) * m.splitByInterval
+		// The range of the last downstream subquery can be smaller than the split interval
+		splitRange := m.splitByInterval
+		if splitOffset+splitRange > expr.Range {
+			splitRange = expr.Range - splitOffset
+		}
+		subquery := clone(expr).(*syntax.SubqueryExpr)
+		subquery.Range = splitRange
+		subquery.Offset = expr.Offset + splitOffset
+
+		var downstream syntax.SampleExpr = subquery
+		if vectorAggrPushdown != nil {
+			downstream = &syntax.VectorAggregationExpr{
+				Left:      subquery,
+				Grouping:  vectorAggrPushdown.Grouping,
+				Params:    vectorAggrPushdown.Params,
+				Operation: vectorAggrPushdown.Operation,
+			}
+		}
+		downstreams = &ConcatSampleExpr{
+			DownstreamSampleExpr: DownstreamSampleExpr{
+				SampleExpr: downstream,
+			},
+			next: downstreams,
+		}
+	}
+
+	// Update stats and metrics
+	m.stats.AddSplitQueries(splitCount)
+	recorder.Add(splitCount, MetricsKey)
+
+	return &syntax.VectorAggregationExpr{
+		Left: downstreams,
+		Grouping: &syntax.Grouping{
+			Without: true,
+			Groups:  []string{},
+		},
+		Operation: op,
+	}
+}
+
 // isSplittableByRange returns whether it is possible to optimize the given
 // sample expression.
 // A vector aggregation is splittable, if the aggregation operation is
 // supported and the inner expression is also splittable.
-// A range aggregation is splittable, if the aggregation operation is
-// supported.
+// A range aggregation or a subquery is splittable, if the aggregation
+// operation is supported.
 // A binary expression is splittable, if both the left and the right-hand side
 // are splittable.
 func isSplittableByRange(expr syntax.SampleExpr) bool {
@@ -483,8 +612,13 @@ func isSplittableByRange(expr syntax.SampleExpr) bool {
 		// Note: if both left-hand side and right-hand side are literal expressions,
 		// the syntax.ParseSampleExpr returns a literal expression
 		return isSplittableByRange(e.SampleExpr) || literalLHS && isSplittableByRange(e.RHS) || literalRHS
+	case *syntax.SubqueryExpr:
+		_, ok := splittableSubqueryOp[e.Operation]
+		return ok
 	case *syntax.LabelReplaceExpr:
 		return isSplittableByRange(e.Left)
+	case *syntax.HistogramQuantileExpr:
+		return isSplittableByRange(e.Left)
 	case *syntax.VectorExpr:
 		return false
 	default:
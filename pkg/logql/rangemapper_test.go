@@ -1732,6 +1732,89 @@ func Test_SplitRangeVectorMapping(t *testing.T) {
 			)`,
 			3,
 		},
+
+		// subqueries
+		{
+			`max_over_time(rate({app="foo"}[1m])[150s:30s])`,
+			`max without () (
+				downstream<max_over_time(rate({app="foo"}[1m])[30s:30s] offset 2m0s), shard=<nil>>
+				++ downstream<max_over_time(rate({app="foo"}[1m])[1m:30s] offset 1m0s), shard=<nil>>
+				++ downstream<max_over_time(rate({app="foo"}[1m])[1m:30s]), shard=<nil>>
+			)`,
+			3,
+		},
+		{
+			`sum by (a) (count_over_time(sum by (a) (rate({app="foo"}[5m]))[2m:] offset 1h))`,
+			`sum by (a) (
+				sum without () (
+					downstream<sum by (a) (count_over_time(sum by (a) (rate({app="foo"}[5m]))[1m:] offset 1h1m0s)), shard=<nil>>
+					++ downstream<sum by (a) (count_over_time(sum by (a) (rate({app="foo"}[5m]))[1m:] offset 1h0m0s)), shard=<nil>>
+				)
+			)`,
+			2,
+		},
+		{
+			`max(min_over_time(rate({app="foo"}[1m])[2m:]))`,
+			`max(
+				min without () (
+					downstream<min_over_time(rate({app="foo"}[1m])[1m:] offset 1m0s), shard=<nil>>
+					++ downstream<min_over_time(rate({app="foo"}[1m])[1m:]), shard=<nil>>
+				)
+			)`,
+			2,
+		},
+
+		// histograms
+		{
+			`histogram_over_time({app="foo"} | unwrap latency [3m]) by (app)`,
+			`sum by (app,le) (
+				downstream<histogram_over_time({app="foo"} | unwrap latency[1m] offset 2m0s) by (app), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap latency[1m] offset 1m0s) by (app), shard=<nil>>
+				++ downstream<histogram_over_time({app="foo"} | unwrap latency[1m]) by (app), shard=<nil>>
+			)`,
+			3,
+		},
+		{
+			`histogram_quantile(0.99, sum by (le) (histogram_over_time({app="foo"} | unwrap latency [2m])))`,
+			`histogram_quantile(0.99,
+				sum by (le) (
+					sum without () (
+						downstream<sum by (le) (histogram_over_time({app="foo"} | unwrap latency[1m] offset 1m0s)), shard=<nil>>
+						++ downstream<sum by (le) (histogram_over_time({app="foo"} | unwrap latency[1m])), shard=<nil>>
+					)
+				)
+			)`,
+			2,
+		},
+
+		// distinct counts
+		{
+			`hll_over_time({app="foo"} | unwrap user [2m])`,
+			`max without () (
+				downstream<hll_over_time({app="foo"} | unwrap user[1m] offset 1m0s), shard=<nil>>
+				++ downstream<hll_over_time({app="foo"} | unwrap user[1m]), shard=<nil>>
+			)`,
+			2,
+		},
+		{
+			`approx_count_distinct by (app) (hll_over_time({app="foo"} | json | unwrap user [2m]))`,
+			`approx_count_distinct by (app) (
+				max by (app,hll_register) (
+					downstream<hll_over_time({app="foo"} | json | unwrap user[1m] offset 1m0s) by (app), shard=<nil>>
+					++ downstream<hll_over_time({app="foo"} | json | unwrap user[1m]) by (app), shard=<nil>>
+				)
+			)`,
+			2,
+		},
+		{
+			`count_distinct_over_time({app="foo"} | unwrap user [3m]) by (app)`,
+			`approx_count_distinct by (app) (
+				downstream<hll_over_time({app="foo"} | unwrap user[1m] offset 2m0s) by (app), shard=<nil>>
+				++ downstream<hll_over_time({app="foo"} | unwrap user[1m] offset 1m0s) by (app), shard=<nil>>
+				++ downstream<hll_over_time({app="foo"} | unwrap user[1m]) by (app), shard=<nil>>
+			)`,
+			3,
+		},
 	} {
 		tc := tc
 		t.Run(tc.expr, func(t *testing.T) {
@@ -1909,6 +1992,17 @@ func Test_SplitRangeVectorMapping_Noop(t *testing.T) {
 			`(sum(last_over_time({app="foo"} | logfmt | unwrap total_count [1d]) by (foo)) or vector(0.000000))`,
 		},
 
+		// should be noop if the subquery aggregation is not splittable or its range is lower or equal to split interval (1m),
+		// whatever the ranges of its inner query.
+		{
+			`avg_over_time(rate({app="foo"}[3m])[3m:])`,
+			`avg_over_time(rate({app="foo"}[3m])[3m:])`,
+		},
+		{
+			`max_over_time(rate({app="foo"}[3m])[1m:10s])`,
+			`max_over_time(rate({app="foo"}[3m])[1m:10s])`,
+		},
+
 		// should be noop if literal expression
 		{
 			`5`,
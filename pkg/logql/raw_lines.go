@@ -0,0 +1,190 @@
+package logql
+
+import (
+	"time"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+const (
+	// The log lines grouped by the multiline stage are bounded as the ones of the multiline stage of Promtail:
+	// a group ends after 128 log lines, or when the next log line of its stream is more than 3s apart.
+	multilineMaxLines = 128
+	multilineMaxWait  = 3 * time.Second
+)
+
+// RawLinesSelector returns the selector of the log lines of a log query with a multiline or json_array stage, i.e. all
+// the log lines of its streams, and false if the query has none. These stages are applied to the log lines as they are
+// selected, before the other stages.
+func RawLinesSelector(expr syntax.LogSelectorExpr) (string, bool) {
+	if syntax.MultilineStage(expr) == nil && syntax.JSONArrayStage(expr) == nil {
+		return "", false
+	}
+	return syntax.MatchersString(expr.Matchers()), true
+}
+
+// NewRawLinesEntryIterator groups the consecutive log lines of the streams of it with the multiline stage of the log
+// query, or splits them into the elements of their JSON array with its json_array stage, and applies the other stages
+// of its pipeline to the resulting log lines. The iterator must iterate over the log lines of RawLinesSelector. It is
+// returned as is if the query has none of these stages.
+func NewRawLinesEntryIterator(it iter.EntryIterator, expr syntax.LogSelectorExpr, direction logproto.Direction) (iter.EntryIterator, error) {
+	multiline, jsonArray := syntax.MultilineStage(expr), syntax.JSONArrayStage(expr)
+	if multiline == nil && jsonArray == nil {
+		return it, nil
+	}
+	// these stages are noop stages of the pipeline.
+	pipeline, err := expr.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	it = &streamLabelsEntryIterator{EntryIterator: it, streams: map[streamKey]string{}}
+	if multiline != nil {
+		it = iter.NewMultilineEntryIterator(it, multiline.Regexp(), multilineMaxLines, multilineMaxWait, direction)
+	} else {
+		it = &jsonArrayEntryIterator{EntryIterator: it, splitter: jsonArray.Splitter()}
+	}
+	return &pipelineEntryIterator{
+		EntryIterator: it,
+		pipeline:      pipeline,
+		streams:       map[string]log.StreamPipeline{},
+	}, nil
+}
+
+// streamLabelsEntryIterator returns the log lines of an iterator with the labels of their streams, i.e. without their
+// structured metadata, so the log lines of a stream are grouped together whatever their structured metadata.
+type streamLabelsEntryIterator struct {
+	iter.EntryIterator
+	// streams caches the labels of the streams by the labels of the log lines.
+	streams map[streamKey]string
+
+	labels string
+	err    error
+}
+
+func (i *streamLabelsEntryIterator) Next() bool {
+	if !i.EntryIterator.Next() {
+		return false
+	}
+	lbs := i.EntryIterator.Labels()
+	key := streamKey{hash: i.EntryIterator.StreamHash(), labels: lbs}
+	if labels, ok := i.streams[key]; ok {
+		i.labels = labels
+		return true
+	}
+	parsed, err := syntax.ParseLabels(lbs)
+	if err != nil {
+		i.err = err
+		return false
+	}
+	structuredMetadata := logproto.FromStructuredMetadataToLabels(i.EntryIterator.Entry().StructuredMetadata)
+	i.labels = log.WithoutStructuredMetadata(parsed, structuredMetadata).String()
+	i.streams[key] = i.labels
+	return true
+}
+
+// streamKey identifies the labels of the log lines of a stream, since the labels of the log lines of different streams
+// can be the same once their structured metadata are added.
+type streamKey struct {
+	hash   uint64
+	labels string
+}
+
+func (i *streamLabelsEntryIterator) Labels() string { return i.labels }
+
+func (i *streamLabelsEntryIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.EntryIterator.Error()
+}
+
+// jsonArrayEntryIterator splits the log lines holding a JSON array into a log line per element, with the timestamp of
+// the log line. The other log lines are returned as they are.
+type jsonArrayEntryIterator struct {
+	iter.EntryIterator
+	splitter *log.JSONArraySplitter
+
+	entry    logproto.Entry
+	elements [][]byte
+	cur      logproto.Entry
+}
+
+func (i *jsonArrayEntryIterator) Next() bool {
+	for len(i.elements) == 0 {
+		if !i.EntryIterator.Next() {
+			return false
+		}
+		i.entry = i.EntryIterator.Entry()
+		elements, ok := i.splitter.Split([]byte(i.entry.Line))
+		if !ok {
+			i.cur = i.entry
+			return true
+		}
+		i.elements = elements
+	}
+	i.cur = logproto.Entry{Timestamp: i.entry.Timestamp, Line: string(i.elements[0]), StructuredMetadata: i.entry.StructuredMetadata}
+	i.elements = i.elements[1:]
+	return true
+}
+
+func (i *jsonArrayEntryIterator) Entry() logproto.Entry { return i.cur }
+
+// pipelineEntryIterator applies a pipeline to the log lines of an iterator.
+type pipelineEntryIterator struct {
+	iter.EntryIterator
+	pipeline log.Pipeline
+	streams  map[string]log.StreamPipeline
+
+	cur    logproto.Entry
+	labels log.LabelsResult
+	err    error
+}
+
+func (i *pipelineEntryIterator) Next() bool {
+	for i.EntryIterator.Next() {
+		sp, err := i.streamPipeline(i.EntryIterator.Labels())
+		if err != nil {
+			i.err = err
+			return false
+		}
+		entry := i.EntryIterator.Entry()
+		line, lbs, ok := sp.ProcessString(entry.Timestamp.UnixNano(), entry.Line, logproto.FromStructuredMetadataToLabels(entry.StructuredMetadata)...)
+		if !ok {
+			continue
+		}
+		i.cur = logproto.Entry{Timestamp: entry.Timestamp, Line: line, StructuredMetadata: entry.StructuredMetadata}
+		i.labels = lbs
+		return true
+	}
+	return false
+}
+
+// streamPipeline returns the pipeline of the stream.
+func (i *pipelineEntryIterator) streamPipeline(labels string) (log.StreamPipeline, error) {
+	if sp, ok := i.streams[labels]; ok {
+		return sp, nil
+	}
+	lbs, err := syntax.ParseLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	sp := i.pipeline.ForStream(lbs)
+	i.streams[labels] = sp
+	return sp, nil
+}
+
+func (i *pipelineEntryIterator) Entry() logproto.Entry { return i.cur }
+
+func (i *pipelineEntryIterator) Labels() string { return i.labels.String() }
+
+func (i *pipelineEntryIterator) StreamHash() uint64 { return i.labels.Hash() }
+
+func (i *pipelineEntryIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.EntryIterator.Error()
+}
@@ -28,12 +28,15 @@ func (s ConstantShards) GetStats(_ syntax.Expr) (stats.Stats, error) { return st
 type ShardMapper struct {
 	shards  ShardResolver
 	metrics *MapperMetrics
+	// approximateQuantiles enables the sharding of quantile_over_time with the histograms of the values.
+	approximateQuantiles bool
 }
 
-func NewShardMapper(resolver ShardResolver, metrics *MapperMetrics) ShardMapper {
+func NewShardMapper(resolver ShardResolver, metrics *MapperMetrics, approximateQuantiles bool) ShardMapper {
 	return ShardMapper{
-		shards:  resolver,
-		metrics: metrics,
+		shards:               resolver,
+		metrics:              metrics,
+		approximateQuantiles: approximateQuantiles,
 	}
 }
 
@@ -41,6 +44,11 @@ func NewShardMapperMetrics(registerer prometheus.Registerer) *MapperMetrics {
 	return newMapperMetrics(registerer, "shard")
 }
 
+// NewTenantMapperMetrics returns the metrics of the shard mapper used to federate the queries of multiple tenants.
+func NewTenantMapperMetrics(registerer prometheus.Registerer) *MapperMetrics {
+	return newMapperMetrics(registerer, "tenant")
+}
+
 func (m ShardMapper) Parse(query string) (noop bool, bytesPerShard uint64, expr syntax.Expr, err error) {
 	parsed, err := syntax.ParseExpr(query)
 	if err != nil {
@@ -84,11 +92,18 @@ func (m ShardMapper) Map(expr syntax.Expr, r *downstreamRecorder) (syntax.Expr,
 	case *syntax.MatchersExpr, *syntax.PipelineExpr:
 		return m.mapLogSelectorExpr(e.(syntax.LogSelectorExpr), r)
 	case *syntax.VectorAggregationExpr:
+		if e.Operation == syntax.OpTypeApproxCountDistinct {
+			return m.mapApproxCountDistinctExpr(e, r)
+		}
 		return m.mapVectorAggregationExpr(e, r)
 	case *syntax.LabelReplaceExpr:
 		return m.mapLabelReplaceExpr(e, r)
+	case *syntax.HistogramQuantileExpr:
+		return m.mapHistogramQuantileExpr(e, r)
 	case *syntax.RangeAggregationExpr:
 		return m.mapRangeAggregationExpr(e, r)
+	case *syntax.SubqueryExpr:
+		return m.mapSubqueryExpr(e, r)
 	case *syntax.BinOpExpr:
 		lhsMapped, lhsBytesPerShard, err := m.Map(e.SampleExpr, r)
 		if err != nil {
@@ -272,6 +287,34 @@ func (m ShardMapper) mapVectorAggregationExpr(expr *syntax.VectorAggregationExpr
 	}
 }
 
+// mapApproxCountDistinctExpr shards the sketches of approx_count_distinct, which merges them across the shards.
+// The grouping of approx_count_distinct is pushed down to the sketches to reduce the series of the shards.
+// approx_count_distinct by (a) (hll_over_time(x)) -> approx_count_distinct by (a) (hll_over_time(x, shard=1) by (a) ++ ...)
+func (m ShardMapper) mapApproxCountDistinctExpr(expr *syntax.VectorAggregationExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
+	sketches, ok := expr.Left.(*syntax.RangeAggregationExpr)
+	if !ok || sketches.Operation != syntax.OpRangeTypeHLL || hasLabelModifier(sketches) {
+		return m.mapVectorAggregationExpr(expr, r)
+	}
+	cpy := *sketches
+	if cpy.Grouping == nil && !noopGrouping(expr.Grouping) {
+		cpy.Grouping = expr.Grouping
+	}
+	sharded, bytesPerShard, err := m.mapSampleExpr(&cpy, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &syntax.VectorAggregationExpr{
+		Left:      sharded,
+		Grouping:  expr.Grouping,
+		Operation: expr.Operation,
+	}, bytesPerShard, nil
+}
+
+// noopGrouping returns whether the grouping keeps all the labels.
+func noopGrouping(g *syntax.Grouping) bool {
+	return g.Without && len(g.Groups) == 0
+}
+
 func (m ShardMapper) mapLabelReplaceExpr(expr *syntax.LabelReplaceExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
 	subMapped, bytesPerShard, err := m.Map(expr.Left, r)
 	if err != nil {
@@ -282,6 +325,38 @@ func (m ShardMapper) mapLabelReplaceExpr(expr *syntax.LabelReplaceExpr, r *downs
 	return &cpy, bytesPerShard, nil
 }
 
+// mapHistogramQuantileExpr shards the histograms of the quantile, which is calculated from the buckets merged across
+// the shards.
+func (m ShardMapper) mapHistogramQuantileExpr(expr *syntax.HistogramQuantileExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
+	subMapped, bytesPerShard, err := m.Map(expr.Left, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	sampleExpr, ok := subMapped.(syntax.SampleExpr)
+	if !ok {
+		return nil, 0, badASTMapping(subMapped)
+	}
+	cpy := *expr
+	cpy.Left = sampleExpr
+	return &cpy, bytesPerShard, nil
+}
+
+// mapSubqueryExpr shards the inner query of the subquery, the range aggregation of the subquery is applied
+// to the merged results of the shards.
+func (m ShardMapper) mapSubqueryExpr(expr *syntax.SubqueryExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
+	subMapped, bytesPerShard, err := m.Map(expr.Left, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	sampleExpr, ok := subMapped.(syntax.SampleExpr)
+	if !ok {
+		return nil, 0, badASTMapping(subMapped)
+	}
+	cpy := *expr
+	cpy.Left = sampleExpr
+	return &cpy, bytesPerShard, nil
+}
+
 func (m ShardMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr, r *downstreamRecorder) (syntax.SampleExpr, uint64, error) {
 	if hasLabelModifier(expr) {
 		// if an expr can modify labels this means multiple shards can return the same labelset.
@@ -302,6 +377,66 @@ func (m ShardMapper) mapRangeAggregationExpr(expr *syntax.RangeAggregationExpr,
 		// rate(x) -> rate(x, shard=1) ++ rate(x, shard=2)...
 		// same goes for bytes_rate and bytes_over_time
 		return m.mapSampleExpr(expr, r)
+	case syntax.OpRangeTypeHistogram:
+		// histogram_over_time(x) -> histogram_over_time(x, shard=1) ++ histogram_over_time(x, shard=2)...
+		// The grouped buckets of different shards can have the same labels, so they are summed by their labels.
+		// histogram_over_time(x) by (a) -> sum by (a, le) (histogram_over_time(x, shard=1) by (a) ++ ...)
+		sharded, bytesPerShard, err := m.mapSampleExpr(expr, r)
+		if err != nil || expr.Grouping == nil {
+			return sharded, bytesPerShard, err
+		}
+		return &syntax.VectorAggregationExpr{
+			Left:      sharded,
+			Grouping:  histogramGrouping(expr.Grouping),
+			Operation: syntax.OpTypeSum,
+		}, bytesPerShard, nil
+	case syntax.OpRangeTypeHLL:
+		// hll_over_time(x) -> hll_over_time(x, shard=1) ++ hll_over_time(x, shard=2)...
+		// The grouped registers of different shards can have the same labels, so they are merged by their labels.
+		// hll_over_time(x) by (a) -> max by (a, hll_register) (hll_over_time(x, shard=1) by (a) ++ ...)
+		sharded, bytesPerShard, err := m.mapSampleExpr(expr, r)
+		if err != nil || expr.Grouping == nil {
+			return sharded, bytesPerShard, err
+		}
+		return &syntax.VectorAggregationExpr{
+			Left:      sharded,
+			Grouping:  hllGrouping(expr.Grouping),
+			Operation: syntax.OpTypeMax,
+		}, bytesPerShard, nil
+	case syntax.OpRangeTypeCountDistinct:
+		// The distinct values are counted from the sketches merged across the shards.
+		// count_distinct_over_time(x) by (a) -> approx_count_distinct by (a) (hll_over_time(x, shard=1) by (a) ++ ...)
+		grouping := expr.Grouping
+		if grouping == nil {
+			grouping = &syntax.Grouping{Without: true, Groups: []string{}}
+		}
+		return m.mapApproxCountDistinctExpr(&syntax.VectorAggregationExpr{
+			Left: &syntax.RangeAggregationExpr{
+				Left:      expr.Left,
+				Operation: syntax.OpRangeTypeHLL,
+				Grouping:  expr.Grouping,
+			},
+			Grouping:  grouping,
+			Operation: syntax.OpTypeApproxCountDistinct,
+		}, r)
+	case syntax.OpRangeTypeQuantile:
+		if m.approximateQuantiles && expr.Params != nil {
+			// The quantiles are approximated from the histograms of the values merged across the shards.
+			// quantile_over_time(q, x) by (a) -> histogram_quantile(q, sum by (a, le) (histogram_over_time(x, shard=1) by (a) ++ ...))
+			sharded, bytesPerShard, err := m.mapRangeAggregationExpr(&syntax.RangeAggregationExpr{
+				Left:      expr.Left,
+				Operation: syntax.OpRangeTypeHistogram,
+				Grouping:  expr.Grouping,
+			}, r)
+			if err != nil {
+				return nil, 0, err
+			}
+			return &syntax.HistogramQuantileExpr{
+				Left:     sharded,
+				Quantile: *expr.Params,
+			}, bytesPerShard, nil
+		}
+		fallthrough
 	default:
 		// This part of the query is not shardable, so the bytesPerShard is the bytes for all the log matchers in expr
 		exprStats, err := m.shards.GetStats(expr)
@@ -51,7 +51,7 @@ func TestShardedStringer(t *testing.T) {
 }
 
 func TestMapSampleExpr(t *testing.T) {
-	m := NewShardMapper(ConstantShards(2), nilShardMetrics)
+	m := NewShardMapper(ConstantShards(2), nilShardMetrics, false)
 
 	for _, tc := range []struct {
 		in  syntax.SampleExpr
@@ -113,7 +113,7 @@ func TestMapSampleExpr(t *testing.T) {
 }
 
 func TestMappingStrings(t *testing.T) {
-	m := NewShardMapper(ConstantShards(2), nilShardMetrics)
+	m := NewShardMapper(ConstantShards(2), nilShardMetrics, false)
 	for _, tc := range []struct {
 		in  string
 		out string
@@ -294,6 +294,124 @@ func TestMappingStrings(t *testing.T) {
 			in:  `sum(count_over_time({a=~".+"}[1s]) * ignoring () count_over_time({a=~".+"}[1s]))`,
 			out: `sum(downstream<sum((count_over_time({a=~".+"}[1s])*count_over_time({a=~".+"}[1s]))),shard=0_of_2>++downstream<sum((count_over_time({a=~".+"}[1s])*count_over_time({a=~".+"}[1s]))),shard=1_of_2>)`,
 		},
+		{
+			// the inner query of a subquery is sharded
+			in: `max_over_time(sum(rate({foo="bar"}[1m]))[1h:1m])`,
+			out: `max_over_time(
+				sum(
+					downstream<sum(rate({foo="bar"}[1m])), shard=0_of_2>
+					++ downstream<sum(rate({foo="bar"}[1m])), shard=1_of_2>
+				)[1h:1m]
+			)`,
+		},
+		{
+			in:  `max_over_time(quantile_over_time(0.99, {foo="bar"} | unwrap bar [1m])[1h:])`,
+			out: `max_over_time(quantile_over_time(0.99,{foo="bar"} | unwrap bar[1m])[1h:])`,
+		},
+		{
+			// the grouped buckets of the shards are summed by bucket
+			in: `histogram_over_time({foo="bar"} | unwrap latency [1m]) by (app)`,
+			out: `sum by (app,le)(
+				downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app), shard=0_of_2>
+				++ downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app), shard=1_of_2>
+			)`,
+		},
+		{
+			in: `histogram_quantile(0.99, sum by (le) (histogram_over_time({foo="bar"} | unwrap latency [1m])))`,
+			out: `histogram_quantile(0.99,
+				sum by (le)(
+					downstream<sum by (le)(histogram_over_time({foo="bar"} | unwrap latency[1m])), shard=0_of_2>
+					++ downstream<sum by (le)(histogram_over_time({foo="bar"} | unwrap latency[1m])), shard=1_of_2>
+				)
+			)`,
+		},
+		{
+			// the grouped registers of the shards are merged by register
+			in: `hll_over_time({foo="bar"} | unwrap user [1m]) by (app)`,
+			out: `max by (app,hll_register)(
+				downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=0_of_2>
+				++ downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=1_of_2>
+			)`,
+		},
+		{
+			// the grouping is pushed down to the sketches
+			in: `approx_count_distinct by (app) (hll_over_time({foo="bar"} | unwrap user [1m]))`,
+			out: `approx_count_distinct by (app)(
+				downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=0_of_2>
+				++ downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=1_of_2>
+			)`,
+		},
+		{
+			in: `count_distinct_over_time({foo="bar"} | unwrap user [1m]) by (app)`,
+			out: `approx_count_distinct by (app)(
+				downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=0_of_2>
+				++ downstream<hll_over_time({foo="bar"} | unwrap user[1m]) by (app), shard=1_of_2>
+			)`,
+		},
+		{
+			in: `sum(count_distinct_over_time({foo="bar"} | unwrap user [1m]))`,
+			out: `sum(
+				approx_count_distinct without ()(
+					downstream<hll_over_time({foo="bar"} | unwrap user[1m]), shard=0_of_2>
+					++ downstream<hll_over_time({foo="bar"} | unwrap user[1m]), shard=1_of_2>
+				)
+			)`,
+		},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			ast, err := syntax.ParseExpr(tc.in)
+			require.Nil(t, err)
+
+			mapped, _, err := m.Map(ast, nilShardMetrics.downstreamRecorder())
+			require.Nil(t, err)
+
+			require.Equal(t, removeWhiteSpace(tc.out), removeWhiteSpace(mapped.String()))
+		})
+	}
+}
+
+func TestMappingStrings_ApproximateQuantiles(t *testing.T) {
+	m := NewShardMapper(ConstantShards(2), nilShardMetrics, true)
+	for _, tc := range []struct {
+		in  string
+		out string
+	}{
+		{
+			in: `quantile_over_time(0.99, {foo="bar"} | unwrap latency [1m])`,
+			out: `histogram_quantile(0.99,
+				downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]), shard=0_of_2>
+				++ downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]), shard=1_of_2>
+			)`,
+		},
+		{
+			in: `quantile_over_time(0.5, {foo="bar"} | unwrap latency [1m]) by (app)`,
+			out: `histogram_quantile(0.5,
+				sum by (app, le) (
+					downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app), shard=0_of_2>
+					++ downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app), shard=1_of_2>
+				)
+			)`,
+		},
+		{
+			in: `max by (app) (quantile_over_time(0.5, {foo="bar"} | unwrap latency [1m]) by (app, pod))`,
+			out: `max by (app) (
+				histogram_quantile(0.5,
+					sum by (app, pod, le) (
+						downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app, pod), shard=0_of_2>
+						++ downstream<histogram_over_time({foo="bar"} | unwrap latency[1m]) by (app, pod), shard=1_of_2>
+					)
+				)
+			)`,
+		},
+		{
+			// the labels modified by the pipelines can't be merged across the shards.
+			in:  `quantile_over_time(0.99, {foo="bar"} | label_format app="{{.pod}}" | unwrap latency [1m]) by (app)`,
+			out: `quantile_over_time(0.99, {foo="bar"} | label_format app="{{.pod}}" | unwrap latency[1m]) by (app)`,
+		},
+		{
+			in:  `max_over_time({foo="bar"} | unwrap latency [1m])`,
+			out: `max_over_time({foo="bar"} | unwrap latency[1m])`,
+		},
 	} {
 		t.Run(tc.in, func(t *testing.T) {
 			ast, err := syntax.ParseExpr(tc.in)
@@ -308,7 +426,7 @@ func TestMappingStrings(t *testing.T) {
 }
 
 func TestMapping(t *testing.T) {
-	m := NewShardMapper(ConstantShards(2), nilShardMetrics)
+	m := NewShardMapper(ConstantShards(2), nilShardMetrics, false)
 
 	for _, tc := range []struct {
 		in   string
@@ -1273,7 +1391,7 @@ func TestStringTrimming(t *testing.T) {
 		},
 	} {
 		t.Run(tc.expr, func(t *testing.T) {
-			m := NewShardMapper(ConstantShards(tc.shards), nilShardMetrics)
+			m := NewShardMapper(ConstantShards(tc.shards), nilShardMetrics, false)
 			_, _, mappedExpr, err := m.Parse(tc.expr)
 			require.Nil(t, err)
 			require.Equal(t, removeWhiteSpace(tc.expected), removeWhiteSpace(mappedExpr.String()))
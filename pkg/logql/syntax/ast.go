@@ -475,6 +475,8 @@ func (e *LabelParserExpr) Stage() (log.Stage, error) {
 		return log.NewUnpackParser(), nil
 	case OpParserTypePattern:
 		return log.NewPatternParser(e.Param)
+	case OpParserTypeXML:
+		return log.NewXMLParser(), nil
 	default:
 		return nil, fmt.Errorf("unknown parser operator: %s", e.Op)
 	}
@@ -631,6 +633,283 @@ func (e *KeepLabelsExpr) String() string {
 
 func (e *KeepLabelsExpr) Walk(f WalkFn) { f(e) }
 
+// LookupExpr adds the labels of the rows of a lookup table, e.g: | lookup "owners" on service.
+// The table is resolved by name before the pipeline is created, see SetLookupTables.
+type LookupExpr struct {
+	Table string
+	On    string
+
+	table *log.LookupTable
+	implicit
+}
+
+func newLookupExpr(table, on string) *LookupExpr {
+	return &LookupExpr{Table: table, On: on}
+}
+
+func (e *LookupExpr) Shardable() bool { return true }
+
+func (e *LookupExpr) Walk(f WalkFn) { f(e) }
+
+func (e *LookupExpr) Stage() (log.Stage, error) {
+	if e.table == nil {
+		return nil, fmt.Errorf("lookup table %s not found", e.Table)
+	}
+	return log.NewLookup(e.table, e.On)
+}
+
+func (e *LookupExpr) String() string {
+	return fmt.Sprintf("%s %s %s %s %s", OpPipe, OpLookup, strconv.Quote(e.Table), OpOn, e.On)
+}
+
+// SortExpr orders the log lines of a log query by the value of a label, e.g: | sort by duration desc.
+// The log lines are ordered when the results of the query are read, so the stage is always the last one, but a
+// head or tail stage. See LogOrdering.
+type SortExpr struct {
+	Label string
+	Desc  bool
+	implicit
+}
+
+func mustNewSortExpr(label, order string) *SortExpr {
+	switch order {
+	case "", OpSortAsc:
+		return &SortExpr{Label: label}
+	case OpSortDesc:
+		return &SortExpr{Label: label, Desc: true}
+	default:
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid sort order %s, expected %s or %s", order, OpSortAsc, OpSortDesc), 0, 0))
+	}
+}
+
+func (e *SortExpr) Shardable() bool { return true }
+
+func (e *SortExpr) Walk(f WalkFn) { f(e) }
+
+func (e *SortExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+func (e *SortExpr) String() string {
+	order := OpSortAsc
+	if e.Desc {
+		order = OpSortDesc
+	}
+	return fmt.Sprintf("%s %s by %s %s", OpPipe, OpTypeSort, e.Label, order)
+}
+
+// LimitExpr keeps the first or the last log lines of a log query, e.g: | head 20.
+// The log lines are ordered by the sort stage preceding it, or by timestamp in the direction of the query.
+type LimitExpr struct {
+	Op    string
+	Limit int
+	implicit
+}
+
+func mustNewLimitExpr(op, limit string) *LimitExpr {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid %s limit %s, expected a positive integer", op, limit), 0, 0))
+	}
+	return &LimitExpr{Op: op, Limit: n}
+}
+
+func (e *LimitExpr) Shardable() bool { return true }
+
+func (e *LimitExpr) Walk(f WalkFn) { f(e) }
+
+func (e *LimitExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+func (e *LimitExpr) String() string {
+	return fmt.Sprintf("%s %s %d", OpPipe, e.Op, e.Limit)
+}
+
+// LogOrdering returns the sort and the head or tail stages of a log query, nil if the query has none.
+func LogOrdering(expr LogSelectorExpr) (*SortExpr, *LimitExpr) {
+	var (
+		sort  *SortExpr
+		limit *LimitExpr
+	)
+	expr.Walk(func(e interface{}) {
+		switch e := e.(type) {
+		case *SortExpr:
+			sort = e
+		case *LimitExpr:
+			limit = e
+		}
+	})
+	return sort, limit
+}
+
+// MultilineExpr groups the consecutive log lines of the streams into single log lines, e.g: | multiline firstline="^\d{4}-".
+// A log line matching the firstline regular expression starts a new log line, and the following log lines are appended
+// to it. The log lines are grouped when they are selected, before the other stages, so the stage is always the first one.
+// See MultilineStage.
+type MultilineExpr struct {
+	FirstLine string
+
+	re *regexp.Regexp
+	implicit
+}
+
+func mustNewMultilineExpr(name, firstLine string) *MultilineExpr {
+	if name != OpMultilineFirstLine {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid multiline parameter %s, expected %s", name, OpMultilineFirstLine), 0, 0))
+	}
+	re, err := regexp.Compile(firstLine)
+	if err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid multiline %s regex %s: %s", OpMultilineFirstLine, firstLine, err), 0, 0))
+	}
+	return &MultilineExpr{FirstLine: firstLine, re: re}
+}
+
+func (e *MultilineExpr) Shardable() bool { return true }
+
+func (e *MultilineExpr) Walk(f WalkFn) { f(e) }
+
+func (e *MultilineExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+// Regexp returns the regular expression matching the first lines of the log lines.
+func (e *MultilineExpr) Regexp() *regexp.Regexp { return e.re }
+
+func (e *MultilineExpr) String() string {
+	return fmt.Sprintf("%s %s %s=%s", OpPipe, OpMultiline, OpMultilineFirstLine, strconv.Quote(e.FirstLine))
+}
+
+// MultilineStage returns the multiline stage of a log query, nil if the query has none.
+func MultilineStage(expr LogSelectorExpr) *MultilineExpr {
+	p, ok := expr.(*PipelineExpr)
+	if !ok || len(p.MultiStages) == 0 {
+		return nil
+	}
+	multiline, _ := p.MultiStages[0].(*MultilineExpr)
+	return multiline
+}
+
+// JSONArrayExpr splits the log lines holding a JSON array into a log line per element of the array, e.g: | json_array
+// or | json_array "events". The log lines are split when they are selected, before the other stages, so the stage is
+// always the first one. See JSONArrayStage.
+type JSONArrayExpr struct {
+	Path string
+
+	splitter *log.JSONArraySplitter
+	implicit
+}
+
+func mustNewJSONArrayExpr(path string) *JSONArrayExpr {
+	splitter, err := log.NewJSONArraySplitter(path)
+	if err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid %s path: %s", OpJSONArray, err), 0, 0))
+	}
+	return &JSONArrayExpr{Path: path, splitter: splitter}
+}
+
+func (e *JSONArrayExpr) Shardable() bool { return true }
+
+func (e *JSONArrayExpr) Walk(f WalkFn) { f(e) }
+
+func (e *JSONArrayExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+// Splitter returns the splitter of the log lines into the elements of their array.
+func (e *JSONArrayExpr) Splitter() *log.JSONArraySplitter { return e.splitter }
+
+func (e *JSONArrayExpr) String() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s %s", OpPipe, OpJSONArray)
+	}
+	return fmt.Sprintf("%s %s %s", OpPipe, OpJSONArray, strconv.Quote(e.Path))
+}
+
+// JSONArrayStage returns the json_array stage of a log query, nil if the query has none.
+func JSONArrayStage(expr LogSelectorExpr) *JSONArrayExpr {
+	p, ok := expr.(*PipelineExpr)
+	if !ok || len(p.MultiStages) == 0 {
+		return nil
+	}
+	jsonArray, _ := p.MultiStages[0].(*JSONArrayExpr)
+	return jsonArray
+}
+
+// ContextExpr returns the log lines around the log lines of a log query from the same streams, e.g: | context before=5 after=5.
+// The log lines around are returned as they are stored, and are selected when reading the chunks of the streams,
+// so the stage is always the last one. See ContextLines.
+type ContextExpr struct {
+	Before int
+	After  int
+	implicit
+}
+
+func mustNewContextExpr(params ...string) *ContextExpr {
+	e := &ContextExpr{}
+	for i := 0; i < len(params); i += 2 {
+		name, value := params[i], params[i+1]
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid context %s %s, expected a non-negative integer", name, value), 0, 0))
+		}
+		switch name {
+		case OpContextBefore:
+			e.Before = n
+		case OpContextAfter:
+			e.After = n
+		default:
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid context parameter %s, expected %s or %s", name, OpContextBefore, OpContextAfter), 0, 0))
+		}
+	}
+	return e
+}
+
+func (e *ContextExpr) Shardable() bool { return true }
+
+func (e *ContextExpr) Walk(f WalkFn) { f(e) }
+
+func (e *ContextExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+func (e *ContextExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s", OpPipe, OpContext))
+	if e.Before > 0 || e.After == 0 {
+		sb.WriteString(fmt.Sprintf(" %s=%d", OpContextBefore, e.Before))
+	}
+	if e.After > 0 {
+		sb.WriteString(fmt.Sprintf(" %s=%d", OpContextAfter, e.After))
+	}
+	return sb.String()
+}
+
+// ContextStage returns the context stage of a log query, nil if the query has none.
+func ContextStage(expr LogSelectorExpr) *ContextExpr {
+	p, ok := expr.(*PipelineExpr)
+	if !ok || len(p.MultiStages) == 0 {
+		return nil
+	}
+	context, _ := p.MultiStages[len(p.MultiStages)-1].(*ContextExpr)
+	return context
+}
+
+// LookupTableNames returns the names of the lookup tables of the expression.
+func LookupTableNames(expr Expr) []string {
+	var names []string
+	expr.Walk(func(e interface{}) {
+		if l, ok := e.(*LookupExpr); ok {
+			for _, name := range names {
+				if name == l.Table {
+					return
+				}
+			}
+			names = append(names, l.Table)
+		}
+	})
+	return names
+}
+
+// SetLookupTables sets the lookup tables of the expression by name.
+func SetLookupTables(expr Expr, tables map[string]*log.LookupTable) {
+	expr.Walk(func(e interface{}) {
+		if l, ok := e.(*LookupExpr); ok {
+			l.table = tables[l.Table]
+		}
+	})
+}
+
 func (e *LineFmtExpr) Shardable() bool { return true }
 
 func (e *LineFmtExpr) Walk(f WalkFn) { f(e) }
@@ -717,6 +996,92 @@ func (j *JSONExpressionParser) String() string {
 	return sb.String()
 }
 
+// XMLExpressionParser extracts the values of XPath expressions from xml log lines, e.g: | xml user="/event/user".
+type XMLExpressionParser struct {
+	Expressions []log.LabelExtractionExpr
+
+	implicit
+}
+
+func mustNewXMLExpressionParser(expressions []log.LabelExtractionExpr) *XMLExpressionParser {
+	if _, err := log.NewXMLExpressionParser(expressions); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid xml parser: %s", err.Error()), 0, 0))
+	}
+	return &XMLExpressionParser{
+		Expressions: expressions,
+	}
+}
+
+func (x *XMLExpressionParser) Shardable() bool { return true }
+
+func (x *XMLExpressionParser) Walk(f WalkFn) { f(x) }
+
+func (x *XMLExpressionParser) Stage() (log.Stage, error) {
+	return log.NewXMLExpressionParser(x.Expressions)
+}
+
+func (x *XMLExpressionParser) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s ", OpPipe, OpParserTypeXML))
+	for i, exp := range x.Expressions {
+		sb.WriteString(exp.Identifier)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(exp.Expression))
+
+		if i+1 != len(x.Expressions) {
+			sb.WriteString(",")
+		}
+	}
+	return sb.String()
+}
+
+// CSVParserExpr extracts the values of delimiter separated log lines, e.g: | csv columns="ts,level,msg" delimiter=";".
+// The columns are separated by commas, and the delimiter is a comma by default.
+type CSVParserExpr struct {
+	Columns   []string
+	Delimiter string
+
+	implicit
+}
+
+func mustNewCSVParserExpr(params []string) *CSVParserExpr {
+	e := &CSVParserExpr{Delimiter: ","}
+	for i := 0; i < len(params); i += 2 {
+		switch name, value := params[i], params[i+1]; name {
+		case OpCSVColumns:
+			e.Columns = strings.Split(value, ",")
+			for j, column := range e.Columns {
+				e.Columns[j] = strings.TrimSpace(column)
+			}
+		case OpCSVDelimiter:
+			e.Delimiter = value
+		default:
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parameter %s, expected %s or %s", name, OpCSVColumns, OpCSVDelimiter), 0, 0))
+		}
+	}
+	if _, err := log.NewCSVParser(e.Columns, e.Delimiter); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parser: %s", err.Error()), 0, 0))
+	}
+	return e
+}
+
+func (e *CSVParserExpr) Shardable() bool { return true }
+
+func (e *CSVParserExpr) Walk(f WalkFn) { f(e) }
+
+func (e *CSVParserExpr) Stage() (log.Stage, error) {
+	return log.NewCSVParser(e.Columns, e.Delimiter)
+}
+
+func (e *CSVParserExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s %s=%s", OpPipe, OpParserTypeCSV, OpCSVColumns, strconv.Quote(strings.Join(e.Columns, ","))))
+	if e.Delimiter != "," {
+		sb.WriteString(fmt.Sprintf(" %s=%s", OpCSVDelimiter, strconv.Quote(e.Delimiter)))
+	}
+	return sb.String()
+}
+
 type DistinctFilterExpr struct {
 	labels []string
 	implicit
@@ -949,6 +1314,8 @@ const (
 	OpTypeSort     = "sort"
 	OpTypeSortDesc = "sort_desc"
 
+	OpTypeApproxCountDistinct = "approx_count_distinct"
+
 	// range vector ops
 	OpRangeTypeCount       = "count_over_time"
 	OpRangeTypeRate        = "rate"
@@ -965,6 +1332,10 @@ const (
 	OpRangeTypeFirst       = "first_over_time"
 	OpRangeTypeLast        = "last_over_time"
 	OpRangeTypeAbsent      = "absent_over_time"
+	OpRangeTypeHistogram   = "histogram_over_time"
+
+	OpRangeTypeCountDistinct = "count_distinct_over_time"
+	OpRangeTypeHLL           = "hll_over_time"
 
 	//vector
 	OpTypeVector = "vector"
@@ -996,6 +1367,12 @@ const (
 	OpParserTypeRegexp  = "regexp"
 	OpParserTypeUnpack  = "unpack"
 	OpParserTypePattern = "pattern"
+	OpParserTypeXML     = "xml"
+	OpParserTypeCSV     = "csv"
+
+	// csv parser parameters
+	OpCSVColumns   = "columns"
+	OpCSVDelimiter = "delimiter"
 
 	OpFmtLine    = "line_format"
 	OpFmtLabel   = "label_format"
@@ -1018,6 +1395,8 @@ const (
 
 	OpLabelReplace = "label_replace"
 
+	OpTypeHistogramQuantile = "histogram_quantile"
+
 	// function filters
 	OpFilterIP = "ip"
 
@@ -1029,6 +1408,27 @@ const (
 	// keep labels
 	OpKeep = "keep"
 
+	// lookup tables
+	OpLookup = "lookup"
+
+	// ordering of log queries
+	OpSortAsc  = "asc"
+	OpSortDesc = "desc"
+	OpHead     = "head"
+	OpTail     = "tail"
+
+	// multiline log lines
+	OpMultiline          = "multiline"
+	OpMultilineFirstLine = "firstline"
+
+	// json arrays
+	OpJSONArray = "json_array"
+
+	// context lines of log queries
+	OpContext       = "context"
+	OpContextBefore = "before"
+	OpContextAfter  = "after"
+
 	// parser flags
 	OpStrict    = "--strict"
 	OpKeepEmpty = "--keep-empty"
@@ -1131,7 +1531,8 @@ func (e *RangeAggregationExpr) MatcherGroups() ([]MatcherRange, error) {
 func (e RangeAggregationExpr) validate() error {
 	if e.Grouping != nil {
 		switch e.Operation {
-		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst, OpRangeTypeLast:
+		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst, OpRangeTypeLast,
+			OpRangeTypeHistogram, OpRangeTypeCountDistinct, OpRangeTypeHLL:
 		default:
 			return fmt.Errorf("grouping not allowed for %s aggregation", e.Operation)
 		}
@@ -1140,7 +1541,13 @@ func (e RangeAggregationExpr) validate() error {
 		switch e.Operation {
 		case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev,
 			OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeRate, OpRangeTypeRateCounter,
-			OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast:
+			OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast, OpRangeTypeHistogram:
+			return nil
+		case OpRangeTypeCountDistinct, OpRangeTypeHLL:
+			// the distinct values are counted from their hashes, they can't be converted.
+			if e.Left.Unwrap.Operation != "" {
+				return fmt.Errorf("invalid conversion %s for %s aggregation", e.Left.Unwrap.Operation, e.Operation)
+			}
 			return nil
 		default:
 			return fmt.Errorf("invalid aggregation %s with unwrap", e.Operation)
@@ -1188,6 +1595,144 @@ func (e *RangeAggregationExpr) Walk(f WalkFn) {
 	e.Left.Walk(f)
 }
 
+// subqueryRange is the range and the optional resolution of a subquery, e.g: [1h:1m].
+type subqueryRange struct {
+	Range time.Duration
+	Step  time.Duration
+}
+
+// SubqueryExpr applies a range vector aggregation to the results of a metric query evaluated over a range,
+// e.g: max_over_time(rate({app="foo"}[1m])[1h:1m]).
+// The inner query is evaluated at every Step within the range, or at every step of the query when Step is zero.
+type SubqueryExpr struct {
+	Left      SampleExpr
+	Operation string
+	Params    *float64
+	Range     time.Duration
+	Step      time.Duration
+	Offset    time.Duration
+	err       error
+	implicit
+}
+
+func newSubqueryExpr(left SampleExpr, operation string, r subqueryRange, o *OffsetExpr, stringParams *string) SampleExpr {
+	e := &SubqueryExpr{
+		Left:      left,
+		Operation: operation,
+		Range:     r.Range,
+		Step:      r.Step,
+	}
+	if o != nil {
+		e.Offset = o.Offset
+	}
+	if stringParams != nil {
+		if operation != OpRangeTypeQuantile {
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter %s not supported for operation %s", *stringParams, operation), 0, 0)}
+		}
+		params, err := strconv.ParseFloat(*stringParams, 64)
+		if err != nil {
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for operation %s: %s", operation, err), 0, 0)}
+		}
+		e.Params = &params
+	} else if operation == OpRangeTypeQuantile {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+	}
+	if err := e.validate(); err != nil {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+	return e
+}
+
+func (e *SubqueryExpr) validate() error {
+	switch e.Operation {
+	case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev, OpRangeTypeStdvar,
+		OpRangeTypeQuantile, OpRangeTypeFirst, OpRangeTypeLast, OpRangeTypeCount:
+	default:
+		return fmt.Errorf("invalid aggregation %s in subquery", e.Operation)
+	}
+	if e.Range <= 0 {
+		return fmt.Errorf("invalid subquery range %s", model.Duration(e.Range))
+	}
+	return nil
+}
+
+// RangeAggregation returns the range aggregation applied to the samples of the subquery.
+func (e *SubqueryExpr) RangeAggregation() *RangeAggregationExpr {
+	return &RangeAggregationExpr{
+		Left: &LogRange{
+			Interval: e.Range,
+			Offset:   e.Offset,
+		},
+		Operation: e.Operation,
+		Params:    e.Params,
+	}
+}
+
+func (e *SubqueryExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+// MatcherGroups returns the matcher groups of the inner query, whose ranges are extended by the range of the subquery.
+func (e *SubqueryExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	groups, err := e.Left.MatcherGroups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].Interval += e.Range
+		groups[i].Offset += e.Offset
+	}
+	return groups, nil
+}
+
+func (e *SubqueryExpr) Extractor() (SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+// Shardable returns false: the shards of a subquery are the shards of its inner query.
+func (e *SubqueryExpr) Shardable() bool {
+	return false
+}
+
+func (e *SubqueryExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *SubqueryExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	sb.WriteString("(")
+	if e.Params != nil {
+		sb.WriteString(strconv.FormatFloat(*e.Params, 'f', -1, 64))
+		sb.WriteString(",")
+	}
+	sb.WriteString(e.Left.String())
+	sb.WriteString(fmt.Sprintf("[%v:", model.Duration(e.Range)))
+	if e.Step != 0 {
+		sb.WriteString(model.Duration(e.Step).String())
+	}
+	sb.WriteString("]")
+	if e.Offset != 0 {
+		offsetExpr := OffsetExpr{Offset: e.Offset}
+		sb.WriteString(offsetExpr.String())
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // Grouping struct represents the grouping by/without label(s) for vector aggregators and range vector aggregators.
 // The representation is as follows:
 //   - No Grouping (labels dismissed): <operation> (<expr>) => Grouping{Without: false, Groups: nil}
@@ -1301,11 +1846,15 @@ func (e *VectorAggregationExpr) Extractor() (log.SampleExtractor, error) {
 
 // canInjectVectorGrouping tells if a vector operation can inject grouping into the nested range vector.
 func canInjectVectorGrouping(vecOp, rangeOp string) bool {
+	if vecOp == OpTypeApproxCountDistinct {
+		// the sketches of the groups are merged by approx_count_distinct.
+		return rangeOp == OpRangeTypeHLL
+	}
 	if vecOp != OpTypeSum {
 		return false
 	}
 	switch rangeOp {
-	case OpRangeTypeBytes, OpRangeTypeBytesRate, OpRangeTypeSum, OpRangeTypeRate, OpRangeTypeCount:
+	case OpRangeTypeBytes, OpRangeTypeBytesRate, OpRangeTypeSum, OpRangeTypeRate, OpRangeTypeCount, OpRangeTypeHistogram:
 		return true
 	default:
 		return false
@@ -1345,7 +1894,7 @@ func (e *VectorAggregationExpr) Shardable() bool {
 			// cleaner. For now I'm disallowing sharding on both.
 			case *LabelParserExpr:
 				shardable = false
-			case *LogfmtParserExpr:
+			case *LogfmtParserExpr, *XMLExpressionParser, *CSVParserExpr:
 				shardable = false
 			}
 		})
@@ -1906,6 +2455,75 @@ func (e *LabelReplaceExpr) String() string {
 	return sb.String()
 }
 
+// HistogramQuantileExpr calculates the quantile of the histograms produced by histogram_over_time, e.g:
+// histogram_quantile(0.99, sum by (le) (histogram_over_time({app="foo"} | unwrap latency [5m]))).
+// The buckets of a histogram are the series with the same labels except for the bucket label.
+type HistogramQuantileExpr struct {
+	Left     SampleExpr
+	Quantile float64
+	err      error
+
+	implicit
+}
+
+func newHistogramQuantileExpr(left SampleExpr, quantile string) SampleExpr {
+	q, err := strconv.ParseFloat(quantile, 64)
+	if err != nil {
+		return &HistogramQuantileExpr{
+			err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for function %s: %s", OpTypeHistogramQuantile, err), 0, 0),
+		}
+	}
+	return &HistogramQuantileExpr{
+		Left:     left,
+		Quantile: q,
+	}
+}
+
+func (e *HistogramQuantileExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+func (e *HistogramQuantileExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.MatcherGroups()
+}
+
+func (e *HistogramQuantileExpr) Extractor() (SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+// Shardable is false since the quantile is calculated from the buckets merged across the shards.
+func (e *HistogramQuantileExpr) Shardable() bool {
+	return false
+}
+
+func (e *HistogramQuantileExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *HistogramQuantileExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpTypeHistogramQuantile)
+	sb.WriteString("(")
+	sb.WriteString(strconv.FormatFloat(e.Quantile, 'f', -1, 64))
+	sb.WriteString(",")
+	sb.WriteString(e.Left.String())
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // shardableOps lists the operations which may be sharded.
 // topk, botk, max, & min all must be concatenated and then evaluated in order to avoid
 // potential data loss due to series distribution across shards.
@@ -1938,6 +2556,10 @@ var shardableOps = map[string]bool{
 	OpRangeTypeSum:       true,
 	OpRangeTypeMax:       true,
 	OpRangeTypeMin:       true,
+	// the buckets of histogram_over_time are counters of the samples.
+	OpRangeTypeHistogram: true,
+	// the registers of the sketches of hll_over_time are merged with max.
+	OpRangeTypeHLL: true,
 
 	// binops - arith
 	OpTypeAdd: true,
@@ -98,6 +98,14 @@ func Test_SampleExpr_String(t *testing.T) {
 		`avg( rate( ( {job="nginx"} |= "GET" ) [10s] ) ) by (region)`,
 		`avg(min_over_time({job="nginx"} |= "GET" | unwrap foo[10s])) by (region)`,
 		`avg(min_over_time({job="nginx"} |= "GET" | unwrap foo[10s] offset 10m)) by (region)`,
+		`max_over_time(rate({job="nginx"}[1m])[1h:1m])`,
+		`max_over_time(sum by (region) (rate({job="nginx"}[1m]))[1h:] offset 10m)`,
+		`quantile_over_time(0.99, sum(rate({job="nginx"}[1m]))[1h:5m])`,
+		`avg_over_time(max_over_time(rate({job="nginx"}[1m])[10m:1m])[1h:10m])`,
+		`histogram_over_time({job="nginx"} | json | unwrap duration(latency) [5m]) by (path)`,
+		`histogram_quantile(0.99, sum by (le) (histogram_over_time({job="nginx"} | unwrap latency [5m])))`,
+		`count_distinct_over_time({job="nginx"} | json | unwrap user [5m]) by (path)`,
+		`approx_count_distinct by (path) (hll_over_time({job="nginx"} | json | unwrap user [5m]))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m]))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m] offset 10m))`,
 		`sum by (cluster) (count_over_time({job="mysql"}[5m])) / sum by (cluster) (count_over_time({job="postgres"}[5m])) `,
@@ -225,6 +233,25 @@ func Test_SampleExpr_Sort_Fail(t *testing.T) {
 	}
 }
 
+func Test_SubqueryExpr_Fail(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{`rate(count_over_time({job="nginx"}[1m])[1h:1m])`, "invalid aggregation rate in subquery"},
+		{`max_over_time(count_over_time({job="nginx"}[1m])[0s:1m])`, "invalid subquery range 0s"},
+		{`quantile_over_time(count_over_time({job="nginx"}[1m])[1h:1m])`, "parameter required for operation quantile_over_time"},
+		{`max_over_time(1, count_over_time({job="nginx"}[1m])[1h:1m])`, "parameter 1 not supported for operation max_over_time"},
+		{`max_over_time(count_over_time({job="nginx"}[1m])[foo:1m])`, "not a valid duration string"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseExpr(tc.query)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
 func TestMatcherGroups(t *testing.T) {
 	for i, tc := range []struct {
 		query string
@@ -258,6 +285,18 @@ func TestMatcherGroups(t *testing.T) {
 				},
 			},
 		},
+		{
+			query: `max_over_time(count_over_time({job="foo"}[5m] offset 10m)[1h:1m] offset 1h)`,
+			exp: []MatcherRange{
+				{
+					Interval: time.Hour + 5*time.Minute,
+					Offset:   time.Hour + 10*time.Minute,
+					Matchers: []*labels.Matcher{
+						labels.MustNewMatcher(labels.MatchEqual, "job", "foo"),
+					},
+				},
+			},
+		},
 	} {
 		t.Run(fmt.Sprint(i), func(t *testing.T) {
 			expr, err := ParseExpr(tc.query)
@@ -436,6 +475,38 @@ func TestStringer(t *testing.T) {
 			in:  `0 > count_over_time({foo="bar"}[1m])`,
 			out: `(0 > count_over_time({foo="bar"}[1m]))`,
 		},
+		{
+			in:  `{foo="bar"} | logfmt | lookup   "owners"   on service`,
+			out: `{foo="bar"} | logfmt | lookup "owners" on service`,
+		},
+		{
+			in:  `{foo="bar"} | logfmt | sort   by  duration   desc |  head  20`,
+			out: `{foo="bar"} | logfmt | sort by duration desc | head 20`,
+		},
+		{
+			in:  `{foo="bar"} | multiline   firstline = "^\\d{4}-" | logfmt`,
+			out: `{foo="bar"} | multiline firstline="^\\d{4}-" | logfmt`,
+		},
+		{
+			in:  `{foo="bar"} | xml | xml  user = "/event/user" , id="//@id"`,
+			out: `{foo="bar"} | xml | xml user="/event/user",id="//@id"`,
+		},
+		{
+			in:  `{foo="bar"} | csv  columns = "ts, level,msg"  delimiter="\t" | csv columns="a"`,
+			out: `{foo="bar"} | csv columns="ts,level,msg" delimiter="\t" | csv columns="a"`,
+		},
+		{
+			in:  `{foo="bar"} | json_array   | json ids="items[*].id"`,
+			out: `{foo="bar"} | json_array | json ids="items[*].id"`,
+		},
+		{
+			in:  `{foo="bar"} | json_array  "events"`,
+			out: `{foo="bar"} | json_array "events"`,
+		},
+		{
+			in:  `{foo="bar"} |= "error" | context  after = 3 before = 0`,
+			out: `{foo="bar"} |= "error" | context after=3`,
+		},
 	} {
 		t.Run(tc.in, func(t *testing.T) {
 			expr, err := ParseExpr(tc.in)
@@ -19,6 +19,7 @@ import (
   Matcher                 *labels.Matcher
   Matchers                []*labels.Matcher
   RangeAggregationExpr    SampleExpr
+  SubqueryExpr            SampleExpr
   RangeOp                 string
   ConvOp                  string
   Selector                []*labels.Matcher
@@ -30,10 +31,12 @@ import (
   FilterOp                string
   BinOpExpr               SampleExpr
   LabelReplaceExpr        SampleExpr
+  HistogramQuantileExpr   SampleExpr
   binOp                   string
   bytes                   uint64
   str                     string
   duration                time.Duration
+  subqueryRange           subqueryRange
   LiteralExpr             *LiteralExpr
   BinOpModifier           *BinOpOptions
   BoolModifier            *BinOpOptions
@@ -72,6 +75,7 @@ import (
   KeepLabel               log.KeepLabel
   KeepLabels              []log.KeepLabel
   KeepLabelsExpr          *KeepLabelsExpr
+  LookupExpr              *LookupExpr
 }
 
 %start root
@@ -86,6 +90,7 @@ import (
 %type <Matcher>               matcher
 %type <Matchers>              matchers
 %type <RangeAggregationExpr>  rangeAggregationExpr
+%type <SubqueryExpr>          subqueryExpr
 %type <RangeOp>               rangeOp
 %type <ConvOp>                convOp
 %type <Selector>              selector
@@ -97,6 +102,7 @@ import (
 %type <BinOpExpr>             binOpExpr
 %type <LiteralExpr>           literalExpr
 %type <LabelReplaceExpr>      labelReplaceExpr
+%type <HistogramQuantileExpr> histogramQuantileExpr
 %type <BinOpModifier>         binOpModifier
 %type <BoolModifier>          boolModifier
 %type <OnOrIgnoringModifier>  onOrIgnoringModifier
@@ -112,7 +118,7 @@ import (
 %type <LineFilter>            lineFilter
 %type <DistinctFilter>        distinctFilter
 %type <DistinctLabel>         distinctLabel
-%type <ParserFlags>           parserFlags
+%type <ParserFlags>           parserFlags csvParserParams
 %type <LineFormatExpr>        lineFormatExpr
 %type <DecolorizeExpr>        decolorizeExpr
 %type <DropLabelsExpr>        dropLabelsExpr
@@ -121,6 +127,8 @@ import (
 %type <KeepLabelsExpr>        keepLabelsExpr
 %type <KeepLabels>            keepLabels
 %type <KeepLabel>             keepLabel
+%type <LookupExpr>            lookupExpr
+%type <PipelineStage>         sortExpr limitExpr multilineExpr contextExpr jsonArrayExpr xmlExpressionParser csvParser
 %type <LabelFormatExpr>       labelFormatExpr
 %type <LabelFormat>           labelFormat
 %type <LabelsFormat>          labelsFormat
@@ -136,12 +144,13 @@ import (
 %token <bytes> BYTES
 %token <str>      IDENTIFIER STRING NUMBER PARSER_FLAG
 %token <duration> DURATION RANGE
+%token <subqueryRange> SUBQUERY_RANGE
 %token <val>      MATCHERS LABELS EQ RE NRE OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT
                   OPEN_PARENTHESIS CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE RATE_COUNTER SUM SORT SORT_DESC AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
                   BYTES_OVER_TIME BYTES_RATE BOOL JSON DISTINCT REGEXP LOGFMT PIPE LINE_FMT LABEL_FMT UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
                   MAX_OVER_TIME STDVAR_OVER_TIME STDDEV_OVER_TIME QUANTILE_OVER_TIME BYTES_CONV DURATION_CONV DURATION_SECONDS_CONV
                   FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME VECTOR LABEL_REPLACE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
-                  DECOLORIZE DROP KEEP
+                  DECOLORIZE DROP KEEP HISTOGRAM_OVER_TIME HISTOGRAM_QUANTILE COUNT_DISTINCT_OVER_TIME HLL_OVER_TIME APPROX_COUNT_DISTINCT LOOKUP HEAD TAIL MULTILINE CONTEXT JSON_ARRAY XML CSV
 
 // Operators are listed with increasing precedence.
 %left <binOp> OR
@@ -162,10 +171,12 @@ expr:
 
 metricExpr:
       rangeAggregationExpr                          { $$ = $1 }
+    | subqueryExpr                                  { $$ = $1 }
     | vectorAggregationExpr                         { $$ = $1 }
     | binOpExpr                                     { $$ = $1 }
     | literalExpr                                   { $$ = $1 }
     | labelReplaceExpr                              { $$ = $1 }
+    | histogramQuantileExpr                         { $$ = $1 }
     | vectorExpr                                    { $$ = $1 }
     | OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS { $$ = $2 }
     ;
@@ -224,6 +235,13 @@ rangeAggregationExpr:
     | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS grouping  { $$ = newRangeAggregationExpr($5, $1, $7, &$3) }
     ;
 
+subqueryExpr:
+      rangeOp OPEN_PARENTHESIS metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS                                   { $$ = newSubqueryExpr($3, $1, $4, nil, nil) }
+    | rangeOp OPEN_PARENTHESIS metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS                        { $$ = newSubqueryExpr($3, $1, $4, $5, nil) }
+    | rangeOp OPEN_PARENTHESIS NUMBER COMMA metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS                      { $$ = newSubqueryExpr($5, $1, $6, nil, &$3) }
+    | rangeOp OPEN_PARENTHESIS NUMBER COMMA metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS           { $$ = newSubqueryExpr($5, $1, $6, $7, &$3) }
+    ;
+
 vectorAggregationExpr:
     // Aggregations with 1 argument.
       vectorOp OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS                               { $$ = mustNewVectorAggregationExpr($3, $1, nil, nil) }
@@ -240,6 +258,10 @@ labelReplaceExpr:
       { $$ = mustNewLabelReplaceExpr($3, $5, $7, $9, $11)}
     ;
 
+histogramQuantileExpr:
+    HISTOGRAM_QUANTILE OPEN_PARENTHESIS NUMBER COMMA metricExpr CLOSE_PARENTHESIS { $$ = newHistogramQuantileExpr($5, $3) }
+    ;
+
 filter:
       PIPE_MATCH                       { $$ = labels.MatchRegexp }
     | PIPE_EXACT                       { $$ = labels.MatchEqual }
@@ -275,6 +297,8 @@ pipelineStage:
   | PIPE logfmtParser            { $$ = $2 }
   | PIPE labelParser             { $$ = $2 }
   | PIPE jsonExpressionParser    { $$ = $2 }
+  | PIPE xmlExpressionParser     { $$ = $2 }
+  | PIPE csvParser               { $$ = $2 }
   | PIPE logfmtExpressionParser  { $$ = $2 }
   | PIPE labelFilter             { $$ = &LabelFilterExpr{LabelFilterer: $2 }}
   | PIPE lineFormatExpr          { $$ = $2 }
@@ -283,6 +307,12 @@ pipelineStage:
   | PIPE dropLabelsExpr          { $$ = $2 }
   | PIPE keepLabelsExpr          { $$ = $2 }
   | PIPE distinctFilter          { $$ = $2 }
+  | PIPE lookupExpr              { $$ = $2 }
+  | PIPE sortExpr                { $$ = $2 }
+  | PIPE limitExpr               { $$ = $2 }
+  | PIPE multilineExpr           { $$ = $2 }
+  | PIPE contextExpr             { $$ = $2 }
+  | PIPE jsonArrayExpr           { $$ = $2 }
  ;
 
 filterOp:
@@ -314,11 +344,23 @@ labelParser:
   | REGEXP STRING       { $$ = newLabelParserExpr(OpParserTypeRegexp, $2) }
   | UNPACK              { $$ = newLabelParserExpr(OpParserTypeUnpack, "") }
   | PATTERN STRING      { $$ = newLabelParserExpr(OpParserTypePattern, $2) }
+  | XML                 { $$ = newLabelParserExpr(OpParserTypeXML, "") }
   ;
 
 jsonExpressionParser:
     JSON labelExtractionExpressionList { $$ = newJSONExpressionParser($2) }
 
+xmlExpressionParser:
+    XML labelExtractionExpressionList { $$ = mustNewXMLExpressionParser($2) }
+
+csvParserParams:
+    IDENTIFIER EQ STRING                    { $$ = []string{ $1, $3 } }
+  | csvParserParams IDENTIFIER EQ STRING    { $$ = append($1, $2, $4) }
+  ;
+
+csvParser:
+    CSV csvParserParams { $$ = mustNewCSVParserExpr($2) }
+
 logfmtExpressionParser:
     LOGFMT parserFlags labelExtractionExpressionList  { $$ = newLogfmtExpressionParser($3, $2)}
   | LOGFMT labelExtractionExpressionList              { $$ = newLogfmtExpressionParser($2, nil)}
@@ -432,6 +474,30 @@ keepLabels:
 
 keepLabelsExpr: KEEP keepLabels { $$ = newKeepLabelsExpr($2) }
 
+lookupExpr: LOOKUP STRING ON IDENTIFIER { $$ = newLookupExpr($2, $4) }
+
+sortExpr:
+      SORT BY IDENTIFIER              { $$ = mustNewSortExpr($3, "") }
+    | SORT BY IDENTIFIER IDENTIFIER   { $$ = mustNewSortExpr($3, $4) }
+    ;
+
+limitExpr:
+      HEAD NUMBER     { $$ = mustNewLimitExpr(OpHead, $2) }
+    | TAIL NUMBER     { $$ = mustNewLimitExpr(OpTail, $2) }
+    ;
+
+multilineExpr: MULTILINE IDENTIFIER EQ STRING { $$ = mustNewMultilineExpr($2, $4) }
+
+jsonArrayExpr:
+      JSON_ARRAY            { $$ = mustNewJSONArrayExpr("") }
+    | JSON_ARRAY STRING     { $$ = mustNewJSONArrayExpr($2) }
+    ;
+
+contextExpr:
+      CONTEXT IDENTIFIER EQ NUMBER                          { $$ = mustNewContextExpr($2, $4) }
+    | CONTEXT IDENTIFIER EQ NUMBER IDENTIFIER EQ NUMBER     { $$ = mustNewContextExpr($2, $4, $5, $7) }
+    ;
+
 // Operator precedence only works if each of these is listed separately.
 binOpExpr:
          expr OR binOpModifier expr          { $$ = mustNewBinOpExpr("or", $3, $1, $4) }
@@ -546,24 +612,28 @@ vectorOp:
       | TOPK    { $$ = OpTypeTopK }
       | SORT    { $$ = OpTypeSort }
       | SORT_DESC    { $$ = OpTypeSortDesc }
+      | APPROX_COUNT_DISTINCT { $$ = OpTypeApproxCountDistinct }
       ;
 
 rangeOp:
-      COUNT_OVER_TIME    { $$ = OpRangeTypeCount }
-    | RATE               { $$ = OpRangeTypeRate }
-    | RATE_COUNTER       { $$ = OpRangeTypeRateCounter }
-    | BYTES_OVER_TIME    { $$ = OpRangeTypeBytes }
-    | BYTES_RATE         { $$ = OpRangeTypeBytesRate }
-    | AVG_OVER_TIME      { $$ = OpRangeTypeAvg }
-    | SUM_OVER_TIME      { $$ = OpRangeTypeSum }
-    | MIN_OVER_TIME      { $$ = OpRangeTypeMin }
-    | MAX_OVER_TIME      { $$ = OpRangeTypeMax }
-    | STDVAR_OVER_TIME   { $$ = OpRangeTypeStdvar }
-    | STDDEV_OVER_TIME   { $$ = OpRangeTypeStddev }
-    | QUANTILE_OVER_TIME { $$ = OpRangeTypeQuantile }
-    | FIRST_OVER_TIME    { $$ = OpRangeTypeFirst }
-    | LAST_OVER_TIME     { $$ = OpRangeTypeLast }
-    | ABSENT_OVER_TIME   { $$ = OpRangeTypeAbsent }
+      COUNT_OVER_TIME          { $$ = OpRangeTypeCount }
+    | RATE                     { $$ = OpRangeTypeRate }
+    | RATE_COUNTER             { $$ = OpRangeTypeRateCounter }
+    | BYTES_OVER_TIME          { $$ = OpRangeTypeBytes }
+    | BYTES_RATE               { $$ = OpRangeTypeBytesRate }
+    | AVG_OVER_TIME            { $$ = OpRangeTypeAvg }
+    | SUM_OVER_TIME            { $$ = OpRangeTypeSum }
+    | MIN_OVER_TIME            { $$ = OpRangeTypeMin }
+    | MAX_OVER_TIME            { $$ = OpRangeTypeMax }
+    | STDVAR_OVER_TIME         { $$ = OpRangeTypeStdvar }
+    | STDDEV_OVER_TIME         { $$ = OpRangeTypeStddev }
+    | QUANTILE_OVER_TIME       { $$ = OpRangeTypeQuantile }
+    | FIRST_OVER_TIME          { $$ = OpRangeTypeFirst }
+    | LAST_OVER_TIME           { $$ = OpRangeTypeLast }
+    | ABSENT_OVER_TIME         { $$ = OpRangeTypeAbsent }
+    | HISTOGRAM_OVER_TIME      { $$ = OpRangeTypeHistogram }
+    | COUNT_DISTINCT_OVER_TIME { $$ = OpRangeTypeCountDistinct }
+    | HLL_OVER_TIME            { $$ = OpRangeTypeHLL }
     ;
 
 offsetExpr:
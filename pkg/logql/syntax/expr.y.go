@@ -22,6 +22,7 @@ type exprSymType struct {
 	Matcher               *labels.Matcher
 	Matchers              []*labels.Matcher
 	RangeAggregationExpr  SampleExpr
+	SubqueryExpr          SampleExpr
 	RangeOp               string
 	ConvOp                string
 	Selector              []*labels.Matcher
@@ -33,10 +34,12 @@ type exprSymType struct {
 	FilterOp              string
 	BinOpExpr             SampleExpr
 	LabelReplaceExpr      SampleExpr
+	HistogramQuantileExpr SampleExpr
 	binOp                 string
 	bytes                 uint64
 	str                   string
 	duration              time.Duration
+	subqueryRange         subqueryRange
 	LiteralExpr           *LiteralExpr
 	BinOpModifier         *BinOpOptions
 	BoolModifier          *BinOpOptions
@@ -75,6 +78,7 @@ type exprSymType struct {
 	KeepLabel      log.KeepLabel
 	KeepLabels     []log.KeepLabel
 	KeepLabelsExpr *KeepLabelsExpr
+	LookupExpr     *LookupExpr
 }
 
 const BYTES = 57346
@@ -84,89 +88,103 @@ const NUMBER = 57349
 const PARSER_FLAG = 57350
 const DURATION = 57351
 const RANGE = 57352
-const MATCHERS = 57353
-const LABELS = 57354
-const EQ = 57355
-const RE = 57356
-const NRE = 57357
-const OPEN_BRACE = 57358
-const CLOSE_BRACE = 57359
-const OPEN_BRACKET = 57360
-const CLOSE_BRACKET = 57361
-const COMMA = 57362
-const DOT = 57363
-const PIPE_MATCH = 57364
-const PIPE_EXACT = 57365
-const OPEN_PARENTHESIS = 57366
-const CLOSE_PARENTHESIS = 57367
-const BY = 57368
-const WITHOUT = 57369
-const COUNT_OVER_TIME = 57370
-const RATE = 57371
-const RATE_COUNTER = 57372
-const SUM = 57373
-const SORT = 57374
-const SORT_DESC = 57375
-const AVG = 57376
-const MAX = 57377
-const MIN = 57378
-const COUNT = 57379
-const STDDEV = 57380
-const STDVAR = 57381
-const BOTTOMK = 57382
-const TOPK = 57383
-const BYTES_OVER_TIME = 57384
-const BYTES_RATE = 57385
-const BOOL = 57386
-const JSON = 57387
-const DISTINCT = 57388
-const REGEXP = 57389
-const LOGFMT = 57390
-const PIPE = 57391
-const LINE_FMT = 57392
-const LABEL_FMT = 57393
-const UNWRAP = 57394
-const AVG_OVER_TIME = 57395
-const SUM_OVER_TIME = 57396
-const MIN_OVER_TIME = 57397
-const MAX_OVER_TIME = 57398
-const STDVAR_OVER_TIME = 57399
-const STDDEV_OVER_TIME = 57400
-const QUANTILE_OVER_TIME = 57401
-const BYTES_CONV = 57402
-const DURATION_CONV = 57403
-const DURATION_SECONDS_CONV = 57404
-const FIRST_OVER_TIME = 57405
-const LAST_OVER_TIME = 57406
-const ABSENT_OVER_TIME = 57407
-const VECTOR = 57408
-const LABEL_REPLACE = 57409
-const UNPACK = 57410
-const OFFSET = 57411
-const PATTERN = 57412
-const IP = 57413
-const ON = 57414
-const IGNORING = 57415
-const GROUP_LEFT = 57416
-const GROUP_RIGHT = 57417
-const DECOLORIZE = 57418
-const DROP = 57419
-const KEEP = 57420
-const OR = 57421
-const AND = 57422
-const UNLESS = 57423
-const CMP_EQ = 57424
-const NEQ = 57425
-const LT = 57426
-const LTE = 57427
-const GT = 57428
-const GTE = 57429
-const ADD = 57430
-const SUB = 57431
-const MUL = 57432
-const DIV = 57433
-const MOD = 57434
-const POW = 57435
+const SUBQUERY_RANGE = 57353
+const MATCHERS = 57354
+const LABELS = 57355
+const EQ = 57356
+const RE = 57357
+const NRE = 57358
+const OPEN_BRACE = 57359
+const CLOSE_BRACE = 57360
+const OPEN_BRACKET = 57361
+const CLOSE_BRACKET = 57362
+const COMMA = 57363
+const DOT = 57364
+const PIPE_MATCH = 57365
+const PIPE_EXACT = 57366
+const OPEN_PARENTHESIS = 57367
+const CLOSE_PARENTHESIS = 57368
+const BY = 57369
+const WITHOUT = 57370
+const COUNT_OVER_TIME = 57371
+const RATE = 57372
+const RATE_COUNTER = 57373
+const SUM = 57374
+const SORT = 57375
+const SORT_DESC = 57376
+const AVG = 57377
+const MAX = 57378
+const MIN = 57379
+const COUNT = 57380
+const STDDEV = 57381
+const STDVAR = 57382
+const BOTTOMK = 57383
+const TOPK = 57384
+const BYTES_OVER_TIME = 57385
+const BYTES_RATE = 57386
+const BOOL = 57387
+const JSON = 57388
+const DISTINCT = 57389
+const REGEXP = 57390
+const LOGFMT = 57391
+const PIPE = 57392
+const LINE_FMT = 57393
+const LABEL_FMT = 57394
+const UNWRAP = 57395
+const AVG_OVER_TIME = 57396
+const SUM_OVER_TIME = 57397
+const MIN_OVER_TIME = 57398
+const MAX_OVER_TIME = 57399
+const STDVAR_OVER_TIME = 57400
+const STDDEV_OVER_TIME = 57401
+const QUANTILE_OVER_TIME = 57402
+const BYTES_CONV = 57403
+const DURATION_CONV = 57404
+const DURATION_SECONDS_CONV = 57405
+const FIRST_OVER_TIME = 57406
+const LAST_OVER_TIME = 57407
+const ABSENT_OVER_TIME = 57408
+const VECTOR = 57409
+const LABEL_REPLACE = 57410
+const UNPACK = 57411
+const OFFSET = 57412
+const PATTERN = 57413
+const IP = 57414
+const ON = 57415
+const IGNORING = 57416
+const GROUP_LEFT = 57417
+const GROUP_RIGHT = 57418
+const DECOLORIZE = 57419
+const DROP = 57420
+const KEEP = 57421
+const HISTOGRAM_OVER_TIME = 57422
+const HISTOGRAM_QUANTILE = 57423
+const COUNT_DISTINCT_OVER_TIME = 57424
+const HLL_OVER_TIME = 57425
+const APPROX_COUNT_DISTINCT = 57426
+const LOOKUP = 57427
+const HEAD = 57428
+const TAIL = 57429
+const MULTILINE = 57430
+const CONTEXT = 57431
+const JSON_ARRAY = 57432
+const XML = 57433
+const CSV = 57434
+const OR = 57435
+const AND = 57436
+const UNLESS = 57437
+const CMP_EQ = 57438
+const NEQ = 57439
+const LT = 57440
+const LTE = 57441
+const GT = 57442
+const GTE = 57443
+const ADD = 57444
+const SUB = 57445
+const MUL = 57446
+const DIV = 57447
+const MOD = 57448
+const POW = 57449
 
 var exprToknames = [...]string{
 	"$end",
@@ -179,6 +197,7 @@ var exprToknames = [...]string{
 	"PARSER_FLAG",
 	"DURATION",
 	"RANGE",
+	"SUBQUERY_RANGE",
 	"MATCHERS",
 	"LABELS",
 	"EQ",
@@ -247,6 +266,19 @@ var exprToknames = [...]string{
 	"DECOLORIZE",
 	"DROP",
 	"KEEP",
+	"HISTOGRAM_OVER_TIME",
+	"HISTOGRAM_QUANTILE",
+	"COUNT_DISTINCT_OVER_TIME",
+	"HLL_OVER_TIME",
+	"APPROX_COUNT_DISTINCT",
+	"LOOKUP",
+	"HEAD",
+	"TAIL",
+	"MULTILINE",
+	"CONTEXT",
+	"JSON_ARRAY",
+	"XML",
+	"CSV",
 	"OR",
 	"AND",
 	"UNLESS",
@@ -263,6 +295,7 @@ var exprToknames = [...]string{
 	"MOD",
 	"POW",
 }
+
 var exprStatenames = [...]string{}
 
 const exprEofCode = 1
@@ -270,7 +303,7 @@ const exprErrCode = 2
 const exprInitialStackSize = 16
 
 
-var exprExca = [...]int{
+var exprExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -278,260 +311,309 @@ var exprExca = [...]int{
 
 const exprPrivate = 57344
 
-const exprLast = 587
-
-var exprAct = [...]int{
-
-	284, 225, 82, 4, 125, 64, 181, 203, 196, 199,
-	73, 234, 188, 63, 56, 5, 287, 151, 78, 186,
-	75, 2, 51, 52, 53, 54, 55, 56, 165, 166,
-	16, 53, 54, 55, 56, 292, 290, 163, 164, 13,
-	289, 71, 359, 147, 149, 150, 92, 6, 69, 70,
-	359, 21, 22, 23, 36, 45, 46, 37, 39, 40,
-	38, 41, 42, 43, 44, 24, 25, 139, 67, 107,
-	287, 83, 84, 113, 379, 227, 26, 27, 28, 29,
-	30, 31, 32, 374, 367, 155, 33, 34, 35, 47,
-	19, 160, 333, 366, 288, 364, 153, 57, 58, 61,
-	62, 59, 60, 51, 52, 53, 54, 55, 56, 72,
-	162, 17, 18, 148, 167, 168, 169, 170, 171, 172,
-	173, 174, 175, 176, 177, 178, 179, 180, 208, 149,
-	150, 289, 141, 289, 108, 236, 193, 334, 201, 205,
-	81, 190, 83, 84, 343, 301, 324, 136, 301, 136,
-	350, 216, 71, 349, 299, 311, 240, 356, 301, 69,
-	70, 232, 183, 348, 183, 221, 129, 226, 129, 252,
-	228, 237, 229, 48, 49, 50, 57, 58, 61, 62,
-	59, 60, 51, 52, 53, 54, 55, 56, 325, 245,
-	246, 247, 336, 337, 338, 230, 13, 214, 209, 212,
-	213, 210, 211, 136, 154, 249, 49, 50, 57, 58,
-	61, 62, 59, 60, 51, 52, 53, 54, 55, 56,
-	72, 136, 129, 184, 182, 282, 285, 236, 291, 143,
-	294, 301, 107, 297, 113, 298, 347, 236, 286, 153,
-	129, 283, 295, 121, 135, 122, 120, 309, 130, 132,
-	292, 71, 136, 305, 307, 310, 312, 308, 69, 70,
-	201, 205, 315, 313, 320, 319, 123, 183, 124, 142,
-	236, 129, 333, 301, 131, 133, 134, 301, 303, 288,
-	136, 377, 302, 221, 221, 227, 326, 362, 328, 330,
-	306, 332, 107, 136, 340, 236, 331, 342, 327, 129,
-	323, 107, 71, 322, 344, 287, 296, 222, 183, 69,
-	70, 289, 129, 244, 373, 238, 243, 236, 289, 72,
-	121, 135, 122, 120, 71, 130, 132, 182, 353, 354,
-	152, 69, 70, 107, 355, 242, 227, 235, 241, 13,
-	357, 358, 215, 123, 159, 124, 363, 154, 158, 157,
-	88, 131, 133, 134, 233, 87, 80, 346, 66, 369,
-	250, 370, 371, 13, 300, 257, 256, 184, 182, 255,
-	72, 6, 253, 375, 239, 21, 22, 23, 36, 45,
-	46, 37, 39, 40, 38, 41, 42, 43, 44, 24,
-	25, 231, 72, 223, 264, 254, 218, 265, 156, 263,
-	26, 27, 28, 29, 30, 31, 32, 13, 251, 372,
-	33, 34, 35, 47, 19, 6, 361, 360, 339, 21,
-	22, 23, 36, 45, 46, 37, 39, 40, 38, 41,
-	42, 43, 44, 24, 25, 17, 18, 260, 329, 217,
-	261, 79, 259, 378, 26, 27, 28, 29, 30, 31,
-	32, 317, 318, 77, 33, 34, 35, 47, 19, 290,
-	376, 262, 189, 224, 71, 248, 145, 89, 71, 161,
-	189, 69, 70, 187, 341, 69, 70, 224, 293, 17,
-	18, 144, 71, 279, 146, 86, 280, 85, 278, 69,
-	70, 276, 273, 126, 277, 274, 275, 272, 227, 365,
-	270, 3, 227, 271, 258, 269, 267, 352, 74, 268,
-	351, 266, 316, 314, 304, 197, 227, 93, 94, 95,
-	96, 97, 98, 99, 100, 101, 102, 103, 104, 105,
-	106, 281, 72, 220, 219, 218, 72, 217, 194, 192,
-	191, 368, 345, 321, 204, 200, 189, 79, 207, 197,
-	72, 127, 111, 112, 195, 116, 202, 118, 198, 117,
-	115, 114, 185, 206, 119, 65, 137, 128, 138, 109,
-	110, 91, 90, 11, 10, 9, 140, 20, 12, 15,
-	8, 335, 14, 7, 76, 68, 1,
+const exprLast = 802
+
+var exprAct = [...]int16{
+	331, 4, 264, 89, 71, 143, 233, 208, 80, 229,
+	226, 215, 70, 272, 5, 3, 176, 63, 213, 190,
+	191, 85, 81, 55, 56, 57, 64, 65, 68, 69,
+	66, 67, 58, 59, 60, 61, 62, 63, 56, 57,
+	64, 65, 68, 69, 66, 67, 58, 59, 60, 61,
+	62, 63, 64, 65, 68, 69, 66, 67, 58, 59,
+	60, 61, 62, 63, 58, 59, 60, 61, 62, 63,
+	60, 61, 62, 63, 299, 115, 172, 174, 175, 192,
+	193, 123, 332, 414, 245, 174, 175, 161, 18, 178,
+	181, 340, 339, 423, 100, 239, 186, 444, 15, 439,
+	82, 2, 179, 210, 90, 91, 6, 147, 293, 418,
+	24, 25, 26, 42, 51, 52, 43, 45, 46, 44,
+	47, 48, 49, 50, 27, 28, 309, 332, 255, 310,
+	330, 308, 391, 423, 161, 29, 30, 31, 32, 33,
+	34, 35, 431, 74, 78, 36, 37, 38, 54, 21,
+	210, 76, 77, 332, 147, 223, 217, 231, 235, 173,
+	220, 39, 22, 40, 41, 53, 251, 246, 249, 250,
+	247, 248, 78, 164, 332, 211, 209, 253, 265, 76,
+	77, 349, 80, 19, 20, 270, 408, 430, 393, 394,
+	395, 429, 262, 78, 307, 266, 81, 267, 189, 275,
+	76, 77, 194, 195, 196, 197, 198, 199, 200, 201,
+	202, 203, 204, 205, 206, 207, 116, 284, 285, 286,
+	333, 161, 211, 209, 349, 79, 78, 265, 385, 407,
+	385, 288, 426, 76, 77, 349, 396, 210, 263, 166,
+	406, 147, 333, 404, 78, 400, 420, 332, 78, 382,
+	338, 76, 77, 79, 342, 76, 77, 258, 442, 379,
+	265, 347, 329, 327, 335, 334, 336, 115, 339, 343,
+	339, 346, 345, 123, 79, 179, 337, 328, 265, 341,
+	353, 380, 265, 305, 78, 254, 306, 274, 304, 161,
+	339, 76, 77, 349, 354, 356, 359, 361, 405, 161,
+	274, 362, 231, 235, 371, 366, 370, 79, 360, 147,
+	209, 88, 338, 90, 91, 210, 279, 155, 73, 147,
+	274, 358, 349, 268, 274, 79, 168, 351, 399, 79,
+	137, 153, 138, 136, 384, 148, 150, 340, 386, 389,
+	388, 357, 115, 263, 397, 355, 115, 390, 387, 78,
+	401, 303, 339, 139, 258, 140, 76, 77, 274, 349,
+	274, 149, 151, 152, 350, 79, 167, 258, 161, 154,
+	156, 157, 158, 159, 160, 141, 142, 437, 344, 276,
+	403, 273, 415, 265, 413, 378, 416, 377, 147, 283,
+	417, 259, 115, 282, 281, 280, 252, 185, 184, 421,
+	183, 422, 96, 95, 425, 94, 87, 289, 348, 298,
+	297, 296, 170, 294, 278, 277, 18, 269, 260, 86,
+	433, 428, 364, 381, 435, 436, 15, 302, 169, 301,
+	79, 171, 84, 295, 180, 440, 292, 290, 24, 25,
+	26, 42, 51, 52, 43, 45, 46, 44, 47, 48,
+	49, 50, 27, 28, 324, 321, 261, 325, 322, 323,
+	320, 438, 434, 29, 30, 31, 32, 33, 34, 35,
+	424, 419, 398, 36, 37, 38, 54, 21, 318, 315,
+	383, 319, 316, 317, 314, 216, 376, 443, 287, 39,
+	22, 40, 41, 53, 312, 271, 241, 313, 216, 311,
+	441, 214, 368, 369, 427, 15, 240, 188, 187, 93,
+	92, 19, 20, 6, 412, 411, 409, 24, 25, 26,
+	42, 51, 52, 43, 45, 46, 44, 47, 48, 49,
+	50, 27, 28, 375, 367, 365, 363, 227, 144, 352,
+	326, 257, 29, 30, 31, 32, 33, 34, 35, 256,
+	255, 254, 36, 37, 38, 54, 21, 244, 238, 224,
+	219, 218, 432, 410, 402, 374, 373, 372, 39, 22,
+	40, 41, 53, 234, 182, 230, 216, 300, 291, 86,
+	243, 242, 237, 227, 15, 222, 145, 119, 122, 225,
+	19, 20, 6, 126, 121, 120, 24, 25, 26, 42,
+	51, 52, 43, 45, 46, 44, 47, 48, 49, 50,
+	27, 28, 135, 134, 133, 132, 131, 130, 232, 128,
+	228, 29, 30, 31, 32, 33, 34, 35, 127, 125,
+	124, 36, 37, 38, 54, 21, 221, 212, 236, 129,
+	72, 162, 146, 163, 117, 118, 99, 39, 22, 40,
+	41, 53, 98, 177, 13, 12, 11, 10, 165, 23,
+	14, 17, 9, 15, 392, 16, 8, 7, 83, 19,
+	20, 180, 75, 1, 0, 24, 25, 26, 42, 51,
+	52, 43, 45, 46, 44, 47, 48, 49, 50, 27,
+	28, 0, 0, 0, 0, 161, 0, 0, 0, 0,
+	29, 30, 31, 32, 33, 34, 35, 0, 0, 0,
+	36, 37, 38, 54, 21, 147, 0, 0, 0, 0,
+	0, 0, 0, 155, 0, 0, 39, 22, 40, 41,
+	53, 97, 0, 0, 0, 0, 137, 153, 138, 136,
+	0, 148, 150, 0, 0, 0, 0, 0, 19, 20,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 139,
+	0, 140, 0, 0, 0, 0, 0, 149, 151, 152,
+	0, 0, 0, 0, 0, 154, 156, 157, 158, 159,
+	160, 141, 142, 0, 0, 0, 0, 0, 101, 102,
+	103, 104, 105, 106, 107, 108, 109, 110, 111, 112,
+	113, 114,
 }
-var exprPact = [...]int{
 
-	23, -1000, 94, -1000, -1000, 309, 23, -1000, -1000, -1000,
-	-1000, -1000, -1000, 436, 332, 116, -1000, 480, 478, 331,
-	326, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+var exprPact = [...]int16{
+	81, -1000, -70, -1000, -1000, 268, 81, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, 414, 381, 286, -1000, 503,
+	502, 380, 378, 377, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 2, 2,
-	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
-	2, 2, 2, 309, -1000, 137, 275, -1000, 61, -1000,
-	-1000, -1000, -1000, 244, 204, 94, 464, -1000, -1000, 30,
-	323, 391, 325, 324, 320, -1000, -1000, 23, 462, 23,
-	-35, -46, -1000, 23, 23, 23, 23, 23, 23, 23,
-	23, 23, 23, 23, 23, 23, 23, -1000, -1000, -1000,
-	-1000, -1000, -1000, 288, -1000, -1000, -1000, -1000, -1000, -1000,
-	465, 541, 534, -1000, 533, -1000, -1000, -1000, -1000, 216,
-	532, -1000, 544, 540, 539, 543, 115, -1000, -1000, -1000,
-	318, -1000, -1000, -1000, -1000, -1000, 542, 531, 529, 528,
-	527, 282, 373, 467, 180, 170, 371, 347, 312, 290,
-	354, 131, 126, 314, 311, 292, 289, 15, 15, -59,
-	-59, -79, -79, -79, -79, -66, -66, -66, -66, -66,
-	-66, 288, 216, 216, 216, 457, 340, -1000, -1000, 395,
-	340, -1000, -1000, 144, -1000, 352, -1000, 382, 349, -1000,
-	30, -1000, 346, -1000, 30, -1000, 345, -1000, 433, 390,
-	502, 496, 488, 487, 479, 525, -1000, -1000, -1000, -1000,
-	-1000, -1000, 45, 180, 236, 84, 26, 198, 453, 281,
-	45, 23, 129, 344, 257, -1000, -1000, 253, -1000, 508,
-	-1000, 265, 232, 222, 130, 142, 288, 247, -1000, 340,
-	541, 507, -1000, 510, 446, 540, 539, 538, 279, -1000,
-	-1000, -1000, 276, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, 121, -1000, 163, 287, -9, 287, 429, -53, 216,
-	-53, 82, 132, 408, 269, 449, -1000, -1000, 119, -1000,
-	23, 537, -1000, -1000, 337, 211, -1000, 138, -1000, -1000,
-	128, -1000, 125, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 504, 501, -1000, 45, -9, 287, -9, -1000,
-	-1000, 288, -1000, -53, -1000, 133, -1000, -1000, -1000, 1,
-	407, 406, 262, 45, 70, -1000, 493, -1000, -1000, -1000,
-	-1000, 68, 59, -1000, -9, -1000, 536, -7, -9, -17,
-	-53, -53, 399, -1000, -1000, 294, -1000, -1000, 58, -9,
-	-1000, -1000, -53, 454, -1000, -1000, 261, 437, 49, -1000,
+	-1000, -1000, -1000, -1000, -1000, 49, 49, 49, 49, 49,
+	49, 49, 49, 49, 49, 49, 49, 49, 49, 49,
+	268, -1000, 156, 690, -1000, 167, -1000, -1000, -1000, -1000,
+	340, 300, -70, 410, -1000, -1000, 62, 646, 567, 375,
+	373, 372, -1000, -1000, 81, 501, 500, 81, -54, 4,
+	-1000, 81, 81, 81, 81, 81, 81, 81, 81, 81,
+	81, 81, 81, 81, 81, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 129, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, 493, 571, 555, -1000,
+	554, 571, 580, -1000, -1000, -1000, -1000, 363, 553, -1000,
+	578, 570, 568, 577, 552, 68, 499, 489, 576, 575,
+	551, 70, -1000, -1000, -1000, 371, -1000, -1000, -1000, -1000,
+	-1000, 574, 545, 544, 543, 535, 365, 397, 445, 333,
+	409, 297, 396, 488, 355, 353, 394, 393, 290, -56,
+	370, 369, 368, 364, -44, -44, -34, -34, -90, -90,
+	-90, -90, -38, -38, -38, -38, -38, -38, 129, 363,
+	363, 363, 480, 386, -1000, -1000, 423, 386, -1000, -1000,
+	386, 573, 422, 82, -1000, 392, -1000, 419, 390, -1000,
+	62, -1000, 389, -1000, 62, -1000, 388, -1000, 1, 572,
+	-1000, -1000, 415, 413, -1000, 279, 122, 490, 475, 474,
+	451, 450, 534, -1000, -1000, -1000, -1000, -1000, -1000, 77,
+	409, 104, 232, 177, 240, 284, 228, 352, 77, 81,
+	235, 387, 338, -1000, -1000, 301, -1000, 533, 81, -1000,
+	319, 315, 295, 282, 294, 129, 216, -1000, 386, 571,
+	530, 408, 529, -1000, 532, 497, 570, 568, 562, 561,
+	560, 527, 479, 362, -1000, -1000, -1000, 360, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, 233, -1000, 255, 412,
+	-1000, 223, 471, 12, 218, 128, 42, 128, 12, 363,
+	127, 210, 462, 302, -1000, -1000, 219, -1000, 81, 559,
+	-1000, -1000, 359, 217, 272, -1000, 214, -1000, -1000, 203,
+	-1000, 160, -1000, -1000, 510, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, 558, 509, 508, -1000,
+	77, 57, -1000, -1000, -1000, 12, 42, 128, 42, -1000,
+	129, -1000, 84, -1000, -1000, -1000, 461, 220, 83, 460,
+	77, 206, -1000, 498, -1000, -1000, -1000, -1000, -1000, -1000,
+	407, 165, 161, -1000, -1000, 116, -1000, 42, 557, 12,
+	452, 43, 42, 38, 12, -1000, -1000, 356, 454, -1000,
+	-1000, -1000, 73, -1000, 12, 42, -1000, 494, -1000, -1000,
+	-1000, 237, 481, 71, -1000,
 }
-var exprPgo = [...]int{
-
-	0, 586, 20, 585, 2, 11, 501, 3, 17, 4,
-	584, 583, 582, 581, 15, 580, 579, 578, 577, 576,
-	575, 574, 573, 467, 572, 571, 570, 569, 13, 5,
-	568, 567, 566, 6, 565, 68, 564, 563, 562, 561,
-	560, 559, 558, 9, 557, 556, 7, 555, 8, 554,
-	12, 19, 553, 552, 1, 551, 493, 0,
+
+var exprPgo = [...]int16{
+	0, 673, 100, 672, 3, 13, 15, 1, 16, 5,
+	668, 667, 666, 665, 664, 14, 662, 661, 660, 659,
+	658, 657, 656, 655, 654, 731, 652, 646, 645, 644,
+	12, 4, 643, 642, 641, 7, 640, 143, 639, 638,
+	637, 636, 630, 629, 628, 620, 9, 619, 618, 6,
+	617, 616, 615, 614, 613, 612, 595, 594, 593, 10,
+	589, 11, 18, 588, 587, 2, 586, 538, 0,
 }
-var exprR1 = [...]int{
 
+var exprR1 = [...]int8{
 	0, 1, 2, 2, 7, 7, 7, 7, 7, 7,
-	7, 6, 6, 6, 8, 8, 8, 8, 8, 8,
+	7, 7, 7, 6, 6, 6, 8, 8, 8, 8,
 	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
 	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	54, 54, 54, 13, 13, 13, 11, 11, 11, 11,
-	15, 15, 15, 15, 15, 15, 22, 3, 3, 3,
-	3, 14, 14, 14, 10, 10, 9, 9, 9, 9,
-	28, 28, 29, 29, 29, 29, 29, 29, 29, 29,
-	29, 29, 29, 29, 19, 35, 35, 34, 34, 38,
-	38, 27, 27, 26, 26, 26, 26, 53, 52, 52,
-	39, 40, 48, 48, 49, 49, 49, 47, 37, 37,
-	36, 33, 33, 33, 33, 33, 33, 33, 33, 33,
-	50, 50, 51, 51, 56, 56, 55, 55, 32, 32,
-	32, 32, 32, 32, 32, 30, 30, 30, 30, 30,
-	30, 30, 31, 31, 31, 31, 31, 31, 31, 43,
-	43, 42, 42, 41, 46, 46, 45, 45, 44, 20,
-	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
-	20, 20, 20, 20, 24, 24, 25, 25, 25, 25,
-	23, 23, 23, 23, 23, 23, 23, 23, 21, 21,
-	21, 17, 18, 16, 16, 16, 16, 16, 16, 16,
-	16, 16, 16, 16, 12, 12, 12, 12, 12, 12,
-	12, 12, 12, 12, 12, 12, 12, 12, 12, 57,
-	5, 5, 4, 4, 4, 4,
+	8, 8, 65, 65, 65, 14, 14, 14, 11, 11,
+	11, 11, 12, 12, 12, 12, 16, 16, 16, 16,
+	16, 16, 23, 24, 3, 3, 3, 3, 15, 15,
+	15, 10, 10, 9, 9, 9, 9, 30, 30, 31,
+	31, 31, 31, 31, 31, 31, 31, 31, 31, 31,
+	31, 31, 31, 31, 31, 31, 31, 31, 31, 20,
+	37, 37, 36, 36, 40, 40, 29, 29, 28, 28,
+	28, 28, 28, 64, 56, 41, 41, 57, 63, 63,
+	42, 43, 59, 59, 60, 60, 60, 58, 39, 39,
+	38, 35, 35, 35, 35, 35, 35, 35, 35, 35,
+	61, 61, 62, 62, 67, 67, 66, 66, 34, 34,
+	34, 34, 34, 34, 34, 32, 32, 32, 32, 32,
+	32, 32, 33, 33, 33, 33, 33, 33, 33, 46,
+	46, 45, 45, 44, 49, 49, 48, 48, 47, 50,
+	51, 51, 52, 52, 53, 55, 55, 54, 54, 21,
+	21, 21, 21, 21, 21, 21, 21, 21, 21, 21,
+	21, 21, 21, 21, 26, 26, 27, 27, 27, 27,
+	25, 25, 25, 25, 25, 25, 25, 25, 22, 22,
+	22, 18, 19, 17, 17, 17, 17, 17, 17, 17,
+	17, 17, 17, 17, 17, 13, 13, 13, 13, 13,
+	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
+	13, 13, 13, 68, 5, 5, 4, 4, 4, 4,
 }
-var exprR2 = [...]int{
 
+var exprR2 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	3, 1, 2, 3, 2, 3, 4, 5, 3, 4,
-	5, 6, 3, 4, 5, 6, 3, 4, 5, 6,
-	4, 5, 6, 7, 3, 4, 4, 5, 3, 2,
-	3, 6, 3, 1, 1, 1, 4, 6, 5, 7,
-	4, 5, 5, 6, 7, 7, 12, 1, 1, 1,
-	1, 3, 3, 2, 1, 3, 3, 3, 3, 3,
-	1, 2, 1, 2, 2, 2, 2, 2, 2, 2,
-	2, 2, 2, 2, 1, 2, 5, 1, 2, 1,
-	2, 1, 2, 1, 2, 1, 2, 2, 3, 2,
+	1, 1, 3, 1, 2, 3, 2, 3, 4, 5,
+	3, 4, 5, 6, 3, 4, 5, 6, 3, 4,
+	5, 6, 4, 5, 6, 7, 3, 4, 4, 5,
+	3, 2, 3, 6, 3, 1, 1, 1, 4, 6,
+	5, 7, 5, 6, 7, 8, 4, 5, 5, 6,
+	7, 7, 12, 6, 1, 1, 1, 1, 3, 3,
+	2, 1, 3, 3, 3, 3, 3, 1, 2, 1,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 1,
+	2, 5, 1, 2, 1, 2, 1, 2, 1, 2,
+	1, 2, 1, 2, 2, 3, 4, 2, 3, 2,
 	2, 1, 3, 3, 1, 3, 3, 2, 1, 3,
 	2, 1, 1, 1, 1, 3, 2, 3, 3, 3,
 	3, 1, 1, 3, 6, 6, 1, 1, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 1,
 	1, 1, 3, 2, 1, 1, 1, 3, 2, 4,
+	3, 4, 2, 2, 4, 1, 2, 4, 7, 4,
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
 	4, 4, 4, 4, 0, 1, 5, 4, 5, 4,
 	1, 1, 2, 4, 5, 2, 4, 5, 1, 2,
 	2, 4, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 2,
-	1, 3, 4, 4, 3, 3,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 2, 1, 3, 4, 4, 3, 3,
 }
-var exprChk = [...]int{
-
-	-1000, -1, -2, -6, -7, -14, 24, -11, -15, -20,
-	-21, -22, -17, 16, -12, -16, 7, 88, 89, 67,
-	-18, 28, 29, 30, 42, 43, 53, 54, 55, 56,
-	57, 58, 59, 63, 64, 65, 31, 34, 37, 35,
-	36, 38, 39, 40, 41, 32, 33, 66, 79, 80,
-	81, 88, 89, 90, 91, 92, 93, 82, 83, 86,
-	87, 84, 85, -28, -29, -34, 49, -35, -3, 22,
-	23, 15, 83, -7, -6, -2, -10, 17, -9, 5,
-	24, 24, -4, 26, 27, 7, 7, 24, 24, -23,
-	-24, -25, 44, -23, -23, -23, -23, -23, -23, -23,
-	-23, -23, -23, -23, -23, -23, -23, -29, -35, -27,
-	-26, -53, -52, -33, -39, -40, -47, -41, -44, -36,
-	48, 45, 47, 68, 70, -9, -56, -55, -31, 24,
-	50, 76, 51, 77, 78, 46, 5, -32, -30, 6,
-	-19, 71, 25, 25, 17, 2, 20, 13, 83, 14,
-	15, -8, 7, -14, 24, -7, 7, 24, 24, 24,
-	-7, 7, -2, 72, 73, 74, 75, -2, -2, -2,
-	-2, -2, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -33, 80, 20, 79, -38, -51, 8, -50, 5,
-	-51, 6, 6, -33, 6, -49, -48, 5, -42, -43,
-	5, -9, -45, -46, 5, -9, -37, 5, 13, 83,
-	86, 87, 84, 85, 82, 24, -9, 6, 6, 6,
-	6, 2, 25, 20, 10, -54, -28, 49, -14, -8,
-	25, 20, -7, 7, -5, 25, 5, -5, 25, 20,
-	25, 24, 24, 24, 24, -33, -33, -33, 8, -51,
-	20, 13, 25, 20, 13, 20, 20, 20, 71, 9,
-	4, 7, 71, 9, 4, 7, 9, 4, 7, 9,
-	4, 7, 9, 4, 7, 9, 4, 7, 9, 4,
-	7, 6, -4, -8, -57, -54, -28, 69, 10, 49,
-	10, -54, 52, 25, -54, -28, 25, -4, -7, 25,
-	20, 20, 25, 25, 6, -5, 25, -5, 25, 25,
-	-5, 25, -5, -50, 6, -48, 2, 5, 6, -43,
-	-46, 5, 24, 24, 25, 25, -54, -28, -54, 9,
-	-57, -33, -57, 10, 5, -13, 60, 61, 62, 10,
-	25, 25, -54, 25, -7, 5, 20, 25, 25, 25,
-	25, 6, 6, -4, -54, -57, 24, -57, -54, 49,
-	10, 10, 25, -4, 25, 6, 25, 25, 5, -54,
-	-57, -57, 10, 20, 25, -57, 6, 20, 6, 25,
+
+var exprChk = [...]int16{
+	-1000, -1, -2, -6, -7, -15, 25, -11, -12, -16,
+	-21, -22, -23, -24, -18, 17, -13, -17, 7, 102,
+	103, 68, 81, -19, 29, 30, 31, 43, 44, 54,
+	55, 56, 57, 58, 59, 60, 64, 65, 66, 80,
+	82, 83, 32, 35, 38, 36, 37, 39, 40, 41,
+	42, 33, 34, 84, 67, 93, 94, 95, 102, 103,
+	104, 105, 106, 107, 96, 97, 100, 101, 98, 99,
+	-30, -31, -36, 50, -37, -3, 23, 24, 16, 97,
+	-7, -6, -2, -10, 18, -9, 5, 25, 25, -4,
+	27, 28, 7, 7, 25, 25, 25, -25, -26, -27,
+	45, -25, -25, -25, -25, -25, -25, -25, -25, -25,
+	-25, -25, -25, -25, -25, -31, -37, -29, -28, -64,
+	-56, -57, -63, -35, -42, -43, -58, -44, -47, -38,
+	-50, -51, -52, -53, -54, -55, 49, 46, 48, 69,
+	71, 91, 92, -9, -67, -66, -33, 25, 51, 77,
+	52, 78, 79, 47, 85, 33, 86, 87, 88, 89,
+	90, 5, -34, -32, 6, -20, 72, 26, 26, 18,
+	2, 21, 14, 97, 15, 16, -8, 7, -7, -15,
+	25, -7, 7, 25, 25, 25, -7, 7, 7, -2,
+	73, 74, 75, 76, -2, -2, -2, -2, -2, -2,
+	-2, -2, -2, -2, -2, -2, -2, -2, -35, 94,
+	21, 93, -40, -62, 8, -61, 5, -62, 6, 6,
+	-62, -41, 5, -35, 6, -60, -59, 5, -45, -46,
+	5, -9, -48, -49, 5, -9, -39, 5, 6, 27,
+	7, 7, 5, 5, 6, 14, 97, 100, 101, 98,
+	99, 96, 25, -9, 6, 6, 6, 6, 2, 26,
+	21, 11, -30, 10, -65, 50, -15, -8, 26, 21,
+	-7, 7, -5, 26, 5, -5, 26, 21, 21, 26,
+	25, 25, 25, 25, -35, -35, -35, 8, -62, 21,
+	14, 5, 14, 26, 21, 14, 21, 21, 21, 73,
+	5, 14, 14, 72, 9, 4, 7, 72, 9, 4,
+	7, 9, 4, 7, 9, 4, 7, 9, 4, 7,
+	9, 4, 7, 9, 4, 7, 6, -4, -8, -7,
+	26, -68, 70, 10, -65, -68, -65, -30, 10, 50,
+	53, -30, 26, -65, 26, -4, -7, 26, 21, 21,
+	26, 26, 6, -7, -5, 26, -5, 26, 26, -5,
+	26, -5, -61, 6, 14, 6, -59, 2, 5, 6,
+	-46, -49, 5, 5, 5, 6, 7, 25, 25, 26,
+	26, 11, 26, 9, -68, 10, -65, -30, -65, -68,
+	-35, 5, -14, 61, 62, 63, 26, -65, 10, 26,
+	26, -7, 5, 21, 26, 26, 26, 26, 26, 6,
+	5, 6, 6, -4, 26, -68, -68, -65, 25, 10,
+	26, -68, -65, 50, 10, -4, 26, 6, 14, 26,
+	26, 26, 5, -68, 10, -65, -68, 21, 7, 26,
+	-68, 6, 21, 6, 26,
 }
-var exprDef = [...]int{
-
-	0, -2, 1, 2, 3, 11, 0, 4, 5, 6,
-	7, 8, 9, 0, 0, 0, 188, 0, 0, 0,
-	0, 204, 205, 206, 207, 208, 209, 210, 211, 212,
-	213, 214, 215, 216, 217, 218, 193, 194, 195, 196,
-	197, 198, 199, 200, 201, 202, 203, 192, 174, 174,
-	174, 174, 174, 174, 174, 174, 174, 174, 174, 174,
-	174, 174, 174, 12, 70, 72, 0, 87, 0, 57,
-	58, 59, 60, 3, 2, 0, 0, 63, 64, 0,
-	0, 0, 0, 0, 0, 189, 190, 0, 0, 0,
-	180, 181, 175, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 71, 88, 73,
-	74, 75, 76, 77, 78, 79, 80, 81, 82, 83,
-	91, 93, 0, 95, 0, 111, 112, 113, 114, 0,
-	0, 101, 0, 0, 0, 0, 0, 126, 127, 85,
-	0, 84, 10, 13, 61, 62, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 3, 188, 0, 0, 0,
-	3, 0, 159, 0, 0, 182, 185, 160, 161, 162,
-	163, 164, 165, 166, 167, 168, 169, 170, 171, 172,
-	173, 116, 0, 0, 0, 92, 99, 89, 122, 121,
-	97, 94, 96, 0, 100, 107, 104, 0, 153, 151,
-	149, 150, 158, 156, 154, 155, 110, 108, 0, 0,
-	0, 0, 0, 0, 0, 0, 65, 66, 67, 68,
-	69, 39, 46, 0, 14, 0, 0, 0, 0, 0,
-	50, 0, 3, 188, 0, 224, 220, 0, 225, 0,
-	191, 0, 0, 0, 0, 117, 118, 119, 90, 98,
-	0, 0, 115, 0, 0, 0, 0, 0, 0, 133,
-	140, 147, 0, 132, 139, 146, 128, 135, 142, 129,
-	136, 143, 130, 137, 144, 131, 138, 145, 134, 141,
-	148, 0, 48, 0, 15, 18, 34, 0, 22, 0,
-	26, 0, 0, 0, 0, 0, 38, 52, 3, 51,
-	0, 0, 222, 223, 0, 0, 177, 0, 179, 183,
-	0, 186, 0, 123, 120, 105, 106, 102, 103, 152,
-	157, 109, 0, 0, 86, 47, 19, 35, 36, 219,
-	23, 42, 27, 30, 40, 0, 43, 44, 45, 16,
-	0, 0, 0, 53, 3, 221, 0, 176, 178, 184,
-	187, 0, 0, 49, 37, 31, 0, 17, 20, 0,
-	24, 28, 0, 54, 55, 0, 124, 125, 0, 21,
-	25, 29, 32, 0, 41, 33, 0, 0, 0, 56,
+
+var exprDef = [...]int16{
+	0, -2, 1, 2, 3, 13, 0, 4, 5, 6,
+	7, 8, 9, 10, 11, 0, 0, 0, 218, 0,
+	0, 0, 0, 0, 235, 236, 237, 238, 239, 240,
+	241, 242, 243, 244, 245, 246, 247, 248, 249, 250,
+	251, 252, 223, 224, 225, 226, 227, 228, 229, 230,
+	231, 232, 233, 234, 222, 204, 204, 204, 204, 204,
+	204, 204, 204, 204, 204, 204, 204, 204, 204, 204,
+	14, 77, 79, 0, 102, 0, 64, 65, 66, 67,
+	3, 2, 0, 0, 70, 71, 0, 0, 0, 0,
+	0, 0, 219, 220, 0, 0, 0, 0, 210, 211,
+	205, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 78, 103, 80, 81, 82,
+	83, 84, 85, 86, 87, 88, 89, 90, 91, 92,
+	93, 94, 95, 96, 97, 98, 106, 108, 0, 110,
+	0, 112, 0, 131, 132, 133, 134, 0, 0, 121,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	185, 0, 146, 147, 100, 0, 99, 12, 15, 68,
+	69, 0, 0, 0, 0, 0, 0, 218, 3, 13,
+	0, 3, 218, 0, 0, 0, 3, 0, 0, 189,
+	0, 0, 212, 215, 190, 191, 192, 193, 194, 195,
+	196, 197, 198, 199, 200, 201, 202, 203, 136, 0,
+	0, 0, 107, 119, 104, 142, 141, 113, 109, 111,
+	114, 117, 0, 0, 120, 127, 124, 0, 173, 171,
+	169, 170, 178, 176, 174, 175, 130, 128, 0, 0,
+	182, 183, 0, 0, 186, 0, 0, 0, 0, 0,
+	0, 0, 0, 72, 73, 74, 75, 76, 41, 48,
+	0, 0, 14, 16, 0, 0, 13, 0, 56, 0,
+	3, 218, 0, 258, 254, 0, 259, 0, 0, 221,
+	0, 0, 0, 0, 137, 138, 139, 105, 118, 0,
+	0, 0, 0, 135, 0, 0, 0, 0, 0, 0,
+	180, 0, 0, 0, 153, 160, 167, 0, 152, 159,
+	166, 148, 155, 162, 149, 156, 163, 150, 157, 164,
+	151, 158, 165, 154, 161, 168, 0, 50, 0, 3,
+	52, 0, 0, 28, 0, 17, 20, 36, 24, 0,
+	0, 14, 0, 0, 40, 58, 3, 57, 0, 0,
+	256, 257, 0, 3, 0, 207, 0, 209, 213, 0,
+	216, 0, 143, 140, 0, 115, 125, 126, 122, 123,
+	172, 177, 129, 179, 181, 184, 187, 0, 0, 101,
+	49, 0, 53, 253, 29, 32, 21, 37, 38, 25,
+	44, 42, 0, 45, 46, 47, 0, 0, 18, 0,
+	59, 3, 255, 0, 63, 206, 208, 214, 217, 116,
+	0, 0, 0, 51, 54, 0, 33, 39, 0, 30,
+	0, 19, 22, 0, 26, 60, 61, 0, 0, 144,
+	145, 55, 0, 31, 34, 23, 27, 0, 188, 43,
+	35, 0, 0, 0, 62,
 }
-var exprTok1 = [...]int{
 
+var exprTok1 = [...]int8{
 	1,
 }
-var exprTok2 = [...]int{
 
+var exprTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
@@ -541,9 +623,11 @@ var exprTok2 = [...]int{
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
 	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
 	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
-	92, 93,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105, 106, 107,
 }
-var exprTok3 = [...]int{
+
+var exprTok3 = [...]int8{
 	0,
 }
 
@@ -624,9 +708,9 @@ func exprErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := exprPact[state]
+	base := int(exprPact[state])
 	for tok := TOKSTART; tok-1 < len(exprToknames); tok++ {
-		if n := base + tok; n >= 0 && n < exprLast && exprChk[exprAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < exprLast && int(exprChk[int(exprAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -636,13 +720,13 @@ func exprErrorMessage(state, lookAhead int) string {
 
 	if exprDef[state] == -2 {
 		i := 0
-		for exprExca[i] != -1 || exprExca[i+1] != state {
+		for exprExca[i] != -1 || int(exprExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; exprExca[i] >= 0; i += 2 {
-			tok := exprExca[i]
+			tok := int(exprExca[i])
 			if tok < TOKSTART || exprExca[i+1] == 0 {
 				continue
 			}
@@ -673,30 +757,30 @@ func exprlex1(lex exprLexer, lval *exprSymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = exprTok1[0]
+		token = int(exprTok1[0])
 		goto out
 	}
 	if char < len(exprTok1) {
-		token = exprTok1[char]
+		token = int(exprTok1[char])
 		goto out
 	}
 	if char >= exprPrivate {
 		if char < exprPrivate+len(exprTok2) {
-			token = exprTok2[char-exprPrivate]
+			token = int(exprTok2[char-exprPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(exprTok3); i += 2 {
-		token = exprTok3[i+0]
+		token = int(exprTok3[i+0])
 		if token == char {
-			token = exprTok3[i+1]
+			token = int(exprTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = exprTok2[1] /* unknown char */
+		token = int(exprTok2[1]) /* unknown char */
 	}
 	if exprDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", exprTokname(token), uint(char))
@@ -751,7 +835,7 @@ exprstack:
 	exprS[exprp].yys = exprstate
 
 exprnewstate:
-	exprn = exprPact[exprstate]
+	exprn = int(exprPact[exprstate])
 	if exprn <= exprFlag {
 		goto exprdefault /* simple state */
 	}
@@ -762,8 +846,8 @@ exprnewstate:
 	if exprn < 0 || exprn >= exprLast {
 		goto exprdefault
 	}
-	exprn = exprAct[exprn]
-	if exprChk[exprn] == exprtoken { /* valid shift */
+	exprn = int(exprAct[exprn])
+	if int(exprChk[exprn]) == exprtoken { /* valid shift */
 		exprrcvr.char = -1
 		exprtoken = -1
 		exprVAL = exprrcvr.lval
@@ -776,7 +860,7 @@ exprnewstate:
 
 exprdefault:
 	/* default state action */
-	exprn = exprDef[exprstate]
+	exprn = int(exprDef[exprstate])
 	if exprn == -2 {
 		if exprrcvr.char < 0 {
 			exprrcvr.char, exprtoken = exprlex1(exprlex, &exprrcvr.lval)
@@ -785,18 +869,18 @@ exprdefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if exprExca[xi+0] == -1 && exprExca[xi+1] == exprstate {
+			if exprExca[xi+0] == -1 && int(exprExca[xi+1]) == exprstate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			exprn = exprExca[xi+0]
+			exprn = int(exprExca[xi+0])
 			if exprn < 0 || exprn == exprtoken {
 				break
 			}
 		}
-		exprn = exprExca[xi+1]
+		exprn = int(exprExca[xi+1])
 		if exprn < 0 {
 			goto ret0
 		}
@@ -818,10 +902,10 @@ exprdefault:
 
 			/* find a state where "error" is a legal shift action */
 			for exprp >= 0 {
-				exprn = exprPact[exprS[exprp].yys] + exprErrCode
+				exprn = int(exprPact[exprS[exprp].yys]) + exprErrCode
 				if exprn >= 0 && exprn < exprLast {
-					exprstate = exprAct[exprn] /* simulate a shift of "error" */
-					if exprChk[exprstate] == exprErrCode {
+					exprstate = int(exprAct[exprn]) /* simulate a shift of "error" */
+					if int(exprChk[exprstate]) == exprErrCode {
 						goto exprstack
 					}
 				}
@@ -857,7 +941,7 @@ exprdefault:
 	exprpt := exprp
 	_ = exprpt // guard against "declared and not used"
 
-	exprp -= exprR2[exprn]
+	exprp -= int(exprR2[exprn])
 	// exprp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if exprp+1 >= len(exprS) {
@@ -868,16 +952,16 @@ exprdefault:
 	exprVAL = exprS[exprp+1]
 
 	/* consult goto table to find next state */
-	exprn = exprR1[exprn]
-	exprg := exprPgo[exprn]
+	exprn = int(exprR1[exprn])
+	exprg := int(exprPgo[exprn])
 	exprj := exprg + exprS[exprp].yys + 1
 
 	if exprj >= exprLast {
-		exprstate = exprAct[exprg]
+		exprstate = int(exprAct[exprg])
 	} else {
-		exprstate = exprAct[exprj]
-		if exprChk[exprstate] != -exprn {
-			exprstate = exprAct[exprg]
+		exprstate = int(exprAct[exprj])
+		if int(exprChk[exprstate]) != -exprn {
+			exprstate = int(exprAct[exprg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -906,1105 +990,1275 @@ exprdefault:
 	case 5:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
-			exprVAL.MetricExpr = exprDollar[1].VectorAggregationExpr
+			exprVAL.MetricExpr = exprDollar[1].SubqueryExpr
 		}
 	case 6:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
-			exprVAL.MetricExpr = exprDollar[1].BinOpExpr
+			exprVAL.MetricExpr = exprDollar[1].VectorAggregationExpr
 		}
 	case 7:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
-			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
+			exprVAL.MetricExpr = exprDollar[1].BinOpExpr
 		}
 	case 8:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
-			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
+			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
 		}
 	case 9:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
-			exprVAL.MetricExpr = exprDollar[1].VectorExpr
+			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
 		}
 	case 10:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.MetricExpr = exprDollar[1].HistogramQuantileExpr
+		}
+	case 11:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.MetricExpr = exprDollar[1].VectorExpr
+		}
+	case 12:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.MetricExpr = exprDollar[2].MetricExpr
 		}
-	case 11:
+	case 13:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LogExpr = newMatcherExpr(exprDollar[1].Selector)
 		}
-	case 12:
+	case 14:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LogExpr = newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr)
 		}
-	case 13:
+	case 15:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogExpr = exprDollar[2].LogExpr
 		}
-	case 14:
+	case 16:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, nil)
 		}
-	case 15:
+	case 17:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 16:
+	case 18:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, nil)
 		}
-	case 17:
+	case 19:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, exprDollar[5].OffsetExpr)
 		}
-	case 18:
+	case 20:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 19:
+	case 21:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[4].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 20:
+	case 22:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[5].UnwrapExpr, nil)
 		}
-	case 21:
+	case 23:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[6].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 22:
+	case 24:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, nil)
 		}
-	case 23:
+	case 25:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, exprDollar[4].OffsetExpr)
 		}
-	case 24:
+	case 26:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 25:
+	case 27:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, exprDollar[6].OffsetExpr)
 		}
-	case 26:
+	case 28:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, nil)
 		}
-	case 27:
+	case 29:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, exprDollar[4].OffsetExpr)
 		}
-	case 28:
+	case 30:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, nil)
 		}
-	case 29:
+	case 31:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, exprDollar[6].OffsetExpr)
 		}
-	case 30:
+	case 32:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 31:
+	case 33:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 32:
+	case 34:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 33:
+	case 35:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, exprDollar[7].OffsetExpr)
 		}
-	case 34:
+	case 36:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, nil, nil)
 		}
-	case 35:
+	case 37:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 36:
+	case 38:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 37:
+	case 39:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, exprDollar[5].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 38:
+	case 40:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = exprDollar[2].LogRangeExpr
 		}
-	case 40:
+	case 42:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[3].str, "")
 		}
-	case 41:
+	case 43:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[5].str, exprDollar[3].ConvOp)
 		}
-	case 42:
+	case 44:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.UnwrapExpr = exprDollar[1].UnwrapExpr.addPostFilter(exprDollar[3].LabelFilter)
 		}
-	case 43:
+	case 45:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.ConvOp = OpConvBytes
 		}
-	case 44:
+	case 46:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.ConvOp = OpConvDuration
 		}
-	case 45:
+	case 47:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.ConvOp = OpConvDurationSeconds
 		}
-	case 46:
+	case 48:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, nil, nil)
 		}
-	case 47:
+	case 49:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, nil, &exprDollar[3].str)
 		}
-	case 48:
+	case 50:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[5].Grouping, nil)
 		}
-	case 49:
+	case 51:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
-	case 50:
+	case 52:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, nil, nil)
+		}
+	case 53:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].subqueryRange, exprDollar[5].OffsetExpr, nil)
+		}
+	case 54:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].subqueryRange, nil, &exprDollar[3].str)
+		}
+	case 55:
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].subqueryRange, exprDollar[7].OffsetExpr, &exprDollar[3].str)
+		}
+	case 56:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
 		}
-	case 51:
+	case 57:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
 		}
-	case 52:
+	case 58:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
 		}
-	case 53:
+	case 59:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
 		}
-	case 54:
+	case 60:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
-	case 55:
+	case 61:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, &exprDollar[4].str)
 		}
-	case 56:
+	case 62:
 		exprDollar = exprS[exprpt-12 : exprpt+1]
 		{
 			exprVAL.LabelReplaceExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
 		}
-	case 57:
+	case 63:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.HistogramQuantileExpr = newHistogramQuantileExpr(exprDollar[5].MetricExpr, exprDollar[3].str)
+		}
+	case 64:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchRegexp
 		}
-	case 58:
+	case 65:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchEqual
 		}
-	case 59:
+	case 66:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchNotRegexp
 		}
-	case 60:
+	case 67:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchNotEqual
 		}
-	case 61:
+	case 68:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Selector = exprDollar[2].Matchers
 		}
-	case 62:
+	case 69:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Selector = exprDollar[2].Matchers
 		}
-	case 63:
+	case 70:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 		}
-	case 64:
+	case 71:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Matchers = []*labels.Matcher{exprDollar[1].Matcher}
 		}
-	case 65:
+	case 72:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matchers = append(exprDollar[1].Matchers, exprDollar[3].Matcher)
 		}
-	case 66:
+	case 73:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 67:
+	case 74:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 68:
+	case 75:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 69:
+	case 76:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 70:
+	case 77:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.PipelineExpr = MultiStageExpr{exprDollar[1].PipelineStage}
 		}
-	case 71:
+	case 78:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineExpr = append(exprDollar[1].PipelineExpr, exprDollar[2].PipelineStage)
 		}
-	case 72:
+	case 79:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[1].LineFilters
 		}
-	case 73:
+	case 80:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtParser
 		}
-	case 74:
+	case 81:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelParser
 		}
-	case 75:
+	case 82:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].JSONExpressionParser
 		}
-	case 76:
+	case 83:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 84:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 85:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtExpressionParser
 		}
-	case 77:
+	case 86:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = &LabelFilterExpr{LabelFilterer: exprDollar[2].LabelFilter}
 		}
-	case 78:
+	case 87:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].LineFormatExpr
 		}
-	case 79:
+	case 88:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].DecolorizeExpr
 		}
-	case 80:
+	case 89:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelFormatExpr
 		}
-	case 81:
+	case 90:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].DropLabelsExpr
 		}
-	case 82:
+	case 91:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].KeepLabelsExpr
 		}
-	case 83:
+	case 92:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.PipelineStage = exprDollar[2].DistinctFilter
 		}
-	case 84:
+	case 93:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].LookupExpr
+		}
+	case 94:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 95:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 96:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 97:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 98:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].PipelineStage
+		}
+	case 99:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.FilterOp = OpFilterIP
 		}
-	case 85:
+	case 100:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str)
 		}
-	case 86:
+	case 101:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, exprDollar[2].FilterOp, exprDollar[4].str)
 		}
-	case 87:
+	case 102:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LineFilters = exprDollar[1].LineFilter
 		}
-	case 88:
+	case 103:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFilters = newNestedLineFilterExpr(exprDollar[1].LineFilters, exprDollar[2].LineFilter)
 		}
-	case 89:
+	case 104:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.ParserFlags = []string{exprDollar[1].str}
 		}
-	case 90:
+	case 105:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.ParserFlags = append(exprDollar[1].ParserFlags, exprDollar[2].str)
 		}
-	case 91:
+	case 106:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(nil)
 		}
-	case 92:
+	case 107:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(exprDollar[2].ParserFlags)
 		}
-	case 93:
+	case 108:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeJSON, "")
 		}
-	case 94:
+	case 109:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeRegexp, exprDollar[2].str)
 		}
-	case 95:
+	case 110:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeUnpack, "")
 		}
-	case 96:
+	case 111:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypePattern, exprDollar[2].str)
 		}
-	case 97:
+	case 112:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeXML, "")
+		}
+	case 113:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.JSONExpressionParser = newJSONExpressionParser(exprDollar[2].LabelExtractionExpressionList)
 		}
-	case 98:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
+	case 114:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
-			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[3].LabelExtractionExpressionList, exprDollar[2].ParserFlags)
+			exprVAL.PipelineStage = mustNewXMLExpressionParser(exprDollar[2].LabelExtractionExpressionList)
 		}
-	case 99:
+	case 115:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.ParserFlags = []string{exprDollar[1].str, exprDollar[3].str}
+		}
+	case 116:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.ParserFlags = append(exprDollar[1].ParserFlags, exprDollar[2].str, exprDollar[4].str)
+		}
+	case 117:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewCSVParserExpr(exprDollar[2].ParserFlags)
+		}
+	case 118:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[3].LabelExtractionExpressionList, exprDollar[2].ParserFlags)
+		}
+	case 119:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[2].LabelExtractionExpressionList, nil)
 		}
-	case 100:
+	case 120:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFormatExpr = newLineFmtExpr(exprDollar[2].str)
 		}
-	case 101:
+	case 121:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.DecolorizeExpr = newDecolorizeExpr()
 		}
-	case 102:
+	case 122:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFormat = log.NewRenameLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 103:
+	case 123:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFormat = log.NewTemplateLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 104:
+	case 124:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelsFormat = []log.LabelFmt{exprDollar[1].LabelFormat}
 		}
-	case 105:
+	case 125:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelsFormat = append(exprDollar[1].LabelsFormat, exprDollar[3].LabelFormat)
 		}
-	case 107:
+	case 127:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelFormatExpr = newLabelFmtExpr(exprDollar[2].LabelsFormat)
 		}
-	case 108:
+	case 128:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.DistinctLabel = []string{exprDollar[1].str}
 		}
-	case 109:
+	case 129:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DistinctLabel = append(exprDollar[1].DistinctLabel, exprDollar[3].str)
 		}
-	case 110:
+	case 130:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.DistinctFilter = newDistinctFilterExpr(exprDollar[2].DistinctLabel)
 		}
-	case 111:
+	case 131:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewStringLabelFilter(exprDollar[1].Matcher)
 		}
-	case 112:
+	case 132:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].IPLabelFilter
 		}
-	case 113:
+	case 133:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].UnitFilter
 		}
-	case 114:
+	case 134:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].NumberFilter
 		}
-	case 115:
+	case 135:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[2].LabelFilter
 		}
-	case 116:
+	case 136:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[2].LabelFilter)
 		}
-	case 117:
+	case 137:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 118:
+	case 138:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 119:
+	case 139:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewOrLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 120:
+	case 140:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 121:
+	case 141:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[1].str)
 		}
-	case 122:
+	case 142:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelExtractionExpressionList = []log.LabelExtractionExpr{exprDollar[1].LabelExtractionExpression}
 		}
-	case 123:
+	case 143:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelExtractionExpressionList = append(exprDollar[1].LabelExtractionExpressionList, exprDollar[3].LabelExtractionExpression)
 		}
-	case 124:
+	case 144:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterEqual)
 		}
-	case 125:
+	case 145:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterNotEqual)
 		}
-	case 126:
+	case 146:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.UnitFilter = exprDollar[1].DurationFilter
 		}
-	case 127:
+	case 147:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.UnitFilter = exprDollar[1].BytesFilter
 		}
-	case 128:
+	case 148:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 129:
+	case 149:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 130:
+	case 150:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 131:
+	case 151:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 132:
+	case 152:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 133:
+	case 153:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 134:
+	case 154:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 135:
+	case 155:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 136:
+	case 156:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 137:
+	case 157:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 138:
+	case 158:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 139:
+	case 159:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 140:
+	case 160:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 141:
+	case 161:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 142:
+	case 162:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 143:
+	case 163:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 144:
+	case 164:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 145:
+	case 165:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 146:
+	case 166:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 147:
+	case 167:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 148:
+	case 168:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 149:
+	case 169:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.DropLabel = log.NewDropLabel(nil, exprDollar[1].str)
 		}
-	case 150:
+	case 170:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.DropLabel = log.NewDropLabel(exprDollar[1].Matcher, "")
 		}
-	case 151:
+	case 171:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.DropLabels = []log.DropLabel{exprDollar[1].DropLabel}
 		}
-	case 152:
+	case 172:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DropLabels = append(exprDollar[1].DropLabels, exprDollar[3].DropLabel)
 		}
-	case 153:
+	case 173:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.DropLabelsExpr = newDropLabelsExpr(exprDollar[2].DropLabels)
 		}
-	case 154:
+	case 174:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(nil, exprDollar[1].str)
 		}
-	case 155:
+	case 175:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(exprDollar[1].Matcher, "")
 		}
-	case 156:
+	case 176:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.KeepLabels = []log.KeepLabel{exprDollar[1].KeepLabel}
 		}
-	case 157:
+	case 177:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.KeepLabels = append(exprDollar[1].KeepLabels, exprDollar[3].KeepLabel)
 		}
-	case 158:
+	case 178:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.KeepLabelsExpr = newKeepLabelsExpr(exprDollar[2].KeepLabels)
 		}
-	case 159:
+	case 179:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.LookupExpr = newLookupExpr(exprDollar[2].str, exprDollar[4].str)
+		}
+	case 180:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewSortExpr(exprDollar[3].str, "")
+		}
+	case 181:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewSortExpr(exprDollar[3].str, exprDollar[4].str)
+		}
+	case 182:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewLimitExpr(OpHead, exprDollar[2].str)
+		}
+	case 183:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewLimitExpr(OpTail, exprDollar[2].str)
+		}
+	case 184:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewMultilineExpr(exprDollar[2].str, exprDollar[4].str)
+		}
+	case 185:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewJSONArrayExpr("")
+		}
+	case 186:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewJSONArrayExpr(exprDollar[2].str)
+		}
+	case 187:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewContextExpr(exprDollar[2].str, exprDollar[4].str)
+		}
+	case 188:
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+		{
+			exprVAL.PipelineStage = mustNewContextExpr(exprDollar[2].str, exprDollar[4].str, exprDollar[5].str, exprDollar[7].str)
+		}
+	case 189:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("or", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 160:
+	case 190:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("and", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 161:
+	case 191:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("unless", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 162:
+	case 192:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("+", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 163:
+	case 193:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("-", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 164:
+	case 194:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("*", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 165:
+	case 195:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("/", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 166:
+	case 196:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("%", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 167:
+	case 197:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("^", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 168:
+	case 198:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("==", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 169:
+	case 199:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("!=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 170:
+	case 200:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 171:
+	case 201:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 172:
+	case 202:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 173:
+	case 203:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 174:
+	case 204:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}}
 		}
-	case 175:
+	case 205:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}, ReturnBool: true}
 		}
-	case 176:
+	case 206:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 177:
+	case 207:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 		}
-	case 178:
+	case 208:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 179:
+	case 209:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 		}
-	case 180:
+	case 210:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].BoolModifier
 		}
-	case 181:
+	case 211:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 		}
-	case 182:
+	case 212:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 183:
+	case 213:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 184:
+	case 214:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 185:
+	case 215:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 186:
+	case 216:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 187:
+	case 217:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 188:
+	case 218:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[1].str, false)
 		}
-	case 189:
+	case 219:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, false)
 		}
-	case 190:
+	case 220:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, true)
 		}
-	case 191:
+	case 221:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.VectorExpr = NewVectorExpr(exprDollar[3].str)
 		}
-	case 192:
+	case 222:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Vector = OpTypeVector
 		}
-	case 193:
+	case 223:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeSum
 		}
-	case 194:
+	case 224:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeAvg
 		}
-	case 195:
+	case 225:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeCount
 		}
-	case 196:
+	case 226:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMax
 		}
-	case 197:
+	case 227:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMin
 		}
-	case 198:
+	case 228:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStddev
 		}
-	case 199:
+	case 229:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStdvar
 		}
-	case 200:
+	case 230:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeBottomK
 		}
-	case 201:
+	case 231:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeTopK
 		}
-	case 202:
+	case 232:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeSort
 		}
-	case 203:
+	case 233:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeSortDesc
 		}
-	case 204:
+	case 234:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.VectorOp = OpTypeApproxCountDistinct
+		}
+	case 235:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeCount
 		}
-	case 205:
+	case 236:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeRate
 		}
-	case 206:
+	case 237:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeRateCounter
 		}
-	case 207:
+	case 238:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytes
 		}
-	case 208:
+	case 239:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytesRate
 		}
-	case 209:
+	case 240:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeAvg
 		}
-	case 210:
+	case 241:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeSum
 		}
-	case 211:
+	case 242:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeMin
 		}
-	case 212:
+	case 243:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeMax
 		}
-	case 213:
+	case 244:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeStdvar
 		}
-	case 214:
+	case 245:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeStddev
 		}
-	case 215:
+	case 246:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeQuantile
 		}
-	case 216:
+	case 247:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeFirst
 		}
-	case 217:
+	case 248:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeLast
 		}
-	case 218:
+	case 249:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeAbsent
 		}
-	case 219:
+	case 250:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeHistogram
+		}
+	case 251:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeCountDistinct
+		}
+	case 252:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeHLL
+		}
+	case 253:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.OffsetExpr = newOffsetExpr(exprDollar[2].duration)
 		}
-	case 220:
+	case 254:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Labels = []string{exprDollar[1].str}
 		}
-	case 221:
+	case 255:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
-	case 222:
+	case 256:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: exprDollar[3].Labels}
 		}
-	case 223:
+	case 257:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: exprDollar[3].Labels}
 		}
-	case 224:
+	case 258:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: nil}
 		}
-	case 225:
+	case 259:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: nil}
@@ -70,6 +70,9 @@ func (r RangeAggregationExpr) extractor(override *Grouping) (log.SampleExtractor
 		default:
 			convOp = log.ConvertFloat
 		}
+		if r.Operation == OpRangeTypeCountDistinct || r.Operation == OpRangeTypeHLL {
+			convOp = log.ConvertHash
+		}
 
 		return log.LabelExtractorWithStages(
 			r.Left.Unwrap.Identifier,
@@ -64,6 +64,8 @@ var tokens = map[string]int{
 	OpParserTypeLogfmt:  LOGFMT,
 	OpParserTypeUnpack:  UNPACK,
 	OpParserTypePattern: PATTERN,
+	OpParserTypeXML:     XML,
+	OpParserTypeCSV:     CSV,
 
 	// fmt
 	OpFmtLabel: LABEL_FMT,
@@ -79,6 +81,19 @@ var tokens = map[string]int{
 
 	// keep labels
 	OpKeep: KEEP,
+
+	// lookup tables
+	OpLookup: LOOKUP,
+
+	// ordering of log queries
+	OpHead: HEAD,
+	OpTail: TAIL,
+
+	// multiline log lines
+	OpMultiline: MULTILINE,
+
+	// json arrays
+	OpJSONArray: JSON_ARRAY,
 }
 
 var parserFlags = map[string]struct{}{
@@ -89,22 +104,25 @@ var parserFlags = map[string]struct{}{
 // functionTokens are tokens that needs to be suffixes with parenthesis
 var functionTokens = map[string]int{
 	// range vec ops
-	OpRangeTypeRate:        RATE,
-	OpRangeTypeRateCounter: RATE_COUNTER,
-	OpRangeTypeCount:       COUNT_OVER_TIME,
-	OpRangeTypeBytesRate:   BYTES_RATE,
-	OpRangeTypeBytes:       BYTES_OVER_TIME,
-	OpRangeTypeAvg:         AVG_OVER_TIME,
-	OpRangeTypeSum:         SUM_OVER_TIME,
-	OpRangeTypeMin:         MIN_OVER_TIME,
-	OpRangeTypeMax:         MAX_OVER_TIME,
-	OpRangeTypeStdvar:      STDVAR_OVER_TIME,
-	OpRangeTypeStddev:      STDDEV_OVER_TIME,
-	OpRangeTypeQuantile:    QUANTILE_OVER_TIME,
-	OpRangeTypeFirst:       FIRST_OVER_TIME,
-	OpRangeTypeLast:        LAST_OVER_TIME,
-	OpRangeTypeAbsent:      ABSENT_OVER_TIME,
-	OpTypeVector:           VECTOR,
+	OpRangeTypeRate:          RATE,
+	OpRangeTypeRateCounter:   RATE_COUNTER,
+	OpRangeTypeCount:         COUNT_OVER_TIME,
+	OpRangeTypeBytesRate:     BYTES_RATE,
+	OpRangeTypeBytes:         BYTES_OVER_TIME,
+	OpRangeTypeAvg:           AVG_OVER_TIME,
+	OpRangeTypeSum:           SUM_OVER_TIME,
+	OpRangeTypeMin:           MIN_OVER_TIME,
+	OpRangeTypeMax:           MAX_OVER_TIME,
+	OpRangeTypeStdvar:        STDVAR_OVER_TIME,
+	OpRangeTypeStddev:        STDDEV_OVER_TIME,
+	OpRangeTypeQuantile:      QUANTILE_OVER_TIME,
+	OpRangeTypeFirst:         FIRST_OVER_TIME,
+	OpRangeTypeLast:          LAST_OVER_TIME,
+	OpRangeTypeAbsent:        ABSENT_OVER_TIME,
+	OpRangeTypeHistogram:     HISTOGRAM_OVER_TIME,
+	OpRangeTypeCountDistinct: COUNT_DISTINCT_OVER_TIME,
+	OpRangeTypeHLL:           HLL_OVER_TIME,
+	OpTypeVector:             VECTOR,
 
 	// vec ops
 	OpTypeSum:      SUM,
@@ -120,6 +138,11 @@ var functionTokens = map[string]int{
 	OpTypeSortDesc: SORT_DESC,
 	OpLabelReplace: LABEL_REPLACE,
 
+	OpTypeApproxCountDistinct: APPROX_COUNT_DISTINCT,
+
+	// functions
+	OpTypeHistogramQuantile: HISTOGRAM_QUANTILE,
+
 	// conversion Op
 	OpConvBytes:           BYTES_CONV,
 	OpConvDuration:        DURATION_CONV,
@@ -201,6 +224,9 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		l.builder.Reset()
 		for r := l.Next(); r != scanner.EOF; r = l.Next() {
 			if r == ']' {
+				if rng, step, ok := strings.Cut(l.builder.String(), ":"); ok {
+					return l.subqueryRange(rng, step, lval)
+				}
 				i, err := model.ParseDuration(l.builder.String())
 				if err != nil {
 					l.Error(err.Error())
@@ -228,6 +254,9 @@ func (l *lexer) Lex(lval *exprSymType) int {
 	}
 
 	if tok, ok := functionTokens[tokenText]; ok {
+		if tok == SORT && isSortStage(l.Scanner) {
+			return tok
+		}
 		if !isFunction(l.Scanner) {
 			lval.str = tokenText
 			return IDENTIFIER
@@ -244,10 +273,34 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return tok
 	}
 
+	// context is only a keyword in the context stage, so it can still be used as a label name.
+	if tokenText == OpContext && isContextStage(l.Scanner) {
+		return CONTEXT
+	}
+
 	lval.str = tokenText
 	return IDENTIFIER
 }
 
+// subqueryRange parses the range and the optional resolution of a subquery, e.g. [1h:1m] or [1h:].
+func (l *lexer) subqueryRange(rng, step string, lval *exprSymType) int {
+	r, err := model.ParseDuration(rng)
+	if err != nil {
+		l.Error(err.Error())
+		return 0
+	}
+	lval.subqueryRange = subqueryRange{Range: time.Duration(r)}
+	if step != "" {
+		s, err := model.ParseDuration(step)
+		if err != nil {
+			l.Error(err.Error())
+			return 0
+		}
+		lval.subqueryRange.Step = time.Duration(s)
+	}
+	return SUBQUERY_RANGE
+}
+
 func (l *lexer) Error(msg string) {
 	l.errs = append(l.errs, logqlmodel.NewParseError(msg, l.Line, l.Column))
 }
@@ -402,6 +455,30 @@ func isFunction(sc Scanner) bool {
 	return false
 }
 
+// isSortStage returns whether sort is the sort stage of a log query, e.g: | sort by duration desc.
+func isSortStage(sc Scanner) bool {
+	sc = trimSpace(sc)
+	if sc.Next() != 'b' || sc.Next() != 'y' || !unicode.IsSpace(sc.Peek()) {
+		return false
+	}
+	sc = trimSpace(sc)
+	return sc.Peek() != '('
+}
+
+// isContextStage returns whether context is the context stage of a log query, e.g: | context before=5 after=5.
+func isContextStage(sc Scanner) bool {
+	sc = trimSpace(sc)
+	var sb strings.Builder
+	for r := sc.Peek(); unicode.IsLetter(r); r = sc.Peek() {
+		sb.WriteRune(sc.Next())
+	}
+	if name := sb.String(); name != OpContextBefore && name != OpContextAfter {
+		return false
+	}
+	sc = trimSpace(sc)
+	return sc.Peek() == '='
+}
+
 func trimSpace(l Scanner) Scanner {
 	for n := l.Peek(); n != scanner.EOF; n = l.Peek() {
 		if unicode.IsSpace(n) {
@@ -104,6 +104,15 @@ func validateExpr(expr Expr) error {
 	case SampleExpr:
 		return validateSampleExpr(e)
 	case LogSelectorExpr:
+		if err := validateLogOrdering(e, true); err != nil {
+			return err
+		}
+		if err := validateMultiline(e, true); err != nil {
+			return err
+		}
+		if err := validateContext(e, true); err != nil {
+			return err
+		}
 		return validateLogSelectorExpression(e)
 	default:
 		return logqlmodel.NewParseError(fmt.Sprintf("unexpected expression type: %v", e), 0, 0)
@@ -181,11 +190,25 @@ func validateSampleExpr(expr SampleExpr) error {
 			}
 		}
 		return validateSampleExpr(e.Left)
+	case *SubqueryExpr:
+		if e.err != nil {
+			return e.err
+		}
+		return validateSampleExpr(e.Left)
 	default:
 		selector, err := e.Selector()
 		if err != nil {
 			return err
 		}
+		if err := validateLogOrdering(selector, false); err != nil {
+			return err
+		}
+		if err := validateMultiline(selector, false); err != nil {
+			return err
+		}
+		if err := validateContext(selector, false); err != nil {
+			return err
+		}
 		return validateLogSelectorExpression(selector)
 	}
 }
@@ -199,6 +222,87 @@ func validateLogSelectorExpression(expr LogSelectorExpr) error {
 	}
 }
 
+// validateLogOrdering checks that the sort stage and the head or tail stage are the last stages of a log query,
+// and that they are not used by metric queries.
+func validateLogOrdering(expr LogSelectorExpr, allowed bool) error {
+	p, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil
+	}
+	var sorted, limited StageExpr
+	for _, stage := range p.MultiStages {
+		switch stage.(type) {
+		case *SortExpr, *LimitExpr:
+			if !allowed {
+				return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' is only allowed in log queries", stage.String()), 0, 0)
+			}
+		}
+		switch {
+		case limited != nil:
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' must be the last stage", limited.String()), 0, 0)
+		case sorted != nil:
+			if _, ok := stage.(*LimitExpr); !ok {
+				return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' must be the last stage before head or tail", sorted.String()), 0, 0)
+			}
+		}
+		switch stage.(type) {
+		case *SortExpr:
+			sorted = stage
+		case *LimitExpr:
+			limited = stage
+		}
+	}
+	return nil
+}
+
+// validateMultiline checks that the multiline or json_array stage is the first stage of a log query,
+// and that it is not used by metric queries.
+func validateMultiline(expr LogSelectorExpr, allowed bool) error {
+	p, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil
+	}
+	for i, stage := range p.MultiStages {
+		switch stage.(type) {
+		case *MultilineExpr, *JSONArrayExpr:
+		default:
+			continue
+		}
+		if !allowed {
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' is only allowed in log queries", stage.String()), 0, 0)
+		}
+		if i != 0 {
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' must be the first stage", stage.String()), 0, 0)
+		}
+	}
+	return nil
+}
+
+// validateContext checks that the context stage is the last stage of a log query, that it is not used with the
+// multiline, json_array, sort, head or tail stages, and that it is not used by metric queries.
+func validateContext(expr LogSelectorExpr, allowed bool) error {
+	p, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil
+	}
+	for i, stage := range p.MultiStages {
+		if _, ok := stage.(*ContextExpr); !ok {
+			continue
+		}
+		if !allowed {
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' is only allowed in log queries", stage.String()), 0, 0)
+		}
+		if i != len(p.MultiStages)-1 {
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' must be the last stage", stage.String()), 0, 0)
+		}
+		sortExpr, limitExpr := LogOrdering(expr)
+		if MultilineStage(expr) != nil || JSONArrayStage(expr) != nil || sortExpr != nil || limitExpr != nil {
+			return logqlmodel.NewParseError(fmt.Sprintf("stage '%s' is not allowed with multiline, json_array, sort, head or tail stages", stage.String()), 0, 0)
+		}
+	}
+	return nil
+}
+
 // validateSortGrouping prevent by|without groupings on sort operations.
 // This will keep compatibility with promql and allowing sort by (foo) doesn't make much sense anyway when sort orders by value instead of labels.
 func validateSortGrouping(grouping *Grouping) error {
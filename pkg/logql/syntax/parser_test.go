@@ -2891,7 +2891,7 @@ func TestParse(t *testing.T) {
 		},
 		{
 			in:  `quantile_over_time(foo,{namespace="tns"} |= "level=error" | json |foo>=5,bar<25ms| unwrap latency [5m])`,
-			err: logqlmodel.NewParseError("syntax error: unexpected IDENTIFIER, expecting NUMBER or { or (", 1, 20),
+			err: logqlmodel.NewParseError("syntax error: unexpected IDENTIFIER", 1, 20),
 		},
 		{
 			in:  `vector(abc)`,
@@ -3139,6 +3139,273 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			in: `max_over_time(rate({app="foo"}[1m])[1h:1m])`,
+			exp: &SubqueryExpr{
+				Left: &RangeAggregationExpr{
+					Left: &LogRange{
+						Left:     newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+						Interval: time.Minute,
+					},
+					Operation: OpRangeTypeRate,
+				},
+				Operation: OpRangeTypeMax,
+				Range:     time.Hour,
+				Step:      time.Minute,
+			},
+		},
+		{
+			in: `quantile_over_time(0.99, sum(rate({app="foo"}[1m]))[1h:] offset 1d)`,
+			exp: newSubqueryExpr(
+				mustNewVectorAggregationExpr(
+					newRangeAggregationExpr(
+						&LogRange{
+							Left:     newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+							Interval: time.Minute,
+						},
+						OpRangeTypeRate, nil, nil,
+					),
+					OpTypeSum, nil, nil,
+				),
+				OpRangeTypeQuantile,
+				subqueryRange{Range: time.Hour},
+				newOffsetExpr(24*time.Hour),
+				NewStringLabelFilter("0.99"),
+			),
+		},
+		{
+			in: `histogram_quantile(0.99, sum by (le) (histogram_over_time({app="foo"} | unwrap latency [5m])))`,
+			exp: &HistogramQuantileExpr{
+				Left: mustNewVectorAggregationExpr(
+					newRangeAggregationExpr(
+						newLogRange(newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+							5*time.Minute,
+							newUnwrapExpr("latency", ""),
+							nil),
+						OpRangeTypeHistogram, nil, nil,
+					),
+					OpTypeSum, &Grouping{Groups: []string{"le"}}, nil,
+				),
+				Quantile: 0.99,
+			},
+		},
+		{
+			in:  `histogram_over_time({app="foo"}[5m])`,
+			err: logqlmodel.NewParseError("invalid aggregation histogram_over_time without unwrap", 0, 0),
+		},
+		{
+			in: `approx_count_distinct by (app) (hll_over_time({app="foo"} | unwrap user [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+						5*time.Minute,
+						newUnwrapExpr("user", ""),
+						nil),
+					OpRangeTypeHLL, nil, nil,
+				),
+				OpTypeApproxCountDistinct, &Grouping{Groups: []string{"app"}}, nil,
+			),
+		},
+		{
+			in: `count_distinct_over_time({app="foo"} | unwrap user [5m]) by (app)`,
+			exp: newRangeAggregationExpr(
+				newLogRange(newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+					5*time.Minute,
+					newUnwrapExpr("user", ""),
+					nil),
+				OpRangeTypeCountDistinct, &Grouping{Groups: []string{"app"}}, nil,
+			),
+		},
+		{
+			in:  `count_distinct_over_time({app="foo"} | unwrap duration(latency) [5m])`,
+			err: logqlmodel.NewParseError("invalid conversion duration for count_distinct_over_time aggregation", 0, 0),
+		},
+		{
+			in: `{app="foo"} | logfmt | lookup "owners" on service`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					newLogfmtParserExpr(nil),
+					newLookupExpr("owners", "service"),
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | lookup owners on service`,
+			err: logqlmodel.NewParseError("syntax error: unexpected IDENTIFIER, expecting STRING", 1, 22),
+		},
+		{
+			in: `{app="foo"} | logfmt | sort by duration desc | head 20`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					newLogfmtParserExpr(nil),
+					&SortExpr{Label: "duration", Desc: true},
+					&LimitExpr{Op: OpHead, Limit: 20},
+				},
+			),
+		},
+		{
+			in: `{app="foo"} | sort by duration | tail 5`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					&SortExpr{Label: "duration"},
+					&LimitExpr{Op: OpTail, Limit: 5},
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | sort by duration up`,
+			err: logqlmodel.NewParseError("invalid sort order up, expected asc or desc", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | head 0`,
+			err: logqlmodel.NewParseError("invalid head limit 0, expected a positive integer", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | sort by duration | logfmt`,
+			err: logqlmodel.NewParseError("stage '| sort by duration asc' must be the last stage before head or tail", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | head 10 | sort by duration`,
+			err: logqlmodel.NewParseError("stage '| head 10' must be the last stage", 0, 0),
+		},
+		{
+			in:  `count_over_time({app="foo"} | logfmt | sort by duration [5m])`,
+			err: logqlmodel.NewParseError("stage '| sort by duration asc' is only allowed in log queries", 0, 0),
+		},
+		{
+			in: `{app="foo"} | multiline firstline="^\\d{4}-" |= "error" | logfmt`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					mustNewMultilineExpr(OpMultilineFirstLine, `^\d{4}-`),
+					newLineFilterExpr(labels.MatchEqual, "", "error"),
+					newLogfmtParserExpr(nil),
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | multiline first="^\\d{4}-"`,
+			err: logqlmodel.NewParseError("invalid multiline parameter first, expected firstline", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | multiline firstline="(a"`,
+			err: logqlmodel.NewParseError("invalid multiline firstline regex (a: error parsing regexp: missing closing ): `(a`", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | logfmt | multiline firstline="^\\d{4}-"`,
+			err: logqlmodel.NewParseError(`stage '| multiline firstline="^\\d{4}-"' must be the first stage`, 0, 0),
+		},
+		{
+			in:  `count_over_time({app="foo"} | multiline firstline="^\\d{4}-" [5m])`,
+			err: logqlmodel.NewParseError(`stage '| multiline firstline="^\\d{4}-"' is only allowed in log queries`, 0, 0),
+		},
+		{
+			in: `{app="foo"} | xml | xml user="/event/user", id="//@id" | user="foo"`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					newLabelParserExpr(OpParserTypeXML, ""),
+					mustNewXMLExpressionParser([]log.LabelExtractionExpr{
+						log.NewLabelExtractionExpr("user", "/event/user"),
+						log.NewLabelExtractionExpr("id", "//@id"),
+					}),
+					&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "user", "foo"))},
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | xml user="/event//user"`,
+			err: logqlmodel.NewParseError("invalid xml parser: cannot parse expression [/event//user]: empty element name", 0, 0),
+		},
+		{
+			in: `{app="foo"} | csv columns="ts, level,,msg" delimiter="\t" | level="error"`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					&CSVParserExpr{Columns: []string{"ts", "level", "", "msg"}, Delimiter: "\t"},
+					&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "level", "error"))},
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | csv columns="a,b" separator=";"`,
+			err: logqlmodel.NewParseError("invalid csv parameter separator, expected columns or delimiter", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | csv delimiter=";"`,
+			err: logqlmodel.NewParseError("invalid csv parser: no csv column provided", 0, 0),
+		},
+		{
+			in: `{app="foo"} | json_array "events" | json ids="items[*].id"`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					mustNewJSONArrayExpr("events"),
+					newJSONExpressionParser([]log.LabelExtractionExpr{log.NewLabelExtractionExpr("ids", "items[*].id")}),
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | json_array "events[*]"`,
+			err: logqlmodel.NewParseError("invalid json_array path: cannot use wildcards in expression [events[*]]", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | logfmt | json_array`,
+			err: logqlmodel.NewParseError("stage '| json_array' must be the first stage", 0, 0),
+		},
+		{
+			in:  `count_over_time({app="foo"} | json_array [5m])`,
+			err: logqlmodel.NewParseError("stage '| json_array' is only allowed in log queries", 0, 0),
+		},
+		{
+			in: `{app="foo"} |= "error" | context before=2 after=1`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					newLineFilterExpr(labels.MatchEqual, "", "error"),
+					mustNewContextExpr(OpContextBefore, "2", OpContextAfter, "1"),
+				},
+			),
+		},
+		{
+			in: `{app="foo"} | logfmt | context="x"`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStageExpr{
+					newLogfmtParserExpr(nil),
+					&LabelFilterExpr{LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "context", "x"))},
+				},
+			),
+		},
+		{
+			in:  `{app="foo"} | context before=1.5`,
+			err: logqlmodel.NewParseError("invalid context before 1.5, expected a non-negative integer", 0, 0),
+		},
+		{
+			in:  `{app="foo"} |= "error" | context before=2 | logfmt`,
+			err: logqlmodel.NewParseError("stage '| context before=2' must be the last stage", 0, 0),
+		},
+		{
+			in:  `{app="foo"} | multiline firstline="^\\d{4}-" |= "error" | context after=3`,
+			err: logqlmodel.NewParseError("stage '| context after=3' is not allowed with multiline, json_array, sort, head or tail stages", 0, 0),
+		},
+		{
+			in:  `count_over_time({app="foo"} |= "error" | context before=2 [5m])`,
+			err: logqlmodel.NewParseError("stage '| context before=2' is only allowed in log queries", 0, 0),
+		},
+		{
+			in: `sort(count_over_time({app="foo"} [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}), 5*time.Minute, nil, nil),
+					OpRangeTypeCount, nil, nil,
+				),
+				OpTypeSort, nil, nil,
+			),
+		},
 	} {
 		t.Run(tc.in, func(t *testing.T) {
 			ast, err := ParseExpr(tc.in)
@@ -3246,6 +3513,25 @@ func Test_PipelineCombined(t *testing.T) {
 	require.Equal(t, string([]byte(`1.5s|POST|200`)), string(line))
 }
 
+func Test_PipelineLookup(t *testing.T) {
+	expr, err := ParseLogSelector(`{app="foo"} | logfmt | lookup "owners" on service`, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"owners"}, LookupTableNames(expr))
+
+	// the lookup tables must be set before creating the pipeline.
+	_, err = expr.Pipeline()
+	require.EqualError(t, err, `parse error : stage '| lookup "owners" on service' : lookup table owners not found`)
+
+	table, err := log.NewLookupTable([]string{"service", "team"}, [][]string{{"api", "platform"}})
+	require.NoError(t, err)
+	SetLookupTables(expr, map[string]*log.LookupTable{"owners": table})
+	p, err := expr.Pipeline()
+	require.NoError(t, err)
+	_, lbs, matches := p.ForStream(labels.FromStrings("app", "foo")).Process(0, []byte(`service=api msg=hello`))
+	require.True(t, matches)
+	require.Equal(t, labels.FromStrings("app", "foo", "msg", "hello", "service", "api", "team", "platform"), lbs.Labels())
+}
+
 func Benchmark_PipelineCombined(b *testing.B) {
 	query := `{job="cortex-ops/query-frontend"} |= "logging.go" | logfmt | line_format "{{.msg}}" | regexp "(?P<method>\\w+) (?P<path>[\\w|/]+) \\((?P<status>\\d+?)\\) (?P<duration>.*)" | (duration > 1s or status==200) and method="POST" | line_format "{{.duration}}|{{.method}}|{{.status}}"`
 
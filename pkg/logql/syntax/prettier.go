@@ -109,6 +109,7 @@ func (e *LogfmtParserExpr) Pretty(level int) string {
 // `| regexp`
 // `| pattern`
 // `| unpack`
+// `| xml`
 func (e *LabelParserExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
@@ -121,6 +122,36 @@ func (e *KeepLabelsExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | lookup "owners" on service
+func (e *LookupExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | sort by duration desc
+func (e *SortExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | head 20
+func (e *LimitExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | multiline firstline="^\\d{4}-"
+func (e *MultilineExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | json_array "events"
+func (e *JSONArrayExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | context before=5 after=5
+func (e *ContextExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 // e.g: | level!="error"
 func (e *LabelFilterExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -146,6 +177,16 @@ func (e *JSONExpressionParser) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | xml label="expression", another="expression"
+func (e *XMLExpressionParser) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | csv columns="ts,level,msg" delimiter=";"
+func (e *CSVParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 // e.g: | logfmt label="expression", another="expression"
 func (e *LogfmtExpressionParser) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -226,6 +267,35 @@ func (e *RangeAggregationExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: max_over_time(rate({foo="bar"}[5m])[1h:1m])
+func (e *SubqueryExpr) Pretty(level int) string {
+	s := indent(level)
+	if !needSplit(e) {
+		return s + e.String()
+	}
+
+	s += e.Operation + "(\n"
+
+	if e.Params != nil {
+		s = fmt.Sprintf("%s%s%s,", s, indent(level+1), fmt.Sprint(*e.Params))
+		s += "\n"
+	}
+
+	s += e.Left.Pretty(level + 1)
+	s += fmt.Sprintf("[%s:", model.Duration(e.Range))
+	if e.Step != 0 {
+		s += model.Duration(e.Step).String()
+	}
+	s += "]"
+	if e.Offset != 0 {
+		s += (&OffsetExpr{Offset: e.Offset}).Pretty(level)
+	}
+
+	s += "\n" + indent(level) + ")"
+
+	return s
+}
+
 // e.g:
 // sum(count_over_time({foo="bar"}[5m])) by (container)
 // topk(10, count_over_time({foo="bar"}[5m])) by (container)
@@ -338,6 +408,22 @@ func (e *LabelReplaceExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: histogram_quantile(0.99, sum by (le) (histogram_over_time({app="foo"} | unwrap latency [5m])))
+func (e *HistogramQuantileExpr) Pretty(level int) string {
+	s := indent(level)
+
+	if !needSplit(e) {
+		return s + e.String()
+	}
+
+	s += OpTypeHistogramQuantile + "(\n"
+	s += indent(level+1) + strconv.FormatFloat(e.Quantile, 'f', -1, 64) + ",\n"
+	s += e.Left.Pretty(level+1) + "\n"
+	s += indent(level) + ")"
+
+	return s
+}
+
 // e.g: vector(5)
 func (e *VectorExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -56,6 +56,15 @@ func TestFormat(t *testing.T) {
 			exp: `count_over_time(
   {job="loki", instance="localhost"}
     | logfmt [1m]
+)`,
+		},
+		{
+			name: "subquery",
+			in:   `max_over_time(rate({job="loki"}[1m])[1h:1m] offset 1h)`,
+			exp: `max_over_time(
+  rate(
+    {job="loki"} [1m]
+  )[1h:1m] offset 1h
 )`,
 		},
 		{
@@ -20,6 +20,8 @@ type Result struct {
 	Data       parser.Value
 	Statistics stats.Result
 	Headers    []*definitions.PrometheusResponseHeader
+	// Warnings are returned to the client along with the result, e.g. when only a part of the result could be computed.
+	Warnings []string
 }
 
 // Streams is promql.Value
@@ -27,14 +27,16 @@ var (
 
 // Context is the metadata context. It is passed through the query path and accumulates metadata.
 type Context struct {
-	mtx     sync.Mutex
-	headers map[string][]string
+	mtx      sync.Mutex
+	headers  map[string][]string
+	warnings map[string]struct{}
 }
 
 // NewContext creates a new metadata context
 func NewContext(ctx context.Context) (*Context, context.Context) {
 	contextData := &Context{
-		headers: map[string][]string{},
+		headers:  map[string][]string{},
+		warnings: map[string]struct{}{},
 	}
 	ctx = context.WithValue(ctx, metadataKey, contextData)
 	return contextData, ctx
@@ -45,7 +47,8 @@ func FromContext(ctx context.Context) *Context {
 	v, ok := ctx.Value(metadataKey).(*Context)
 	if !ok {
 		return &Context{
-			headers: map[string][]string{},
+			headers:  map[string][]string{},
+			warnings: map[string]struct{}{},
 		}
 	}
 	return v
@@ -94,3 +97,37 @@ func ExtendHeaders(dst map[string][]string, src []*definitions.PrometheusRespons
 		dst[header.Name] = header.Values
 	}
 }
+
+// Warnings returns the warnings accumulated in the context so far, sorted.
+func (c *Context) Warnings() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if len(c.warnings) == 0 {
+		return nil
+	}
+	warnings := make([]string, 0, len(c.warnings))
+	for w := range c.warnings {
+		warnings = append(warnings, w)
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// AddWarnings adds warnings to the embedded warnings in a context in a concurrency-safe manner.
+// The same warning is only added once.
+func AddWarnings(ctx context.Context, warnings ...string) error {
+	context, ok := ctx.Value(metadataKey).(*Context)
+	if !ok {
+		return ErrNoCtxData
+	}
+
+	context.mtx.Lock()
+	defer context.mtx.Unlock()
+
+	for _, w := range warnings {
+		context.warnings[w] = struct{}{}
+	}
+
+	return nil
+}
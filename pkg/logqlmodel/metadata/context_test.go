@@ -56,3 +56,14 @@ func TestHeadersNoKey(t *testing.T) {
 
 	require.True(t, errors.Is(err, ErrNoCtxData))
 }
+
+func TestWarnings(t *testing.T) {
+	metadata, ctx := NewContext(context.Background())
+	require.Nil(t, metadata.Warnings())
+
+	require.NoError(t, AddWarnings(ctx, "warning2", "warning1"))
+	require.NoError(t, AddWarnings(ctx, "warning1"))
+	require.Equal(t, []string{"warning1", "warning2"}, metadata.Warnings())
+
+	require.True(t, errors.Is(AddWarnings(context.Background(), "warning"), ErrNoCtxData))
+}
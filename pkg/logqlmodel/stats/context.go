@@ -60,6 +60,7 @@ const (
 	ResultCache                = "result"
 	StatsResultCache           = "stats-result"
 	WriteDedupeCache           = "write-dedupe"
+	BloomFilterCache           = "bloom-filter"
 )
 
 // NewContext creates a new statistics context
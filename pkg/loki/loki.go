@@ -346,6 +346,7 @@ type Loki struct {
 	InternalServer            *server.Server
 	ring                      *ring.Ring
 	Overrides                 limiter.CombinedLimits
+	lookupTables              *logql.LookupTables
 	tenantConfigs             *runtime.TenantConfigs
 	TenantLimits              validation.TenantLimits
 	distributor               *distributor.Distributor
@@ -666,7 +667,7 @@ func (t *Loki) setupModuleManager() error {
 		TableManager:             {Server, Analytics},
 		Compactor:                {Server, Overrides, MemberlistKV, Analytics},
 		IndexGateway:             {Server, Store, Overrides, Analytics, MemberlistKV, IndexGatewayRing, IndexGatewayInterceptors},
-		IngesterQuerier:          {Ring},
+		IngesterQuerier:          {Ring, Overrides},
 		QuerySchedulerRing:       {Overrides, Server, MemberlistKV},
 		IndexGatewayRing:         {Overrides, Server, MemberlistKV},
 		All:                      {QueryScheduler, QueryFrontend, Querier, Ingester, Distributor, Ruler, Compactor},
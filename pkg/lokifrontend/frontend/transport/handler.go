@@ -144,7 +144,13 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(resp.StatusCode)
 	// we don't check for copy error as there is no much we can do at this point
-	_, _ = io.Copy(w, resp.Body)
+	if flusher, ok := w.(http.Flusher); ok && resp.ContentLength < 0 {
+		// The length of streamed responses is unknown, they are flushed to the client as they are read.
+		_, _ = io.Copy(flushWriter{w: w, flusher: flusher}, resp.Body)
+	} else {
+		_, _ = io.Copy(w, resp.Body)
+	}
+	_ = resp.Body.Close()
 
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
@@ -239,6 +245,19 @@ func writeError(w http.ResponseWriter, err error) {
 	server.WriteError(w, err)
 }
 
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil {
+		fw.flusher.Flush()
+	}
+	return n, err
+}
+
 func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Header, stats *querier_stats.Stats) {
 	if stats != nil {
 		parts := make([]string, 0)
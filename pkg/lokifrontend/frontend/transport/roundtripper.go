@@ -8,6 +8,8 @@ import (
 
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/httpgrpc/server"
+
+	"github.com/grafana/loki/pkg/util/httpreq"
 )
 
 // GrpcRoundTripper is similar to http.RoundTripper, but works with HTTP requests converted to protobuf messages.
@@ -15,6 +17,13 @@ type GrpcRoundTripper interface {
 	RoundTripGRPC(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
 }
 
+// GrpcStreamingRoundTripper is a GrpcRoundTripper that can return the body of the responses as it is received.
+type GrpcStreamingRoundTripper interface {
+	GrpcRoundTripper
+	// RoundTripGRPCStream returns the body of the response in the returned reader if it's streamed.
+	RoundTripGRPCStream(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, io.ReadCloser, error)
+}
+
 func AdaptGrpcRoundTripperToHTTPRoundTripper(r GrpcRoundTripper) http.RoundTripper {
 	return &grpcRoundTripperAdapter{roundTripper: r}
 }
@@ -39,6 +48,10 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 		return nil, err
 	}
 
+	if sr, ok := a.roundTripper.(GrpcStreamingRoundTripper); ok && r.Header.Get(httpreq.LokiResponseStreamingHeader) != "" {
+		return a.roundTripStream(r, sr, req)
+	}
+
 	resp, err := a.roundTripper.RoundTripGRPC(r.Context(), req)
 	if err != nil {
 		return nil, err
@@ -55,3 +68,24 @@ func (a *grpcRoundTripperAdapter) RoundTrip(r *http.Request) (*http.Response, er
 	}
 	return httpResp, nil
 }
+
+func (a *grpcRoundTripperAdapter) roundTripStream(r *http.Request, sr GrpcStreamingRoundTripper, req *httpgrpc.HTTPRequest) (*http.Response, error) {
+	resp, body, err := sr.RoundTripGRPCStream(r.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		body = io.NopCloser(bytes.NewReader(resp.Body))
+	}
+
+	httpResp := &http.Response{
+		StatusCode:    int(resp.Code),
+		Body:          body,
+		Header:        http.Header{},
+		ContentLength: -1,
+	}
+	for _, h := range resp.Headers {
+		httpResp.Header[h.Key] = h.Values
+	}
+	return httpResp, nil
+}
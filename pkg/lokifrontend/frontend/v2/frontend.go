@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"sync"
@@ -22,6 +23,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/weaveworks/common/httpgrpc"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/dskit/tenant"
 
@@ -86,10 +89,18 @@ type frontendRequest struct {
 	actor        []string
 	statsEnabled bool
 
+	ctx    context.Context
 	cancel context.CancelFunc
 
 	enqueue  chan enqueueResult
 	response chan *frontendv2pb.QueryResultRequest
+	// streamedResponse receives the response when the querier streams its body.
+	streamedResponse chan *streamedResponse
+}
+
+type streamedResponse struct {
+	metadata *httpgrpc.HTTPResponse
+	body     *streamedBody
 }
 
 type enqueueStatus int
@@ -186,10 +197,29 @@ func (f *Frontend) stopping(_ error) error {
 
 // RoundTripGRPC round trips a proto (instead of a HTTP request).
 func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
-	tenantIDs, err := tenant.TenantIDs(ctx)
+	resp, body, err := f.RoundTripGRPCStream(ctx, req)
+	if err != nil || body == nil {
+		return resp, err
+	}
+	defer body.Close()
+
+	// The querier streamed the body of the response, which is buffered for the callers expecting a whole response.
+	buf, err := io.ReadAll(body)
 	if err != nil {
 		return nil, err
 	}
+	resp.Body = buf
+	return resp, nil
+}
+
+// RoundTripGRPCStream round trips a proto like RoundTripGRPC. If the querier streams the body of the response,
+// the returned response has no body and the body is read from the returned reader as it is received instead.
+// The reader must be closed.
+func (f *Frontend) RoundTripGRPCStream(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, io.ReadCloser, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	tenantID := tenant.JoinTenantIDs(tenantIDs)
 
 	// Propagate trace context in gRPC too - this will be ignored if using HTTP.
@@ -197,12 +227,11 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 	if tracer != nil && span != nil {
 		carrier := (*lokigrpc.HeadersCarrier)(req)
 		if err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, carrier); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
 
 	freq := &frontendRequest{
 		queryID:      f.lastQueryID.Inc(),
@@ -211,16 +240,28 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 		actor:        httpreq.ExtractActorPath(ctx),
 		statsEnabled: stats.IsEnabled(ctx),
 
+		ctx:    ctx,
 		cancel: cancel,
 
 		// Buffer of 1 to ensure response or error can be written to the channel
 		// even if this goroutine goes away due to client context cancellation.
-		enqueue:  make(chan enqueueResult, 1),
-		response: make(chan *frontendv2pb.QueryResultRequest, 1),
+		enqueue:          make(chan enqueueResult, 1),
+		response:         make(chan *frontendv2pb.QueryResultRequest, 1),
+		streamedResponse: make(chan *streamedResponse, 1),
 	}
 
 	f.requests.put(freq)
-	defer f.requests.delete(freq.queryID)
+	release := func() {
+		cancel()
+		f.requests.delete(freq.queryID)
+	}
+	// A streamed response is released once its body is closed.
+	streamed := false
+	defer func() {
+		if !streamed {
+			release()
+		}
+	}()
 
 	retries := f.cfg.WorkerConcurrency + 1 // To make sure we hit at least two different schedulers.
 
@@ -228,7 +269,7 @@ enqueueAgain:
 	var cancelCh chan<- uint64
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 
 	case f.requestsCh <- freq:
 		// Enqueued, let's wait for response.
@@ -244,7 +285,7 @@ enqueueAgain:
 			}
 		}
 
-		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
+		return nil, nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
 	}
 
 	select {
@@ -258,7 +299,7 @@ enqueueAgain:
 				level.Warn(f.log).Log("msg", "failed to send cancellation request to scheduler, queue full")
 			}
 		}
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 
 	case resp := <-freq.response:
 		if stats.ShouldTrackHTTPGRPCResponse(resp.HttpResponse) {
@@ -266,7 +307,12 @@ enqueueAgain:
 			stats.Merge(resp.Stats) // Safe if stats is nil.
 		}
 
-		return resp.HttpResponse, nil
+		return resp.HttpResponse, nil, nil
+
+	case resp := <-freq.streamedResponse:
+		streamed = true
+		resp.body.release = release
+		return resp.metadata, resp.body, nil
 	}
 }
 
@@ -298,6 +344,117 @@ func (f *Frontend) QueryResult(ctx context.Context, qrReq *frontendv2pb.QueryRes
 	return &frontendv2pb.QueryResultResponse{}, nil
 }
 
+// QueryResultStream receives a response whose body is streamed by the querier, and hands the chunks of the body over
+// to the reader of the response one at a time, so that the querier can't send faster than the response is read.
+func (f *Frontend) QueryResultStream(s frontendv2pb.FrontendForQuerier_QueryResultStreamServer) error {
+	tenantIDs, err := tenant.TenantIDs(s.Context())
+	if err != nil {
+		return err
+	}
+	userID := tenant.JoinTenantIDs(tenantIDs)
+
+	msg, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	metadata := msg.GetMetadata()
+	if metadata == nil {
+		return status.Error(codes.InvalidArgument, "the first message of a streamed query result must have the response metadata")
+	}
+
+	req := f.requests.get(msg.QueryID)
+	// Same as in QueryResult, the user is verified to not leak results between users.
+	if req == nil || req.tenantID != userID {
+		return status.Error(codes.Canceled, "query is not in progress")
+	}
+
+	body := &streamedBody{
+		ctx:    req.ctx,
+		chunks: make(chan []byte),
+		closed: make(chan struct{}),
+	}
+	select {
+	case req.streamedResponse <- &streamedResponse{metadata: metadata, body: body}:
+	default:
+		level.Warn(f.log).Log("msg", "failed to write query result to the response channel", "queryID", msg.QueryID, "user", userID)
+		return status.Error(codes.AlreadyExists, "query result already received")
+	}
+
+	for {
+		msg, err := s.Recv()
+		if err == io.EOF {
+			close(body.chunks)
+			return s.SendAndClose(&frontendv2pb.QueryResultResponse{})
+		}
+		if err != nil {
+			body.err = err
+			close(body.chunks)
+			return err
+		}
+
+		switch data := msg.Data.(type) {
+		case *frontendv2pb.QueryResultStreamRequest_Body:
+			select {
+			case body.chunks <- data.Body:
+			case <-body.closed:
+				// Cancel the query, the rest of the response isn't needed.
+				return status.Error(codes.Canceled, "response body closed")
+			case <-req.ctx.Done():
+				return status.Error(codes.Canceled, req.ctx.Err().Error())
+			}
+		case *frontendv2pb.QueryResultStreamRequest_Stats:
+			if stats.ShouldTrackHTTPGRPCResponse(metadata) {
+				stats.FromContext(req.ctx).Merge(data.Stats) // Safe if stats is nil.
+			}
+		}
+	}
+}
+
+// streamedBody is the body of a response streamed by a querier.
+type streamedBody struct {
+	ctx    context.Context
+	chunks chan []byte
+	// err is set before chunks is closed if the stream failed.
+	err error
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	release   func()
+
+	buf []byte
+}
+
+func (b *streamedBody) Read(p []byte) (int, error) {
+	for len(b.buf) == 0 {
+		select {
+		case chunk, ok := <-b.chunks:
+			if !ok {
+				if b.err != nil {
+					return 0, b.err
+				}
+				return 0, io.EOF
+			}
+			b.buf = chunk
+		case <-b.ctx.Done():
+			return 0, b.ctx.Err()
+		}
+	}
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
+
+// Close stops the querier streaming the response, if it is not complete yet, and releases the request.
+func (b *streamedBody) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+		if b.release != nil {
+			b.release()
+		}
+	})
+	return nil
+}
+
 // CheckReady determines if the query frontend is ready.  Function parameters/return
 // chosen to match the same method in the ingester
 func (f *Frontend) CheckReady(_ context.Context) error {
@@ -2,6 +2,7 @@ package v2
 
 import (
 	"context"
+	"io"
 	"net"
 	"strconv"
 	"strings"
@@ -396,3 +397,100 @@ func (m *mockScheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_F
 		}
 	}
 }
+
+type fakeQueryResultStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	msgs chan *frontendv2pb.QueryResultStreamRequest
+}
+
+func (s *fakeQueryResultStream) Context() context.Context { return s.ctx }
+
+func (s *fakeQueryResultStream) Recv() (*frontendv2pb.QueryResultStreamRequest, error) {
+	msg, ok := <-s.msgs
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+func (s *fakeQueryResultStream) SendAndClose(*frontendv2pb.QueryResultResponse) error { return nil }
+
+func TestFrontendStreamedResponse(t *testing.T) {
+	const userID = "test"
+	streamErr := make(chan error, 1)
+
+	f, _ := setupFrontend(t, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		s := &fakeQueryResultStream{
+			ctx:  user.InjectOrgID(context.Background(), userID),
+			msgs: make(chan *frontendv2pb.QueryResultStreamRequest, 4),
+		}
+		s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Metadata{
+			Metadata: &httpgrpc.HTTPResponse{Code: 200},
+		}}
+		s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Body{Body: []byte("hello ")}}
+		s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Body{Body: []byte("world")}}
+		s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Stats{Stats: &stats.Stats{}}}
+		close(s.msgs)
+
+		go func() {
+			// Same as QueryResult, the frontend must first be waiting for the response.
+			time.Sleep(100 * time.Millisecond)
+			streamErr <- f.QueryResultStream(s)
+		}()
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	resp, body, err := f.RoundTripGRPCStream(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int32(200), resp.Code)
+	require.NotNil(t, body)
+
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(b))
+	require.NoError(t, body.Close())
+	require.NoError(t, <-streamErr)
+}
+
+func TestFrontendStreamedResponseClosed(t *testing.T) {
+	const userID = "test"
+	streamErr := make(chan error, 1)
+	stop := make(chan struct{})
+
+	f, _ := setupFrontend(t, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		s := &fakeQueryResultStream{
+			ctx:  user.InjectOrgID(context.Background(), userID),
+			msgs: make(chan *frontendv2pb.QueryResultStreamRequest),
+		}
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			streamErr <- f.QueryResultStream(s)
+		}()
+		go func() {
+			s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Metadata{
+				Metadata: &httpgrpc.HTTPResponse{Code: 200},
+			}}
+			// The querier keeps sending the body until the frontend stops reading it.
+			for {
+				select {
+				case s.msgs <- &frontendv2pb.QueryResultStreamRequest{QueryID: msg.QueryID, Data: &frontendv2pb.QueryResultStreamRequest_Body{Body: []byte("more")}}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	_, body, err := f.RoundTripGRPCStream(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+	require.NoError(t, err)
+	b := make([]byte, 4)
+	_, err = io.ReadFull(body, b)
+	require.NoError(t, err)
+	require.Equal(t, "more", string(b))
+
+	require.NoError(t, body.Close())
+	require.Error(t, <-streamErr)
+	close(stop)
+}
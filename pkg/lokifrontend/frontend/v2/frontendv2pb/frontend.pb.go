@@ -4,6 +4,7 @@
 package frontendv2pb
 
 import (
+	bytes "bytes"
 	context "context"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
@@ -90,13 +91,119 @@ func (m *QueryResultRequest) GetStats() *stats.Stats {
 	return nil
 }
 
+type QueryResultStreamRequest struct {
+	QueryID uint64 `protobuf:"varint,1,opt,name=queryID,proto3" json:"queryID,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//	*QueryResultStreamRequest_Metadata
+	//	*QueryResultStreamRequest_Body
+	//	*QueryResultStreamRequest_Stats
+	Data isQueryResultStreamRequest_Data `protobuf_oneof:"data"`
+}
+
+func (m *QueryResultStreamRequest) Reset()      { *m = QueryResultStreamRequest{} }
+func (*QueryResultStreamRequest) ProtoMessage() {}
+func (*QueryResultStreamRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_85a7e5cdf8261f06, []int{1}
+}
+func (m *QueryResultStreamRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryResultStreamRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryResultStreamRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryResultStreamRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryResultStreamRequest.Merge(m, src)
+}
+func (m *QueryResultStreamRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryResultStreamRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryResultStreamRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryResultStreamRequest proto.InternalMessageInfo
+
+type isQueryResultStreamRequest_Data interface {
+	isQueryResultStreamRequest_Data()
+	Equal(interface{}) bool
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type QueryResultStreamRequest_Metadata struct {
+	Metadata *httpgrpc.HTTPResponse `protobuf:"bytes,2,opt,name=metadata,proto3,oneof" json:"metadata,omitempty"`
+}
+type QueryResultStreamRequest_Body struct {
+	Body []byte `protobuf:"bytes,3,opt,name=body,proto3,oneof" json:"body,omitempty"`
+}
+type QueryResultStreamRequest_Stats struct {
+	Stats *stats.Stats `protobuf:"bytes,4,opt,name=stats,proto3,oneof" json:"stats,omitempty"`
+}
+
+func (*QueryResultStreamRequest_Metadata) isQueryResultStreamRequest_Data() {}
+func (*QueryResultStreamRequest_Body) isQueryResultStreamRequest_Data()     {}
+func (*QueryResultStreamRequest_Stats) isQueryResultStreamRequest_Data()    {}
+
+func (m *QueryResultStreamRequest) GetData() isQueryResultStreamRequest_Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *QueryResultStreamRequest) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
+func (m *QueryResultStreamRequest) GetMetadata() *httpgrpc.HTTPResponse {
+	if x, ok := m.GetData().(*QueryResultStreamRequest_Metadata); ok {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (m *QueryResultStreamRequest) GetBody() []byte {
+	if x, ok := m.GetData().(*QueryResultStreamRequest_Body); ok {
+		return x.Body
+	}
+	return nil
+}
+
+func (m *QueryResultStreamRequest) GetStats() *stats.Stats {
+	if x, ok := m.GetData().(*QueryResultStreamRequest_Stats); ok {
+		return x.Stats
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*QueryResultStreamRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*QueryResultStreamRequest_Metadata)(nil),
+		(*QueryResultStreamRequest_Body)(nil),
+		(*QueryResultStreamRequest_Stats)(nil),
+	}
+}
+
 type QueryResultResponse struct {
 }
 
 func (m *QueryResultResponse) Reset()      { *m = QueryResultResponse{} }
 func (*QueryResultResponse) ProtoMessage() {}
 func (*QueryResultResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_85a7e5cdf8261f06, []int{1}
+	return fileDescriptor_85a7e5cdf8261f06, []int{2}
 }
 func (m *QueryResultResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -127,6 +234,7 @@ var xxx_messageInfo_QueryResultResponse proto.InternalMessageInfo
 
 func init() {
 	proto.RegisterType((*QueryResultRequest)(nil), "frontendv2pb.QueryResultRequest")
+	proto.RegisterType((*QueryResultStreamRequest)(nil), "frontendv2pb.QueryResultStreamRequest")
 	proto.RegisterType((*QueryResultResponse)(nil), "frontendv2pb.QueryResultResponse")
 }
 
@@ -135,29 +243,34 @@ func init() {
 }
 
 var fileDescriptor_85a7e5cdf8261f06 = []byte{
-	// 342 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x51, 0xbd, 0x6e, 0xf2, 0x30,
-	0x14, 0xb5, 0xbf, 0xaf, 0x3f, 0x92, 0x61, 0x72, 0x7f, 0x14, 0x21, 0xf5, 0x8a, 0x66, 0x62, 0x8a,
-	0xa5, 0xb4, 0x43, 0xd5, 0x11, 0x55, 0xa8, 0xdd, 0x4a, 0xca, 0xd4, 0x0d, 0xa8, 0x1b, 0x28, 0x10,
-	0x07, 0xdb, 0x01, 0xb1, 0xf5, 0x09, 0xaa, 0x3e, 0x46, 0x1f, 0xa5, 0x23, 0x23, 0x63, 0x31, 0x4b,
-	0x47, 0x1e, 0xa1, 0x4a, 0x0c, 0x11, 0x51, 0xa5, 0x2e, 0x57, 0xe7, 0xe6, 0xdc, 0x9b, 0x73, 0xee,
-	0x31, 0xb9, 0x8a, 0x07, 0x21, 0x1b, 0x8a, 0x41, 0xff, 0x59, 0x8a, 0x48, 0xf3, 0xe8, 0x89, 0xe5,
-	0x60, 0xe2, 0xe7, 0x78, 0xe2, 0xc7, 0x9d, 0xbc, 0xf1, 0x62, 0x29, 0xb4, 0xa0, 0xe5, 0x5d, 0xb2,
-	0x72, 0x19, 0xf6, 0x75, 0x2f, 0xe9, 0x78, 0x5d, 0x31, 0x62, 0x53, 0xde, 0x9e, 0xf0, 0xa9, 0x90,
-	0x03, 0xc5, 0xba, 0x62, 0x34, 0x12, 0x11, 0xeb, 0x69, 0x1d, 0x87, 0x32, 0xee, 0xe6, 0xc0, 0xfe,
-	0xa3, 0x72, 0x1c, 0x8a, 0x50, 0x64, 0x90, 0xa5, 0x68, 0xf3, 0xf5, 0x2c, 0xf5, 0x34, 0x4e, 0xb8,
-	0xec, 0x73, 0xc9, 0x94, 0x6e, 0x6b, 0x65, 0xab, 0xa5, 0xdd, 0x37, 0x4c, 0x68, 0x33, 0xe1, 0x72,
-	0x16, 0x70, 0x95, 0x0c, 0x75, 0xc0, 0xc7, 0x09, 0x57, 0x9a, 0x3a, 0xe4, 0x30, 0xdd, 0x99, 0xdd,
-	0xdd, 0x38, 0xb8, 0x8a, 0x6b, 0x7b, 0xc1, 0xb6, 0xa5, 0xd7, 0xa4, 0x9c, 0xea, 0x06, 0x5c, 0xc5,
-	0x22, 0x52, 0xdc, 0xf9, 0x57, 0xc5, 0xb5, 0x92, 0x7f, 0xea, 0xe5, 0x66, 0x6e, 0x5b, 0xad, 0xfb,
-	0x2d, 0x1b, 0x14, 0x66, 0xa9, 0x4b, 0xf6, 0x33, 0x6d, 0xe7, 0x7f, 0xb6, 0x54, 0xf6, 0xac, 0x93,
-	0x87, 0xb4, 0x06, 0x96, 0x72, 0x4f, 0xc8, 0x51, 0xc1, 0x8f, 0x5d, 0xf5, 0x5f, 0x08, 0x6d, 0x6c,
-	0x22, 0x6a, 0x08, 0xd9, 0xb4, 0xf7, 0xd0, 0x16, 0x29, 0xed, 0x0c, 0xd3, 0xaa, 0xb7, 0x1b, 0xa3,
-	0xf7, 0xfb, 0xae, 0xca, 0xf9, 0x1f, 0x13, 0x56, 0xc9, 0x45, 0xf5, 0xfa, 0x7c, 0x09, 0x68, 0xb1,
-	0x04, 0xb4, 0x5e, 0x02, 0x7e, 0x35, 0x80, 0x3f, 0x0c, 0xe0, 0x4f, 0x03, 0x78, 0x6e, 0x00, 0x7f,
-	0x19, 0xc0, 0xdf, 0x06, 0xd0, 0xda, 0x00, 0x7e, 0x5f, 0x01, 0x9a, 0xaf, 0x00, 0x2d, 0x56, 0x80,
-	0x1e, 0x0b, 0x4f, 0xd8, 0x39, 0xc8, 0xe2, 0xbd, 0xf8, 0x09, 0x00, 0x00, 0xff, 0xff, 0x01, 0x87,
-	0x83, 0x56, 0x13, 0x02, 0x00, 0x00,
+	// 418 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xc1, 0xce, 0xd2, 0x40,
+	0x10, 0xc7, 0x77, 0xb5, 0xa2, 0x59, 0x7a, 0x71, 0x45, 0xd3, 0x90, 0xb8, 0xc1, 0xc6, 0x18, 0x4e,
+	0x6d, 0x52, 0x39, 0x18, 0x8f, 0xc4, 0x90, 0x7a, 0x93, 0x85, 0x93, 0xb7, 0x16, 0xd6, 0x42, 0xa0,
+	0xdd, 0xb2, 0xdd, 0x42, 0xb8, 0xf9, 0x04, 0xc6, 0xc7, 0xf0, 0xe4, 0x73, 0x78, 0x32, 0x1c, 0x39,
+	0x4a, 0xb9, 0x78, 0xe4, 0x11, 0x4c, 0xbb, 0xd0, 0xb4, 0x21, 0xf2, 0x7d, 0x97, 0xcd, 0x7f, 0x76,
+	0x66, 0xf6, 0xff, 0xcb, 0xcc, 0xa2, 0x77, 0xf1, 0x22, 0xb0, 0x97, 0x7c, 0x31, 0xff, 0x22, 0x78,
+	0x24, 0x59, 0x34, 0xb5, 0x4b, 0xb1, 0x76, 0x4a, 0xbd, 0x76, 0x62, 0xbf, 0x0c, 0xac, 0x58, 0x70,
+	0xc9, 0xb1, 0x5e, 0x4d, 0xb6, 0x7b, 0xc1, 0x5c, 0xce, 0x52, 0xdf, 0x9a, 0xf0, 0xd0, 0xde, 0x30,
+	0x6f, 0xcd, 0x36, 0x5c, 0x2c, 0x12, 0x7b, 0xc2, 0xc3, 0x90, 0x47, 0xf6, 0x4c, 0xca, 0x38, 0x10,
+	0xf1, 0xa4, 0x14, 0xea, 0x8d, 0x76, 0x2b, 0xe0, 0x01, 0x2f, 0xa4, 0x9d, 0xab, 0xf3, 0xed, 0xcb,
+	0x9c, 0x69, 0x95, 0x32, 0x31, 0x67, 0xc2, 0x4e, 0xa4, 0x27, 0x13, 0x75, 0xaa, 0xb4, 0xf9, 0x0d,
+	0x22, 0x3c, 0x4c, 0x99, 0xd8, 0x52, 0x96, 0xa4, 0x4b, 0x49, 0xd9, 0x2a, 0x65, 0x89, 0xc4, 0x06,
+	0x7a, 0x9c, 0xf7, 0x6c, 0x3f, 0x7e, 0x30, 0x60, 0x07, 0x76, 0x35, 0x7a, 0x09, 0xf1, 0x7b, 0xa4,
+	0xe7, 0xbe, 0x94, 0x25, 0x31, 0x8f, 0x12, 0x66, 0x3c, 0xe8, 0xc0, 0x6e, 0xd3, 0x79, 0x61, 0x95,
+	0x30, 0xee, 0x78, 0xfc, 0xe9, 0x92, 0xa5, 0xb5, 0x5a, 0x6c, 0xa2, 0x47, 0x85, 0xb7, 0xf1, 0xb0,
+	0x68, 0xd2, 0x2d, 0x45, 0x32, 0xca, 0x4f, 0xaa, 0x52, 0xe6, 0x4f, 0x88, 0x8c, 0x0a, 0xd0, 0x48,
+	0x0a, 0xe6, 0x85, 0x77, 0x63, 0xf5, 0xd0, 0x93, 0x90, 0x49, 0x6f, 0xea, 0x49, 0xef, 0x36, 0x92,
+	0x0b, 0x68, 0x59, 0x89, 0x5b, 0x48, 0xf3, 0xf9, 0x74, 0x5b, 0xf0, 0xe8, 0x2e, 0xa0, 0x45, 0x84,
+	0x5f, 0x5f, 0x30, 0xb5, 0x6b, 0x4c, 0x17, 0x9c, 0x41, 0xfb, 0x0d, 0xa4, 0xe5, 0x6f, 0x98, 0xcf,
+	0xd1, 0xb3, 0xda, 0x00, 0x95, 0x8d, 0xf3, 0x1b, 0x22, 0x3c, 0x38, 0x2f, 0x75, 0xc0, 0xc5, 0x50,
+	0x6d, 0x00, 0x8f, 0x51, 0xb3, 0x52, 0x8d, 0x3b, 0x56, 0x75, 0xf1, 0xd6, 0xf5, 0x26, 0xda, 0xaf,
+	0x6e, 0x54, 0x28, 0x2b, 0x13, 0x60, 0x1f, 0x3d, 0xbd, 0x9a, 0x19, 0x7e, 0xf3, 0xdf, 0xce, 0xda,
+	0x50, 0xef, 0xe5, 0xd0, 0x85, 0xfd, 0xfe, 0xee, 0x40, 0xc0, 0xfe, 0x40, 0xc0, 0xe9, 0x40, 0xe0,
+	0xd7, 0x8c, 0xc0, 0x1f, 0x19, 0x81, 0xbf, 0x32, 0x02, 0x77, 0x19, 0x81, 0x7f, 0x32, 0x02, 0xff,
+	0x66, 0x04, 0x9c, 0x32, 0x02, 0xbf, 0x1f, 0x09, 0xd8, 0x1d, 0x09, 0xd8, 0x1f, 0x09, 0xf8, 0x5c,
+	0xfb, 0xd8, 0x7e, 0xa3, 0xf8, 0x74, 0x6f, 0xff, 0x0d, 0x00, 0xc0, 0x46, 0x1e, 0x4e, 0x29, 0x03,
+	0x00, 0x00,
 }
 
 func (this *QueryResultRequest) Equal(that interface{}) bool {
@@ -190,6 +303,111 @@ func (this *QueryResultRequest) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *QueryResultStreamRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryResultStreamRequest)
+	if !ok {
+		that2, ok := that.(QueryResultStreamRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
+	if that1.Data == nil {
+		if this.Data != nil {
+			return false
+		}
+	} else if this.Data == nil {
+		return false
+	} else if !this.Data.Equal(that1.Data) {
+		return false
+	}
+	return true
+}
+func (this *QueryResultStreamRequest_Metadata) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryResultStreamRequest_Metadata)
+	if !ok {
+		that2, ok := that.(QueryResultStreamRequest_Metadata)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Metadata.Equal(that1.Metadata) {
+		return false
+	}
+	return true
+}
+func (this *QueryResultStreamRequest_Body) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryResultStreamRequest_Body)
+	if !ok {
+		that2, ok := that.(QueryResultStreamRequest_Body)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.Body, that1.Body) {
+		return false
+	}
+	return true
+}
+func (this *QueryResultStreamRequest_Stats) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryResultStreamRequest_Stats)
+	if !ok {
+		that2, ok := that.(QueryResultStreamRequest_Stats)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Stats.Equal(that1.Stats) {
+		return false
+	}
+	return true
+}
 func (this *QueryResultResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -227,6 +445,43 @@ func (this *QueryResultRequest) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *QueryResultStreamRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&frontendv2pb.QueryResultStreamRequest{")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	if this.Data != nil {
+		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QueryResultStreamRequest_Metadata) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&frontendv2pb.QueryResultStreamRequest_Metadata{` +
+		`Metadata:` + fmt.Sprintf("%#v", this.Metadata) + `}`}, ", ")
+	return s
+}
+func (this *QueryResultStreamRequest_Body) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&frontendv2pb.QueryResultStreamRequest_Body{` +
+		`Body:` + fmt.Sprintf("%#v", this.Body) + `}`}, ", ")
+	return s
+}
+func (this *QueryResultStreamRequest_Stats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&frontendv2pb.QueryResultStreamRequest_Stats{` +
+		`Stats:` + fmt.Sprintf("%#v", this.Stats) + `}`}, ", ")
+	return s
+}
 func (this *QueryResultResponse) GoString() string {
 	if this == nil {
 		return "nil"
@@ -258,6 +513,10 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type FrontendForQuerierClient interface {
 	QueryResult(ctx context.Context, in *QueryResultRequest, opts ...grpc.CallOption) (*QueryResultResponse, error)
+	// QueryResultStream reports back the result of the query, streaming the body of the response as it is produced.
+	// The first message has the status code and headers of the response, the following ones chunks of its body,
+	// and the last one may have the stats of the query.
+	QueryResultStream(ctx context.Context, opts ...grpc.CallOption) (FrontendForQuerier_QueryResultStreamClient, error)
 }
 
 type frontendForQuerierClient struct {
@@ -277,9 +536,47 @@ func (c *frontendForQuerierClient) QueryResult(ctx context.Context, in *QueryRes
 	return out, nil
 }
 
+func (c *frontendForQuerierClient) QueryResultStream(ctx context.Context, opts ...grpc.CallOption) (FrontendForQuerier_QueryResultStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_FrontendForQuerier_serviceDesc.Streams[0], "/frontendv2pb.FrontendForQuerier/QueryResultStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &frontendForQuerierQueryResultStreamClient{stream}
+	return x, nil
+}
+
+type FrontendForQuerier_QueryResultStreamClient interface {
+	Send(*QueryResultStreamRequest) error
+	CloseAndRecv() (*QueryResultResponse, error)
+	grpc.ClientStream
+}
+
+type frontendForQuerierQueryResultStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *frontendForQuerierQueryResultStreamClient) Send(m *QueryResultStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *frontendForQuerierQueryResultStreamClient) CloseAndRecv() (*QueryResultResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(QueryResultResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // FrontendForQuerierServer is the server API for FrontendForQuerier service.
 type FrontendForQuerierServer interface {
 	QueryResult(context.Context, *QueryResultRequest) (*QueryResultResponse, error)
+	// QueryResultStream reports back the result of the query, streaming the body of the response as it is produced.
+	// The first message has the status code and headers of the response, the following ones chunks of its body,
+	// and the last one may have the stats of the query.
+	QueryResultStream(FrontendForQuerier_QueryResultStreamServer) error
 }
 
 // UnimplementedFrontendForQuerierServer can be embedded to have forward compatible implementations.
@@ -289,6 +586,9 @@ type UnimplementedFrontendForQuerierServer struct {
 func (*UnimplementedFrontendForQuerierServer) QueryResult(ctx context.Context, req *QueryResultRequest) (*QueryResultResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryResult not implemented")
 }
+func (*UnimplementedFrontendForQuerierServer) QueryResultStream(srv FrontendForQuerier_QueryResultStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryResultStream not implemented")
+}
 
 func RegisterFrontendForQuerierServer(s *grpc.Server, srv FrontendForQuerierServer) {
 	s.RegisterService(&_FrontendForQuerier_serviceDesc, srv)
@@ -312,6 +612,32 @@ func _FrontendForQuerier_QueryResult_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FrontendForQuerier_QueryResultStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FrontendForQuerierServer).QueryResultStream(&frontendForQuerierQueryResultStreamServer{stream})
+}
+
+type FrontendForQuerier_QueryResultStreamServer interface {
+	SendAndClose(*QueryResultResponse) error
+	Recv() (*QueryResultStreamRequest, error)
+	grpc.ServerStream
+}
+
+type frontendForQuerierQueryResultStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *frontendForQuerierQueryResultStreamServer) SendAndClose(m *QueryResultResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *frontendForQuerierQueryResultStreamServer) Recv() (*QueryResultStreamRequest, error) {
+	m := new(QueryResultStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _FrontendForQuerier_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "frontendv2pb.FrontendForQuerier",
 	HandlerType: (*FrontendForQuerierServer)(nil),
@@ -321,7 +647,13 @@ var _FrontendForQuerier_serviceDesc = grpc.ServiceDesc{
 			Handler:    _FrontendForQuerier_QueryResult_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryResultStream",
+			Handler:       _FrontendForQuerier_QueryResultStream_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "pkg/lokifrontend/frontend/v2/frontendv2pb/frontend.proto",
 }
 
@@ -377,6 +709,101 @@ func (m *QueryResultRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QueryResultStreamRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryResultStreamRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResultStreamRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Data != nil {
+		{
+			size := m.Data.Size()
+			i -= size
+			if _, err := m.Data.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.QueryID != 0 {
+		i = encodeVarintFrontend(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QueryResultStreamRequest_Metadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResultStreamRequest_Metadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Metadata != nil {
+		{
+			size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintFrontend(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func (m *QueryResultStreamRequest_Body) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResultStreamRequest_Body) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Body != nil {
+		i -= len(m.Body)
+		copy(dAtA[i:], m.Body)
+		i = encodeVarintFrontend(dAtA, i, uint64(len(m.Body)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *QueryResultStreamRequest_Stats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryResultStreamRequest_Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Stats != nil {
+		{
+			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintFrontend(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
 func (m *QueryResultResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -431,6 +858,57 @@ func (m *QueryResultRequest) Size() (n int) {
 	return n
 }
 
+func (m *QueryResultStreamRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.QueryID != 0 {
+		n += 1 + sovFrontend(uint64(m.QueryID))
+	}
+	if m.Data != nil {
+		n += m.Data.Size()
+	}
+	return n
+}
+
+func (m *QueryResultStreamRequest_Metadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Metadata != nil {
+		l = m.Metadata.Size()
+		n += 1 + l + sovFrontend(uint64(l))
+	}
+	return n
+}
+func (m *QueryResultStreamRequest_Body) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Body != nil {
+		l = len(m.Body)
+		n += 1 + l + sovFrontend(uint64(l))
+	}
+	return n
+}
+func (m *QueryResultStreamRequest_Stats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovFrontend(uint64(l))
+	}
+	return n
+}
 func (m *QueryResultResponse) Size() (n int) {
 	if m == nil {
 		return 0
@@ -458,6 +936,47 @@ func (this *QueryResultRequest) String() string {
 	}, "")
 	return s
 }
+func (this *QueryResultStreamRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryResultStreamRequest{`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
+		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryResultStreamRequest_Metadata) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryResultStreamRequest_Metadata{`,
+		`Metadata:` + strings.Replace(fmt.Sprintf("%v", this.Metadata), "HTTPResponse", "httpgrpc.HTTPResponse", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryResultStreamRequest_Body) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryResultStreamRequest_Body{`,
+		`Body:` + fmt.Sprintf("%v", this.Body) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryResultStreamRequest_Stats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryResultStreamRequest_Stats{`,
+		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "stats.Stats", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *QueryResultResponse) String() string {
 	if this == nil {
 		return "nil"
@@ -619,6 +1138,181 @@ func (m *QueryResultRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *QueryResultStreamRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFrontend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryResultStreamRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryResultStreamRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &httpgrpc.HTTPResponse{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Data = &QueryResultStreamRequest_Metadata{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Body", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := make([]byte, postIndex-iNdEx)
+			copy(v, dAtA[iNdEx:postIndex])
+			m.Data = &QueryResultStreamRequest_Body{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &stats.Stats{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Data = &QueryResultStreamRequest_Stats{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFrontend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthFrontend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *QueryResultResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
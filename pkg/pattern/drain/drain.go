@@ -0,0 +1,356 @@
+// Package drain clusters log lines into templates using the Drain algorithm.
+//
+// See "Drain: An Online Log Parsing Approach with Fixed Depth Tree" by Pinjia He et al.
+// https://jiemingzhu.github.io/pub/pjhe_icws2017.pdf
+//
+// Lines are split into space separated tokens and walked down a fixed depth prefix
+// tree keyed by the token count and the first tokens of the line. Each leaf holds the
+// clusters whose template is compared token by token with the line, the most similar
+// cluster above the similarity threshold absorbs the line and the tokens differing
+// from its template are replaced by a wildcard.
+//
+// Templates use the unnamed capture `<_>` of the `pattern` parser as wildcard, so
+// a template is a valid `pattern` expression once one of its wildcards is named.
+package drain
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prometheus/common/model"
+)
+
+// Wildcard is the token replacing the variable parts of a template.
+const Wildcard = "<_>"
+
+type Config struct {
+	// LogClusterDepth is the depth of the prefix tree, including the root and the token count layer.
+	LogClusterDepth int
+	// SimTh is the minimum ratio of equal tokens for a line to join a cluster.
+	SimTh float64
+	// MaxChildren is the maximum number of children of an inner node of the prefix tree.
+	MaxChildren int
+	// MaxClusters is the maximum number of clusters tracked, the least recently used cluster
+	// is evicted when it is exceeded.
+	MaxClusters int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		LogClusterDepth: 4,
+		SimTh:           0.4,
+		MaxChildren:     100,
+		MaxClusters:     300,
+	}
+}
+
+// Sample is the number of lines matching a cluster within a step.
+type Sample struct {
+	Timestamp model.Time
+	Value     int64
+}
+
+// LogCluster is a template and the number of lines it matched over time.
+type LogCluster struct {
+	id       int
+	lastUsed int64
+
+	Tokens  []string
+	Size    int64
+	Samples []Sample
+}
+
+// String returns the template of the cluster.
+func (c *LogCluster) String() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+func (c *LogCluster) append(ts model.Time) {
+	c.Size++
+	n := len(c.Samples)
+	switch {
+	case n == 0 || c.Samples[n-1].Timestamp < ts:
+		c.Samples = append(c.Samples, Sample{Timestamp: ts, Value: 1})
+	case c.Samples[n-1].Timestamp == ts:
+		c.Samples[n-1].Value++
+	default:
+		// Lines are usually trained in order, fall back to an insertion otherwise.
+		i := sort.Search(n, func(i int) bool { return c.Samples[i].Timestamp >= ts })
+		if c.Samples[i].Timestamp == ts {
+			c.Samples[i].Value++
+			return
+		}
+		c.Samples = append(c.Samples, Sample{})
+		copy(c.Samples[i+1:], c.Samples[i:])
+		c.Samples[i] = Sample{Timestamp: ts, Value: 1}
+	}
+}
+
+type node struct {
+	keyToChild map[string]*node
+	clusterIDs []int
+}
+
+func newNode() *node {
+	return &node{keyToChild: map[string]*node{}}
+}
+
+// Drain trains clusters from log lines. It is not safe for concurrent use.
+type Drain struct {
+	cfg      Config
+	step     int64
+	root     *node
+	clusters map[int]*LogCluster
+	lastID   int
+	trained  int64
+}
+
+// New returns a Drain counting the lines of each cluster by steps of the given duration.
+func New(cfg Config, step time.Duration) *Drain {
+	if cfg.LogClusterDepth < 3 {
+		cfg.LogClusterDepth = 3
+	}
+	s := step.Milliseconds()
+	if s <= 0 {
+		s = 1
+	}
+	return &Drain{
+		cfg:      cfg,
+		step:     s,
+		root:     newNode(),
+		clusters: map[int]*LogCluster{},
+	}
+}
+
+// Train adds the line at the given time in unix nanoseconds to its most similar cluster,
+// or to a new cluster if none is similar enough, and returns that cluster.
+func (d *Drain) Train(line string, ts int64) *LogCluster {
+	tokens := tokenize(line)
+	d.trained++
+
+	cluster := d.treeSearch(tokens, false)
+	if cluster == nil {
+		d.lastID++
+		cluster = &LogCluster{id: d.lastID, lastUsed: d.trained, Tokens: tokens}
+		d.clusters[cluster.id] = cluster
+		d.addSeqToPrefixTree(cluster)
+		d.evict()
+	} else {
+		cluster.Tokens = createTemplate(tokens, cluster.Tokens)
+		cluster.lastUsed = d.trained
+	}
+
+	ms := ts / int64(time.Millisecond)
+	cluster.append(model.Time(ms - ms%d.step))
+	return cluster
+}
+
+// Match returns the cluster matching the line without training it, or nil.
+func (d *Drain) Match(line string) *LogCluster {
+	return d.treeSearch(tokenize(line), true)
+}
+
+// Clusters returns all clusters, ordered by decreasing size.
+func (d *Drain) Clusters() []*LogCluster {
+	res := make([]*LogCluster, 0, len(d.clusters))
+	for _, c := range d.clusters {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Size == res[j].Size {
+			return res[i].id < res[j].id
+		}
+		return res[i].Size > res[j].Size
+	})
+	return res
+}
+
+// evict drops the least recently used cluster when there are too many of them.
+// Its id is removed lazily from the prefix tree.
+func (d *Drain) evict() {
+	if d.cfg.MaxClusters <= 0 || len(d.clusters) <= d.cfg.MaxClusters {
+		return
+	}
+	var oldest *LogCluster
+	for _, c := range d.clusters {
+		if oldest == nil || c.lastUsed < oldest.lastUsed {
+			oldest = c
+		}
+	}
+	delete(d.clusters, oldest.id)
+}
+
+func (d *Drain) maxNodeDepth() int {
+	return d.cfg.LogClusterDepth - 2
+}
+
+func (d *Drain) treeSearch(tokens []string, includeParams bool) *LogCluster {
+	curr, ok := d.root.keyToChild[strconv.Itoa(len(tokens))]
+	if !ok {
+		return nil
+	}
+	if len(tokens) == 0 {
+		for _, id := range curr.clusterIDs {
+			if c, ok := d.clusters[id]; ok {
+				return c
+			}
+		}
+		return nil
+	}
+
+	depth := 1
+	for _, token := range tokens {
+		if depth >= d.maxNodeDepth() || depth == len(tokens) {
+			break
+		}
+		next, ok := curr.keyToChild[token]
+		if !ok {
+			next, ok = curr.keyToChild[Wildcard]
+		}
+		if !ok {
+			return nil
+		}
+		curr = next
+		depth++
+	}
+	return d.fastMatch(curr.clusterIDs, tokens, includeParams)
+}
+
+func (d *Drain) fastMatch(ids []int, tokens []string, includeParams bool) *LogCluster {
+	var (
+		best          *LogCluster
+		maxSim        = -1.0
+		maxParamCount = -1
+	)
+	for _, id := range ids {
+		c, ok := d.clusters[id]
+		if !ok {
+			continue
+		}
+		sim, paramCount := seqDistance(c.Tokens, tokens, includeParams)
+		if sim > maxSim || (sim == maxSim && paramCount > maxParamCount) {
+			best, maxSim, maxParamCount = c, sim, paramCount
+		}
+	}
+	if maxSim >= d.cfg.SimTh {
+		return best
+	}
+	return nil
+}
+
+func (d *Drain) addSeqToPrefixTree(cluster *LogCluster) {
+	key := strconv.Itoa(len(cluster.Tokens))
+	curr, ok := d.root.keyToChild[key]
+	if !ok {
+		curr = newNode()
+		d.root.keyToChild[key] = curr
+	}
+	if len(cluster.Tokens) == 0 {
+		curr.clusterIDs = d.appendClusterID(curr.clusterIDs, cluster.id)
+		return
+	}
+
+	depth := 1
+	for _, token := range cluster.Tokens {
+		if depth >= d.maxNodeDepth() || depth >= len(cluster.Tokens) {
+			curr.clusterIDs = d.appendClusterID(curr.clusterIDs, cluster.id)
+			return
+		}
+
+		next, ok := curr.keyToChild[token]
+		if !ok {
+			next = d.addChild(curr, token)
+		}
+		curr = next
+		depth++
+	}
+	curr.clusterIDs = d.appendClusterID(curr.clusterIDs, cluster.id)
+}
+
+// addChild adds a child for the token to the node, or returns the wildcard child
+// when the token looks variable or the node has too many children already.
+func (d *Drain) addChild(n *node, token string) *node {
+	wildcard, hasWildcard := n.keyToChild[Wildcard]
+	if hasNumbers(token) {
+		if !hasWildcard {
+			wildcard = newNode()
+			n.keyToChild[Wildcard] = wildcard
+		}
+		return wildcard
+	}
+
+	switch {
+	case hasWildcard && len(n.keyToChild) < d.cfg.MaxChildren:
+	case hasWildcard:
+		return wildcard
+	case len(n.keyToChild)+1 < d.cfg.MaxChildren:
+	default:
+		wildcard = newNode()
+		n.keyToChild[Wildcard] = wildcard
+		return wildcard
+	}
+	child := newNode()
+	n.keyToChild[token] = child
+	return child
+}
+
+// appendClusterID appends the id to the ids, dropping the ids of evicted clusters.
+func (d *Drain) appendClusterID(ids []int, id int) []int {
+	res := ids[:0]
+	for _, existing := range ids {
+		if _, ok := d.clusters[existing]; ok {
+			res = append(res, existing)
+		}
+	}
+	return append(res, id)
+}
+
+// seqDistance returns the ratio of tokens of the template equal to the line,
+// and the number of wildcards in the template.
+func seqDistance(template, tokens []string, includeParams bool) (float64, int) {
+	if len(template) == 0 {
+		return 1, 0
+	}
+	var simTokens, paramCount int
+	for i, t := range template {
+		if t == Wildcard {
+			paramCount++
+			continue
+		}
+		if t == tokens[i] {
+			simTokens++
+		}
+	}
+	if includeParams {
+		simTokens += paramCount
+	}
+	return float64(simTokens) / float64(len(template)), paramCount
+}
+
+func createTemplate(tokens, template []string) []string {
+	for i := range template {
+		if template[i] != tokens[i] {
+			template[i] = Wildcard
+		}
+	}
+	return template
+}
+
+func tokenize(line string) []string {
+	if line == "" {
+		return nil
+	}
+	return strings.Split(line, " ")
+}
+
+func hasNumbers(s string) bool {
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
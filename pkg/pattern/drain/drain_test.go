@@ -0,0 +1,129 @@
+package drain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logql/log/pattern"
+)
+
+func TestDrain_Train(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{
+			name: "variable tokens are masked",
+			lines: []string{
+				"connected to 10.0.0.1 in 3ms",
+				"connected to 10.0.0.2 in 12ms",
+				"connected to 10.0.0.3 in 3ms",
+			},
+			expected: []string{"connected to <_> in <_>"},
+		},
+		{
+			name: "different token counts are different clusters",
+			lines: []string{
+				"user alice logged in",
+				"user bob logged in",
+				"user alice logged out after 10s",
+				"user carol logged in",
+			},
+			expected: []string{
+				"user <_> logged in",
+				"user alice logged out after 10s",
+			},
+		},
+		{
+			name: "dissimilar lines are different clusters",
+			lines: []string{
+				"GET /api/users 200",
+				"GET /api/users 500",
+				"GET retrying request because of timeout",
+				"GET retrying request because of timeout",
+			},
+			expected: []string{
+				"GET /api/users <_>",
+				"GET retrying request because of timeout",
+			},
+		},
+		{
+			name:     "empty lines",
+			lines:    []string{"", ""},
+			expected: []string{""},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New(DefaultConfig(), time.Second)
+			for i, l := range tc.lines {
+				d.Train(l, int64(i)*int64(time.Second))
+			}
+			var actual []string
+			for _, c := range d.Clusters() {
+				actual = append(actual, c.String())
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDrain_Samples(t *testing.T) {
+	d := New(DefaultConfig(), 10*time.Second)
+	for _, ts := range []time.Duration{time.Second, 5 * time.Second, 12 * time.Second, 3 * time.Second, 31 * time.Second} {
+		d.Train("request served in 10ms", int64(ts))
+	}
+
+	clusters := d.Clusters()
+	require.Len(t, clusters, 1)
+	require.Equal(t, int64(5), clusters[0].Size)
+	require.Equal(t, []Sample{
+		{Timestamp: model.Time(0), Value: 3},
+		{Timestamp: model.Time(10000), Value: 1},
+		{Timestamp: model.Time(30000), Value: 1},
+	}, clusters[0].Samples)
+}
+
+func TestDrain_MaxClusters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxClusters = 2
+	d := New(cfg, time.Second)
+
+	d.Train("first line of logs", 0)
+	d.Train("second kind of line here", 0)
+	d.Train("first line of logs", 0)
+	d.Train("a third type", 0)
+
+	var actual []string
+	for _, c := range d.Clusters() {
+		actual = append(actual, c.String())
+	}
+	require.Equal(t, []string{"first line of logs", "a third type"}, actual)
+	require.Nil(t, d.Match("second kind of line here"))
+}
+
+// Templates are meant to be refined into `pattern` parser expressions by naming their wildcards.
+func TestDrain_TemplatesArePatterns(t *testing.T) {
+	lines := []string{
+		`level=info ts=2023-09-05T10:00:00Z caller=flush.go:42 msg="flushing stream" chunks=3`,
+		`level=info ts=2023-09-05T10:00:01Z caller=flush.go:42 msg="flushing stream" chunks=12`,
+		`level=info ts=2023-09-05T10:00:02Z caller=flush.go:42 msg="flushing stream" chunks=1`,
+	}
+	d := New(DefaultConfig(), time.Second)
+	for i, l := range lines {
+		d.Train(l, int64(i))
+	}
+	clusters := d.Clusters()
+	require.Len(t, clusters, 1)
+	require.Equal(t, `level=info <_> caller=flush.go:42 msg="flushing stream" <_>`, clusters[0].String())
+
+	m, err := pattern.New(strings.Replace(clusters[0].String(), Wildcard, "<ts>", 1))
+	require.NoError(t, err)
+	for _, l := range lines {
+		require.Equal(t, [][]byte{[]byte(strings.Fields(l)[1])}, m.Matches([]byte(l)))
+	}
+}
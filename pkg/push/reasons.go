@@ -0,0 +1,13 @@
+package push
+
+// Reasons of the rejections of the entries of the push requests shared by Loki and its clients, see RejectedEntry.
+// The entries rejected for these reasons can be pushed again later.
+const (
+	// ReasonRateLimited is the reason of the entries rejected for exceeding the ingestion rate limit of the tenant.
+	ReasonRateLimited = "rate_limited"
+	// ReasonStreamRateLimit is the reason of the entries rejected for exceeding the rate limit of their stream.
+	ReasonStreamRateLimit = "per_stream_rate_limit"
+	// ReasonStreamLimit is the reason of the entries rejected because the limit of active streams of the tenant is
+	// reached.
+	ReasonStreamLimit = "stream_limit"
+)
@@ -14,20 +14,20 @@ var (
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Hash:   1234*10 ^ 9,
 		Entries: []Entry{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, nil},
+			{now.Add(1 * time.Second), line, nil},
+			{now.Add(2 * time.Second), line, LabelsAdapter{{Name: "traceID", Value: "1234"}}},
+			{now.Add(3 * time.Second), line, LabelsAdapter{{Name: "user", Value: "abc"}, {Name: "traceID", Value: "5678"}}},
 		},
 	}
 	streamAdapter = StreamAdapter{
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Hash:   1234*10 ^ 9,
 		Entries: []EntryAdapter{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, nil},
+			{now.Add(1 * time.Second), line, nil},
+			{now.Add(2 * time.Second), line, []LabelPairAdapter{{Name: "traceID", Value: "1234"}}},
+			{now.Add(3 * time.Second), line, []LabelPairAdapter{{Name: "user", Value: "abc"}, {Name: "traceID", Value: "5678"}}},
 		},
 	}
 )
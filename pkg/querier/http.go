@@ -488,6 +488,26 @@ func (q *QuerierAPI) seriesVolumeHandler(ctx context.Context, r *http.Request, r
 	}
 }
 
+// PatternsHandler clusters the log lines selected by a query into patterns,
+// with the number of lines matching each pattern over time.
+func (q *QuerierAPI) PatternsHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParsePatternsQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	resp, err := q.querier.Patterns(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := queryrange.WriteResponse(r, nil, resp, w); err != nil {
+		serverutil.WriteError(err, w)
+	}
+}
+
 // parseRegexQuery parses regex and query querystring from httpRequest and returns the combined LogQL query.
 // This is used only to keep regexp query string support until it gets fully deprecated.
 func parseRegexQuery(httpRequest *http.Request) (string, error) {
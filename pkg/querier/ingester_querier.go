@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/loki/pkg/storage/stores/index/seriesvolume"
@@ -12,6 +13,7 @@ import (
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -27,6 +29,15 @@ import (
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	index_stats "github.com/grafana/loki/pkg/storage/stores/index/stats"
 	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const (
+	// shardSizesSyncPeriod is the period at which the shard sizes of the tenants are recorded.
+	shardSizesSyncPeriod = 10 * time.Second
+	// shardSizeChangeGracePeriod is added to the lookback period during which the ingesters of the previous shards
+	// of a tenant are queried, to cover the delays in the reloads of the overrides by the distributors and the queriers.
+	shardSizeChangeGracePeriod = time.Minute
 )
 
 type responseFromIngesters struct {
@@ -34,28 +45,52 @@ type responseFromIngesters struct {
 	response interface{}
 }
 
+// IngesterQuerierLimits are the limits of the tenants used by the IngesterQuerier.
+type IngesterQuerierLimits interface {
+	IngestionTenantShardSize(userID string) int
+	AllByUserID() map[string]*validation.Limits
+	DefaultLimits() *validation.Limits
+}
+
 // IngesterQuerier helps with querying the ingesters.
+// With shuffle sharding, its service records the shard sizes of the tenants.
 type IngesterQuerier struct {
+	services.Service
+
 	ring            ring.ReadRing
 	pool            *ring_client.Pool
 	extraQueryDelay time.Duration
+
+	limits IngesterQuerierLimits
+	// shuffleShardingLookback is the period the ingesters are queried for. With shuffle sharding, only the ingesters
+	// of the shards of the tenant during this period are queried. 0 to query all the ingesters.
+	shuffleShardingLookback time.Duration
+	shardSizes              *shardSizeHistory
 }
 
-func NewIngesterQuerier(clientCfg client.Config, ring ring.ReadRing, extraQueryDelay time.Duration) (*IngesterQuerier, error) {
+func NewIngesterQuerier(clientCfg client.Config, ring ring.ReadRing, extraQueryDelay time.Duration, limits IngesterQuerierLimits, shuffleShardingLookback time.Duration) (*IngesterQuerier, error) {
 	factory := func(addr string) (ring_client.PoolClient, error) {
 		return client.New(clientCfg, addr)
 	}
 
-	return newIngesterQuerier(clientCfg, ring, extraQueryDelay, factory)
+	return newIngesterQuerier(clientCfg, ring, extraQueryDelay, limits, shuffleShardingLookback, factory)
 }
 
 // newIngesterQuerier creates a new IngesterQuerier and allows to pass a custom ingester client factory
 // used for testing purposes
-func newIngesterQuerier(clientCfg client.Config, ring ring.ReadRing, extraQueryDelay time.Duration, clientFactory ring_client.PoolFactory) (*IngesterQuerier, error) {
+func newIngesterQuerier(clientCfg client.Config, ring ring.ReadRing, extraQueryDelay time.Duration, limits IngesterQuerierLimits, shuffleShardingLookback time.Duration, clientFactory ring_client.PoolFactory) (*IngesterQuerier, error) {
 	iq := IngesterQuerier{
-		ring:            ring,
-		pool:            clientpool.NewPool(clientCfg.PoolConfig, ring, clientFactory, util_log.Logger),
-		extraQueryDelay: extraQueryDelay,
+		ring:                    ring,
+		pool:                    clientpool.NewPool(clientCfg.PoolConfig, ring, clientFactory, util_log.Logger),
+		extraQueryDelay:         extraQueryDelay,
+		limits:                  limits,
+		shuffleShardingLookback: shuffleShardingLookback,
+		shardSizes:              newShardSizeHistory(),
+	}
+	if limits != nil && shuffleShardingLookback > 0 {
+		iq.Service = services.NewTimerService(shardSizesSyncPeriod, iq.syncShardSizes, iq.syncShardSizes, nil)
+	} else {
+		iq.Service = services.NewIdleService(nil, nil)
 	}
 
 	err := services.StartAndAwaitRunning(context.Background(), iq.pool)
@@ -66,10 +101,39 @@ func newIngesterQuerier(clientCfg client.Config, ring ring.ReadRing, extraQueryD
 	return &iq, nil
 }
 
-// forAllIngesters runs f, in parallel, for all ingesters
+// ingestersRing returns the ring of the ingesters which may have received the streams of the tenant of the request.
+// With shuffle sharding, these are the ingesters of the shards of the tenant during the lookback period.
+func (q *IngesterQuerier) ingestersRing(ctx context.Context) ring.ReadRing {
+	if q.limits == nil || q.shuffleShardingLookback <= 0 {
+		return q.ring
+	}
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return q.ring
+	}
+
+	now := time.Now()
+	shardSize := q.shardSizes.shardSize(tenantID, q.limits.IngestionTenantShardSize(tenantID), now, q.shuffleShardingLookback+shardSizeChangeGracePeriod)
+	if shardSize <= 0 {
+		return q.ring
+	}
+	return q.ring.ShuffleShardWithLookback(tenantID, shardSize, q.shuffleShardingLookback, now)
+}
+
+// syncShardSizes records the shard sizes of the tenants.
+func (q *IngesterQuerier) syncShardSizes(_ context.Context) error {
+	overrides := map[string]int{}
+	for tenantID, limits := range q.limits.AllByUserID() {
+		overrides[tenantID] = limits.IngestionTenantShardSize
+	}
+	q.shardSizes.record(time.Now(), q.limits.DefaultLimits().IngestionTenantShardSize, overrides, q.shuffleShardingLookback+shardSizeChangeGracePeriod)
+	return nil
+}
+
+// forAllIngesters runs f, in parallel, for all ingesters which may have received the streams of the tenant
 // TODO taken from Cortex, see if we can refactor out an usable interface.
 func (q *IngesterQuerier) forAllIngesters(ctx context.Context, f func(context.Context, logproto.QuerierClient) (interface{}, error)) ([]responseFromIngesters, error) {
-	replicationSet, err := q.ring.GetReplicationSetForOperation(ring.Read)
+	replicationSet, err := q.ingestersRing(ctx).GetReplicationSetForOperation(ring.Read)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +241,7 @@ func (q *IngesterQuerier) TailDisconnectedIngesters(ctx context.Context, req *lo
 	}
 
 	// Get the current replication set from the ring
-	replicationSet, err := q.ring.GetReplicationSetForOperation(ring.Read)
+	replicationSet, err := q.ingestersRing(ctx).GetReplicationSetForOperation(ring.Read)
 	if err != nil {
 		return nil, err
 	}
@@ -234,7 +298,7 @@ func (q *IngesterQuerier) Series(ctx context.Context, req *logproto.SeriesReques
 }
 
 func (q *IngesterQuerier) TailersCount(ctx context.Context) ([]uint32, error) {
-	replicationSet, err := q.ring.GetAllHealthy(ring.Read)
+	replicationSet, err := q.ingestersRing(ctx).GetAllHealthy(ring.Read)
 	if err != nil {
 		return nil, err
 	}
@@ -379,3 +443,125 @@ func isUnimplementedCallError(err error) bool {
 	}
 	return (s.Code() == codes.Unimplemented)
 }
+
+// shardSizeHistory records the shard sizes of the tenants, so that the ingesters of the previous shards of a tenant
+// are still queried during the lookback period after its shard size changes. As the shard sizes of the tenants before
+// the history was first recorded are unknown, all the ingesters are queried during the lookback period after that.
+type shardSizeHistory struct {
+	mtx      sync.Mutex
+	since    time.Time
+	defaults *tenantShardSizes
+	// tenants are the shard sizes of the tenants with overrides, or whose previous overrides are in the lookback period.
+	tenants map[string]*tenantShardSizes
+}
+
+type tenantShardSizes struct {
+	current  int
+	previous map[int]time.Time // shard size -> when it was replaced
+}
+
+func newShardSizeHistory() *shardSizeHistory {
+	return &shardSizeHistory{
+		defaults: &tenantShardSizes{previous: map[int]time.Time{}},
+		tenants:  map[string]*tenantShardSizes{},
+	}
+}
+
+// record records the default shard size and the shard sizes of the tenants with overrides,
+// and forgets the shard sizes replaced before the lookback period.
+func (h *shardSizeHistory) record(now time.Time, defaultSize int, overrides map[string]int, lookback time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	first := h.since.IsZero()
+	if first {
+		h.since = now
+		h.defaults.current = defaultSize
+	}
+	for tenantID, size := range overrides {
+		s, ok := h.tenants[tenantID]
+		if !ok {
+			s = &tenantShardSizes{current: size, previous: map[int]time.Time{}}
+			if !first {
+				// The tenant had the default shard size before its overrides.
+				s = h.defaults.clone()
+			}
+			h.tenants[tenantID] = s
+		}
+		s.set(size, now)
+		s.expire(now, lookback)
+	}
+	for tenantID, s := range h.tenants {
+		if _, ok := overrides[tenantID]; ok {
+			continue
+		}
+		s.set(defaultSize, now)
+		s.expire(now, lookback)
+		if len(s.previous) == 0 {
+			delete(h.tenants, tenantID)
+		}
+	}
+	h.defaults.set(defaultSize, now)
+	h.defaults.expire(now, lookback)
+}
+
+// shardSize returns the shard size to query for the tenant with the current shard size: 0 if the tenant was not
+// shuffle sharded at some point of the lookback period, or else its greatest shard size during this period.
+func (h *shardSizeHistory) shardSize(tenantID string, current int, now time.Time, lookback time.Duration) int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.since.IsZero() || now.Sub(h.since) < lookback {
+		return 0
+	}
+	s, ok := h.tenants[tenantID]
+	if !ok {
+		s = h.defaults
+	}
+	shardSize := maxShardSize(current, s.current)
+	for size, replacedAt := range s.previous {
+		if now.Sub(replacedAt) <= lookback {
+			shardSize = maxShardSize(shardSize, size)
+		}
+	}
+	return shardSize
+}
+
+func (s *tenantShardSizes) set(size int, now time.Time) {
+	if size < 0 {
+		size = 0
+	}
+	if size == s.current {
+		return
+	}
+	s.previous[s.current] = now
+	delete(s.previous, size)
+	s.current = size
+}
+
+func (s *tenantShardSizes) expire(now time.Time, lookback time.Duration) {
+	for size, replacedAt := range s.previous {
+		if now.Sub(replacedAt) > lookback {
+			delete(s.previous, size)
+		}
+	}
+}
+
+func (s *tenantShardSizes) clone() *tenantShardSizes {
+	c := &tenantShardSizes{current: s.current, previous: make(map[int]time.Time, len(s.previous))}
+	for size, replacedAt := range s.previous {
+		c.previous[size] = replacedAt
+	}
+	return c
+}
+
+// maxShardSize returns the greatest shard size, 0 meaning all the ingesters.
+func maxShardSize(a, b int) int {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
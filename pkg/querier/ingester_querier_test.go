@@ -11,14 +11,17 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 func TestIngesterQuerier_earlyExitOnQuorum(t *testing.T) {
@@ -103,6 +106,8 @@ func TestIngesterQuerier_earlyExitOnQuorum(t *testing.T) {
 					mockIngesterClientConfig(),
 					newReadRingMock(ringIngesters, 1),
 					mockQuerierConfig().ExtraQueryDelay,
+					nil,
+					0,
 					newIngesterClientMockFactory(ingesterClient),
 				)
 				require.NoError(t, err)
@@ -202,6 +207,8 @@ func TestIngesterQuerier_earlyExitOnQuorum(t *testing.T) {
 					mockIngesterClientConfig(),
 					newReadRingMock(ringIngesters, 1),
 					mockQuerierConfig().ExtraQueryDelay,
+					nil,
+					0,
 					newIngesterClientMockFactory(ingesterClient),
 				)
 				require.NoError(t, err)
@@ -299,6 +306,8 @@ func TestQuerier_tailDisconnectedIngesters(t *testing.T) {
 				mockIngesterClientConfig(),
 				newReadRingMock(testData.ringIngesters, 0),
 				mockQuerierConfig().ExtraQueryDelay,
+				nil,
+				0,
 				newIngesterClientMockFactory(ingesterClient),
 			)
 			require.NoError(t, err)
@@ -361,6 +370,8 @@ func TestIngesterQuerier_SeriesVolume(t *testing.T) {
 			mockIngesterClientConfig(),
 			newReadRingMock([]ring.InstanceDesc{mockInstanceDesc("1.1.1.1", ring.ACTIVE), mockInstanceDesc("3.3.3.3", ring.ACTIVE)}, 0),
 			mockQuerierConfig().ExtraQueryDelay,
+			nil,
+			0,
 			newIngesterClientMockFactory(ingesterClient),
 		)
 		require.NoError(t, err)
@@ -381,6 +392,8 @@ func TestIngesterQuerier_SeriesVolume(t *testing.T) {
 			mockIngesterClientConfig(),
 			newReadRingMock([]ring.InstanceDesc{mockInstanceDesc("1.1.1.1", ring.ACTIVE), mockInstanceDesc("3.3.3.3", ring.ACTIVE)}, 0),
 			mockQuerierConfig().ExtraQueryDelay,
+			nil,
+			0,
 			newIngesterClientMockFactory(ingesterClient),
 		)
 		require.NoError(t, err)
@@ -391,3 +404,97 @@ func TestIngesterQuerier_SeriesVolume(t *testing.T) {
 		require.Equal(t, []logproto.Volume(nil), volumes.Volumes)
 	})
 }
+
+type shardSizeLimits struct {
+	shardSize int
+}
+
+func (l *shardSizeLimits) IngestionTenantShardSize(_ string) int {
+	return l.shardSize
+}
+
+func (l *shardSizeLimits) AllByUserID() map[string]*validation.Limits {
+	return map[string]*validation.Limits{"test": {IngestionTenantShardSize: l.shardSize}}
+}
+
+func (l *shardSizeLimits) DefaultLimits() *validation.Limits {
+	return &validation.Limits{}
+}
+
+func TestIngesterQuerier_ShuffleSharding(t *testing.T) {
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("GetSeriesVolume", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.VolumeResponse{
+		Volumes: []logproto.Volume{{Name: `{foo="bar"}`, Volume: 1}},
+		Limit:   10,
+	}, nil)
+
+	limits := &shardSizeLimits{shardSize: 2}
+	ingesterQuerier, err := newIngesterQuerier(
+		mockIngesterClientConfig(),
+		newReadRingMock([]ring.InstanceDesc{mockInstanceDesc("1.1.1.1", ring.ACTIVE), mockInstanceDesc("2.2.2.2", ring.ACTIVE), mockInstanceDesc("3.3.3.3", ring.ACTIVE)}, 0),
+		mockQuerierConfig().ExtraQueryDelay,
+		limits,
+		time.Hour,
+		newIngesterClientMockFactory(ingesterClient),
+	)
+	require.NoError(t, err)
+
+	ingestersQueried := func() uint64 {
+		volumes, err := ingesterQuerier.SeriesVolume(user.InjectOrgID(context.Background(), "test"), "", 0, 1, 10)
+		require.NoError(t, err)
+		return volumes.Volumes[0].Volume
+	}
+
+	// All the ingesters are queried until the shard sizes were recorded during the lookback period.
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ingesterQuerier))
+	defer services.StopAndAwaitTerminated(context.Background(), ingesterQuerier) //nolint:errcheck
+	require.Equal(t, uint64(3), ingestersQueried())
+
+	// Only the ingesters of the shard of the tenant are queried.
+	ingesterQuerier.shardSizes.mtx.Lock()
+	ingesterQuerier.shardSizes.since = time.Now().Add(-2 * time.Hour)
+	ingesterQuerier.shardSizes.mtx.Unlock()
+	require.Equal(t, uint64(2), ingestersQueried())
+
+	// The ingesters of the previous shard are still queried after the shard size decreases.
+	limits.shardSize = 1
+	require.NoError(t, ingesterQuerier.syncShardSizes(context.Background()))
+	require.Equal(t, uint64(2), ingestersQueried())
+
+	// All the ingesters are queried after shuffle sharding is disabled.
+	limits.shardSize = 0
+	require.Equal(t, uint64(3), ingestersQueried())
+}
+
+func TestShardSizeHistory(t *testing.T) {
+	now := time.Now()
+	h := newShardSizeHistory()
+
+	// The shard sizes are unknown during the lookback period after the history starts.
+	h.record(now, 0, map[string]int{"tenant": 3}, time.Hour)
+	require.Equal(t, 0, h.shardSize("tenant", 3, now, time.Hour))
+	require.Equal(t, 3, h.shardSize("tenant", 3, now.Add(time.Hour), time.Hour))
+
+	// The shard size can be increased at any time.
+	require.Equal(t, 6, h.shardSize("tenant", 6, now.Add(time.Hour), time.Hour))
+	h.record(now.Add(time.Hour), 0, map[string]int{"tenant": 6}, time.Hour)
+
+	// The previous greatest shard size is kept during the lookback period after it was replaced.
+	h.record(now.Add(2*time.Hour), 0, map[string]int{"tenant": 3}, time.Hour)
+	require.Equal(t, 6, h.shardSize("tenant", 3, now.Add(2*time.Hour+30*time.Minute), time.Hour))
+	require.Equal(t, 3, h.shardSize("tenant", 3, now.Add(3*time.Hour+time.Minute), time.Hour))
+
+	// The tenant was not shuffle sharded during the lookback period.
+	h.record(now.Add(4*time.Hour), 0, map[string]int{}, time.Hour)
+	require.Equal(t, 0, h.shardSize("tenant", 0, now.Add(4*time.Hour), time.Hour))
+	h.record(now.Add(5*time.Hour), 0, map[string]int{"tenant": 3}, time.Hour)
+	require.Equal(t, 0, h.shardSize("tenant", 3, now.Add(5*time.Hour+30*time.Minute), time.Hour))
+	h.record(now.Add(6*time.Hour), 0, map[string]int{"tenant": 3}, time.Hour)
+	require.Equal(t, 3, h.shardSize("tenant", 3, now.Add(6*time.Hour+time.Minute), time.Hour))
+
+	// The tenants without overrides have the default shard size.
+	require.Equal(t, 0, h.shardSize("other", 0, now.Add(6*time.Hour), time.Hour))
+	h.record(now.Add(7*time.Hour), 2, map[string]int{"tenant": 3}, time.Hour)
+	require.Equal(t, 0, h.shardSize("other", 2, now.Add(7*time.Hour+30*time.Minute), time.Hour))
+	require.Equal(t, 2, h.shardSize("other", 2, now.Add(8*time.Hour+time.Minute), time.Hour))
+}
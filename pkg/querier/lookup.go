@@ -0,0 +1,25 @@
+package querier
+
+import (
+	"context"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// lookupTablesForUser loads the lookup tables of the tenant used by the lookup stages of the expression, and returns
+// their references sent along with the requests to the ingesters, and the tables used by the store.
+func (q *SingleTenantQuerier) lookupTablesForUser(ctx context.Context, expr syntax.Expr) ([]*logproto.LookupTable, map[string]*log.LookupTable, error) {
+	if len(syntax.LookupTableNames(expr)) == 0 {
+		return nil, nil, nil
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q.lookupTables.Load(ctx, userID, expr)
+}
@@ -0,0 +1,87 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestQuerier_SelectLogWithLookupTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\n"), 0o644))
+
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(mockStreamIterator(1, 2), nil)
+
+	queryClient := newQueryClientMock()
+	queryClient.On("Recv").Return(mockQueryResponse([]logproto.Stream{mockStream(1, 2)}), nil)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(queryClient, nil)
+
+	limitsCfg := defaultLimitsTestConfig()
+	limitsCfg.LookupTables = map[string]string{"owners": path}
+	limits, err := validation.NewOverrides(limitsCfg, nil)
+	require.NoError(t, err)
+
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{}, store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	request := logproto.QueryRequest{
+		Selector:  `{type="test"} | logfmt | lookup "owners" on service`,
+		Limit:     10,
+		Start:     time.Unix(0, 300000000),
+		End:       time.Unix(0, 600000000),
+		Direction: logproto.FORWARD,
+	}
+
+	_, err = q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.NoError(t, err)
+
+	// the requests only reference the version of the table, loaded by the ingesters from their configuration.
+	expr, err := syntax.ParseExpr(request.Selector)
+	require.NoError(t, err)
+	tables, resolved, err := q.lookupTables.Load(ctx, "test", expr)
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	require.Equal(t, "owners", tables[0].Name)
+	require.NotEmpty(t, tables[0].Version)
+
+	expectedRequest := &logproto.QueryRequest{
+		Selector:     request.Selector,
+		Limit:        request.Limit,
+		Start:        request.Start,
+		End:          request.End,
+		Direction:    request.Direction,
+		LookupTables: tables,
+	}
+
+	// the store uses the tables loaded by the querier.
+	require.Contains(t, store.Calls[0].Arguments, logql.SelectLogParams{QueryRequest: expectedRequest, ResolvedLookupTables: resolved})
+	require.Contains(t, ingesterClient.Calls[0].Arguments, expectedRequest)
+
+	request.Selector = `{type="test"} | logfmt | lookup "unknown" on service`
+	request.LookupTables = nil
+	_, err = q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "lookup table unknown is not configured"), err)
+}
@@ -209,6 +209,30 @@ func (q *MultiTenantQuerier) SeriesVolume(ctx context.Context, req *logproto.Vol
 	return merged, nil
 }
 
+func (q *MultiTenantQuerier) Patterns(ctx context.Context, req *loghttp.PatternsQuery) (*loghttp.PatternsResponse, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenantIDs) == 1 {
+		return q.Querier.Patterns(ctx, req)
+	}
+
+	responses := make([]*loghttp.PatternsResponse, len(tenantIDs))
+	for i, id := range tenantIDs {
+		singleContext := user.InjectOrgID(ctx, id)
+		resp, err := q.Querier.Patterns(singleContext, req)
+		if err != nil {
+			return nil, err
+		}
+
+		responses[i] = resp
+	}
+
+	return mergePatterns(responses...), nil
+}
+
 // removeTenantSelector filters the given tenant IDs based on any tenant ID filter the in passed selector.
 func removeTenantSelector(params logql.SelectSampleParams, tenantIDs []string) (map[string]struct{}, syntax.Expr, error) {
 	expr, err := params.Expr()
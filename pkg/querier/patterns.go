@@ -0,0 +1,121 @@
+package querier
+
+import (
+	"context"
+	"sort"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/pattern/drain"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// Patterns clusters the log lines selected by the query into patterns.
+// Lines are read both from the ingesters and the store, as for any log query,
+// up to the configured maximum number of lines.
+func (q *SingleTenantQuerier) Patterns(ctx context.Context, req *loghttp.PatternsQuery) (*loghttp.PatternsResponse, error) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "Querier.Patterns")
+	defer sp.Finish()
+
+	expr, err := syntax.ParseLogSelector(req.Query, true)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{
+		QueryRequest: &logproto.QueryRequest{
+			Selector:  expr.String(),
+			Start:     req.Start,
+			End:       req.End,
+			Limit:     uint32(q.cfg.PatternsMaxLines),
+			Direction: logproto.FORWARD,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer util.LogErrorWithContext(ctx, "closing iterator", it.Close)
+
+	d := drain.New(drain.DefaultConfig(), req.Step)
+	var lines int
+	for it.Next() {
+		if q.cfg.PatternsMaxLines > 0 && lines >= q.cfg.PatternsMaxLines {
+			break
+		}
+		entry := it.Entry()
+		d.Train(entry.Line, entry.Timestamp.UnixNano())
+		lines++
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	sp.LogKV("lines", lines)
+
+	clusters := d.Clusters()
+	resp := &loghttp.PatternsResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   make([]loghttp.PatternSeries, 0, len(clusters)),
+	}
+	for _, c := range clusters {
+		series := loghttp.PatternSeries{
+			Pattern: c.String(),
+			Samples: make([]loghttp.PatternSample, 0, len(c.Samples)),
+		}
+		for _, s := range c.Samples {
+			series.Samples = append(series.Samples, loghttp.PatternSample{Timestamp: s.Timestamp, Value: s.Value})
+		}
+		resp.Data = append(resp.Data, series)
+	}
+	return resp, nil
+}
+
+// mergePatterns sums the samples of identical patterns and orders the patterns by decreasing count.
+func mergePatterns(responses ...*loghttp.PatternsResponse) *loghttp.PatternsResponse {
+	var (
+		byPattern = map[string]map[int64]int64{}
+		totals    = map[string]int64{}
+	)
+	for _, resp := range responses {
+		for _, series := range resp.Data {
+			samples, ok := byPattern[series.Pattern]
+			if !ok {
+				samples = map[int64]int64{}
+				byPattern[series.Pattern] = samples
+			}
+			for _, s := range series.Samples {
+				samples[int64(s.Timestamp)] += s.Value
+				totals[series.Pattern] += s.Value
+			}
+		}
+	}
+
+	merged := &loghttp.PatternsResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   make([]loghttp.PatternSeries, 0, len(byPattern)),
+	}
+	for pattern, samples := range byPattern {
+		series := loghttp.PatternSeries{
+			Pattern: pattern,
+			Samples: make([]loghttp.PatternSample, 0, len(samples)),
+		}
+		for ts, v := range samples {
+			series.Samples = append(series.Samples, loghttp.PatternSample{Timestamp: model.Time(ts), Value: v})
+		}
+		sort.Slice(series.Samples, func(i, j int) bool { return series.Samples[i].Timestamp < series.Samples[j].Timestamp })
+		merged.Data = append(merged.Data, series)
+	}
+	sort.Slice(merged.Data, func(i, j int) bool {
+		ti, tj := totals[merged.Data[i].Pattern], totals[merged.Data[j].Pattern]
+		if ti == tj {
+			return merged.Data[i].Pattern < merged.Data[j].Pattern
+		}
+		return ti > tj
+	})
+	return merged
+}
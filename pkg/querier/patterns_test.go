@@ -0,0 +1,105 @@
+package querier
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestQuerier_Patterns(t *testing.T) {
+	start := time.Unix(0, 0)
+	stream := func(lines ...string) logproto.Stream {
+		s := logproto.Stream{Labels: `{app="foo"}`}
+		for i, l := range lines {
+			s.Entries = append(s.Entries, logproto.Entry{Timestamp: start.Add(time.Duration(i) * 20 * time.Second), Line: l})
+		}
+		return s
+	}
+
+	queryClient := newQueryClientMock()
+	queryClient.On("Recv").Return(mockQueryResponse([]logproto.Stream{stream(
+		"GET /users took 10ms",
+		"GET /users took 15ms",
+		"failed to connect to db",
+	)}), nil).Once()
+	queryClient.On("Recv").Return(nil, io.EOF)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(queryClient, nil)
+
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(iter.NewStreamIterator(stream(
+		"GET /orders took 3ms",
+	)), nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{},
+		store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	resp, err := q.Patterns(ctx, &loghttp.PatternsQuery{
+		Query: `{app="foo"}`,
+		Start: start,
+		End:   start.Add(time.Minute),
+		Step:  30 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &loghttp.PatternsResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: []loghttp.PatternSeries{
+			{
+				Pattern: "GET <_> took <_>",
+				Samples: []loghttp.PatternSample{
+					{Timestamp: model.Time(0), Value: 3},
+				},
+			},
+			{
+				Pattern: "failed to connect to db",
+				Samples: []loghttp.PatternSample{
+					{Timestamp: model.Time(30000), Value: 1},
+				},
+			},
+		},
+	}, resp)
+
+	query := ingesterClient.GetMockedCallsByMethod("Query")[0].Arguments.Get(1).(*logproto.QueryRequest)
+	require.Equal(t, logproto.FORWARD, query.Direction)
+}
+
+func TestMergePatterns(t *testing.T) {
+	merged := mergePatterns(
+		&loghttp.PatternsResponse{Data: []loghttp.PatternSeries{
+			{Pattern: "foo <_>", Samples: []loghttp.PatternSample{{Timestamp: 10, Value: 1}, {Timestamp: 20, Value: 1}}},
+			{Pattern: "bar <_>", Samples: []loghttp.PatternSample{{Timestamp: 10, Value: 2}}},
+		}},
+		&loghttp.PatternsResponse{Data: []loghttp.PatternSeries{
+			{Pattern: "bar <_>", Samples: []loghttp.PatternSample{{Timestamp: 0, Value: 1}, {Timestamp: 10, Value: 2}}},
+		}},
+	)
+	require.Equal(t, &loghttp.PatternsResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: []loghttp.PatternSeries{
+			{Pattern: "bar <_>", Samples: []loghttp.PatternSample{{Timestamp: 0, Value: 1}, {Timestamp: 10, Value: 4}}},
+			{Pattern: "foo <_>", Samples: []loghttp.PatternSample{{Timestamp: 10, Value: 1}, {Timestamp: 20, Value: 1}}},
+		},
+	}, merged)
+}
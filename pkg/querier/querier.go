@@ -60,6 +60,7 @@ type Config struct {
 	MultiTenantQueriesEnabled     bool             `yaml:"multi_tenant_queries_enabled"`
 	QueryTimeout                  time.Duration    `yaml:"query_timeout" doc:"hidden"`
 	PerRequestLimitsEnabled       bool             `yaml:"per_request_limits_enabled"`
+	PatternsMaxLines              int              `yaml:"patterns_max_lines"`
 }
 
 // RegisterFlags register flags.
@@ -73,6 +74,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.QueryIngesterOnly, "querier.query-ingester-only", false, "When true, queriers only query the ingesters, and not stored data. This is useful when the object store is unavailable.")
 	f.BoolVar(&cfg.MultiTenantQueriesEnabled, "querier.multi-tenant-queries-enabled", false, "When true, allow queries to span multiple tenants.")
 	f.BoolVar(&cfg.PerRequestLimitsEnabled, "querier.per-request-limits-enabled", false, "When true, querier limits sent via a header are enforced.")
+	f.IntVar(&cfg.PatternsMaxLines, "querier.patterns-max-lines", 100000, "Maximum number of log lines clustered by a patterns query. 0 means no limit.")
 }
 
 // Validate validates the config.
@@ -91,6 +93,7 @@ type Querier interface {
 	Tail(ctx context.Context, req *logproto.TailRequest) (*Tailer, error)
 	IndexStats(ctx context.Context, req *loghttp.RangeQuery) (*stats.Stats, error)
 	SeriesVolume(ctx context.Context, req *logproto.VolumeRequest) (*logproto.VolumeResponse, error)
+	Patterns(ctx context.Context, req *loghttp.PatternsQuery) (*loghttp.PatternsResponse, error)
 }
 
 type Limits interface {
@@ -100,6 +103,7 @@ type Limits interface {
 	MaxStreamsMatchersPerQuery(context.Context, string) int
 	MaxConcurrentTailRequests(context.Context, string) int
 	MaxEntriesLimitPerQuery(context.Context, string) int
+	LookupTables(context.Context, string) map[string]string
 }
 
 // SingleTenantQuerier handles single tenant queries.
@@ -107,6 +111,7 @@ type SingleTenantQuerier struct {
 	cfg             Config
 	store           storage.Store
 	limits          Limits
+	lookupTables    *logql.LookupTables
 	ingesterQuerier *IngesterQuerier
 	deleteGetter    deleteGetter
 	metrics         *Metrics
@@ -117,12 +122,13 @@ type deleteGetter interface {
 }
 
 // New makes a new Querier.
-func New(cfg Config, store storage.Store, ingesterQuerier *IngesterQuerier, limits Limits, d deleteGetter, r prometheus.Registerer) (*SingleTenantQuerier, error) {
+func New(cfg Config, store storage.Store, ingesterQuerier *IngesterQuerier, limits Limits, lookupTables *logql.LookupTables, d deleteGetter, r prometheus.Registerer) (*SingleTenantQuerier, error) {
 	return &SingleTenantQuerier{
 		cfg:             cfg,
 		store:           store,
 		ingesterQuerier: ingesterQuerier,
 		limits:          limits,
+		lookupTables:    lookupTables,
 		deleteGetter:    d,
 		metrics:         NewMetrics(r),
 	}, nil
@@ -141,6 +147,48 @@ func (q *SingleTenantQuerier) SelectLogs(ctx context.Context, params logql.Selec
 		level.Error(spanlogger.FromContext(ctx)).Log("msg", "failed loading deletes for user", "err", err)
 	}
 
+	expr, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	params.QueryRequest.LookupTables, params.ResolvedLookupTables, err = q.lookupTablesForUser(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if selector, ok := logql.RawLinesSelector(expr); ok {
+		return q.selectRawLogs(ctx, params, selector)
+	}
+	return q.selectLogs(ctx, params)
+}
+
+// selectRawLogs selects the log lines of a log query with a multiline or json_array stage. The ingesters and the store
+// return all the log lines of the streams, which are grouped or split by the querier before the other stages of the
+// pipeline, so the groups are not split by the chunks of the streams. These log lines are read lazily until the limit
+// of the query is reached by the resulting log lines.
+func (q *SingleTenantQuerier) selectRawLogs(ctx context.Context, params logql.SelectLogParams, selector string) (iter.EntryIterator, error) {
+	// parsed again with the lookup tables of the request.
+	expr, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	req := *params.QueryRequest
+	req.Selector = selector
+	req.Limit = 0
+	it, err := q.selectLogs(ctx, logql.SelectLogParams{QueryRequest: &req, ResolvedLookupTables: params.ResolvedLookupTables})
+	if err != nil {
+		return nil, err
+	}
+	grouped, err := logql.NewRawLinesEntryIterator(it, expr, params.Direction)
+	if err != nil {
+		listutil.LogErrorWithContext(ctx, "closing iterator", it.Close)
+		return nil, err
+	}
+	return grouped, nil
+}
+
+func (q *SingleTenantQuerier) selectLogs(ctx context.Context, params logql.SelectLogParams) (iter.EntryIterator, error) {
 	ingesterQueryInterval, storeQueryInterval := q.buildQueryIntervals(params.Start, params.End)
 
 	iters := []iter.EntryIterator{}
@@ -149,7 +197,8 @@ func (q *SingleTenantQuerier) SelectLogs(ctx context.Context, params logql.Selec
 		// because the initial request is used below to query stores
 		queryRequestCopy := *params.QueryRequest
 		newParams := logql.SelectLogParams{
-			QueryRequest: &queryRequestCopy,
+			QueryRequest:         &queryRequestCopy,
+			ResolvedLookupTables: params.ResolvedLookupTables,
 		}
 		newParams.Start = ingesterQueryInterval.start
 		newParams.End = ingesterQueryInterval.end
@@ -195,6 +244,15 @@ func (q *SingleTenantQuerier) SelectSamples(ctx context.Context, params logql.Se
 		level.Error(spanlogger.FromContext(ctx)).Log("msg", "failed loading deletes for user", "err", err)
 	}
 
+	expr, err := params.Expr()
+	if err != nil {
+		return nil, err
+	}
+	params.SampleQueryRequest.LookupTables, params.ResolvedLookupTables, err = q.lookupTablesForUser(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
 	ingesterQueryInterval, storeQueryInterval := q.buildQueryIntervals(params.Start, params.End)
 
 	iters := []iter.SampleIterator{}
@@ -203,7 +261,8 @@ func (q *SingleTenantQuerier) SelectSamples(ctx context.Context, params logql.Se
 		// because the initial request is used below to query stores
 		queryRequestCopy := *params.SampleQueryRequest
 		newParams := logql.SelectSampleParams{
-			SampleQueryRequest: &queryRequestCopy,
+			SampleQueryRequest:   &queryRequestCopy,
+			ResolvedLookupTables: params.ResolvedLookupTables,
 		}
 		newParams.Start = ingesterQueryInterval.start
 		newParams.End = ingesterQueryInterval.end
@@ -439,8 +439,8 @@ func (r *readRingMock) HasInstance(instanceID string) bool {
 	return false
 }
 
-func (r *readRingMock) ShuffleShardWithLookback(_ string, _ int, _ time.Duration, _ time.Time) ring.ReadRing {
-	return r
+func (r *readRingMock) ShuffleShardWithLookback(identifier string, size int, _ time.Duration, _ time.Time) ring.ReadRing {
+	return r.ShuffleShard(identifier, size)
 }
 
 func (r *readRingMock) CleanupShuffleShardCache(_ string) {}
@@ -536,6 +536,18 @@ func (q *querierMock) IndexStats(_ context.Context, _ *loghttp.RangeQuery) (*sta
 	return nil, nil
 }
 
+func (q *querierMock) Patterns(ctx context.Context, req *loghttp.PatternsQuery) (*loghttp.PatternsResponse, error) {
+	args := q.MethodCalled("Patterns", ctx, req)
+
+	resp := args.Get(0)
+	err := args.Error(1)
+	if resp == nil {
+		return nil, err
+	}
+
+	return resp.(*loghttp.PatternsResponse), err
+}
+
 func (q *querierMock) SeriesVolume(ctx context.Context, req *logproto.VolumeRequest) (*logproto.VolumeResponse, error) {
 	args := q.MethodCalled("SeriesVolume", ctx, req)
 
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -19,8 +20,10 @@ import (
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/pkg/storage"
 	"github.com/grafana/loki/pkg/storage/stores/indexshipper/compactor/deletion"
 	"github.com/grafana/loki/pkg/validation"
@@ -1138,6 +1141,180 @@ func TestQuerier_SelectLogWithDeletes(t *testing.T) {
 	require.Equal(t, "test", delGetter.user)
 }
 
+func TestQuerier_SelectMultilineLogs(t *testing.T) {
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(iter.NewStreamIterator(logproto.Stream{
+		Labels: `{type="test"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(1, 0), Line: "2023-01-01 level=error"},
+			{Timestamp: time.Unix(1, 1), Line: "  at foo"},
+			{Timestamp: time.Unix(2, 0), Line: "2023-01-01 level=info"},
+			{Timestamp: time.Unix(2, 1), Line: "  at bar"},
+			{Timestamp: time.Unix(3, 0), Line: "2023-01-01 level=error"},
+		},
+	}), nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	cfg := mockQuerierConfig()
+	cfg.QueryStoreOnly = true
+	q, err := newQuerier(
+		cfg,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{}, store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	request := logproto.QueryRequest{
+		Selector:  `{type="test"} | multiline firstline="^\\d{4}-" |= "at" | logfmt | level="error"`,
+		Limit:     10,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}
+
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.NoError(t, err)
+
+	// the log lines of the streams are selected without the pipeline, and without limit.
+	expectedRequest := &logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Start:     request.Start,
+		End:       request.End,
+		Direction: request.Direction,
+	}
+	require.Contains(t, store.Calls[0].Arguments, logql.SelectLogParams{QueryRequest: expectedRequest})
+
+	require.True(t, it.Next())
+	require.Equal(t, `{level="error", type="test"}`, it.Labels())
+	require.Equal(t, logproto.Entry{Timestamp: time.Unix(1, 0), Line: "2023-01-01 level=error\n  at foo"}, it.Entry())
+	require.False(t, it.Next())
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+}
+
+func TestQuerier_SelectMultilineLogsWithStructuredMetadata(t *testing.T) {
+	// the structured metadata of the log lines are part of the labels of the streams selected, while the log lines of a
+	// stream must be grouped whatever their structured metadata.
+	streamHash := labels.Labels{{Name: "type", Value: "test"}}.Hash()
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(iter.NewSortEntryIterator([]iter.EntryIterator{
+		iter.NewStreamIterator(logproto.Stream{
+			Labels: `{trace_id="a", type="test"}`,
+			Hash:   streamHash,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "2023-01-01 level=error", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "a"}}},
+			},
+		}),
+		iter.NewStreamIterator(logproto.Stream{
+			Labels: `{trace_id="b", type="test"}`,
+			Hash:   streamHash,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 1), Line: "  at foo", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "b"}}},
+				{Timestamp: time.Unix(2, 0), Line: "2023-01-01 level=error", StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "b"}}},
+			},
+		}),
+	}, logproto.FORWARD), nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	cfg := mockQuerierConfig()
+	cfg.QueryStoreOnly = true
+	q, err := newQuerier(
+		cfg,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{}, store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	request := logproto.QueryRequest{
+		Selector:  `{type="test"} | multiline firstline="^\\d{4}-" |= "at" | trace_id="a"`,
+		Limit:     10,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}
+
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.NoError(t, err)
+
+	// the group has the structured metadata of its first log line.
+	require.True(t, it.Next())
+	require.Equal(t, `{trace_id="a", type="test"}`, it.Labels())
+	require.Equal(t, logproto.Entry{
+		Timestamp:          time.Unix(1, 0),
+		Line:               "2023-01-01 level=error\n  at foo",
+		StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "a"}},
+	}, it.Entry())
+	require.False(t, it.Next())
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+}
+
+func TestQuerier_SelectJSONArrayLogs(t *testing.T) {
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(iter.NewStreamIterator(logproto.Stream{
+		Labels: `{type="test"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(1, 0), Line: `[{"user":"foo","action":"login"},{"user":"bar","action":"delete"}]`},
+			{Timestamp: time.Unix(2, 0), Line: `{"user":"baz","action":"delete"}`},
+			{Timestamp: time.Unix(3, 0), Line: `[{"user":"qux","action":"delete"}]`},
+		},
+	}), nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	cfg := mockQuerierConfig()
+	cfg.QueryStoreOnly = true
+	q, err := newQuerier(
+		cfg,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{}, store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	request := logproto.QueryRequest{
+		Selector:  `{type="test"} | json_array | json | action="delete" | line_format "{{.user}}"`,
+		Limit:     10,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}
+
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.NoError(t, err)
+
+	expectedRequest := &logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Start:     request.Start,
+		End:       request.End,
+		Direction: request.Direction,
+	}
+	require.Contains(t, store.Calls[0].Arguments, logql.SelectLogParams{QueryRequest: expectedRequest})
+
+	// the log lines without array are returned as they are.
+	var lines []string
+	for it.Next() {
+		require.Equal(t, `{action="delete", type="test", user="`+it.Entry().Line+`"}`, it.Labels())
+		lines = append(lines, it.Entry().Line)
+	}
+	require.Equal(t, []string{"bar", "baz", "qux"}, lines)
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+}
+
 func TestQuerier_SelectSamplesWithDeletes(t *testing.T) {
 	queryClient := newQuerySampleClientMock()
 	queryClient.On("Recv").Return(mockQueryResponse([]logproto.Stream{mockStream(1, 2)}), nil)
@@ -1199,12 +1376,12 @@ func TestQuerier_SelectSamplesWithDeletes(t *testing.T) {
 }
 
 func newQuerier(cfg Config, clientCfg client.Config, clientFactory ring_client.PoolFactory, ring ring.ReadRing, dg *mockDeleteGettter, store storage.Store, limits *validation.Overrides) (*SingleTenantQuerier, error) {
-	iq, err := newIngesterQuerier(clientCfg, ring, cfg.ExtraQueryDelay, clientFactory)
+	iq, err := newIngesterQuerier(clientCfg, ring, cfg.ExtraQueryDelay, nil, 0, clientFactory)
 	if err != nil {
 		return nil, err
 	}
 
-	return New(cfg, store, iq, limits, dg, nil)
+	return New(cfg, store, iq, limits, logql.NewLookupTables(limits), dg, nil)
 }
 
 type mockDeleteGettter struct {
@@ -571,7 +571,8 @@ func decodeResponseJSON(r *http.Response, req queryrangebase.Request) (queryrang
 						ResultType: loghttp.ResultTypeMatrix,
 						Result:     toProtoMatrix(resp.Data.Result.(loghttp.Matrix)),
 					},
-					Headers: convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Headers:  convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Warnings: resp.Warnings,
 				},
 				Statistics: resp.Data.Statistics,
 			}, nil
@@ -601,7 +602,8 @@ func decodeResponseJSON(r *http.Response, req queryrangebase.Request) (queryrang
 					ResultType: loghttp.ResultTypeStream,
 					Result:     resp.Data.Result.(loghttp.Streams).ToProto(),
 				},
-				Headers: httpResponseHeadersToPromResponseHeaders(r.Header),
+				Headers:  httpResponseHeadersToPromResponseHeaders(r.Header),
+				Warnings: resp.Warnings,
 			}, nil
 		case loghttp.ResultTypeVector:
 			return &LokiPromResponse{
@@ -611,7 +613,8 @@ func decodeResponseJSON(r *http.Response, req queryrangebase.Request) (queryrang
 						ResultType: loghttp.ResultTypeVector,
 						Result:     toProtoVector(resp.Data.Result.(loghttp.Vector)),
 					},
-					Headers: convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Headers:  convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Warnings: resp.Warnings,
 				},
 				Statistics: resp.Data.Statistics,
 			}, nil
@@ -623,7 +626,8 @@ func decodeResponseJSON(r *http.Response, req queryrangebase.Request) (queryrang
 						ResultType: loghttp.ResultTypeScalar,
 						Result:     toProtoScalar(resp.Data.Result.(loghttp.Scalar)),
 					},
-					Headers: convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Headers:  convertPrometheusResponseHeadersToPointers(httpResponseHeadersToPromResponseHeaders(r.Header)),
+					Warnings: resp.Warnings,
 				},
 				Statistics: resp.Data.Statistics,
 			}, nil
@@ -701,6 +705,7 @@ func encodeResponseJSON(ctx context.Context, version loghttp.Version, res queryr
 		result := logqlmodel.Result{
 			Data:       logqlmodel.Streams(streams),
 			Statistics: response.Statistics,
+			Warnings:   response.Warnings,
 		}
 		if version == loghttp.VersionLegacy {
 			if err := marshal_legacy.WriteQueryResponseJSON(result, &buf); err != nil {
@@ -1248,6 +1253,39 @@ func NewEmptyResponse(r queryrangebase.Request) (queryrangebase.Response, error)
 }
 
 func mergeLokiResponse(responses ...queryrangebase.Response) *LokiResponse {
+	return mergeLokiResponseWith(mergeOrderedNonOverlappingStreams, responses...)
+}
+
+// mergeLokiResponseFor merges the responses of the log query req. The responses of the queries with sort, head or
+// tail stages are merged in the order of these stages, instead of by timestamp.
+func mergeLokiResponseFor(req *LokiRequest, responses ...queryrangebase.Response) *LokiResponse {
+	expr, ok := orderedLogSelector(req.Query)
+	if !ok {
+		return mergeLokiResponse(responses...)
+	}
+	return mergeLokiResponseWith(func(resps []*LokiResponse, limit uint32, direction logproto.Direction) []logproto.Stream {
+		var streams []logproto.Stream
+		for _, resp := range resps {
+			streams = append(streams, resp.Data.Result...)
+		}
+		return logql.MergeOrderedStreams(expr, streams, direction, limit)
+	}, responses...)
+}
+
+// orderedLogSelector returns the log selector of the query if it has sort, head or tail stages.
+func orderedLogSelector(query string) (syntax.LogSelectorExpr, bool) {
+	expr, err := syntax.ParseLogSelector(query, false)
+	if err != nil {
+		return nil, false
+	}
+	sortExpr, limitExpr := syntax.LogOrdering(expr)
+	return expr, sortExpr != nil || limitExpr != nil
+}
+
+func mergeLokiResponseWith(
+	merge func(resps []*LokiResponse, limit uint32, direction logproto.Direction) []logproto.Stream,
+	responses ...queryrangebase.Response,
+) *LokiResponse {
 	if len(responses) == 0 {
 		return nil
 	}
@@ -1273,7 +1311,7 @@ func mergeLokiResponse(responses ...queryrangebase.Response) *LokiResponse {
 		Statistics: mergedStats,
 		Data: LokiData{
 			ResultType: loghttp.ResultTypeStream,
-			Result:     mergeOrderedNonOverlappingStreams(lokiResponses, lokiRes.Limit, lokiRes.Direction),
+			Result:     merge(lokiResponses, lokiRes.Limit, lokiRes.Direction),
 		},
 	}
 }
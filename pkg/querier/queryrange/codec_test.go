@@ -236,6 +236,22 @@ func Test_codec_DecodeResponse(t *testing.T) {
 				Statistics: statsResult,
 			}, false,
 		},
+		{
+			"vector-warnings",
+			&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(vectorStringWarnings))},
+			nil,
+			&LokiPromResponse{
+				Response: &queryrangebase.PrometheusResponse{
+					Status: loghttp.QueryStatusSuccess,
+					Data: queryrangebase.PrometheusData{
+						ResultType: loghttp.ResultTypeVector,
+						Result:     make([]queryrangebase.SampleStream, 0),
+					},
+					Warnings: []string{`the results of tenant "a" are omitted`},
+				},
+				Statistics: statsResult,
+			}, false,
+		},
 		{
 			"streams v1", &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(streamsString))},
 			&LokiRequest{Direction: logproto.FORWARD, Limit: 100, Path: "/loki/api/v1/query_range"},
@@ -1451,6 +1467,15 @@ var (
 	},
 	"status": "success"
   }`
+	vectorStringWarnings = `{
+	"data": {
+	  ` + statsResultString + `
+	  "resultType": "vector",
+	  "result": []
+	},
+	"status": "success",
+	"warnings": ["the results of tenant \"a\" are omitted"]
+  }`
 
 	sampleStreams = []queryrangebase.SampleStream{
 		{
@@ -0,0 +1,346 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/tenant"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/stores/index/stats"
+	"github.com/grafana/loki/pkg/util/spanlogger"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+const (
+	limErrQueryCostBudgetTmpl = "the query would exceed the query cost budget of the tenant (query: %s, used: %s, budget: %s per %s); retry later or reduce the time range of the query"
+
+	// maxConcurrentEstimateReq is the number of splits whose index stats are requested at once.
+	maxConcurrentEstimateReq = 10
+)
+
+// QueryCost is the estimated cost of a query, computed from the index stats of its splits before it is executed.
+type QueryCost struct {
+	Bytes   uint64 `json:"bytes"`
+	Chunks  uint64 `json:"chunks"`
+	Streams uint64 `json:"streams"`
+	Entries uint64 `json:"entries"`
+	// Splits is the number of intervals the query is split into.
+	Splits int `json:"splits"`
+	// Shards is the highest number of shards a split is sharded into if the query is shardable.
+	Shards int `json:"shards"`
+	// Subqueries is the number of queries executed by the queriers if the query is shardable.
+	Subqueries int `json:"subqueries"`
+}
+
+func (c *QueryCost) add(o QueryCost) {
+	c.Bytes += o.Bytes
+	c.Chunks += o.Chunks
+	c.Streams += o.Streams
+	c.Entries += o.Entries
+	c.Splits += o.Splits
+	c.Subqueries += o.Subqueries
+	if o.Shards > c.Shards {
+		c.Shards = o.Shards
+	}
+}
+
+// costEstimator estimates the cost of queries from the index stats of the matchers of each of their splits.
+// The costs of the splits using another index than TSDB are not estimated.
+type costEstimator struct {
+	logger       log.Logger
+	limits       Limits
+	configs      []config.PeriodConfig
+	engineOpts   logql.EngineOpts
+	statsHandler queryrangebase.Handler
+	sharded      bool
+}
+
+func newCostEstimator(logger log.Logger, limits Limits, configs []config.PeriodConfig, engineOpts logql.EngineOpts, sharded bool, statsHandler queryrangebase.Handler) *costEstimator {
+	return &costEstimator{
+		logger:       logger,
+		limits:       limits,
+		configs:      configs,
+		engineOpts:   engineOpts,
+		statsHandler: statsHandler,
+		sharded:      sharded,
+	}
+}
+
+// Estimate returns the estimated cost of the query, split by interval and sharded as the query-frontend would.
+func (e *costEstimator) Estimate(ctx context.Context, r queryrangebase.Request) (QueryCost, error) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "costEstimator.Estimate")
+	defer sp.Finish()
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return QueryCost{}, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := syntax.ParseExpr(r.GetQuery())
+	if err != nil {
+		return QueryCost{}, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	groups, err := syntax.MatcherGroups(expr)
+	if err != nil {
+		return QueryCost{}, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	// Same as the shard resolver, a query without matchers queries everything.
+	if len(groups) == 0 {
+		groups = append(groups, syntax.MatcherRange{})
+	}
+
+	splits := []queryrangebase.Request{r}
+	if _, ok := r.(*LokiInstantRequest); !ok {
+		if interval := validation.MaxDurationOrZeroPerTenant(tenantIDs, e.limits.QuerySplitDuration); interval > 0 {
+			split, err := splitByTime(r, interval)
+			if err != nil {
+				return QueryCost{}, err
+			}
+			if len(split) > 0 {
+				splits = split
+			}
+		}
+	}
+	maxBytesPerShard := validation.SmallestPositiveIntPerTenant(tenantIDs, e.limits.TSDBMaxBytesPerShard)
+
+	costs := make([]QueryCost, len(splits))
+	if err := concurrency.ForEachJob(ctx, len(splits), maxConcurrentEstimateReq, func(ctx context.Context, i int) error {
+		split := splits[i]
+		costs[i] = QueryCost{Splits: 1, Subqueries: 1}
+
+		conf, err := ShardingConfigs(e.configs).ValidRange(split.GetStart(), split.GetEnd())
+		if err != nil || conf.IndexType != config.TSDBType {
+			return nil
+		}
+		results, err := getStatsForMatchers(ctx, e.logger, e.statsHandler, model.Time(split.GetStart()), model.Time(split.GetEnd()), groups, len(groups), e.engineOpts.MaxLookBackPeriod)
+		if err != nil {
+			return err
+		}
+		combined := stats.MergeStats(results...)
+		costs[i].Bytes = combined.Bytes
+		costs[i].Chunks = combined.Chunks
+		costs[i].Streams = combined.Streams
+		costs[i].Entries = combined.Entries
+		if e.sharded {
+			if factor := guessShardFactor(combined, maxBytesPerShard, 0); factor > 0 {
+				costs[i].Shards = factor
+				costs[i].Subqueries = factor
+			}
+		}
+		return nil
+	}); err != nil {
+		return QueryCost{}, err
+	}
+
+	var cost QueryCost
+	for _, c := range costs {
+		cost.add(c)
+	}
+	return cost, nil
+}
+
+// estimateRoundTripper answers the query estimate requests with the estimated cost of the query.
+type estimateRoundTripper struct {
+	estimator *costEstimator
+}
+
+func (rt estimateRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	req, err := loghttp.ParseRangeQuery(r)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	cost, err := rt.estimator.Estimate(r.Context(), &LokiRequest{
+		Query:   req.Query,
+		StartTs: req.Start,
+		EndTs:   req.End,
+		Step:    req.Step.Milliseconds(),
+		Path:    r.URL.Path,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := jsoniter.ConfigFastest.Marshal(cost)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{JSONType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+// queryAdmission admits the queries whose estimated bytes fit in the query cost budgets of their tenants.
+type queryAdmission struct {
+	logger    log.Logger
+	limits    Limits
+	estimator *costEstimator
+	budgets   *costBudgets
+}
+
+// Admit returns an error if the estimated bytes of the query exceed the remaining budget of one of its tenants.
+// Otherwise the bytes are charged to the budgets of all its tenants, and it returns the function refunding them if
+// the query fails or is cancelled.
+func (a *queryAdmission) Admit(ctx context.Context, r queryrangebase.Request) (func(), error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	enabled := false
+	for _, id := range tenantIDs {
+		if a.limits.QueryCostBudget(ctx, id) > 0 {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return noRefund, nil
+	}
+
+	log := spanlogger.FromContextWithFallback(ctx, a.logger)
+	defer log.Finish()
+
+	cost, err := a.estimator.Estimate(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets := make([]costBudget, 0, len(tenantIDs))
+	for _, id := range tenantIDs {
+		if budget := a.limits.QueryCostBudget(ctx, id); budget > 0 {
+			budgets = append(budgets, costBudget{tenant: id, bytes: uint64(budget), window: a.limits.QueryCostBudgetWindow(id)})
+		}
+	}
+	refund, rejected, used := a.budgets.charge(cost.Bytes, budgets)
+	if rejected != nil {
+		level.Warn(log).Log("msg", "query exceeds the query cost budget", "status", "rejected", "tenant", rejected.tenant, "estimated_bytes", humanize.IBytes(cost.Bytes), "used_bytes", humanize.IBytes(used), "budget_bytes", humanize.IBytes(rejected.bytes), "window", rejected.window)
+		a.budgets.rejected.WithLabelValues(rejected.tenant).Inc()
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, limErrQueryCostBudgetTmpl, humanize.IBytes(cost.Bytes), humanize.IBytes(used), humanize.IBytes(rejected.bytes), model.Duration(rejected.window))
+	}
+	level.Debug(log).Log("msg", "query is within the query cost budget", "status", "accepted", "estimated_bytes", humanize.IBytes(cost.Bytes))
+	return refund, nil
+}
+
+func noRefund() {}
+
+type costBudget struct {
+	tenant string
+	bytes  uint64
+	window time.Duration
+}
+
+// costBudgets tracks the estimated bytes of the admitted queries of each tenant over a sliding window.
+// The budgets are tracked by each query-frontend for the queries it receives, they are not shared between the replicas.
+type costBudgets struct {
+	mtx     sync.Mutex
+	tenants map[string]*costWindow
+	now     func() time.Time
+
+	rejected *prometheus.CounterVec
+}
+
+type costWindow struct {
+	costs []*timedCost
+	total uint64
+}
+
+type timedCost struct {
+	ts    time.Time
+	bytes uint64
+}
+
+func newCostBudgets(registerer prometheus.Registerer) *costBudgets {
+	return &costBudgets{
+		tenants: map[string]*costWindow{},
+		now:     time.Now,
+		rejected: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_cost_budget_rejected_queries_total",
+			Help:      "Total number of queries rejected because they would exceed the query cost budget of their tenant.",
+		}, []string{"tenant"}),
+	}
+}
+
+// charge charges the bytes to all the budgets if none of them would be exceeded, and returns the function refunding
+// them. Otherwise it returns the exceeded budget and the bytes already used in its window.
+func (b *costBudgets) charge(bytes uint64, budgets []costBudget) (func(), *costBudget, uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := b.now()
+	for i, budget := range budgets {
+		w := b.window(budget.tenant, now.Add(-budget.window))
+		if w.total+bytes > budget.bytes {
+			return nil, &budgets[i], w.total
+		}
+	}
+	charged := make(map[string]*timedCost, len(budgets))
+	for _, budget := range budgets {
+		w := b.window(budget.tenant, now.Add(-budget.window))
+		c := &timedCost{ts: now, bytes: bytes}
+		w.costs = append(w.costs, c)
+		w.total += bytes
+		charged[budget.tenant] = c
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { b.refund(charged) }) }, nil, 0
+}
+
+// refund removes the costs charged to the windows of the tenants, unless they already left them.
+func (b *costBudgets) refund(charged map[string]*timedCost) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for tenant, c := range charged {
+		w, ok := b.tenants[tenant]
+		if !ok {
+			continue
+		}
+		for i := range w.costs {
+			if w.costs[i] == c {
+				w.costs = append(w.costs[:i], w.costs[i+1:]...)
+				w.total -= c.bytes
+				break
+			}
+		}
+	}
+}
+
+// window returns the window of the tenant without the costs charged before the start.
+func (b *costBudgets) window(tenant string, start time.Time) *costWindow {
+	w, ok := b.tenants[tenant]
+	if !ok {
+		w = &costWindow{}
+		b.tenants[tenant] = w
+	}
+	i := 0
+	for ; i < len(w.costs) && !w.costs[i].ts.After(start); i++ {
+		w.total -= w.costs[i].bytes
+	}
+	w.costs = w.costs[i:]
+	return w
+}
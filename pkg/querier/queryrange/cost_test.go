@@ -0,0 +1,226 @@
+package queryrange
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// costTestStatsHandler returns index stats of 1GB per hour and matcher group.
+var costTestStatsHandler = queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	hours := uint64(time.Duration(r.GetEnd()-r.GetStart()) * time.Millisecond / time.Hour)
+	return &IndexStatsResponse{Response: &logproto.IndexStatsResponse{
+		Streams: 1,
+		Chunks:  10 * hours,
+		Entries: 100 * hours,
+		Bytes:   hours << 30,
+	}}, nil
+})
+
+func TestCostEstimator(t *testing.T) {
+	start := time.Unix(0, 0)
+	for _, tc := range []struct {
+		desc     string
+		req      queryrangebase.Request
+		limits   fakeLimits
+		sharded  bool
+		expected QueryCost
+	}{
+		{
+			desc:     "not split",
+			req:      &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(4 * time.Hour)},
+			expected: QueryCost{Bytes: 4 << 30, Chunks: 40, Streams: 1, Entries: 400, Splits: 1, Subqueries: 1},
+		},
+		{
+			desc:     "split",
+			req:      &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(4 * time.Hour)},
+			limits:   fakeLimits{splits: map[string]time.Duration{"1": 2 * time.Hour}},
+			expected: QueryCost{Bytes: 4 << 30, Chunks: 40, Streams: 2, Entries: 400, Splits: 2, Subqueries: 2},
+		},
+		{
+			desc:     "split and sharded",
+			req:      &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(4 * time.Hour)},
+			limits:   fakeLimits{splits: map[string]time.Duration{"1": 2 * time.Hour}},
+			sharded:  true,
+			expected: QueryCost{Bytes: 4 << 30, Chunks: 40, Streams: 2, Entries: 400, Splits: 2, Shards: 4, Subqueries: 8},
+		},
+		{
+			desc:     "several matcher groups",
+			req:      &LokiRequest{Query: `sum(count_over_time({app="foo"}[1h])) / sum(count_over_time({app="bar"}[1h]))`, StartTs: start.Add(time.Hour), EndTs: start.Add(3 * time.Hour)},
+			expected: QueryCost{Bytes: 6 << 30, Chunks: 60, Streams: 2, Entries: 600, Splits: 1, Subqueries: 1},
+		},
+		{
+			desc:     "instant queries are not split",
+			req:      &LokiInstantRequest{Query: `count_over_time({app="foo"}[3h])`, TimeTs: start.Add(3 * time.Hour)},
+			limits:   fakeLimits{splits: map[string]time.Duration{"1": time.Hour}},
+			expected: QueryCost{Bytes: 3 << 30, Chunks: 30, Streams: 1, Entries: 300, Splits: 1, Subqueries: 1},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := newCostEstimator(util_log.Logger, tc.limits, testSchemasTSDB, testEngineOpts, tc.sharded, costTestStatsHandler)
+			cost, err := e.Estimate(user.InjectOrgID(context.Background(), "1"), tc.req)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cost)
+		})
+	}
+
+	// Only the periods using TSDB are estimated.
+	e := newCostEstimator(util_log.Logger, fakeLimits{}, testSchemas, testEngineOpts, false, costTestStatsHandler)
+	cost, err := e.Estimate(user.InjectOrgID(context.Background(), "1"), &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, QueryCost{Splits: 1, Subqueries: 1}, cost)
+}
+
+func TestEstimateRoundTripper(t *testing.T) {
+	rt := estimateRoundTripper{estimator: newCostEstimator(util_log.Logger, fakeLimits{}, testSchemasTSDB, testEngineOpts, false, costTestStatsHandler)}
+
+	params := url.Values{"query": {`{app="foo"}`}, "start": {"0"}, "end": {"7200000000000"}}
+	req, err := http.NewRequest(http.MethodGet, "/loki/api/v1/query/estimate?"+params.Encode(), nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "1")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var cost QueryCost
+	require.NoError(t, jsoniter.Unmarshal(body, &cost))
+	require.Equal(t, QueryCost{Bytes: 2 << 30, Chunks: 20, Streams: 1, Entries: 200, Splits: 1, Subqueries: 1}, cost)
+}
+
+func TestQueryAdmission(t *testing.T) {
+	now := time.Unix(0, 0)
+	budgets := newCostBudgets(nil)
+	budgets.now = func() time.Time { return now }
+	limits := fakeLimits{queryCostBudget: 5 << 30, queryCostBudgetWindow: time.Hour}
+	a := &queryAdmission{
+		logger:    util_log.Logger,
+		limits:    limits,
+		estimator: newCostEstimator(util_log.Logger, limits, testSchemasTSDB, testEngineOpts, false, costTestStatsHandler),
+		budgets:   budgets,
+	}
+	ctx := user.InjectOrgID(context.Background(), "1")
+	// Each query reads 2GB.
+	req := &LokiRequest{Query: `{app="foo"}`, StartTs: now, EndTs: now.Add(2 * time.Hour)}
+	admit := func(ctx context.Context) error {
+		_, err := a.Admit(ctx, req)
+		return err
+	}
+
+	require.NoError(t, admit(ctx))
+	now = now.Add(10 * time.Minute)
+	require.NoError(t, admit(ctx))
+
+	now = now.Add(10 * time.Minute)
+	err := admit(ctx)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+
+	// The budgets of the other tenants are independent.
+	require.NoError(t, admit(user.InjectOrgID(context.Background(), "2")))
+
+	// The first query leaves the window.
+	now = now.Add(41 * time.Minute)
+	require.NoError(t, admit(ctx))
+	require.Error(t, admit(ctx))
+
+	// Multi-tenant queries must fit in the budgets of all their tenants.
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	require.Error(t, admit(user.InjectOrgID(context.Background(), "1|2")))
+}
+
+func TestQueryAdmission_Disabled(t *testing.T) {
+	a := &queryAdmission{
+		logger:  util_log.Logger,
+		limits:  fakeLimits{},
+		budgets: newCostBudgets(nil),
+		estimator: newCostEstimator(util_log.Logger, fakeLimits{}, testSchemasTSDB, testEngineOpts, false, queryrangebase.HandlerFunc(func(context.Context, queryrangebase.Request) (queryrangebase.Response, error) {
+			t.Fatal("unexpected estimate of a query without budget")
+			return nil, nil
+		})),
+	}
+	refund, err := a.Admit(user.InjectOrgID(context.Background(), "1"), &LokiRequest{Query: `{app="foo"}`})
+	require.NoError(t, err)
+	refund()
+}
+
+func TestQueryAdmission_Refund(t *testing.T) {
+	now := time.Unix(0, 0)
+	budgets := newCostBudgets(nil)
+	budgets.now = func() time.Time { return now }
+	limits := fakeLimits{queryCostBudget: 3 << 30, queryCostBudgetWindow: time.Hour}
+	a := &queryAdmission{
+		logger:    util_log.Logger,
+		limits:    limits,
+		estimator: newCostEstimator(util_log.Logger, limits, testSchemasTSDB, testEngineOpts, false, costTestStatsHandler),
+		budgets:   budgets,
+	}
+	ctx := user.InjectOrgID(context.Background(), "1")
+	// Each query reads 2GB.
+	req := &LokiRequest{Query: `{app="foo"}`, StartTs: now, EndTs: now.Add(2 * time.Hour)}
+
+	refund, err := a.Admit(ctx, req)
+	require.NoError(t, err)
+	_, err = a.Admit(ctx, req)
+	require.Error(t, err)
+
+	// The bytes of the failed or cancelled queries are refunded once.
+	refund()
+	refund()
+	_, err = a.Admit(ctx, req)
+	require.NoError(t, err)
+	_, err = a.Admit(ctx, req)
+	require.Error(t, err)
+
+	// The costs which already left the window are not refunded.
+	refund, err = a.Admit(user.InjectOrgID(context.Background(), "2"), req)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Hour)
+	_, err = a.Admit(user.InjectOrgID(context.Background(), "2"), req)
+	require.NoError(t, err)
+	refund()
+	_, err = a.Admit(user.InjectOrgID(context.Background(), "2"), req)
+	require.Error(t, err)
+}
+
+func Test_admitted(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   int
+		err      error
+		refunded bool
+	}{
+		{name: "succeeded", status: http.StatusOK},
+		{name: "failed", status: http.StatusInternalServerError, refunded: true},
+		{name: "cancelled", err: context.Canceled, refunded: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			refunded := false
+			next := queryrangebase.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+				if tc.err != nil {
+					return nil, tc.err
+				}
+				return &http.Response{StatusCode: tc.status, Body: http.NoBody}, nil
+			})
+			_, err := admitted(next, &http.Request{}, func() { refunded = true })
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.refunded, refunded)
+		})
+	}
+}
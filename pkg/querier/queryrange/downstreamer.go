@@ -78,14 +78,18 @@ func (h DownstreamHandler) Downstreamer(ctx context.Context) logql.Downstreamer
 		}
 	}
 
-	locks := make(chan struct{}, p)
-	for i := 0; i < p; i++ {
+	return newInstance(p, h.next)
+}
+
+func newInstance(parallelism int, handler queryrangebase.Handler) *instance {
+	locks := make(chan struct{}, parallelism)
+	for i := 0; i < parallelism; i++ {
 		locks <- struct{}{}
 	}
 	return &instance{
-		parallelism: p,
+		parallelism: parallelism,
 		locks:       locks,
-		handler:     h.next,
+		handler:     handler,
 	}
 }
 
@@ -233,6 +237,7 @@ func ResponseToResult(resp queryrangebase.Response) (logqlmodel.Result, error) {
 			Statistics: r.Statistics,
 			Data:       streams,
 			Headers:    resp.GetHeaders(),
+			Warnings:   r.Warnings,
 		}, nil
 
 	case *LokiPromResponse:
@@ -244,12 +249,14 @@ func ResponseToResult(resp queryrangebase.Response) (logqlmodel.Result, error) {
 				Statistics: r.Statistics,
 				Data:       sampleStreamToVector(r.Response.Data.Result),
 				Headers:    resp.GetHeaders(),
+				Warnings:   r.Response.Warnings,
 			}, nil
 		}
 		return logqlmodel.Result{
 			Statistics: r.Statistics,
 			Data:       sampleStreamToMatrix(r.Response.Data.Result),
 			Headers:    resp.GetHeaders(),
+			Warnings:   r.Response.Warnings,
 		}, nil
 
 	default:
@@ -353,8 +360,9 @@ type accumulatedStreams struct {
 	streams      []*logproto.Stream
 	order        logproto.Direction
 
-	stats   stats.Result        // for accumulating statistics from downstream requests
-	headers map[string][]string // for accumulating headers from downstream requests
+	stats    stats.Result        // for accumulating statistics from downstream requests
+	headers  map[string][]string // for accumulating headers from downstream requests
+	warnings []string            // for accumulating warnings from downstream requests
 }
 
 func newStreamAccumulator(order logproto.Direction, limit int) *accumulatedStreams {
@@ -591,6 +599,7 @@ func (acc *accumulatedStreams) Result() []logqlmodel.Result {
 		Data:       streams,
 		Statistics: acc.stats,
 		Headers:    make([]*definitions.PrometheusResponseHeader, 0, len(acc.headers)),
+		Warnings:   acc.warnings,
 	}
 
 	for name, vals := range acc.headers {
@@ -616,6 +625,7 @@ func (acc *accumulatedStreams) Accumulate(x logqlmodel.Result, _ int) error {
 	}
 	acc.stats.Merge(x.Statistics)
 	metadata.ExtendHeaders(acc.headers, x.Headers)
+	acc.warnings = append(acc.warnings, x.Warnings...)
 
 	switch got := x.Data.(type) {
 	case logqlmodel.Streams:
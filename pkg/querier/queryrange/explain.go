@@ -0,0 +1,434 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+// queryExplainer returns the plans of the queries as they are split and sharded by the query-frontend.
+// The plans are computed the same way as the middlewares, without executing the queries.
+type queryExplainer struct {
+	logger        log.Logger
+	limits        Limits
+	configs       ShardingConfigs
+	engineOpts    logql.EngineOpts
+	sharded       bool
+	shardLogs     bool
+	alignWithStep bool
+	codec         queryrangebase.Codec
+	statsHandler  queryrangebase.Handler
+	// analyzer executes the analyzed queries with the tripperwares of the other queries, so that they are limited,
+	// cached, split and sharded the same way. Their downstream queries must be recorded by a downstreamRecorder.
+	analyzer http.RoundTripper
+	now      func() time.Time
+
+	// The metrics of the mappers used for the plans are not registered.
+	shardMetrics, rangeMetrics *logql.MapperMetrics
+}
+
+func newQueryExplainer(logger log.Logger, cfg Config, engineOpts logql.EngineOpts, limits Limits, configs []config.PeriodConfig, codec queryrangebase.Codec, statsHandler queryrangebase.Handler, analyzer http.RoundTripper) *queryExplainer {
+	return &queryExplainer{
+		logger:        logger,
+		limits:        limits,
+		configs:       configs,
+		engineOpts:    engineOpts,
+		sharded:       cfg.ShardedQueries && hasShards(configs),
+		shardLogs:     !cfg.StreamLogQueries,
+		alignWithStep: cfg.AlignQueriesWithStep,
+		codec:         codec,
+		statsHandler:  statsHandler,
+		analyzer:      analyzer,
+		now:           time.Now,
+		shardMetrics:  logql.NewShardMapperMetrics(nil),
+		rangeMetrics:  logql.NewRangeMapperMetrics(nil),
+	}
+}
+
+// plannedQuery is a downstream node of a plan with the request executing it.
+type plannedQuery struct {
+	node *logql.PlanNode
+	req  queryrangebase.Request
+}
+
+// Explain returns the plan of the query. When the query is analyzed, the downstream queries of the plan are executed
+// and the plan contains their statistics.
+func (e *queryExplainer) Explain(ctx context.Context, r queryrangebase.Request, mode loghttp.ExplainMode) (*logql.PlanNode, error) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "queryExplainer.Explain")
+	defer sp.Finish()
+
+	var (
+		plan    *logql.PlanNode
+		planned []plannedQuery
+		err     error
+	)
+	switch req := r.(type) {
+	case *LokiRequest:
+		plan, planned, err = e.planRange(ctx, req)
+	case *LokiInstantRequest:
+		plan, planned, err = e.planInstant(ctx, req)
+	default:
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "unexpected request type %T", r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == loghttp.ExplainAnalyze {
+		if err := e.analyze(ctx, r, planned); err != nil {
+			return nil, err
+		}
+		plan.MergeStats()
+	}
+	return plan, nil
+}
+
+// planRange plans a range query split by interval, then sharded.
+func (e *queryExplainer) planRange(ctx context.Context, r *LokiRequest) (*logql.PlanNode, []plannedQuery, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := syntax.ParseExpr(r.Query)
+	if err != nil {
+		return nil, nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	var req queryrangebase.Request = r
+	splitter, sharded := splitByTime, e.sharded
+	switch expr := expr.(type) {
+	case syntax.SampleExpr:
+		splitter = splitMetricByTime
+		// Same as the step align middleware, the metric queries are aligned before being split.
+		if e.alignWithStep && r.GetStep() > 0 {
+			req = r.WithStartEnd((r.GetStart()/r.GetStep())*r.GetStep(), (r.GetEnd()/r.GetStep())*r.GetStep())
+		}
+	case syntax.LogSelectorExpr:
+		// Only filter expressions are sharded, and the streamed log queries are never sharded.
+		sharded = sharded && e.shardLogs && expr.HasFilter()
+	}
+
+	var splits []queryrangebase.Request
+	interval := validation.MaxDurationOrZeroPerTenant(tenantIDs, e.limits.QuerySplitDuration)
+	if interval > 0 {
+		splits, err = splitter(req, interval)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(splits) == 0 {
+		return e.planShards(ctx, req, sharded)
+	}
+
+	root := &logql.PlanNode{Type: logql.PlanSplitByInterval, Interval: model.Duration(interval)}
+	var planned []plannedQuery
+	for _, split := range splits {
+		start, end := util.TimeFromMillis(split.GetStart()), util.TimeFromMillis(split.GetEnd())
+		plan, splitPlanned, err := e.planShards(ctx, split, sharded)
+		if err != nil {
+			return nil, nil, err
+		}
+		root.Children = append(root.Children, &logql.PlanNode{
+			Type:     logql.PlanSplit,
+			Start:    &start,
+			End:      &end,
+			Children: []*logql.PlanNode{plan},
+		})
+		planned = append(planned, splitPlanned...)
+	}
+	return root, planned, nil
+}
+
+// planInstant plans an instant query split by range, then each of its downstream queries sharded.
+func (e *queryExplainer) planInstant(ctx context.Context, r *LokiInstantRequest) (*logql.PlanNode, []plannedQuery, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := syntax.ParseExpr(r.Query)
+	if err != nil {
+		return nil, nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	// Only the metric queries are split and sharded.
+	if _, ok := expr.(syntax.SampleExpr); !ok || !e.sharded {
+		return e.planShards(ctx, r, false)
+	}
+
+	interval := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, e.limits.QuerySplitDuration)
+	if interval == 0 {
+		return e.planShards(ctx, r, true)
+	}
+	mapper, err := logql.NewRangeMapper(interval, e.rangeMetrics, logql.NewMapperStats())
+	if err != nil {
+		return nil, nil, err
+	}
+	noop, parsed, err := mapper.Parse(r.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if noop {
+		return e.planShards(ctx, r, true)
+	}
+
+	root := &logql.PlanNode{Type: logql.PlanSplitByRange, Interval: model.Duration(interval), Children: []*logql.PlanNode{logql.NewPlan(parsed)}}
+	planned, err := e.shardDownstreams(ctx, root, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, planned, nil
+}
+
+// shardDownstreams replaces the downstream nodes of the plan by their sharded plans.
+func (e *queryExplainer) shardDownstreams(ctx context.Context, node *logql.PlanNode, r queryrangebase.Request) ([]plannedQuery, error) {
+	var planned []plannedQuery
+	for i, child := range node.Children {
+		if child.Type != logql.PlanDownstream {
+			childPlanned, err := e.shardDownstreams(ctx, child, r)
+			if err != nil {
+				return nil, err
+			}
+			planned = append(planned, childPlanned...)
+			continue
+		}
+		plan, childPlanned, err := e.planShards(ctx, r.WithQuery(child.Query), true)
+		if err != nil {
+			return nil, err
+		}
+		node.Children[i] = plan
+		planned = append(planned, childPlanned...)
+	}
+	return planned, nil
+}
+
+// planShards plans a query sharded by the shard mapper, or as a single downstream query if it can't be sharded.
+func (e *queryExplainer) planShards(ctx context.Context, r queryrangebase.Request, sharded bool) (*logql.PlanNode, []plannedQuery, error) {
+	unsharded := func() (*logql.PlanNode, []plannedQuery, error) {
+		node := &logql.PlanNode{Type: logql.PlanDownstream, Query: r.GetQuery()}
+		return node, []plannedQuery{{node: node, req: r}}, nil
+	}
+	if !sharded {
+		return unsharded()
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	// Same as the shard splitter, the queries within the sharding lookback are not sharded.
+	if minShardingLookback := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, e.limits.MinShardingLookback); minShardingLookback > 0 {
+		if !util.TimeFromMillis(r.GetEnd()).Before(e.now().Add(-minShardingLookback)) {
+			return unsharded()
+		}
+	}
+
+	maxRVDuration, maxOffset, err := maxRangeVectorAndOffsetDuration(r.GetQuery())
+	if err != nil {
+		return unsharded()
+	}
+	conf, err := e.configs.GetConf(int64(model.Time(r.GetStart()).Add(-maxRVDuration).Add(-maxOffset)), int64(model.Time(r.GetEnd()).Add(-maxOffset)))
+	if err != nil {
+		return unsharded()
+	}
+	resolver, ok := shardResolverForConf(
+		ctx,
+		conf,
+		e.engineOpts.MaxLookBackPeriod,
+		e.logger,
+		MinWeightedParallelism(ctx, tenantIDs, e.configs, e.limits, model.Time(r.GetStart()), model.Time(r.GetEnd())),
+		0,
+		r,
+		e.statsHandler,
+		e.limits,
+	)
+	if !ok {
+		return unsharded()
+	}
+
+	noop, _, parsed, err := logql.NewShardMapper(resolver, e.shardMetrics, approximateQuantiles(tenantIDs, e.limits)).Parse(r.GetQuery())
+	if err != nil {
+		return nil, nil, err
+	}
+	if noop {
+		return unsharded()
+	}
+
+	plan := logql.NewPlan(parsed)
+	leaves := plan.Leaves()
+	planned := make([]plannedQuery, 0, len(leaves))
+	for _, leaf := range leaves {
+		var shards []string
+		if leaf.Shard != "" {
+			shards = append(shards, leaf.Shard)
+		}
+		parsedShards, err := logql.ParseShards(shards)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err := withShards(r.WithQuery(leaf.Query), parsedShards)
+		if err != nil {
+			return nil, nil, err
+		}
+		planned = append(planned, plannedQuery{node: leaf, req: req})
+	}
+	return plan, planned, nil
+}
+
+func withShards(r queryrangebase.Request, shards logql.Shards) (queryrangebase.Request, error) {
+	switch r := r.(type) {
+	case *LokiRequest:
+		return r.WithShards(shards), nil
+	case *LokiInstantRequest:
+		return r.WithShards(shards), nil
+	default:
+		return nil, fmt.Errorf("expected *LokiRequest or *LokiInstantRequest, got (%T)", r)
+	}
+}
+
+// analyze executes the query with the analyzer, and sets the statistics of the downstream queries of its plan
+// to the ones of the recorded downstream queries. The downstream queries answered by the results caches have no statistics.
+func (e *queryExplainer) analyze(ctx context.Context, r queryrangebase.Request, planned []plannedQuery) error {
+	recorded := &downstreamQueries{}
+	ctx = context.WithValue(ctx, downstreamQueriesKey, recorded)
+
+	req, err := e.codec.EncodeRequest(ctx, r)
+	if err != nil {
+		return err
+	}
+	if err := user.InjectOrgIDIntoHTTPRequest(ctx, req); err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	resp, err := e.analyzer.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// The responses of the streamed log queries are only executed as they are read.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return httpgrpc.Errorf(resp.StatusCode, string(body))
+	}
+
+	for _, p := range planned {
+		p.node.Stats = recorded.stats(p.req)
+	}
+	return nil
+}
+
+type downstreamQueriesKeyType int
+
+const downstreamQueriesKey downstreamQueriesKeyType = 0
+
+// downstreamQueries are the statistics of the downstream queries executed for an analyzed query.
+type downstreamQueries struct {
+	mtx     sync.Mutex
+	queries []downstreamQuery
+}
+
+type downstreamQuery struct {
+	query      string
+	shards     string
+	start, end int64
+	stats      stats.Result
+}
+
+func (d *downstreamQueries) add(r queryrangebase.Request, s stats.Result) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.queries = append(d.queries, downstreamQuery{query: r.GetQuery(), shards: requestShards(r), start: r.GetStart(), end: r.GetEnd(), stats: s})
+}
+
+// stats returns the merged statistics of the downstream queries of the planned query, which may be split by the
+// results caches, or nil if none was executed.
+func (d *downstreamQueries) stats(r queryrangebase.Request) *stats.Result {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var merged *stats.Result
+	shards := requestShards(r)
+	for _, q := range d.queries {
+		if q.query != r.GetQuery() || q.shards != shards || q.start < r.GetStart() || q.end > r.GetEnd() {
+			continue
+		}
+		if merged == nil {
+			merged = &stats.Result{}
+		}
+		merged.Merge(q.stats)
+	}
+	return merged
+}
+
+func requestShards(r queryrangebase.Request) string {
+	switch r := r.(type) {
+	case *LokiRequest:
+		return fmt.Sprint(r.Shards)
+	case *LokiInstantRequest:
+		return fmt.Sprint(r.Shards)
+	default:
+		return ""
+	}
+}
+
+// downstreamRecorder records the statistics of the downstream queries of the analyzed queries.
+type downstreamRecorder struct {
+	codec queryrangebase.Codec
+	next  http.RoundTripper
+}
+
+func newDownstreamRecorder(codec queryrangebase.Codec, next http.RoundTripper) http.RoundTripper {
+	return downstreamRecorder{codec: codec, next: next}
+}
+
+func (d downstreamRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	recorded, ok := r.Context().Value(downstreamQueriesKey).(*downstreamQueries)
+	if !ok {
+		return d.next.RoundTrip(r)
+	}
+	req, err := d.codec.DecodeRequest(r.Context(), r, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch req.(type) {
+	case *LokiRequest, *LokiInstantRequest:
+	default:
+		return d.next.RoundTrip(r)
+	}
+	resp, err := d.next.RoundTrip(r)
+	if err != nil || resp.StatusCode/100 != 2 {
+		return resp, err
+	}
+	// The response is decoded for its statistics, then encoded again for the tripperwares.
+	decoded, err := d.codec.DecodeResponse(r.Context(), resp, req)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	switch res := decoded.(type) {
+	case *LokiResponse:
+		recorded.add(req, res.Statistics)
+	case *LokiPromResponse:
+		recorded.add(req, res.Statistics)
+	}
+	return d.codec.EncodeResponse(r.Context(), r, decoded)
+}
@@ -0,0 +1,249 @@
+package queryrange
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/config"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// explainTestHandler answers the downstream queries with empty responses reading 10 bytes.
+type explainTestHandler struct {
+	mtx      sync.Mutex
+	requests []queryrangebase.Request
+}
+
+func (h *explainTestHandler) Do(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	h.mtx.Lock()
+	h.requests = append(h.requests, r)
+	h.mtx.Unlock()
+
+	resp, err := NewEmptyResponse(r)
+	if err != nil {
+		return nil, err
+	}
+	s := stats.Result{Querier: stats.Querier{Store: stats.Store{Chunk: stats.Chunk{DecompressedBytes: 10}}}}
+	s.ComputeSummary(time.Second, 0, 0)
+	switch resp := resp.(type) {
+	case *LokiResponse:
+		resp.Statistics = s
+	case *LokiPromResponse:
+		resp.Statistics = s
+	}
+	return resp, nil
+}
+
+// explainTestRoundTripper answers the index stats requests with costTestStatsHandler and the queries with the handler.
+func explainTestRoundTripper(h queryrangebase.Handler) http.RoundTripper {
+	return queryrangebase.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req, err := DefaultCodec.DecodeRequest(r.Context(), r, nil)
+		if err != nil {
+			return nil, err
+		}
+		handler := h
+		if _, ok := req.(*logproto.IndexStatsRequest); ok {
+			handler = costTestStatsHandler
+		}
+		resp, err := handler.Do(r.Context(), req)
+		if err != nil {
+			return nil, err
+		}
+		return DefaultCodec.EncodeResponse(r.Context(), r, resp)
+	})
+}
+
+// newTestQueryExplainer returns a query explainer analyzing the queries with the tripperwares of the query-frontend.
+func newTestQueryExplainer(t *testing.T, cfg Config, limits Limits, next queryrangebase.Handler) *queryExplainer {
+	tpw, stopper, err := NewTripperware(cfg, testEngineOpts, util_log.Logger, limits, nil, config.SchemaConfig{Configs: testSchemasTSDB}, nil, false, nil)
+	require.NoError(t, err)
+	if stopper != nil {
+		t.Cleanup(stopper.Stop)
+	}
+	analyzer := tpw(newDownstreamRecorder(DefaultCodec, explainTestRoundTripper(next)))
+	return newQueryExplainer(util_log.Logger, cfg, testEngineOpts, limits, testSchemasTSDB, DefaultCodec, costTestStatsHandler, analyzer)
+}
+
+func TestQueryExplainer(t *testing.T) {
+	start := time.Unix(0, 0)
+	cfg := Config{}
+	cfg.ShardedQueries = true
+
+	for _, tc := range []struct {
+		desc     string
+		req      queryrangebase.Request
+		validate func(t *testing.T, plan *logql.PlanNode)
+	}{
+		{
+			desc: "metric query split and sharded",
+			req:  &LokiRequest{Query: `sum by (app) (count_over_time({app="foo"}[1m]))`, StartTs: start, EndTs: start.Add(2 * time.Hour), Step: 60000, Limit: 100, Direction: logproto.BACKWARD},
+			validate: func(t *testing.T, plan *logql.PlanNode) {
+				require.Equal(t, logql.PlanSplitByInterval, plan.Type)
+				require.Len(t, plan.Children, 2)
+				for _, split := range plan.Children {
+					require.Equal(t, logql.PlanSplit, split.Type)
+					require.Equal(t, time.Hour, split.End.Sub(*split.Start).Round(time.Hour))
+					require.Equal(t, logql.PlanAggregation, split.Children[0].Type)
+					require.Equal(t, "sum by (app)", split.Children[0].Operation)
+				}
+				leaves := plan.Leaves()
+				require.Len(t, leaves, 4)
+				for i, leaf := range leaves {
+					require.Equal(t, []string{"0_of_2", "1_of_2"}[i%2], leaf.Shard)
+				}
+			},
+		},
+		{
+			desc: "log query without filter is only split",
+			req:  &LokiRequest{Query: `{app="foo"}`, StartTs: start, EndTs: start.Add(2 * time.Hour), Limit: 100, Direction: logproto.BACKWARD},
+			validate: func(t *testing.T, plan *logql.PlanNode) {
+				require.Equal(t, logql.PlanSplitByInterval, plan.Type)
+				leaves := plan.Leaves()
+				require.Len(t, leaves, 2)
+				for _, leaf := range leaves {
+					require.Equal(t, &logql.PlanNode{Type: logql.PlanDownstream, Query: `{app="foo"}`, Stats: leaf.Stats}, leaf)
+				}
+			},
+		},
+		{
+			desc: "instant query split by range and sharded",
+			req:  &LokiInstantRequest{Query: `sum(count_over_time({app="foo"}[2h]))`, TimeTs: start.Add(2 * time.Hour), Limit: 100, Direction: logproto.BACKWARD},
+			validate: func(t *testing.T, plan *logql.PlanNode) {
+				require.Equal(t, logql.PlanSplitByRange, plan.Type)
+				require.Equal(t, "1h", plan.Interval.String())
+				leaves := plan.Leaves()
+				require.Len(t, leaves, 4)
+				for _, leaf := range leaves {
+					require.NotEmpty(t, leaf.Shard)
+				}
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			limits := fakeLimits{maxQueryParallelism: 2, tsdbMaxQueryParallelism: 2, splits: map[string]time.Duration{"1": time.Hour}, queryTimeout: time.Minute}
+			next := &explainTestHandler{}
+			e := newTestQueryExplainer(t, cfg, limits, next)
+			ctx := user.InjectOrgID(context.Background(), "1")
+
+			plan, err := e.Explain(ctx, tc.req, loghttp.ExplainPlan)
+			require.NoError(t, err)
+			tc.validate(t, plan)
+			require.Nil(t, plan.Stats)
+			require.Empty(t, next.requests)
+
+			plan, err = e.Explain(ctx, tc.req, loghttp.ExplainAnalyze)
+			require.NoError(t, err)
+			tc.validate(t, plan)
+			leaves := plan.Leaves()
+			require.Len(t, next.requests, len(leaves))
+			for _, leaf := range leaves {
+				require.Equal(t, int64(10), leaf.Stats.Summary.TotalBytesProcessed)
+			}
+			require.Equal(t, int64(10*len(leaves)), plan.Stats.Summary.TotalBytesProcessed)
+			require.Equal(t, float64(len(leaves)), plan.Stats.Summary.ExecTime)
+
+			// The downstream queries are executed with their shards.
+			shards := map[string]int{}
+			for _, r := range next.requests {
+				require.LessOrEqual(t, r.GetEnd(), tc.req.GetEnd())
+				switch r := r.(type) {
+				case *LokiRequest:
+					for _, s := range r.Shards {
+						shards[s]++
+					}
+				case *LokiInstantRequest:
+					for _, s := range r.Shards {
+						shards[s]++
+					}
+				}
+			}
+			for _, leaf := range leaves {
+				if leaf.Shard != "" {
+					require.NotZero(t, shards[leaf.Shard])
+				}
+			}
+		})
+	}
+}
+
+func TestQueryExplainer_AnalyzeLimits(t *testing.T) {
+	start := time.Unix(0, 0)
+	cfg := Config{}
+	cfg.ShardedQueries = true
+	req := &LokiRequest{Query: `sum by (app) (count_over_time({app="foo"}[1m]))`, StartTs: start, EndTs: start.Add(2 * time.Hour), Step: 60000, Limit: 100, Direction: logproto.BACKWARD}
+
+	for _, tc := range []struct {
+		desc     string
+		limits   fakeLimits
+		expected string
+	}{
+		{
+			desc:     "max query bytes read",
+			limits:   fakeLimits{maxQueryBytesRead: 1 << 30},
+			expected: "the query would read too many bytes",
+		},
+		{
+			desc:     "max querier bytes read",
+			limits:   fakeLimits{maxQuerierBytesRead: 1 << 20},
+			expected: "shard query is too large to execute on a single querier",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			limits := tc.limits
+			limits.maxQueryParallelism, limits.tsdbMaxQueryParallelism = 2, 2
+			limits.splits = map[string]time.Duration{"1": time.Hour}
+			limits.queryTimeout = time.Minute
+			next := &explainTestHandler{}
+			e := newTestQueryExplainer(t, cfg, limits, next)
+			ctx := user.InjectOrgID(context.Background(), "1")
+
+			// The queries are planned regardless of their limits, but only executed within them.
+			_, err := e.Explain(ctx, req, loghttp.ExplainPlan)
+			require.NoError(t, err)
+			_, err = e.Explain(ctx, req, loghttp.ExplainAnalyze)
+			require.ErrorContains(t, err, tc.expected)
+			require.Empty(t, next.requests)
+		})
+	}
+}
+
+func TestRoundTripper_Explain(t *testing.T) {
+	cfg := Config{}
+	cfg.ShardedQueries = true
+	limits := fakeLimits{maxQueryParallelism: 2, tsdbMaxQueryParallelism: 2, splits: map[string]time.Duration{"1": time.Hour}, queryTimeout: time.Minute}
+	unexpected := queryrangebase.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("unexpected roundtripper called")
+		return nil, nil
+	})
+	explainer := newQueryExplainer(util_log.Logger, cfg, testEngineOpts, limits, testSchemasTSDB, DefaultCodec, costTestStatsHandler, unexpected)
+	rt := newRoundTripper(util_log.Logger, unexpected, unexpected, unexpected, unexpected, unexpected, unexpected, unexpected, unexpected, unexpected, nil, unexpected, nil, explainer, limits)
+
+	params := url.Values{"query": {`sum(count_over_time({app="foo"} |= "bar" [1m]))`}, "start": {"0"}, "end": {"3600000000000"}, "explain": {"true"}}
+	req, err := http.NewRequest(http.MethodGet, "/loki/api/v1/query_range?"+params.Encode(), nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req.WithContext(user.InjectOrgID(context.Background(), "1")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var explained loghttp.ExplainResponse
+	require.NoError(t, jsoniter.Unmarshal(body, &explained))
+	require.Equal(t, loghttp.QueryStatusSuccess, explained.Status)
+	require.Equal(t, logql.PlanSplitByInterval, explained.Data.Type)
+	require.Len(t, explained.Data.Leaves(), 2)
+}
@@ -0,0 +1,285 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/metadata"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/util/spanlogger"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+const (
+	// tenantLabel is the label holding the tenant of the federated series, same as in the multi-tenant querier.
+	tenantLabel = "__tenant_id__"
+	// retainExistingPrefix prefixes the tenant label of the series already having one.
+	retainExistingPrefix = "original_"
+
+	// FederationFailurePolicyFail fails the federated query when the query of any tenant fails.
+	FederationFailurePolicyFail = "fail"
+	// FederationFailurePolicyWarn omits the results of the tenants whose query failed and returns a warning.
+	FederationFailurePolicyWarn = "warn"
+)
+
+type FederationMetrics struct {
+	tenantFailures *prometheus.CounterVec
+}
+
+func NewFederationMetrics(registerer prometheus.Registerer) *FederationMetrics {
+	return &FederationMetrics{
+		tenantFailures: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_federated_tenant_failures_total",
+			Help:      "Number of failed queries of a tenant within federated multi-tenant queries, by failure policy.",
+		}, []string{"policy"}),
+	}
+}
+
+type federation struct {
+	logger        log.Logger
+	engineOpts    logql.EngineOpts
+	limits        Limits
+	failurePolicy string
+	mapperMetrics *logql.MapperMetrics
+	metrics       *FederationMetrics
+	next          queryrangebase.Handler
+}
+
+// NewFederationMiddleware creates a middleware federating the queries of multiple tenants.
+// The query is split into one query per tenant, executed by the next handlers with the limits, splits, shards
+// and caches of the tenant. The results are merged with the tenant label, which can be used in the aggregations.
+// The queries which can't be merged in the query-frontend are federated by the queriers.
+func NewFederationMiddleware(
+	logger log.Logger,
+	engineOpts logql.EngineOpts,
+	limits Limits,
+	failurePolicy string,
+	mapperMetrics *logql.MapperMetrics,
+	metrics *FederationMetrics,
+) queryrangebase.Middleware {
+	if metrics == nil {
+		metrics = NewFederationMetrics(nil)
+	}
+	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return &federation{
+			logger:        logger,
+			engineOpts:    engineOpts,
+			limits:        limits,
+			failurePolicy: failurePolicy,
+			mapperMetrics: mapperMetrics,
+			metrics:       metrics,
+			next:          next,
+		}
+	})
+}
+
+func (f *federation) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	if len(tenantIDs) < 2 {
+		return f.next.Do(ctx, r)
+	}
+
+	sp, ctx := opentracing.StartSpanFromContext(ctx, "federation.Do")
+	defer sp.Finish()
+	logger := spanlogger.FromContextWithFallback(ctx, util_log.WithContext(ctx, f.logger))
+
+	// Each tenant is mapped as a shard of the query, so the query is federated the same way it is sharded.
+	noop, _, parsed, err := logql.NewShardMapper(logql.ConstantShards(len(tenantIDs)), f.mapperMetrics, approximateQuantiles(tenantIDs, f.limits)).Parse(r.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	if noop || !federable(parsed) {
+		level.Debug(logger).Log("msg", "query federated by the queriers", "query", r.GetQuery())
+		return f.next.Do(ctx, r)
+	}
+	level.Debug(logger).Log("msg", "federating query", "tenants", len(tenantIDs), "mapped", parsed.String())
+
+	params, err := paramsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	ng := logql.NewDownstreamEngine(f.engineOpts, federatedHandler{federation: f, tenantIDs: tenantIDs}, f.limits, f.logger)
+	res, err := ng.Query(ctx, params, parsed).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resultToResponse(r, params, res)
+}
+
+// federable returns whether all the selections of the mapped query are executed by the downstream queries.
+// The selections left in the query, like the unsharded range aggregations, can't be executed by the query-frontend.
+func federable(expr syntax.Expr) bool {
+	if _, ok := expr.(syntax.SampleExpr); !ok {
+		return true
+	}
+	ok := true
+	expr.Walk(func(e interface{}) {
+		switch e.(type) {
+		case *syntax.RangeAggregationExpr, *syntax.MatchersExpr, *syntax.PipelineExpr:
+			ok = false
+		}
+	})
+	return ok
+}
+
+// federatedHandler downstreams the query of each tenant, identified by its shard, to the next handler.
+type federatedHandler struct {
+	*federation
+	tenantIDs []string
+}
+
+func (h federatedHandler) Downstreamer(ctx context.Context) logql.Downstreamer {
+	p := DefaultDownstreamConcurrency
+	if x := validation.SmallestPositiveIntPerTenant(h.tenantIDs, func(id string) int { return h.limits.MaxQueryParallelism(ctx, id) }); x > 0 {
+		p = x
+	}
+	return federatedDownstreamer{federatedHandler: h, instance: newInstance(p, h.next)}
+}
+
+type federatedDownstreamer struct {
+	federatedHandler
+	instance *instance
+}
+
+func (d federatedDownstreamer) Downstream(ctx context.Context, queries []logql.DownstreamQuery) ([]logqlmodel.Result, error) {
+	return d.instance.For(ctx, queries, func(qry logql.DownstreamQuery) (logqlmodel.Result, error) {
+		if len(qry.Shards) == 0 {
+			return d.do(ctx, ParamsToLokiRequest(qry.Params, nil).WithQuery(qry.Expr.String()))
+		}
+		id := d.tenantIDs[qry.Shards[0].Shard]
+
+		expr, matched, err := tenantExpr(qry.Expr, id)
+		if err != nil {
+			return logqlmodel.Result{}, err
+		}
+		if !matched {
+			return emptyResult(qry.Expr, qry.Params), nil
+		}
+
+		res, err := d.do(user.InjectOrgID(ctx, id), ParamsToLokiRequest(qry.Params, nil).WithQuery(expr.String()))
+		if err != nil {
+			d.metrics.tenantFailures.WithLabelValues(d.failurePolicy).Inc()
+			if d.failurePolicy != FederationFailurePolicyWarn {
+				return logqlmodel.Result{}, err
+			}
+			level.Warn(util_log.WithContext(ctx, d.logger)).Log("msg", "omitting the results of a federated tenant", "tenant", id, "err", err)
+			if err := metadata.AddWarnings(ctx, fmt.Sprintf("the results of tenant %s are omitted: %s", id, errorMessage(err))); err != nil {
+				level.Warn(util_log.WithContext(ctx, d.logger)).Log("msg", "unable to add warnings to results context", "err", err)
+			}
+			return emptyResult(qry.Expr, qry.Params), nil
+		}
+		return withTenantLabel(res, id)
+	})
+}
+
+func (d federatedDownstreamer) do(ctx context.Context, req queryrangebase.Request) (logqlmodel.Result, error) {
+	resp, err := d.next.Do(ctx, req)
+	if err != nil {
+		return logqlmodel.Result{}, err
+	}
+	return ResponseToResult(resp)
+}
+
+// errorMessage returns the message of the error, without the status code of the HTTP errors.
+func errorMessage(err error) string {
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return string(resp.Body)
+	}
+	return err.Error()
+}
+
+// tenantExpr returns the query of a tenant, without its tenant matchers, and whether the tenant matches them.
+// The matchers of the retained tenant label are renamed to the tenant label.
+func tenantExpr(expr syntax.Expr, id string) (syntax.Expr, bool, error) {
+	expr, err := syntax.Clone(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	matched := true
+	expr.Walk(func(e interface{}) {
+		m, ok := e.(*syntax.MatchersExpr)
+		if !ok {
+			return
+		}
+		matchers := make([]*labels.Matcher, 0, len(m.Mts))
+		for _, matcher := range m.Mts {
+			switch matcher.Name {
+			case tenantLabel:
+				matched = matched && matcher.Matches(id)
+			case retainExistingPrefix + tenantLabel:
+				renamed := *matcher
+				renamed.Name = tenantLabel
+				matchers = append(matchers, &renamed)
+			default:
+				matchers = append(matchers, matcher)
+			}
+		}
+		m.Mts = matchers
+	})
+	return expr, matched, nil
+}
+
+// emptyResult returns the empty result of the query, used for the tenants which are not selected.
+func emptyResult(expr syntax.Expr, params logql.Params) logqlmodel.Result {
+	if _, ok := expr.(syntax.SampleExpr); !ok {
+		return logqlmodel.Result{Data: logqlmodel.Streams{}}
+	}
+	if logql.GetRangeType(params) == logql.InstantType {
+		return logqlmodel.Result{Data: promql.Vector{}}
+	}
+	return logqlmodel.Result{Data: promql.Matrix{}}
+}
+
+// withTenantLabel adds the tenant label to the series of the result.
+// The existing tenant labels are retained with the original prefix.
+func withTenantLabel(res logqlmodel.Result, id string) (logqlmodel.Result, error) {
+	relabel := func(lbls labels.Labels) labels.Labels {
+		b := labels.NewBuilder(lbls)
+		if lbls.Has(tenantLabel) {
+			b.Set(retainExistingPrefix+tenantLabel, lbls.Get(tenantLabel))
+		}
+		return b.Set(tenantLabel, id).Labels()
+	}
+
+	switch data := res.Data.(type) {
+	case promql.Matrix:
+		for i := range data {
+			data[i].Metric = relabel(data[i].Metric)
+		}
+	case promql.Vector:
+		for i := range data {
+			data[i].Metric = relabel(data[i].Metric)
+		}
+	case logqlmodel.Streams:
+		for i := range data {
+			lbls, err := syntax.ParseLabels(data[i].Labels)
+			if err != nil {
+				return logqlmodel.Result{}, err
+			}
+			data[i].Labels = relabel(lbls).String()
+		}
+	default:
+		return logqlmodel.Result{}, fmt.Errorf("unexpected result type (%T)", res.Data)
+	}
+	return res, nil
+}
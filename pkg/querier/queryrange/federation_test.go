@@ -0,0 +1,219 @@
+package queryrange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// federationTestHandler answers the queries of each tenant with a vector of a single sample, whose value is the
+// number of the tenant, or with the entries of a single stream for the log queries.
+type federationTestHandler struct {
+	mtx      sync.Mutex
+	tenants  []string
+	queries  []string
+	failures map[string]error
+}
+
+func (h *federationTestHandler) Do(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+	id, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.mtx.Lock()
+	h.tenants = append(h.tenants, id)
+	h.queries = append(h.queries, r.GetQuery())
+	h.mtx.Unlock()
+
+	if err := h.failures[id]; err != nil {
+		return nil, err
+	}
+	if _, ok := r.(*LokiRequest); ok {
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: logproto.BACKWARD,
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{Labels: `{__tenant_id__="foo", app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 10), Line: id}}},
+				},
+			},
+		}, nil
+	}
+	return &LokiPromResponse{Response: &queryrangebase.PrometheusResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: queryrangebase.PrometheusData{
+			ResultType: loghttp.ResultTypeVector,
+			Result: []queryrangebase.SampleStream{
+				{
+					Labels:  []logproto.LabelAdapter{{Name: "app", Value: "foo"}},
+					Samples: []logproto.LegacySample{{Value: float64(len(id)), TimestampMs: 10}},
+				},
+			},
+		},
+	}}, nil
+}
+
+func newFederationTestMiddleware(policy string) queryrangebase.Middleware {
+	return NewFederationMiddleware(
+		log.NewNopLogger(),
+		testEngineOpts,
+		fakeLimits{maxSeries: math.MaxInt32, maxQueryParallelism: 2, queryTimeout: time.Second},
+		policy,
+		logql.NewTenantMapperMetrics(nil),
+		nil,
+	)
+}
+
+func TestFederation(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	ctx := user.InjectOrgID(context.Background(), "a|bb")
+
+	for _, tc := range []struct {
+		desc            string
+		query           string
+		expected        []queryrangebase.SampleStream
+		expectedTenants []string
+		expectedQueries []string
+	}{
+		{
+			desc:  "aggregation by tenant",
+			query: `sum by (__tenant_id__) (count_over_time({app="foo"}[1m]))`,
+			expected: []queryrangebase.SampleStream{
+				{Labels: []logproto.LabelAdapter{{Name: "__tenant_id__", Value: "a"}}, Samples: []logproto.LegacySample{{Value: 1, TimestampMs: 10}}},
+				{Labels: []logproto.LabelAdapter{{Name: "__tenant_id__", Value: "bb"}}, Samples: []logproto.LegacySample{{Value: 2, TimestampMs: 10}}},
+			},
+			expectedTenants: []string{"a", "bb"},
+			expectedQueries: []string{`sum by (__tenant_id__)(count_over_time({app="foo"}[1m]))`},
+		},
+		{
+			desc:  "aggregation across tenants",
+			query: `sum by (app) (count_over_time({app="foo"}[1m]))`,
+			expected: []queryrangebase.SampleStream{
+				{Labels: []logproto.LabelAdapter{{Name: "app", Value: "foo"}}, Samples: []logproto.LegacySample{{Value: 3, TimestampMs: 10}}},
+			},
+			expectedTenants: []string{"a", "bb"},
+			expectedQueries: []string{`sum by (app)(count_over_time({app="foo"}[1m]))`},
+		},
+		{
+			desc:  "tenant matchers",
+			query: `sum by (__tenant_id__) (count_over_time({app="foo", __tenant_id__="bb", original___tenant_id__="c"}[1m]))`,
+			expected: []queryrangebase.SampleStream{
+				{Labels: []logproto.LabelAdapter{{Name: "__tenant_id__", Value: "bb"}}, Samples: []logproto.LegacySample{{Value: 2, TimestampMs: 10}}},
+			},
+			expectedTenants: []string{"bb"},
+			expectedQueries: []string{`sum by (__tenant_id__)(count_over_time({app="foo", __tenant_id__="c"}[1m]))`},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			next := &federationTestHandler{}
+			resp, err := newFederationTestMiddleware(FederationFailurePolicyFail).Wrap(next).Do(ctx, &LokiInstantRequest{
+				Query:  tc.query,
+				TimeTs: util.TimeFromMillis(10),
+				Path:   "/loki/api/v1/query",
+			})
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, resp.(*LokiPromResponse).Response.Data.Result)
+			require.ElementsMatch(t, tc.expectedTenants, next.tenants)
+			for _, q := range next.queries {
+				require.Contains(t, tc.expectedQueries, q)
+			}
+		})
+	}
+}
+
+func TestFederation_Logs(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+
+	next := &federationTestHandler{}
+	resp, err := newFederationTestMiddleware(FederationFailurePolicyFail).Wrap(next).Do(user.InjectOrgID(context.Background(), "a|bb"), &LokiRequest{
+		Query:     `{app="foo"} |= "bar"`,
+		Limit:     10,
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, 20),
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/query_range",
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a", "bb"}, next.tenants)
+
+	var labels []string
+	for _, s := range resp.(*LokiResponse).Data.Result {
+		labels = append(labels, s.Labels)
+	}
+	// The existing tenant labels are retained with the original prefix.
+	require.ElementsMatch(t, []string{
+		`{__tenant_id__="a", app="foo", original___tenant_id__="foo"}`,
+		`{__tenant_id__="bb", app="foo", original___tenant_id__="foo"}`,
+	}, labels)
+}
+
+func TestFederation_FailurePolicy(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	ctx := user.InjectOrgID(context.Background(), "a|bb")
+	req := &LokiInstantRequest{
+		Query:  `sum by (__tenant_id__) (count_over_time({app="foo"}[1m]))`,
+		TimeTs: util.TimeFromMillis(10),
+		Path:   "/loki/api/v1/query",
+	}
+	failures := map[string]error{"bb": errors.New("too many outstanding requests")}
+
+	_, err := newFederationTestMiddleware(FederationFailurePolicyFail).Wrap(&federationTestHandler{failures: failures}).Do(ctx, req)
+	require.EqualError(t, err, "too many outstanding requests")
+
+	resp, err := newFederationTestMiddleware(FederationFailurePolicyWarn).Wrap(&federationTestHandler{failures: failures}).Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []queryrangebase.SampleStream{
+		{Labels: []logproto.LabelAdapter{{Name: "__tenant_id__", Value: "a"}}, Samples: []logproto.LegacySample{{Value: 1, TimestampMs: 10}}},
+	}, resp.(*LokiPromResponse).Response.Data.Result)
+	require.Equal(t, []string{"the results of tenant bb are omitted: too many outstanding requests"}, resp.(*LokiPromResponse).Response.Warnings)
+}
+
+func TestFederation_Passthrough(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	defer tenant.WithDefaultResolver(tenant.NewSingleResolver())
+
+	for _, tc := range []struct {
+		desc  string
+		orgID string
+		query string
+	}{
+		{desc: "single tenant", orgID: "a", query: `sum(count_over_time({app="foo"}[1m]))`},
+		{desc: "query not federable", orgID: "a|bb", query: `quantile_over_time(0.99, {app="foo"} | unwrap bar [1m])`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var orgIDs []string
+			next := queryrangebase.HandlerFunc(func(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				orgID, err := user.ExtractOrgID(ctx)
+				require.NoError(t, err)
+				orgIDs = append(orgIDs, orgID)
+				require.Equal(t, tc.query, r.GetQuery())
+				return NewEmptyResponse(r)
+			})
+			_, err := newFederationTestMiddleware(FederationFailurePolicyFail).Wrap(next).Do(user.InjectOrgID(context.Background(), tc.orgID), &LokiInstantRequest{
+				Query:  tc.query,
+				TimeTs: util.TimeFromMillis(10),
+				Path:   "/loki/api/v1/query",
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{tc.orgID}, orgIDs)
+		})
+	}
+}
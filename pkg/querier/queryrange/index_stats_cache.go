@@ -101,7 +101,7 @@ func NewIndexStatsCacheMiddleware(
 	return queryrangebase.NewResultsCacheMiddleware(
 		log,
 		c,
-		IndexStatsSplitter{cacheKeyLimits{limits, transformer}},
+		IndexStatsSplitter{cacheKeyLimits{Limits: limits, transformer: transformer}},
 		limits,
 		merger,
 		IndexStatsExtractor{},
@@ -66,8 +66,12 @@ type Limits interface {
 	RequiredNumberLabels(context.Context, string) int
 	MaxQueryBytesRead(context.Context, string) int
 	MaxQuerierBytesRead(context.Context, string) int
+	QueryCostBudget(context.Context, string) int
+	QueryCostBudgetWindow(string) time.Duration
+	ApproximateQuantiles(string) bool
 	MaxStatsCacheFreshness(context.Context, string) time.Duration
 	VolumeEnabled(string) bool
+	LookupTables(context.Context, string) map[string]string
 }
 
 type limits struct {
@@ -119,7 +123,8 @@ type UserIDTransformer func(context.Context, string) string
 // cacheKeyLimits intersects Limits and CacheSplitter
 type cacheKeyLimits struct {
 	Limits
-	transformer UserIDTransformer
+	transformer  UserIDTransformer
+	lookupTables *logql.LookupTables
 }
 
 func (l cacheKeyLimits) GenerateCacheKey(ctx context.Context, userID string, r queryrangebase.Request) string {
@@ -130,13 +135,33 @@ func (l cacheKeyLimits) GenerateCacheKey(ctx context.Context, userID string, r q
 		currentInterval = r.GetStart() / denominator
 	}
 
+	tenantID := userID
 	if l.transformer != nil {
 		userID = l.transformer(ctx, userID)
 	}
 
 	// include both the currentInterval and the split duration in key to ensure
 	// a cache key can't be reused when an interval changes
-	return fmt.Sprintf("%s:%s:%d:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval, split)
+	return fmt.Sprintf("%s:%s:%d:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval, split) + lookupTablesCacheKey(ctx, l.lookupTables, tenantID, r.GetQuery())
+}
+
+// lookupTablesCacheKey returns the versions of the lookup tables of the tenant used by the lookup stages of the query,
+// included in the cache keys of its results so that they are not reused once the tables change.
+func lookupTablesCacheKey(ctx context.Context, lookupTables *logql.LookupTables, userID string, query string) string {
+	expr, err := syntax.ParseExpr(query)
+	if err != nil || len(syntax.LookupTableNames(expr)) == 0 {
+		return ""
+	}
+	tables, _, err := lookupTables.Load(ctx, userID, expr)
+	if err != nil {
+		// the queriers fail to load the tables too, but the results must not be reused once they load them.
+		return fmt.Sprintf(":lookup_tables_unversioned_%d", time.Now().UnixNano())
+	}
+	var sb strings.Builder
+	for _, t := range tables {
+		sb.WriteString(":" + t.Name + "@" + t.Version)
+	}
+	return sb.String()
 }
 
 type limitsMiddleware struct {
@@ -720,6 +745,16 @@ func MinWeightedParallelism(ctx context.Context, tenantIDs []string, configs []c
 	})
 }
 
+// approximateQuantiles returns whether the quantiles can be approximated for all the tenants of the query.
+func approximateQuantiles(tenantIDs []string, l Limits) bool {
+	for _, id := range tenantIDs {
+		if !l.ApproximateQuantiles(id) {
+			return false
+		}
+	}
+	return len(tenantIDs) > 0
+}
+
 // validates log entries limits
 func validateMaxEntriesLimits(req *http.Request, reqLimit uint32, limits Limits) error {
 	tenantIDs, err := tenant.TenantIDs(req.Context())
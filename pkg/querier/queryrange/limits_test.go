@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/pkg/storage/config"
@@ -46,7 +49,7 @@ func TestLimits(t *testing.T) {
 	require.Equal(
 		t,
 		fmt.Sprintf("%s:%s:%d:%d:%d", "a", r.GetQuery(), r.GetStep(), r.GetStart()/int64(time.Hour/time.Millisecond), int64(time.Hour)),
-		cacheKeyLimits{wrapped, nil}.GenerateCacheKey(context.Background(), "a", r),
+		cacheKeyLimits{wrapped, nil, nil}.GenerateCacheKey(context.Background(), "a", r),
 	)
 }
 
@@ -56,7 +59,7 @@ func Test_seriesLimiter(t *testing.T) {
 	cfg.CacheIndexStatsResults = false
 	// split in 7 with 2 in // max.
 	l := WithSplitByLimits(fakeLimits{maxSeries: 1, maxQueryParallelism: 2}, time.Hour)
-	tpw, stopper, err := NewTripperware(cfg, testEngineOpts, util_log.Logger, l, config.SchemaConfig{
+	tpw, stopper, err := NewTripperware(cfg, testEngineOpts, util_log.Logger, l, nil, config.SchemaConfig{
 		Configs: testSchemas,
 	}, nil, false, nil)
 	if stopper != nil {
@@ -246,7 +249,7 @@ func Test_MaxQueryLookBack(t *testing.T) {
 	tpw, stopper, err := NewTripperware(testConfig, testEngineOpts, util_log.Logger, fakeLimits{
 		maxQueryLookback:    1 * time.Hour,
 		maxQueryParallelism: 1,
-	}, config.SchemaConfig{
+	}, nil, config.SchemaConfig{
 		Configs: testSchemas,
 	}, nil, false, nil)
 	if stopper != nil {
@@ -279,7 +282,7 @@ func Test_MaxQueryLookBack(t *testing.T) {
 }
 
 func Test_GenerateCacheKey_NoDivideZero(t *testing.T) {
-	l := cacheKeyLimits{WithSplitByLimits(nil, 0), nil}
+	l := cacheKeyLimits{WithSplitByLimits(nil, 0), nil, nil}
 	start := time.Now()
 	r := &LokiRequest{
 		Query:   "qry",
@@ -294,6 +297,24 @@ func Test_GenerateCacheKey_NoDivideZero(t *testing.T) {
 	)
 }
 
+func Test_GenerateCacheKey_LookupTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\n"), 0o644))
+
+	limits := fakeLimits{lookupTables: map[string]string{"owners": path}}
+	l := cacheKeyLimits{WithSplitByLimits(limits, time.Hour), nil, logql.NewLookupTables(limits)}
+	r := &LokiRequest{
+		Query:   `{app="foo"} | logfmt | lookup "owners" on service`,
+		StartTs: time.Now(),
+	}
+	key := l.GenerateCacheKey(context.Background(), "foo", r)
+	require.Equal(t, key, l.GenerateCacheKey(context.Background(), "foo", r))
+
+	// the cached results are not reused once the table changes.
+	require.NoError(t, os.WriteFile(path, []byte("service,team\napi,platform\nbilling,payments\n"), 0o644))
+	require.NotEqual(t, key, l.GenerateCacheKey(context.Background(), "foo", r))
+}
+
 func Test_WeightedParallelism(t *testing.T) {
 	limits := &fakeLimits{
 		tsdbMaxQueryParallelism: 100,
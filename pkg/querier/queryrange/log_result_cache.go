@@ -20,6 +20,7 @@ import (
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
@@ -51,30 +52,32 @@ func NewLogResultCacheMetrics(registerer prometheus.Registerer) *LogResultCacheM
 // Log hits are difficult to handle because of the limit query parameter and the size of the response.
 // In the future it could be extended to cache non-empty query results.
 // see https://docs.google.com/document/d/1_mACOpxdWZ5K0cIedaja5gzMbv-m0lUVazqZd2O4mEU/edit
-func NewLogResultCache(logger log.Logger, limits Limits, cache cache.Cache, shouldCache queryrangebase.ShouldCacheFn,
+func NewLogResultCache(logger log.Logger, limits Limits, lookupTables *logql.LookupTables, cache cache.Cache, shouldCache queryrangebase.ShouldCacheFn,
 	transformer UserIDTransformer, metrics *LogResultCacheMetrics) queryrangebase.Middleware {
 	if metrics == nil {
 		metrics = NewLogResultCacheMetrics(nil)
 	}
 	return queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
 		return &logResultCache{
-			next:        next,
-			limits:      limits,
-			cache:       cache,
-			logger:      logger,
-			shouldCache: shouldCache,
-			transformer: transformer,
-			metrics:     metrics,
+			next:         next,
+			limits:       limits,
+			lookupTables: lookupTables,
+			cache:        cache,
+			logger:       logger,
+			shouldCache:  shouldCache,
+			transformer:  transformer,
+			metrics:      metrics,
 		}
 	})
 }
 
 type logResultCache struct {
-	next        queryrangebase.Handler
-	limits      Limits
-	cache       cache.Cache
-	shouldCache queryrangebase.ShouldCacheFn
-	transformer UserIDTransformer
+	next         queryrangebase.Handler
+	limits       Limits
+	lookupTables *logql.LookupTables
+	cache        cache.Cache
+	shouldCache  queryrangebase.ShouldCacheFn
+	transformer  UserIDTransformer
 
 	metrics *LogResultCacheMetrics
 	logger  log.Logger
@@ -122,7 +125,8 @@ func (l *logResultCache) Do(ctx context.Context, req queryrangebase.Request) (qu
 		}
 	}
 
-	cacheKey := fmt.Sprintf("log:%s:%s:%d:%d", tenant.JoinTenantIDs(transformedTenantIDs), req.GetQuery(), interval.Nanoseconds(), alignedStart.UnixNano()/(interval.Nanoseconds()))
+	cacheKey := fmt.Sprintf("log:%s:%s:%d:%d", tenant.JoinTenantIDs(transformedTenantIDs), req.GetQuery(), interval.Nanoseconds(), alignedStart.UnixNano()/(interval.Nanoseconds())) +
+		lookupTablesCacheKey(ctx, l.lookupTables, tenant.JoinTenantIDs(tenantIDs), req.GetQuery())
 
 	_, buff, _, err := l.cache.Fetch(ctx, []string{cache.HashKey(cacheKey)})
 	if err != nil {
@@ -260,7 +264,7 @@ func (l *logResultCache) handleHit(ctx context.Context, cacheKey string, cachedR
 				if startResp.Status != loghttp.QueryStatusSuccess {
 					return startResp, nil
 				}
-				result = mergeLokiResponse(startResp, result)
+				result = mergeLokiResponseFor(lokiReq, startResp, result)
 			}
 		}
 
@@ -274,7 +278,7 @@ func (l *logResultCache) handleHit(ctx context.Context, cacheKey string, cachedR
 				if endResp.Status != loghttp.QueryStatusSuccess {
 					return endResp, nil
 				}
-				result = mergeLokiResponse(endResp, result)
+				result = mergeLokiResponseFor(lokiReq, endResp, result)
 			}
 		}
 	}
@@ -34,6 +34,7 @@ func Test_LogResultCacheSameRange(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -76,6 +77,7 @@ func Test_LogResultCacheSameRangeNonEmpty(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -124,6 +126,7 @@ func Test_LogResultCacheSmallerRange(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -174,6 +177,7 @@ func Test_LogResultCacheDifferentRange(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -250,6 +254,7 @@ func Test_LogResultCacheDifferentRangeNonEmpty(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -337,6 +342,7 @@ func Test_LogResultCacheDifferentRangeNonEmptyAndEmpty(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			cache.NewMockCache(),
 			nil,
 			nil,
@@ -447,6 +453,7 @@ func Test_LogResultNonOverlappingCache(t *testing.T) {
 			fakeLimits{
 				splits: map[string]time.Duration{"foo": time.Minute},
 			},
+			nil,
 			mockCache,
 			nil,
 			nil,
@@ -3,12 +3,14 @@
 package queryrange
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/prometheus/prometheus/promql"
 
+	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
@@ -21,9 +23,21 @@ import (
 const (
 	JSONType     = `application/json; charset=utf-8`
 	ProtobufType = `application/vnd.google.protobuf`
+	// StreamBatchesType is the type of the responses of log queries written by WriteStreamBatches.
+	StreamBatchesType = `application/vnd.loki.stream-batches`
+
+	// streamBatchSize is the maximum number of entries in the batches written by WriteStreamBatches.
+	streamBatchSize = 1000
 )
 
 func WriteResponse(req *http.Request, params *logql.LiteralParams, v any, w http.ResponseWriter) error {
+	if req.Header.Get("Accept") == StreamBatchesType {
+		if result, ok := v.(logqlmodel.Result); ok && result.Data.Type() == logqlmodel.ValueTypeStreams {
+			w.Header().Add("Content-Type", StreamBatchesType)
+			return WriteStreamBatches(params, result, w)
+		}
+	}
+
 	if req.Header.Get("Accept") == ProtobufType {
 		w.Header().Add("Content-Type", ProtobufType)
 		return WriteResponseProtobuf(req, params, v, w)
@@ -67,6 +81,76 @@ func WriteQueryResponseProtobuf(params *logql.LiteralParams, v logqlmodel.Result
 	return err
 }
 
+// WriteStreamBatches writes the entries of the streams of a log query result in batches of LokiResponse,
+// each one prefixed by its length. The entries are ordered by the direction of the query across the batches,
+// so that they can be merged as they are read. The last batch has no streams but the statistics of the query.
+func WriteStreamBatches(params logql.Params, v logqlmodel.Result, w io.Writer) error {
+	streams, ok := v.Data.(logqlmodel.Streams)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for streams", v.Data)
+	}
+
+	var (
+		batch   = &LokiResponse{Status: loghttp.QueryStatusSuccess, Data: LokiData{ResultType: loghttp.ResultTypeStream}}
+		indexes = map[string]int{}
+		size    int
+		buf     []byte
+	)
+	writeBatch := func() error {
+		var err error
+		if buf, err = appendStreamBatch(buf[:0], batch); err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+
+	it := iter.NewStreamsIterator(streams, params.Direction())
+	defer it.Close()
+	for it.Next() {
+		i, ok := indexes[it.Labels()]
+		if !ok {
+			i = len(batch.Data.Result)
+			indexes[it.Labels()] = i
+			batch.Data.Result = append(batch.Data.Result, logproto.Stream{Labels: it.Labels(), Hash: it.StreamHash()})
+		}
+		batch.Data.Result[i].Entries = append(batch.Data.Result[i].Entries, it.Entry())
+
+		if size++; size == streamBatchSize {
+			if err := writeBatch(); err != nil {
+				return err
+			}
+			batch.Data.Result, size = batch.Data.Result[:0], 0
+			for k := range indexes {
+				delete(indexes, k)
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if size > 0 {
+		if err := writeBatch(); err != nil {
+			return err
+		}
+	}
+
+	batch.Data.Result = nil
+	batch.Statistics = v.Statistics
+	return writeBatch()
+}
+
+func appendStreamBatch(buf []byte, batch *LokiResponse) ([]byte, error) {
+	size := batch.Size()
+	buf = binary.AppendUvarint(buf, uint64(size))
+	n := len(buf)
+	buf = append(buf, make([]byte, size)...)
+	if _, err := batch.MarshalToSizedBuffer(buf[n:]); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // WriteLabelResponseProtobuf marshals a logproto.LabelResponse to queryrange LokiLabelNamesResponse
 // and then writes it to the provided io.Writer.
 func WriteLabelResponseProtobuf(version loghttp.Version, l logproto.LabelResponse, w io.Writer) error {
@@ -160,6 +244,7 @@ func ResultToResponse(result logqlmodel.Result, params *logql.LiteralParams) (*Q
 							ResultType: loghttp.ResultTypeVector,
 							Result:     sampleStream,
 						},
+						Warnings: result.Warnings,
 					},
 					Statistics: result.Statistics,
 				},
@@ -179,6 +264,7 @@ func ResultToResponse(result logqlmodel.Result, params *logql.LiteralParams) (*Q
 							ResultType: loghttp.ResultTypeMatrix,
 							Result:     sampleStream,
 						},
+						Warnings: result.Warnings,
 					},
 					Statistics: result.Statistics,
 				},
@@ -199,6 +285,7 @@ func ResultToResponse(result logqlmodel.Result, params *logql.LiteralParams) (*Q
 							ResultType: loghttp.ResultTypeScalar,
 							Result:     sampleStream,
 						},
+						Warnings: result.Warnings,
 					},
 					Statistics: result.Statistics,
 				},
@@ -217,6 +304,7 @@ func ResultToResponse(result logqlmodel.Result, params *logql.LiteralParams) (*Q
 					},
 					Status:     "success",
 					Statistics: result.Statistics,
+					Warnings:   result.Warnings,
 				},
 			},
 		}, nil
@@ -14,17 +14,20 @@ type Metrics struct {
 	*SplitByMetrics
 	*LogResultCacheMetrics
 	*queryrangebase.ResultsCacheMetrics
+	*FederationMetrics
 }
 
 type MiddlewareMapperMetrics struct {
-	shardMapper *logql.MapperMetrics
-	rangeMapper *logql.MapperMetrics
+	shardMapper  *logql.MapperMetrics
+	rangeMapper  *logql.MapperMetrics
+	tenantMapper *logql.MapperMetrics
 }
 
 func NewMiddlewareMapperMetrics(registerer prometheus.Registerer) *MiddlewareMapperMetrics {
 	return &MiddlewareMapperMetrics{
-		shardMapper: logql.NewShardMapperMetrics(registerer),
-		rangeMapper: logql.NewRangeMapperMetrics(registerer),
+		shardMapper:  logql.NewShardMapperMetrics(registerer),
+		rangeMapper:  logql.NewRangeMapperMetrics(registerer),
+		tenantMapper: logql.NewTenantMapperMetrics(registerer),
 	}
 }
 
@@ -36,5 +39,6 @@ func NewMetrics(registerer prometheus.Registerer) *Metrics {
 		SplitByMetrics:              NewSplitByMetrics(registerer),
 		LogResultCacheMetrics:       NewLogResultCacheMetrics(registerer),
 		ResultsCacheMetrics:         queryrangebase.NewResultsCacheMetrics(registerer),
+		FederationMetrics:           NewFederationMetrics(registerer),
 	}
 }
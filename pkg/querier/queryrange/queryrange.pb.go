@@ -237,6 +237,7 @@ type LokiResponse struct {
 	Version    uint32                                                                                               `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
 	Statistics stats.Result                                                                                         `protobuf:"bytes,8,opt,name=statistics,proto3" json:"statistics"`
 	Headers    []github_com_grafana_loki_pkg_querier_queryrange_queryrangebase_definitions.PrometheusResponseHeader `protobuf:"bytes,9,rep,name=Headers,proto3,customtype=github.com/grafana/loki/pkg/querier/queryrange/queryrangebase/definitions.PrometheusResponseHeader" json:"-"`
+	Warnings   []string                                                                                             `protobuf:"bytes,10,rep,name=Warnings,proto3" json:"warnings,omitempty"`
 }
 
 func (m *LokiResponse) Reset()      { *m = LokiResponse{} }
@@ -327,6 +328,13 @@ func (m *LokiResponse) GetStatistics() stats.Result {
 	return stats.Result{}
 }
 
+func (m *LokiResponse) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type LokiSeriesRequest struct {
 	Match   []string  `protobuf:"bytes,1,rep,name=match,proto3" json:"match,omitempty"`
 	StartTs time.Time `protobuf:"bytes,2,opt,name=startTs,proto3,stdtime" json:"startTs"`
@@ -992,80 +1000,81 @@ func init() {
 }
 
 var fileDescriptor_51b9d53b40d11902 = []byte{
-	// 1161 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xec, 0x57, 0x4d, 0x6f, 0x23, 0x35,
-	0x18, 0x8e, 0xf3, 0xd5, 0xc6, 0xa5, 0x05, 0xdc, 0xb2, 0x3b, 0x2a, 0xab, 0x99, 0x28, 0x12, 0x6c,
-	0x90, 0x60, 0x22, 0xda, 0xb2, 0xcb, 0x97, 0x10, 0x3b, 0x14, 0xd4, 0x4a, 0x2b, 0x04, 0xb3, 0x15,
-	0x77, 0xa7, 0x71, 0x93, 0xa1, 0xf3, 0xd5, 0xb1, 0x53, 0xd1, 0x1b, 0x3f, 0x00, 0xa4, 0xfd, 0x0b,
-	0x5c, 0x10, 0x12, 0x88, 0x1f, 0x80, 0xc4, 0xbd, 0xc7, 0x1e, 0x57, 0x95, 0x18, 0x68, 0x7a, 0x81,
-	0x72, 0xe9, 0x4f, 0x40, 0xfe, 0x98, 0xc4, 0x93, 0x7e, 0x6c, 0xd3, 0xbd, 0x14, 0x69, 0x2f, 0x89,
-	0xed, 0x79, 0x1e, 0xdb, 0xef, 0xf3, 0x3e, 0xaf, 0x3d, 0x03, 0xef, 0xc6, 0xdb, 0xdd, 0xd6, 0x4e,
-	0x9f, 0x24, 0x1e, 0x49, 0xc4, 0xff, 0x5e, 0x82, 0xc3, 0x2e, 0xd1, 0x9a, 0x76, 0x9c, 0x44, 0x2c,
-	0x42, 0x70, 0x34, 0xb2, 0xb8, 0xd0, 0x8d, 0xba, 0x91, 0x18, 0x6e, 0xf1, 0x96, 0x44, 0x2c, 0x5a,
-	0xdd, 0x28, 0xea, 0xfa, 0xa4, 0x25, 0x7a, 0xed, 0xfe, 0x56, 0x8b, 0x79, 0x01, 0xa1, 0x0c, 0x07,
-	0xb1, 0x02, 0xbc, 0xca, 0xd7, 0xf2, 0xa3, 0xae, 0x64, 0x66, 0x0d, 0xf5, 0xb0, 0xae, 0x1e, 0xee,
-	0xf8, 0x41, 0xd4, 0x21, 0x7e, 0x8b, 0x32, 0xcc, 0xa8, 0xfc, 0x55, 0x88, 0x79, 0x8e, 0x88, 0xfb,
-	0xb4, 0x27, 0x7e, 0xd4, 0xe0, 0x27, 0x4f, 0xdd, 0x7f, 0x1b, 0x53, 0xd2, 0xea, 0x90, 0x2d, 0x2f,
-	0xf4, 0x98, 0x17, 0x85, 0x54, 0x6f, 0xab, 0x49, 0xee, 0x5d, 0x6d, 0x92, 0x71, 0x4d, 0x1a, 0x07,
-	0x45, 0x38, 0xf3, 0x30, 0xda, 0xf6, 0x5c, 0xb2, 0xd3, 0x27, 0x94, 0xa1, 0x05, 0x58, 0x11, 0x18,
-	0x03, 0xd4, 0x41, 0xb3, 0xe6, 0xca, 0x0e, 0x1f, 0xf5, 0xbd, 0xc0, 0x63, 0x46, 0xb1, 0x0e, 0x9a,
-	0xb3, 0xae, 0xec, 0x20, 0x04, 0xcb, 0x94, 0x91, 0xd8, 0x28, 0xd5, 0x41, 0xb3, 0xe4, 0x8a, 0x36,
-	0x5a, 0x84, 0xd3, 0x5e, 0xc8, 0x48, 0xb2, 0x8b, 0x7d, 0xa3, 0x26, 0xc6, 0x87, 0x7d, 0xf4, 0x11,
-	0x9c, 0xa2, 0x0c, 0x27, 0x6c, 0x83, 0x1a, 0xe5, 0x3a, 0x68, 0xce, 0x2c, 0x2d, 0xda, 0x52, 0x6f,
-	0x3b, 0xd3, 0xdb, 0xde, 0xc8, 0xf4, 0x76, 0xa6, 0xf7, 0x53, 0xab, 0xf0, 0xf8, 0x4f, 0x0b, 0xb8,
-	0x19, 0x09, 0xbd, 0x0f, 0x2b, 0x24, 0xec, 0x6c, 0x50, 0xa3, 0x32, 0x01, 0x5b, 0x52, 0xd0, 0xdb,
-	0xb0, 0xd6, 0xf1, 0x12, 0xb2, 0xc9, 0x35, 0x33, 0xaa, 0x75, 0xd0, 0x9c, 0x5b, 0x9a, 0xb7, 0x87,
-	0xf9, 0x5b, 0xcd, 0x1e, 0xb9, 0x23, 0x14, 0x0f, 0x2f, 0xc6, 0xac, 0x67, 0x4c, 0x09, 0x25, 0x44,
-	0x1b, 0x35, 0x60, 0x95, 0xf6, 0x70, 0xd2, 0xa1, 0xc6, 0x74, 0xbd, 0xd4, 0xac, 0x39, 0xf0, 0x24,
-	0xb5, 0xd4, 0x88, 0xab, 0xfe, 0x1b, 0xff, 0x00, 0x88, 0xb8, 0xa4, 0xeb, 0x21, 0x65, 0x38, 0x64,
-	0xd7, 0x51, 0xf6, 0x43, 0x58, 0xe5, 0xce, 0xdb, 0xa0, 0x42, 0xdb, 0xab, 0x86, 0xaa, 0x38, 0xf9,
-	0x58, 0xcb, 0x13, 0xc5, 0x5a, 0x39, 0x37, 0xd6, 0xea, 0x85, 0xb1, 0xfe, 0x5c, 0x86, 0x2f, 0x48,
-	0xfb, 0xd0, 0x38, 0x0a, 0x29, 0xe1, 0xa4, 0x47, 0x0c, 0xb3, 0x3e, 0x95, 0x61, 0x2a, 0x92, 0x18,
-	0x71, 0xd5, 0x13, 0xf4, 0x31, 0x2c, 0xaf, 0x62, 0x86, 0x45, 0xc8, 0x33, 0x4b, 0x0b, 0xb6, 0x66,
-	0x4a, 0x3e, 0x17, 0x7f, 0xe6, 0xdc, 0xe2, 0x51, 0x9d, 0xa4, 0xd6, 0x5c, 0x07, 0x33, 0xfc, 0x66,
-	0x14, 0x78, 0x8c, 0x04, 0x31, 0xdb, 0x73, 0x05, 0x13, 0xbd, 0x03, 0x6b, 0x9f, 0x26, 0x49, 0x94,
-	0x6c, 0xec, 0xc5, 0x44, 0x48, 0x54, 0x73, 0x6e, 0x9f, 0xa4, 0xd6, 0x3c, 0xc9, 0x06, 0x35, 0xc6,
-	0x08, 0x89, 0xde, 0x80, 0x15, 0xd1, 0x11, 0xa2, 0xd4, 0x9c, 0xf9, 0x93, 0xd4, 0x7a, 0x51, 0x50,
-	0x34, 0xb8, 0x44, 0xe4, 0x35, 0xac, 0x5c, 0x49, 0xc3, 0x61, 0x2a, 0xab, 0x7a, 0x2a, 0x0d, 0x38,
-	0xb5, 0x4b, 0x12, 0xca, 0xa7, 0x99, 0x12, 0xe3, 0x59, 0x17, 0x3d, 0x80, 0x90, 0x0b, 0xe3, 0x51,
-	0xe6, 0x6d, 0x72, 0x3f, 0x71, 0x31, 0x66, 0x6d, 0x79, 0x5c, 0xb8, 0x84, 0xf6, 0x7d, 0xe6, 0x20,
-	0xa5, 0x82, 0x06, 0x74, 0xb5, 0x36, 0xfa, 0x05, 0xc0, 0xa9, 0x35, 0x82, 0x3b, 0x24, 0xa1, 0x46,
-	0xad, 0x5e, 0x6a, 0xce, 0x2c, 0xbd, 0x66, 0xeb, 0x67, 0xc3, 0x17, 0x49, 0x14, 0x10, 0xd6, 0x23,
-	0x7d, 0x9a, 0x25, 0x48, 0xa2, 0x9d, 0xed, 0xc3, 0xd4, 0x6a, 0x77, 0x3d, 0xd6, 0xeb, 0xb7, 0xed,
-	0xcd, 0x28, 0x68, 0x75, 0x13, 0xbc, 0x85, 0x43, 0xdc, 0xf2, 0xa3, 0x6d, 0xaf, 0x35, 0xf1, 0x79,
-	0x74, 0xe1, 0x3a, 0x27, 0xa9, 0x05, 0xde, 0x72, 0xb3, 0x2d, 0x36, 0xfe, 0x00, 0xf0, 0x65, 0x9e,
-	0xe1, 0x47, 0x7c, 0x6e, 0xaa, 0x15, 0x46, 0x80, 0xd9, 0x66, 0xcf, 0x00, 0xdc, 0x66, 0xae, 0xec,
-	0xe8, 0x87, 0x45, 0xf1, 0x99, 0x0e, 0x8b, 0xd2, 0xe4, 0x87, 0x45, 0x56, 0x0d, 0xe5, 0x73, 0xab,
-	0xa1, 0x72, 0x61, 0x35, 0x7c, 0x57, 0x92, 0x95, 0x9f, 0xc5, 0x37, 0x41, 0x4d, 0x7c, 0x36, 0xac,
-	0x89, 0x92, 0xd8, 0xed, 0xd0, 0x6a, 0x72, 0xae, 0xf5, 0x0e, 0x09, 0x99, 0xb7, 0xe5, 0x91, 0xe4,
-	0x29, 0x95, 0xa1, 0xd9, 0xad, 0x94, 0xb7, 0x9b, 0xee, 0x95, 0xf2, 0x8d, 0xf7, 0xca, 0x58, 0x75,
-	0x54, 0xae, 0x51, 0x1d, 0x8d, 0xdf, 0x01, 0x7c, 0x85, 0xa7, 0xe3, 0x21, 0x6e, 0x13, 0xff, 0x73,
-	0x1c, 0x8c, 0x2c, 0xa7, 0x99, 0x0b, 0x3c, 0x93, 0xb9, 0x8a, 0xd7, 0x37, 0x57, 0x49, 0x33, 0xd7,
-	0xf0, 0x6e, 0x28, 0x6b, 0x77, 0x43, 0xe3, 0xb4, 0x08, 0x6f, 0x8d, 0xef, 0x7f, 0x02, 0x4b, 0xbd,
-	0xae, 0x59, 0xaa, 0xe6, 0xa0, 0xe7, 0x96, 0xb9, 0x82, 0x65, 0x7e, 0x04, 0x70, 0x3a, 0xbb, 0x83,
-	0x90, 0x0d, 0xa1, 0xa4, 0x89, 0x6b, 0x46, 0x0a, 0x3d, 0xc7, 0xc9, 0xc9, 0x70, 0xd4, 0xd5, 0x10,
-	0xe8, 0x6b, 0x58, 0x95, 0x3d, 0x55, 0xc5, 0xb7, 0xb5, 0x2a, 0x66, 0x09, 0xc1, 0xc1, 0x83, 0x0e,
-	0x8e, 0x19, 0x49, 0x9c, 0xf7, 0xf8, 0x2e, 0x0e, 0x53, 0xeb, 0xee, 0x65, 0x12, 0x89, 0x37, 0x44,
-	0xc9, 0xe3, 0xc9, 0x95, 0x6b, 0xba, 0x6a, 0x85, 0xc6, 0xf7, 0x00, 0xbe, 0xc4, 0x37, 0xca, 0xa5,
-	0x19, 0xba, 0x62, 0x15, 0x4e, 0x27, 0xaa, 0xad, 0x7c, 0xdd, 0xb0, 0xf3, 0xb2, 0x9e, 0x23, 0xa5,
-	0x53, 0xde, 0x4f, 0x2d, 0xe0, 0x0e, 0x99, 0x68, 0x39, 0x27, 0x63, 0xf1, 0x3c, 0x19, 0x39, 0xa5,
-	0x90, 0x13, 0xee, 0xb7, 0x22, 0x44, 0xeb, 0x61, 0x87, 0x7c, 0xc3, 0xcd, 0x37, 0xf2, 0x69, 0xff,
-	0xcc, 0x8e, 0xee, 0x8c, 0x44, 0x39, 0x8b, 0x77, 0x3e, 0x38, 0x4c, 0xad, 0xfb, 0x97, 0xa9, 0x72,
-	0x09, 0x59, 0x0b, 0x41, 0x37, 0x6e, 0xf1, 0xe6, 0xdf, 0x8b, 0x3f, 0x00, 0x38, 0xfb, 0x55, 0xe4,
-	0xf7, 0x03, 0x72, 0x63, 0xef, 0xc4, 0xc6, 0xaf, 0x45, 0x38, 0x97, 0xed, 0x51, 0xa9, 0x1c, 0x9c,
-	0x49, 0xae, 0x31, 0x4a, 0x6e, 0x1e, 0xeb, 0xdc, 0x3f, 0x4c, 0xad, 0xe5, 0x2b, 0x25, 0x36, 0x4f,
-	0xfc, 0xff, 0x26, 0xf5, 0xdf, 0x22, 0x9c, 0xfd, 0x92, 0xcf, 0x32, 0xd4, 0xeb, 0x5d, 0x58, 0xa5,
-	0xe2, 0x36, 0x57, 0x6a, 0x99, 0xe3, 0x6f, 0xbe, 0xf9, 0xf7, 0x86, 0xb5, 0x82, 0xab, 0xf0, 0xfc,
-	0x7b, 0xc0, 0xe7, 0x97, 0x40, 0x96, 0xf7, 0xc6, 0x38, 0xf3, 0xec, 0x15, 0xc1, 0xd9, 0x92, 0x83,
-	0xee, 0xc1, 0x8a, 0xa8, 0x5e, 0x95, 0xf6, 0xdc, 0xb2, 0x67, 0xcb, 0x68, 0xad, 0xe0, 0x4a, 0x38,
-	0x5a, 0x82, 0xe5, 0x38, 0x89, 0x02, 0xf5, 0xb1, 0x76, 0x67, 0x7c, 0x4d, 0xfd, 0xe8, 0x59, 0x2b,
-	0xb8, 0x02, 0x8b, 0x56, 0xb8, 0x45, 0xf9, 0x99, 0x95, 0x1d, 0xc0, 0xc6, 0x38, 0x4d, 0xa3, 0x64,
-	0x50, 0xb4, 0x02, 0xab, 0xbb, 0x22, 0xed, 0xe2, 0xdd, 0x99, 0x3b, 0x53, 0x23, 0xe5, 0x0d, 0xc1,
-	0xe3, 0x92, 0x58, 0x07, 0x8e, 0xfc, 0xe7, 0xac, 0x1c, 0x1c, 0x99, 0x85, 0x27, 0x47, 0x66, 0xe1,
-	0xf4, 0xc8, 0x04, 0xdf, 0x0e, 0x4c, 0xf0, 0xd3, 0xc0, 0x04, 0xfb, 0x03, 0x13, 0x1c, 0x0c, 0x4c,
-	0xf0, 0xd7, 0xc0, 0x04, 0x7f, 0x0f, 0xcc, 0xc2, 0xe9, 0xc0, 0x04, 0x8f, 0x8f, 0xcd, 0xc2, 0xc1,
-	0xb1, 0x59, 0x78, 0x72, 0x6c, 0x16, 0xda, 0x55, 0x61, 0xb9, 0xe5, 0xff, 0x02, 0x00, 0x00, 0xff,
-	0xff, 0xf8, 0x4c, 0x44, 0xf0, 0x43, 0x10, 0x00, 0x00,
+	// 1182 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xec, 0x57, 0x4d, 0x6f, 0x23, 0xb5,
+	0x1f, 0x8e, 0xf3, 0xd6, 0xc4, 0xfd, 0xb7, 0x7f, 0x70, 0x97, 0xee, 0xa8, 0xac, 0x66, 0xa2, 0x48,
+	0xb0, 0x41, 0x82, 0x44, 0xa4, 0x65, 0x97, 0x37, 0x21, 0x76, 0x28, 0xa8, 0x95, 0x56, 0x08, 0x66,
+	0x2b, 0x38, 0x3b, 0x8d, 0x9b, 0x0c, 0xcd, 0xcc, 0xa4, 0xb6, 0x53, 0xe8, 0x8d, 0x0f, 0x00, 0xd2,
+	0x7e, 0x05, 0x2e, 0x88, 0x03, 0xe2, 0x03, 0x20, 0x71, 0xef, 0xb1, 0xc7, 0x55, 0x25, 0x66, 0x69,
+	0x7a, 0x81, 0x70, 0xe9, 0x47, 0x40, 0xb6, 0x67, 0x26, 0x9e, 0xa4, 0xed, 0x36, 0xdd, 0x4b, 0x91,
+	0xb8, 0x24, 0x7e, 0x79, 0x1e, 0xdb, 0xbf, 0xe7, 0xf7, 0xfc, 0xec, 0x04, 0xde, 0xed, 0xef, 0x76,
+	0x1a, 0x7b, 0x03, 0x42, 0x5d, 0x42, 0xe5, 0xf7, 0x01, 0xc5, 0x7e, 0x87, 0x68, 0xcd, 0x7a, 0x9f,
+	0x06, 0x3c, 0x40, 0x70, 0x3c, 0xb2, 0x72, 0xab, 0x13, 0x74, 0x02, 0x39, 0xdc, 0x10, 0x2d, 0x85,
+	0x58, 0xb1, 0x3a, 0x41, 0xd0, 0xe9, 0x91, 0x86, 0xec, 0xb5, 0x06, 0x3b, 0x0d, 0xee, 0x7a, 0x84,
+	0x71, 0xec, 0xf5, 0x23, 0xc0, 0xcb, 0x62, 0xaf, 0x5e, 0xd0, 0x51, 0xcc, 0xb8, 0x11, 0x4d, 0x56,
+	0xa2, 0xc9, 0xbd, 0x9e, 0x17, 0xb4, 0x49, 0xaf, 0xc1, 0x38, 0xe6, 0x4c, 0x7d, 0x46, 0x88, 0x25,
+	0x81, 0xe8, 0x0f, 0x58, 0x57, 0x7e, 0x44, 0x83, 0x1f, 0x3d, 0xf3, 0xfc, 0x2d, 0xcc, 0x48, 0xa3,
+	0x4d, 0x76, 0x5c, 0xdf, 0xe5, 0x6e, 0xe0, 0x33, 0xbd, 0x1d, 0x2d, 0x72, 0xef, 0x6a, 0x8b, 0x4c,
+	0x6a, 0x52, 0x3d, 0xca, 0xc2, 0xf9, 0x87, 0xc1, 0xae, 0xeb, 0x90, 0xbd, 0x01, 0x61, 0x1c, 0xdd,
+	0x82, 0x05, 0x89, 0x31, 0x40, 0x05, 0xd4, 0xca, 0x8e, 0xea, 0x88, 0xd1, 0x9e, 0xeb, 0xb9, 0xdc,
+	0xc8, 0x56, 0x40, 0x6d, 0xc1, 0x51, 0x1d, 0x84, 0x60, 0x9e, 0x71, 0xd2, 0x37, 0x72, 0x15, 0x50,
+	0xcb, 0x39, 0xb2, 0x8d, 0x56, 0x60, 0xc9, 0xf5, 0x39, 0xa1, 0xfb, 0xb8, 0x67, 0x94, 0xe5, 0x78,
+	0xd2, 0x47, 0x1f, 0xc0, 0x39, 0xc6, 0x31, 0xe5, 0x5b, 0xcc, 0xc8, 0x57, 0x40, 0x6d, 0xbe, 0xb9,
+	0x52, 0x57, 0x7a, 0xd7, 0x63, 0xbd, 0xeb, 0x5b, 0xb1, 0xde, 0x76, 0xe9, 0x30, 0xb4, 0x32, 0x8f,
+	0x9f, 0x5a, 0xc0, 0x89, 0x49, 0xe8, 0x5d, 0x58, 0x20, 0x7e, 0x7b, 0x8b, 0x19, 0x85, 0x19, 0xd8,
+	0x8a, 0x82, 0xde, 0x84, 0xe5, 0xb6, 0x4b, 0xc9, 0xb6, 0xd0, 0xcc, 0x28, 0x56, 0x40, 0x6d, 0xb1,
+	0xb9, 0x54, 0x4f, 0xf2, 0xb7, 0x1e, 0x4f, 0x39, 0x63, 0x94, 0x08, 0xaf, 0x8f, 0x79, 0xd7, 0x98,
+	0x93, 0x4a, 0xc8, 0x36, 0xaa, 0xc2, 0x22, 0xeb, 0x62, 0xda, 0x66, 0x46, 0xa9, 0x92, 0xab, 0x95,
+	0x6d, 0x38, 0x0a, 0xad, 0x68, 0xc4, 0x89, 0xbe, 0xab, 0x7f, 0x01, 0x88, 0x84, 0xa4, 0x9b, 0x3e,
+	0xe3, 0xd8, 0xe7, 0xd7, 0x51, 0xf6, 0x7d, 0x58, 0x14, 0xce, 0xdb, 0x62, 0x46, 0x6e, 0x86, 0x50,
+	0x23, 0x4e, 0x3a, 0xd6, 0xfc, 0x4c, 0xb1, 0x16, 0xce, 0x8d, 0xb5, 0x78, 0x61, 0xac, 0x4f, 0xf3,
+	0xf0, 0x7f, 0xca, 0x3e, 0xac, 0x1f, 0xf8, 0x8c, 0x08, 0xd2, 0x23, 0x8e, 0xf9, 0x80, 0xa9, 0x30,
+	0x23, 0x92, 0x1c, 0x71, 0xa2, 0x19, 0xf4, 0x21, 0xcc, 0xaf, 0x63, 0x8e, 0x65, 0xc8, 0xf3, 0xcd,
+	0x5b, 0x75, 0xcd, 0x94, 0x62, 0x2d, 0x31, 0x67, 0x2f, 0x8b, 0xa8, 0x46, 0xa1, 0xb5, 0xd8, 0xc6,
+	0x1c, 0xbf, 0x1e, 0x78, 0x2e, 0x27, 0x5e, 0x9f, 0x1f, 0x38, 0x92, 0x89, 0xde, 0x82, 0xe5, 0x8f,
+	0x29, 0x0d, 0xe8, 0xd6, 0x41, 0x9f, 0x48, 0x89, 0xca, 0xf6, 0xed, 0x51, 0x68, 0x2d, 0x91, 0x78,
+	0x50, 0x63, 0x8c, 0x91, 0xe8, 0x35, 0x58, 0x90, 0x1d, 0x29, 0x4a, 0xd9, 0x5e, 0x1a, 0x85, 0xd6,
+	0xff, 0x25, 0x45, 0x83, 0x2b, 0x44, 0x5a, 0xc3, 0xc2, 0x95, 0x34, 0x4c, 0x52, 0x59, 0xd4, 0x53,
+	0x69, 0xc0, 0xb9, 0x7d, 0x42, 0x99, 0x58, 0x66, 0x4e, 0x8e, 0xc7, 0x5d, 0xf4, 0x00, 0x42, 0x21,
+	0x8c, 0xcb, 0xb8, 0xbb, 0x2d, 0xfc, 0x24, 0xc4, 0x58, 0xa8, 0xab, 0xeb, 0xc2, 0x21, 0x6c, 0xd0,
+	0xe3, 0x36, 0x8a, 0x54, 0xd0, 0x80, 0x8e, 0xd6, 0x46, 0x3f, 0x03, 0x38, 0xb7, 0x41, 0x70, 0x9b,
+	0x50, 0x66, 0x94, 0x2b, 0xb9, 0xda, 0x7c, 0xf3, 0x95, 0xba, 0x7e, 0x37, 0x7c, 0x46, 0x03, 0x8f,
+	0xf0, 0x2e, 0x19, 0xb0, 0x38, 0x41, 0x0a, 0x6d, 0xef, 0x1e, 0x87, 0x56, 0xab, 0xe3, 0xf2, 0xee,
+	0xa0, 0x55, 0xdf, 0x0e, 0xbc, 0x46, 0x87, 0xe2, 0x1d, 0xec, 0xe3, 0x46, 0x2f, 0xd8, 0x75, 0x1b,
+	0x33, 0xdf, 0x47, 0x17, 0xee, 0x33, 0x0a, 0x2d, 0xf0, 0x86, 0x13, 0x1f, 0x11, 0x35, 0x61, 0xe9,
+	0x4b, 0x4c, 0x7d, 0xd7, 0xef, 0x30, 0x03, 0x4a, 0x4f, 0x2d, 0x8f, 0x42, 0x0b, 0x7d, 0x1d, 0x8d,
+	0x69, 0x59, 0x48, 0x70, 0xd5, 0xdf, 0x01, 0x7c, 0x51, 0xb8, 0xe2, 0x91, 0x38, 0x0f, 0xd3, 0x8a,
+	0xc9, 0xc3, 0x7c, 0xbb, 0x6b, 0x00, 0xb1, 0x8c, 0xa3, 0x3a, 0xfa, 0x05, 0x93, 0x7d, 0xae, 0x0b,
+	0x26, 0x37, 0xfb, 0x05, 0x13, 0x57, 0x50, 0xfe, 0xdc, 0x0a, 0x2a, 0x5c, 0x58, 0x41, 0xdf, 0xe5,
+	0x20, 0xd2, 0xe3, 0x9b, 0xa1, 0x8e, 0x3e, 0x49, 0xea, 0x28, 0x27, 0x4f, 0x9b, 0xd8, 0x53, 0xad,
+	0xb5, 0xd9, 0x26, 0x3e, 0x77, 0x77, 0x5c, 0x42, 0x9f, 0x51, 0x4d, 0x9a, 0x45, 0x73, 0x69, 0x8b,
+	0xea, 0xfe, 0xca, 0xdf, 0x7c, 0x7f, 0xa5, 0x2b, 0xaa, 0x70, 0x8d, 0x8a, 0xaa, 0xfe, 0x06, 0xe0,
+	0x4b, 0x22, 0x1d, 0x0f, 0x71, 0x8b, 0xf4, 0x3e, 0xc5, 0xde, 0xd8, 0x72, 0x9a, 0xb9, 0xc0, 0x73,
+	0x99, 0x2b, 0x7b, 0x7d, 0x73, 0xe5, 0x34, 0x73, 0x25, 0xef, 0x49, 0x5e, 0x7b, 0x4f, 0xaa, 0x67,
+	0x59, 0xb8, 0x3c, 0x79, 0xfe, 0x19, 0x2c, 0xf5, 0xaa, 0x66, 0xa9, 0xb2, 0x8d, 0xfe, 0xb3, 0xcc,
+	0x15, 0x2c, 0xf3, 0x23, 0x80, 0xa5, 0xf8, 0xdd, 0x42, 0x75, 0x08, 0x15, 0x4d, 0x3e, 0x4d, 0x4a,
+	0xe8, 0x45, 0x41, 0xa6, 0xc9, 0xa8, 0xa3, 0x21, 0xd0, 0x57, 0xb0, 0xa8, 0x7a, 0x51, 0x15, 0xdf,
+	0xd6, 0xaa, 0x98, 0x53, 0x82, 0xbd, 0x07, 0x6d, 0xdc, 0xe7, 0x84, 0xda, 0xef, 0x88, 0x53, 0x1c,
+	0x87, 0xd6, 0xdd, 0xcb, 0x24, 0x92, 0xbf, 0x2a, 0x15, 0x4f, 0x24, 0x57, 0xed, 0xe9, 0x44, 0x3b,
+	0x54, 0xbf, 0x07, 0xf0, 0x05, 0x71, 0x50, 0x21, 0x4d, 0xe2, 0x8a, 0x75, 0x58, 0xa2, 0x51, 0x3b,
+	0xf2, 0x75, 0xb5, 0x9e, 0x96, 0xf5, 0x1c, 0x29, 0xed, 0xfc, 0x61, 0x68, 0x01, 0x27, 0x61, 0xa2,
+	0xd5, 0x94, 0x8c, 0xd9, 0xf3, 0x64, 0x14, 0x94, 0x4c, 0x4a, 0xb8, 0x5f, 0xb3, 0x10, 0x6d, 0xfa,
+	0x6d, 0xf2, 0x8d, 0x30, 0xdf, 0xd8, 0xa7, 0x83, 0xa9, 0x13, 0xdd, 0x19, 0x8b, 0x32, 0x8d, 0xb7,
+	0xdf, 0x3b, 0x0e, 0xad, 0xfb, 0x97, 0xa9, 0x72, 0x09, 0x59, 0x0b, 0x41, 0x37, 0x6e, 0xf6, 0xc6,
+	0x1b, 0xb7, 0xfa, 0x03, 0x80, 0x0b, 0x5f, 0x04, 0xbd, 0x81, 0x47, 0x6e, 0xec, 0x9b, 0x58, 0xfd,
+	0x25, 0x0b, 0x17, 0xe3, 0x33, 0x46, 0x2a, 0x7b, 0x53, 0xc9, 0x35, 0xc6, 0xc9, 0x4d, 0x63, 0xed,
+	0xfb, 0xc7, 0xa1, 0xb5, 0x7a, 0xa5, 0xc4, 0xa6, 0x89, 0xff, 0xde, 0xa4, 0xfe, 0x9d, 0x85, 0x0b,
+	0x9f, 0x8b, 0x55, 0x12, 0xbd, 0xde, 0x86, 0x45, 0x26, 0x36, 0x8a, 0x1f, 0x1d, 0x73, 0xf2, 0xd7,
+	0x72, 0xfa, 0x77, 0xc3, 0x46, 0xc6, 0x89, 0xf0, 0xe2, 0x3f, 0x44, 0x4f, 0x3c, 0x02, 0x71, 0xde,
+	0xab, 0x93, 0xcc, 0xe9, 0x27, 0x42, 0xb0, 0x15, 0x07, 0xdd, 0x83, 0x05, 0x59, 0xbd, 0x46, 0x6e,
+	0x7a, 0xdb, 0xe9, 0x32, 0xda, 0xc8, 0x38, 0x0a, 0x8e, 0x9a, 0x30, 0xdf, 0xa7, 0x81, 0x17, 0xfd,
+	0xc1, 0xbb, 0x33, 0xb9, 0xa7, 0x7e, 0xf5, 0x6c, 0x64, 0x1c, 0x89, 0x45, 0x6b, 0xc2, 0xa2, 0xe2,
+	0xce, 0x8a, 0x2f, 0x60, 0x63, 0x92, 0xa6, 0x51, 0x62, 0x28, 0x5a, 0x83, 0xc5, 0x7d, 0x99, 0x76,
+	0xa3, 0x18, 0x39, 0x53, 0x23, 0xa5, 0x0d, 0x21, 0xe2, 0x52, 0x58, 0x1b, 0x8e, 0xfd, 0x67, 0xaf,
+	0x1d, 0x9d, 0x98, 0x99, 0x27, 0x27, 0x66, 0xe6, 0xec, 0xc4, 0x04, 0xdf, 0x0e, 0x4d, 0xf0, 0xd3,
+	0xd0, 0x04, 0x87, 0x43, 0x13, 0x1c, 0x0d, 0x4d, 0xf0, 0xc7, 0xd0, 0x04, 0x7f, 0x0e, 0xcd, 0xcc,
+	0xd9, 0xd0, 0x04, 0x8f, 0x4f, 0xcd, 0xcc, 0xd1, 0xa9, 0x99, 0x79, 0x72, 0x6a, 0x66, 0x5a, 0x45,
+	0x69, 0xb9, 0xd5, 0x7f, 0x06, 0x00, 0x23, 0xf2, 0xad, 0x69, 0x77, 0x10, 0x00, 0x00,
 }
 
 func (this *LokiRequest) Equal(that interface{}) bool {
@@ -1216,6 +1225,14 @@ func (this *LokiResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *LokiSeriesRequest) Equal(that interface{}) bool {
@@ -1766,7 +1783,7 @@ func (this *LokiResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 14)
 	s = append(s, "&queryrange.LokiResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	s = append(s, "Data: "+strings.Replace(this.Data.GoString(), `&`, ``, 1)+",\n")
@@ -1777,6 +1794,7 @@ func (this *LokiResponse) GoString() string {
 	s = append(s, "Version: "+fmt.Sprintf("%#v", this.Version)+",\n")
 	s = append(s, "Statistics: "+strings.Replace(this.Statistics.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1802,9 +1820,9 @@ func (this *LokiSeriesResponse) GoString() string {
 	s = append(s, "&queryrange.LokiSeriesResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	if this.Data != nil {
-		vs := make([]*logproto.SeriesIdentifier, len(this.Data))
+		vs := make([]logproto.SeriesIdentifier, len(this.Data))
 		for i := range vs {
-			vs[i] = &this.Data[i]
+			vs[i] = this.Data[i]
 		}
 		s = append(s, "Data: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
@@ -2133,6 +2151,15 @@ func (m *LokiResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintQueryrange(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x52
+		}
+	}
 	if len(m.Headers) > 0 {
 		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -2732,7 +2759,8 @@ func (m *QueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 }
 
 func (m *QueryResponse_Series) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2752,7 +2780,8 @@ func (m *QueryResponse_Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 func (m *QueryResponse_Labels) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Labels) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2772,7 +2801,8 @@ func (m *QueryResponse_Labels) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 func (m *QueryResponse_Stats) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2792,7 +2822,8 @@ func (m *QueryResponse_Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 func (m *QueryResponse_Prom) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Prom) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2812,7 +2843,8 @@ func (m *QueryResponse_Prom) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 func (m *QueryResponse_Streams) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Streams) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2832,7 +2864,8 @@ func (m *QueryResponse_Streams) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 func (m *QueryResponse_Volume) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
 func (m *QueryResponse_Volume) MarshalToSizedBuffer(dAtA []byte) (int, error) {
@@ -2969,6 +3002,12 @@ func (m *LokiResponse) Size() (n int) {
 			n += 1 + l + sovQueryrange(uint64(l))
 		}
 	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovQueryrange(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -3272,8 +3311,8 @@ func (this *LokiRequest) String() string {
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
 		`Step:` + fmt.Sprintf("%v", this.Step) + `,`,
-		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Direction:` + fmt.Sprintf("%v", this.Direction) + `,`,
 		`Path:` + fmt.Sprintf("%v", this.Path) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
@@ -3289,7 +3328,7 @@ func (this *LokiInstantRequest) String() string {
 	s := strings.Join([]string{`&LokiInstantRequest{`,
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
-		`TimeTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.TimeTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`TimeTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.TimeTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Direction:` + fmt.Sprintf("%v", this.Direction) + `,`,
 		`Path:` + fmt.Sprintf("%v", this.Path) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
@@ -3311,6 +3350,7 @@ func (this *LokiResponse) String() string {
 		`Version:` + fmt.Sprintf("%v", this.Version) + `,`,
 		`Statistics:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Statistics), "Result", "stats.Result", 1), `&`, ``, 1) + `,`,
 		`Headers:` + fmt.Sprintf("%v", this.Headers) + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3321,8 +3361,8 @@ func (this *LokiSeriesRequest) String() string {
 	}
 	s := strings.Join([]string{`&LokiSeriesRequest{`,
 		`Match:` + fmt.Sprintf("%v", this.Match) + `,`,
-		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Path:` + fmt.Sprintf("%v", this.Path) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`}`,
@@ -3353,8 +3393,8 @@ func (this *LokiLabelNamesRequest) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&LokiLabelNamesRequest{`,
-		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Path:` + fmt.Sprintf("%v", this.Path) + `,`,
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
 		`}`,
@@ -3414,8 +3454,8 @@ func (this *VolumeRequest) String() string {
 	}
 	s := strings.Join([]string{`&VolumeRequest{`,
 		`Match:` + fmt.Sprintf("%v", this.Match) + `,`,
-		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
-		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`StartTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StartTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
+		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "timestamppb.Timestamp", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -4302,6 +4342,38 @@ func (m *LokiResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthQueryrange
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
@@ -6036,6 +6108,7 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 func skipQueryrange(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -6067,10 +6140,8 @@ func skipQueryrange(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -6091,55 +6162,30 @@ func skipQueryrange(dAtA []byte) (n int, err error) {
 				return 0, ErrInvalidLengthQueryrange
 			}
 			iNdEx += length
-			if iNdEx < 0 {
-				return 0, ErrInvalidLengthQueryrange
-			}
-			return iNdEx, nil
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowQueryrange
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipQueryrange(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-				if iNdEx < 0 {
-					return 0, ErrInvalidLengthQueryrange
-				}
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupQueryrange
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthQueryrange
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthQueryrange = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowQueryrange   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthQueryrange        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowQueryrange          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupQueryrange = fmt.Errorf("proto: unexpected end of group")
 )
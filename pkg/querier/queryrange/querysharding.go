@@ -184,7 +184,7 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 		return ast.next.Do(ctx, r)
 	}
 
-	mapper := logql.NewShardMapper(resolver, ast.metrics)
+	mapper := logql.NewShardMapper(resolver, ast.metrics, approximateQuantiles(tenants, ast.limits))
 
 	noop, bytesPerShard, parsed, err := mapper.Parse(r.GetQuery())
 	if err != nil {
@@ -209,15 +209,6 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 		return nil, err
 	}
 
-	var path string
-	switch r := r.(type) {
-	case *LokiRequest:
-		path = r.GetPath()
-	case *LokiInstantRequest:
-		path = r.GetPath()
-	default:
-		return nil, fmt.Errorf("expected *LokiRequest or *LokiInstantRequest, got (%T)", r)
-	}
 	query := ast.ng.Query(ctx, params, parsed)
 
 	res, err := query.Exec(ctx)
@@ -228,6 +219,21 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 	// Merge index stats result cache stats from shard resolver into the query stats.
 	res.Statistics.Caches.StatsResult.Merge(resolverStats.Caches().StatsResult)
 
+	return resultToResponse(r, params, res)
+}
+
+// resultToResponse converts the result of a query executed by a downstream engine to the response of the request.
+func resultToResponse(r queryrangebase.Request, params logql.Params, res logqlmodel.Result) (queryrangebase.Response, error) {
+	var path string
+	switch r := r.(type) {
+	case *LokiRequest:
+		path = r.GetPath()
+	case *LokiInstantRequest:
+		path = r.GetPath()
+	default:
+		return nil, fmt.Errorf("expected *LokiRequest or *LokiInstantRequest, got (%T)", r)
+	}
+
 	value, err := marshal.NewResultValue(res.Data)
 	if err != nil {
 		return nil, err
@@ -242,7 +248,8 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 					ResultType: loghttp.ResultTypeMatrix,
 					Result:     toProtoMatrix(value.(loghttp.Matrix)),
 				},
-				Headers: res.Headers,
+				Headers:  res.Headers,
+				Warnings: res.Warnings,
 			},
 			Statistics: res.Statistics,
 		}, nil
@@ -262,7 +269,8 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 				ResultType: loghttp.ResultTypeStream,
 				Result:     value.(loghttp.Streams).ToProto(),
 			},
-			Headers: respHeaders,
+			Headers:  respHeaders,
+			Warnings: res.Warnings,
 		}, nil
 	case parser.ValueTypeVector:
 		return &LokiPromResponse{
@@ -273,7 +281,8 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 					ResultType: loghttp.ResultTypeVector,
 					Result:     toProtoVector(value.(loghttp.Vector)),
 				},
-				Headers: res.Headers,
+				Headers:  res.Headers,
+				Warnings: res.Warnings,
 			},
 		}, nil
 	default:
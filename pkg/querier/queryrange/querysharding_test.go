@@ -464,6 +464,61 @@ func Test_InstantSharding(t *testing.T) {
 	require.Equal(t, loghttp.QueryStatusSuccess, response.(*LokiPromResponse).Response.Status)
 }
 
+func Test_InstantSharding_ApproximateQuantiles(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	cpyPeriodConf := testSchemas[0]
+	cpyPeriodConf.RowShards = 3
+
+	for _, tc := range []struct {
+		desc            string
+		approximate     bool
+		expectedQueries []string
+	}{
+		{
+			desc:            "exact quantiles",
+			expectedQueries: []string{`quantile_over_time(0.99, {app="foo"} | unwrap latency [1m])`},
+		},
+		{
+			desc:        "approximated quantiles",
+			approximate: true,
+			expectedQueries: []string{
+				`histogram_over_time({app="foo"} | unwrap latency[1m])`,
+				`histogram_over_time({app="foo"} | unwrap latency[1m])`,
+				`histogram_over_time({app="foo"} | unwrap latency[1m])`,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var lock sync.Mutex
+			var queries []string
+			sharding := NewQueryShardMiddleware(log.NewNopLogger(), ShardingConfigs{
+				cpyPeriodConf,
+			}, testEngineOpts, DefaultCodec, queryrangebase.NewInstrumentMiddlewareMetrics(nil),
+				nilShardingMetrics,
+				fakeLimits{
+					maxSeries:            math.MaxInt32,
+					maxQueryParallelism:  10,
+					queryTimeout:         time.Second,
+					approximateQuantiles: tc.approximate,
+				},
+				0,
+				nil)
+			_, err := sharding.Wrap(queryrangebase.HandlerFunc(func(c context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				queries = append(queries, r.GetQuery())
+				return NewEmptyResponse(r)
+			})).Do(ctx, &LokiInstantRequest{
+				Query:  `quantile_over_time(0.99, {app="foo"} | unwrap latency [1m])`,
+				TimeTs: util.TimeFromMillis(10),
+				Path:   "/v1/query",
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedQueries, queries)
+		})
+	}
+}
+
 func Test_SeriesShardingHandler(t *testing.T) {
 	sharding := NewSeriesQueryShardMiddleware(log.NewNopLogger(), ShardingConfigs{
 		config.PeriodConfig{
@@ -1,8 +1,10 @@
 package queryrange
 
 import (
+	"bytes"
 	"context"
 	"flag"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -33,6 +36,10 @@ type Config struct {
 	Transformer            UserIDTransformer     `yaml:"-"`
 	CacheIndexStatsResults bool                  `yaml:"cache_index_stats_results"`
 	StatsCacheConfig       IndexStatsCacheConfig `yaml:"index_stats_results_cache" doc:"description=If a cache config is not specified and cache_index_stats_results is true, the config for the results cache is used."`
+	StreamLogQueries       bool                  `yaml:"stream_log_queries"`
+
+	FederatedQueries              bool   `yaml:"federated_queries"`
+	FederatedQueriesFailurePolicy string `yaml:"federated_queries_failure_policy"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -40,6 +47,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Config.RegisterFlags(f)
 	f.BoolVar(&cfg.CacheIndexStatsResults, "querier.cache-index-stats-results", false, "Cache index stats query results.")
 	cfg.StatsCacheConfig.RegisterFlags(f)
+	f.BoolVar(&cfg.StreamLogQueries, "querier.stream-log-queries", false, "Stream the responses of log queries from the queriers to the clients instead of buffering them in the query-frontend. The queries are split by interval and their entries are written as they are received, which bounds the memory of the query-frontend. The queriers only stream the responses when the query-scheduler is used. Streamed queries are neither sharded nor cached.")
+	f.BoolVar(&cfg.FederatedQueries, "querier.federated-queries", false, "Federate the queries of multiple tenants in the query-frontend. The query is split into one query per tenant, which is executed with the limits, splits, shards and results cache of the tenant, and the results are merged with the __tenant_id__ label. The queries which can't be merged in the query-frontend are federated by the queriers. Requires multi-tenant queries to be enabled.")
+	f.StringVar(&cfg.FederatedQueriesFailurePolicy, "querier.federated-queries-failure-policy", FederationFailurePolicyFail, "What to do when the query of a tenant fails in a federated query. 'fail' fails the whole query. 'warn' omits the results of the tenant and returns a warning in the response.")
 }
 
 // Validate validates the config.
@@ -53,6 +63,14 @@ func (cfg *Config) Validate() error {
 			return errors.Wrap(err, "invalid index_stats_results_cache config")
 		}
 	}
+
+	if cfg.FederatedQueries {
+		switch cfg.FederatedQueriesFailurePolicy {
+		case FederationFailurePolicyFail, FederationFailurePolicyWarn:
+		default:
+			return errors.Errorf("invalid federated_queries_failure_policy %q, must be %q or %q", cfg.FederatedQueriesFailurePolicy, FederationFailurePolicyFail, FederationFailurePolicyWarn)
+		}
+	}
 	return nil
 }
 
@@ -95,6 +113,7 @@ func NewTripperware(
 	engineOpts logql.EngineOpts,
 	log log.Logger,
 	limits Limits,
+	lookupTables *logql.LookupTables,
 	schema config.SchemaConfig,
 	cacheGenNumLoader queryrangebase.CacheGenNumberLoader,
 	retentionEnabled bool,
@@ -140,7 +159,7 @@ func NewTripperware(
 		return nil, nil, err
 	}
 
-	metricsTripperware, err := NewMetricTripperware(cfg, engineOpts, log, limits, schema, codec, resultsCache,
+	metricsTripperware, err := NewMetricTripperware(cfg, engineOpts, log, limits, lookupTables, schema, codec, resultsCache,
 		cacheGenNumLoader, retentionEnabled, PrometheusExtractor{}, metrics, indexStatsTripperware)
 	if err != nil {
 		return nil, nil, err
@@ -153,7 +172,7 @@ func NewTripperware(
 
 	// NOTE: When we would start caching response from non-metric queries we would have to consider cache gen headers as well in
 	// MergeResponse implementation for Loki codecs same as it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
-	logFilterTripperware, err := NewLogFilterTripperware(cfg, engineOpts, log, limits, schema, codec, resultsCache, metrics, indexStatsTripperware)
+	logFilterTripperware, err := NewLogFilterTripperware(cfg, engineOpts, log, limits, lookupTables, schema, codec, resultsCache, metrics, indexStatsTripperware)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -178,6 +197,13 @@ func NewTripperware(
 		return nil, nil, err
 	}
 
+	streamingTripperware, err := NewStreamingTripperware(cfg, engineOpts, log, limits, schema, codec, indexStatsTripperware)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	budgets := newCostBudgets(registerer)
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		var (
 			metricRT       = metricsTripperware(next)
@@ -188,9 +214,19 @@ func NewTripperware(
 			instantRT      = instantMetricTripperware(next)
 			statsRT        = indexStatsTripperware(next)
 			seriesVolumeRT = seriesVolumeTripperware(next)
+			streamingRT    = streamingTripperware(next)
+			estimator      = newCostEstimator(log, limits, schema.Configs, engineOpts, cfg.ShardedQueries, queryrangebase.NewRoundTripperHandler(statsRT, codec))
+			estimateRT     = estimateRoundTripper{estimator: estimator}
+			admission      = &queryAdmission{logger: log, limits: limits, estimator: estimator, budgets: budgets}
+			// The analyzed queries are executed with the same tripperwares as the other queries, except that their
+			// downstream queries are recorded.
+			recorder = newDownstreamRecorder(codec, next)
+			analyzer = newRoundTripper(log, recorder, limitedTripperware(recorder), logFilterTripperware(recorder), metricsTripperware(recorder), nil, nil,
+				instantMetricTripperware(recorder), nil, nil, streamingTripperware(recorder), nil, nil, nil, limits)
+			explainer = newQueryExplainer(log, cfg, engineOpts, limits, schema.Configs, codec, queryrangebase.NewRoundTripperHandler(statsRT, codec), analyzer)
 		)
 
-		return newRoundTripper(log, next, limitedRT, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, statsRT, seriesVolumeRT, limits)
+		return newRoundTripper(log, next, limitedRT, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, statsRT, seriesVolumeRT, streamingRT, estimateRT, admission, explainer, limits)
 	}, StopperWrapper{resultsCache, statsCache}, nil
 }
 
@@ -199,11 +235,17 @@ type roundTripper struct {
 
 	next, limited, log, metric, series, labels, instantMetric, indexStats, seriesVolume http.RoundTripper
 
-	limits Limits
+	// streaming handles the log queries when their responses are streamed, it is nil otherwise.
+	streaming http.RoundTripper
+	estimate  http.RoundTripper
+
+	admission *queryAdmission
+	explainer *queryExplainer
+	limits    Limits
 }
 
 // newRoundTripper creates a new queryrange roundtripper
-func newRoundTripper(logger log.Logger, next, limited, log, metric, series, labels, instantMetric, indexStats, seriesVolume http.RoundTripper, limits Limits) roundTripper {
+func newRoundTripper(logger log.Logger, next, limited, log, metric, series, labels, instantMetric, indexStats, seriesVolume, streaming, estimate http.RoundTripper, admission *queryAdmission, explainer *queryExplainer, limits Limits) roundTripper {
 	return roundTripper{
 		logger:        logger,
 		limited:       limited,
@@ -215,6 +257,10 @@ func newRoundTripper(logger log.Logger, next, limited, log, metric, series, labe
 		instantMetric: instantMetric,
 		indexStats:    indexStats,
 		seriesVolume:  seriesVolume,
+		streaming:     streaming,
+		estimate:      estimate,
+		admission:     admission,
+		explainer:     explainer,
 		next:          next,
 	}
 }
@@ -253,7 +299,14 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 					return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 				}
 			}
-			return r.metric.RoundTrip(req)
+			if rangeQuery.Explain != loghttp.ExplainNone {
+				return r.explain(req, rangeQuery.Explain)
+			}
+			refund, err := r.admit(req)
+			if err != nil {
+				return nil, err
+			}
+			return admitted(r.metric, req, refund)
 		case syntax.LogSelectorExpr:
 			// Note, this function can mutate the request
 			expr, err := transformRegexQuery(req, e)
@@ -268,11 +321,24 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 			}
 
+			if rangeQuery.Explain != loghttp.ExplainNone {
+				return r.explain(req, rangeQuery.Explain)
+			}
+			refund, err := r.admit(req)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only the responses of the v1 API are streamed, the legacy API has another format.
+			if r.streaming != nil && strings.HasSuffix(req.URL.Path, "/v1/query_range") {
+				return admitted(r.streaming, req, refund)
+			}
+
 			// Only filter expressions are query sharded
 			if !expr.HasFilter() {
-				return r.limited.RoundTrip(req)
+				return admitted(r.limited, req, refund)
 			}
-			return r.log.RoundTrip(req)
+			return admitted(r.log, req, refund)
 
 		default:
 			return r.next.RoundTrip(req)
@@ -308,11 +374,19 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		queryHash := logql.HashedQuery(instantQuery.Query)
 		level.Info(logger).Log("msg", "executing query", "type", "instant", "query", instantQuery.Query, "query_hash", queryHash)
 
+		if instantQuery.Explain != loghttp.ExplainNone {
+			return r.explain(req, instantQuery.Explain)
+		}
+		refund, err := r.admit(req)
+		if err != nil {
+			return nil, err
+		}
+
 		switch expr.(type) {
 		case syntax.SampleExpr:
-			return r.instantMetric.RoundTrip(req)
+			return admitted(r.instantMetric, req, refund)
 		default:
-			return r.next.RoundTrip(req)
+			return admitted(r.next, req, refund)
 		}
 	case IndexStatsOp:
 		statsQuery, err := loghttp.ParseIndexStatsQuery(req)
@@ -349,11 +423,78 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 			"limit", volumeQuery.Limit)
 
 		return r.seriesVolume.RoundTrip(req)
+	case QueryEstimateOp:
+		rangeQuery, err := loghttp.ParseRangeQuery(req)
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+		level.Info(logger).Log("msg", "estimating query", "query", rangeQuery.Query, "length", rangeQuery.End.Sub(rangeQuery.Start))
+
+		return r.estimate.RoundTrip(req)
 	default:
 		return r.next.RoundTrip(req)
 	}
 }
 
+// admit checks that the query fits in the query cost budgets of its tenants, and returns the function refunding the
+// budgets charged for the query.
+func (r roundTripper) admit(req *http.Request) (func(), error) {
+	if r.admission == nil {
+		return noRefund, nil
+	}
+	decoded, err := DefaultCodec.DecodeRequest(req.Context(), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.admission.Admit(req.Context(), decoded)
+}
+
+// admitted executes an admitted query. The query cost budgets charged for the query are refunded if it fails or is
+// cancelled.
+func admitted(next http.RoundTripper, req *http.Request, refund func()) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil || (resp != nil && resp.StatusCode/100 != 2) {
+		refund()
+	}
+	return resp, err
+}
+
+// explain answers the query with its plan. The analyzed queries are executed,
+// so they must fit in the query cost budgets of their tenants.
+func (r roundTripper) explain(req *http.Request, mode loghttp.ExplainMode) (*http.Response, error) {
+	if r.explainer == nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "explaining queries is not supported")
+	}
+	refund := noRefund
+	if mode == loghttp.ExplainAnalyze {
+		var err error
+		if refund, err = r.admit(req); err != nil {
+			return nil, err
+		}
+	}
+	decoded, err := DefaultCodec.DecodeRequest(req.Context(), req, nil)
+	if err != nil {
+		refund()
+		return nil, err
+	}
+	plan, err := r.explainer.Explain(req.Context(), decoded, mode)
+	if err != nil {
+		refund()
+		return nil, err
+	}
+
+	body, err := jsoniter.ConfigFastest.Marshal(loghttp.ExplainResponse{Status: loghttp.QueryStatusSuccess, Data: plan})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{JSONType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
 // transformRegexQuery backport the old regexp params into the v1 query format
 func transformRegexQuery(req *http.Request, expr syntax.LogSelectorExpr) (syntax.LogSelectorExpr, error) {
 	regexp := req.Form.Get("regexp")
@@ -381,6 +522,7 @@ const (
 	IndexStatsOp        = "index_stats"
 	SeriesVolumeOp      = "series_volume"
 	SeriesVolumeRangeOp = "series_volume_range"
+	QueryEstimateOp     = "query_estimate"
 )
 
 func getOperation(path string) string {
@@ -399,6 +541,8 @@ func getOperation(path string) string {
 		return SeriesVolumeOp
 	case path == "/loki/api/v1/index/series_volume_range":
 		return SeriesVolumeRangeOp
+	case path == "/loki/api/v1/query/estimate":
+		return QueryEstimateOp
 	default:
 		return ""
 	}
@@ -410,6 +554,7 @@ func NewLogFilterTripperware(
 	engineOpts logql.EngineOpts,
 	log log.Logger,
 	limits Limits,
+	lookupTables *logql.LookupTables,
 	schema config.SchemaConfig,
 	codec queryrangebase.Codec,
 	c cache.Cache,
@@ -419,18 +564,26 @@ func NewLogFilterTripperware(
 	return func(next http.RoundTripper) http.RoundTripper {
 		statsHandler := queryrangebase.NewRoundTripperHandler(indexStatsTripperware(next), codec)
 
-		queryRangeMiddleware := []queryrangebase.Middleware{
-			StatsCollectorMiddleware(),
+		queryRangeMiddleware := []queryrangebase.Middleware{StatsCollectorMiddleware()}
+
+		if cfg.FederatedQueries {
+			queryRangeMiddleware = append(queryRangeMiddleware,
+				NewFederationMiddleware(log, engineOpts, limits, cfg.FederatedQueriesFailurePolicy, metrics.MiddlewareMapperMetrics.tenantMapper, metrics.FederationMetrics),
+			)
+		}
+
+		queryRangeMiddleware = append(queryRangeMiddleware,
 			NewLimitsMiddleware(limits),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
 			queryrangebase.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
 			SplitByIntervalMiddleware(schema.Configs, limits, codec, splitByTime, metrics.SplitByMetrics),
-		}
+		)
 
 		if cfg.CacheResults {
 			queryCacheMiddleware := NewLogResultCache(
 				log,
 				limits,
+				lookupTables,
 				c,
 				func(_ context.Context, r queryrangebase.Request) bool {
 					return !r.GetCachingOptions().Disabled
@@ -481,9 +634,32 @@ func NewLogFilterTripperware(
 	}, nil
 }
 
+// NewStreamingTripperware creates a new frontend tripperware streaming the responses of log queries, if enabled.
+func NewStreamingTripperware(
+	cfg Config,
+	engineOpts logql.EngineOpts,
+	log log.Logger,
+	limits Limits,
+	schema config.SchemaConfig,
+	codec queryrangebase.Codec,
+	indexStatsTripperware queryrangebase.Tripperware,
+) (queryrangebase.Tripperware, error) {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if !cfg.StreamLogQueries {
+			return nil
+		}
+		statsHandler := queryrangebase.NewRoundTripperHandler(indexStatsTripperware(next), codec)
+
+		return NewStreamingRoundTripper(log, next, limits, schema.Configs,
+			NewLimitsMiddleware(limits),
+			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
+		)
+	}, nil
+}
+
 // NewLimitedTripperware creates a new frontend tripperware responsible for handling log requests which are label matcher only, no filter expression.
 func NewLimitedTripperware(
-	_ Config,
+	cfg Config,
 	engineOpts logql.EngineOpts,
 	log log.Logger,
 	limits Limits,
@@ -495,8 +671,15 @@ func NewLimitedTripperware(
 	return func(next http.RoundTripper) http.RoundTripper {
 		statsHandler := queryrangebase.NewRoundTripperHandler(indexStatsTripperware(next), codec)
 
-		queryRangeMiddleware := []queryrangebase.Middleware{
-			StatsCollectorMiddleware(),
+		queryRangeMiddleware := []queryrangebase.Middleware{StatsCollectorMiddleware()}
+
+		if cfg.FederatedQueries {
+			queryRangeMiddleware = append(queryRangeMiddleware,
+				NewFederationMiddleware(log, engineOpts, limits, cfg.FederatedQueriesFailurePolicy, metrics.MiddlewareMapperMetrics.tenantMapper, metrics.FederationMetrics),
+			)
+		}
+
+		queryRangeMiddleware = append(queryRangeMiddleware,
 			NewLimitsMiddleware(limits),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
 			queryrangebase.InstrumentMiddleware("split_by_interval", metrics.InstrumentMiddlewareMetrics),
@@ -507,7 +690,7 @@ func NewLimitedTripperware(
 			// Below we also fix the number of shards to a static number.
 			SplitByIntervalMiddleware(schema.Configs, WithMaxParallelism(limits, 1), codec, splitByTime, metrics.SplitByMetrics),
 			NewQuerierSizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
-		}
+		)
 
 		if len(queryRangeMiddleware) > 0 {
 			return NewLimitedRoundTripper(next, codec, limits, schema.Configs, queryRangeMiddleware...)
@@ -603,6 +786,7 @@ func NewMetricTripperware(
 	engineOpts logql.EngineOpts,
 	log log.Logger,
 	limits Limits,
+	lookupTables *logql.LookupTables,
 	schema config.SchemaConfig,
 	codec queryrangebase.Codec,
 	c cache.Cache,
@@ -612,7 +796,7 @@ func NewMetricTripperware(
 	metrics *Metrics,
 	indexStatsTripperware queryrangebase.Tripperware,
 ) (queryrangebase.Tripperware, error) {
-	cacheKey := cacheKeyLimits{limits, cfg.Transformer}
+	cacheKey := cacheKeyLimits{limits, cfg.Transformer, lookupTables}
 	var queryCacheMiddleware queryrangebase.Middleware
 	if cfg.CacheResults {
 		var err error
@@ -648,11 +832,16 @@ func NewMetricTripperware(
 	return func(next http.RoundTripper) http.RoundTripper {
 		statsHandler := queryrangebase.NewRoundTripperHandler(indexStatsTripperware(next), codec)
 
-		queryRangeMiddleware := []queryrangebase.Middleware{
-			StatsCollectorMiddleware(),
-			NewLimitsMiddleware(limits),
+		queryRangeMiddleware := []queryrangebase.Middleware{StatsCollectorMiddleware()}
+
+		if cfg.FederatedQueries {
+			queryRangeMiddleware = append(queryRangeMiddleware,
+				NewFederationMiddleware(log, engineOpts, limits, cfg.FederatedQueriesFailurePolicy, metrics.MiddlewareMapperMetrics.tenantMapper, metrics.FederationMetrics),
+			)
 		}
 
+		queryRangeMiddleware = append(queryRangeMiddleware, NewLimitsMiddleware(limits))
+
 		if cfg.AlignQueriesWithStep {
 			queryRangeMiddleware = append(
 				queryRangeMiddleware,
@@ -734,11 +923,18 @@ func NewInstantMetricTripperware(
 	return func(next http.RoundTripper) http.RoundTripper {
 		statsHandler := queryrangebase.NewRoundTripperHandler(indexStatsTripperware(next), codec)
 
-		queryRangeMiddleware := []queryrangebase.Middleware{
-			StatsCollectorMiddleware(),
+		queryRangeMiddleware := []queryrangebase.Middleware{StatsCollectorMiddleware()}
+
+		if cfg.FederatedQueries {
+			queryRangeMiddleware = append(queryRangeMiddleware,
+				NewFederationMiddleware(log, engineOpts, limits, cfg.FederatedQueriesFailurePolicy, metrics.MiddlewareMapperMetrics.tenantMapper, metrics.FederationMetrics),
+			)
+		}
+
+		queryRangeMiddleware = append(queryRangeMiddleware,
 			NewLimitsMiddleware(limits),
 			NewQuerySizeLimiterMiddleware(schema.Configs, engineOpts, log, limits, statsHandler),
-		}
+		)
 
 		if cfg.ShardedQueries {
 			queryRangeMiddleware = append(queryRangeMiddleware,
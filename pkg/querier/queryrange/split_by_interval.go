@@ -204,7 +204,10 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 		return h.next.Do(ctx, intervals[0])
 	}
 
-	var limit int64
+	var (
+		limit   int64
+		ordered *LokiRequest
+	)
 	switch req := r.(type) {
 	case *LokiRequest:
 		limit = int64(req.Limit)
@@ -213,6 +216,17 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 				intervals[i], intervals[j] = intervals[j], intervals[i]
 			}
 		}
+		if expr, ok := orderedLogSelector(req.Query); ok {
+			// the first log lines of the sort and tail stages can be in any interval, so all the intervals are queried.
+			sortExpr, limitExpr := syntax.LogOrdering(expr)
+			switch {
+			case sortExpr != nil || limitExpr.Op == syntax.OpTail:
+				limit = 0
+			case int64(limitExpr.Limit) < limit:
+				limit = int64(limitExpr.Limit)
+			}
+			ordered = req
+		}
 	case *LokiSeriesRequest, *LokiLabelNamesRequest, *logproto.IndexStatsRequest, *logproto.VolumeRequest:
 		// Set this to 0 since this is not used in Series/Labels/Index Request.
 		limit = 0
@@ -235,6 +249,9 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 	if err != nil {
 		return nil, err
 	}
+	if ordered != nil {
+		return mergeLokiResponseFor(ordered, resps...), nil
+	}
 	return h.merger.MergeResponse(resps...)
 }
 
@@ -320,15 +337,34 @@ func maxRangeVectorAndOffsetDuration(q string) (time.Duration, time.Duration, er
 
 	var maxRVDuration, maxOffset time.Duration
 	expr.Walk(func(e interface{}) {
-		if r, ok := e.(*syntax.LogRange); ok {
-			if r.Interval > maxRVDuration {
-				maxRVDuration = r.Interval
+		switch e := e.(type) {
+		case *syntax.LogRange:
+			if e.Interval > maxRVDuration {
+				maxRVDuration = e.Interval
 			}
-			if r.Offset > maxOffset {
-				maxOffset = r.Offset
+			if e.Offset > maxOffset {
+				maxOffset = e.Offset
+			}
+		case *syntax.SubqueryExpr:
+			// the range vectors of a subquery are extended by the range and the offset of the subquery.
+			groups, gErr := e.MatcherGroups()
+			if gErr != nil {
+				err = gErr
+				return
+			}
+			for _, g := range groups {
+				if g.Interval > maxRVDuration {
+					maxRVDuration = g.Interval
+				}
+				if g.Offset > maxOffset {
+					maxOffset = g.Offset
+				}
 			}
 		}
 	})
+	if err != nil {
+		return 0, 0, err
+	}
 	return maxRVDuration, maxOffset, nil
 }
 
@@ -784,6 +784,74 @@ func Test_splitByInterval_Do(t *testing.T) {
 	}
 }
 
+func Test_splitByInterval_DoOrdered(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	latencies := []int{5, 30, 10, 20}
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		start := r.(*LokiRequest).StartTs
+		latency := latencies[start.UnixNano()/time.Hour.Nanoseconds()]
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: r.(*LokiRequest).Direction,
+			Limit:     r.(*LokiRequest).Limit,
+			Version:   uint32(loghttp.VersionV1),
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{
+					{
+						Labels: fmt.Sprintf(`{foo="bar", latency="%d"}`, latency),
+						Entries: []logproto.Entry{
+							{Timestamp: start, Line: fmt.Sprintf("latency=%d", latency)},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour)
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		splitByTime,
+		nilMetrics,
+	).Wrap(next)
+
+	for _, tc := range []struct {
+		query    string
+		expected []int
+	}{
+		// all the splits are queried, the first log lines by timestamp are not the first ones by latency.
+		{`{foo="bar"} | logfmt | sort by latency desc | head 2`, []int{30, 20}},
+		{`{foo="bar"} | logfmt | sort by latency desc | tail 2`, []int{10, 5}},
+		{`{foo="bar"} | logfmt | sort by latency`, []int{5, 10, 20, 30}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			res, err := split.Do(ctx, &LokiRequest{
+				StartTs:   time.Unix(0, 0),
+				EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+				Query:     tc.query,
+				Limit:     1000,
+				Step:      1,
+				Direction: logproto.BACKWARD,
+				Path:      "/api/prom/query_range",
+			})
+			require.NoError(t, err)
+
+			var got []int
+			for _, stream := range res.(*LokiResponse).Data.Result {
+				var latency int
+				_, err := fmt.Sscanf(stream.Entries[0].Line, "latency=%d", &latency)
+				require.NoError(t, err)
+				got = append(got, latency)
+			}
+			require.Equal(t, tc.expected, got)
+			require.Equal(t, int64(4), res.(*LokiResponse).Statistics.Summary.Splits)
+		})
+	}
+}
+
 func Test_series_splitByInterval_Do(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
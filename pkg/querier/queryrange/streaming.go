@@ -0,0 +1,467 @@
+package queryrange
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util/httpreq"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+const (
+	// maxStreamBatchSize is the maximum size in bytes of a batch of a response written by WriteStreamBatches.
+	maxStreamBatchSize = 512 << 20
+	// streamedFlushSize is the number of entries written to the client at once by the streaming round tripper.
+	streamedFlushSize = 100
+)
+
+type streamingRoundTripper struct {
+	logger     log.Logger
+	next       http.RoundTripper
+	limits     Limits
+	configs    []config.PeriodConfig
+	middleware queryrangebase.Middleware
+}
+
+// NewStreamingRoundTripper returns a round tripper executing log queries without buffering their responses.
+// The queries are split by interval, and the responses of the sub-queries are streamed by the queriers in batches.
+// Up to the max query parallelism sub-queries are executed at once, their entries are merged in the direction
+// of the query as they are received and written to the client as chunked JSON until the limit of the query is reached.
+// Reading the responses only as fast as the client reads the merged entries applies backpressure to the queriers.
+// The middlewares are applied to the queries before they are split, they must not execute them.
+func NewStreamingRoundTripper(logger log.Logger, next http.RoundTripper, limits Limits, configs []config.PeriodConfig, middlewares ...queryrangebase.Middleware) http.RoundTripper {
+	return streamingRoundTripper{
+		logger:     logger,
+		next:       next,
+		limits:     limits,
+		configs:    configs,
+		middleware: queryrangebase.MergeMiddlewares(middlewares...),
+	}
+}
+
+func (rt streamingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	decoded, err := DefaultCodec.DecodeRequest(ctx, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var req *LokiRequest
+	resp, err := rt.middleware.Wrap(queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		var ok bool
+		if req, ok = r.(*LokiRequest); !ok {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, "unexpected request type %T for a streamed log query", r)
+		}
+		return nil, nil
+	})).Do(ctx, decoded)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		// The middlewares answered the query without executing it.
+		return DefaultCodec.EncodeResponse(ctx, r, resp)
+	}
+
+	reqs := []queryrangebase.Request{req}
+	if interval := validation.MaxDurationOrZeroPerTenant(tenantIDs, rt.limits.QuerySplitDuration); interval > 0 {
+		split, err := splitByTime(req, interval)
+		if err != nil {
+			return nil, err
+		}
+		if len(split) > 0 {
+			reqs = split
+		}
+	}
+	if req.Direction == logproto.BACKWARD {
+		for i, j := 0, len(reqs)-1; i < j; i, j = i+1, j-1 {
+			reqs[i], reqs[j] = reqs[j], reqs[i]
+		}
+	}
+	parallelism := MinWeightedParallelism(ctx, tenantIDs, rt.configs, rt.limits, model.Time(req.GetStart()), model.Time(req.GetEnd()))
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q := &streamedQuery{
+		ctx:         ctx,
+		next:        rt.next,
+		direction:   req.Direction,
+		reqs:        reqs,
+		parallelism: parallelism,
+	}
+	// The first response is awaited to return its error with its status code, the following errors interrupt the response.
+	it, err := q.iterator()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer cancel()
+		err := q.write(pw, it, req.Limit)
+		if err != nil && ctx.Err() == nil {
+			level.Error(util_log.WithContext(ctx, rt.logger)).Log("msg", "failed to stream log query response", "err", err)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{JSONType}},
+		Body:          &streamedResponseBody{PipeReader: pr, cancel: cancel},
+		ContentLength: -1,
+	}, nil
+}
+
+type streamedResponseBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+// Close cancels the sub-queries still in flight.
+func (b *streamedResponseBody) Close() error {
+	b.cancel()
+	return b.PipeReader.Close()
+}
+
+// streamedQuery executes the sub-queries of a streamed log query.
+type streamedQuery struct {
+	ctx         context.Context
+	next        http.RoundTripper
+	direction   logproto.Direction
+	reqs        []queryrangebase.Request
+	parallelism int
+
+	mtx   sync.Mutex
+	stats stats.Result
+}
+
+// iterator returns an iterator over the entries of all the sub-queries in the direction of the query.
+// The sub-queries are executed by windows of parallelism sub-queries, whose entries are merged by a heap iterator.
+// The sub-queries of the next window are started when the current window is first read.
+func (q *streamedQuery) iterator() (iter.EntryIterator, error) {
+	var windows []*windowIterator
+	for start := 0; start < len(q.reqs); start += q.parallelism {
+		end := start + q.parallelism
+		if end > len(q.reqs) {
+			end = len(q.reqs)
+		}
+		windows = append(windows, &windowIterator{q: q, reqs: q.reqs[start:end], done: make(chan struct{})})
+	}
+	for i := 0; i < len(windows)-1; i++ {
+		windows[i].next = windows[i+1]
+	}
+
+	// The first window is awaited so that the errors of the first responses are returned with their status code.
+	first := windows[0]
+	first.start()
+	<-first.done
+	if first.err != nil {
+		return nil, first.err
+	}
+	return &chainedWindowsIterator{windows: windows}, nil
+}
+
+// window starts the sub-queries and merges their entries.
+func (q *streamedQuery) window(reqs []queryrangebase.Request) (iter.EntryIterator, error) {
+	its := make([]iter.EntryIterator, 0, len(reqs))
+	for _, req := range reqs {
+		it, err := q.execute(req)
+		if err != nil {
+			for _, it := range its {
+				_ = it.Close()
+			}
+			return nil, err
+		}
+		its = append(its, it)
+	}
+	return iter.NewMergeEntryIterator(q.ctx, its, q.direction), nil
+}
+
+func (q *streamedQuery) mergeStats(s stats.Result) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.stats.Merge(s)
+}
+
+// execute starts the sub-query and returns an iterator over its entries as they are received.
+func (q *streamedQuery) execute(req queryrangebase.Request) (iter.EntryIterator, error) {
+	httpReq, err := DefaultCodec.EncodeRequest(q.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.InjectOrgIDIntoHTTPRequest(q.ctx, httpReq); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	httpReq.Header.Set("Accept", StreamBatchesType)
+	httpReq.Header.Set(httpreq.LokiResponseStreamingHeader, "true")
+
+	resp, err := q.next.RoundTrip(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 || resp.Header.Get("Content-Type") != StreamBatchesType {
+		// Errors, and the responses of queriers that don't stream log queries, are decoded as a whole.
+		defer resp.Body.Close()
+		decoded, err := DefaultCodec.DecodeResponse(q.ctx, resp, req)
+		if err != nil {
+			return nil, err
+		}
+		lokiResp, ok := decoded.(*LokiResponse)
+		if !ok {
+			return nil, fmt.Errorf("unexpected response type %T for a log query", decoded)
+		}
+		q.mergeStats(lokiResp.Statistics)
+		return iter.NewStreamsIterator(lokiResp.Data.Result, q.direction), nil
+	}
+	return newStreamBatchesIterator(resp.Body, q.direction, q.mergeStats), nil
+}
+
+// write writes the entries of the iterator to w as the JSON of a streams result, up to the limit.
+func (q *streamedQuery) write(w io.Writer, it iter.EntryIterator, limit uint32) error {
+	defer it.Close()
+
+	var (
+		jw      = marshal.NewStreamsResponseWriter(w)
+		batch   logqlmodel.Streams
+		indexes = map[string]int{}
+		size    int
+		written uint32
+	)
+	flush := func() error {
+		if err := jw.WriteStreams(batch); err != nil {
+			return err
+		}
+		batch, size = batch[:0], 0
+		for k := range indexes {
+			delete(indexes, k)
+		}
+		return nil
+	}
+
+	for (limit == 0 || written < limit) && it.Next() {
+		i, ok := indexes[it.Labels()]
+		if !ok {
+			i = len(batch)
+			indexes[it.Labels()] = i
+			batch = append(batch, logproto.Stream{Labels: it.Labels()})
+		}
+		batch[i].Entries = append(batch[i].Entries, it.Entry())
+		written++
+
+		if size++; size == streamedFlushSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := q.ctx.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.stats.Summary.TotalEntriesReturned = int64(written)
+	return jw.Close(q.stats)
+}
+
+// windowIterator iterates over the merged entries of a window of sub-queries.
+type windowIterator struct {
+	q    *streamedQuery
+	reqs []queryrangebase.Request
+	next *windowIterator
+
+	once sync.Once
+	done chan struct{}
+	it   iter.EntryIterator
+	err  error
+}
+
+// start starts the sub-queries of the window in the background.
+func (w *windowIterator) start() {
+	w.once.Do(func() {
+		go func() {
+			defer close(w.done)
+			w.it, w.err = w.q.window(w.reqs)
+		}()
+	})
+}
+
+// wait waits for the sub-queries of the window to be started, and starts those of the next window.
+func (w *windowIterator) wait() error {
+	w.start()
+	select {
+	case <-w.done:
+	case <-w.q.ctx.Done():
+		return w.q.ctx.Err()
+	}
+	if w.next != nil {
+		w.next.start()
+	}
+	return w.err
+}
+
+// close closes the iterator of the window once its sub-queries are started, if they were.
+func (w *windowIterator) close() {
+	started := true
+	w.once.Do(func() { started = false })
+	if !started {
+		return
+	}
+	go func() {
+		<-w.done
+		if w.it != nil {
+			_ = w.it.Close()
+		}
+	}()
+}
+
+// chainedWindowsIterator iterates over the entries of the windows one after the other.
+type chainedWindowsIterator struct {
+	windows []*windowIterator
+	curr    iter.EntryIterator
+	err     error
+}
+
+func (i *chainedWindowsIterator) Next() bool {
+	for i.err == nil {
+		if i.curr != nil {
+			if i.curr.Next() {
+				return true
+			}
+			if i.err = i.curr.Error(); i.err != nil {
+				return false
+			}
+			i.windows[0].close()
+			i.windows, i.curr = i.windows[1:], nil
+		}
+		if len(i.windows) == 0 {
+			return false
+		}
+		if i.err = i.windows[0].wait(); i.err != nil {
+			return false
+		}
+		i.curr = i.windows[0].it
+	}
+	return false
+}
+
+func (i *chainedWindowsIterator) Entry() logproto.Entry { return i.curr.Entry() }
+func (i *chainedWindowsIterator) Labels() string        { return i.curr.Labels() }
+func (i *chainedWindowsIterator) StreamHash() uint64    { return i.curr.StreamHash() }
+func (i *chainedWindowsIterator) Error() error          { return i.err }
+
+func (i *chainedWindowsIterator) Close() error {
+	for _, w := range i.windows {
+		w.close()
+	}
+	i.windows = nil
+	return nil
+}
+
+// streamBatchesIterator iterates over the entries of a response written by WriteStreamBatches as it is read.
+type streamBatchesIterator struct {
+	body      io.ReadCloser
+	r         *bufio.Reader
+	direction logproto.Direction
+	stats     func(stats.Result)
+
+	buf  []byte
+	curr iter.EntryIterator
+	err  error
+}
+
+func newStreamBatchesIterator(body io.ReadCloser, direction logproto.Direction, stats func(stats.Result)) *streamBatchesIterator {
+	return &streamBatchesIterator{
+		body:      body,
+		r:         bufio.NewReader(body),
+		direction: direction,
+		stats:     stats,
+	}
+}
+
+func (i *streamBatchesIterator) Next() bool {
+	for i.err == nil {
+		if i.curr != nil && i.curr.Next() {
+			return true
+		}
+
+		batch, err := i.readBatch()
+		if err != nil {
+			if err != io.EOF {
+				i.err = err
+			}
+			return false
+		}
+		i.stats(batch.Statistics)
+		// The entries of a batch are ordered, but grouped by stream.
+		i.curr = iter.NewStreamsIterator(batch.Data.Result, i.direction)
+	}
+	return false
+}
+
+func (i *streamBatchesIterator) readBatch() (*LokiResponse, error) {
+	size, err := binary.ReadUvarint(i.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxStreamBatchSize {
+		return nil, fmt.Errorf("stream batch of %d bytes exceeds the limit of %d bytes", size, maxStreamBatchSize)
+	}
+	if cap(i.buf) < int(size) {
+		i.buf = make([]byte, size)
+	}
+	i.buf = i.buf[:size]
+	if _, err := io.ReadFull(i.r, i.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	var batch LokiResponse
+	if err := batch.Unmarshal(i.buf); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (i *streamBatchesIterator) Entry() logproto.Entry { return i.curr.Entry() }
+func (i *streamBatchesIterator) Labels() string        { return i.curr.Labels() }
+func (i *streamBatchesIterator) StreamHash() uint64    { return i.curr.StreamHash() }
+func (i *streamBatchesIterator) Error() error          { return i.err }
+
+func (i *streamBatchesIterator) Close() error {
+	return i.body.Close()
+}
@@ -0,0 +1,254 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/pkg/util/httpreq"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// streamingTestStreams returns two streams with an entry every minute between start and end, in the direction.
+func streamingTestStreams(start, end time.Time, direction logproto.Direction) logqlmodel.Streams {
+	streams := logqlmodel.Streams{{Labels: `{app="a"}`}, {Labels: `{app="b"}`}}
+	for ts := start; ts.Before(end); ts = ts.Add(time.Minute) {
+		for i := range streams {
+			streams[i].Entries = append(streams[i].Entries, logproto.Entry{Timestamp: ts, Line: fmt.Sprintf("%s %d", streams[i].Labels, ts.Unix())})
+		}
+	}
+	if direction == logproto.BACKWARD {
+		for _, s := range streams {
+			for i, j := 0, len(s.Entries)-1; i < j; i, j = i+1, j-1 {
+				s.Entries[i], s.Entries[j] = s.Entries[j], s.Entries[i]
+			}
+		}
+	}
+	return streams
+}
+
+func TestWriteStreamBatches(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	for _, direction := range []logproto.Direction{logproto.FORWARD, logproto.BACKWARD} {
+		t.Run(direction.String(), func(t *testing.T) {
+			streams := streamingTestStreams(start, start.Add(24*time.Hour), direction)
+			params, err := paramsFromRequest(&LokiRequest{Direction: direction})
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, WriteStreamBatches(params, logqlmodel.Result{
+				Data:       streams,
+				Statistics: stats.Result{Summary: stats.Summary{Splits: 42}},
+			}, &buf))
+
+			var result stats.Result
+			it := newStreamBatchesIterator(io.NopCloser(&buf), direction, result.Merge)
+			var count int
+			var prev time.Time
+			for it.Next() {
+				ts := it.Entry().Timestamp
+				if count > 0 {
+					if direction == logproto.FORWARD {
+						require.False(t, ts.Before(prev))
+					} else {
+						require.False(t, ts.After(prev))
+					}
+				}
+				require.Equal(t, fmt.Sprintf("%s %d", it.Labels(), ts.Unix()), it.Entry().Line)
+				prev = ts
+				count++
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+			require.Equal(t, 2*24*60, count)
+			require.Equal(t, int64(42), result.Summary.Splits)
+		})
+	}
+}
+
+func TestStreamBatchesIterator_Truncated(t *testing.T) {
+	params, err := paramsFromRequest(&LokiRequest{Direction: logproto.FORWARD})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteStreamBatches(params, logqlmodel.Result{Data: streamingTestStreams(time.Unix(0, 0), time.Unix(3600, 0), logproto.FORWARD)}, &buf))
+
+	it := newStreamBatchesIterator(io.NopCloser(bytes.NewReader(buf.Bytes()[:buf.Len()/2])), logproto.FORWARD, func(stats.Result) {})
+	for it.Next() {
+	}
+	require.ErrorIs(t, it.Error(), io.ErrUnexpectedEOF)
+}
+
+// streamingTestQuerier answers the log queries with the entries of streamingTestStreams in batches.
+type streamingTestQuerier struct {
+	mtx      sync.Mutex
+	requests []*LokiRequest
+	fail     bool
+}
+
+func (q *streamingTestQuerier) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get(httpreq.LokiResponseStreamingHeader) == "" || r.Header.Get("Accept") != StreamBatchesType {
+		return nil, fmt.Errorf("the response isn't requested to be streamed")
+	}
+	if _, _, err := user.ExtractOrgIDFromHTTPRequest(r); err != nil {
+		return nil, err
+	}
+	decoded, err := DefaultCodec.DecodeRequest(r.Context(), r, nil)
+	if err != nil {
+		return nil, err
+	}
+	req := decoded.(*LokiRequest)
+	q.mtx.Lock()
+	q.requests = append(q.requests, req)
+	q.mtx.Unlock()
+
+	if q.fail {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(bytes.NewBufferString("bad request")),
+		}, nil
+	}
+
+	params, err := paramsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := WriteStreamBatches(params, logqlmodel.Result{
+		Data:       streamingTestStreams(req.StartTs, req.EndTs, req.Direction),
+		Statistics: stats.Result{Summary: stats.Summary{Splits: 1}},
+	}, &buf); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{StreamBatchesType}},
+		Body:       io.NopCloser(&buf),
+	}, nil
+}
+
+func TestStreamingRoundTripper(t *testing.T) {
+	start := time.Unix(0, 0).UTC()
+	end := start.Add(6 * time.Hour)
+	limits := fakeLimits{maxQueryParallelism: 2, splits: map[string]time.Duration{"1": time.Hour}}
+
+	for _, tc := range []struct {
+		direction logproto.Direction
+		limit     uint32
+	}{
+		{logproto.FORWARD, 1000},
+		{logproto.BACKWARD, 1000},
+		{logproto.FORWARD, 150},
+		{logproto.BACKWARD, 150},
+	} {
+		t.Run(fmt.Sprintf("%s_%d", tc.direction, tc.limit), func(t *testing.T) {
+			querier := &streamingTestQuerier{}
+			rt := NewStreamingRoundTripper(util_log.Logger, querier, limits, testSchemas)
+
+			ctx := user.InjectOrgID(context.Background(), "1")
+			req, err := DefaultCodec.EncodeRequest(ctx, &LokiRequest{
+				Query:     `{app=~"a|b"}`,
+				Limit:     tc.limit,
+				StartTs:   start,
+				EndTs:     end,
+				Direction: tc.direction,
+				Path:      "/loki/api/v1/query_range",
+			})
+			require.NoError(t, err)
+			resp, err := rt.RoundTrip(req.WithContext(ctx))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, int64(-1), resp.ContentLength)
+
+			decoded, err := DefaultCodec.DecodeResponse(ctx, resp, &LokiRequest{Direction: tc.direction, Limit: tc.limit})
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			lokiResp := decoded.(*LokiResponse)
+
+			// The entries are the first ones in the direction of the query, a stream may be written in several parts.
+			count := int(tc.limit)
+			if count > 2*6*60 {
+				count = 2 * 6 * 60
+			}
+			expectedStart, expectedEnd := start, start.Add(time.Duration(count/2)*time.Minute)
+			if tc.direction == logproto.BACKWARD {
+				expectedStart, expectedEnd = end.Add(-time.Duration(count/2)*time.Minute), end
+			}
+			actual := map[string][]logproto.Entry{}
+			for _, s := range lokiResp.Data.Result {
+				actual[s.Labels] = append(actual[s.Labels], s.Entries...)
+			}
+			expected := map[string][]logproto.Entry{}
+			for _, s := range streamingTestStreams(expectedStart, expectedEnd, tc.direction) {
+				expected[s.Labels] = s.Entries
+			}
+			require.Equal(t, expected, actual)
+			require.Equal(t, int64(count), lokiResp.Statistics.Summary.TotalEntriesReturned)
+
+			// The queries are split by hour, and are only executed when needed.
+			querier.mtx.Lock()
+			defer querier.mtx.Unlock()
+			require.LessOrEqual(t, len(querier.requests), 6)
+			if count < int(tc.limit) {
+				require.Len(t, querier.requests, 6)
+				require.Equal(t, int64(6), lokiResp.Statistics.Summary.Splits)
+			}
+			for _, r := range querier.requests {
+				require.Equal(t, time.Hour, r.EndTs.Sub(r.StartTs))
+			}
+		})
+	}
+}
+
+func TestStreamingRoundTripper_Error(t *testing.T) {
+	rt := NewStreamingRoundTripper(util_log.Logger, &streamingTestQuerier{fail: true}, fakeLimits{maxQueryParallelism: 2}, testSchemas)
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+	req, err := DefaultCodec.EncodeRequest(ctx, &LokiRequest{
+		Query:     `{app="a"}`,
+		Limit:     100,
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(3600, 0),
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+	})
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req.WithContext(ctx))
+	require.Error(t, err)
+}
+
+func TestStreamingRoundTripper_Middlewares(t *testing.T) {
+	querier := &streamingTestQuerier{}
+	empty := queryrangebase.MiddlewareFunc(func(next queryrangebase.Handler) queryrangebase.Handler {
+		return queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+			return NewEmptyResponse(r)
+		})
+	})
+	rt := NewStreamingRoundTripper(util_log.Logger, querier, fakeLimits{maxQueryParallelism: 2}, testSchemas, empty)
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+	req, err := DefaultCodec.EncodeRequest(ctx, &LokiRequest{
+		Query:     `{app="a"}`,
+		Limit:     100,
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(3600, 0),
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+	})
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req.WithContext(ctx))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, querier.requests)
+}
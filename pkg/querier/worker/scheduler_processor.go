@@ -167,6 +167,11 @@ func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger,
 		stats, ctx = querier_stats.ContextWithEmptyStats(ctx)
 	}
 
+	if handler, ok := sp.handler.(StreamingRequestHandler); ok && streamResponse(request) {
+		sp.runStreamingRequest(ctx, logger, handler, queryID, frontendAddress, stats, request)
+		return
+	}
+
 	response, err := sp.handler.Handle(ctx, request)
 	if err != nil {
 		var ok bool
@@ -212,6 +217,47 @@ func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger,
 	)
 }
 
+// runStreamingRequest runs the request, streaming the response to the frontend as it is written by the handler.
+// The query is canceled if the frontend stops reading the response.
+func (sp *schedulerProcessor) runStreamingRequest(ctx context.Context, logger log.Logger, handler StreamingRequestHandler, queryID uint64, frontendAddress string, stats *querier_stats.Stats, request *httpgrpc.HTTPRequest) {
+	logger = log.With(logger, "frontend", frontendAddress)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The stream can only be retried until the response is being sent.
+	var stream frontendv2pb.FrontendForQuerier_QueryResultStreamClient
+	runPoolWithBackoff(
+		ctx,
+		logger,
+		sp.frontendPool,
+		frontendAddress,
+		func(c client.PoolClient) error {
+			var err error
+			stream, err = c.(frontendv2pb.FrontendForQuerierClient).QueryResultStream(ctx)
+			if err != nil {
+				level.Error(logger).Log("msg", "error streaming query result to frontend", "err", err)
+			}
+			return err
+		},
+	)
+	if stream == nil {
+		return
+	}
+
+	w := newStreamResponseWriter(stream, queryID, cancel)
+	handler.HandleStream(ctx, request, w)
+	w.Flush()
+	if stats != nil {
+		w.send(&frontendv2pb.QueryResultStreamRequest{QueryID: queryID, Data: &frontendv2pb.QueryResultStreamRequest_Stats{Stats: stats}})
+	}
+
+	// Send only fails with io.EOF if the frontend closed the stream, the actual error is returned by CloseAndRecv.
+	if _, err := stream.CloseAndRecv(); err != nil {
+		level.Error(logger).Log("msg", "error streaming query result to frontend", "err", err)
+	}
+}
+
 var defaultBackoff = backoff.Config{
 	MinBackoff: 100 * time.Millisecond,
 	MaxBackoff: 10 * time.Second,
@@ -265,7 +311,11 @@ func (sp *schedulerProcessor) createFrontendClient(addr string) (client.PoolClie
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor,
 		middleware.UnaryClientInstrumentInterceptor(sp.metrics.frontendClientRequestDuration),
-	}, nil)
+	}, []grpc.StreamClientInterceptor{
+		otgrpc.OpenTracingStreamClientInterceptor(opentracing.GlobalTracer()),
+		middleware.StreamClientUserHeaderInterceptor,
+		middleware.StreamClientInstrumentInterceptor(sp.metrics.frontendClientRequestDuration),
+	})
 	if err != nil {
 		return nil, err
 	}
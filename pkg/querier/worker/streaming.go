@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/httpgrpc/server"
+
+	"github.com/grafana/loki/pkg/lokifrontend/frontend/v2/frontendv2pb"
+	"github.com/grafana/loki/pkg/util/httpreq"
+)
+
+// streamedBodyChunkSize is the size of the chunks of the bodies of the responses streamed to the query-frontend.
+const streamedBodyChunkSize = 64 * 1024
+
+// StreamingRequestHandler is a RequestHandler that can also write the responses as they are produced,
+// so that they can be streamed to the query-frontend.
+type StreamingRequestHandler interface {
+	RequestHandler
+	// HandleStream handles the request like Handle, writing the response to w.
+	HandleStream(ctx context.Context, req *httpgrpc.HTTPRequest, w http.ResponseWriter)
+}
+
+// NewHTTPRequestHandler returns a StreamingRequestHandler serving the requests with the HTTP handler.
+func NewHTTPRequestHandler(handler http.Handler) StreamingRequestHandler {
+	return &httpRequestHandler{Server: server.NewServer(handler), handler: handler}
+}
+
+type httpRequestHandler struct {
+	*server.Server
+	handler http.Handler
+}
+
+func (h *httpRequestHandler) HandleStream(ctx context.Context, r *httpgrpc.HTTPRequest, w http.ResponseWriter) {
+	req, err := http.NewRequest(r.Method, r.Url, bytes.NewReader(r.Body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, h := range r.Headers {
+		req.Header[h.Key] = h.Values
+	}
+	req = req.WithContext(ctx)
+	req.RequestURI = r.Url
+	req.ContentLength = int64(len(r.Body))
+
+	h.handler.ServeHTTP(w, req)
+}
+
+// streamResponse tells whether the query-frontend asked for the response of the request to be streamed.
+func streamResponse(req *httpgrpc.HTTPRequest) bool {
+	for _, h := range req.Headers {
+		if http.CanonicalHeaderKey(h.Key) == httpreq.LokiResponseStreamingHeader && len(h.Values) > 0 && h.Values[0] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// streamResponseWriter sends the response written by a handler to the query-frontend in chunks.
+// Writes block while the query-frontend doesn't read the response, and fail once the stream is broken.
+type streamResponseWriter struct {
+	stream  frontendv2pb.FrontendForQuerier_QueryResultStreamClient
+	queryID uint64
+	// cancel cancels the query once the response can't be sent anymore.
+	cancel context.CancelFunc
+
+	header       http.Header
+	code         int
+	sentMetadata bool
+	buf          []byte
+	err          error
+}
+
+func newStreamResponseWriter(stream frontendv2pb.FrontendForQuerier_QueryResultStreamClient, queryID uint64, cancel context.CancelFunc) *streamResponseWriter {
+	return &streamResponseWriter{
+		stream:  stream,
+		queryID: queryID,
+		cancel:  cancel,
+		header:  http.Header{},
+	}
+}
+
+func (w *streamResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *streamResponseWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= streamedBodyChunkSize {
+		w.Flush()
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// Flush sends the status code and headers of the response if they weren't sent yet, and what is buffered of its body.
+func (w *streamResponseWriter) Flush() {
+	if w.err != nil {
+		return
+	}
+	if !w.sentMetadata {
+		w.WriteHeader(http.StatusOK)
+		w.sentMetadata = true
+		w.send(&frontendv2pb.QueryResultStreamRequest{QueryID: w.queryID, Data: &frontendv2pb.QueryResultStreamRequest_Metadata{
+			Metadata: &httpgrpc.HTTPResponse{
+				Code:    int32(w.code),
+				Headers: toHeaders(w.header),
+			},
+		}})
+	}
+
+	for start := 0; start < len(w.buf); start += streamedBodyChunkSize {
+		end := start + streamedBodyChunkSize
+		if end > len(w.buf) {
+			end = len(w.buf)
+		}
+		// The chunk is marshalled by Send, so the buffer can be reused afterwards.
+		w.send(&frontendv2pb.QueryResultStreamRequest{QueryID: w.queryID, Data: &frontendv2pb.QueryResultStreamRequest_Body{
+			Body: w.buf[start:end],
+		}})
+	}
+	w.buf = w.buf[:0]
+}
+
+func (w *streamResponseWriter) send(msg *frontendv2pb.QueryResultStreamRequest) {
+	if w.err != nil {
+		return
+	}
+	if w.err = w.stream.Send(msg); w.err != nil {
+		w.cancel()
+	}
+}
+
+func toHeaders(h http.Header) []*httpgrpc.Header {
+	result := make([]*httpgrpc.Header, 0, len(h))
+	for k, vs := range h {
+		result = append(result, &httpgrpc.Header{Key: k, Values: vs})
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/loki/pkg/lokifrontend/frontend/v2/frontendv2pb"
+	"github.com/grafana/loki/pkg/util/httpreq"
+)
+
+type fakeQueryResultStreamClient struct {
+	grpc.ClientStream
+	msgs []*frontendv2pb.QueryResultStreamRequest
+	err  error
+}
+
+func (c *fakeQueryResultStreamClient) Send(msg *frontendv2pb.QueryResultStreamRequest) error {
+	if c.err != nil {
+		return c.err
+	}
+	// The messages are marshalled when sent.
+	b, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	var sent frontendv2pb.QueryResultStreamRequest
+	if err := sent.Unmarshal(b); err != nil {
+		return err
+	}
+	c.msgs = append(c.msgs, &sent)
+	return nil
+}
+
+func (c *fakeQueryResultStreamClient) CloseAndRecv() (*frontendv2pb.QueryResultResponse, error) {
+	return &frontendv2pb.QueryResultResponse{}, nil
+}
+
+func TestStreamResponseWriter(t *testing.T) {
+	stream := &fakeQueryResultStreamClient{}
+	w := newStreamResponseWriter(stream, 42, func() {})
+
+	body := bytes.Repeat([]byte("0123456789"), streamedBodyChunkSize/10+1)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPartialContent)
+	_, err := w.Write(body[:10])
+	require.NoError(t, err)
+	// Nothing is sent until enough of the body is written.
+	require.Empty(t, stream.msgs)
+
+	_, err = w.Write(body[10:])
+	require.NoError(t, err)
+	w.Flush()
+
+	require.Len(t, stream.msgs, 3)
+	for _, msg := range stream.msgs {
+		require.Equal(t, uint64(42), msg.QueryID)
+	}
+	metadata := stream.msgs[0].GetMetadata()
+	require.NotNil(t, metadata)
+	require.Equal(t, int32(http.StatusPartialContent), metadata.Code)
+	require.Equal(t, []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/json"}}}, metadata.Headers)
+	require.Equal(t, body, append(stream.msgs[1].GetBody(), stream.msgs[2].GetBody()...))
+}
+
+func TestStreamResponseWriter_Error(t *testing.T) {
+	stream := &fakeQueryResultStreamClient{err: errors.New("broken stream")}
+	var canceled bool
+	w := newStreamResponseWriter(stream, 42, func() { canceled = true })
+
+	_, err := w.Write(make([]byte, streamedBodyChunkSize))
+	require.EqualError(t, err, "broken stream")
+	require.True(t, canceled)
+}
+
+func TestStreamResponse(t *testing.T) {
+	require.False(t, streamResponse(&httpgrpc.HTTPRequest{}))
+	require.True(t, streamResponse(&httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{{Key: httpreq.LokiResponseStreamingHeader, Values: []string{"true"}}}}))
+}
+
+func TestHTTPRequestHandler_HandleStream(t *testing.T) {
+	h := NewHTTPRequestHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "bar", r.Header.Get("X-Foo"))
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	stream := &fakeQueryResultStreamClient{}
+	w := newStreamResponseWriter(stream, 1, func() {})
+	h.HandleStream(context.Background(), &httpgrpc.HTTPRequest{
+		Method:  http.MethodGet,
+		Url:     "/loki/api/v1/query_range",
+		Headers: []*httpgrpc.Header{{Key: "X-Foo", Values: []string{"bar"}}},
+	}, w)
+	w.Flush()
+
+	require.Len(t, stream.msgs, 2)
+	require.Equal(t, int32(http.StatusOK), stream.msgs[0].GetMetadata().Code)
+	require.Equal(t, "/loki/api/v1/query_range", string(stream.msgs[1].GetBody()))
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
-	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
 	"github.com/weaveworks/common/middleware"
 
 	querier_worker "github.com/grafana/loki/pkg/querier/worker"
@@ -108,7 +107,7 @@ func InitWorkerService(
 		return querier_worker.NewQuerierWorker(
 			*(cfg.QuerierWorkerConfig),
 			cfg.SchedulerRing,
-			httpgrpc_server.NewServer(externalHandler),
+			querier_worker.NewHTTPRequestHandler(externalHandler),
 			util_log.Logger,
 			reg,
 		)
@@ -144,7 +143,7 @@ func InitWorkerService(
 	return querier_worker.NewQuerierWorker(
 		*(cfg.QuerierWorkerConfig),
 		cfg.SchedulerRing,
-		httpgrpc_server.NewServer(internalHandler),
+		querier_worker.NewHTTPRequestHandler(internalHandler),
 		util_log.Logger,
 		reg,
 	)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -72,6 +73,46 @@ func newWALRegistry(logger log.Logger, reg prometheus.Registerer, config Config,
 	}
 }
 
+// RemoteWriteStorage remote-writes the samples appended for the tenants as the samples of their rules, with the
+// remote-write configuration of the ruler and its per-tenant overrides, outside of the ruler.
+type RemoteWriteStorage struct {
+	registry storageRegistry
+
+	mtx        sync.Mutex
+	configured map[string]struct{}
+}
+
+// NewRemoteWriteStorage returns a RemoteWriteStorage whose write-ahead logs are in the given directory, which must not
+// be the directory of the write-ahead logs of the ruler. Its metrics are registered with the given prefix.
+func NewRemoteWriteStorage(cfg Config, walDir, metricsPrefix string, overrides RulesLimits, logger log.Logger, reg prometheus.Registerer) *RemoteWriteStorage {
+	cfg.WAL.Dir = walDir
+	reg = prometheus.WrapRegistererWithPrefix(metricsPrefix, reg)
+
+	return &RemoteWriteStorage{
+		registry:   newWALRegistry(log.With(logger, "storage", "registry"), reg, cfg, overrides),
+		configured: map[string]struct{}{},
+	}
+}
+
+// Appender returns an appender for the tenant of the context. The samples of a tenant are discarded until the
+// storage of the tenant, configured the first time an appender is requested for it, is ready.
+func (s *RemoteWriteStorage) Appender(ctx context.Context) storage.Appender {
+	if tenant, err := user.ExtractOrgID(ctx); err == nil {
+		s.mtx.Lock()
+		if _, ok := s.configured[tenant]; !ok {
+			s.registry.configureTenantStorage(tenant)
+			s.configured[tenant] = struct{}{}
+		}
+		s.mtx.Unlock()
+	}
+	return s.registry.Appender(ctx)
+}
+
+// Stop stops the storages of the tenants.
+func (s *RemoteWriteStorage) Stop() {
+	s.registry.stop()
+}
+
 func createInstanceManager(logger log.Logger, reg prometheus.Registerer) *instance.BasicManager {
 	tenantManager := &tenantWALManager{
 		reg:    reg,
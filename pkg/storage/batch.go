@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
@@ -313,6 +314,8 @@ type logBatchIterator struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	pipeline syntax.Pipeline
+	// contextLines returns the log lines around the matches of the queries with a context stage, across the batches.
+	contextLines *logql.ContextLines
 }
 
 func newLogBatchIterator(
@@ -323,6 +326,7 @@ func newLogBatchIterator(
 	batchSize int,
 	matchers []*labels.Matcher,
 	pipeline syntax.Pipeline,
+	contextLines *logql.ContextLines,
 	direction logproto.Direction,
 	start, end time.Time,
 	chunkFilterer chunk.Filterer,
@@ -330,6 +334,7 @@ func newLogBatchIterator(
 	ctx, cancel := context.WithCancel(ctx)
 	return &logBatchIterator{
 		pipeline:           pipeline,
+		contextLines:       contextLines,
 		ctx:                ctx,
 		cancel:             cancel,
 		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, direction, start, end, metrics, matchers, chunkFilterer),
@@ -412,11 +417,14 @@ func (it *logBatchIterator) buildIterators(chks map[model.Fingerprint][][]*LazyC
 	result := make([]iter.EntryIterator, 0, len(chks))
 	for _, chunks := range chks {
 		if len(chunks) != 0 && len(chunks[0]) != 0 {
-			streamPipeline := it.pipeline.ForStream(labels.NewBuilder(chunks[0][0].Chunk.Metric).Del(labels.MetricName).Labels())
-			iterator, err := it.buildHeapIterator(chunks, from, through, streamPipeline, nextChunk)
+			streamLabels := labels.NewBuilder(chunks[0][0].Chunk.Metric).Del(labels.MetricName).Labels()
+			iterator, err := it.buildHeapIterator(chunks, from, through, it.pipeline.ForStream(streamLabels), nextChunk)
 			if err != nil {
 				return nil, err
 			}
+			if it.contextLines != nil {
+				iterator = it.contextLines.Iterator(iterator, streamLabels)
+			}
 
 			result = append(result, iterator)
 		}
@@ -0,0 +1,186 @@
+// Package bloom builds a bloom filter of the n-grams of the lines of each chunk
+// when it is flushed, and uses it at query time to skip the chunks which can't
+// contain the strings required by the line filters of the query.
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/willf/bloom"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+const (
+	filterMagic   = "LBF"
+	filterVersion = byte(1)
+)
+
+var (
+	errInvalidFilter  = errors.New("invalid bloom filter")
+	errUnsupportedChk = errors.New("unsupported chunk encoding")
+)
+
+// Config configures the bloom filters of chunks.
+type Config struct {
+	Enabled           bool    `yaml:"enabled"`
+	NGramLength       int     `yaml:"ngram_length"`
+	FalsePositiveRate float64 `yaml:"false_positive_rate"`
+	QueryConcurrency  int     `yaml:"query_concurrency"`
+
+	MaxFetchedFiltersPerQuery int          `yaml:"max_fetched_filters_per_query"`
+	Cache                     cache.Config `yaml:"cache"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Build a bloom filter of the n-grams of the lines of each chunk when it is flushed, and use it to skip the chunks which can't match the line filters of a query. Chunks flushed without a bloom filter are always fetched.")
+	f.IntVar(&cfg.NGramLength, prefix+"ngram-length", 4, "Length in bytes of the n-grams added to the bloom filters. Line filters shorter than this can't be used to skip chunks.")
+	f.Float64Var(&cfg.FalsePositiveRate, prefix+"false-positive-rate", 0.01, "Target false positive rate of the bloom filters.")
+	f.IntVar(&cfg.QueryConcurrency, prefix+"query-concurrency", 16, "Maximum number of bloom filters fetched in parallel by a query.")
+	f.IntVar(&cfg.MaxFetchedFiltersPerQuery, prefix+"max-fetched-filters-per-query", 1000, "Maximum number of bloom filters fetched from the object store by a query, in addition to the ones found in the cache. The chunks whose filters are not fetched are not skipped. 0 to disable.")
+	cfg.Cache.RegisterFlagsWithPrefix(prefix+"cache.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.NGramLength <= 0 || cfg.NGramLength > math.MaxUint8 {
+		return fmt.Errorf("invalid bloom filter n-gram length %d, it must be between 1 and %d", cfg.NGramLength, math.MaxUint8)
+	}
+	if cfg.FalsePositiveRate <= 0 || cfg.FalsePositiveRate >= 1 {
+		return fmt.Errorf("invalid bloom filter false positive rate %v, it must be between 0 and 1", cfg.FalsePositiveRate)
+	}
+	return nil
+}
+
+// Filter is the bloom filter of the n-grams of the lines of a chunk.
+type Filter struct {
+	NGramLength int
+	// CompressedBytes and UncompressedBytes are the sizes of the chunk, used to report the bytes skipped.
+	CompressedBytes   int
+	UncompressedBytes int
+
+	bloom *bloom.BloomFilter
+}
+
+// BuildFilter returns the bloom filter of the chunk.
+func BuildFilter(c chunk.Chunk, ngramLength int, falsePositiveRate float64) (*Filter, error) {
+	facade, ok := c.Data.(*chunkenc.Facade)
+	if !ok {
+		return nil, errUnsupportedChk
+	}
+	lokiChunk := facade.LokiChunk()
+
+	from, through := lokiChunk.Bounds()
+	it, err := lokiChunk.Iterator(context.Background(), from, through.Add(time.Nanosecond), logproto.FORWARD, log.NewNoopPipeline().ForStream(c.Metric))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	ngrams := map[uint64]struct{}{}
+	for it.Next() {
+		line := it.Entry().Line
+		for i := 0; i+ngramLength <= len(line); i++ {
+			ngrams[xxhash.Sum64String(line[i:i+ngramLength])] = struct{}{}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	n := uint(len(ngrams))
+	if n == 0 {
+		n = 1
+	}
+	f := &Filter{
+		NGramLength:       ngramLength,
+		CompressedBytes:   lokiChunk.CompressedSize(),
+		UncompressedBytes: lokiChunk.UncompressedSize(),
+		bloom:             bloom.NewWithEstimates(n, falsePositiveRate),
+	}
+	var key [8]byte
+	for h := range ngrams {
+		binary.LittleEndian.PutUint64(key[:], h)
+		f.bloom.Add(key[:])
+	}
+	return f, nil
+}
+
+// MayContain returns false if none of the lines of the chunk contains s.
+// Strings shorter than the n-gram length are always reported as possibly contained.
+func (f *Filter) MayContain(s string) bool {
+	var key [8]byte
+	for i := 0; i+f.NGramLength <= len(s); i++ {
+		binary.LittleEndian.PutUint64(key[:], xxhash.Sum64String(s[i:i+f.NGramLength]))
+		if !f.bloom.Test(key[:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode serializes the filter.
+func (f *Filter) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(filterMagic)
+	buf.WriteByte(filterVersion)
+	buf.WriteByte(byte(f.NGramLength))
+
+	var sizes [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(sizes[:], uint64(f.CompressedBytes))
+	n += binary.PutUvarint(sizes[n:], uint64(f.UncompressedBytes))
+	buf.Write(sizes[:n])
+
+	if _, err := f.bloom.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeFilter deserializes a filter encoded with Encode.
+func DecodeFilter(b []byte) (*Filter, error) {
+	if len(b) < len(filterMagic)+2 || string(b[:len(filterMagic)]) != filterMagic {
+		return nil, errInvalidFilter
+	}
+	b = b[len(filterMagic):]
+	if b[0] != filterVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", errInvalidFilter, b[0])
+	}
+	f := &Filter{NGramLength: int(b[1])}
+	if f.NGramLength == 0 {
+		return nil, errInvalidFilter
+	}
+
+	r := bytes.NewReader(b[2:])
+	compressed, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidFilter, err)
+	}
+	uncompressed, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidFilter, err)
+	}
+	f.CompressedBytes, f.UncompressedBytes = int(compressed), int(uncompressed)
+
+	f.bloom = &bloom.BloomFilter{}
+	if _, err := f.bloom.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidFilter, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,136 @@
+package bloom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+)
+
+func newChunk(t *testing.T, from time.Time, lines ...string) chunk.Chunk {
+	t.Helper()
+	lbs := labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: "foo"}}
+
+	chk := chunkenc.NewMemChunk(chunkenc.EncSnappy, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+	for i, l := range lines {
+		require.NoError(t, chk.Append(&logproto.Entry{Timestamp: from.Add(time.Duration(i) * time.Second), Line: l}))
+	}
+	require.NoError(t, chk.Close())
+
+	c := chunk.NewChunk("fake", client.Fingerprint(lbs), lbs, chunkenc.NewFacade(chk, 0, 0),
+		model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(from.Add(time.Duration(len(lines))*time.Second).UnixNano()))
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestFilter(t *testing.T) {
+	c := newChunk(t, time.Unix(0, 0),
+		"level=info msg=\"request completed\" path=/api/users",
+		"level=error msg=\"connection refused\" host=db-1",
+	)
+	f, err := BuildFilter(c, 4, 0.001)
+	require.NoError(t, err)
+
+	for _, s := range []string{"connection refused", "/api/users", "db-1", "level=", "ab"} {
+		require.True(t, f.MayContain(s), s)
+	}
+	for _, s := range []string{"timeout", "/api/orders", "db-2"} {
+		require.False(t, f.MayContain(s), s)
+	}
+
+	b, err := f.Encode()
+	require.NoError(t, err)
+	decoded, err := DecodeFilter(b)
+	require.NoError(t, err)
+	require.Equal(t, f, decoded)
+
+	_, err = DecodeFilter([]byte("foo"))
+	require.ErrorIs(t, err, errInvalidFilter)
+	b[len(filterMagic)] = 42
+	_, err = DecodeFilter(b)
+	require.ErrorIs(t, err, errInvalidFilter)
+}
+
+func TestRequiredLineFilters(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected []string
+	}{
+		{`{app="foo"}`, nil},
+		{`{app="foo"} |= "error"`, []string{"error"}},
+		{`{app="foo"} |= "error" |= "db"`, []string{"db", "error"}},
+		{`{app="foo"} != "error" |~ "db.*" |= ip("1.2.3.4")`, nil},
+		{`{app="foo"} |= "error" | json | line_format "{{.msg}}" |= "db"`, []string{"error"}},
+		{`{app="foo"} | logfmt |= "error"`, []string{"error"}},
+		{`{app="foo"} | decolorize |= "error"`, nil},
+		{`{app="foo"} |= "error" | unpack |= "db"`, []string{"error"}},
+		{`{app="foo"} | logfmt | unpack |= "error"`, nil},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, RequiredLineFilters(expr))
+		})
+	}
+}
+
+func TestRequiredLineFiltersUnpack(t *testing.T) {
+	// The packed entries are stored escaped, so the line filters following unpack can't be checked against the blooms.
+	c := newChunk(t, time.Unix(0, 0), `{"_entry":"level=error msg=\"a\"b\"","app":"foo"}`)
+	f, err := BuildFilter(c, 3, 0.001)
+	require.NoError(t, err)
+	require.False(t, f.MayContain(`msg="a"b"`))
+
+	expr, err := syntax.ParseLogSelector(`{app="foo"} |= "level=error" | unpack |= "msg=\"a\"b\""`, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"level=error"}, RequiredLineFilters(expr))
+}
+
+func TestStore(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:   config.DayTime{Time: 0},
+		Schema: "v12",
+	}}}
+	cfg := Config{Enabled: true, NGramLength: 4, FalsePositiveRate: 0.01, QueryConcurrency: 2}
+	store := NewStore(cfg, schemaCfg, objectClient, cache.NewMockCache(), NewMetrics(prometheus.NewRegistry()), log.NewNopLogger())
+
+	ctx := context.Background()
+	withError := newChunk(t, time.Unix(0, 0), "level=error msg=timeout")
+	withoutError := newChunk(t, time.Unix(10, 0), "level=info msg=done")
+	withoutFilter := newChunk(t, time.Unix(20, 0), "level=info msg=done")
+	store.PutFilters(ctx, withError, withoutError)
+
+	chunks := []chunk.Chunk{withError, withoutError, withoutFilter}
+	require.Equal(t, []bool{true, false, true}, store.MayContain(ctx, chunks, []string{"error"}))
+	require.Equal(t, []bool{false, false, true}, store.MayContain(ctx, chunks, []string{"error", "done"}))
+	require.Equal(t, []bool{true, true, true}, store.MayContain(ctx, chunks, []string{"msg="}))
+
+	// The filters, and the chunks without filter, are read from the cache once fetched.
+	require.NoError(t, objectClient.DeleteObject(ctx, store.key(withError.ChunkRef)))
+	require.NoError(t, objectClient.DeleteObject(ctx, store.key(withoutError.ChunkRef)))
+	require.Equal(t, []bool{true, false, true}, store.MayContain(ctx, chunks, []string{"error"}))
+
+	// The chunks whose filters are not fetched are not skipped.
+	cfg.MaxFetchedFiltersPerQuery = 1
+	store = NewStore(cfg, schemaCfg, objectClient, cache.NewNoopCache(), NewMetrics(prometheus.NewRegistry()), log.NewNopLogger())
+	store.PutFilters(ctx, withError, withoutError)
+	require.Equal(t, []bool{true, true}, store.MayContain(ctx, []chunk.Chunk{withError, withoutError}, []string{"error"}))
+	require.Equal(t, []bool{false, true}, store.MayContain(ctx, []chunk.Chunk{withoutError, withError}, []string{"error"}))
+}
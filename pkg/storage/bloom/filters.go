@@ -0,0 +1,38 @@
+package bloom
+
+import (
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// RequiredLineFilters returns the strings that every line selected by the expression contains,
+// that is the matches of the `|=` line filters applied to the lines as stored in the chunks.
+func RequiredLineFilters(expr syntax.LogSelectorExpr) []string {
+	p, ok := expr.(*syntax.PipelineExpr)
+	if !ok {
+		return nil
+	}
+
+	var res []string
+	for _, stage := range p.MultiStages {
+		switch s := stage.(type) {
+		case *syntax.LineFilterExpr:
+			// Chained line filters are all required.
+			for f := s; f != nil; f = f.Left {
+				if f.Ty == labels.MatchEqual && f.Op == "" && f.Match != "" {
+					res = append(res, f.Match)
+				}
+			}
+		case *syntax.LineFmtExpr, *syntax.DecolorizeExpr:
+			// The following line filters apply to the modified line.
+			return res
+		case *syntax.LabelParserExpr:
+			// unpack replaces the line with the unescaped value of the packed entry.
+			if s.Op == syntax.OpParserTypeUnpack {
+				return res
+			}
+		}
+	}
+	return res
+}
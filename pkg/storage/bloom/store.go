@@ -0,0 +1,262 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/stores"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// keyPrefix is the prefix of the object keys of the filters, which are
+// otherwise the same as the keys of their chunks.
+const keyPrefix = "bloom/"
+
+const (
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+type Metrics struct {
+	filtersWritten *prometheus.CounterVec
+	chunksChecked  prometheus.Counter
+	chunksSkipped  prometheus.Counter
+	filtersMissing prometheus.Counter
+	filtersSkipped prometheus.Counter
+	skippedBytes   *prometheus.CounterVec
+}
+
+func NewMetrics(r prometheus.Registerer) *Metrics {
+	return &Metrics{
+		filtersWritten: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "written_total",
+			Help:      "Number of chunk bloom filters built and written at flush time, partitioned by status.",
+		}, []string{"status"}),
+		chunksChecked: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "chunks_checked_total",
+			Help:      "Number of chunks checked against the line filters of a query.",
+		}),
+		chunksSkipped: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "chunks_skipped_total",
+			Help:      "Number of chunks not fetched because their bloom filter rules out the line filters of the query.",
+		}),
+		filtersMissing: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "missing_total",
+			Help:      "Number of chunks checked without a bloom filter, or whose filter could not be read.",
+		}),
+		filtersSkipped: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "not_fetched_total",
+			Help:      "Number of chunks checked without fetching their bloom filter, because the query fetched the maximum number of filters.",
+		}),
+		skippedBytes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "bloom_filter",
+			Name:      "skipped_bytes_total",
+			Help:      "Size of the chunks not fetched because of their bloom filter, partitioned by compressed and uncompressed size.",
+		}, []string{"type"}),
+	}
+}
+
+// Store reads and writes the bloom filters of the chunks of a period from its object store.
+// The filters read, and the chunks without filter, are cached.
+type Store struct {
+	cfg       Config
+	schemaCfg config.SchemaConfig
+	client    client.ObjectClient
+	cache     cache.Cache
+	metrics   *Metrics
+	logger    log.Logger
+}
+
+func NewStore(cfg Config, schemaCfg config.SchemaConfig, client client.ObjectClient, cache cache.Cache, metrics *Metrics, logger log.Logger) *Store {
+	return &Store{
+		cfg:       cfg,
+		schemaCfg: schemaCfg,
+		client:    client,
+		cache:     cache,
+		metrics:   metrics,
+		logger:    logger,
+	}
+}
+
+func (s *Store) key(ref logproto.ChunkRef) string {
+	return keyPrefix + s.schemaCfg.ExternalKey(ref)
+}
+
+// PutFilters builds and writes the filters of the chunks.
+// Failures are only logged: a chunk without a filter is never skipped by queries.
+func (s *Store) PutFilters(ctx context.Context, chunks ...chunk.Chunk) {
+	for _, c := range chunks {
+		if err := s.putFilter(ctx, c); err != nil {
+			s.metrics.filtersWritten.WithLabelValues(statusFailure).Inc()
+			level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to write chunk bloom filter", "chunk", s.schemaCfg.ExternalKey(c.ChunkRef), "err", err)
+			continue
+		}
+		s.metrics.filtersWritten.WithLabelValues(statusSuccess).Inc()
+	}
+}
+
+func (s *Store) putFilter(ctx context.Context, c chunk.Chunk) error {
+	f, err := BuildFilter(c, s.cfg.NGramLength, s.cfg.FalsePositiveRate)
+	if err != nil {
+		return err
+	}
+	b, err := f.Encode()
+	if err != nil {
+		return err
+	}
+	return s.client.PutObject(ctx, s.key(c.ChunkRef), bytes.NewReader(b))
+}
+
+// MayContain returns, for each chunk, whether its lines may contain all the strings.
+// Chunks without a readable filter, or whose filter is neither cached nor among the
+// filters fetched by the query, may always contain them.
+func (s *Store) MayContain(ctx context.Context, chunks []chunk.Chunk, strs []string) []bool {
+	res := make([]bool, len(chunks))
+	keys := make([]string, len(chunks))
+	indexes := make(map[string]int, len(chunks))
+	for i, c := range chunks {
+		res[i] = true
+		keys[i] = s.key(c.ChunkRef)
+		indexes[keys[i]] = i
+	}
+	s.metrics.chunksChecked.Add(float64(len(chunks)))
+
+	check := func(idx int, f *Filter) {
+		if f == nil {
+			s.metrics.filtersMissing.Inc()
+			return
+		}
+		for _, str := range strs {
+			if !f.MayContain(str) {
+				res[idx] = false
+				s.metrics.chunksSkipped.Inc()
+				s.metrics.skippedBytes.WithLabelValues("compressed").Add(float64(f.CompressedBytes))
+				s.metrics.skippedBytes.WithLabelValues("uncompressed").Add(float64(f.UncompressedBytes))
+				return
+			}
+		}
+	}
+
+	found, bufs, missing, err := s.cache.Fetch(ctx, keys)
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to read chunk bloom filters from the cache", "err", err)
+		found, bufs, missing = nil, nil, keys
+	}
+	for i, key := range found {
+		// An empty cached filter is the one of a chunk without filter.
+		if len(bufs[i]) == 0 {
+			check(indexes[key], nil)
+			continue
+		}
+		f, err := DecodeFilter(bufs[i])
+		if err != nil {
+			missing = append(missing, key)
+			continue
+		}
+		check(indexes[key], f)
+	}
+
+	if max := s.cfg.MaxFetchedFiltersPerQuery; max > 0 && len(missing) > max {
+		s.metrics.filtersSkipped.Add(float64(len(missing) - max))
+		missing = missing[:max]
+	}
+
+	fetched := make([][]byte, len(missing))
+	_ = concurrency.ForEachJob(ctx, len(missing), s.cfg.QueryConcurrency, func(ctx context.Context, i int) error {
+		idx := indexes[missing[i]]
+		b, err := s.getFilter(ctx, missing[i])
+		if err != nil {
+			if s.client.IsObjectNotFoundErr(err) {
+				fetched[i] = []byte{}
+			} else {
+				level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to read chunk bloom filter", "chunk", s.schemaCfg.ExternalKey(chunks[idx].ChunkRef), "err", err)
+			}
+			check(idx, nil)
+			return nil
+		}
+		f, err := DecodeFilter(b)
+		if err != nil {
+			level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to decode chunk bloom filter", "chunk", s.schemaCfg.ExternalKey(chunks[idx].ChunkRef), "err", err)
+			check(idx, nil)
+			return nil
+		}
+		fetched[i] = b
+		check(idx, f)
+		return nil
+	})
+
+	var cacheKeys []string
+	var cacheBufs [][]byte
+	for i, b := range fetched {
+		if b != nil {
+			cacheKeys = append(cacheKeys, missing[i])
+			cacheBufs = append(cacheBufs, b)
+		}
+	}
+	if len(cacheKeys) > 0 {
+		if err := s.cache.Store(ctx, cacheKeys, cacheBufs); err != nil {
+			level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to write chunk bloom filters to the cache", "err", err)
+		}
+	}
+	return res
+}
+
+func (s *Store) getFilter(ctx context.Context, key string) ([]byte, error) {
+	r, _, err := s.client.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+type chunkWriter struct {
+	stores.ChunkWriter
+	store *Store
+}
+
+// NewChunkWriter returns a chunk writer which also writes the bloom filters of the chunks written.
+func NewChunkWriter(w stores.ChunkWriter, s *Store) stores.ChunkWriter {
+	return &chunkWriter{ChunkWriter: w, store: s}
+}
+
+func (w *chunkWriter) Put(ctx context.Context, chunks []chunk.Chunk) error {
+	if err := w.ChunkWriter.Put(ctx, chunks); err != nil {
+		return err
+	}
+	w.store.PutFilters(ctx, chunks...)
+	return nil
+}
+
+func (w *chunkWriter) PutOne(ctx context.Context, from, through model.Time, c chunk.Chunk) error {
+	if err := w.ChunkWriter.PutOne(ctx, from, through, c); err != nil {
+		return err
+	}
+	w.store.PutFilters(ctx, c)
+	return nil
+}
@@ -355,7 +355,13 @@ func (c *Chunk) Decode(decodeContext *DecodeContext, input []byte) error {
 		return ErrDataLength
 	}
 
-	return c.Data.UnmarshalFromBuf(remainingData[:int(dataLen)])
+	if err := c.Data.UnmarshalFromBuf(remainingData[:int(dataLen)]); err != nil {
+		return err
+	}
+	if d, ok := c.Data.(TenantData); ok {
+		d.SetTenant(c.UserID)
+	}
+	return nil
 }
 
 func equalByKey(a, b Chunk) bool {
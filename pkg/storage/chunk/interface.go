@@ -59,6 +59,11 @@ type Data interface {
 	Utilization() float64
 }
 
+// TenantData is implemented by the Data which needs the tenant of the chunk to be read.
+type TenantData interface {
+	SetTenant(tenant string)
+}
+
 // RequestChunkFilterer creates ChunkFilterer for a given request context.
 type RequestChunkFilterer interface {
 	ForRequest(ctx context.Context) Filterer
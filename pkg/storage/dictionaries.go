@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+)
+
+// dictionaryKeyPrefix is the prefix of the object keys of the dictionaries of the chunks.
+const dictionaryKeyPrefix = "dictionaries/"
+
+// dictionaryStore stores the zstd dictionaries of the chunks in the object stores of the periods.
+// Dictionaries are written to the object store of the active period, and read from the object
+// stores of all the periods since chunks can reference dictionaries written during an earlier period.
+// The object clients are only created once a dictionary is used.
+type dictionaryStore struct {
+	cfg           Config
+	schemaCfg     config.SchemaConfig
+	clientMetrics ClientMetrics
+
+	once    sync.Once
+	err     error
+	clients []periodObjectClient // newest period first
+}
+
+type periodObjectClient struct {
+	from model.Time
+	client.ObjectClient
+}
+
+func newDictionaryStore(cfg Config, schemaCfg config.SchemaConfig, clientMetrics ClientMetrics) *dictionaryStore {
+	return &dictionaryStore{
+		cfg:           cfg,
+		schemaCfg:     schemaCfg,
+		clientMetrics: clientMetrics,
+	}
+}
+
+func (d *dictionaryStore) init() error {
+	d.once.Do(func() {
+		// Periods sharing the same object store share its client.
+		byStore := map[string]client.ObjectClient{}
+		for _, p := range d.schemaCfg.Configs {
+			objectStoreType := p.ObjectType
+			if objectStoreType == "" {
+				objectStoreType = p.IndexType
+			}
+			c, ok := byStore[objectStoreType]
+			if !ok {
+				var err error
+				c, err = NewObjectClient(objectStoreType, d.cfg, d.clientMetrics)
+				if err != nil {
+					d.err = fmt.Errorf("creating the object client of the chunk dictionaries: %w", err)
+					return
+				}
+				byStore[objectStoreType] = c
+			}
+			d.clients = append(d.clients, periodObjectClient{from: p.From.Time, ObjectClient: c})
+		}
+		sort.Slice(d.clients, func(i, j int) bool { return d.clients[i].from > d.clients[j].from })
+	})
+	return d.err
+}
+
+// dictionaryKey is the key of a dictionary of a tenant.
+// Dictionaries are scoped by tenant as they are trained on the lines of the tenant.
+func dictionaryKey(tenant string, id uint32) string {
+	return fmt.Sprintf("%s%s/%08x", dictionaryKeyPrefix, tenant, id)
+}
+
+func latestDictionaryKey(tenant string) string {
+	return dictionaryKeyPrefix + tenant + "/latest"
+}
+
+// GetDictionary implements chunkenc.DictionaryStore.
+func (d *dictionaryStore) GetDictionary(ctx context.Context, tenant string, id uint32) ([]byte, error) {
+	if err := d.init(); err != nil {
+		return nil, err
+	}
+
+	seen := map[client.ObjectClient]struct{}{}
+	for _, c := range d.clients {
+		if _, ok := seen[c.ObjectClient]; ok {
+			continue
+		}
+		seen[c.ObjectClient] = struct{}{}
+
+		content, err := getObject(ctx, c, dictionaryKey(tenant, id))
+		if err != nil {
+			if c.IsObjectNotFoundErr(err) {
+				continue
+			}
+			return nil, err
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("chunk dictionary %08x of tenant %s not found", id, tenant)
+}
+
+// PutDictionary implements chunkenc.DictionaryStore.
+// It fails if another dictionary of the tenant with the same ID exists.
+func (d *dictionaryStore) PutDictionary(ctx context.Context, tenant string, id uint32, content []byte) error {
+	c, err := d.activeClient()
+	if err != nil {
+		return err
+	}
+
+	existing, err := getObject(ctx, c, dictionaryKey(tenant, id))
+	if err == nil {
+		if !bytes.Equal(existing, content) {
+			return fmt.Errorf("a different chunk dictionary with ID %08x already exists", id)
+		}
+		return nil
+	}
+	if !c.IsObjectNotFoundErr(err) {
+		return err
+	}
+	return c.PutObject(ctx, dictionaryKey(tenant, id), bytes.NewReader(content))
+}
+
+// GetLatestDictionary implements chunkenc.DictionaryStore.
+func (d *dictionaryStore) GetLatestDictionary(ctx context.Context, tenant string) (uint32, time.Time, error) {
+	c, err := d.activeClient()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	b, err := getObject(ctx, c, latestDictionaryKey(tenant))
+	if err != nil {
+		if c.IsObjectNotFoundErr(err) {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, err
+	}
+	var (
+		id        uint32
+		trainedAt int64
+	)
+	if _, err := fmt.Sscanf(string(b), "%08x %d", &id, &trainedAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid latest chunk dictionary of tenant %s: %w", tenant, err)
+	}
+	return id, time.Unix(0, trainedAt), nil
+}
+
+// SetLatestDictionary implements chunkenc.DictionaryStore.
+func (d *dictionaryStore) SetLatestDictionary(ctx context.Context, tenant string, id uint32, trainedAt time.Time) error {
+	c, err := d.activeClient()
+	if err != nil {
+		return err
+	}
+	return c.PutObject(ctx, latestDictionaryKey(tenant), strings.NewReader(fmt.Sprintf("%08x %d", id, trainedAt.UnixNano())))
+}
+
+// activeClient returns the object client of the active period.
+func (d *dictionaryStore) activeClient() (client.ObjectClient, error) {
+	if err := d.init(); err != nil {
+		return nil, err
+	}
+	now := model.Now()
+	for _, c := range d.clients {
+		if c.from <= now {
+			return c.ObjectClient, nil
+		}
+	}
+	return nil, fmt.Errorf("no active period to store the chunk dictionaries")
+}
+
+func (d *dictionaryStore) stop() {
+	// Prevent the creation of the clients if they were never used.
+	d.once.Do(func() {})
+	seen := map[client.ObjectClient]struct{}{}
+	for _, c := range d.clients {
+		if _, ok := seen[c.ObjectClient]; ok {
+			continue
+		}
+		seen[c.ObjectClient] = struct{}{}
+		c.Stop()
+	}
+}
+
+func getObject(ctx context.Context, c client.ObjectClient, key string) ([]byte, error) {
+	r, _, err := c.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+)
+
+func TestDictionaryStore(t *testing.T) {
+	cfg := Config{FSConfig: local.FSConfig{Directory: t.TempDir()}}
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{
+		{From: config.DayTime{Time: 0}, IndexType: config.TSDBType, ObjectType: config.StorageTypeFileSystem},
+		{From: config.DayTime{Time: timeToModelTime(parseDate("2019-01-01"))}, IndexType: config.TSDBType, ObjectType: config.StorageTypeFileSystem},
+	}}
+	s := newDictionaryStore(cfg, schemaCfg, cm)
+	defer s.stop()
+	ctx := context.Background()
+
+	_, err := s.GetDictionary(ctx, "fake", 42)
+	require.Error(t, err)
+	require.NoError(t, s.PutDictionary(ctx, "fake", 42, []byte("foo")))
+	require.NoError(t, s.PutDictionary(ctx, "fake", 42, []byte("foo")))
+	require.Error(t, s.PutDictionary(ctx, "fake", 42, []byte("bar")))
+	content, err := s.GetDictionary(ctx, "fake", 42)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), content)
+
+	// The dictionaries are scoped by tenant.
+	_, err = s.GetDictionary(ctx, "other", 42)
+	require.Error(t, err)
+	require.NoError(t, s.PutDictionary(ctx, "other", 42, []byte("bar")))
+	content, err = s.GetDictionary(ctx, "other", 42)
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), content)
+
+	id, _, err := s.GetLatestDictionary(ctx, "fake")
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), id)
+	require.NoError(t, s.SetLatestDictionary(ctx, "fake", 42, time.Unix(0, 10)))
+	id, trainedAt, err := s.GetLatestDictionary(ctx, "fake")
+	require.NoError(t, err)
+	require.Equal(t, uint32(42), id)
+	require.Equal(t, time.Unix(0, 10), trainedAt)
+	id, _, err = s.GetLatestDictionary(ctx, "other")
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), id)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/grafana/dskit/flagext"
 
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	"github.com/grafana/loki/pkg/storage/chunk/client"
 	"github.com/grafana/loki/pkg/storage/chunk/client/alibaba"
@@ -287,6 +288,8 @@ type Config struct {
 	BoltDBShipperConfig shipper.Config      `yaml:"boltdb_shipper" doc:"description=Configures storing index in an Object Store (GCS/S3/Azure/Swift/COS/Filesystem) in the form of boltdb files. Required fields only required when boltdb-shipper is defined in config."`
 	TSDBShipperConfig   indexshipper.Config `yaml:"tsdb_shipper"`
 
+	BloomFilters bloom.Config `yaml:"bloom_filters" doc:"description=Configures the bloom filters of the n-grams of chunk lines, used to skip chunks which can't match the line filters of a query. Bloom filters are written to the object store of the chunks, and cached once read by the queries."`
+
 	// Config for using AsyncStore when using async index stores like `boltdb-shipper`.
 	// It is required for getting chunk ids of recently flushed chunks from the ingesters.
 	EnableAsyncStore bool          `yaml:"-"`
@@ -315,6 +318,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.BoltDBShipperConfig.RegisterFlags(f)
 	f.IntVar(&cfg.MaxChunkBatchSize, "store.max-chunk-batch-size", 50, "The maximum number of chunks to fetch per batch.")
 	cfg.TSDBShipperConfig.RegisterFlagsWithPrefix("tsdb.", f)
+	cfg.BloomFilters.RegisterFlagsWithPrefix("store.bloom-filters.", f)
 }
 
 // Validate config and returns error on failure
@@ -343,6 +347,9 @@ func (cfg *Config) Validate() error {
 	if err := cfg.TSDBShipperConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid tsdb config")
 	}
+	if err := cfg.BloomFilters.Validate(); err != nil {
+		return errors.Wrap(err, "invalid bloom filters config")
+	}
 
 	return cfg.NamedStores.validate()
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -15,11 +16,15 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/pkg/analytics"
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	logqllog "github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/astmapper"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	"github.com/grafana/loki/pkg/storage/chunk/client"
@@ -73,6 +78,11 @@ type store struct {
 	logger log.Logger
 
 	chunkFilterer chunk.RequestChunkFilterer
+
+	// bloomStores are the bloom filter stores by start of period.
+	bloomStores map[model.Time]*bloom.Store
+
+	dictionaries *dictionaryStore
 }
 
 // NewStore creates a new Loki Store using configuration supplied.
@@ -148,6 +158,22 @@ func NewStore(cfg Config, storeCfg config.ChunkStoreConfig, schemaCfg config.Sch
 }
 
 func (s *store) init() error {
+	var bloomMetrics *bloom.Metrics
+	var bloomCache cache.Cache
+	if s.cfg.BloomFilters.Enabled {
+		bloomMetrics = bloom.NewMetrics(s.registerer)
+		s.bloomStores = map[model.Time]*bloom.Store{}
+
+		bloomCacheCfg := s.cfg.BloomFilters.Cache
+		bloomCacheCfg.Prefix = "bloom-filters"
+		c, err := cache.New(bloomCacheCfg, s.registerer, s.logger, stats.BloomFilterCache)
+		if err != nil {
+			return err
+		}
+		// The cache is shared by the stores of all the periods.
+		bloomCache = cache.StopOnce(c)
+	}
+
 	for i, p := range s.schemaCfg.Configs {
 		p := p
 		chunkClient, err := s.chunkClientForPeriod(p)
@@ -168,9 +194,17 @@ func (s *store) init() error {
 			return err
 		}
 
+		if s.cfg.BloomFilters.Enabled {
+			w, stop = s.withBloomFilters(p, w, stop, bloomCache, bloomMetrics)
+		}
+
 		s.composite.AddStore(p.From.Time, f, idx, w, stop)
 	}
 
+	// The dictionaries of the chunks encoded with zstd-dict are read from and written to the object stores.
+	s.dictionaries = newDictionaryStore(s.cfg, s.schemaCfg, s.clientMetrics)
+	chunkenc.SetDictionaryStore(s.dictionaries)
+
 	if s.cfg.EnableAsyncStore {
 		s.Store = NewAsyncStore(s.cfg.AsyncStoreConfig, s.Store, s.schemaCfg)
 	}
@@ -178,6 +212,84 @@ func (s *store) init() error {
 	return nil
 }
 
+// Stop stops the stores of all the periods.
+func (s *store) Stop() {
+	s.Store.Stop()
+	if s.dictionaries != nil {
+		s.dictionaries.stop()
+	}
+}
+
+// withBloomFilters wraps the chunk writer of the period to also write the bloom filters of the chunks
+// to the object store of the period. Periods which don't store chunks in an object store have no bloom filters.
+func (s *store) withBloomFilters(p config.PeriodConfig, w stores.ChunkWriter, stop func(), c cache.Cache, metrics *bloom.Metrics) (stores.ChunkWriter, func()) {
+	objectStoreType := p.ObjectType
+	if objectStoreType == "" {
+		objectStoreType = p.IndexType
+	}
+	objectClient, err := NewObjectClient(objectStoreType, s.cfg, s.clientMetrics)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "bloom filters are not supported by the object store of the period", "period", p.From.String(), "object_store", objectStoreType, "err", err)
+		return w, stop
+	}
+
+	bs := bloom.NewStore(s.cfg.BloomFilters, s.schemaCfg, objectClient, c, metrics, s.logger)
+	s.bloomStores[p.From.Time] = bs
+	return bloom.NewChunkWriter(w, bs), func() {
+		stop()
+		objectClient.Stop()
+		c.Stop()
+	}
+}
+
+// skipChunksWithBlooms drops the chunks whose bloom filter rules out the line filters of the expression.
+func (s *store) skipChunksWithBlooms(ctx context.Context, expr syntax.LogSelectorExpr, chunks []*LazyChunk) []*LazyChunk {
+	if len(s.bloomStores) == 0 {
+		return chunks
+	}
+	required := bloom.RequiredLineFilters(expr)
+	if len(required) == 0 {
+		return chunks
+	}
+
+	byPeriod := map[model.Time][]*LazyChunk{}
+	for _, c := range chunks {
+		p, err := s.schemaCfg.SchemaForTime(c.Chunk.From)
+		if err != nil {
+			continue
+		}
+		byPeriod[p.From.Time] = append(byPeriod[p.From.Time], c)
+	}
+
+	skipped := map[*LazyChunk]struct{}{}
+	for from, periodChunks := range byPeriod {
+		bs, ok := s.bloomStores[from]
+		if !ok {
+			continue
+		}
+		chks := make([]chunk.Chunk, 0, len(periodChunks))
+		for _, c := range periodChunks {
+			chks = append(chks, c.Chunk)
+		}
+		for i, ok := range bs.MayContain(ctx, chks, required) {
+			if !ok {
+				skipped[periodChunks[i]] = struct{}{}
+			}
+		}
+	}
+
+	if len(skipped) == 0 {
+		return chunks
+	}
+	res := make([]*LazyChunk, 0, len(chunks)-len(skipped))
+	for _, c := range chunks {
+		if _, ok := skipped[c]; !ok {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
 func (s *store) chunkClientForPeriod(p config.PeriodConfig) (client.Client, error) {
 	objectStoreType := p.ObjectType
 	if objectStoreType == "" {
@@ -468,10 +580,27 @@ func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter
 		return nil, err
 	}
 
+	// the streams of the queries with a context stage are read without the pipeline, which is applied by the
+	// context lines to return the log lines around the matches, including the ones of the chunks without matches.
+	contextLines, err := logql.NewContextLines(expr, req.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	if contextLines == nil {
+		lazyChunks = s.skipChunksWithBlooms(ctx, expr, lazyChunks)
+		if len(lazyChunks) == 0 {
+			return iter.NoopIterator, nil
+		}
+	}
+
 	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
 	}
+	if contextLines != nil {
+		pipeline = logqllog.NewNoopPipeline()
+	}
 
 	pipeline, err = deletion.SetupPipeline(req, pipeline)
 	if err != nil {
@@ -483,7 +612,7 @@ func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter
 		chunkFilterer = s.chunkFilterer.ForRequest(ctx)
 	}
 
-	return newLogBatchIterator(ctx, s.schemaCfg, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, req.Direction, req.Start, req.End, chunkFilterer)
+	return newLogBatchIterator(ctx, s.schemaCfg, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, contextLines, req.Direction, req.Start, req.End, chunkFilterer)
 }
 
 func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error) {
@@ -506,6 +635,15 @@ func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams)
 		return nil, err
 	}
 
+	selector, err := expr.Selector()
+	if err != nil {
+		return nil, err
+	}
+	lazyChunks = s.skipChunksWithBlooms(ctx, selector, lazyChunks)
+	if len(lazyChunks) == 0 {
+		return iter.NoopIterator, nil
+	}
+
 	extractor, err := expr.Extractor()
 	if err != nil {
 		return nil, err
@@ -11,10 +11,13 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
@@ -26,6 +29,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/querier/astmapper"
+	"github.com/grafana/loki/pkg/storage/bloom"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/pkg/storage/config"
@@ -1586,3 +1590,72 @@ func TestStore_BoltdbTsdbSameIndexPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestStore_BloomFilters(t *testing.T) {
+	tempDir := t.TempDir()
+
+	shipperConfig := indexshipper.Config{}
+	flagext.DefaultValues(&shipperConfig)
+	shipperConfig.ActiveIndexDirectory = path.Join(tempDir, "index")
+	shipperConfig.CacheLocation = path.Join(tempDir, "cache")
+	shipperConfig.Mode = indexshipper.ModeReadWrite
+
+	cfg := Config{
+		FSConfig:          local.FSConfig{Directory: path.Join(tempDir, "chunks")},
+		TSDBShipperConfig: shipperConfig,
+		MaxChunkBatchSize: 10,
+	}
+	cfg.BloomFilters = bloom.Config{Enabled: true, NGramLength: 4, FalsePositiveRate: 0.01, QueryConcurrency: 2}
+
+	periodDate := parseDate("2019-01-01")
+	schemaConfig := config.SchemaConfig{
+		Configs: []config.PeriodConfig{
+			{
+				From:       config.DayTime{Time: timeToModelTime(periodDate)},
+				IndexType:  config.TSDBType,
+				ObjectType: config.StorageTypeFileSystem,
+				Schema:     "v12",
+				IndexTables: config.PeriodicTableConfig{
+					Prefix: "index_",
+					Period: time.Hour * 24,
+				},
+			},
+		},
+	}
+
+	limits, err := validation.NewOverrides(validation.Limits{}, nil)
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	store, err := NewStore(cfg, config.ChunkStoreConfig{}, schemaConfig, limits, cm, registry, util_log.Logger)
+	require.NoError(t, err)
+	defer store.Stop()
+
+	// the lines of the chunks are their timestamps, so each hour is only in one chunk
+	for _, tr := range []timeRange{
+		{periodDate.Add(2 * time.Hour), periodDate.Add(2*time.Hour + time.Minute)},
+		{periodDate.Add(5 * time.Hour), periodDate.Add(5*time.Hour + time.Minute)},
+	} {
+		chk := newChunk(buildTestStreams(fooLabelsWithName, tr))
+		require.NoError(t, store.PutOne(ctx, chk.From, chk.Through, chk))
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "fake")
+	req := newQuery(`{foo="bar"} |= "05:00:3"`, periodDate, periodDate.Add(24*time.Hour), nil, nil)
+	it, err := store.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: req})
+	require.NoError(t, err)
+	streams, _, err := iter.ReadBatch(it, req.Limit)
+	require.NoError(t, it.Close())
+	require.NoError(t, err)
+	require.Len(t, streams.Streams, 1)
+	require.Len(t, streams.Streams[0].Entries, 10)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_bloom_filter_chunks_checked_total Number of chunks checked against the line filters of a query.
+# TYPE loki_bloom_filter_chunks_checked_total counter
+loki_bloom_filter_chunks_checked_total 2
+# HELP loki_bloom_filter_chunks_skipped_total Number of chunks not fetched because their bloom filter rules out the line filters of the query.
+# TYPE loki_bloom_filter_chunks_skipped_total counter
+loki_bloom_filter_chunks_skipped_total 1
+`), "loki_bloom_filter_chunks_checked_total", "loki_bloom_filter_chunks_skipped_total"))
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"github.com/grafana/loki/pkg/push"
+)
+
+// EntryTotalSize returns the size of the line of the entry plus the size of its structured metadata.
+func EntryTotalSize(entry *push.Entry) int {
+	return len(entry.Line) + StructuredMetadataSize(entry.StructuredMetadata)
+}
+
+// StructuredMetadataSize returns the size of the names and values of the given structured metadata.
+func StructuredMetadataSize(metas push.LabelsAdapter) int {
+	size := 0
+	for _, meta := range metas {
+		size += len(meta.Name) + len(meta.Value)
+	}
+	return size
+}
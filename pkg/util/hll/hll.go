@@ -0,0 +1,63 @@
+// Package hll implements HyperLogLog sketches, estimating the number of distinct values added to them.
+package hll
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// Precision is the number of bits of the hashes of the values indexing the registers of the sketches.
+	// The relative standard error of the estimated counts is 1.04/sqrt(2^Precision), about 3%.
+	Precision = 10
+	// Registers is the number of registers of the sketches.
+	Registers = 1 << Precision
+)
+
+// Register returns the index of the register of a hash of hashBits bits, and the rank of the hash stored in the
+// register: the position of the leftmost 1 in the bits of the hash following the bits of the index.
+func Register(h uint64, hashBits int) (int, uint8) {
+	index := h >> (hashBits - Precision)
+	w := h << (64 - hashBits + Precision)
+	rank := bits.LeadingZeros64(w) + 1
+	if maxRank := hashBits - Precision + 1; rank > maxRank {
+		rank = maxRank
+	}
+	return int(index & (Registers - 1)), uint8(rank)
+}
+
+// Sketch is a HyperLogLog sketch. The sketches are merged by keeping the greatest rank of each register.
+type Sketch [Registers]uint8
+
+// Insert adds the hash of hashBits bits of a value to the sketch, and returns whether the sketch changed.
+func (s *Sketch) Insert(h uint64, hashBits int) bool {
+	return s.Set(Register(h, hashBits))
+}
+
+// Set sets the rank of the register if it is greater than its current rank, and returns whether the sketch changed.
+func (s *Sketch) Set(i int, rank uint8) bool {
+	if rank <= s[i] {
+		return false
+	}
+	s[i] = rank
+	return true
+}
+
+// Count returns the estimated number of distinct values of the sketch. The small counts are estimated with
+// linear counting, as in the original HyperLogLog algorithm.
+func (s *Sketch) Count() float64 {
+	var sum float64
+	var zeros int
+	for _, rank := range s {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	m := float64(Registers)
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
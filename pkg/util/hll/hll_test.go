@@ -0,0 +1,45 @@
+package hll
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	for _, tc := range []struct {
+		hash          uint64
+		hashBits      int
+		expectedIndex int
+		expectedRank  uint8
+	}{
+		{hash: 0, hashBits: 53, expectedIndex: 0, expectedRank: 53 - Precision + 1},
+		{hash: 1, hashBits: 53, expectedIndex: 0, expectedRank: 53 - Precision},
+		{hash: 1 << 52, hashBits: 53, expectedIndex: Registers / 2, expectedRank: 53 - Precision + 1},
+		{hash: 1<<52 | 1<<(52-Precision), hashBits: 53, expectedIndex: Registers / 2, expectedRank: 1},
+		{hash: 1<<53 - 1, hashBits: 53, expectedIndex: Registers - 1, expectedRank: 1},
+		{hash: 0, hashBits: 64, expectedIndex: 0, expectedRank: 64 - Precision + 1},
+		{hash: 1 << 63, hashBits: 64, expectedIndex: Registers / 2, expectedRank: 64 - Precision + 1},
+		{hash: 1<<64 - 1, hashBits: 64, expectedIndex: Registers - 1, expectedRank: 1},
+	} {
+		i, rank := Register(tc.hash, tc.hashBits)
+		require.Equal(t, tc.expectedIndex, i, "hash %x", tc.hash)
+		require.Equal(t, tc.expectedRank, rank, "hash %x", tc.hash)
+	}
+}
+
+func TestSketchCount(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 100, 1000, 10000, 100000} {
+		var s Sketch
+		for i := 0; i < n; i++ {
+			h := xxhash.Sum64String(strconv.Itoa(i))
+			s.Insert(h, 64)
+			// the duplicated values are counted once.
+			require.False(t, s.Insert(h, 64))
+		}
+		// The standard error of the counts is about 3%.
+		require.InDelta(t, float64(n), s.Count(), 0.1*float64(n)+0.5, "count %d", n)
+	}
+}
@@ -16,6 +16,10 @@ var (
 
 	// LokiActorPathDelimiter is the delimiter used to serialise the hierarchy of the actor.
 	LokiActorPathDelimiter = "|"
+
+	// LokiResponseStreamingHeader is the name of the header set on the requests whose responses are read
+	// by the query-frontend as they are streamed by the queriers.
+	LokiResponseStreamingHeader = "X-Loki-Response-Streaming"
 )
 
 func PropagateHeadersMiddleware(headers ...string) middleware.Interface {
@@ -14,6 +14,7 @@ import (
 	legacy "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logqlmodel"
+	logqlstats "github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/stores/index/stats"
 	marshal_legacy "github.com/grafana/loki/pkg/util/marshal/legacy"
 )
@@ -40,6 +41,8 @@ func WriteResponseJSON(r *http.Request, v any, w http.ResponseWriter) error {
 		return WriteIndexStatsResponseJSON(result, w)
 	case *logproto.VolumeResponse:
 		return WriteSeriesVolumeResponseJSON(result, w)
+	case *loghttp.PatternsResponse:
+		return WritePatternsResponseJSON(result, w)
 	}
 	return fmt.Errorf("unknown response type %T", v)
 }
@@ -57,6 +60,58 @@ func WriteQueryResponseJSON(v logqlmodel.Result, w io.Writer) error {
 	return s.Flush()
 }
 
+// StreamsResponseWriter writes the v1 loghttp JSON of a streams query result incrementally,
+// so that the entries of a log query can be written while they are still being merged.
+type StreamsResponseWriter struct {
+	s       *jsoniter.Stream
+	opened  bool
+	written bool
+}
+
+// NewStreamsResponseWriter returns a StreamsResponseWriter writing to w. It must be closed.
+func NewStreamsResponseWriter(w io.Writer) *StreamsResponseWriter {
+	return &StreamsResponseWriter{s: jsoniter.ConfigFastest.BorrowStream(w)}
+}
+
+// WriteStreams writes streams of the result and flushes them to the underlying writer.
+// The same labels may be written in several streams.
+func (w *StreamsResponseWriter) WriteStreams(streams logqlmodel.Streams) error {
+	w.start()
+	for _, stream := range streams {
+		if w.written {
+			w.s.WriteMore()
+		}
+		w.written = true
+		if err := encodeStream(stream, w.s); err != nil {
+			return fmt.Errorf("could not write JSON response: %w", err)
+		}
+	}
+	return w.s.Flush()
+}
+
+// Close writes the statistics, that end the result, and flushes them to the underlying writer.
+func (w *StreamsResponseWriter) Close(statistics logqlstats.Result) error {
+	defer jsoniter.ConfigFastest.ReturnStream(w.s)
+
+	w.start()
+	w.s.WriteArrayEnd()
+	w.s.WriteMore()
+	w.s.WriteObjectField("stats")
+	w.s.WriteVal(statistics)
+	w.s.WriteObjectEnd()
+	w.s.WriteObjectEnd()
+	w.s.WriteRaw("\n")
+	return w.s.Flush()
+}
+
+func (w *StreamsResponseWriter) start() {
+	if w.opened {
+		return
+	}
+	w.opened = true
+	w.s.WriteRaw(`{"status":"success","data":{"resultType":"streams","result":[`)
+}
+
 // WriteLabelResponseJSON marshals a logproto.LabelResponse to v1 loghttp JSON
 // and then writes it to the provided io.Writer.
 func WriteLabelResponseJSON(l logproto.LabelResponse, w io.Writer) error {
@@ -136,3 +191,13 @@ func WriteSeriesVolumeResponseJSON(r *logproto.VolumeResponse, w io.Writer) erro
 	s.WriteRaw("\n")
 	return s.Flush()
 }
+
+// WritePatternsResponseJSON marshals a loghttp.PatternsResponse to JSON and then
+// writes it to the provided io.Writer.
+func WritePatternsResponseJSON(r *loghttp.PatternsResponse, w io.Writer) error {
+	s := jsoniter.ConfigFastest.BorrowStream(w)
+	defer jsoniter.ConfigFastest.ReturnStream(s)
+	s.WriteVal(r)
+	s.WriteRaw("\n")
+	return s.Flush()
+}
@@ -19,6 +19,7 @@ import (
 	legacy "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
 // covers responses from /loki/api/v1/query_range and /loki/api/v1/query
@@ -532,6 +533,34 @@ func Test_WriteQueryResponseJSON(t *testing.T) {
 	}
 }
 
+func Test_StreamsResponseWriter(t *testing.T) {
+	for i, queryTest := range queryTests {
+		streams, ok := queryTest.actual.(logqlmodel.Streams)
+		if !ok {
+			continue
+		}
+		var expected bytes.Buffer
+		require.NoError(t, WriteQueryResponseJSON(logqlmodel.Result{Data: streams}, &expected))
+
+		// The streams are written one at a time.
+		var b bytes.Buffer
+		w := NewStreamsResponseWriter(&b)
+		for _, s := range streams {
+			require.NoError(t, w.WriteStreams(logqlmodel.Streams{s}))
+		}
+		require.NoError(t, w.WriteStreams(nil))
+		require.NoError(t, w.Close(stats.Result{}))
+
+		require.JSONEqf(t, expected.String(), b.String(), "Query Test %d failed", i)
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, NewStreamsResponseWriter(&b).Close(stats.Result{}))
+	var expected bytes.Buffer
+	require.NoError(t, WriteQueryResponseJSON(logqlmodel.Result{Data: logqlmodel.Streams{}}, &expected))
+	require.JSONEq(t, expected.String(), b.String())
+}
+
 func Test_WriteLabelResponseJSON(t *testing.T) {
 	for i, labelTest := range labelTests {
 		var b bytes.Buffer
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/go-kit/log/level"
 	dskit_flagext "github.com/grafana/dskit/flagext"
 
@@ -16,10 +17,12 @@ import (
 	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
 
 	"github.com/grafana/loki/pkg/distributor/shardstreams"
+	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	ruler_config "github.com/grafana/loki/pkg/ruler/config"
 	"github.com/grafana/loki/pkg/ruler/util"
@@ -52,6 +55,9 @@ const (
 	defaultPerStreamBurstLimit  = 5 * defaultPerStreamRateLimit
 
 	DefaultPerTenantQueryTimeout = "1m"
+
+	defaultMaxStructuredMetadataSize  = "64kb"
+	defaultMaxStructuredMetadataCount = 128
 )
 
 // Limits describe all the limits for users; can be used to describe global default
@@ -63,6 +69,7 @@ type Limits struct {
 	IngestionRateStrategy       string           `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
 	IngestionRateMB             float64          `yaml:"ingestion_rate_mb" json:"ingestion_rate_mb"`
 	IngestionBurstSizeMB        float64          `yaml:"ingestion_burst_size_mb" json:"ingestion_burst_size_mb"`
+	IngestionTenantShardSize    int              `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
 	MaxLabelNameLength          int              `yaml:"max_label_name_length" json:"max_label_name_length"`
 	MaxLabelValueLength         int              `yaml:"max_label_value_length" json:"max_label_value_length"`
 	MaxLabelNamesPerSeries      int              `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
@@ -74,6 +81,18 @@ type Limits struct {
 	MaxLineSizeTruncate         bool             `yaml:"max_line_size_truncate" json:"max_line_size_truncate"`
 	IncrementDuplicateTimestamp bool             `yaml:"increment_duplicate_timestamp" json:"increment_duplicate_timestamp"`
 
+	AllowStructuredMetadata           bool             `yaml:"allow_structured_metadata" json:"allow_structured_metadata"`
+	MaxStructuredMetadataSize         flagext.ByteSize `yaml:"max_structured_metadata_size" json:"max_structured_metadata_size"`
+	MaxStructuredMetadataEntriesCount int              `yaml:"max_structured_metadata_entries_count" json:"max_structured_metadata_entries_count"`
+
+	RelabelConfigs       []*util.RelabelConfig `yaml:"relabel_configs,omitempty" json:"relabel_configs,omitempty" doc:"description=List of relabel configurations applied by the distributors to the labels of the pushed streams, before their validation. The streams dropped by the relabeling are not ingested. The actions drop, keep, labeldrop, labelkeep, replace and hashmod are the most useful ones."`
+	ParsedRelabelConfigs []*relabel.Config     `yaml:"-" json:"-"` // populated during validation.
+	RelabelConfigsHash   uint64                `yaml:"-" json:"-"` // populated during validation.
+
+	LabelCardinalityCollapseThreshold int `yaml:"label_cardinality_collapse_threshold" json:"label_cardinality_collapse_threshold"`
+
+	StreamAggregations []StreamAggregation `yaml:"stream_aggregations,omitempty" json:"stream_aggregations,omitempty" doc:"description=Counters of the entries of the streams pushed by the tenant, computed by the distributors when the distributor aggregation is enabled, and remote-written with the remote-write configuration of the ruler of the tenant. Each aggregation counts the lines, or the bytes of the lines, of the streams matching its selector, or of all the streams if empty, by the values of its by labels. The name is the name of the metric, whose series also have the distributor label set to the instance ID of the distributor."`
+
 	// Ingester enforced limits.
 	MaxLocalStreamsPerUser  int              `yaml:"max_streams_per_user" json:"max_streams_per_user"`
 	MaxGlobalStreamsPerUser int              `yaml:"max_global_streams_per_user" json:"max_global_streams_per_user"`
@@ -101,11 +120,14 @@ type Limits struct {
 	QueryTimeout               model.Duration   `yaml:"query_timeout" json:"query_timeout"`
 
 	// Query frontend enforced limits. The default is actually parameterized by the queryrange config.
-	QuerySplitDuration  model.Duration   `yaml:"split_queries_by_interval" json:"split_queries_by_interval"`
-	MinShardingLookback model.Duration   `yaml:"min_sharding_lookback" json:"min_sharding_lookback"`
-	MaxQueryBytesRead   flagext.ByteSize `yaml:"max_query_bytes_read" json:"max_query_bytes_read"`
-	MaxQuerierBytesRead flagext.ByteSize `yaml:"max_querier_bytes_read" json:"max_querier_bytes_read"`
-	VolumeEnabled       bool             `yaml:"volume_enabled" json:"volume_enabled" doc:"description=Enable log-volume endpoints."`
+	QuerySplitDuration    model.Duration   `yaml:"split_queries_by_interval" json:"split_queries_by_interval"`
+	MinShardingLookback   model.Duration   `yaml:"min_sharding_lookback" json:"min_sharding_lookback"`
+	MaxQueryBytesRead     flagext.ByteSize `yaml:"max_query_bytes_read" json:"max_query_bytes_read"`
+	MaxQuerierBytesRead   flagext.ByteSize `yaml:"max_querier_bytes_read" json:"max_querier_bytes_read"`
+	QueryCostBudget       flagext.ByteSize `yaml:"query_cost_budget" json:"query_cost_budget"`
+	QueryCostBudgetWindow model.Duration   `yaml:"query_cost_budget_window" json:"query_cost_budget_window"`
+	ApproximateQuantiles  bool             `yaml:"approximate_quantiles" json:"approximate_quantiles"`
+	VolumeEnabled         bool             `yaml:"volume_enabled" json:"volume_enabled" doc:"description=Enable log-volume endpoints."`
 
 	// Ruler defaults and limits.
 
@@ -172,11 +194,15 @@ type Limits struct {
 
 	ShardStreams *shardstreams.Config `yaml:"shard_streams" json:"shard_streams"`
 
+	OTLPConfig *push.OTLPConfig `yaml:"otlp_config" json:"otlp_config" doc:"description=Configures how OTLP log records pushed to /otlp/v1/logs are turned into streams and entries."`
+
 	BlockedQueries []*validation.BlockedQuery `yaml:"blocked_queries,omitempty" json:"blocked_queries,omitempty"`
 
 	RequiredLabels       []string `yaml:"required_labels,omitempty" json:"required_labels,omitempty" doc:"description=Define a list of required selector labels."`
 	RequiredNumberLabels int      `yaml:"minimum_labels_number,omitempty" json:"minimum_labels_number,omitempty" doc:"description=Minimum number of label matchers a query should contain."`
 
+	LookupTables map[string]string `yaml:"lookup_tables,omitempty" json:"lookup_tables,omitempty" doc:"description=Lookup tables of the lookup stages of the queries. A map of the names of the tables to the paths of their CSV files, whose first record is the header of the columns. The files must be the same on the query-frontends, the queriers and the ingesters, and are reloaded when they change."`
+
 	IndexGatewayShardSize int `yaml:"index_gateway_shard_size" json:"index_gateway_shard_size"`
 }
 
@@ -187,6 +213,22 @@ type StreamRetention struct {
 	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
 }
 
+const (
+	// StreamAggregationLines is the value of the stream aggregations counting the lines of the streams.
+	StreamAggregationLines = "lines"
+	// StreamAggregationBytes is the value of the stream aggregations counting the bytes of the lines of the streams.
+	StreamAggregationBytes = "bytes"
+)
+
+// StreamAggregation configures a counter of the entries of the streams pushed by a tenant.
+type StreamAggregation struct {
+	Name     string            `yaml:"name" json:"name"`
+	Selector string            `yaml:"selector" json:"selector"`
+	By       []string          `yaml:"by" json:"by"`
+	Value    string            `yaml:"value" json:"value"`
+	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+}
+
 // LimitError are errors that do not comply with the limits specified.
 type LimitError string
 
@@ -199,6 +241,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.IngestionRateStrategy, "distributor.ingestion-rate-limit-strategy", "global", "Whether the ingestion rate limit should be applied individually to each distributor instance (local), or evenly shared across the cluster (global). The ingestion rate strategy cannot be overridden on a per-tenant basis.\n- local: enforces the limit on a per distributor basis. The actual effective rate limit will be N times higher, where N is the number of distributor replicas.\n- global: enforces the limit globally, configuring a per-distributor local rate limiter as 'ingestion_rate / N', where N is the number of distributor replicas (it's automatically adjusted if the number of replicas change). The global strategy requires the distributors to form their own ring, which is used to keep track of the current number of healthy distributor replicas.")
 	f.Float64Var(&l.IngestionRateMB, "distributor.ingestion-rate-limit-mb", 4, "Per-user ingestion rate limit in sample size per second. Units in MB.")
 	f.Float64Var(&l.IngestionBurstSizeMB, "distributor.ingestion-burst-size-mb", 6, "Per-user allowed ingestion burst size (in sample size). Units in MB. The burst size refers to the per-distributor local rate limiter even in the case of the 'global' strategy, and should be set at least to the maximum logs size expected in a single push request.")
+	f.IntVar(&l.IngestionTenantShardSize, "distributor.ingestion-tenant-shard-size", 0, "The number of ingesters the streams of the tenant are written to, when shuffle sharding the ingesters. The queriers only query the ingesters of the shard of the tenant during the query_ingesters_within period, including the ingesters of its previous shards within this period when the shard size changes. As the queriers record the shard sizes of the tenants in memory, they query all the ingesters during the query_ingesters_within period after they start. 0 to disable shuffle sharding: the streams are written to all the ingesters.")
 	f.Var(&l.MaxLineSize, "distributor.max-line-size", "Maximum line size on ingestion path. Example: 256kb. Any log line exceeding this limit will be discarded unless `distributor.max-line-size-truncate` is set which in case it is truncated instead of discarding it completely. There is no limit when unset or set to 0.")
 	f.BoolVar(&l.MaxLineSizeTruncate, "distributor.max-line-size-truncate", false, "Whether to truncate lines that exceed max_line_size.")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names.")
@@ -214,6 +257,12 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.EnforceMetricName, "validation.enforce-metric-name", true, "Enforce every sample has a metric name.")
 	f.IntVar(&l.MaxEntriesLimitPerQuery, "validation.max-entries-limit", 5000, "Maximum number of log entries that will be returned for a query.")
 
+	f.BoolVar(&l.AllowStructuredMetadata, "validation.allow-structured-metadata", false, "Allow user to send structured metadata in push payload. Structured metadata requires unordered writes to be enabled.")
+	_ = l.MaxStructuredMetadataSize.Set(defaultMaxStructuredMetadataSize)
+	f.Var(&l.MaxStructuredMetadataSize, "validation.max-structured-metadata-size", "Maximum size accepted for structured metadata per log line. 0 to disable.")
+	f.IntVar(&l.MaxStructuredMetadataEntriesCount, "validation.max-structured-metadata-entries-count", defaultMaxStructuredMetadataCount, "Maximum number of structured metadata entries per log line. 0 to disable.")
+	f.IntVar(&l.LabelCardinalityCollapseThreshold, "validation.label-cardinality-collapse-threshold", 0, "Number of distinct values of a label of the streams of a tenant, counted by each distributor over the label cardinality window, above which the values of the label are collapsed instead of creating new streams. The label value is replaced by __collapsed__ and moved to the structured metadata of the entries if it is allowed, or else appended to their log lines. The entries which can't hold the values within the structured metadata limits and the max line size are rejected with the collapsed_labels_too_long reason. An event is pushed to the tenant, in the stream whose __loki_event__ label is label_values_collapsed, when a label starts to be collapsed. 0 to disable.")
+
 	f.IntVar(&l.MaxLocalStreamsPerUser, "ingester.max-streams-per-user", 0, "Maximum number of active streams per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxGlobalStreamsPerUser, "ingester.max-global-streams-per-user", 5000, "Maximum number of active streams per user, across the cluster. 0 to disable. When the global limit is enabled, each ingester is configured with a dynamic local limit based on the replication factor and the current number of healthy ingesters, and is kept updated whenever the number of ingesters change.")
 	f.BoolVar(&l.UnorderedWrites, "ingester.unordered-writes", true, "When true, out-of-order writes are accepted.")
@@ -248,6 +297,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	f.Var(&l.MaxQueryBytesRead, "frontend.max-query-bytes-read", "Max number of bytes a query can fetch. Enforced in log and metric queries only when TSDB is used. The default value of 0 disables this limit.")
 	f.Var(&l.MaxQuerierBytesRead, "frontend.max-querier-bytes-read", "Max number of bytes a query can fetch after splitting and sharding. Enforced in log and metric queries only when TSDB is used. The default value of 0 disables this limit.")
+	f.Var(&l.QueryCostBudget, "frontend.query-cost-budget", "Max number of bytes the queries of a tenant can fetch over the query cost budget window. The bytes a query would fetch are estimated from the index stats of its splits before it is executed, and queries exceeding the remaining budget are rejected. The bytes of the queries which fail or are cancelled are refunded. Each query-frontend enforces the budget on the queries it receives, so the budget of the tenant is multiplied by the number of query-frontends. Estimated only when TSDB is used. The default value of 0 disables this limit.")
+	_ = l.QueryCostBudgetWindow.Set("1h")
+	f.Var(&l.QueryCostBudgetWindow, "frontend.query-cost-budget-window", "Sliding window over which the query cost budget is enforced.")
+	f.BoolVar(&l.ApproximateQuantiles, "frontend.approximate-quantiles", false, "Shard quantile_over_time queries by approximating the quantiles from the histograms of the values merged across the shards, instead of executing them unsharded. The approximated quantiles are within about 9% of the values.")
 
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
@@ -283,6 +336,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	l.ShardStreams = &shardstreams.Config{}
 	l.ShardStreams.RegisterFlagsWithPrefix("shard-streams", f)
+
+	l.OTLPConfig = &push.OTLPConfig{}
+	l.OTLPConfig.RegisterFlagsWithPrefix("distributor.otlp", f)
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -321,10 +377,59 @@ func (l *Limits) Validate() error {
 		}
 	}
 
+	if l.RelabelConfigs != nil {
+		l.ParsedRelabelConfigs = make([]*relabel.Config, 0, len(l.RelabelConfigs))
+		h := xxhash.New()
+		for _, cfg := range l.RelabelConfigs {
+			// convert the util.RelabelConfig into relabel.Config, which validates it.
+			out, err := yaml.Marshal(cfg)
+			if err != nil {
+				return err
+			}
+			_, _ = h.Write(out)
+			var rc relabel.Config
+			if err := yaml.Unmarshal(out, &rc); err != nil {
+				return fmt.Errorf("invalid relabel config: %w", err)
+			}
+			l.ParsedRelabelConfigs = append(l.ParsedRelabelConfigs, &rc)
+		}
+		l.RelabelConfigsHash = h.Sum64()
+	}
+
+	for i, aggregation := range l.StreamAggregations {
+		if !model.IsValidMetricName(model.LabelValue(aggregation.Name)) {
+			return fmt.Errorf("invalid stream aggregation name: %q", aggregation.Name)
+		}
+		if aggregation.Selector != "" {
+			matchers, err := syntax.ParseMatchers(aggregation.Selector)
+			if err != nil {
+				return fmt.Errorf("invalid stream aggregation %s labels matchers: %w", aggregation.Name, err)
+			}
+			// populate matchers during validation
+			l.StreamAggregations[i].Matchers = matchers
+		}
+		for _, name := range aggregation.By {
+			if !model.LabelName(name).IsValid() {
+				return fmt.Errorf("invalid stream aggregation %s label name: %q", aggregation.Name, name)
+			}
+		}
+		switch aggregation.Value {
+		case "":
+			l.StreamAggregations[i].Value = StreamAggregationLines
+		case StreamAggregationLines, StreamAggregationBytes:
+		default:
+			return fmt.Errorf("invalid stream aggregation %s value %q, must be %s or %s", aggregation.Name, aggregation.Value, StreamAggregationLines, StreamAggregationBytes)
+		}
+	}
+
 	if _, err := deletionmode.ParseMode(l.DeletionMode); err != nil {
 		return err
 	}
 
+	if l.AllowStructuredMetadata && !l.UnorderedWrites {
+		return errors.New("structured metadata requires unordered writes to be enabled")
+	}
+
 	if l.CompactorDeletionEnabled {
 		level.Warn(util_log.Logger).Log("msg", "The compactor.allow-deletes configuration option has been deprecated and will be ignored. Instead, use deletion_mode in the limits_configs to adjust deletion functionality")
 	}
@@ -393,6 +498,11 @@ func (o *Overrides) IngestionBurstSizeBytes(userID string) int {
 	return int(o.getOverridesForUser(userID).IngestionBurstSizeMB * bytesInMB)
 }
 
+// IngestionTenantShardSize returns the number of ingesters the streams of the user are written to when shuffle sharding the ingesters.
+func (o *Overrides) IngestionTenantShardSize(userID string) int {
+	return o.getOverridesForUser(userID).IngestionTenantShardSize
+}
+
 // MaxLabelNameLength returns maximum length a label name can be.
 func (o *Overrides) MaxLabelNameLength(userID string) int {
 	return o.getOverridesForUser(userID).MaxLabelNameLength
@@ -524,6 +634,21 @@ func (o *Overrides) MaxQuerierBytesRead(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerierBytesRead.Val()
 }
 
+// QueryCostBudget returns the maximum bytes the queries of a tenant can read over the query cost budget window.
+func (o *Overrides) QueryCostBudget(_ context.Context, userID string) int {
+	return o.getOverridesForUser(userID).QueryCostBudget.Val()
+}
+
+// QueryCostBudgetWindow returns the sliding window over which the query cost budget is enforced.
+func (o *Overrides) QueryCostBudgetWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QueryCostBudgetWindow)
+}
+
+// ApproximateQuantiles returns whether the quantiles of quantile_over_time can be approximated to shard the queries.
+func (o *Overrides) ApproximateQuantiles(userID string) bool {
+	return o.getOverridesForUser(userID).ApproximateQuantiles
+}
+
 // MaxConcurrentTailRequests returns the limit to number of concurrent tail requests.
 func (o *Overrides) MaxConcurrentTailRequests(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxConcurrentTailRequests
@@ -548,6 +673,11 @@ func (o *Overrides) QueryTimeout(_ context.Context, userID string) time.Duration
 	return time.Duration(o.getOverridesForUser(userID).QueryTimeout)
 }
 
+// LookupTables returns the paths of the CSV files of the lookup tables of the tenant, by name.
+func (o *Overrides) LookupTables(_ context.Context, userID string) map[string]string {
+	return o.getOverridesForUser(userID).LookupTables
+}
+
 func (o *Overrides) MaxCacheFreshness(_ context.Context, userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxCacheFreshness)
 }
@@ -707,6 +837,37 @@ func (o *Overrides) UnorderedWrites(userID string) bool {
 	return o.getOverridesForUser(userID).UnorderedWrites
 }
 
+func (o *Overrides) AllowStructuredMetadata(userID string) bool {
+	return o.getOverridesForUser(userID).AllowStructuredMetadata
+}
+
+func (o *Overrides) MaxStructuredMetadataSize(userID string) int {
+	return o.getOverridesForUser(userID).MaxStructuredMetadataSize.Val()
+}
+
+func (o *Overrides) MaxStructuredMetadataCount(userID string) int {
+	return o.getOverridesForUser(userID).MaxStructuredMetadataEntriesCount
+}
+
+func (o *Overrides) LabelCardinalityCollapseThreshold(userID string) int {
+	return o.getOverridesForUser(userID).LabelCardinalityCollapseThreshold
+}
+
+// StreamAggregations returns the aggregations of the streams pushed by a given user.
+func (o *Overrides) StreamAggregations(userID string) []StreamAggregation {
+	return o.getOverridesForUser(userID).StreamAggregations
+}
+
+// RelabelConfigs returns the relabel configs applied to the labels of the streams pushed by a given user.
+func (o *Overrides) RelabelConfigs(userID string) []*relabel.Config {
+	return o.getOverridesForUser(userID).ParsedRelabelConfigs
+}
+
+// RelabelConfigsHash returns the hash of the relabel configs of a given user, which changes with them.
+func (o *Overrides) RelabelConfigsHash(userID string) uint64 {
+	return o.getOverridesForUser(userID).RelabelConfigsHash
+}
+
 func (o *Overrides) DeletionMode(userID string) string {
 	return o.getOverridesForUser(userID).DeletionMode
 }
@@ -715,6 +876,13 @@ func (o *Overrides) ShardStreams(userID string) *shardstreams.Config {
 	return o.getOverridesForUser(userID).ShardStreams
 }
 
+func (o *Overrides) OTLPConfig(userID string) push.OTLPConfig {
+	if cfg := o.getOverridesForUser(userID).OTLPConfig; cfg != nil {
+		return *cfg
+	}
+	return push.OTLPConfig{ResourceAttributesAsLabels: push.DefaultOTLPResourceAttributesAsLabels}
+}
+
 func (o *Overrides) BlockedQueries(_ context.Context, userID string) []*validation.BlockedQuery {
 	return o.getOverridesForUser(userID).BlockedQueries
 }
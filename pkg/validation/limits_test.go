@@ -8,9 +8,12 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/grafana/loki/pkg/ruler/util"
 	"github.com/grafana/loki/pkg/storage/stores/indexshipper/compactor/deletionmode"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -303,3 +306,39 @@ func TestLimitsValidation(t *testing.T) {
 		require.True(t, errors.Is(limits.Validate(), tc.expected))
 	}
 }
+
+func TestLimitsRelabelConfigsValidation(t *testing.T) {
+	limits := Limits{DeletionMode: "disabled", RelabelConfigs: []*util.RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "dev", Action: "drop"},
+	}}
+	require.NoError(t, limits.Validate())
+	require.Len(t, limits.ParsedRelabelConfigs, 1)
+	require.Equal(t, relabel.Drop, limits.ParsedRelabelConfigs[0].Action)
+
+	// hashmod requires a modulus.
+	limits = Limits{DeletionMode: "disabled", RelabelConfigs: []*util.RelabelConfig{
+		{SourceLabels: []string{"pod"}, TargetLabel: "shard", Action: "hashmod"},
+	}}
+	require.Error(t, limits.Validate())
+}
+
+func TestLimitsStreamAggregationsValidation(t *testing.T) {
+	limits := Limits{DeletionMode: "disabled", StreamAggregations: []StreamAggregation{
+		{Name: "app_lines_total", Selector: `{env="prod"}`, By: []string{"app", "level"}},
+		{Name: "bytes_total", Value: StreamAggregationBytes},
+	}}
+	require.NoError(t, limits.Validate())
+	require.Equal(t, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "env", "prod")}, limits.StreamAggregations[0].Matchers)
+	require.Equal(t, StreamAggregationLines, limits.StreamAggregations[0].Value)
+	require.Nil(t, limits.StreamAggregations[1].Matchers)
+
+	for _, aggregation := range []StreamAggregation{
+		{Name: "app-lines"},
+		{Name: "app_lines_total", Selector: `{env=`},
+		{Name: "app_lines_total", By: []string{"app.name"}},
+		{Name: "app_lines_total", Value: "entries"},
+	} {
+		limits = Limits{DeletionMode: "disabled", StreamAggregations: []StreamAggregation{aggregation}}
+		require.Error(t, limits.Validate(), aggregation)
+	}
+}
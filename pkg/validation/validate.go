@@ -6,6 +6,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
+	"github.com/grafana/loki/pkg/push"
 	"github.com/grafana/loki/pkg/util/flagext"
 )
 
@@ -19,18 +20,18 @@ const (
 	InvalidLabelsErrorMsg = "Error parsing labels '%s' with error: %s"
 	// RateLimited is one of the values for the reason to discard samples.
 	// Declared here to avoid duplication in ingester and distributor.
-	RateLimited         = "rate_limited"
+	RateLimited         = push.ReasonRateLimited
 	RateLimitedErrorMsg = "Ingestion rate limit exceeded for user %s (limit: %d bytes/sec) while attempting to ingest '%d' lines totaling '%d' bytes, reduce log volume or contact your Loki administrator to see if the limit can be increased"
 	// LineTooLong is a reason for discarding too long log lines.
 	LineTooLong         = "line_too_long"
 	LineTooLongErrorMsg = "Max entry size '%d' bytes exceeded for stream '%s' while adding an entry with length '%d' bytes"
 	// StreamLimit is a reason for discarding lines when we can't create a new stream
 	// because the limit of active streams has been reached.
-	StreamLimit         = "stream_limit"
+	StreamLimit         = push.ReasonStreamLimit
 	StreamLimitErrorMsg = "Maximum active stream limit exceeded, reduce the number of active streams (reduce labels or reduce label values), or contact your Loki administrator to see if the limit can be increased, user: '%s'"
 	// StreamRateLimit is a reason for discarding lines when the streams own rate limit is hit
 	// rather than the overall ingestion rate limit.
-	StreamRateLimit = "per_stream_rate_limit"
+	StreamRateLimit = push.ReasonStreamRateLimit
 	// OutOfOrder is a reason for discarding lines when Loki doesn't accept out
 	// of order log lines (parameter `-ingester.unordered-writes` is set to
 	// `false`) and the lines in question are older than the newest line in the
@@ -60,6 +61,19 @@ const (
 	// DuplicateLabelNames is a reason for discarding a log line which has duplicate label names
 	DuplicateLabelNames         = "duplicate_label_names"
 	DuplicateLabelNamesErrorMsg = "stream '%s' has duplicate label name: '%s'"
+	// DisallowedStructuredMetadata is a reason for discarding a log line which has structured metadata
+	// while the tenant is not allowed to send it.
+	DisallowedStructuredMetadata         = "disallowed_structured_metadata"
+	DisallowedStructuredMetadataErrorMsg = "stream '%s' includes structured metadata, but this feature is disallowed. Please see `limits_config.allow_structured_metadata` or contact your Loki administrator to enable it."
+	// StructuredMetadataTooLarge is a reason for discarding a log line which has structured metadata too large
+	StructuredMetadataTooLarge         = "structured_metadata_too_large"
+	StructuredMetadataTooLargeErrorMsg = "stream '%s' has structured metadata too large: '%d' bytes, limit: '%d' bytes. Please see `limits_config.max_structured_metadata_size` or contact your Loki administrator to increase it."
+	// StructuredMetadataTooMany is a reason for discarding a log line which has too many structured metadata entries
+	StructuredMetadataTooMany         = "structured_metadata_too_many"
+	StructuredMetadataTooManyErrorMsg = "stream '%s' has too many structured metadata labels: '%d', limit: '%d'. Please see `limits_config.max_structured_metadata_entries_count` or contact your Loki administrator to increase it."
+	// CollapsedLabelsTooLong is a reason for discarding a log line which can't hold the values of the collapsed labels of its stream.
+	CollapsedLabelsTooLong         = "collapsed_labels_too_long"
+	CollapsedLabelsTooLongErrorMsg = "entry for stream '%s' can't hold the values of its collapsed labels: they don't fit in its structured metadata, and the line with the values would exceed the max entry size '%d' bytes"
 )
 
 type ErrStreamRateLimit struct {